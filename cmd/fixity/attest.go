@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jeffanddom/fixity/internal/attest"
+	"github.com/jeffanddom/fixity/internal/coordinator"
+	"github.com/jeffanddom/fixity/internal/signing"
+)
+
+func attestCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "attest",
+		Short: "Issue and verify signed checksum attestations",
+	}
+
+	createCmd := &cobra.Command{
+		Use:   "create",
+		Short: "Issue a signed attestation of recorded checksums",
+		Long: `Issue a signed statement of the digests Fixity recorded for a file (--file),
+the files at or beneath a directory (--path, empty for the whole target) or the
+files a completed scan hashed (--scan), with when each was computed.
+
+The attestation is written as JSON to --output (default: a name derived from
+the target and attestation ID) and its detached signature alongside with .sig
+appended. It is also stored, so it can be downloaded again from the target's
+Attestations page.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			targetName, _ := cmd.Flags().GetString("target")
+			file, _ := cmd.Flags().GetString("file")
+			dir, _ := cmd.Flags().GetString("path")
+			scanID, _ := cmd.Flags().GetInt64("scan")
+			output, _ := cmd.Flags().GetString("output")
+
+			var req attest.Request
+			switch {
+			case file != "" && !cmd.Flags().Changed("path") && scanID == 0:
+				req = attest.Request{Scope: attest.ScopeFile, Path: file}
+			case file == "" && cmd.Flags().Changed("path") && scanID == 0:
+				req = attest.Request{Scope: attest.ScopeSubtree, Path: dir}
+			case file == "" && !cmd.Flags().Changed("path") && scanID != 0:
+				req = attest.Request{Scope: attest.ScopeScan, ScanID: scanID}
+			default:
+				return fmt.Errorf("give exactly one of --file, --path or --scan")
+			}
+
+			key, err := signing.LoadOrCreate(cfg.Signing.KeyFile)
+			if err != nil {
+				return err
+			}
+
+			db, err := openDatabase()
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			ctx := context.Background()
+			target, err := lookupTarget(ctx, db, targetName)
+			if err != nil {
+				return err
+			}
+
+			att, err := attest.Build(ctx, db, target, req)
+			if err != nil {
+				return err
+			}
+			stored, err := attest.Issue(ctx, db, key, att, "cli")
+			if err != nil {
+				return err
+			}
+
+			if output == "" {
+				output = attest.Filename(stored, target.Name)
+			}
+			if err := os.WriteFile(output, stored.Content, 0644); err != nil {
+				return fmt.Errorf("failed to write attestation: %w", err)
+			}
+			if err := os.WriteFile(output+".sig", []byte(stored.Signature), 0644); err != nil {
+				return fmt.Errorf("failed to write signature: %w", err)
+			}
+
+			fmt.Printf("✓ Attested %d files of %s\n", stored.FileCount, target.Name)
+			if att.Unhashed > 0 {
+				fmt.Printf("  %d files without a recorded checksum were left out\n", att.Unhashed)
+			}
+			fmt.Printf("  Written to %s and %s.sig\n", output, output)
+			fmt.Printf("  Signed with key %s\n", stored.KeyID)
+			return nil
+		},
+	}
+
+	createCmd.Flags().String("target", "", "Storage target name or ID (required)")
+	createCmd.Flags().String("file", "", "Attest one file")
+	createCmd.Flags().String("path", "", "Attest the files at or beneath a directory")
+	createCmd.Flags().Int64("scan", 0, "Attest the files a completed scan hashed")
+	createCmd.Flags().String("output", "", "File to write the attestation to, with its signature alongside")
+
+	verifyCmd := &cobra.Command{
+		Use:   "verify FILE",
+		Short: "Check an attestation against its signature",
+		Long: `Check that an attestation is unchanged since Fixity signed it, using the
+detached signature in FILE.sig (or --signature), and that the key that signed
+it is one this Fixity has signed with.
+
+With --rehash the attested files are also read from the target's storage and
+hashed again, and any whose digest no longer matches are reported.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			signaturePath, _ := cmd.Flags().GetString("signature")
+			rehash, _ := cmd.Flags().GetBool("rehash")
+			if signaturePath == "" {
+				signaturePath = args[0] + ".sig"
+			}
+
+			content, err := os.ReadFile(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to read attestation: %w", err)
+			}
+			signature, err := os.ReadFile(signaturePath)
+			if err != nil {
+				return fmt.Errorf("failed to read signature: %w", err)
+			}
+
+			db, err := openDatabase()
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			ctx := context.Background()
+			att, sig, err := attest.Verify(ctx, db, content, signature)
+			if err != nil {
+				return fmt.Errorf("%s: verification failed: %w", filepath.Base(args[0]), err)
+			}
+
+			fmt.Printf("✓ %s is signed by key %s (signed %s)\n", filepath.Base(args[0]), sig.KeyID, sig.SignedAt.Format(time.RFC3339))
+			fmt.Printf("  Attests %d files of %s, issued by %s\n", len(att.Files), att.Target.Name, att.IssuedBy)
+
+			if !rehash {
+				return nil
+			}
+
+			target, err := db.StorageTargets.GetByID(ctx, att.Target.ID)
+			if err != nil {
+				return fmt.Errorf("failed to get storage target: %w", err)
+			}
+			backend, err := coordinator.TargetBackend(target)
+			if err != nil {
+				return fmt.Errorf("failed to create storage backend: %w", err)
+			}
+			defer backend.Close()
+
+			mismatches, err := attest.Rehash(ctx, backend, att)
+			if err != nil {
+				return err
+			}
+			for _, m := range mismatches {
+				if m.Err != nil {
+					fmt.Printf("  ✗ %s: %v\n", m.Path, m.Err)
+				} else {
+					fmt.Printf("  ✗ %s: attested %s, now %s\n", m.Path, m.Expected, m.Actual)
+				}
+			}
+			if len(mismatches) > 0 {
+				return fmt.Errorf("%d of %d attested files no longer match", len(mismatches), len(att.Files))
+			}
+
+			fmt.Printf("✓ All %d files still match their attested digests\n", len(att.Files))
+			return nil
+		},
+	}
+
+	verifyCmd.Flags().String("signature", "", "Detached signature file (default: FILE.sig)")
+	verifyCmd.Flags().Bool("rehash", false, "Read and hash the attested files again")
+
+	cmd.AddCommand(createCmd, verifyCmd)
+	return cmd
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jeffanddom/fixity/internal/audit"
+	"github.com/jeffanddom/fixity/internal/database"
+	"github.com/jeffanddom/fixity/internal/signing"
+)
+
+func auditCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Check that Fixity's own records have not been tampered with",
+	}
+
+	verifyCmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Walk the change event chains and report any break",
+		Long: `Walk each target's hash chain of change events, recomputing every event's
+hash and its link to the event before it, and check the chain against the
+signed checkpoints written after each scan. Edited, deleted and inserted
+events are reported, as are recorded checksums that changed since the latest
+checkpoint without an event. Exits non-zero if any break is found.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			targetName, _ := cmd.Flags().GetString("target")
+
+			db, err := openDatabase()
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			ctx := context.Background()
+			targets, err := auditTargets(ctx, db, targetName)
+			if err != nil {
+				return err
+			}
+
+			broken := 0
+			for _, target := range targets {
+				result, err := audit.Verify(ctx, db, target.ID)
+				if err != nil {
+					return fmt.Errorf("%s: %w", target.Name, err)
+				}
+
+				if result.OK() {
+					fmt.Printf("✓ %s: %d events, %d checkpoints\n", target.Name, result.Events, result.Checkpoints)
+				} else {
+					broken++
+					fmt.Printf("✗ %s: %d events, %d checkpoints, %d breaks\n", target.Name, result.Events, result.Checkpoints, result.BreakCount)
+					for _, b := range result.Breaks {
+						fmt.Printf("  [%s] %s\n", b.Kind, b.Message)
+					}
+					if hidden := result.BreakCount - len(result.Breaks); hidden > 0 {
+						fmt.Printf("  ... and %d more\n", hidden)
+					}
+				}
+				if result.Legacy > 0 {
+					fmt.Printf("  %d events recorded before the chain began are not covered\n", result.Legacy)
+				}
+				if result.Checkpoints == 0 {
+					fmt.Println("  No checkpoints yet; edits rewriting the whole chain cannot be detected")
+				} else if !result.FilesChecked {
+					fmt.Println("  Recorded checksums not checked: events were recorded since the latest checkpoint")
+				}
+			}
+
+			if broken > 0 {
+				return fmt.Errorf("audit verification failed for %d of %d targets", broken, len(targets))
+			}
+			return nil
+		},
+	}
+
+	verifyCmd.Flags().String("target", "", "Storage target name or ID (default: all targets)")
+
+	checkpointCmd := &cobra.Command{
+		Use:   "checkpoint",
+		Short: "Sign a checkpoint of a target's records now",
+		Long: `Sign a checkpoint of a target's event chain and recorded checksums. The
+server writes one after every scan when it has a signing key; this writes one
+without waiting for the next scan, such as after upgrading.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			targetName, _ := cmd.Flags().GetString("target")
+
+			key, err := signing.LoadOrCreate(cfg.Signing.KeyFile)
+			if err != nil {
+				return err
+			}
+
+			db, err := openDatabase()
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			ctx := context.Background()
+			targets, err := auditTargets(ctx, db, targetName)
+			if err != nil {
+				return err
+			}
+
+			for _, target := range targets {
+				scan, err := db.Scans.GetLatest(ctx, target.ID)
+				if err != nil {
+					return err
+				}
+				if scan != nil && scan.Status == database.ScanStatusRunning {
+					fmt.Printf("- %s: skipped, a scan is running\n", target.Name)
+					continue
+				}
+
+				checkpoint, err := audit.WriteCheckpoint(ctx, db, key, target.ID)
+				if errors.Is(err, audit.ErrUnexplainedChange) {
+					fmt.Printf("✗ %s: checkpoint at event %d, %d files, signed with key %s; %v\n",
+						target.Name, checkpoint.ChainSeq, checkpoint.FileCount, checkpoint.KeyID, err)
+					continue
+				}
+				if err != nil {
+					return fmt.Errorf("%s: %w", target.Name, err)
+				}
+				fmt.Printf("✓ %s: checkpoint at event %d, %d files, signed with key %s\n",
+					target.Name, checkpoint.ChainSeq, checkpoint.FileCount, checkpoint.KeyID)
+			}
+			return nil
+		},
+	}
+
+	checkpointCmd.Flags().String("target", "", "Storage target name or ID (default: all targets)")
+
+	cmd.AddCommand(verifyCmd, checkpointCmd)
+	return cmd
+}
+
+// auditTargets returns the named target, or every target if none is named
+func auditTargets(ctx context.Context, db *database.Database, nameOrID string) ([]*database.StorageTarget, error) {
+	if nameOrID == "" {
+		return db.StorageTargets.ListAll(ctx)
+	}
+	target, err := lookupTarget(ctx, db, nameOrID)
+	if err != nil {
+		return nil, err
+	}
+	return []*database.StorageTarget{target}, nil
+}
@@ -15,6 +15,8 @@ import (
 	"github.com/jeffanddom/fixity/internal/database"
 	"github.com/jeffanddom/fixity/internal/migrate"
 	"github.com/jeffanddom/fixity/internal/server"
+	"github.com/jeffanddom/fixity/internal/signing"
+	"github.com/jeffanddom/fixity/internal/timestamp"
 )
 
 var (
@@ -40,6 +42,11 @@ func main() {
 	rootCmd.AddCommand(serveCmd())
 	rootCmd.AddCommand(userCmd())
 	rootCmd.AddCommand(migrateCmd())
+	rootCmd.AddCommand(manifestCmd())
+	rootCmd.AddCommand(reportCmd())
+	rootCmd.AddCommand(auditCmd())
+	rootCmd.AddCommand(attestCmd())
+	rootCmd.AddCommand(timestampCmd())
 	rootCmd.AddCommand(versionCmd())
 
 	if err := rootCmd.Execute(); err != nil {
@@ -92,14 +99,37 @@ The server will:
 
 			// Create services
 			authService := auth.NewService(db, auth.Config{})
+
+			// Audit reports and checkpoints are signed; without a key neither
+			// is written
+			signingKey, err := signing.LoadOrCreate(cfg.Signing.KeyFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to load signing key, audit reports and checkpoints are disabled: %v\n", err)
+			}
+
+			// Completed scans are time-stamped only if a TSA is configured
+			var tsa *timestamp.Client
+			if cfg.Timestamp.URL != "" {
+				tsa = timestamp.NewClient(cfg.Timestamp.URL)
+			}
+			tsaRoots, err := timestamp.LoadRoots(cfg.Timestamp.CAFile)
+			if err != nil {
+				return fmt.Errorf("invalid TSA_CA_FILE: %w", err)
+			}
+
 			coord := coordinator.NewCoordinator(db, coordinator.Config{
 				MaxConcurrentScans: cfg.Scanner.MaxConcurrentScans,
+				Throttle:           cfg.Scanner.Throttle,
+				SigningKey:         signingKey,
+				TSA:                tsa,
 			})
 
 			// Create server
 			srv, err := server.New(db, authService, coord, server.Config{
 				ListenAddr:        cfg.Server.ListenAddr,
 				SessionCookieName: cfg.Server.SessionCookieName,
+				SigningKey:        signingKey,
+				TSARoots:          tsaRoots,
 			})
 			if err != nil {
 				return fmt.Errorf("failed to create server: %w", err)
@@ -107,6 +137,13 @@ The server will:
 
 			fmt.Println("✓ Services initialized")
 
+			// Watch local targets that have change watching turned on
+			watchCtx, stopWatching := context.WithCancel(context.Background())
+			defer stopWatching()
+			if err := coord.StartWatching(watchCtx); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to start change watchers: %v\n", err)
+			}
+
 			// Setup graceful shutdown
 			shutdown := make(chan os.Signal, 1)
 			signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
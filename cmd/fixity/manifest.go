@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jeffanddom/fixity/internal/checksum"
+	"github.com/jeffanddom/fixity/internal/database"
+	"github.com/jeffanddom/fixity/internal/manifest"
+	"github.com/jeffanddom/fixity/internal/migrate"
+)
+
+// openDatabase connects to the configured database and runs any pending
+// migrations
+func openDatabase() (*database.Database, error) {
+	db, err := database.FromURL(cfg.Database.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	if err := migrate.AutoMigrate(db.DB(), "fixity"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	}
+
+	return db, nil
+}
+
+// lookupTarget finds a storage target by name, or by ID if none has the name
+func lookupTarget(ctx context.Context, db *database.Database, nameOrID string) (*database.StorageTarget, error) {
+	if nameOrID == "" {
+		return nil, fmt.Errorf("target is required (--target)")
+	}
+
+	target, err := db.StorageTargets.GetByName(ctx, nameOrID)
+	if err == nil && target != nil {
+		return target, nil
+	}
+
+	if id, parseErr := strconv.ParseInt(nameOrID, 10, 64); parseErr == nil {
+		if target, err := db.StorageTargets.GetByID(ctx, id); err == nil {
+			return target, nil
+		}
+	}
+
+	return nil, fmt.Errorf("storage target not found: %s", nameOrID)
+}
+
+func manifestCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "manifest",
+		Short: "Import and export checksum manifests",
+	}
+
+	importCmd := &cobra.Command{
+		Use:   "import FILE",
+		Short: "Seed a target's expected checksums from a manifest",
+		Long: `Seed a target's expected checksums from a BagIt manifest or md5sum-style file.
+
+Files not yet recorded are checked against the manifest when a scan first
+finds them, instead of being trusted as found. Files already recorded are
+compared with their recorded checksum now, and any conflict is reported.
+
+Manifest paths are taken as relative to --path within the target; for a bag
+stored on the target, give the bag's directory. The format and algorithm are
+guessed from the file name (manifest-sha256.txt, MD5SUMS, photos.sha256) unless
+given.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			targetName, _ := cmd.Flags().GetString("target")
+			dir, _ := cmd.Flags().GetString("path")
+			formatName, _ := cmd.Flags().GetString("format")
+			algorithmName, _ := cmd.Flags().GetString("algorithm")
+
+			filename := args[0]
+			format := manifest.FormatOf(filename)
+			if formatName != "" {
+				var err error
+				if format, err = manifest.ParseFormat(formatName); err != nil {
+					return err
+				}
+			}
+
+			algorithm, ok := manifest.AlgorithmOf(filename)
+			if algorithmName != "" {
+				algorithm, ok = checksum.Algorithm(algorithmName), true
+			}
+			if !ok {
+				return fmt.Errorf("cannot tell the manifest's algorithm from its name; give --algorithm")
+			}
+
+			f, err := os.Open(filename)
+			if err != nil {
+				return fmt.Errorf("failed to open manifest: %w", err)
+			}
+			defer f.Close()
+
+			entries, err := manifest.Parse(f, format, algorithm)
+			if err != nil {
+				return fmt.Errorf("failed to parse %s: %w", filename, err)
+			}
+
+			db, err := openDatabase()
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			ctx := context.Background()
+			target, err := lookupTarget(ctx, db, targetName)
+			if err != nil {
+				return err
+			}
+
+			result, err := manifest.Import(ctx, db, target.ID, dir, entries, algorithm, filepath.Base(filename))
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("✓ Imported %d entries from %s into %s\n", len(entries), filename, target.Name)
+			fmt.Printf("  Expected on first scan: %d\n", result.Expected)
+			fmt.Printf("  Already recorded, matching: %d\n", result.Matched)
+			fmt.Printf("  Already recorded with another algorithm: %d\n", result.Skipped)
+
+			if len(result.Conflicts) > 0 {
+				for _, conflict := range result.Conflicts {
+					fmt.Fprintf(os.Stderr, "conflict: %s: manifest has %s, recorded %s\n",
+						conflict.Path, conflict.Expected, conflict.Recorded)
+				}
+				return fmt.Errorf("%d files conflict with their recorded checksums", len(result.Conflicts))
+			}
+			return nil
+		},
+	}
+
+	importCmd.Flags().String("target", "", "Storage target name or ID (required)")
+	importCmd.Flags().String("path", "", "Directory within the target that manifest paths are relative to")
+	importCmd.Flags().String("format", "", "Manifest format: bagit or coreutils (default: from file name)")
+	importCmd.Flags().String("algorithm", "", "Checksum algorithm: md5, sha256 or blake3 (default: from file name)")
+
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Write a target's recorded checksums as a manifest",
+		Long: `Write the recorded checksums of a target, or of a directory within it, as a
+BagIt manifest or md5sum-style file.
+
+Paths are relative to --path. In a BagIt manifest they are placed under data/,
+so the manifest describes a bag whose payload is that directory. Only files
+hashed with the chosen algorithm are written; the rest are counted as
+skipped.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			targetName, _ := cmd.Flags().GetString("target")
+			dir, _ := cmd.Flags().GetString("path")
+			formatName, _ := cmd.Flags().GetString("format")
+			algorithmName, _ := cmd.Flags().GetString("algorithm")
+			output, _ := cmd.Flags().GetString("output")
+
+			format, err := manifest.ParseFormat(formatName)
+			if err != nil {
+				return err
+			}
+
+			db, err := openDatabase()
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			ctx := context.Background()
+			target, err := lookupTarget(ctx, db, targetName)
+			if err != nil {
+				return err
+			}
+
+			algorithm := checksum.Algorithm(target.ChecksumAlgorithm)
+			if algorithmName != "" {
+				algorithm = checksum.Algorithm(algorithmName)
+			}
+			if err := checksum.ValidateAlgorithm(algorithm); err != nil {
+				return err
+			}
+
+			w := os.Stdout
+			if output != "" {
+				f, err := os.Create(output)
+				if err != nil {
+					return fmt.Errorf("failed to create %s: %w", output, err)
+				}
+				defer f.Close()
+				w = f
+			}
+
+			result, err := manifest.Export(ctx, db, w, target.ID, dir, format, algorithm)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(os.Stderr, "✓ Wrote %d %s checksums (%d files skipped)\n", result.Written, algorithm, result.Skipped)
+			return nil
+		},
+	}
+
+	exportCmd.Flags().String("target", "", "Storage target name or ID (required)")
+	exportCmd.Flags().String("path", "", "Directory within the target to export (default: whole target)")
+	exportCmd.Flags().String("format", string(manifest.FormatCoreutils), "Manifest format: bagit or coreutils")
+	exportCmd.Flags().String("algorithm", "", "Checksum algorithm (default: the target's)")
+	exportCmd.Flags().String("output", "", "File to write (default: standard output)")
+
+	cmd.AddCommand(importCmd, exportCmd)
+	return cmd
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jeffanddom/fixity/internal/report"
+	"github.com/jeffanddom/fixity/internal/signing"
+)
+
+func reportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Generate and verify signed fixity audit reports",
+	}
+
+	generateCmd := &cobra.Command{
+		Use:   "generate",
+		Short: "Generate a signed audit report for a target",
+		Long: `Generate a signed audit report for a target: how much of it scans verified
+during the period, which files were never verified, the corruption found and
+a summary of changes.
+
+The period is the previous whole --period (day, week, month, quarter or year,
+in UTC), so the command can run from cron, or the dates --from through --to.
+The report is stored, so it can also be downloaded from the target's Reports
+page. With --output it is also written to that file, and its detached
+signature to the same name with .sig appended.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			targetName, _ := cmd.Flags().GetString("target")
+			periodName, _ := cmd.Flags().GetString("period")
+			from, _ := cmd.Flags().GetString("from")
+			to, _ := cmd.Flags().GetString("to")
+			formatName, _ := cmd.Flags().GetString("format")
+			output, _ := cmd.Flags().GetString("output")
+
+			format, err := report.ParseFormat(formatName)
+			if err != nil {
+				return err
+			}
+
+			start, end, err := report.PreviousPeriod(periodName, time.Now())
+			if err != nil {
+				return err
+			}
+			if from != "" || to != "" {
+				if start, err = report.ParseDate(from); err != nil {
+					return err
+				}
+				last, err := report.ParseDate(to)
+				if err != nil {
+					return err
+				}
+				end = last.AddDate(0, 0, 1)
+			}
+
+			key, err := signing.LoadOrCreate(cfg.Signing.KeyFile)
+			if err != nil {
+				return err
+			}
+
+			db, err := openDatabase()
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			ctx := context.Background()
+			target, err := lookupTarget(ctx, db, targetName)
+			if err != nil {
+				return err
+			}
+
+			stored, err := report.Generate(ctx, db, key, target, start, end, format, "cli")
+			if err != nil {
+				return err
+			}
+
+			if output != "" {
+				if err := os.WriteFile(output, stored.Content, 0644); err != nil {
+					return fmt.Errorf("failed to write report: %w", err)
+				}
+				if err := os.WriteFile(output+".sig", []byte(stored.Signature), 0644); err != nil {
+					return fmt.Errorf("failed to write signature: %w", err)
+				}
+			}
+
+			fmt.Printf("✓ Generated report %d for %s, %s to %s\n", stored.ID, target.Name,
+				start.Format("2006-01-02"), end.AddDate(0, 0, -1).Format("2006-01-02"))
+			if output != "" {
+				fmt.Printf("  Written to %s and %s.sig\n", output, output)
+			}
+			fmt.Printf("  Signed with key %s\n", stored.KeyID)
+			return nil
+		},
+	}
+
+	generateCmd.Flags().String("target", "", "Storage target name or ID (required)")
+	generateCmd.Flags().String("period", "month", "Previous whole period: day, week, month, quarter or year")
+	generateCmd.Flags().String("from", "", "First day of a custom period (YYYY-MM-DD)")
+	generateCmd.Flags().String("to", "", "Last day of a custom period (YYYY-MM-DD)")
+	generateCmd.Flags().String("format", string(report.FormatHTML), "Report format: html, csv or json")
+	generateCmd.Flags().String("output", "", "File to write the report to, with its signature alongside")
+
+	verifyCmd := &cobra.Command{
+		Use:   "verify FILE",
+		Short: "Check an audit report against its signature",
+		Long: `Check that an audit report is unchanged since Fixity signed it, using the
+detached signature in FILE.sig (or --signature), and that the key that signed
+it is one this Fixity has signed with.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			signaturePath, _ := cmd.Flags().GetString("signature")
+			if signaturePath == "" {
+				signaturePath = args[0] + ".sig"
+			}
+
+			content, err := os.ReadFile(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to read report: %w", err)
+			}
+			signature, err := os.ReadFile(signaturePath)
+			if err != nil {
+				return fmt.Errorf("failed to read signature: %w", err)
+			}
+
+			db, err := openDatabase()
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			sig, err := report.Verify(context.Background(), db, content, signature)
+			if err != nil {
+				return fmt.Errorf("%s: verification failed: %w", filepath.Base(args[0]), err)
+			}
+
+			fmt.Printf("✓ %s is signed by key %s (signed %s)\n", filepath.Base(args[0]), sig.KeyID, sig.SignedAt.Format(time.RFC3339))
+			return nil
+		},
+	}
+
+	verifyCmd.Flags().String("signature", "", "Detached signature file (default: FILE.sig)")
+
+	cmd.AddCommand(generateCmd, verifyCmd)
+	return cmd
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jeffanddom/fixity/internal/timestamp"
+)
+
+// pendingTimestampLimit caps how many pending time-stamps one stamp run retries
+const pendingTimestampLimit = 1000
+
+func timestampCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "timestamp",
+		Short: "Check and retry RFC 3161 trusted time-stamps of scans",
+	}
+
+	verifyCmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Check a scan's time-stamp token",
+		Long: `Check that a scan's time-stamp token is for the Merkle root of the checksums
+recorded when it completed, and that it is signed by a Time Stamping
+Authority whose certificate chains to TSA_CA_FILE (or the system's trusted
+roots) as of the time it gives.
+
+With --recompute the Merkle root is also computed again from the checksums
+recorded now, which only matches while the scan is the target's latest.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			scanID, _ := cmd.Flags().GetInt64("scan")
+			recompute, _ := cmd.Flags().GetBool("recompute")
+			if scanID == 0 {
+				return fmt.Errorf("scan ID is required (--scan)")
+			}
+
+			roots, err := timestamp.LoadRoots(cfg.Timestamp.CAFile)
+			if err != nil {
+				return err
+			}
+
+			db, err := openDatabase()
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			ctx := context.Background()
+			ts, token, err := timestamp.VerifyScan(ctx, db, scanID, roots)
+			if err != nil {
+				return fmt.Errorf("scan %d: verification failed: %w", scanID, err)
+			}
+
+			fmt.Printf("✓ Scan %d was time-stamped %s\n", scanID, token.GenTime.Format(time.RFC3339Nano))
+			fmt.Printf("  Merkle root %s of %d checksums\n", ts.MerkleRoot, ts.LeafCount)
+			if signer, err := token.Signer(); err == nil {
+				fmt.Printf("  Signed by %s (serial %s)\n", signer.Subject, token.SerialNumber)
+			}
+
+			if !recompute {
+				return nil
+			}
+
+			scan, err := db.Scans.GetByID(ctx, scanID)
+			if err != nil {
+				return err
+			}
+			latest, err := db.Scans.GetLatest(ctx, scan.StorageTargetID)
+			if err != nil {
+				return err
+			}
+			if latest == nil || latest.ID != scan.ID {
+				fmt.Println("  Not recomputed: the target has been scanned since")
+				return nil
+			}
+
+			root, count, err := timestamp.MerkleRoot(ctx, db, scan.StorageTargetID)
+			if err != nil {
+				return err
+			}
+			if hex.EncodeToString(root) != ts.MerkleRoot || count != ts.LeafCount {
+				return fmt.Errorf("recorded checksums no longer match the time-stamped root: %d checksums now give %x", count, root)
+			}
+			fmt.Println("✓ Recorded checksums still match the time-stamped root")
+			return nil
+		},
+	}
+
+	verifyCmd.Flags().Int64("scan", 0, "Scan ID (required)")
+	verifyCmd.Flags().Bool("recompute", false, "Also recompute the Merkle root from the recorded checksums")
+
+	stampCmd := &cobra.Command{
+		Use:   "stamp",
+		Short: "Retry time-stamps the TSA could not issue",
+		Long: `Send the Merkle roots of completed scans that could not be time-stamped when
+they completed, such as while the TSA was unreachable, to the TSA at TSA_URL
+again. The roots were recorded when each scan completed, so later scans do
+not change what is time-stamped; the time given is that of the retry.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			scanID, _ := cmd.Flags().GetInt64("scan")
+			if cfg.Timestamp.URL == "" {
+				return fmt.Errorf("TSA_URL is not set")
+			}
+
+			db, err := openDatabase()
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			ctx := context.Background()
+			pending, err := db.ScanTimestamps.ListPending(ctx, pendingTimestampLimit)
+			if err != nil {
+				return err
+			}
+
+			client := timestamp.NewClient(cfg.Timestamp.URL)
+			stamped, failed := 0, 0
+			for _, ts := range pending {
+				if scanID != 0 && ts.ScanID != scanID {
+					continue
+				}
+				if err := timestamp.Stamp(ctx, db, client, ts); err != nil {
+					failed++
+					fmt.Printf("✗ Scan %d: %v\n", ts.ScanID, err)
+					continue
+				}
+				stamped++
+				fmt.Printf("✓ Scan %d time-stamped %s\n", ts.ScanID, ts.GenTime.Format(time.RFC3339))
+			}
+
+			if stamped == 0 && failed == 0 {
+				fmt.Println("No scans are waiting to be time-stamped")
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d scans could not be time-stamped", failed)
+			}
+			return nil
+		},
+	}
+
+	stampCmd.Flags().Int64("scan", 0, "Only retry this scan")
+
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Write a scan's time-stamp token to a file",
+		Long: `Write a scan's DER time-stamp token, so it can be checked without Fixity:
+
+  openssl ts -verify -token_in -in TOKEN -digest ROOT -CAfile TSA_CA.pem
+
+where ROOT is the Merkle root fixity timestamp verify prints.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			scanID, _ := cmd.Flags().GetInt64("scan")
+			output, _ := cmd.Flags().GetString("output")
+			if scanID == 0 {
+				return fmt.Errorf("scan ID is required (--scan)")
+			}
+			if output == "" {
+				output = fmt.Sprintf("fixity-scan-%d.tst", scanID)
+			}
+
+			db, err := openDatabase()
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			ts, err := db.ScanTimestamps.GetByScanID(context.Background(), scanID)
+			if err != nil {
+				return err
+			}
+			if ts == nil || ts.Token == nil {
+				return fmt.Errorf("scan %d has no time-stamp token", scanID)
+			}
+
+			if err := os.WriteFile(output, ts.Token, 0644); err != nil {
+				return fmt.Errorf("failed to write token: %w", err)
+			}
+			fmt.Printf("✓ Written to %s\n", output)
+			fmt.Printf("  Merkle root %s\n", ts.MerkleRoot)
+			return nil
+		},
+	}
+
+	exportCmd.Flags().Int64("scan", 0, "Scan ID (required)")
+	exportCmd.Flags().String("output", "", "File to write the token to (default: fixity-scan-ID.tst)")
+
+	cmd.AddCommand(verifyCmd, stampCmd, exportCmd)
+	return cmd
+}
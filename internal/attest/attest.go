@@ -0,0 +1,269 @@
+// Package attest issues signed checksum attestations: statements, signed
+// with Fixity's key, that a target's files had given digests at given times,
+// for a single file, a subtree or the files a scan hashed. Attestations are
+// JSON with a detached signature, so they can be handed to third parties and
+// checked later, optionally by hashing the files again.
+package attest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/jeffanddom/fixity/internal/database"
+	"github.com/jeffanddom/fixity/internal/manifest"
+	"github.com/jeffanddom/fixity/internal/signing"
+)
+
+// Type identifies attestation documents
+const Type = "fixity.attestation/v1"
+
+// listBatchSize is how many files of a subtree are read at a time
+const listBatchSize = 10000
+
+// Scope is what an attestation covers
+type Scope string
+
+const (
+	ScopeFile    Scope = "file"    // One file
+	ScopeSubtree Scope = "subtree" // The files at or beneath a directory
+	ScopeScan    Scope = "scan"    // The files a scan hashed
+)
+
+// ParseScope parses an attestation scope name
+func ParseScope(name string) (Scope, error) {
+	switch scope := Scope(name); scope {
+	case ScopeFile, ScopeSubtree, ScopeScan:
+		return scope, nil
+	}
+	return "", fmt.Errorf("unknown attestation scope %q: must be file, subtree or scan", name)
+}
+
+// Request names what to attest: a file or directory path of the target, or
+// a scan of it
+type Request struct {
+	Scope  Scope
+	Path   string
+	ScanID int64
+}
+
+// Attestation is the signed document
+type Attestation struct {
+	Type     string    `json:"type"`
+	Target   Target    `json:"target"`
+	Scope    Scope     `json:"scope"`
+	Path     string    `json:"path,omitempty"`
+	Scan     *Scan     `json:"scan,omitempty"`
+	IssuedAt time.Time `json:"issued_at"`
+	IssuedBy string    `json:"issued_by"`
+	KeyID    string    `json:"key_id"`
+	Files    []File    `json:"files"`
+	Unhashed int64     `json:"unhashed,omitempty"` // Files in scope with no recorded digest, not attested
+}
+
+// Target identifies the storage target the files are on
+type Target struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// Scan identifies the scan a scan attestation covers
+type Scan struct {
+	ID          int64     `json:"id"`
+	StartedAt   time.Time `json:"started_at"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// File is one attested digest. HashedAt is when it was computed, if known;
+// otherwise it was computed during the attested scan.
+type File struct {
+	Path      string     `json:"path"`
+	Size      int64      `json:"size"`
+	Algorithm string     `json:"algorithm"`
+	Digest    string     `json:"digest"`
+	HashedAt  *time.Time `json:"hashed_at,omitempty"`
+}
+
+// Build collects the recorded digests a request covers
+func Build(ctx context.Context, db *database.Database, target *database.StorageTarget, req Request) (*Attestation, error) {
+	att := &Attestation{
+		Type:   Type,
+		Target: Target{ID: target.ID, Name: target.Name},
+		Scope:  req.Scope,
+		Files:  []File{},
+	}
+
+	switch req.Scope {
+	case ScopeFile:
+		att.Path = manifest.CleanDir(req.Path)
+		file, err := db.Files.GetByPath(ctx, target.ID, att.Path)
+		if err != nil {
+			return nil, err
+		}
+		if file == nil || file.DeletedAt != nil {
+			return nil, fmt.Errorf("file not found: %s", att.Path)
+		}
+		if file.CurrentChecksum == nil {
+			return nil, fmt.Errorf("%s has no recorded checksum", att.Path)
+		}
+		att.Files = append(att.Files, fileOf(file))
+
+	case ScopeSubtree:
+		att.Path = manifest.CleanDir(req.Path)
+		afterPath := ""
+		for {
+			files, err := db.Files.ListUnderPathBatch(ctx, target.ID, att.Path, afterPath, listBatchSize)
+			if err != nil {
+				return nil, err
+			}
+			for _, file := range files {
+				if file.FileType != database.FileTypeRegular || file.CurrentChecksum == nil {
+					att.Unhashed++
+					continue
+				}
+				att.Files = append(att.Files, fileOf(file))
+			}
+			if len(files) < listBatchSize {
+				break
+			}
+			afterPath = files[len(files)-1].Path
+		}
+		if len(att.Files) == 0 {
+			return nil, fmt.Errorf("no hashed files under %q", att.Path)
+		}
+
+	case ScopeScan:
+		scan, err := db.Scans.GetByID(ctx, req.ScanID)
+		if err != nil || scan.StorageTargetID != target.ID {
+			return nil, fmt.Errorf("scan not found: %d", req.ScanID)
+		}
+		if scan.CompletedAt == nil {
+			return nil, fmt.Errorf("scan %d has not completed", scan.ID)
+		}
+		att.Scan = &Scan{ID: scan.ID, StartedAt: scan.StartedAt.UTC(), CompletedAt: scan.CompletedAt.UTC()}
+
+		digests, err := db.ChangeEvents.ListScanDigests(ctx, scan)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range digests {
+			file := File{Path: d.Path, Size: d.Size, Algorithm: d.Algorithm, Digest: d.Digest}
+			if d.HashedAt != nil {
+				hashedAt := d.HashedAt.UTC()
+				file.HashedAt = &hashedAt
+			}
+			att.Files = append(att.Files, file)
+		}
+		if len(att.Files) == 0 {
+			return nil, fmt.Errorf("scan %d hashed no files", scan.ID)
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown attestation scope %q", req.Scope)
+	}
+
+	return att, nil
+}
+
+func fileOf(file *database.File) File {
+	f := File{
+		Path:      file.Path,
+		Size:      file.Size,
+		Algorithm: *file.ChecksumType,
+		Digest:    *file.CurrentChecksum,
+	}
+	if file.LastChecksummedAt != nil {
+		hashedAt := file.LastChecksummedAt.UTC()
+		f.HashedAt = &hashedAt
+	}
+	return f
+}
+
+// Issue signs and stores an attestation made by Build
+func Issue(ctx context.Context, db *database.Database, key *signing.Key, att *Attestation, issuedBy string) (*database.Attestation, error) {
+	att.IssuedAt = time.Now().UTC()
+	att.IssuedBy = issuedBy
+	att.KeyID = key.ID()
+
+	content, err := json.MarshalIndent(att, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode attestation: %w", err)
+	}
+	content = append(content, '\n')
+
+	signature, err := key.Sign(content).Marshal()
+	if err != nil {
+		return nil, err
+	}
+	if err := db.SigningKeys.Register(ctx, key.ID(), signing.EncodePublicKey(key.PublicKey())); err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(content)
+	stored := &database.Attestation{
+		StorageTargetID: att.Target.ID,
+		Scope:           string(att.Scope),
+		FileCount:       int64(len(att.Files)),
+		Content:         content,
+		SHA256:          hex.EncodeToString(sum[:]),
+		Signature:       string(signature),
+		KeyID:           key.ID(),
+		IssuedBy:        issuedBy,
+		IssuedAt:        att.IssuedAt,
+	}
+	if att.Scope == ScopeScan {
+		stored.ScanID = &att.Scan.ID
+	} else {
+		stored.Path = &att.Path
+	}
+	if err := db.Attestations.Create(ctx, stored); err != nil {
+		return nil, err
+	}
+
+	return stored, nil
+}
+
+// Verify checks an attestation against its detached signature and that the
+// key that signed it is one Fixity has signed with, returning the attestation
+func Verify(ctx context.Context, db *database.Database, content, signature []byte) (*Attestation, *signing.Signature, error) {
+	sig, err := signing.ParseSignature(signature)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := sig.Verify(content); err != nil {
+		return nil, nil, err
+	}
+	if err := db.SigningKeys.Check(ctx, sig.KeyID, sig.PublicKey); err != nil {
+		return nil, nil, err
+	}
+
+	att, err := Parse(content)
+	if err != nil {
+		return nil, nil, err
+	}
+	return att, sig, nil
+}
+
+// Parse decodes an attestation document
+func Parse(content []byte) (*Attestation, error) {
+	var att Attestation
+	if err := json.Unmarshal(content, &att); err != nil {
+		return nil, fmt.Errorf("invalid attestation: %w", err)
+	}
+	if att.Type != Type {
+		return nil, fmt.Errorf("not a Fixity attestation: type %q", att.Type)
+	}
+	return &att, nil
+}
+
+var unsafeFilename = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Filename names a stored attestation for download, such as
+// fixity-attestation-photos-42.json
+func Filename(stored *database.Attestation, targetName string) string {
+	return fmt.Sprintf("fixity-attestation-%s-%d.json", unsafeFilename.ReplaceAllString(targetName, "_"), stored.ID)
+}
@@ -0,0 +1,88 @@
+package attest_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jeffanddom/fixity/internal/attest"
+	"github.com/jeffanddom/fixity/internal/checksum"
+	"github.com/jeffanddom/fixity/internal/database"
+	"github.com/jeffanddom/fixity/internal/storage"
+)
+
+func TestParseScope(t *testing.T) {
+	for _, name := range []string{"file", "subtree", "scan"} {
+		if scope, err := attest.ParseScope(name); err != nil || string(scope) != name {
+			t.Errorf("ParseScope(%q) = %q, %v", name, scope, err)
+		}
+	}
+	if _, err := attest.ParseScope("target"); err == nil {
+		t.Error("expected an unknown scope to be rejected")
+	}
+}
+
+func TestParse(t *testing.T) {
+	att, err := attest.Parse([]byte(`{"type": "fixity.attestation/v1", "scope": "file", "files": [{"path": "a.txt", "digest": "abc"}]}`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if att.Scope != attest.ScopeFile || len(att.Files) != 1 || att.Files[0].Path != "a.txt" {
+		t.Errorf("unexpected attestation: %+v", att)
+	}
+
+	if _, err := attest.Parse([]byte(`{"type": "fixity.report"}`)); err == nil {
+		t.Error("expected another document type to be rejected")
+	}
+	if _, err := attest.Parse([]byte(`not json`)); err == nil {
+		t.Error("expected invalid JSON to be rejected")
+	}
+}
+
+func TestRehash(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "docs"), 0755)
+	os.WriteFile(filepath.Join(dir, "docs", "intact.txt"), []byte("intact"), 0644)
+	os.WriteFile(filepath.Join(dir, "docs", "changed.txt"), []byte("changed"), 0644)
+
+	backend, err := storage.NewLocalFSBackend(dir)
+	if err != nil {
+		t.Fatalf("failed to create backend: %v", err)
+	}
+	defer backend.Close()
+
+	digest := func(content string) string {
+		sum, _ := checksum.ComputeSHA256(strings.NewReader(content))
+		return sum
+	}
+	att := &attest.Attestation{
+		Files: []attest.File{
+			{Path: "docs/intact.txt", Size: 6, Algorithm: "sha256", Digest: digest("intact")},
+			{Path: "docs/changed.txt", Size: 8, Algorithm: "sha256", Digest: digest("original")},
+			{Path: "docs/missing.txt", Size: 7, Algorithm: "sha256", Digest: digest("missing")},
+		},
+	}
+
+	mismatches, err := attest.Rehash(context.Background(), backend, att)
+	if err != nil {
+		t.Fatalf("Rehash failed: %v", err)
+	}
+	if len(mismatches) != 2 {
+		t.Fatalf("expected 2 mismatches, got %+v", mismatches)
+	}
+	if m := mismatches[0]; m.Path != "docs/changed.txt" || m.Actual != digest("changed") || m.Err != nil {
+		t.Errorf("unexpected mismatch: %+v", m)
+	}
+	if m := mismatches[1]; m.Path != "docs/missing.txt" || m.Err == nil {
+		t.Errorf("expected the missing file to be reported unreadable: %+v", m)
+	}
+}
+
+func TestFilename(t *testing.T) {
+	stored := &database.Attestation{ID: 42}
+	if got := attest.Filename(stored, "Team photos"); got != "fixity-attestation-Team_photos-42.json" {
+		t.Errorf("unexpected filename: %s", got)
+	}
+}
@@ -0,0 +1,136 @@
+package attest_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jeffanddom/fixity/internal/attest"
+	"github.com/jeffanddom/fixity/internal/database"
+	"github.com/jeffanddom/fixity/internal/signing"
+	"github.com/jeffanddom/fixity/tests/testutil"
+)
+
+func TestIssueAndVerify(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupDB(t, db)
+
+	ctx := context.Background()
+	target := testutil.MustCreateStorageTarget(t, db, "archive")
+	testutil.MustCreateFile(t, db, target.ID, "docs/a.txt")
+	testutil.MustCreateFile(t, db, target.ID, "docs/b.txt")
+	unhashed := testutil.MustCreateFile(t, db, target.ID, "docs/unhashed.txt")
+	unhashed.CurrentChecksum, unhashed.ChecksumType, unhashed.LastChecksummedAt = nil, nil, nil
+	if err := db.Files.Update(ctx, unhashed); err != nil {
+		t.Fatalf("failed to update file: %v", err)
+	}
+	other := testutil.MustCreateFile(t, db, target.ID, "other/x.txt")
+
+	key, err := signing.Generate()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	issue := func(t *testing.T, req attest.Request) (*database.Attestation, *attest.Attestation) {
+		t.Helper()
+		att, err := attest.Build(ctx, db, target, req)
+		if err != nil {
+			t.Fatalf("Build failed: %v", err)
+		}
+		stored, err := attest.Issue(ctx, db, key, att, "tester")
+		if err != nil {
+			t.Fatalf("Issue failed: %v", err)
+		}
+		verified, _, err := attest.Verify(ctx, db, stored.Content, []byte(stored.Signature))
+		if err != nil {
+			t.Fatalf("expected the attestation to verify: %v", err)
+		}
+		return stored, verified
+	}
+
+	t.Run("attests a file", func(t *testing.T) {
+		stored, att := issue(t, attest.Request{Scope: attest.ScopeFile, Path: "/docs/a.txt"})
+
+		if stored.FileCount != 1 || *stored.Path != "docs/a.txt" || stored.KeyID != key.ID() {
+			t.Errorf("unexpected stored attestation: %+v", stored)
+		}
+		if len(att.Files) != 1 || att.Files[0].Digest != "abc123" || att.Files[0].Algorithm != "md5" || att.Files[0].HashedAt == nil {
+			t.Errorf("unexpected files: %+v", att.Files)
+		}
+		if att.Target.Name != "archive" || att.IssuedBy != "tester" {
+			t.Errorf("unexpected attestation: %+v", att)
+		}
+	})
+
+	t.Run("attests a subtree", func(t *testing.T) {
+		_, att := issue(t, attest.Request{Scope: attest.ScopeSubtree, Path: "docs"})
+
+		if len(att.Files) != 2 || att.Files[0].Path != "docs/a.txt" || att.Files[1].Path != "docs/b.txt" {
+			t.Errorf("unexpected files: %+v", att.Files)
+		}
+		if att.Unhashed != 1 {
+			t.Errorf("expected 1 unhashed file, got %d", att.Unhashed)
+		}
+	})
+
+	t.Run("attests the files a scan hashed", func(t *testing.T) {
+		scan := testutil.MustCreateScan(t, db, target.ID)
+		if _, err := attest.Build(ctx, db, target, attest.Request{Scope: attest.ScopeScan, ScanID: scan.ID}); err == nil {
+			t.Error("expected a running scan to be rejected")
+		}
+
+		digest, size := "def456", int64(2048)
+		testutil.MustCreateChangeEvent(t, db, scan.ID, other.ID, database.ChangeEventAdded)
+		db.ChangeEvents.Create(ctx, &database.ChangeEvent{
+			ScanID: scan.ID, FileID: other.ID, EventType: database.ChangeEventVerified,
+			DetectedAt: time.Now(), NewChecksum: &digest, NewSize: &size,
+		})
+		completed := time.Now()
+		scan.Status, scan.CompletedAt = database.ScanStatusCompleted, &completed
+		if err := db.Scans.Update(ctx, scan); err != nil {
+			t.Fatalf("failed to complete scan: %v", err)
+		}
+
+		stored, att := issue(t, attest.Request{Scope: attest.ScopeScan, ScanID: scan.ID})
+		if stored.ScanID == nil || *stored.ScanID != scan.ID || att.Scan == nil {
+			t.Errorf("unexpected stored attestation: %+v", stored)
+		}
+		if len(att.Files) != 1 || att.Files[0].Path != "other/x.txt" || att.Files[0].Digest != digest || att.Files[0].Size != size {
+			t.Errorf("unexpected files: %+v", att.Files)
+		}
+	})
+
+	t.Run("rejects what cannot be attested", func(t *testing.T) {
+		for _, req := range []attest.Request{
+			{Scope: attest.ScopeFile, Path: "docs/none.txt"},
+			{Scope: attest.ScopeFile, Path: "docs/unhashed.txt"},
+			{Scope: attest.ScopeSubtree, Path: "empty"},
+			{Scope: attest.ScopeScan, ScanID: 999999},
+		} {
+			if _, err := attest.Build(ctx, db, target, req); err == nil {
+				t.Errorf("expected %+v to be rejected", req)
+			}
+		}
+	})
+
+	t.Run("rejects an edited attestation", func(t *testing.T) {
+		stored, _ := issue(t, attest.Request{Scope: attest.ScopeFile, Path: "docs/a.txt"})
+		edited := bytes.Replace(stored.Content, []byte("abc123"), []byte("abc124"), 1)
+
+		if _, _, err := attest.Verify(ctx, db, edited, []byte(stored.Signature)); err == nil {
+			t.Error("expected an edited attestation to fail verification")
+		}
+	})
+
+	t.Run("rejects an unknown key", func(t *testing.T) {
+		stored, _ := issue(t, attest.Request{Scope: attest.ScopeFile, Path: "docs/a.txt"})
+		stranger, _ := signing.Generate()
+		signature, _ := stranger.Sign(stored.Content).Marshal()
+
+		if _, _, err := attest.Verify(ctx, db, stored.Content, signature); err == nil {
+			t.Error("expected an attestation signed by an unknown key to fail verification")
+		}
+	})
+}
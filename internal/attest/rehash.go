@@ -0,0 +1,48 @@
+package attest
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jeffanddom/fixity/internal/checksum"
+	"github.com/jeffanddom/fixity/internal/storage"
+)
+
+// Mismatch is an attested file that no longer has its attested digest
+type Mismatch struct {
+	Path     string
+	Expected string
+	Actual   string // Empty if the file could not be read
+	Err      error  // Why the file could not be read
+}
+
+// Rehash reads each attested file from the target's storage and compares
+// its digest with the attested one, returning the files that differ
+func Rehash(ctx context.Context, backend storage.StorageBackend, att *Attestation) ([]Mismatch, error) {
+	var mismatches []Mismatch
+	for _, file := range att.Files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		actual, err := hashFile(ctx, backend, file)
+		if err != nil {
+			mismatches = append(mismatches, Mismatch{Path: file.Path, Expected: file.Digest, Err: err})
+			continue
+		}
+		if actual != file.Digest {
+			mismatches = append(mismatches, Mismatch{Path: file.Path, Expected: file.Digest, Actual: actual})
+		}
+	}
+	return mismatches, nil
+}
+
+func hashFile(ctx context.Context, backend storage.StorageBackend, file File) (string, error) {
+	reader, err := backend.Open(ctx, file.Path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open: %w", err)
+	}
+	defer reader.Close()
+
+	return checksum.Compute(checksum.Algorithm(file.Algorithm), reader)
+}
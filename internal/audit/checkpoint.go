@@ -0,0 +1,153 @@
+// Package audit keeps Fixity's own records tamper-evident. Change events are
+// hash-chained per target as they are recorded (see database.ChainHash), and
+// signed checkpoints of each chain's head and of the target's recorded
+// checksums are written after every scan. Verify walks a chain and reports
+// any event that was edited, removed or added outside it, and any checksum
+// changed without an event.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jeffanddom/fixity/internal/database"
+	"github.com/jeffanddom/fixity/internal/signing"
+)
+
+// Checkpoint is the signed content of an audit checkpoint
+type Checkpoint struct {
+	TargetID    int64     `json:"target_id"`
+	ChainSeq    int64     `json:"chain_seq"`
+	ChainHash   string    `json:"chain_hash"`
+	FilesDigest string    `json:"files_digest"`
+	FileCount   int64     `json:"file_count"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	// Break describes how the recorded checksums changed in ways the events
+	// since the previous checkpoint do not explain; empty if they did not
+	Break string `json:"break,omitempty"`
+}
+
+// ErrUnexplainedChange is returned with a checkpoint that recorded checksums
+// changed without an event
+var ErrUnexplainedChange = errors.New("recorded checksums changed without an event")
+
+// unexplainedPaths is how many paths of files changed without an event a
+// checkpoint names
+const unexplainedPaths = 10
+
+// WriteCheckpoint signs and stores a checkpoint of a target's event chain
+// head and recorded checksums. It should not run while the target is being
+// scanned, or the two may not agree.
+//
+// The recorded checksums must be explained by the previous checkpoint and
+// the events since: unchanged if there are none, and each file as its latest
+// event left it. If they are not, the checkpoint still moves on to the
+// current state but records the break, so it is reported by every later
+// Verify, and it is returned with an error wrapping ErrUnexplainedChange.
+func WriteCheckpoint(ctx context.Context, db *database.Database, key *signing.Key, targetID int64) (*database.AuditCheckpoint, error) {
+	head, err := db.ChangeEvents.GetChainHead(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+	digest, count, err := db.Files.StateDigest(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := db.AuditCheckpoints.GetLatest(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+	unexplained, err := db.Files.ListUnexplained(ctx, targetID, unexplainedPaths+1)
+	if err != nil {
+		return nil, err
+	}
+
+	var reason string
+	switch {
+	case len(unexplained) > 0:
+		paths := make([]string, 0, len(unexplained))
+		for _, file := range unexplained {
+			paths = append(paths, file.Path)
+		}
+		if len(paths) > unexplainedPaths {
+			paths = append(paths[:unexplainedPaths], "...")
+		}
+		reason = fmt.Sprintf("files recorded differently from their latest event: %s", strings.Join(paths, ", "))
+	case previous != nil && previous.ChainSeq == head.Seq && previous.FilesDigest != digest:
+		reason = fmt.Sprintf("recorded files and checksums (%d files) differ from the previous checkpoint (%d files) with no event recorded since",
+			count, previous.FileCount)
+	}
+
+	payload, err := json.Marshal(Checkpoint{
+		TargetID:    targetID,
+		ChainSeq:    head.Seq,
+		ChainHash:   head.Hash,
+		FilesDigest: digest,
+		FileCount:   count,
+		CreatedAt:   time.Now().UTC(),
+		Break:       reason,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+
+	signature, err := key.Sign(payload).Marshal()
+	if err != nil {
+		return nil, err
+	}
+	if err := db.SigningKeys.Register(ctx, key.ID(), signing.EncodePublicKey(key.PublicKey())); err != nil {
+		return nil, err
+	}
+
+	checkpoint := &database.AuditCheckpoint{
+		StorageTargetID: targetID,
+		ChainSeq:        head.Seq,
+		ChainHash:       head.Hash,
+		FilesDigest:     digest,
+		FileCount:       count,
+		Payload:         string(payload),
+		Signature:       string(signature),
+		KeyID:           key.ID(),
+	}
+	if err := db.AuditCheckpoints.Create(ctx, checkpoint); err != nil {
+		return nil, err
+	}
+
+	if reason != "" {
+		return checkpoint, fmt.Errorf("%w: %s", ErrUnexplainedChange, reason)
+	}
+	return checkpoint, nil
+}
+
+// verifyCheckpoint checks a stored checkpoint's signature and that its
+// columns match what was signed, returning the signed content
+func verifyCheckpoint(ctx context.Context, db *database.Database, stored *database.AuditCheckpoint) (*Checkpoint, error) {
+	sig, err := signing.ParseSignature([]byte(stored.Signature))
+	if err != nil {
+		return nil, err
+	}
+	if err := sig.Verify([]byte(stored.Payload)); err != nil {
+		return nil, err
+	}
+
+	if err := db.SigningKeys.Check(ctx, sig.KeyID, sig.PublicKey); err != nil {
+		return nil, err
+	}
+
+	var checkpoint Checkpoint
+	if err := json.Unmarshal([]byte(stored.Payload), &checkpoint); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint: %w", err)
+	}
+	if checkpoint.TargetID != stored.StorageTargetID || checkpoint.ChainSeq != stored.ChainSeq ||
+		checkpoint.ChainHash != stored.ChainHash || checkpoint.FilesDigest != stored.FilesDigest ||
+		checkpoint.FileCount != stored.FileCount {
+		return nil, fmt.Errorf("stored fields differ from the signed checkpoint")
+	}
+
+	return &checkpoint, nil
+}
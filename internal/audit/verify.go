@@ -0,0 +1,235 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/jeffanddom/fixity/internal/database"
+)
+
+const (
+	// chainPage is how many events are read from the database at a time
+	chainPage = 10000
+
+	// maxBreaks is how many breaks a result lists; the rest are only counted
+	maxBreaks = 1000
+)
+
+// BreakKind identifies how a chain was found broken
+type BreakKind string
+
+const (
+	BreakEdited     BreakKind = "edited"     // An event no longer matches its hash
+	BreakRelinked   BreakKind = "relinked"   // An event does not follow the one before it
+	BreakMissing    BreakKind = "missing"    // Positions in the chain have no event
+	BreakInserted   BreakKind = "inserted"   // Events were added outside the chain
+	BreakTruncated  BreakKind = "truncated"  // The chain ends before its recorded head
+	BreakCheckpoint BreakKind = "checkpoint" // A checkpoint is invalid or disagrees with the chain
+	BreakFiles      BreakKind = "files"      // Recorded checksums changed without an event
+)
+
+// Break is one place a target's records fail verification
+type Break struct {
+	Kind    BreakKind
+	Seq     int64 // Chain position, if the break has one
+	EventID int64 // Change event, if the break has one
+	Message string
+}
+
+// Result is the outcome of verifying a target's records
+type Result struct {
+	TargetID     int64
+	Events       int64 // Chained events checked
+	Legacy       int64 // Events recorded before the chain began, which it cannot cover
+	Checkpoints  int   // Checkpoints checked
+	FilesChecked bool  // Recorded checksums were compared with the latest checkpoint
+	Breaks       []Break
+	BreakCount   int // All breaks found; Breaks lists at most maxBreaks
+}
+
+// OK reports whether no breaks were found
+func (r *Result) OK() bool {
+	return r.BreakCount == 0
+}
+
+func (r *Result) addBreak(b Break) {
+	r.BreakCount++
+	if len(r.Breaks) < maxBreaks {
+		r.Breaks = append(r.Breaks, b)
+	}
+}
+
+// Verify walks a target's event chain, recomputing each event's hash and its
+// link to the one before, and checks it against the recorded chain head and
+// the signed checkpoints. Rewriting the chain from an edited event onwards
+// leaves it self-consistent, but not with the checkpoints signed before the
+// edit. Checksums that a checkpoint found changed without an event are
+// reported, and if no events have been recorded since the latest checkpoint,
+// the target's recorded checksums are also compared with it.
+func Verify(ctx context.Context, db *database.Database, targetID int64) (*Result, error) {
+	result := &Result{TargetID: targetID}
+
+	// Checkpoints are matched to the chain by position as it is walked
+	stored, err := db.AuditCheckpoints.ListByTarget(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+	checkpoints := make(map[int64][]*Checkpoint)
+	var latest *Checkpoint
+	for _, s := range stored {
+		checkpoint, err := verifyCheckpoint(ctx, db, s)
+		if err != nil {
+			result.addBreak(Break{
+				Kind:    BreakCheckpoint,
+				Seq:     s.ChainSeq,
+				Message: fmt.Sprintf("checkpoint %d does not verify: %v", s.ID, err),
+			})
+			latest = nil
+			continue
+		}
+		if checkpoint.ChainSeq == 0 && checkpoint.ChainHash != "" {
+			result.addBreak(Break{
+				Kind:    BreakCheckpoint,
+				Message: fmt.Sprintf("checkpoint %d records an empty chain with hash %s", s.ID, checkpoint.ChainHash),
+			})
+		}
+		if checkpoint.Break != "" {
+			result.addBreak(Break{
+				Kind:    BreakFiles,
+				Seq:     checkpoint.ChainSeq,
+				Message: fmt.Sprintf("the checkpoint of %s found %s", checkpoint.CreatedAt.Format("2006-01-02 15:04:05"), checkpoint.Break),
+			})
+		}
+		if checkpoint.ChainSeq > 0 {
+			checkpoints[checkpoint.ChainSeq] = append(checkpoints[checkpoint.ChainSeq], checkpoint)
+		}
+		latest = checkpoint
+	}
+	result.Checkpoints = len(stored)
+
+	var lastSeq int64
+	var prevHash string
+	for {
+		events, err := db.ChangeEvents.ListChain(ctx, targetID, lastSeq, chainPage)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, event := range events {
+			seq := *event.ChainSeq
+			if seq != lastSeq+1 {
+				// The next event's link to a deleted one is not reported again
+				result.addBreak(Break{
+					Kind:    BreakMissing,
+					Seq:     lastSeq + 1,
+					Message: missingMessage(lastSeq+1, seq-1),
+				})
+			} else if *event.PrevHash != prevHash {
+				result.addBreak(Break{
+					Kind:    BreakRelinked,
+					Seq:     seq,
+					EventID: event.ID,
+					Message: fmt.Sprintf("event %d does not follow the event before it", event.ID),
+				})
+			}
+			if database.ChainHash(*event.PrevHash, targetID, seq, event) != *event.Hash {
+				result.addBreak(Break{
+					Kind:    BreakEdited,
+					Seq:     seq,
+					EventID: event.ID,
+					Message: fmt.Sprintf("event %d (%s) was changed after it was recorded", event.ID, event.EventType),
+				})
+			}
+
+			for _, checkpoint := range checkpoints[seq] {
+				if checkpoint.ChainHash != *event.Hash {
+					result.addBreak(Break{
+						Kind:    BreakCheckpoint,
+						Seq:     seq,
+						EventID: event.ID,
+						Message: fmt.Sprintf("the chain at position %d differs from the checkpoint of %s", seq, checkpoint.CreatedAt.Format("2006-01-02 15:04:05")),
+					})
+				}
+			}
+			delete(checkpoints, seq)
+
+			result.Events++
+			lastSeq, prevHash = seq, *event.Hash
+		}
+
+		if len(events) < chainPage {
+			break
+		}
+	}
+
+	unmatched := make([]int64, 0, len(checkpoints))
+	for seq := range checkpoints {
+		unmatched = append(unmatched, seq)
+	}
+	sort.Slice(unmatched, func(i, j int) bool { return unmatched[i] < unmatched[j] })
+	for _, seq := range unmatched {
+		for _, checkpoint := range checkpoints[seq] {
+			result.addBreak(Break{
+				Kind:    BreakCheckpoint,
+				Seq:     seq,
+				Message: fmt.Sprintf("the checkpoint of %s covers position %d, which has no event", checkpoint.CreatedAt.Format("2006-01-02 15:04:05"), seq),
+			})
+		}
+	}
+
+	head, err := db.ChangeEvents.GetChainHead(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if head.Seq != lastSeq || head.Hash != prevHash {
+		result.addBreak(Break{
+			Kind:    BreakTruncated,
+			Seq:     head.Seq,
+			Message: fmt.Sprintf("the chain ends at position %d but its head records position %d", lastSeq, head.Seq),
+		})
+	}
+
+	legacy, inserted, err := db.ChangeEvents.CountUnchained(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+	result.Legacy = legacy
+	if inserted > 0 {
+		result.addBreak(Break{
+			Kind:    BreakInserted,
+			Message: fmt.Sprintf("%d events were added outside the chain", inserted),
+		})
+	}
+
+	// Files only change without an event while a scan is running
+	if latest != nil && latest.ChainSeq == lastSeq && head.Seq == lastSeq {
+		scan, err := db.Scans.GetLatest(ctx, targetID)
+		if err != nil {
+			return nil, err
+		}
+		if scan == nil || scan.Status != database.ScanStatusRunning {
+			digest, count, err := db.Files.StateDigest(ctx, targetID)
+			if err != nil {
+				return nil, err
+			}
+			if digest != latest.FilesDigest {
+				result.addBreak(Break{
+					Kind: BreakFiles,
+					Message: fmt.Sprintf("recorded files and checksums (%d files) differ from the checkpoint of %s (%d files) with no event recorded since",
+						count, latest.CreatedAt.Format("2006-01-02 15:04:05"), latest.FileCount),
+				})
+			}
+			result.FilesChecked = true
+		}
+	}
+
+	return result, nil
+}
+
+func missingMessage(first, last int64) string {
+	if first == last {
+		return fmt.Sprintf("no event at position %d; it was deleted", first)
+	}
+	return fmt.Sprintf("no events at positions %d to %d; they were deleted", first, last)
+}
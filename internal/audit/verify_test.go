@@ -0,0 +1,202 @@
+package audit_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jeffanddom/fixity/internal/audit"
+	"github.com/jeffanddom/fixity/internal/checksum"
+	"github.com/jeffanddom/fixity/internal/database"
+	"github.com/jeffanddom/fixity/internal/scanner"
+	"github.com/jeffanddom/fixity/internal/signing"
+	"github.com/jeffanddom/fixity/internal/storage"
+	"github.com/jeffanddom/fixity/tests/testutil"
+)
+
+// setupChain records three events for a new target and signs a checkpoint
+// of them, returning the target and its events
+func setupChain(t *testing.T, db *database.Database, key *signing.Key, name string) (*database.StorageTarget, []*database.ChangeEvent) {
+	t.Helper()
+	ctx := context.Background()
+
+	target := testutil.MustCreateStorageTarget(t, db, name)
+	file := testutil.MustCreateFile(t, db, target.ID, "photo.jpg")
+	scan := testutil.MustCreateScan(t, db, target.ID)
+
+	sum := "abc123"
+	events := []*database.ChangeEvent{
+		{ScanID: scan.ID, FileID: file.ID, EventType: database.ChangeEventAdded, DetectedAt: testutil.TimeNow()},
+		{ScanID: scan.ID, FileID: file.ID, EventType: database.ChangeEventVerified, DetectedAt: testutil.TimeNow(), OldChecksum: &sum, NewChecksum: &sum},
+	}
+	if err := db.ChangeEvents.CreateBatch(ctx, events); err != nil {
+		t.Fatalf("failed to create events: %v", err)
+	}
+	events = append(events, testutil.MustCreateChangeEvent(t, db, scan.ID, file.ID, database.ChangeEventVerified))
+
+	if _, err := audit.WriteCheckpoint(ctx, db, key, target.ID); err != nil {
+		t.Fatalf("failed to write checkpoint: %v", err)
+	}
+	return target, events
+}
+
+func expectBreak(t *testing.T, result *audit.Result, kind audit.BreakKind) {
+	t.Helper()
+	for _, b := range result.Breaks {
+		if b.Kind == kind {
+			return
+		}
+	}
+	t.Errorf("expected a %s break, got %+v", kind, result.Breaks)
+}
+
+func TestVerify(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupDB(t, db)
+
+	ctx := context.Background()
+	key, err := signing.Generate()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	t.Run("untouched chain verifies", func(t *testing.T) {
+		target, events := setupChain(t, db, key, "untouched")
+
+		for i, event := range events {
+			if event.ChainSeq == nil || *event.ChainSeq != int64(i+1) {
+				t.Fatalf("expected event %d at position %d, got %v", event.ID, i+1, event.ChainSeq)
+			}
+		}
+
+		result, err := audit.Verify(ctx, db, target.ID)
+		if err != nil {
+			t.Fatalf("Verify failed: %v", err)
+		}
+		if !result.OK() || result.Events != 3 || result.Checkpoints != 1 || !result.FilesChecked {
+			t.Errorf("unexpected result: %+v", result)
+		}
+	})
+
+	t.Run("detects an edited event", func(t *testing.T) {
+		target, events := setupChain(t, db, key, "edited")
+		db.DB().ExecContext(ctx, `UPDATE change_events SET new_checksum = 'forged' WHERE id = $1`, events[1].ID)
+
+		result, _ := audit.Verify(ctx, db, target.ID)
+		expectBreak(t, result, audit.BreakEdited)
+	})
+
+	t.Run("detects a deleted event", func(t *testing.T) {
+		target, events := setupChain(t, db, key, "deleted")
+		db.DB().ExecContext(ctx, `DELETE FROM change_events WHERE id = $1`, events[1].ID)
+
+		result, _ := audit.Verify(ctx, db, target.ID)
+		expectBreak(t, result, audit.BreakMissing)
+	})
+
+	t.Run("detects a deleted last event", func(t *testing.T) {
+		target, events := setupChain(t, db, key, "truncated")
+		db.DB().ExecContext(ctx, `DELETE FROM change_events WHERE id = $1`, events[2].ID)
+
+		result, _ := audit.Verify(ctx, db, target.ID)
+		expectBreak(t, result, audit.BreakTruncated)
+		expectBreak(t, result, audit.BreakCheckpoint)
+	})
+
+	t.Run("detects a rewritten chain", func(t *testing.T) {
+		target, events := setupChain(t, db, key, "rewritten")
+
+		// Edit the last event and rehash it and the head consistently
+		forged := *events[2]
+		forged.EventType = database.ChangeEventDeleted
+		hash := database.ChainHash(*forged.PrevHash, target.ID, 3, &forged)
+		db.DB().ExecContext(ctx, `UPDATE change_events SET event_type = 'deleted', hash = $2 WHERE id = $1`, forged.ID, hash)
+		db.DB().ExecContext(ctx, `UPDATE event_chain_heads SET hash = $2 WHERE storage_target_id = $1`, target.ID, hash)
+
+		result, _ := audit.Verify(ctx, db, target.ID)
+		if result.BreakCount != 1 {
+			t.Errorf("expected only the checkpoint to disagree, got %+v", result.Breaks)
+		}
+		expectBreak(t, result, audit.BreakCheckpoint)
+	})
+
+	t.Run("detects an event added outside the chain", func(t *testing.T) {
+		target, events := setupChain(t, db, key, "inserted")
+		db.DB().ExecContext(ctx, `
+			INSERT INTO change_events (scan_id, file_id, event_type, detected_at)
+			VALUES ($1, $2, 'verified', NOW())`, events[0].ScanID, events[0].FileID)
+
+		result, _ := audit.Verify(ctx, db, target.ID)
+		expectBreak(t, result, audit.BreakInserted)
+	})
+
+	t.Run("detects an edited checksum", func(t *testing.T) {
+		target, events := setupChain(t, db, key, "checksum")
+		db.DB().ExecContext(ctx, `UPDATE files SET current_checksum = 'forged' WHERE id = $1`, events[0].FileID)
+
+		result, _ := audit.Verify(ctx, db, target.ID)
+		expectBreak(t, result, audit.BreakFiles)
+	})
+
+	t.Run("detects a checksum edited between scans", func(t *testing.T) {
+		target, events := setupChain(t, db, key, "between-scans")
+		db.DB().ExecContext(ctx, `UPDATE files SET current_checksum = 'forged' WHERE id = $1`, events[0].FileID)
+
+		// A later scan records events, so the edit is no longer the only
+		// change since the checkpoint
+		testutil.MustCreateChangeEvent(t, db, events[0].ScanID, events[0].FileID, database.ChangeEventMetadataChanged)
+		checkpoint, err := audit.WriteCheckpoint(ctx, db, key, target.ID)
+		if !errors.Is(err, audit.ErrUnexplainedChange) || checkpoint == nil {
+			t.Fatalf("expected the checkpoint to record the unexplained change, got %v", err)
+		}
+
+		result, _ := audit.Verify(ctx, db, target.ID)
+		expectBreak(t, result, audit.BreakFiles)
+	})
+
+	t.Run("explains the checksums scans record", func(t *testing.T) {
+		target := testutil.MustCreateStorageTarget(t, db, "scanned")
+		tmpDir := t.TempDir()
+		os.WriteFile(filepath.Join(tmpDir, "a.txt"), []byte("first"), 0644)
+		os.WriteFile(filepath.Join(tmpDir, "b.txt"), []byte("second"), 0644)
+		backend, _ := storage.NewLocalFSBackend(tmpDir)
+		engine := scanner.NewEngine(db, scanner.Config{
+			ChecksumAlgorithm:   checksum.AlgorithmMD5,
+			RandomSamplePercent: 100,
+		})
+
+		scan := func() {
+			t.Helper()
+			if _, err := engine.Scan(ctx, target.ID, backend); err != nil {
+				t.Fatalf("scan failed: %v", err)
+			}
+			if _, err := audit.WriteCheckpoint(ctx, db, key, target.ID); err != nil {
+				t.Fatalf("failed to write checkpoint: %v", err)
+			}
+		}
+		scan()
+		os.WriteFile(filepath.Join(tmpDir, "a.txt"), []byte("first, edited"), 0644)
+		os.Remove(filepath.Join(tmpDir, "b.txt"))
+		os.WriteFile(filepath.Join(tmpDir, "c.txt"), []byte("third"), 0644)
+		scan()
+
+		result, err := audit.Verify(ctx, db, target.ID)
+		if err != nil {
+			t.Fatalf("Verify failed: %v", err)
+		}
+		if !result.OK() {
+			t.Errorf("expected no breaks, got %+v", result.Breaks)
+		}
+	})
+
+	t.Run("detects an edited checkpoint", func(t *testing.T) {
+		target, _ := setupChain(t, db, key, "checkpoint")
+		db.DB().ExecContext(ctx, `UPDATE audit_checkpoints SET file_count = 99 WHERE storage_target_id = $1`, target.ID)
+
+		result, _ := audit.Verify(ctx, db, target.ID)
+		expectBreak(t, result, audit.BreakCheckpoint)
+	})
+}
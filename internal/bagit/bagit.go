@@ -0,0 +1,353 @@
+// Package bagit validates BagIt bags (RFC 8493): the payload and tag
+// manifests against the files' checksums, and the payload against the
+// manifests
+package bagit
+
+import (
+	"bufio"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/zeebo/blake3"
+
+	"github.com/jeffanddom/fixity/internal/manifest"
+)
+
+// Declaration is the file whose presence makes a directory a bag
+const Declaration = "bagit.txt"
+
+// payloadDir holds a bag's payload; everything else is a tag file
+const payloadDir = "data/"
+
+// ProblemKind classifies a problem found in a bag
+type ProblemKind string
+
+const (
+	// ProblemMissing is a file listed in a manifest that is not in the bag
+	ProblemMissing ProblemKind = "missing"
+
+	// ProblemExtra is a payload file not listed in every payload manifest
+	ProblemExtra ProblemKind = "extra"
+
+	// ProblemMismatch is a file whose checksum differs from its manifest's
+	ProblemMismatch ProblemKind = "mismatch"
+
+	// ProblemInvalid is a bag that does not follow the BagIt structure, or a
+	// file that could not be read
+	ProblemInvalid ProblemKind = "invalid"
+)
+
+// Problem is one reason a bag is not valid
+type Problem struct {
+	Path      string // Relative to the bag; empty for the bag as a whole
+	Kind      ProblemKind
+	Algorithm string // Manifest algorithm, for mismatches
+	Expected  string
+	Actual    string
+	Message   string
+}
+
+// Result is the outcome of validating a bag
+type Result struct {
+	Version      string // BagIt-Version declared in bagit.txt
+	PayloadFiles int
+	Problems     []Problem
+}
+
+// Valid reports whether the bag has no problems
+func (r *Result) Valid() bool {
+	return len(r.Problems) == 0
+}
+
+// Bag is a bag found on a storage target
+type Bag struct {
+	// Files lists every file in the bag by its path relative to the bag's
+	// directory, such as "bagit.txt" and "data/photo.jpg"
+	Files []string
+
+	// Open opens a file of the bag by its relative path
+	Open func(ctx context.Context, path string) (io.ReadCloser, error)
+
+	// Recorded returns a checksum already known for a file with the given
+	// algorithm, so it need not be read again. It may be nil.
+	Recorded func(path, algorithm string) (string, bool)
+}
+
+// newHash returns a hash for a BagIt manifest algorithm
+func newHash(algorithm string) (hash.Hash, bool) {
+	switch algorithm {
+	case "md5":
+		return md5.New(), true
+	case "sha1":
+		return sha1.New(), true
+	case "sha256":
+		return sha256.New(), true
+	case "sha512":
+		return sha512.New(), true
+	case "blake3":
+		return blake3.New(), true
+	default:
+		return nil, false
+	}
+}
+
+// manifestAlgorithm returns the algorithm of a payload (manifest-<alg>.txt)
+// or tag (tagmanifest-<alg>.txt) manifest with the given prefix
+func manifestAlgorithm(name, prefix string) (string, bool) {
+	if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ".txt") || strings.Contains(name, "/") {
+		return "", false
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".txt"), true
+}
+
+// expectation is a checksum a manifest lists for a file
+type expectation struct {
+	path      string
+	algorithm string
+	checksum  string
+}
+
+// Validate checks a bag's declaration, that every payload file is listed in
+// every payload manifest and every listed file exists, and that each file's
+// checksum matches every manifest that lists it. Files that cannot be read
+// are reported as problems; only a cancelled context returns an error.
+func Validate(ctx context.Context, bag Bag) (*Result, error) {
+	result := &Result{Problems: []Problem{}}
+	invalid := func(path, format string, args ...interface{}) {
+		result.Problems = append(result.Problems, Problem{Path: path, Kind: ProblemInvalid, Message: fmt.Sprintf(format, args...)})
+	}
+
+	files := make(map[string]bool, len(bag.Files))
+	payload := []string{}
+	payloadAlgorithms, tagAlgorithms := []string{}, []string{}
+	for _, path := range bag.Files {
+		files[path] = true
+		if strings.HasPrefix(path, payloadDir) {
+			payload = append(payload, path)
+		}
+		if algorithm, ok := manifestAlgorithm(path, "manifest-"); ok {
+			payloadAlgorithms = append(payloadAlgorithms, algorithm)
+		}
+		if algorithm, ok := manifestAlgorithm(path, "tagmanifest-"); ok {
+			tagAlgorithms = append(tagAlgorithms, algorithm)
+		}
+	}
+	sort.Strings(payload)
+	sort.Strings(payloadAlgorithms)
+	sort.Strings(tagAlgorithms)
+	result.PayloadFiles = len(payload)
+
+	if err := readDeclaration(ctx, bag, result); err != nil {
+		invalid(Declaration, "%v", err)
+	}
+	if len(payloadAlgorithms) == 0 {
+		invalid("", "no payload manifest")
+	}
+
+	// Read the manifests, noting each listed checksum
+	expected := []expectation{}
+	missing := make(map[string]bool)
+	extra := make(map[string]bool)
+	readManifest := func(name, algorithm string, isPayload bool) map[string]bool {
+		if _, ok := newHash(algorithm); !ok {
+			invalid(name, "unsupported algorithm: %s", algorithm)
+			return nil
+		}
+
+		entries, err := readEntries(ctx, bag, name)
+		if err != nil {
+			invalid(name, "%v", err)
+			return nil
+		}
+
+		listed := make(map[string]bool, len(entries))
+		for _, entry := range entries {
+			if strings.HasPrefix(entry.Path, payloadDir) != isPayload {
+				belongs := "payload"
+				if isPayload {
+					belongs = "tag"
+				}
+				invalid(name, "lists %s, which belongs in a %s manifest", entry.Path, belongs)
+				continue
+			}
+			listed[entry.Path] = true
+			if !files[entry.Path] {
+				missing[entry.Path] = true
+				continue
+			}
+			expected = append(expected, expectation{path: entry.Path, algorithm: algorithm, checksum: entry.Checksum})
+		}
+		return listed
+	}
+
+	for _, algorithm := range payloadAlgorithms {
+		listed := readManifest("manifest-"+algorithm+".txt", algorithm, true)
+		if listed == nil {
+			continue
+		}
+		for _, path := range payload {
+			if !listed[path] {
+				extra[path] = true
+			}
+		}
+	}
+	for _, algorithm := range tagAlgorithms {
+		readManifest("tagmanifest-"+algorithm+".txt", algorithm, false)
+	}
+
+	for path := range missing {
+		result.Problems = append(result.Problems, Problem{Path: path, Kind: ProblemMissing, Message: "listed in a manifest but not in the bag"})
+	}
+	for path := range extra {
+		result.Problems = append(result.Problems, Problem{Path: path, Kind: ProblemExtra, Message: "not listed in every payload manifest"})
+	}
+
+	if err := checkChecksums(ctx, bag, expected, result); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(result.Problems, func(i, j int) bool {
+		if result.Problems[i].Path != result.Problems[j].Path {
+			return result.Problems[i].Path < result.Problems[j].Path
+		}
+		return result.Problems[i].Kind < result.Problems[j].Kind
+	})
+
+	return result, nil
+}
+
+// readDeclaration checks bagit.txt declares a version and tag file encoding
+func readDeclaration(ctx context.Context, bag Bag, result *Result) error {
+	rc, err := bag.Open(ctx, Declaration)
+	if err != nil {
+		return fmt.Errorf("failed to read: %w", err)
+	}
+	defer rc.Close()
+
+	fields := make(map[string]string)
+	scanner := bufio.NewScanner(rc)
+	for scanner.Scan() {
+		line := strings.TrimPrefix(strings.TrimSuffix(scanner.Text(), "\r"), "\ufeff")
+		if name, value, ok := strings.Cut(line, ":"); ok {
+			fields[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read: %w", err)
+	}
+
+	result.Version = fields["BagIt-Version"]
+	if result.Version == "" {
+		return fmt.Errorf("missing BagIt-Version")
+	}
+	if fields["Tag-File-Character-Encoding"] == "" {
+		return fmt.Errorf("missing Tag-File-Character-Encoding")
+	}
+	return nil
+}
+
+func readEntries(ctx context.Context, bag Bag, name string) ([]manifest.Entry, error) {
+	rc, err := bag.Open(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read: %w", err)
+	}
+	defer rc.Close()
+
+	return manifest.ParseBagIt(rc)
+}
+
+// checkChecksums compares each listed checksum with the file's, using
+// recorded checksums where known and reading each remaining file once for
+// all the algorithms it needs
+func checkChecksums(ctx context.Context, bag Bag, expected []expectation, result *Result) error {
+	actual := make(map[[2]string]string)
+	toRead := make(map[string][]string)
+	paths := []string{}
+	for _, e := range expected {
+		if bag.Recorded != nil {
+			if sum, ok := bag.Recorded(e.path, e.algorithm); ok {
+				actual[[2]string{e.path, e.algorithm}] = sum
+				continue
+			}
+		}
+		if _, ok := toRead[e.path]; !ok {
+			paths = append(paths, e.path)
+		}
+		toRead[e.path] = append(toRead[e.path], e.algorithm)
+	}
+
+	unreadable := make(map[string]bool)
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		sums, err := hashFile(ctx, bag, path, toRead[path])
+		if err != nil {
+			unreadable[path] = true
+			result.Problems = append(result.Problems, Problem{Path: path, Kind: ProblemInvalid, Message: err.Error()})
+			continue
+		}
+		for algorithm, sum := range sums {
+			actual[[2]string{path, algorithm}] = sum
+		}
+	}
+
+	for _, e := range expected {
+		if unreadable[e.path] {
+			continue
+		}
+		sum := actual[[2]string{e.path, e.algorithm}]
+		if !strings.EqualFold(sum, e.checksum) {
+			result.Problems = append(result.Problems, Problem{
+				Path:      e.path,
+				Kind:      ProblemMismatch,
+				Algorithm: e.algorithm,
+				Expected:  e.checksum,
+				Actual:    sum,
+				Message:   fmt.Sprintf("%s checksum does not match the manifest", e.algorithm),
+			})
+		}
+	}
+
+	return nil
+}
+
+// hashFile reads a file once, hashing it with each algorithm
+func hashFile(ctx context.Context, bag Bag, path string, algorithms []string) (map[string]string, error) {
+	hashes := make(map[string]hash.Hash, len(algorithms))
+	writers := make([]io.Writer, 0, len(algorithms))
+	for _, algorithm := range algorithms {
+		if _, ok := hashes[algorithm]; ok {
+			continue
+		}
+		h, _ := newHash(algorithm)
+		hashes[algorithm] = h
+		writers = append(writers, h)
+	}
+
+	rc, err := bag.Open(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read: %w", err)
+	}
+	defer rc.Close()
+
+	if _, err := io.Copy(io.MultiWriter(writers...), rc); err != nil {
+		return nil, fmt.Errorf("failed to read: %w", err)
+	}
+
+	sums := make(map[string]string, len(hashes))
+	for algorithm, h := range hashes {
+		sums[algorithm] = hex.EncodeToString(h.Sum(nil))
+	}
+	return sums, nil
+}
@@ -0,0 +1,250 @@
+package bagit
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// memoryBag is a bag held in memory, counting the files read
+type memoryBag struct {
+	files map[string]string
+	reads map[string]int
+}
+
+func (m *memoryBag) bag() Bag {
+	files := make([]string, 0, len(m.files))
+	for name := range m.files {
+		files = append(files, name)
+	}
+	sort.Strings(files)
+
+	return Bag{
+		Files: files,
+		Open: func(ctx context.Context, path string) (io.ReadCloser, error) {
+			content, ok := m.files[path]
+			if !ok {
+				return nil, os.ErrNotExist
+			}
+			m.reads[path]++
+			return io.NopCloser(strings.NewReader(content)), nil
+		},
+	}
+}
+
+func md5Hex(content string) string {
+	sum := md5.Sum([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func sha256Hex(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// newValidBag returns a bag with two payload files, an MD5 and a SHA-256
+// payload manifest and a tag manifest
+func newValidBag() *memoryBag {
+	declaration := "BagIt-Version: 1.0\nTag-File-Character-Encoding: UTF-8\n"
+	manifestMD5 := md5Hex("alpha") + "  data/a.txt\n" + md5Hex("beta") + "  data/sub/b.txt\n"
+	manifestSHA256 := sha256Hex("alpha") + "  data/a.txt\n" + sha256Hex("beta") + "  data/sub/b.txt\n"
+	tagManifest := md5Hex(declaration) + "  bagit.txt\n" +
+		md5Hex(manifestMD5) + "  manifest-md5.txt\n" +
+		md5Hex(manifestSHA256) + "  manifest-sha256.txt\n"
+
+	return &memoryBag{
+		files: map[string]string{
+			"bagit.txt":           declaration,
+			"manifest-md5.txt":    manifestMD5,
+			"manifest-sha256.txt": manifestSHA256,
+			"tagmanifest-md5.txt": tagManifest,
+			"data/a.txt":          "alpha",
+			"data/sub/b.txt":      "beta",
+		},
+		reads: make(map[string]int),
+	}
+}
+
+// problemKinds returns each problem as "path:kind"
+func problemKinds(result *Result) []string {
+	kinds := []string{}
+	for _, p := range result.Problems {
+		kinds = append(kinds, p.Path+":"+string(p.Kind))
+	}
+	return kinds
+}
+
+func TestValidate_Valid(t *testing.T) {
+	m := newValidBag()
+
+	result, err := Validate(context.Background(), m.bag())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Valid() {
+		t.Fatalf("expected a valid bag, got problems %v", problemKinds(result))
+	}
+	if result.Version != "1.0" || result.PayloadFiles != 2 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	// Each payload file is read once for both manifests
+	if m.reads["data/a.txt"] != 1 {
+		t.Errorf("expected data/a.txt to be read once, got %d", m.reads["data/a.txt"])
+	}
+}
+
+func TestValidate_RecordedChecksums(t *testing.T) {
+	m := newValidBag()
+	bag := m.bag()
+	bag.Recorded = func(path, algorithm string) (string, bool) {
+		if path == "data/a.txt" && algorithm == "md5" {
+			return strings.Repeat("0", 32), true
+		}
+		return "", false
+	}
+
+	result, err := Validate(context.Background(), bag)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	kinds := problemKinds(result)
+	if len(kinds) != 1 || kinds[0] != "data/a.txt:mismatch" {
+		t.Fatalf("expected the recorded checksum to be compared, got %v", kinds)
+	}
+	if result.Problems[0].Algorithm != "md5" || result.Problems[0].Expected != md5Hex("alpha") {
+		t.Errorf("unexpected problem: %+v", result.Problems[0])
+	}
+}
+
+func TestValidate_PayloadProblems(t *testing.T) {
+	m := newValidBag()
+	m.files["data/a.txt"] = "tampered"
+	m.files["data/extra.txt"] = "extra"
+	delete(m.files, "data/sub/b.txt")
+
+	result, err := Validate(context.Background(), m.bag())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{
+		"data/a.txt:mismatch",
+		"data/a.txt:mismatch",
+		"data/extra.txt:extra",
+		"data/sub/b.txt:missing",
+	}
+	if got := problemKinds(result); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestValidate_TagManifestMismatch(t *testing.T) {
+	m := newValidBag()
+	m.files["bagit.txt"] = "BagIt-Version: 0.97\nTag-File-Character-Encoding: UTF-8\n"
+
+	result, err := Validate(context.Background(), m.bag())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	kinds := problemKinds(result)
+	if len(kinds) != 1 || kinds[0] != "bagit.txt:mismatch" {
+		t.Errorf("expected the changed declaration to fail the tag manifest, got %v", kinds)
+	}
+}
+
+func TestValidate_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(m *memoryBag)
+		path   string
+	}{
+		{
+			name:   "missing declaration",
+			modify: func(m *memoryBag) { delete(m.files, "bagit.txt"); delete(m.files, "tagmanifest-md5.txt") },
+			path:   "bagit.txt",
+		},
+		{
+			name: "declaration without version",
+			modify: func(m *memoryBag) {
+				m.files["bagit.txt"] = "Tag-File-Character-Encoding: UTF-8\n"
+				delete(m.files, "tagmanifest-md5.txt")
+			},
+			path: "bagit.txt",
+		},
+		{
+			name: "no payload manifest",
+			modify: func(m *memoryBag) {
+				delete(m.files, "manifest-md5.txt")
+				delete(m.files, "manifest-sha256.txt")
+				delete(m.files, "tagmanifest-md5.txt")
+			},
+			path: "",
+		},
+		{
+			name: "unsupported algorithm",
+			modify: func(m *memoryBag) {
+				m.files["manifest-crc32.txt"] = "deadbeef  data/a.txt\n"
+			},
+			path: "manifest-crc32.txt",
+		},
+		{
+			name: "malformed manifest",
+			modify: func(m *memoryBag) {
+				m.files["manifest-md5.txt"] = "not-hex  data/a.txt\n"
+				delete(m.files, "tagmanifest-md5.txt")
+			},
+			path: "manifest-md5.txt",
+		},
+		{
+			name: "tag file in payload manifest",
+			modify: func(m *memoryBag) {
+				m.files["manifest-sha256.txt"] += sha256Hex("x") + "  bag-info.txt\n"
+				delete(m.files, "tagmanifest-md5.txt")
+			},
+			path: "manifest-sha256.txt",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newValidBag()
+			tt.modify(m)
+
+			result, err := Validate(context.Background(), m.bag())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Valid() {
+				t.Fatal("expected an invalid bag")
+			}
+
+			found := false
+			for _, p := range result.Problems {
+				if p.Path == tt.path && p.Kind == ProblemInvalid {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("expected an invalid problem for %q, got %+v", tt.path, result.Problems)
+			}
+		})
+	}
+}
+
+func TestValidate_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := Validate(ctx, newValidBag().bag()); err == nil {
+		t.Error("expected an error for a cancelled context")
+	}
+}
@@ -0,0 +1,164 @@
+package checksum
+
+import "time"
+
+// Thresholds for the adaptive controller. Throughput must improve by at
+// least aimdGain to justify another worker; a drop beyond aimdLoss combined
+// with rising per-byte latency means the backend is saturated.
+const (
+	aimdGain        = 1.05
+	aimdLoss        = 0.90
+	aimdLatencyRise = 1.20
+)
+
+// sample holds pool activity observed during one control interval
+type sample struct {
+	files   int64
+	bytes   int64
+	busy    time.Duration // Sum of job durations
+	elapsed time.Duration
+}
+
+// throughput returns bytes per second over the interval
+func (s sample) throughput() float64 {
+	if s.elapsed <= 0 {
+		return 0
+	}
+	return float64(s.bytes) / s.elapsed.Seconds()
+}
+
+// costPerByte returns the average time a worker spent per byte hashed
+func (s sample) costPerByte() float64 {
+	if s.bytes <= 0 {
+		return 0
+	}
+	return s.busy.Seconds() / float64(s.bytes)
+}
+
+// aimd decides the next concurrency from successive samples:
+// additive increase while throughput keeps improving, multiplicative
+// decrease when throughput falls and latency rises
+type aimd struct {
+	min, max int
+	prev     sample
+}
+
+// next returns the concurrency to use for the following interval
+func (a *aimd) next(limit int, s sample) int {
+	if s.files == 0 || s.bytes == 0 {
+		// Idle or only failures: nothing to learn from
+		return limit
+	}
+
+	prev := a.prev
+	a.prev = s
+
+	if prev.bytes == 0 {
+		// First measurement: probe upwards
+		return a.clamp(limit + 1)
+	}
+
+	throughput, prevThroughput := s.throughput(), prev.throughput()
+	switch {
+	case throughput < prevThroughput*aimdLoss && s.costPerByte() > prev.costPerByte()*aimdLatencyRise:
+		return a.clamp(limit / 2)
+	case throughput >= prevThroughput*aimdGain:
+		return a.clamp(limit + 1)
+	default:
+		return limit
+	}
+}
+
+func (a *aimd) clamp(limit int) int {
+	if limit < a.min {
+		return a.min
+	}
+	if limit > a.max {
+		return a.max
+	}
+	return limit
+}
+
+// poolMeter accumulates activity for both the controller and PoolStats
+type poolMeter struct {
+	// Current control interval
+	interval      sample
+	intervalStart time.Time
+
+	// Totals across the pool's lifetime
+	files     int64
+	bytes     int64
+	firstJob  time.Time
+	lastJob   time.Time
+	peak      int
+	weighted  float64   // Concurrency integrated over time (worker-seconds)
+	weightedT time.Time // Time up to which weighted has been accumulated
+}
+
+// record accounts a finished job
+func (m *poolMeter) record(start, end time.Time, bytes int64) {
+	if m.firstJob.IsZero() {
+		m.firstJob = start
+		m.weightedT = start
+	}
+	if m.intervalStart.IsZero() {
+		m.intervalStart = start
+	}
+	if end.After(m.lastJob) {
+		m.lastJob = end
+	}
+
+	m.files++
+	m.bytes += bytes
+	m.interval.files++
+	m.interval.bytes += bytes
+	m.interval.busy += end.Sub(start)
+}
+
+// takeSample returns the current interval's activity and starts a new interval
+func (m *poolMeter) takeSample(now time.Time) sample {
+	s := m.interval
+	if !m.intervalStart.IsZero() {
+		s.elapsed = now.Sub(m.intervalStart)
+	}
+	m.interval = sample{}
+	m.intervalStart = now
+	return s
+}
+
+// accumulate integrates the outgoing concurrency up to now
+func (m *poolMeter) accumulate(limit int, now time.Time) {
+	if m.weightedT.IsZero() {
+		return
+	}
+	if now.After(m.weightedT) {
+		m.weighted += float64(limit) * now.Sub(m.weightedT).Seconds()
+		m.weightedT = now
+	}
+}
+
+// active returns the span between the first job starting and the last finishing
+func (m *poolMeter) active() time.Duration {
+	if m.firstJob.IsZero() {
+		return 0
+	}
+	return m.lastJob.Sub(m.firstJob)
+}
+
+// average returns the time-weighted concurrency while the pool was active
+func (m *poolMeter) average(limit int) float64 {
+	if m.firstJob.IsZero() {
+		return float64(limit)
+	}
+
+	weighted := m.weighted
+	if m.lastJob.After(m.weightedT) {
+		weighted += float64(limit) * m.lastJob.Sub(m.weightedT).Seconds()
+	}
+
+	active := m.active().Seconds()
+	if active <= 0 {
+		return float64(limit)
+	}
+	return weighted / active
+}
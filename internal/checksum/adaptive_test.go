@@ -0,0 +1,79 @@
+package checksum
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAIMD_Next(t *testing.T) {
+	second := time.Second
+	mb := int64(1 << 20)
+
+	t.Run("probes upwards on first sample", func(t *testing.T) {
+		a := &aimd{min: 1, max: 8}
+		if got := a.next(2, sample{files: 10, bytes: 10 * mb, busy: 2 * second, elapsed: second}); got != 3 {
+			t.Errorf("expected 3, got %d", got)
+		}
+	})
+
+	t.Run("increases while throughput improves", func(t *testing.T) {
+		a := &aimd{min: 1, max: 8}
+		a.next(2, sample{files: 10, bytes: 10 * mb, busy: 2 * second, elapsed: second})
+		if got := a.next(3, sample{files: 15, bytes: 15 * mb, busy: 3 * second, elapsed: second}); got != 4 {
+			t.Errorf("expected 4, got %d", got)
+		}
+	})
+
+	t.Run("holds when throughput plateaus", func(t *testing.T) {
+		a := &aimd{min: 1, max: 8}
+		a.next(4, sample{files: 10, bytes: 20 * mb, busy: 4 * second, elapsed: second})
+		if got := a.next(5, sample{files: 10, bytes: 20 * mb, busy: 5 * second, elapsed: second}); got != 5 {
+			t.Errorf("expected 5, got %d", got)
+		}
+	})
+
+	t.Run("halves when throughput drops and latency rises", func(t *testing.T) {
+		a := &aimd{min: 1, max: 8}
+		a.next(8, sample{files: 10, bytes: 20 * mb, busy: 8 * second, elapsed: second})
+		if got := a.next(8, sample{files: 10, bytes: 10 * mb, busy: 8 * second, elapsed: second}); got != 4 {
+			t.Errorf("expected 4, got %d", got)
+		}
+	})
+
+	t.Run("respects bounds", func(t *testing.T) {
+		a := &aimd{min: 2, max: 4}
+		a.next(4, sample{files: 10, bytes: 10 * mb, busy: second, elapsed: second})
+		if got := a.next(4, sample{files: 10, bytes: 20 * mb, busy: second, elapsed: second}); got != 4 {
+			t.Errorf("expected max of 4, got %d", got)
+		}
+		if got := a.next(2, sample{files: 10, bytes: 5 * mb, busy: 4 * second, elapsed: second}); got != 2 {
+			t.Errorf("expected min of 2, got %d", got)
+		}
+	})
+
+	t.Run("ignores idle intervals", func(t *testing.T) {
+		a := &aimd{min: 1, max: 8}
+		if got := a.next(3, sample{elapsed: second}); got != 3 {
+			t.Errorf("expected 3, got %d", got)
+		}
+	})
+}
+
+func TestPoolMeter_Average(t *testing.T) {
+	start := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	var m poolMeter
+	m.record(start, start.Add(time.Second), 100)
+
+	// Two workers for the first two seconds, then four for two seconds
+	m.accumulate(2, start.Add(2*time.Second))
+	m.record(start.Add(2*time.Second), start.Add(4*time.Second), 100)
+
+	if got := m.average(4); got != 3 {
+		t.Errorf("expected average concurrency 3, got %v", got)
+	}
+
+	if m.active() != 4*time.Second {
+		t.Errorf("expected 4s active, got %v", m.active())
+	}
+}
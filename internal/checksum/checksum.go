@@ -5,7 +5,9 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"hash"
 	"io"
+	"sync"
 
 	"github.com/zeebo/blake3"
 )
@@ -24,15 +26,20 @@ const BufferSize = 1024 * 1024
 
 // Compute computes a checksum for the given reader using the specified algorithm
 func Compute(algorithm Algorithm, reader io.Reader) (string, error) {
+	return ComputeWithReadSize(algorithm, reader, 0)
+}
+
+// newHasher returns a hash for algorithm and the algorithm's display name
+func newHasher(algorithm Algorithm) (hash.Hash, string, error) {
 	switch algorithm {
 	case AlgorithmMD5:
-		return ComputeMD5(reader)
+		return md5.New(), "MD5", nil
 	case AlgorithmSHA256:
-		return ComputeSHA256(reader)
+		return sha256.New(), "SHA256", nil
 	case AlgorithmBLAKE3:
-		return ComputeBLAKE3(reader)
+		return blake3.New(), "BLAKE3", nil
 	default:
-		return "", fmt.Errorf("unsupported algorithm: %s", algorithm)
+		return nil, "", fmt.Errorf("unsupported algorithm: %s", algorithm)
 	}
 }
 
@@ -63,12 +70,40 @@ func ComputeBLAKE3(reader io.Reader) (string, error) {
 	return hex.EncodeToString(hasher.Sum(nil)), nil
 }
 
+// bufferPool reuses default-sized read buffers across computations
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		buf := make([]byte, BufferSize)
+		return &buf
+	},
+}
+
+// ComputeWithReadSize computes a checksum reading readSize bytes at a time.
+// A readSize of 0 uses BufferSize.
+func ComputeWithReadSize(algorithm Algorithm, reader io.Reader, readSize int) (string, error) {
+	hasher, name, err := newHasher(algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	var buf []byte
+	if readSize <= 0 || readSize == BufferSize {
+		pooled := bufferPool.Get().(*[]byte)
+		defer bufferPool.Put(pooled)
+		buf = *pooled
+	} else {
+		buf = make([]byte, readSize)
+	}
+
+	// Hide any WriterTo so reads honor the buffer size
+	if _, err := io.CopyBuffer(hasher, struct{ io.Reader }{reader}, buf); err != nil {
+		return "", fmt.Errorf("failed to compute %s: %w", name, err)
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
 // ValidateAlgorithm checks if the algorithm is supported
 func ValidateAlgorithm(algorithm Algorithm) error {
-	switch algorithm {
-	case AlgorithmMD5, AlgorithmSHA256, AlgorithmBLAKE3:
-		return nil
-	default:
-		return fmt.Errorf("unsupported algorithm: %s", algorithm)
-	}
+	_, _, err := newHasher(algorithm)
+	return err
 }
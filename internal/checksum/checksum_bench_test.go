@@ -2,12 +2,14 @@ package checksum_test
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/jeffanddom/fixity/internal/checksum"
+	"github.com/jeffanddom/fixity/internal/storage"
 )
 
 // Benchmark small files (1KB)
@@ -299,3 +301,62 @@ func BenchmarkWorkerPool_8Workers(b *testing.B) {
 		}
 	}
 }
+
+// Benchmark read strategies against a real file. Normal reads leave the file
+// in the page cache, so repeated iterations mostly measure memory bandwidth;
+// drop and direct modes measure what a large scan sees on a cold cache.
+func benchmarkReadOptions(b *testing.B, opts storage.ReadOptions) {
+	tmpDir := b.TempDir()
+	tmpFile := filepath.Join(tmpDir, "benchmark.dat")
+
+	// Create 64MB test file
+	const size = 64 * 1024 * 1024
+	data := bytes.Repeat([]byte("a"), size)
+	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+		b.Fatal(err)
+	}
+
+	backend, _ := storage.NewLocalFSBackend(tmpDir)
+	backend.SetReadOptions(opts)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	b.SetBytes(size)
+
+	for i := 0; i < b.N; i++ {
+		file, err := backend.Open(ctx, "benchmark.dat")
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		_, err = checksum.ComputeWithReadSize(checksum.AlgorithmBLAKE3, file, opts.ReadSize)
+		file.Close()
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReadOptions_Normal_64KB(b *testing.B) {
+	benchmarkReadOptions(b, storage.ReadOptions{CacheMode: storage.CacheModeNormal, ReadSize: 64 * 1024})
+}
+
+func BenchmarkReadOptions_Normal_1MB(b *testing.B) {
+	benchmarkReadOptions(b, storage.ReadOptions{CacheMode: storage.CacheModeNormal, ReadSize: 1024 * 1024})
+}
+
+func BenchmarkReadOptions_Drop_1MB(b *testing.B) {
+	benchmarkReadOptions(b, storage.ReadOptions{CacheMode: storage.CacheModeDrop, ReadSize: 1024 * 1024})
+}
+
+func BenchmarkReadOptions_Drop_4MB(b *testing.B) {
+	benchmarkReadOptions(b, storage.ReadOptions{CacheMode: storage.CacheModeDrop, ReadSize: 4 * 1024 * 1024})
+}
+
+func BenchmarkReadOptions_Direct_1MB(b *testing.B) {
+	benchmarkReadOptions(b, storage.ReadOptions{CacheMode: storage.CacheModeDirect, ReadSize: 1024 * 1024})
+}
+
+func BenchmarkReadOptions_Direct_4MB(b *testing.B) {
+	benchmarkReadOptions(b, storage.ReadOptions{CacheMode: storage.CacheModeDirect, ReadSize: 4 * 1024 * 1024})
+}
@@ -2,10 +2,14 @@ package checksum_test
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"testing"
 	"time"
 
@@ -425,6 +429,239 @@ func TestWorkerPool(t *testing.T) {
 	})
 }
 
+func TestWorkerPool_After(t *testing.T) {
+	pool := checksum.NewWorkerPool(2)
+	pool.Start()
+	defer pool.Stop()
+
+	ran := map[string]bool{}
+	for _, path := range []string{"ok.txt", "missing.txt"} {
+		path := path
+		pool.Submit(&checksum.Job{
+			Path:      path,
+			Algorithm: checksum.AlgorithmMD5,
+			Opener: func() (io.ReadCloser, error) {
+				if path == "missing.txt" {
+					return nil, os.ErrNotExist
+				}
+				return io.NopCloser(strings.NewReader("archive")), nil
+			},
+			// Written on the worker, read once the result arrives
+			After: func() { ran[path] = true },
+		})
+		result := <-pool.Results()
+		if (result.Error == nil) != ran[path] {
+			t.Errorf("%s: expected After to run only after a successful hash, error %v, ran %v", path, result.Error, ran[path])
+		}
+	}
+}
+
+func TestWorkerPool_Retries(t *testing.T) {
+	t.Run("retries transient failures", func(t *testing.T) {
+		pool := checksum.NewWorkerPool(1)
+		pool.Start()
+		defer pool.Stop()
+
+		calls := 0
+		job := &checksum.Job{
+			Path:      "flaky.txt",
+			Algorithm: checksum.AlgorithmMD5,
+			Opener: func() (io.ReadCloser, error) {
+				calls++
+				if calls < 3 {
+					return nil, syscall.EIO
+				}
+				return io.NopCloser(strings.NewReader("hello world")), nil
+			},
+			Retries:      3,
+			RetryBackoff: time.Millisecond,
+		}
+
+		pool.Submit(job)
+
+		result := <-pool.Results()
+		if result.Error != nil {
+			t.Fatalf("unexpected error: %v", result.Error)
+		}
+
+		if result.Attempts != 3 {
+			t.Errorf("expected 3 attempts, got %d", result.Attempts)
+		}
+	})
+
+	t.Run("gives up after configured retries", func(t *testing.T) {
+		pool := checksum.NewWorkerPool(1)
+		pool.Start()
+		defer pool.Stop()
+
+		job := &checksum.Job{
+			Path:      "broken.txt",
+			Algorithm: checksum.AlgorithmMD5,
+			Opener: func() (io.ReadCloser, error) {
+				return nil, syscall.EIO
+			},
+			Retries:      2,
+			RetryBackoff: time.Millisecond,
+		}
+
+		pool.Submit(job)
+
+		result := <-pool.Results()
+		if result.Error == nil {
+			t.Fatal("expected error")
+		}
+
+		if result.Attempts != 3 {
+			t.Errorf("expected 3 attempts, got %d", result.Attempts)
+		}
+	})
+
+	t.Run("does not retry permanent failures", func(t *testing.T) {
+		pool := checksum.NewWorkerPool(1)
+		pool.Start()
+		defer pool.Stop()
+
+		job := &checksum.Job{
+			Path:      "missing.txt",
+			Algorithm: checksum.AlgorithmMD5,
+			Opener: func() (io.ReadCloser, error) {
+				return nil, os.ErrNotExist
+			},
+			Retries:      5,
+			RetryBackoff: time.Millisecond,
+		}
+
+		pool.Submit(job)
+
+		result := <-pool.Results()
+		if result.Attempts != 1 {
+			t.Errorf("expected 1 attempt, got %d", result.Attempts)
+		}
+	})
+}
+
+func TestAdaptiveWorkerPool(t *testing.T) {
+	t.Run("reports stats for processed jobs", func(t *testing.T) {
+		pool := checksum.NewAdaptiveWorkerPool(checksum.AdaptiveConfig{MinWorkers: 1, MaxWorkers: 4})
+		pool.Start()
+		defer pool.Stop()
+
+		for i := 0; i < 3; i++ {
+			pool.Submit(&checksum.Job{
+				Path:      fmt.Sprintf("file%d.txt", i),
+				Algorithm: checksum.AlgorithmMD5,
+				Opener: func() (io.ReadCloser, error) {
+					return io.NopCloser(strings.NewReader("hello world")), nil
+				},
+			})
+		}
+
+		for i := 0; i < 3; i++ {
+			if result := <-pool.Results(); result.Error != nil {
+				t.Fatalf("unexpected error: %v", result.Error)
+			}
+		}
+
+		stats := pool.Stats()
+		if !stats.Adaptive {
+			t.Error("expected adaptive stats")
+		}
+		if stats.FilesHashed != 3 {
+			t.Errorf("expected 3 files hashed, got %d", stats.FilesHashed)
+		}
+		if stats.BytesHashed != 33 {
+			t.Errorf("expected 33 bytes hashed, got %d", stats.BytesHashed)
+		}
+		if stats.Concurrency < 1 || stats.Concurrency > 4 {
+			t.Errorf("concurrency %d outside bounds", stats.Concurrency)
+		}
+	})
+
+	t.Run("scales up when parallelism helps", func(t *testing.T) {
+		pool := checksum.NewAdaptiveWorkerPool(checksum.AdaptiveConfig{
+			MinWorkers: 1,
+			MaxWorkers: 8,
+			Interval:   20 * time.Millisecond,
+		})
+		pool.Start()
+		defer pool.Stop()
+
+		// Latency-bound reads: more workers means proportionally more throughput
+		jobCount := 200
+		go func() {
+			for i := 0; i < jobCount; i++ {
+				pool.Submit(&checksum.Job{
+					Path:      fmt.Sprintf("file%d.txt", i),
+					Algorithm: checksum.AlgorithmMD5,
+					Opener: func() (io.ReadCloser, error) {
+						return io.NopCloser(&slowReader{
+							delay: 2 * time.Millisecond,
+							data:  bytes.Repeat([]byte("a"), 64*1024),
+						}), nil
+					},
+				})
+			}
+		}()
+
+		for i := 0; i < jobCount; i++ {
+			<-pool.Results()
+		}
+
+		stats := pool.Stats()
+		if stats.PeakConcurrency <= 1 {
+			t.Errorf("expected concurrency to grow, peak was %d", stats.PeakConcurrency)
+		}
+		if stats.PeakConcurrency > 8 {
+			t.Errorf("peak concurrency %d exceeds max", stats.PeakConcurrency)
+		}
+		if stats.BytesPerSec() <= 0 {
+			t.Error("expected non-zero throughput")
+		}
+	})
+
+	t.Run("fixed pool reports constant concurrency", func(t *testing.T) {
+		pool := checksum.NewWorkerPool(3)
+		pool.Start()
+		defer pool.Stop()
+
+		stats := pool.Stats()
+		if stats.Adaptive {
+			t.Error("expected fixed pool")
+		}
+		if stats.Concurrency != 3 || stats.MinWorkers != 3 || stats.MaxWorkers != 3 {
+			t.Errorf("unexpected fixed pool stats: %+v", stats)
+		}
+	})
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		class     checksum.ErrorClass
+		retryable bool
+	}{
+		{"not found", &os.PathError{Op: "open", Path: "x", Err: syscall.ENOENT}, checksum.ErrorClassNotFound, false},
+		{"permission denied", fmt.Errorf("failed to open file: %w", os.ErrPermission), checksum.ErrorClassPermissionDenied, false},
+		{"timeout", fmt.Errorf("timeout or cancelled: %w", context.DeadlineExceeded), checksum.ErrorClassTimeout, true},
+		{"cancelled", context.Canceled, checksum.ErrorClassCancelled, false},
+		{"io error", &os.PathError{Op: "read", Path: "x", Err: syscall.EIO}, checksum.ErrorClassIO, true},
+		{"stale nfs handle", syscall.ESTALE, checksum.ErrorClassIO, true},
+		{"unknown", errors.New("something odd"), checksum.ErrorClassUnknown, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checksum.ClassifyError(tt.err); got != tt.class {
+				t.Errorf("ClassifyError() = %s, want %s", got, tt.class)
+			}
+			if got := checksum.IsRetryable(tt.err); got != tt.retryable {
+				t.Errorf("IsRetryable() = %v, want %v", got, tt.retryable)
+			}
+		})
+	}
+}
+
 // slowReader is a test helper that delays reads
 type slowReader struct {
 	delay time.Duration
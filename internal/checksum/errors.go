@@ -0,0 +1,76 @@
+package checksum
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"syscall"
+)
+
+// ErrorClass categorizes why a checksum computation failed
+type ErrorClass string
+
+const (
+	ErrorClassNotFound         ErrorClass = "not_found"
+	ErrorClassPermissionDenied ErrorClass = "permission_denied"
+	ErrorClassTimeout          ErrorClass = "timeout"
+	ErrorClassCancelled        ErrorClass = "cancelled"
+	ErrorClassIO               ErrorClass = "io"
+	ErrorClassUnknown          ErrorClass = "unknown"
+)
+
+// transientErrnos are I/O errors that commonly clear up on their own,
+// e.g. a stale NFS handle or a dropped SMB connection
+var transientErrnos = []syscall.Errno{
+	syscall.EIO,
+	syscall.EAGAIN,
+	syscall.EINTR,
+	syscall.EBUSY,
+	syscall.ESTALE,
+	syscall.ETIMEDOUT,
+	syscall.ECONNRESET,
+	syscall.ECONNABORTED,
+	syscall.EHOSTUNREACH,
+	syscall.ENETUNREACH,
+}
+
+// ClassifyError determines the error class for a checksum failure
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return ""
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorClassTimeout
+	case errors.Is(err, context.Canceled):
+		return ErrorClassCancelled
+	case errors.Is(err, fs.ErrNotExist):
+		return ErrorClassNotFound
+	case errors.Is(err, fs.ErrPermission):
+		return ErrorClassPermissionDenied
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return ErrorClassIO
+	}
+
+	for _, errno := range transientErrnos {
+		if errors.Is(err, errno) {
+			return ErrorClassIO
+		}
+	}
+
+	return ErrorClassUnknown
+}
+
+// IsRetryable reports whether a failure is likely transient and worth retrying.
+// Missing files and permission errors will not change on retry; timeouts and
+// I/O errors often do.
+func IsRetryable(err error) bool {
+	switch ClassifyError(err) {
+	case ErrorClassTimeout, ErrorClassIO:
+		return true
+	default:
+		return false
+	}
+}
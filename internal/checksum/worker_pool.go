@@ -10,10 +10,14 @@ import (
 
 // Job represents a checksum computation job
 type Job struct {
-	Path      string
-	Algorithm Algorithm
-	Opener    func() (io.ReadCloser, error)
-	Timeout   time.Duration
+	Path         string
+	Algorithm    Algorithm
+	Opener       func() (io.ReadCloser, error)
+	Timeout      time.Duration
+	ReadSize     int           // Bytes per read while hashing; 0 uses BufferSize
+	Retries      int           // Extra attempts after a retryable failure
+	RetryBackoff time.Duration // Delay before the first retry, doubled for each subsequent one
+	After        func()        // Runs on the worker once the file has hashed, before its result is sent
 }
 
 // Result represents the result of a checksum computation
@@ -22,16 +26,62 @@ type Result struct {
 	Checksum string
 	Duration time.Duration
 	Error    error
+	Attempts int
 }
 
-// WorkerPool manages parallel checksum computation
+// WorkerPool manages parallel checksum computation.
+// A pool is either fixed-size or adaptive; an adaptive pool periodically
+// resizes itself between MinWorkers and MaxWorkers based on observed
+// throughput and per-byte latency.
 type WorkerPool struct {
-	workers int
+	minWorkers int
+	maxWorkers int
+	adaptive   bool
+	interval   time.Duration
+
 	jobs    chan *Job
 	results chan *Result
 	wg      sync.WaitGroup
 	ctx     context.Context
 	cancel  context.CancelFunc
+
+	controlStop chan struct{}
+	controlDone chan struct{}
+	started     bool
+
+	mu      sync.Mutex
+	limit   int // Target concurrency
+	running int // Live worker goroutines
+	meter   poolMeter
+}
+
+// AdaptiveConfig configures an adaptive worker pool
+type AdaptiveConfig struct {
+	MinWorkers int
+	MaxWorkers int
+	Initial    int           // Starting concurrency, defaults to MinWorkers
+	Interval   time.Duration // How often concurrency is re-evaluated, defaults to 2s
+}
+
+// PoolStats summarizes a pool's concurrency and hashing throughput
+type PoolStats struct {
+	Adaptive        bool
+	MinWorkers      int
+	MaxWorkers      int
+	Concurrency     int     // Concurrency in effect when stats were taken
+	AvgConcurrency  float64 // Time-weighted average while hashing
+	PeakConcurrency int
+	FilesHashed     int64
+	BytesHashed     int64
+	Active          time.Duration // Time from the first job starting to the last finishing
+}
+
+// BytesPerSec returns the average hashing throughput while the pool was active
+func (s PoolStats) BytesPerSec() float64 {
+	if s.Active <= 0 {
+		return 0
+	}
+	return float64(s.BytesHashed) / s.Active.Seconds()
 }
 
 // NewWorkerPool creates a new worker pool with the specified number of workers
@@ -40,35 +90,101 @@ func NewWorkerPool(workers int) *WorkerPool {
 		workers = 1
 	}
 
+	return newWorkerPool(workers, workers, workers, false, 0)
+}
+
+// NewAdaptiveWorkerPool creates a worker pool that adjusts its concurrency
+// at runtime (additive increase, multiplicative decrease)
+func NewAdaptiveWorkerPool(cfg AdaptiveConfig) *WorkerPool {
+	if cfg.MinWorkers <= 0 {
+		cfg.MinWorkers = 1
+	}
+	if cfg.MaxWorkers < cfg.MinWorkers {
+		cfg.MaxWorkers = cfg.MinWorkers
+	}
+	if cfg.Initial < cfg.MinWorkers {
+		cfg.Initial = cfg.MinWorkers
+	}
+	if cfg.Initial > cfg.MaxWorkers {
+		cfg.Initial = cfg.MaxWorkers
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 2 * time.Second
+	}
+
+	return newWorkerPool(cfg.Initial, cfg.MinWorkers, cfg.MaxWorkers, true, cfg.Interval)
+}
+
+func newWorkerPool(initial, minWorkers, maxWorkers int, adaptive bool, interval time.Duration) *WorkerPool {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	return &WorkerPool{
-		workers: workers,
-		jobs:    make(chan *Job, workers*2), // Buffer to prevent blocking
-		results: make(chan *Result, workers*2),
-		ctx:     ctx,
-		cancel:  cancel,
+		minWorkers:  minWorkers,
+		maxWorkers:  maxWorkers,
+		adaptive:    adaptive,
+		interval:    interval,
+		jobs:        make(chan *Job, maxWorkers*2), // Buffer to prevent blocking
+		results:     make(chan *Result, maxWorkers*2),
+		ctx:         ctx,
+		cancel:      cancel,
+		controlStop: make(chan struct{}),
+		controlDone: make(chan struct{}),
+		limit:       initial,
 	}
 }
 
 // Start starts the worker pool
 func (p *WorkerPool) Start() {
-	for i := 0; i < p.workers; i++ {
+	p.mu.Lock()
+	p.meter.peak = p.limit
+	p.spawnLocked()
+	p.mu.Unlock()
+
+	if p.adaptive {
+		go p.control()
+	} else {
+		close(p.controlDone)
+	}
+	p.started = true
+}
+
+// spawnLocked starts workers until the running count reaches the limit
+func (p *WorkerPool) spawnLocked() {
+	for p.running < p.limit {
+		p.running++
 		p.wg.Add(1)
 		go p.worker()
 	}
 }
 
+// retire reports whether the calling worker should exit because the pool shrank
+func (p *WorkerPool) retire() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.running > p.limit {
+		p.running--
+		return true
+	}
+	return false
+}
+
 // worker processes jobs from the jobs channel
 func (p *WorkerPool) worker() {
 	defer p.wg.Done()
 
 	for {
+		if p.retire() {
+			return
+		}
+
 		select {
 		case <-p.ctx.Done():
+			p.exit()
 			return
 		case job, ok := <-p.jobs:
 			if !ok {
+				p.exit()
 				return
 			}
 			p.processJob(job)
@@ -76,7 +192,75 @@ func (p *WorkerPool) worker() {
 	}
 }
 
-// processJob computes checksum for a single job
+// exit records that a worker stopped without being retired
+func (p *WorkerPool) exit() {
+	p.mu.Lock()
+	p.running--
+	p.mu.Unlock()
+}
+
+// control periodically resizes an adaptive pool
+func (p *WorkerPool) control() {
+	defer close(p.controlDone)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	ctl := &aimd{min: p.minWorkers, max: p.maxWorkers}
+	for {
+		select {
+		case <-p.controlStop:
+			return
+		case <-p.ctx.Done():
+			return
+		case now := <-ticker.C:
+			p.mu.Lock()
+			sample := p.meter.takeSample(now)
+			if next := ctl.next(p.limit, sample); next != p.limit {
+				p.setLimitLocked(next, now)
+			}
+			p.mu.Unlock()
+		}
+	}
+}
+
+// setLimitLocked changes the target concurrency, starting workers if it grew.
+// Workers above a lowered limit exit after their current job.
+func (p *WorkerPool) setLimitLocked(limit int, now time.Time) {
+	p.meter.accumulate(p.limit, now)
+	p.limit = limit
+	if limit > p.meter.peak {
+		p.meter.peak = limit
+	}
+	p.spawnLocked()
+}
+
+// Stats returns concurrency and throughput statistics for the pool
+func (p *WorkerPool) Stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return PoolStats{
+		Adaptive:        p.adaptive,
+		MinWorkers:      p.minWorkers,
+		MaxWorkers:      p.maxWorkers,
+		Concurrency:     p.limit,
+		AvgConcurrency:  p.meter.average(p.limit),
+		PeakConcurrency: p.meter.peak,
+		FilesHashed:     p.meter.files,
+		BytesHashed:     p.meter.bytes,
+		Active:          p.meter.active(),
+	}
+}
+
+// recordJob accounts a finished job in the pool's meter
+func (p *WorkerPool) recordJob(start, end time.Time, bytes int64) {
+	p.mu.Lock()
+	p.meter.record(start, end, bytes)
+	p.mu.Unlock()
+}
+
+// processJob computes checksum for a single job, retrying transient failures
 func (p *WorkerPool) processJob(job *Job) {
 	start := time.Now()
 
@@ -84,6 +268,48 @@ func (p *WorkerPool) processJob(job *Job) {
 		Path: job.Path,
 	}
 
+	var bytesRead int64
+	defer func() {
+		p.recordJob(start, time.Now(), bytesRead)
+	}()
+
+	backoff := job.RetryBackoff
+	for {
+		result.Attempts++
+		var n int64
+		result.Checksum, n, result.Error = p.computeOnce(job)
+		bytesRead += n
+
+		if result.Error == nil || result.Attempts > job.Retries || !IsRetryable(result.Error) {
+			break
+		}
+
+		// Wait before retrying, giving up if the pool is stopped
+		if backoff > 0 {
+			timer := time.NewTimer(backoff)
+			select {
+			case <-p.ctx.Done():
+				timer.Stop()
+				result.Duration = time.Since(start)
+				p.sendResult(result)
+				return
+			case <-timer.C:
+			}
+			backoff *= 2
+		}
+	}
+
+	if result.Error == nil && job.After != nil {
+		job.After()
+	}
+
+	result.Duration = time.Since(start)
+	p.sendResult(result)
+}
+
+// computeOnce makes a single attempt at opening and hashing the job's file,
+// returning the checksum and the number of bytes read
+func (p *WorkerPool) computeOnce(job *Job) (string, int64, error) {
 	// Create context with timeout if specified
 	ctx := p.ctx
 	if job.Timeout > 0 {
@@ -95,10 +321,7 @@ func (p *WorkerPool) processJob(job *Job) {
 	// Open the file
 	reader, err := job.Opener()
 	if err != nil {
-		result.Error = fmt.Errorf("failed to open file: %w", err)
-		result.Duration = time.Since(start)
-		p.sendResult(result)
-		return
+		return "", 0, fmt.Errorf("failed to open file: %w", err)
 	}
 	defer reader.Close()
 
@@ -109,19 +332,15 @@ func (p *WorkerPool) processJob(job *Job) {
 	}
 
 	// Compute checksum
-	checksum, err := Compute(job.Algorithm, ctxReader)
+	checksum, err := ComputeWithReadSize(job.Algorithm, ctxReader, job.ReadSize)
 	if err != nil {
 		if ctx.Err() != nil {
-			result.Error = fmt.Errorf("timeout or cancelled: %w", ctx.Err())
-		} else {
-			result.Error = err
+			return "", ctxReader.n, fmt.Errorf("timeout or cancelled: %w", ctx.Err())
 		}
-	} else {
-		result.Checksum = checksum
+		return "", ctxReader.n, err
 	}
 
-	result.Duration = time.Since(start)
-	p.sendResult(result)
+	return checksum, ctxReader.n, nil
 }
 
 // sendResult sends a result to the results channel
@@ -150,6 +369,12 @@ func (p *WorkerPool) Results() <-chan *Result {
 
 // Stop stops the worker pool and waits for all workers to finish
 func (p *WorkerPool) Stop() {
+	// Stop resizing first so no workers are started while waiting
+	close(p.controlStop)
+	if p.started {
+		<-p.controlDone
+	}
+
 	close(p.jobs)
 	p.wg.Wait()
 	close(p.results)
@@ -157,9 +382,11 @@ func (p *WorkerPool) Stop() {
 }
 
 // contextReader wraps an io.Reader to respect context cancellation
+// and counts the bytes read
 type contextReader struct {
 	ctx    context.Context
 	reader io.Reader
+	n      int64
 }
 
 // Read implements io.Reader with context cancellation support
@@ -172,5 +399,7 @@ func (r *contextReader) Read(p []byte) (int, error) {
 	}
 
 	// Perform the read
-	return r.reader.Read(p)
+	n, err := r.reader.Read(p)
+	r.n += int64(n)
+	return n, err
 }
@@ -4,13 +4,17 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+
+	"github.com/jeffanddom/fixity/internal/throttle"
 )
 
 // Config holds application configuration
 type Config struct {
-	Database DatabaseConfig
-	Server   ServerConfig
-	Scanner  ScannerConfig
+	Database  DatabaseConfig
+	Server    ServerConfig
+	Scanner   ScannerConfig
+	Signing   SigningConfig
+	Timestamp TimestampConfig
 }
 
 // DatabaseConfig holds database connection settings
@@ -28,6 +32,19 @@ type ServerConfig struct {
 // ScannerConfig holds scanner settings
 type ScannerConfig struct {
 	MaxConcurrentScans int
+	Throttle           throttle.Schedule // Global read throttle shared by all scans
+}
+
+// SigningConfig holds the key reports and attestations are signed with
+type SigningConfig struct {
+	KeyFile string // PEM Ed25519 private key, generated on first use if missing
+}
+
+// TimestampConfig holds the RFC 3161 Time Stamping Authority scans are
+// time-stamped by
+type TimestampConfig struct {
+	URL    string // TSA endpoint; scans are not time-stamped if empty
+	CAFile string // PEM roots to trust for the TSA; the system's if empty
 }
 
 // Load loads configuration from environment variables
@@ -44,6 +61,30 @@ func Load() (*Config, error) {
 		Scanner: ScannerConfig{
 			MaxConcurrentScans: getEnvInt("MAX_CONCURRENT_SCANS", 5),
 		},
+		Signing: SigningConfig{
+			KeyFile: getEnv("SIGNING_KEY_FILE", "fixity-signing.key"),
+		},
+		Timestamp: TimestampConfig{
+			URL:    getEnv("TSA_URL", ""),
+			CAFile: getEnv("TSA_CA_FILE", ""),
+		},
+	}
+
+	// Global throughput throttle
+	throttleRate, err := throttle.ParseRate(getEnv("THROTTLE_BYTES_PER_SEC", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid THROTTLE_BYTES_PER_SEC: %w", err)
+	}
+	throttleWindows, err := throttle.ParseWindows(getEnv("THROTTLE_SCHEDULE", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid THROTTLE_SCHEDULE: %w", err)
+	}
+	cfg.Scanner.Throttle = throttle.Schedule{
+		Default: throttle.Limits{
+			BytesPerSec: throttleRate,
+			IOPS:        getEnvInt("THROTTLE_IOPS", 0),
+		},
+		Windows: throttleWindows,
 	}
 
 	// Validate required config
@@ -2,14 +2,32 @@ package coordinator
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
+	"github.com/jeffanddom/fixity/internal/audit"
 	"github.com/jeffanddom/fixity/internal/checksum"
 	"github.com/jeffanddom/fixity/internal/database"
+	"github.com/jeffanddom/fixity/internal/replica"
 	"github.com/jeffanddom/fixity/internal/scanner"
+	"github.com/jeffanddom/fixity/internal/signing"
 	"github.com/jeffanddom/fixity/internal/storage"
+	"github.com/jeffanddom/fixity/internal/throttle"
+	"github.com/jeffanddom/fixity/internal/timestamp"
+)
+
+var (
+	// ErrScanRunning is returned when a target already has a scan running
+	ErrScanRunning = errors.New("scan already running")
+
+	// ErrScanLimitReached is returned when MaxConcurrentScans scans are running
+	ErrScanLimitReached = errors.New("concurrent scan limit reached")
+
+	// ErrComparisonRunning is returned when a replica group already has a
+	// comparison running
+	ErrComparisonRunning = errors.New("comparison already running")
 )
 
 // Coordinator orchestrates scans across multiple storage targets
@@ -18,11 +36,21 @@ type Coordinator struct {
 	maxConcurrentSans int
 	mu                sync.Mutex
 	runningScans      map[int64]context.CancelFunc // targetID -> cancel function
+	globalLimiter     *throttle.Limiter            // Shared by all scans
+	targetLimiters    map[int64]*throttle.Limiter  // targetID -> per-target limiter of running scans
+	watchCtx          context.Context              // Set by StartWatching
+	watchers          map[int64]*targetWatch       // targetID -> filesystem watcher
+	comparing         map[int64]bool               // replica groupID -> comparison running
+	signingKey        *signing.Key                 // Signs audit checkpoints; nil writes none
+	tsa               *timestamp.Client            // Time-stamps completed scans; nil skips them
 }
 
 // Config holds coordinator configuration
 type Config struct {
-	MaxConcurrentScans int // Maximum number of concurrent scans
+	MaxConcurrentScans int               // Maximum number of concurrent scans
+	Throttle           throttle.Schedule // Global read throttle shared by all scans
+	SigningKey         *signing.Key      // Signs an audit checkpoint after each scan, if set
+	TSA                *timestamp.Client // Time-stamps each completed scan's checksums, if set
 }
 
 // ScanRequest represents a request to scan a storage target
@@ -39,6 +67,7 @@ type ScanStatus struct {
 	StartedAt   time.Time
 	CompletedAt *time.Time
 	Progress    ScanProgress
+	Throttle    throttle.Limits // Effective read throttle right now
 }
 
 // ScanProgress tracks scan progress
@@ -57,15 +86,55 @@ func NewCoordinator(db *database.Database, config Config) *Coordinator {
 		config.MaxConcurrentScans = 3 // Default: 3 concurrent scans
 	}
 
+	var globalLimiter *throttle.Limiter
+	if !config.Throttle.Unlimited() {
+		globalLimiter = throttle.NewLimiter(config.Throttle)
+	}
+
 	return &Coordinator{
 		db:                db,
 		maxConcurrentSans: config.MaxConcurrentScans,
 		runningScans:      make(map[int64]context.CancelFunc),
+		globalLimiter:     globalLimiter,
+		targetLimiters:    make(map[int64]*throttle.Limiter),
+		watchers:          make(map[int64]*targetWatch),
+		comparing:         make(map[int64]bool),
+		signingKey:        config.SigningKey,
+		tsa:               config.TSA,
 	}
 }
 
 // ScanTarget triggers a scan for a specific storage target
 func (c *Coordinator) ScanTarget(ctx context.Context, targetID int64) (*scanner.ScanResult, error) {
+	return c.runTarget(ctx, targetID, func(ctx context.Context, engine *scanner.Engine, backend storage.StorageBackend) (*scanner.ScanResult, error) {
+		result, err := engine.Scan(ctx, targetID, backend)
+		if err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		return result, nil
+	})
+}
+
+// VerifyTarget triggers a verification-only scan for a storage target, which
+// re-hashes known files within budget without walking the tree
+func (c *Coordinator) VerifyTarget(ctx context.Context, targetID int64, budget scanner.VerifyBudget) (*scanner.ScanResult, error) {
+	return c.runTarget(ctx, targetID, func(ctx context.Context, engine *scanner.Engine, backend storage.StorageBackend) (*scanner.ScanResult, error) {
+		result, err := engine.Verify(ctx, targetID, backend, budget)
+		if err != nil {
+			return nil, fmt.Errorf("verification failed: %w", err)
+		}
+		return result, nil
+	})
+}
+
+// runTarget registers a running scan for a target, builds its throttled
+// backend and scanner, and runs fn with them. Only one scan of either type
+// runs per target at a time.
+func (c *Coordinator) runTarget(
+	ctx context.Context,
+	targetID int64,
+	fn func(ctx context.Context, engine *scanner.Engine, backend storage.StorageBackend) (*scanner.ScanResult, error),
+) (*scanner.ScanResult, error) {
 	// Load target configuration
 	target, err := c.db.StorageTargets.GetByID(ctx, targetID)
 	if err != nil {
@@ -80,13 +149,13 @@ func (c *Coordinator) ScanTarget(ctx context.Context, targetID int64) (*scanner.
 	c.mu.Lock()
 	if _, running := c.runningScans[targetID]; running {
 		c.mu.Unlock()
-		return nil, fmt.Errorf("scan already running for target %d", targetID)
+		return nil, fmt.Errorf("%w for target %d", ErrScanRunning, targetID)
 	}
 
 	// Check concurrent scan limit
 	if len(c.runningScans) >= c.maxConcurrentSans {
 		c.mu.Unlock()
-		return nil, fmt.Errorf("concurrent scan limit reached (%d)", c.maxConcurrentSans)
+		return nil, fmt.Errorf("%w (%d)", ErrScanLimitReached, c.maxConcurrentSans)
 	}
 
 	// Register scan
@@ -98,34 +167,121 @@ func (c *Coordinator) ScanTarget(ctx context.Context, targetID int64) (*scanner.
 	defer func() {
 		c.mu.Lock()
 		delete(c.runningScans, targetID)
+		delete(c.targetLimiters, targetID)
 		c.mu.Unlock()
 	}()
 
 	// Create storage backend
-	backend, err := c.createBackend(target)
+	backend, err := TargetBackend(target)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create storage backend: %w", err)
 	}
+	defer backend.Close()
+
+	// Apply global and per-target read throttling
+	targetLimiter, err := TargetLimiter(target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid throttle configuration: %w", err)
+	}
+	c.mu.Lock()
+	c.targetLimiters[targetID] = targetLimiter
+	c.mu.Unlock()
+	backend = storage.NewThrottledBackend(backend, c.globalLimiter, targetLimiter)
+
+	// Apply path rules outermost so the scanner can see which paths are excluded
+	backend, err = storage.NewFilteredBackend(backend, TargetPathRules(target))
+	if err != nil {
+		return nil, fmt.Errorf("invalid path rules: %w", err)
+	}
 
 	// Create scanner with target-specific configuration
 	scannerConfig := scanner.Config{
 		ChecksumAlgorithm:   checksum.Algorithm(target.ChecksumAlgorithm),
 		ParallelWorkers:     target.ParallelWorkers,
+		AdaptiveWorkers:     target.AdaptiveWorkers,
 		RandomSamplePercent: target.RandomSamplePercent,
 		CheckpointInterval:  target.CheckpointInterval,
 		BatchSize:           target.BatchSize,
 		FileTimeout:         5 * time.Minute,
+		ScanArchives:        target.ScanArchives,
+		ValidateBags:        target.ValidateBags,
+	}
+
+	if target.MinWorkers != nil {
+		scannerConfig.MinWorkers = *target.MinWorkers
+	}
+	if target.MaxWorkers != nil {
+		scannerConfig.MaxWorkers = *target.MaxWorkers
+	}
+	if target.ReadSize != nil {
+		scannerConfig.ReadSize = *target.ReadSize
 	}
 
 	engine := scanner.NewEngine(c.db, scannerConfig)
 
-	// Execute scan
-	result, err := engine.Scan(scanCtx, targetID, backend)
-	if err != nil {
-		return nil, fmt.Errorf("scan failed: %w", err)
+	result, err := fn(scanCtx, engine, backend)
+	if result != nil && c.signingKey != nil {
+		c.writeCheckpoint(ctx, targetID, result)
 	}
+	if result != nil && c.tsa != nil {
+		c.stampScan(ctx, result)
+	}
+	return result, err
+}
 
-	return result, nil
+// writeCheckpoint signs a checkpoint of the target's records after a scan,
+// while no other scan of it can start. A failure, or checksums changed without
+// an event, is recorded as a scan error.
+func (c *Coordinator) writeCheckpoint(ctx context.Context, targetID int64, result *scanner.ScanResult) {
+	if _, err := audit.WriteCheckpoint(ctx, c.db, c.signingKey, targetID); err != nil {
+		msg := fmt.Sprintf("failed to write audit checkpoint: %v", err)
+		if errors.Is(err, audit.ErrUnexplainedChange) {
+			msg = fmt.Sprintf("audit checkpoint: %v", err)
+		}
+		result.Errors = append(result.Errors, msg)
+		result.ErrorsCount++
+		c.db.Scans.AddError(ctx, result.ScanID, msg)
+	}
+}
+
+// stampScan has the TSA time-stamp the target's checksums after a completed
+// scan, while no other scan of it can start. A failure is recorded as a scan
+// error; the Merkle root is kept so it can be time-stamped later.
+func (c *Coordinator) stampScan(ctx context.Context, result *scanner.ScanResult) {
+	scan, err := c.db.Scans.GetByID(ctx, result.ScanID)
+	if err != nil || scan.Status != database.ScanStatusCompleted {
+		return
+	}
+	if _, err := timestamp.StampScan(ctx, c.db, c.tsa, scan); err != nil {
+		msg := fmt.Sprintf("failed to time-stamp scan: %v", err)
+		result.Errors = append(result.Errors, msg)
+		result.ErrorsCount++
+		c.db.Scans.AddError(ctx, result.ScanID, msg)
+	}
+}
+
+// CompareReplicas compares the files of a replica group's targets. Only one
+// comparison runs per group at a time.
+func (c *Coordinator) CompareReplicas(ctx context.Context, groupID int64) (*database.ReplicaComparison, error) {
+	c.mu.Lock()
+	if c.comparing[groupID] {
+		c.mu.Unlock()
+		return nil, ErrComparisonRunning
+	}
+	c.comparing[groupID] = true
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.comparing, groupID)
+		c.mu.Unlock()
+	}()
+
+	comparison, err := replica.NewComparer(c.db).Compare(ctx, groupID)
+	if err != nil {
+		return comparison, fmt.Errorf("replica comparison failed: %w", err)
+	}
+	return comparison, nil
 }
 
 // CancelScan cancels a running scan
@@ -187,12 +343,71 @@ func (c *Coordinator) GetRunningSans(ctx context.Context) ([]*ScanStatus, error)
 				FilesVerified: scan.FilesVerified,
 				ErrorsCount:   scan.ErrorsCount,
 			},
+			Throttle: c.CurrentThrottle(targetID),
 		})
 	}
 
 	return statuses, nil
 }
 
+// CurrentThrottle returns the read throttle currently applied to a target's scans,
+// combining the global limit with the target's own limit if a scan is running
+func (c *Coordinator) CurrentThrottle(targetID int64) throttle.Limits {
+	c.mu.Lock()
+	targetLimiter := c.targetLimiters[targetID]
+	c.mu.Unlock()
+
+	return throttle.Combine(c.globalLimiter.Current(), targetLimiter.Current())
+}
+
+// GlobalThrottle returns the global read throttle schedule
+func (c *Coordinator) GlobalThrottle() throttle.Schedule {
+	return c.globalLimiter.Schedule()
+}
+
+// TargetLimiter builds the per-target limiter from a storage target's throttle settings.
+// Returns nil if the target is not throttled.
+func TargetLimiter(target *database.StorageTarget) (*throttle.Limiter, error) {
+	schedule, err := TargetSchedule(target)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.Unlimited() {
+		return nil, nil
+	}
+	return throttle.NewLimiter(schedule), nil
+}
+
+// TargetSchedule returns the throttle schedule configured on a storage target
+func TargetSchedule(target *database.StorageTarget) (throttle.Schedule, error) {
+	var schedule throttle.Schedule
+
+	if target.ThrottleBytesPerSec != nil {
+		schedule.Default.BytesPerSec = *target.ThrottleBytesPerSec
+	}
+	if target.ThrottleIOPS != nil {
+		schedule.Default.IOPS = *target.ThrottleIOPS
+	}
+	if target.ThrottleSchedule != nil {
+		windows, err := throttle.ParseWindows(*target.ThrottleSchedule)
+		if err != nil {
+			return throttle.Schedule{}, err
+		}
+		schedule.Windows = windows
+	}
+
+	return schedule, nil
+}
+
+// TargetPathRules returns the include/exclude rules configured on a storage target
+func TargetPathRules(target *database.StorageTarget) storage.PathRules {
+	return storage.PathRules{
+		Include:     target.IncludePatterns,
+		Exclude:     target.ExcludePatterns,
+		IgnoreFiles: target.HonorIgnoreFiles,
+	}
+}
+
 // ScanAll triggers scans for all enabled storage targets (respecting concurrency limits)
 func (c *Coordinator) ScanAll(ctx context.Context) ([]*scanner.ScanResult, []error) {
 	// Get all enabled targets
@@ -235,8 +450,9 @@ func (c *Coordinator) ScanAll(ctx context.Context) ([]*scanner.ScanResult, []err
 	return results, errors
 }
 
-// createBackend creates a storage backend for the given target
-func (c *Coordinator) createBackend(target *database.StorageTarget) (storage.StorageBackend, error) {
+// TargetBackend creates the storage backend for a storage target, without
+// throttling or path rules
+func TargetBackend(target *database.StorageTarget) (storage.StorageBackend, error) {
 	// Convert database.StorageType to storage.StorageType
 	var storageType storage.StorageType
 	switch target.Type {
@@ -246,10 +462,24 @@ func (c *Coordinator) createBackend(target *database.StorageTarget) (storage.Sto
 		storageType = storage.TypeNFS
 	case database.StorageTypeSMB:
 		storageType = storage.TypeSMB
+	case database.StorageTypeSFTP:
+		storageType = storage.TypeSFTP
+	case database.StorageTypeWebDAV:
+		storageType = storage.TypeWebDAV
 	default:
 		return nil, fmt.Errorf("unsupported storage type: %s", target.Type)
 	}
 
+	cacheMode, err := storage.ParseCacheMode(target.ReadCacheMode)
+	if err != nil {
+		return nil, err
+	}
+
+	linkPolicy, err := storage.ParseLinkPolicy(target.LinkPolicy)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create backend configuration
 	config := storage.BackendConfig{
 		Type:     storageType,
@@ -257,6 +487,21 @@ func (c *Coordinator) createBackend(target *database.StorageTarget) (storage.Sto
 		Server:   target.Server,
 		Share:    target.Share,
 		CredsRef: target.CredentialsRef,
+		ReadOptions: storage.ReadOptions{
+			CacheMode: cacheMode,
+		},
+		LinkPolicy:    linkPolicy,
+		CaptureXattrs: target.CaptureXattrs,
+	}
+
+	if target.ReadSize != nil {
+		config.ReadOptions.ReadSize = *target.ReadSize
+	}
+	if target.WalkConcurrency != nil {
+		config.WalkConcurrency = *target.WalkConcurrency
+	}
+	if target.HostKey != nil {
+		config.HostKey = *target.HostKey
 	}
 
 	return storage.NewBackend(config)
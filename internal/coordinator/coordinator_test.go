@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/jeffanddom/fixity/internal/coordinator"
 	"github.com/jeffanddom/fixity/internal/database"
@@ -114,6 +115,94 @@ func TestCoordinator_ScanTarget(t *testing.T) {
 		}
 	})
 
+	t.Run("closes the SFTP connections it opened", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+			createTestFile(t, filepath.Join(tmpDir, name), name)
+		}
+
+		server := testutil.NewSFTPServer(t, "hunter2", nil)
+		t.Setenv("FIXITY_TEST_SFTP_PASSWORD", "hunter2")
+		addr, creds, hostKey := "fixity@"+server.Addr, "env:FIXITY_TEST_SFTP_PASSWORD", server.Pin()
+		target := &database.StorageTarget{
+			Name:                "sftp-target",
+			Type:                database.StorageTypeSFTP,
+			Path:                tmpDir,
+			Server:              &addr,
+			CredentialsRef:      &creds,
+			HostKey:             &hostKey,
+			Enabled:             true,
+			ParallelWorkers:     4,
+			RandomSamplePercent: 1.0,
+			ChecksumAlgorithm:   "sha256",
+			CheckpointInterval:  1000,
+			BatchSize:           1000,
+		}
+		if err := db.StorageTargets.Create(context.Background(), target); err != nil {
+			t.Fatalf("failed to create target: %v", err)
+		}
+
+		coord := coordinator.NewCoordinator(db, coordinator.Config{})
+		result, err := coord.ScanTarget(context.Background(), target.ID)
+		if err != nil {
+			t.Fatalf("scan failed: %v", err)
+		}
+		if result.FilesScanned != 3 || server.Accepted() == 0 {
+			t.Fatalf("expected 3 files scanned over SFTP, got %d over %d connections", result.FilesScanned, server.Accepted())
+		}
+
+		// The server sees the client hang up asynchronously
+		deadline := time.Now().Add(5 * time.Second)
+		for server.Open() > 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if open := server.Open(); open != 0 {
+			t.Errorf("expected the scan to close its connections, %d still open", open)
+		}
+	})
+
+	t.Run("walks SFTP targets set to skip unchanged directories in full", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		createTestFile(t, filepath.Join(tmpDir, "a.txt"), "a")
+		createTestFile(t, filepath.Join(tmpDir, "b.log"), "b")
+
+		server := testutil.NewSFTPServer(t, "hunter2", nil)
+		t.Setenv("FIXITY_TEST_SFTP_PASSWORD", "hunter2")
+		addr, creds, hostKey := "fixity@"+server.Addr, "env:FIXITY_TEST_SFTP_PASSWORD", server.Pin()
+		iops := 1000
+		target := &database.StorageTarget{
+			Name:                "sftp-skip-target",
+			Type:                database.StorageTypeSFTP,
+			Path:                tmpDir,
+			Server:              &addr,
+			CredentialsRef:      &creds,
+			HostKey:             &hostKey,
+			Enabled:             true,
+			ParallelWorkers:     2,
+			RandomSamplePercent: 1.0,
+			ChecksumAlgorithm:   "sha256",
+			CheckpointInterval:  1000,
+			BatchSize:           1000,
+			SkipUnchangedDirs:   true,
+			ThrottleIOPS:        &iops,
+			ExcludePatterns:     []string{"*.log"},
+		}
+		if err := db.StorageTargets.Create(context.Background(), target); err != nil {
+			t.Fatalf("failed to create target: %v", err)
+		}
+
+		coord := coordinator.NewCoordinator(db, coordinator.Config{})
+		for i := 0; i < 2; i++ {
+			result, err := coord.ScanTarget(context.Background(), target.ID)
+			if err != nil {
+				t.Fatalf("scan %d failed: %v", i+1, err)
+			}
+			if result.FilesScanned != 1 || result.DirsSkipped != 0 {
+				t.Errorf("scan %d: expected 1 file scanned and no directories skipped, got %d and %d", i+1, result.FilesScanned, result.DirsSkipped)
+			}
+		}
+	})
+
 	// Note: Tests for duplicate scans and concurrent limits are timing-sensitive
 	// and flaky with fast modern CPUs. These scenarios are better tested via
 	// integration tests or with mocked backends that provide controlled delays.
@@ -258,6 +347,137 @@ func TestCoordinator_ScanAll(t *testing.T) {
 }
 
 // Helper function
+func TestCoordinator_Watch(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupDB(t, db)
+
+	tmpDir := t.TempDir()
+	createTestFile(t, filepath.Join(tmpDir, "existing.txt"), "content")
+
+	target := &database.StorageTarget{
+		Name:                "watched-target",
+		Type:                database.StorageTypeLocal,
+		Path:                tmpDir,
+		Enabled:             true,
+		ParallelWorkers:     1,
+		RandomSamplePercent: 1.0,
+		ChecksumAlgorithm:   "md5",
+		CheckpointInterval:  1000,
+		BatchSize:           1000,
+		WatchChanges:        true,
+	}
+	if err := db.StorageTargets.Create(context.Background(), target); err != nil {
+		t.Fatalf("failed to create target: %v", err)
+	}
+
+	coord := coordinator.NewCoordinator(db, coordinator.Config{})
+	if _, ok := coord.WatchStatus(target.ID); ok {
+		t.Error("expected no watcher before StartWatching")
+	}
+
+	if _, err := coord.ScanTarget(context.Background(), target.ID); err != nil {
+		t.Fatalf("initial scan failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := coord.StartWatching(ctx); err != nil {
+		t.Fatalf("failed to start watching: %v", err)
+	}
+	if status, ok := coord.WatchStatus(target.ID); !ok || !status.Active {
+		t.Fatalf("expected an active watcher, got %+v", status)
+	}
+
+	// Give the watcher time to place its watches
+	time.Sleep(200 * time.Millisecond)
+	createTestFile(t, filepath.Join(tmpDir, "new.txt"), "new content")
+
+	incremental := database.ScanTypeIncremental
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		scans, _ := db.Scans.List(context.Background(), database.ScanFilters{
+			StorageTargetID: &target.ID,
+			ScanType:        &incremental,
+		})
+		if len(scans) > 0 && scans[0].CompletedAt != nil {
+			if scans[0].FilesAdded != 1 {
+				t.Errorf("expected the incremental scan to add 1 file, got %d", scans[0].FilesAdded)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for an incremental scan")
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+
+	// Turning watching off stops the watcher
+	target.WatchChanges = false
+	if err := db.StorageTargets.Update(context.Background(), target); err != nil {
+		t.Fatalf("failed to update target: %v", err)
+	}
+	if err := coord.RefreshWatchers(context.Background()); err != nil {
+		t.Fatalf("failed to refresh watchers: %v", err)
+	}
+	if _, ok := coord.WatchStatus(target.ID); ok {
+		t.Error("expected the watcher to be stopped")
+	}
+}
+
+func TestCoordinator_WatchRestart(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupDB(t, db)
+
+	// The watcher fails while the target's directory is missing
+	root := filepath.Join(t.TempDir(), "share")
+	target := &database.StorageTarget{
+		Name:                "restarted-target",
+		Type:                database.StorageTypeLocal,
+		Path:                root,
+		Enabled:             true,
+		ParallelWorkers:     1,
+		RandomSamplePercent: 1.0,
+		ChecksumAlgorithm:   "md5",
+		CheckpointInterval:  1000,
+		BatchSize:           1000,
+		WatchChanges:        true,
+	}
+	if err := db.StorageTargets.Create(context.Background(), target); err != nil {
+		t.Fatalf("failed to create target: %v", err)
+	}
+
+	coord := coordinator.NewCoordinator(db, coordinator.Config{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := coord.StartWatching(ctx); err != nil {
+		t.Fatalf("failed to start watching: %v", err)
+	}
+
+	waitFor := func(what string, ok func(coordinator.WatchStatus) bool) {
+		t.Helper()
+		deadline := time.Now().Add(30 * time.Second)
+		for {
+			if status, found := coord.WatchStatus(target.ID); found && ok(status) {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s", what)
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+	waitFor("the watcher to fail", func(s coordinator.WatchStatus) bool { return !s.Active && s.Err != nil })
+
+	// Once the directory is back the watcher is restarted without a refresh
+	// from a target change
+	if err := os.Mkdir(root, 0755); err != nil {
+		t.Fatalf("failed to create target directory: %v", err)
+	}
+	waitFor("the watcher to restart", func(s coordinator.WatchStatus) bool { return s.Active })
+}
+
 func createTestFile(t *testing.T, path string, content string) {
 	t.Helper()
 	err := os.WriteFile(path, []byte(content), 0644)
@@ -0,0 +1,309 @@
+package coordinator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jeffanddom/fixity/internal/database"
+	"github.com/jeffanddom/fixity/internal/scanner"
+	"github.com/jeffanddom/fixity/internal/storage"
+)
+
+const (
+	// watchSettleDelay is how long a watched target must be quiet before its
+	// queued paths are scanned, so a burst of writes is scanned once
+	watchSettleDelay = 5 * time.Second
+
+	// watchMaxDelay bounds how long queued paths wait while a target is
+	// continuously busy
+	watchMaxDelay = time.Minute
+
+	// watchRetryDelay is how long queued paths wait when the target is
+	// already being scanned or the scan limit is reached
+	watchRetryDelay = 30 * time.Second
+
+	// watchRestartDelay is how long a stopped watcher waits before it is
+	// restarted. The delay doubles with each failure in a row, up to
+	// watchMaxRestartDelay.
+	watchRestartDelay    = 5 * time.Second
+	watchMaxRestartDelay = 30 * time.Minute
+
+	// watchRefreshInterval is how often stopped watchers are checked for a
+	// restart
+	watchRefreshInterval = 5 * time.Second
+)
+
+// targetWatch tracks the watcher of one target
+type targetWatch struct {
+	cancel    context.CancelFunc
+	updatedAt time.Time // Target configuration the watcher was started with
+	startedAt time.Time
+	err       error // Why the watcher stopped, or the last scan failure
+	stopped   bool
+	done      bool      // watchTarget has returned
+	failures  int       // Times in a row the watcher has stopped
+	restartAt time.Time // When a stopped watcher may be restarted
+}
+
+// WatchStatus describes a target's filesystem watcher
+type WatchStatus struct {
+	Active bool  // The watcher is running
+	Err    error // Why the watcher stopped, or the last scan it could not run
+}
+
+// ScanPaths triggers an incremental scan of the given paths of a target
+func (c *Coordinator) ScanPaths(ctx context.Context, targetID int64, paths []string) (*scanner.ScanResult, error) {
+	return c.runTarget(ctx, targetID, func(ctx context.Context, engine *scanner.Engine, backend storage.StorageBackend) (*scanner.ScanResult, error) {
+		result, err := engine.ScanPaths(ctx, targetID, backend, paths)
+		if err != nil {
+			return nil, fmt.Errorf("incremental scan failed: %w", err)
+		}
+		return result, nil
+	})
+}
+
+// StartWatching starts a watcher for every enabled local target with
+// watching turned on. Watchers run until ctx is done; RefreshWatchers picks
+// up later changes to targets, and is called every watchRefreshInterval to
+// restart watchers that stopped.
+func (c *Coordinator) StartWatching(ctx context.Context) error {
+	c.mu.Lock()
+	c.watchCtx = ctx
+	c.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(watchRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.RefreshWatchers(ctx)
+			}
+		}
+	}()
+
+	return c.RefreshWatchers(ctx)
+}
+
+// RefreshWatchers starts, stops and restarts watchers to match the current
+// target configuration, and restarts watchers that stopped once their
+// backoff has passed. It does nothing until StartWatching has been called.
+func (c *Coordinator) RefreshWatchers(ctx context.Context) error {
+	c.mu.Lock()
+	watchCtx := c.watchCtx
+	c.mu.Unlock()
+	if watchCtx == nil {
+		return nil
+	}
+
+	targets, err := c.db.StorageTargets.ListEnabled(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list storage targets: %w", err)
+	}
+
+	wanted := make(map[int64]*database.StorageTarget)
+	for _, target := range targets {
+		if target.WatchChanges && target.Type == database.StorageTypeLocal {
+			wanted[target.ID] = target
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id, watch := range c.watchers {
+		target, ok := wanted[id]
+		if !ok || !target.UpdatedAt.Equal(watch.updatedAt) {
+			watch.cancel()
+			delete(c.watchers, id)
+		}
+	}
+
+	now := time.Now()
+	for id, target := range wanted {
+		failures := 0
+		if watch, ok := c.watchers[id]; ok {
+			// A stopped watcher is restarted once its full scan has run
+			// and its backoff has passed
+			if !watch.stopped || !watch.done || now.Before(watch.restartAt) {
+				continue
+			}
+			watch.cancel()
+			failures = watch.failures
+		}
+		targetCtx, cancel := context.WithCancel(watchCtx)
+		watch := &targetWatch{cancel: cancel, updatedAt: target.UpdatedAt, startedAt: now, failures: failures}
+		c.watchers[id] = watch
+		go c.watchTarget(targetCtx, target, watch)
+	}
+
+	return nil
+}
+
+// WatchStatus reports on a target's watcher; ok is false if it has none
+func (c *Coordinator) WatchStatus(targetID int64) (status WatchStatus, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	watch, ok := c.watchers[targetID]
+	if !ok {
+		return WatchStatus{}, false
+	}
+	return WatchStatus{Active: !watch.stopped, Err: watch.err}, true
+}
+
+// watchTarget runs a target's watcher, scanning queued paths once changes
+// settle. If the watcher loses events or stops with an error, a full scan
+// picks up whatever was missed.
+func (c *Coordinator) watchTarget(ctx context.Context, target *database.StorageTarget, watch *targetWatch) {
+	defer func() {
+		c.mu.Lock()
+		watch.done = true
+		c.mu.Unlock()
+	}()
+
+	fail := func(err error) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		watch.err = err
+		watch.stopped = true
+
+		// A watcher that ran for a while before failing starts its backoff
+		// over
+		if time.Since(watch.startedAt) >= watchMaxRestartDelay {
+			watch.failures = 0
+		}
+		watch.restartAt = time.Now().Add(restartDelay(watch.failures))
+		watch.failures++
+	}
+
+	backend, err := TargetBackend(target)
+	if err != nil {
+		fail(fmt.Errorf("failed to create storage backend: %w", err))
+		return
+	}
+	defer backend.Close()
+
+	watcher, ok := backend.(storage.ChangeWatcher)
+	if !ok {
+		fail(storage.ErrWatchUnsupported)
+		return
+	}
+
+	queue := storage.NewWatchQueue(0)
+	watchErr := make(chan error, 1)
+	go func() {
+		watchErr <- watcher.Watch(ctx, queue)
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case err := <-watchErr:
+			if ctx.Err() != nil {
+				return
+			}
+			if err == nil {
+				err = errors.New("watcher stopped")
+			}
+			fail(err)
+
+			// Changes since the watcher stopped are unknown
+			queue.Overflow()
+			for ctx.Err() == nil {
+				if !c.scanQueued(ctx, target.ID, queue, watch) {
+					break
+				}
+			}
+			return
+
+		case <-queue.Ready():
+			settle(ctx, queue)
+			c.scanQueued(ctx, target.ID, queue, watch)
+		}
+	}
+}
+
+// restartDelay returns how long a watcher that has stopped failures times in
+// a row waits before it is restarted
+func restartDelay(failures int) time.Duration {
+	delay := watchRestartDelay
+	for i := 0; i < failures && delay < watchMaxRestartDelay; i++ {
+		delay *= 2
+	}
+	if delay > watchMaxRestartDelay {
+		delay = watchMaxRestartDelay
+	}
+	return delay
+}
+
+// settle waits until nothing has been queued for watchSettleDelay, or until
+// watchMaxDelay has passed
+func settle(ctx context.Context, queue *storage.WatchQueue) {
+	deadline := time.NewTimer(watchMaxDelay)
+	defer deadline.Stop()
+	quiet := time.NewTimer(watchSettleDelay)
+	defer quiet.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-deadline.C:
+			return
+		case <-quiet.C:
+			return
+		case <-queue.Ready():
+			quiet.Reset(watchSettleDelay)
+		}
+	}
+}
+
+// scanQueued scans the queued paths of a target, or the whole target if the
+// queue overflowed. If the target cannot be scanned right now the paths are
+// queued again after watchRetryDelay and requeued is true.
+func (c *Coordinator) scanQueued(ctx context.Context, targetID int64, queue *storage.WatchQueue, watch *targetWatch) (requeued bool) {
+	paths, overflowed := queue.Drain()
+
+	var err error
+	switch {
+	case overflowed:
+		_, err = c.ScanTarget(ctx, targetID)
+	case len(paths) > 0:
+		_, err = c.ScanPaths(ctx, targetID, paths)
+	default:
+		return false
+	}
+
+	if errors.Is(err, ErrScanRunning) || errors.Is(err, ErrScanLimitReached) {
+		if overflowed {
+			queue.Overflow()
+		}
+		for _, path := range paths {
+			queue.Add(path)
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(watchRetryDelay):
+		}
+		return true
+	}
+
+	if ctx.Err() != nil {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !watch.stopped {
+		watch.err = err
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ArchiveMemberRepository handles the recorded members of archive files
+type ArchiveMemberRepository struct {
+	db *sqlx.DB
+}
+
+// ListByFile retrieves the recorded members of an archive
+func (r *ArchiveMemberRepository) ListByFile(ctx context.Context, fileID int64) ([]*ArchiveMember, error) {
+	query := `SELECT * FROM archive_members WHERE file_id = $1 ORDER BY path`
+
+	var members []*ArchiveMember
+	if err := r.db.SelectContext(ctx, &members, query, fileID); err != nil {
+		return nil, fmt.Errorf("failed to list archive members: %w", err)
+	}
+
+	return members, nil
+}
+
+// Count returns the number of recorded members of an archive
+func (r *ArchiveMemberRepository) Count(ctx context.Context, fileID int64) (int64, error) {
+	var count int64
+	query := `SELECT COUNT(*) FROM archive_members WHERE file_id = $1`
+	if err := r.db.GetContext(ctx, &count, query, fileID); err != nil {
+		return 0, fmt.Errorf("failed to count archive members: %w", err)
+	}
+	return count, nil
+}
+
+// Replace replaces the recorded members of an archive in a single
+// transaction
+func (r *ArchiveMemberRepository) Replace(ctx context.Context, fileID int64, members []*ArchiveMember) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM archive_members WHERE file_id = $1`, fileID); err != nil {
+		return fmt.Errorf("failed to clear archive members: %w", err)
+	}
+
+	query := `
+		INSERT INTO archive_members (
+			file_id, path, size, mod_time, checksum, checksum_type,
+			first_seen, last_checksummed_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8
+		) RETURNING id`
+
+	stmt, err := tx.PreparexContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, member := range members {
+		member.FileID = fileID
+		err := stmt.QueryRowContext(
+			ctx,
+			fileID, member.Path, member.Size, member.ModTime, member.Checksum, member.ChecksumType,
+			member.FirstSeen, member.LastChecksummedAt,
+		).Scan(&member.ID)
+		if err != nil {
+			return fmt.Errorf("failed to insert archive member %s: %w", member.Path, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jeffanddom/fixity/internal/database"
+	"github.com/jeffanddom/fixity/tests/testutil"
+)
+
+func TestArchiveMemberRepository_Replace(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupDB(t, db)
+
+	ctx := context.Background()
+	target := testutil.MustCreateStorageTarget(t, db, "test-target")
+	archive := testutil.MustCreateFile(t, db, target.ID, "deposits/bundle.zip")
+	other := testutil.MustCreateFile(t, db, target.ID, "deposits/other.tar")
+	now := time.Now().Truncate(time.Microsecond)
+
+	if err := db.ArchiveMembers.Replace(ctx, other.ID, []*database.ArchiveMember{
+		{Path: "kept.txt", Size: 1, Checksum: "aa", ChecksumType: "sha256", FirstSeen: now, LastChecksummedAt: now},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Run("records members", func(t *testing.T) {
+		err := db.ArchiveMembers.Replace(ctx, archive.ID, []*database.ArchiveMember{
+			{Path: "b/data.csv", Size: 120, ModTime: &now, Checksum: "bb", ChecksumType: "sha256", FirstSeen: now, LastChecksummedAt: now},
+			{Path: "a/readme.txt", Size: 3, Checksum: "cc", ChecksumType: "sha256", FirstSeen: now, LastChecksummedAt: now},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		members, err := db.ArchiveMembers.ListByFile(ctx, archive.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(members) != 2 {
+			t.Fatalf("expected 2 members, got %d", len(members))
+		}
+		if members[1].Path != "b/data.csv" || members[1].Size != 120 || members[1].ModTime == nil || !members[1].ModTime.Equal(now) {
+			t.Errorf("unexpected member: %+v", members[1])
+		}
+		if members[0].ModTime != nil {
+			t.Errorf("expected no modification time, got %v", members[0].ModTime)
+		}
+	})
+
+	t.Run("replaces previous members", func(t *testing.T) {
+		err := db.ArchiveMembers.Replace(ctx, archive.ID, []*database.ArchiveMember{
+			{Path: "a/readme.txt", Size: 4, Checksum: "dd", ChecksumType: "sha256", FirstSeen: now, LastChecksummedAt: now},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		count, _ := db.ArchiveMembers.Count(ctx, archive.ID)
+		if count != 1 {
+			t.Errorf("expected only the new member, got %d", count)
+		}
+
+		kept, _ := db.ArchiveMembers.ListByFile(ctx, other.ID)
+		if len(kept) != 1 {
+			t.Errorf("expected other archives to be untouched, got %d members", len(kept))
+		}
+	})
+}
@@ -0,0 +1,108 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// AttestationRepository handles issued checksum attestations
+type AttestationRepository struct {
+	db *sqlx.DB
+}
+
+// Create stores an issued attestation
+func (r *AttestationRepository) Create(ctx context.Context, attestation *Attestation) error {
+	query := `
+		INSERT INTO attestations (
+			storage_target_id, scope, path, scan_id, file_count, content,
+			sha256, signature, key_id, issued_by, issued_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+		) RETURNING id`
+
+	err := r.db.QueryRowContext(
+		ctx, query,
+		attestation.StorageTargetID, attestation.Scope, attestation.Path, attestation.ScanID, attestation.FileCount, attestation.Content,
+		attestation.SHA256, attestation.Signature, attestation.KeyID, attestation.IssuedBy, attestation.IssuedAt,
+	).Scan(&attestation.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create attestation: %w", err)
+	}
+
+	attestation.Size = int64(len(attestation.Content))
+	return nil
+}
+
+// GetByID retrieves an attestation, with its content, by ID
+func (r *AttestationRepository) GetByID(ctx context.Context, id int64) (*Attestation, error) {
+	var attestation Attestation
+	query := `SELECT *, octet_length(content) AS size FROM attestations WHERE id = $1`
+	if err := r.db.GetContext(ctx, &attestation, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("attestation not found: %d", id)
+		}
+		return nil, fmt.Errorf("failed to get attestation: %w", err)
+	}
+	return &attestation, nil
+}
+
+// ListByTarget retrieves up to limit of a target's attestations, newest
+// first, without their content
+func (r *AttestationRepository) ListByTarget(ctx context.Context, targetID int64, limit int) ([]*Attestation, error) {
+	query := `
+		SELECT id, storage_target_id, scope, path, scan_id, file_count, sha256,
+			signature, key_id, issued_by, issued_at, octet_length(content) AS size
+		FROM attestations
+		WHERE storage_target_id = $1
+		ORDER BY issued_at DESC, id DESC
+		LIMIT $2`
+
+	var attestations []*Attestation
+	if err := r.db.SelectContext(ctx, &attestations, query, targetID, limit); err != nil {
+		return nil, fmt.Errorf("failed to list attestations: %w", err)
+	}
+
+	return attestations, nil
+}
+
+// ListScanDigests returns the digest a completed scan found for each file it
+// hashed, in path order. Verification events give the digest observed even
+// if the file has been hashed again since; files first hashed or rehashed
+// after a change by the scan, and not hashed since, give their recorded
+// digest and when it was computed.
+func (r *ChangeEventRepository) ListScanDigests(ctx context.Context, scan *Scan) ([]*ObservedDigest, error) {
+	if scan.CompletedAt == nil {
+		return nil, fmt.Errorf("scan %d has not completed", scan.ID)
+	}
+
+	query := `
+		WITH verified AS (
+			SELECT DISTINCT ON (ce.file_id) ce.file_id, ce.new_checksum, ce.new_size
+			FROM change_events ce
+			WHERE ce.scan_id = $1 AND ce.event_type = 'verified' AND ce.new_checksum IS NOT NULL
+			ORDER BY ce.file_id, ce.id DESC
+		)
+		SELECT f.path, COALESCE(v.new_size, f.size) AS size,
+			COALESCE(f.checksum_type, t.checksum_algorithm) AS algorithm,
+			v.new_checksum AS digest, NULL::timestamptz AS hashed_at
+		FROM verified v
+		JOIN files f ON f.id = v.file_id
+		JOIN storage_targets t ON t.id = f.storage_target_id
+		UNION ALL
+		SELECT f.path, f.size, f.checksum_type, f.current_checksum, f.last_checksummed_at
+		FROM files f
+		WHERE f.storage_target_id = $2
+		  AND f.current_checksum IS NOT NULL
+		  AND f.last_checksummed_at BETWEEN $3 AND $4
+		  AND f.id NOT IN (SELECT file_id FROM verified)
+		ORDER BY path`
+
+	var digests []*ObservedDigest
+	if err := r.db.SelectContext(ctx, &digests, query, scan.ID, scan.StorageTargetID, scan.StartedAt, *scan.CompletedAt); err != nil {
+		return nil, fmt.Errorf("failed to list scan digests: %w", err)
+	}
+	return digests, nil
+}
@@ -0,0 +1,206 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// checkedEvents are the change events recorded when a scan reads a file's
+// content: added and modified files are hashed when found, and verified
+// files are re-hashed
+const checkedEvents = `('added', 'modified', 'verified')`
+
+// checkedBefore matches files f whose content a scan started before $3 has
+// read. Added and modified events only count for files that have since been
+// hashed, as the hash may have failed.
+const checkedBefore = `(
+	f.last_checksummed_at IS NOT NULL AND (
+		f.last_checksummed_at < $3
+		OR EXISTS (
+			SELECT 1 FROM change_events e
+			JOIN scans s ON s.id = e.scan_id
+			WHERE e.file_id = f.id AND s.started_at < $3
+				AND e.event_type IN ` + checkedEvents + `
+		)
+	)
+)`
+
+// activeAt matches regular files of target $1 present at $3
+const activeAt = `f.storage_target_id = $1
+	AND f.file_type = 'regular'
+	AND f.first_seen < $3
+	AND (f.deleted_at IS NULL OR f.deleted_at >= $3)`
+
+// corruptionConditions matches verifications by scans of target $1 started
+// in [$2, $3) that found a file's checksum changed
+const corruptionConditions = `s.storage_target_id = $1 AND s.started_at >= $2 AND s.started_at < $3
+	AND e.event_type = 'verified'
+	AND e.old_checksum IS NOT NULL AND e.new_checksum IS NOT NULL
+	AND e.old_checksum <> e.new_checksum`
+
+// PeriodCoverage summarizes which of a target's files were checked during a
+// period, counting the regular files present at its end
+type PeriodCoverage struct {
+	TotalFiles    int64 `db:"total_files"`
+	TotalBytes    int64 `db:"total_bytes"`
+	VerifiedFiles int64 `db:"verified_files"` // Read by a scan started during the period
+	VerifiedBytes int64 `db:"verified_bytes"`
+	NeverVerified int64 `db:"never_verified"` // Not read by any scan before the period ended
+}
+
+// CoveragePercent returns the share of files verified during the period
+func (c *PeriodCoverage) CoveragePercent() float64 {
+	if c.TotalFiles == 0 {
+		return 100
+	}
+	return float64(c.VerifiedFiles) / float64(c.TotalFiles) * 100
+}
+
+// CorruptionIncident is a verification that found a file's content no
+// longer matched its known-good checksum
+type CorruptionIncident struct {
+	EventID    int64     `db:"event_id"`
+	ScanID     int64     `db:"scan_id"`
+	FileID     int64     `db:"file_id"`
+	Path       string    `db:"path"`
+	Expected   string    `db:"expected"`
+	Actual     string    `db:"actual"`
+	DetectedAt time.Time `db:"detected_at"` // When the scan that found it started
+}
+
+// GetPeriodCoverage returns how many of a target's files were verified by
+// scans started in [start, end)
+func (r *FileRepository) GetPeriodCoverage(ctx context.Context, targetID int64, start, end time.Time) (*PeriodCoverage, error) {
+	query := `
+		WITH checked AS (
+			SELECT DISTINCT e.file_id
+			FROM change_events e
+			JOIN scans s ON s.id = e.scan_id
+			WHERE s.storage_target_id = $1 AND s.started_at >= $2 AND s.started_at < $3
+				AND e.event_type IN ` + checkedEvents + `
+		)
+		SELECT
+			COUNT(*) as total_files,
+			COALESCE(SUM(f.size), 0) as total_bytes,
+			COUNT(*) FILTER (WHERE verified) as verified_files,
+			COALESCE(SUM(f.size) FILTER (WHERE verified), 0) as verified_bytes,
+			COUNT(*) FILTER (WHERE NOT ` + checkedBefore + `) as never_verified
+		FROM (
+			SELECT f.*,
+				f.last_checksummed_at IS NOT NULL AND (
+					(f.last_checksummed_at >= $2 AND f.last_checksummed_at < $3)
+					OR f.id IN (SELECT file_id FROM checked)
+				) AS verified
+			FROM files f
+			WHERE ` + activeAt + `
+		) f`
+
+	var coverage PeriodCoverage
+	if err := r.db.GetContext(ctx, &coverage, query, targetID, start, end); err != nil {
+		return nil, fmt.Errorf("failed to get period coverage: %w", err)
+	}
+
+	return &coverage, nil
+}
+
+// ListNeverVerified retrieves up to limit of the regular files present on a
+// target at end that no scan started before then had read, by path
+func (r *FileRepository) ListNeverVerified(ctx context.Context, targetID int64, end time.Time, limit int) ([]*File, error) {
+	query := `
+		SELECT f.* FROM files f
+		WHERE ` + activeAt + `
+			AND NOT ` + checkedBefore + `
+		ORDER BY f.path
+		LIMIT $2`
+
+	var files []*File
+	if err := r.db.SelectContext(ctx, &files, query, targetID, limit, end); err != nil {
+		return nil, fmt.Errorf("failed to list never verified files: %w", err)
+	}
+
+	return files, nil
+}
+
+// ListInPeriod retrieves a target's scans started in [start, end), oldest
+// first
+func (r *ScanRepository) ListInPeriod(ctx context.Context, targetID int64, start, end time.Time) ([]*Scan, error) {
+	query := `
+		SELECT * FROM scans
+		WHERE storage_target_id = $1 AND started_at >= $2 AND started_at < $3
+		ORDER BY started_at, id`
+
+	var scans []*Scan
+	if err := r.db.SelectContext(ctx, &scans, query, targetID, start, end); err != nil {
+		return nil, fmt.Errorf("failed to list scans: %w", err)
+	}
+
+	return scans, nil
+}
+
+// CountByTypeInPeriod counts the change events recorded by a target's scans
+// started in [start, end), by type
+func (r *ChangeEventRepository) CountByTypeInPeriod(ctx context.Context, targetID int64, start, end time.Time) (map[ChangeEventType]int64, error) {
+	query := `
+		SELECT e.event_type, COUNT(*) as count
+		FROM change_events e
+		JOIN scans s ON s.id = e.scan_id
+		WHERE s.storage_target_id = $1 AND s.started_at >= $2 AND s.started_at < $3
+		GROUP BY e.event_type`
+
+	rows, err := r.db.QueryxContext(ctx, query, targetID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count change events: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[ChangeEventType]int64)
+	for rows.Next() {
+		var eventType ChangeEventType
+		var count int64
+		if err := rows.Scan(&eventType, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan change event count: %w", err)
+		}
+		counts[eventType] = count
+	}
+
+	return counts, rows.Err()
+}
+
+// ListCorruption retrieves up to limit verifications by a target's scans
+// started in [start, end) that found a file's checksum changed, oldest first
+func (r *ChangeEventRepository) ListCorruption(ctx context.Context, targetID int64, start, end time.Time, limit int) ([]*CorruptionIncident, error) {
+	query := `
+		SELECT e.id as event_id, e.scan_id, e.file_id, f.path,
+			e.old_checksum as expected, e.new_checksum as actual,
+			s.started_at as detected_at
+		FROM change_events e
+		JOIN scans s ON s.id = e.scan_id
+		JOIN files f ON f.id = e.file_id
+		WHERE ` + corruptionConditions + `
+		ORDER BY s.started_at, e.id
+		LIMIT $4`
+
+	var incidents []*CorruptionIncident
+	if err := r.db.SelectContext(ctx, &incidents, query, targetID, start, end, limit); err != nil {
+		return nil, fmt.Errorf("failed to list corruption incidents: %w", err)
+	}
+
+	return incidents, nil
+}
+
+// CountCorruption counts the verifications ListCorruption would return,
+// without a limit
+func (r *ChangeEventRepository) CountCorruption(ctx context.Context, targetID int64, start, end time.Time) (int64, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM change_events e
+		JOIN scans s ON s.id = e.scan_id
+		WHERE ` + corruptionConditions
+
+	var count int64
+	if err := r.db.GetContext(ctx, &count, query, targetID, start, end); err != nil {
+		return 0, fmt.Errorf("failed to count corruption incidents: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// SigningKeyRepository handles the public keys Fixity has signed with
+type SigningKeyRepository struct {
+	db *sqlx.DB
+}
+
+// Register records a public key, if not already recorded
+func (r *SigningKeyRepository) Register(ctx context.Context, keyID, publicKey string) error {
+	query := `
+		INSERT INTO signing_keys (key_id, public_key)
+		VALUES ($1, $2)
+		ON CONFLICT (key_id) DO NOTHING`
+
+	if _, err := r.db.ExecContext(ctx, query, keyID, publicKey); err != nil {
+		return fmt.Errorf("failed to register signing key: %w", err)
+	}
+	return nil
+}
+
+// Get retrieves a public key by its ID, or nil if Fixity has never signed
+// with it
+func (r *SigningKeyRepository) Get(ctx context.Context, keyID string) (*SigningKey, error) {
+	var key SigningKey
+	query := `SELECT * FROM signing_keys WHERE key_id = $1`
+	if err := r.db.GetContext(ctx, &key, query, keyID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get signing key: %w", err)
+	}
+	return &key, nil
+}
+
+// Check returns an error unless publicKey is the registered key keyID, so
+// signatures made with keys Fixity never used are rejected
+func (r *SigningKeyRepository) Check(ctx context.Context, keyID, publicKey string) error {
+	known, err := r.Get(ctx, keyID)
+	if err != nil {
+		return err
+	}
+	if known == nil || known.PublicKey != publicKey {
+		return fmt.Errorf("signed by key %s, which this Fixity has not signed with", keyID)
+	}
+	return nil
+}
+
+// AuditReportRepository handles generated audit reports
+type AuditReportRepository struct {
+	db *sqlx.DB
+}
+
+// Create stores a generated report
+func (r *AuditReportRepository) Create(ctx context.Context, report *AuditReport) error {
+	query := `
+		INSERT INTO audit_reports (
+			storage_target_id, period_start, period_end, format, content,
+			sha256, signature, key_id, generated_by, generated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+		) RETURNING id`
+
+	err := r.db.QueryRowContext(
+		ctx, query,
+		report.StorageTargetID, report.PeriodStart, report.PeriodEnd, report.Format, report.Content,
+		report.SHA256, report.Signature, report.KeyID, report.GeneratedBy, report.GeneratedAt,
+	).Scan(&report.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create audit report: %w", err)
+	}
+
+	report.Size = int64(len(report.Content))
+	return nil
+}
+
+// GetByID retrieves a report, with its content, by ID
+func (r *AuditReportRepository) GetByID(ctx context.Context, id int64) (*AuditReport, error) {
+	var report AuditReport
+	query := `SELECT *, octet_length(content) AS size FROM audit_reports WHERE id = $1`
+	if err := r.db.GetContext(ctx, &report, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("audit report not found: %d", id)
+		}
+		return nil, fmt.Errorf("failed to get audit report: %w", err)
+	}
+	return &report, nil
+}
+
+// ListByTarget retrieves up to limit of a target's reports, newest first,
+// without their content
+func (r *AuditReportRepository) ListByTarget(ctx context.Context, targetID int64, limit int) ([]*AuditReport, error) {
+	query := `
+		SELECT id, storage_target_id, period_start, period_end, format, sha256,
+			signature, key_id, generated_by, generated_at, octet_length(content) AS size
+		FROM audit_reports
+		WHERE storage_target_id = $1
+		ORDER BY generated_at DESC, id DESC
+		LIMIT $2`
+
+	var reports []*AuditReport
+	if err := r.db.SelectContext(ctx, &reports, query, targetID, limit); err != nil {
+		return nil, fmt.Errorf("failed to list audit reports: %w", err)
+	}
+
+	return reports, nil
+}
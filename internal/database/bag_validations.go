@@ -0,0 +1,128 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// BagValidationRepository handles the results of validating BagIt bags
+type BagValidationRepository struct {
+	db *sqlx.DB
+}
+
+// Create records a bag validation and its problems in a single transaction
+func (r *BagValidationRepository) Create(ctx context.Context, validation *BagValidation, problems []*BagProblem) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO bag_validations (
+			scan_id, storage_target_id, path, valid, bagit_version,
+			payload_files, problem_count, validated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8
+		) RETURNING id`
+
+	err = tx.QueryRowContext(
+		ctx, query,
+		validation.ScanID, validation.StorageTargetID, validation.Path, validation.Valid, validation.BagItVersion,
+		validation.PayloadFiles, validation.ProblemCount, validation.ValidatedAt,
+	).Scan(&validation.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create bag validation: %w", err)
+	}
+
+	if len(problems) > 0 {
+		stmt, err := tx.PreparexContext(ctx, `
+			INSERT INTO bag_problems (
+				validation_id, path, kind, algorithm, expected, actual, message
+			) VALUES (
+				$1, $2, $3, $4, $5, $6, $7
+			) RETURNING id`)
+		if err != nil {
+			return fmt.Errorf("failed to prepare statement: %w", err)
+		}
+		defer stmt.Close()
+
+		for _, problem := range problems {
+			problem.ValidationID = validation.ID
+			err := stmt.QueryRowContext(
+				ctx,
+				problem.ValidationID, problem.Path, problem.Kind, problem.Algorithm,
+				problem.Expected, problem.Actual, problem.Message,
+			).Scan(&problem.ID)
+			if err != nil {
+				return fmt.Errorf("failed to insert bag problem %s: %w", problem.Path, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a bag validation by ID
+func (r *BagValidationRepository) GetByID(ctx context.Context, id int64) (*BagValidation, error) {
+	var validation BagValidation
+	query := `SELECT * FROM bag_validations WHERE id = $1`
+	if err := r.db.GetContext(ctx, &validation, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("bag validation not found: %d", id)
+		}
+		return nil, fmt.Errorf("failed to get bag validation: %w", err)
+	}
+	return &validation, nil
+}
+
+// ListLatestByTarget retrieves the most recent validation of each bag found
+// on a target
+func (r *BagValidationRepository) ListLatestByTarget(ctx context.Context, targetID int64) ([]*BagValidation, error) {
+	query := `
+		SELECT DISTINCT ON (path) * FROM bag_validations
+		WHERE storage_target_id = $1
+		ORDER BY path, validated_at DESC, id DESC`
+
+	var validations []*BagValidation
+	if err := r.db.SelectContext(ctx, &validations, query, targetID); err != nil {
+		return nil, fmt.Errorf("failed to list bag validations: %w", err)
+	}
+
+	return validations, nil
+}
+
+// ListByScan retrieves the bags validated during a scan
+func (r *BagValidationRepository) ListByScan(ctx context.Context, scanID int64) ([]*BagValidation, error) {
+	query := `SELECT * FROM bag_validations WHERE scan_id = $1 ORDER BY path`
+
+	var validations []*BagValidation
+	if err := r.db.SelectContext(ctx, &validations, query, scanID); err != nil {
+		return nil, fmt.Errorf("failed to list bag validations: %w", err)
+	}
+
+	return validations, nil
+}
+
+// ListProblems retrieves up to limit recorded problems of a bag validation
+func (r *BagValidationRepository) ListProblems(ctx context.Context, validationID int64, limit int) ([]*BagProblem, error) {
+	query := `
+		SELECT * FROM bag_problems
+		WHERE validation_id = $1
+		ORDER BY path, kind, id
+		LIMIT $2`
+
+	var problems []*BagProblem
+	if err := r.db.SelectContext(ctx, &problems, query, validationID, limit); err != nil {
+		return nil, fmt.Errorf("failed to list bag problems: %w", err)
+	}
+
+	return problems, nil
+}
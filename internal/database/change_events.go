@@ -90,29 +90,11 @@ func (r *ChangeEventRepository) GetByFile(ctx context.Context, fileID int64) ([]
 
 // Create creates a new change event record
 func (r *ChangeEventRepository) Create(ctx context.Context, event *ChangeEvent) error {
-	query := `
-		INSERT INTO change_events (
-			scan_id, file_id, event_type, detected_at,
-			old_checksum, new_checksum, old_size, new_size,
-			created_at
-		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, NOW()
-		) RETURNING id, created_at`
-
-	err := r.db.QueryRowContext(
-		ctx, query,
-		event.ScanID, event.FileID, event.EventType, event.DetectedAt,
-		event.OldChecksum, event.NewChecksum, event.OldSize, event.NewSize,
-	).Scan(&event.ID, &event.CreatedAt)
-
-	if err != nil {
-		return fmt.Errorf("failed to create change event: %w", err)
-	}
-
-	return nil
+	return r.CreateBatch(ctx, []*ChangeEvent{event})
 }
 
-// CreateBatch creates multiple change event records in a single transaction
+// CreateBatch creates multiple change event records in a single transaction,
+// appending each to its target's hash chain
 func (r *ChangeEventRepository) CreateBatch(ctx context.Context, events []*ChangeEvent) error {
 	if len(events) == 0 {
 		return nil
@@ -128,9 +110,11 @@ func (r *ChangeEventRepository) CreateBatch(ctx context.Context, events []*Chang
 		INSERT INTO change_events (
 			scan_id, file_id, event_type, detected_at,
 			old_checksum, new_checksum, old_size, new_size,
+			old_metadata, new_metadata, member_path, old_path, new_path,
+			storage_target_id, chain_seq, prev_hash, hash,
 			created_at
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, NOW()
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, NOW()
 		) RETURNING id, created_at`
 
 	stmt, err := tx.PreparexContext(ctx, query)
@@ -139,11 +123,18 @@ func (r *ChangeEventRepository) CreateBatch(ctx context.Context, events []*Chang
 	}
 	defer stmt.Close()
 
+	chain := newChainAppender(tx)
 	for _, event := range events {
+		if err := chain.link(ctx, event); err != nil {
+			return err
+		}
+
 		err := stmt.QueryRowContext(
 			ctx,
 			event.ScanID, event.FileID, event.EventType, event.DetectedAt,
 			event.OldChecksum, event.NewChecksum, event.OldSize, event.NewSize,
+			event.OldMetadata, event.NewMetadata, event.MemberPath, event.OldPath, event.NewPath,
+			event.ChainTargetID, event.ChainSeq, event.PrevHash, event.Hash,
 		).Scan(&event.ID, &event.CreatedAt)
 
 		if err != nil {
@@ -151,6 +142,10 @@ func (r *ChangeEventRepository) CreateBatch(ctx context.Context, events []*Chang
 		}
 	}
 
+	if err := chain.save(ctx); err != nil {
+		return err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
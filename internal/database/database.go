@@ -16,16 +16,28 @@ import (
 type Database struct {
 	db *sqlx.DB
 
-	Files             *FileRepository
-	Scans             *ScanRepository
-	ChangeEvents      *ChangeEventRepository
-	StorageTargets    *StorageTargetRepository
-	Users             *UserRepository
-	Sessions          *SessionRepository
-	Webhooks          *WebhookRepository
-	WebhookDeliveries *WebhookDeliveryRepository
-	Config            *ConfigRepository
-	Checkpoints       *CheckpointRepository
+	Files              *FileRepository
+	Scans              *ScanRepository
+	ScanErrors         *ScanErrorRepository
+	ChangeEvents       *ChangeEventRepository
+	StorageTargets     *StorageTargetRepository
+	Users              *UserRepository
+	Sessions           *SessionRepository
+	Webhooks           *WebhookRepository
+	WebhookDeliveries  *WebhookDeliveryRepository
+	Config             *ConfigRepository
+	Checkpoints        *CheckpointRepository
+	Directories        *DirectoryRepository
+	ArchiveMembers     *ArchiveMemberRepository
+	ReplicaGroups      *ReplicaGroupRepository
+	ReplicaComparisons *ReplicaComparisonRepository
+	ExpectedChecksums  *ExpectedChecksumRepository
+	BagValidations     *BagValidationRepository
+	SigningKeys        *SigningKeyRepository
+	AuditReports       *AuditReportRepository
+	AuditCheckpoints   *AuditCheckpointRepository
+	Attestations       *AttestationRepository
+	ScanTimestamps     *ScanTimestampRepository
 }
 
 // ConnectionConfig holds database connection configuration
@@ -90,6 +102,7 @@ func New(cfg ConnectionConfig) (*Database, error) {
 	d := &Database{db: db}
 	d.Files = &FileRepository{db: db}
 	d.Scans = &ScanRepository{db: db}
+	d.ScanErrors = &ScanErrorRepository{db: db}
 	d.ChangeEvents = &ChangeEventRepository{db: db}
 	d.StorageTargets = &StorageTargetRepository{db: db}
 	d.Users = &UserRepository{db: db}
@@ -98,6 +111,17 @@ func New(cfg ConnectionConfig) (*Database, error) {
 	d.WebhookDeliveries = &WebhookDeliveryRepository{db: db}
 	d.Config = &ConfigRepository{db: db}
 	d.Checkpoints = &CheckpointRepository{db: db}
+	d.Directories = &DirectoryRepository{db: db}
+	d.ArchiveMembers = &ArchiveMemberRepository{db: db}
+	d.ReplicaGroups = &ReplicaGroupRepository{db: db}
+	d.ReplicaComparisons = &ReplicaComparisonRepository{db: db}
+	d.ExpectedChecksums = &ExpectedChecksumRepository{db: db}
+	d.BagValidations = &BagValidationRepository{db: db}
+	d.SigningKeys = &SigningKeyRepository{db: db}
+	d.AuditReports = &AuditReportRepository{db: db}
+	d.AuditCheckpoints = &AuditCheckpointRepository{db: db}
+	d.Attestations = &AttestationRepository{db: db}
+	d.ScanTimestamps = &ScanTimestampRepository{db: db}
 
 	return d, nil
 }
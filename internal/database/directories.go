@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// DirectoryRepository handles the directory state recorded by walks
+type DirectoryRepository struct {
+	db *sqlx.DB
+}
+
+// ListByTarget retrieves the recorded directories of a storage target
+func (r *DirectoryRepository) ListByTarget(ctx context.Context, targetID int64) ([]*Directory, error) {
+	query := `SELECT * FROM directories WHERE storage_target_id = $1 ORDER BY path`
+
+	var dirs []*Directory
+	if err := r.db.SelectContext(ctx, &dirs, query, targetID); err != nil {
+		return nil, fmt.Errorf("failed to list directories: %w", err)
+	}
+
+	return dirs, nil
+}
+
+// Replace replaces the recorded directories of a storage target in a single
+// transaction
+func (r *DirectoryRepository) Replace(ctx context.Context, targetID int64, dirs []*Directory) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM directories WHERE storage_target_id = $1`, targetID); err != nil {
+		return fmt.Errorf("failed to clear directories: %w", err)
+	}
+
+	query := `
+		INSERT INTO directories (
+			storage_target_id, path, mod_time, entries
+		) VALUES (
+			$1, $2, $3, $4
+		)`
+
+	stmt, err := tx.PreparexContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, dir := range dirs {
+		dir.StorageTargetID = targetID
+		if _, err := stmt.ExecContext(ctx, targetID, dir.Path, dir.ModTime, dir.Entries); err != nil {
+			return fmt.Errorf("failed to insert directory %s: %w", dir.Path, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
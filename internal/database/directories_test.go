@@ -0,0 +1,67 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jeffanddom/fixity/internal/database"
+	"github.com/jeffanddom/fixity/tests/testutil"
+)
+
+func TestDirectoryRepository_Replace(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupDB(t, db)
+
+	ctx := context.Background()
+	target := testutil.MustCreateStorageTarget(t, db, "test-target")
+	other := testutil.MustCreateStorageTarget(t, db, "other-target")
+	modTime := time.Now().Truncate(time.Microsecond)
+
+	if err := db.Directories.Replace(ctx, other.ID, []*database.Directory{
+		{Path: "kept", ModTime: modTime, Entries: 1},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Run("records directories", func(t *testing.T) {
+		err := db.Directories.Replace(ctx, target.ID, []*database.Directory{
+			{Path: "photos", ModTime: modTime, Entries: 3},
+			{Path: "photos/2024", ModTime: modTime, Entries: 120},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		dirs, err := db.Directories.ListByTarget(ctx, target.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(dirs) != 2 {
+			t.Fatalf("expected 2 directories, got %d", len(dirs))
+		}
+		if dirs[1].Path != "photos/2024" || dirs[1].Entries != 120 || !dirs[1].ModTime.Equal(modTime) {
+			t.Errorf("unexpected directory: %+v", dirs[1])
+		}
+	})
+
+	t.Run("replaces previous directories", func(t *testing.T) {
+		err := db.Directories.Replace(ctx, target.ID, []*database.Directory{
+			{Path: "photos", ModTime: modTime, Entries: 4},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		dirs, _ := db.Directories.ListByTarget(ctx, target.ID)
+		if len(dirs) != 1 || dirs[0].Entries != 4 {
+			t.Errorf("expected only the new directory, got %+v", dirs)
+		}
+
+		kept, _ := db.Directories.ListByTarget(ctx, other.ID)
+		if len(kept) != 1 {
+			t.Errorf("expected other targets to be untouched, got %d directories", len(kept))
+		}
+	})
+}
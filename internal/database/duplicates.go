@@ -0,0 +1,156 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// ContentKey identifies file content: files with the same checksum type,
+// checksum and size are taken to be copies of each other
+type ContentKey struct {
+	ChecksumType string `db:"checksum_type"`
+	Checksum     string `db:"checksum"`
+	Size         int64  `db:"size"`
+}
+
+// DuplicateGroup is content held by more than one active file
+type DuplicateGroup struct {
+	ContentKey
+	Copies  int64 `db:"copies"`
+	Targets int64 `db:"targets"` // Number of distinct targets holding a copy
+}
+
+// WastedBytes is the space taken by every copy beyond the first
+func (g *DuplicateGroup) WastedBytes() int64 {
+	return (g.Copies - 1) * g.Size
+}
+
+// DuplicateFilters holds filtering options for duplicate queries. Groups are
+// listed in content key order after After, so a page can start where the
+// previous one ended however large the table is; a zero After starts from
+// the beginning.
+type DuplicateFilters struct {
+	TargetIDs []int64 // Limit to copies on these targets; empty means all
+	MinSize   int64
+	After     ContentKey
+	Limit     int
+}
+
+// DuplicateSummary totals the duplicate groups matching a filter
+type DuplicateSummary struct {
+	Groups      int64 `db:"groups"`
+	Copies      int64 `db:"copies"`
+	WastedBytes int64 `db:"wasted_bytes"`
+}
+
+// duplicateConditions returns the WHERE clause selecting active, hashed files
+// that match the filters, and its arguments. It matches the partial index on
+// file content.
+func duplicateConditions(filters DuplicateFilters) (string, []interface{}) {
+	where := `deleted_at IS NULL AND current_checksum IS NOT NULL`
+	args := []interface{}{}
+
+	if len(filters.TargetIDs) > 0 {
+		args = append(args, pq.Array(filters.TargetIDs))
+		where += fmt.Sprintf(" AND storage_target_id = ANY($%d)", len(args))
+	}
+
+	if filters.MinSize > 0 {
+		args = append(args, filters.MinSize)
+		where += fmt.Sprintf(" AND size >= $%d", len(args))
+	}
+
+	return where, args
+}
+
+// ListDuplicateGroups returns the next groups of active files sharing
+// content, in content key order
+func (r *FileRepository) ListDuplicateGroups(ctx context.Context, filters DuplicateFilters) ([]*DuplicateGroup, error) {
+	where, args := duplicateConditions(filters)
+	argNum := len(args) + 1
+
+	query := fmt.Sprintf(`
+		SELECT checksum_type, current_checksum AS checksum, size,
+		       COUNT(*) AS copies, COUNT(DISTINCT storage_target_id) AS targets
+		FROM files
+		WHERE %s
+		  AND (checksum_type, current_checksum, size) > ($%d, $%d, $%d)
+		GROUP BY checksum_type, current_checksum, size
+		HAVING COUNT(*) > 1
+		ORDER BY checksum_type, current_checksum, size`,
+		where, argNum, argNum+1, argNum+2)
+	args = append(args, filters.After.ChecksumType, filters.After.Checksum, filters.After.Size)
+	argNum += 3
+
+	if filters.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", argNum)
+		args = append(args, filters.Limit)
+	}
+
+	var groups []*DuplicateGroup
+	if err := r.db.SelectContext(ctx, &groups, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to list duplicate groups: %w", err)
+	}
+
+	return groups, nil
+}
+
+// SummarizeDuplicates totals the duplicate groups matching the filters,
+// ignoring After and Limit. It reads every hashed file in scope, so callers
+// should not run it for each page.
+func (r *FileRepository) SummarizeDuplicates(ctx context.Context, filters DuplicateFilters) (*DuplicateSummary, error) {
+	where, args := duplicateConditions(filters)
+
+	query := fmt.Sprintf(`
+		SELECT COUNT(*) AS groups,
+		       COALESCE(SUM(copies), 0) AS copies,
+		       COALESCE(SUM((copies - 1) * size), 0) AS wasted_bytes
+		FROM (
+			SELECT size, COUNT(*) AS copies
+			FROM files
+			WHERE %s
+			GROUP BY checksum_type, current_checksum, size
+			HAVING COUNT(*) > 1
+		) dups`, where)
+
+	var summary DuplicateSummary
+	if err := r.db.GetContext(ctx, &summary, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to summarize duplicates: %w", err)
+	}
+
+	return &summary, nil
+}
+
+// ListByContent returns the next active files with the given content after
+// the file with ID afterID, in ID order, optionally limited to some targets
+func (r *FileRepository) ListByContent(
+	ctx context.Context,
+	key ContentKey,
+	targetIDs []int64,
+	afterID int64,
+	limit int,
+) ([]*File, error) {
+	where, args := duplicateConditions(DuplicateFilters{TargetIDs: targetIDs})
+	argNum := len(args) + 1
+
+	query := fmt.Sprintf(`
+		SELECT * FROM files
+		WHERE %s
+		  AND checksum_type = $%d
+		  AND current_checksum = $%d
+		  AND size = $%d
+		  AND id > $%d
+		ORDER BY id
+		LIMIT $%d`,
+		where, argNum, argNum+1, argNum+2, argNum+3, argNum+4)
+	args = append(args, key.ChecksumType, key.Checksum, key.Size, afterID, limit)
+
+	var files []*File
+	if err := r.db.SelectContext(ctx, &files, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to list files by content: %w", err)
+	}
+
+	return files, nil
+}
@@ -0,0 +1,108 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jeffanddom/fixity/internal/database"
+	"github.com/jeffanddom/fixity/tests/testutil"
+)
+
+func TestFileRepository_Duplicates(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupDB(t, db)
+
+	ctx := context.Background()
+	primary := testutil.MustCreateStorageTarget(t, db, "primary")
+	backup := testutil.MustCreateStorageTarget(t, db, "backup")
+
+	create := func(targetID int64, path, checksum string, size int64) *database.File {
+		file := testutil.MustCreateFile(t, db, targetID, path)
+		file.CurrentChecksum = &checksum
+		file.Size = size
+		if err := db.Files.Update(ctx, file); err != nil {
+			t.Fatalf("failed to update file: %v", err)
+		}
+		return file
+	}
+
+	create(primary.ID, "/a.jpg", "aaaa", 100)
+	create(primary.ID, "/copy/a.jpg", "aaaa", 100)
+	create(backup.ID, "/a.jpg", "aaaa", 100)
+	create(primary.ID, "/b.jpg", "bbbb", 10)
+	create(backup.ID, "/b.jpg", "bbbb", 10)
+	create(primary.ID, "/unique.jpg", "cccc", 50)
+	create(primary.ID, "/same-sum-other-size.jpg", "bbbb", 11)
+
+	deleted := create(primary.ID, "/gone.jpg", "cccc", 50)
+	now := time.Now()
+	deleted.DeletedAt = &now
+	db.Files.Update(ctx, deleted)
+
+	t.Run("groups active files by content", func(t *testing.T) {
+		groups, err := db.Files.ListDuplicateGroups(ctx, database.DuplicateFilters{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(groups) != 2 {
+			t.Fatalf("expected 2 groups, got %d", len(groups))
+		}
+		if groups[0].Checksum != "aaaa" || groups[0].Copies != 3 || groups[0].Targets != 2 || groups[0].WastedBytes() != 200 {
+			t.Errorf("unexpected first group: %+v", groups[0])
+		}
+
+		summary, err := db.Files.SummarizeDuplicates(ctx, database.DuplicateFilters{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if summary.Groups != 2 || summary.Copies != 5 || summary.WastedBytes != 210 {
+			t.Errorf("unexpected summary: %+v", summary)
+		}
+	})
+
+	t.Run("pages by content key", func(t *testing.T) {
+		groups, _ := db.Files.ListDuplicateGroups(ctx, database.DuplicateFilters{Limit: 1})
+		if len(groups) != 1 {
+			t.Fatalf("expected 1 group, got %d", len(groups))
+		}
+		groups, _ = db.Files.ListDuplicateGroups(ctx, database.DuplicateFilters{After: groups[0].ContentKey, Limit: 1})
+		if len(groups) != 1 || groups[0].Checksum != "bbbb" {
+			t.Errorf("expected the bbbb group on the second page, got %v", groups)
+		}
+	})
+
+	t.Run("limits to targets and size", func(t *testing.T) {
+		groups, _ := db.Files.ListDuplicateGroups(ctx, database.DuplicateFilters{TargetIDs: []int64{primary.ID}})
+		if len(groups) != 1 || groups[0].Copies != 2 {
+			t.Errorf("expected only the aaaa copies on primary, got %v", groups)
+		}
+
+		groups, _ = db.Files.ListDuplicateGroups(ctx, database.DuplicateFilters{MinSize: 50})
+		if len(groups) != 1 || groups[0].Checksum != "aaaa" {
+			t.Errorf("expected only the large group, got %v", groups)
+		}
+	})
+
+	t.Run("lists a group's files", func(t *testing.T) {
+		key := database.ContentKey{ChecksumType: "md5", Checksum: "aaaa", Size: 100}
+		files, err := db.Files.ListByContent(ctx, key, nil, 0, 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(files) != 2 {
+			t.Fatalf("expected 2 files, got %d", len(files))
+		}
+
+		files, _ = db.Files.ListByContent(ctx, key, nil, files[1].ID, 2)
+		if len(files) != 1 || files[0].StorageTargetID != backup.ID {
+			t.Errorf("expected the backup copy on the second page, got %d files", len(files))
+		}
+
+		files, _ = db.Files.ListByContent(ctx, key, []int64{backup.ID}, 0, 10)
+		if len(files) != 1 {
+			t.Errorf("expected 1 file on backup, got %d", len(files))
+		}
+	})
+}
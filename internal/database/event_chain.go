@@ -0,0 +1,312 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// chainLink is the content of a change event covered by its chain hash. The
+// field order is fixed by the struct, so the encoding is stable.
+type chainLink struct {
+	PrevHash    string    `json:"prev_hash"`
+	TargetID    int64     `json:"target_id"`
+	Seq         int64     `json:"seq"`
+	ScanID      int64     `json:"scan_id"`
+	FileID      int64     `json:"file_id"`
+	EventType   string    `json:"event_type"`
+	DetectedAt  time.Time `json:"detected_at"`
+	OldChecksum *string   `json:"old_checksum"`
+	NewChecksum *string   `json:"new_checksum"`
+	OldSize     *int64    `json:"old_size"`
+	NewSize     *int64    `json:"new_size"`
+	OldMetadata *string   `json:"old_metadata"`
+	NewMetadata *string   `json:"new_metadata"`
+	MemberPath  *string   `json:"member_path"`
+	OldPath     *string   `json:"old_path"`
+	NewPath     *string   `json:"new_path"`
+}
+
+// ChainHash returns the hash of a change event at position seq of a target's
+// chain, following the event with hash prevHash ("" for the first event)
+func ChainHash(prevHash string, targetID, seq int64, event *ChangeEvent) string {
+	link, _ := json.Marshal(chainLink{
+		PrevHash:    prevHash,
+		TargetID:    targetID,
+		Seq:         seq,
+		ScanID:      event.ScanID,
+		FileID:      event.FileID,
+		EventType:   string(event.EventType),
+		DetectedAt:  event.DetectedAt.UTC(),
+		OldChecksum: event.OldChecksum,
+		NewChecksum: event.NewChecksum,
+		OldSize:     event.OldSize,
+		NewSize:     event.NewSize,
+		OldMetadata: event.OldMetadata,
+		NewMetadata: event.NewMetadata,
+		MemberPath:  event.MemberPath,
+		OldPath:     event.OldPath,
+		NewPath:     event.NewPath,
+	})
+	sum := sha256.Sum256(link)
+	return hex.EncodeToString(sum[:])
+}
+
+// chainAppender links events into their targets' chains within a
+// transaction, locking each target's chain head until it commits
+type chainAppender struct {
+	tx      *sqlx.Tx
+	targets map[int64]int64           // scanID -> targetID
+	heads   map[int64]*EventChainHead // targetID -> locked head
+}
+
+func newChainAppender(tx *sqlx.Tx) *chainAppender {
+	return &chainAppender{
+		tx:      tx,
+		targets: make(map[int64]int64),
+		heads:   make(map[int64]*EventChainHead),
+	}
+}
+
+// link sets the event's chain position and hashes, advancing its target's head
+func (a *chainAppender) link(ctx context.Context, event *ChangeEvent) error {
+	targetID, ok := a.targets[event.ScanID]
+	if !ok {
+		if err := a.tx.GetContext(ctx, &targetID, `SELECT storage_target_id FROM scans WHERE id = $1`, event.ScanID); err != nil {
+			return fmt.Errorf("failed to get target of scan %d: %w", event.ScanID, err)
+		}
+		a.targets[event.ScanID] = targetID
+	}
+
+	head, ok := a.heads[targetID]
+	if !ok {
+		head = &EventChainHead{}
+		query := `
+			INSERT INTO event_chain_heads (storage_target_id)
+			VALUES ($1)
+			ON CONFLICT (storage_target_id) DO NOTHING`
+		if _, err := a.tx.ExecContext(ctx, query, targetID); err != nil {
+			return fmt.Errorf("failed to create event chain head: %w", err)
+		}
+		query = `SELECT * FROM event_chain_heads WHERE storage_target_id = $1 FOR UPDATE`
+		if err := a.tx.GetContext(ctx, head, query, targetID); err != nil {
+			return fmt.Errorf("failed to lock event chain head: %w", err)
+		}
+		a.heads[targetID] = head
+	}
+
+	// The database keeps microseconds; hash what it will return
+	event.DetectedAt = event.DetectedAt.Truncate(time.Microsecond)
+
+	seq := head.Seq + 1
+	prevHash := head.Hash
+	hash := ChainHash(prevHash, targetID, seq, event)
+	event.ChainTargetID, event.ChainSeq, event.PrevHash, event.Hash = &targetID, &seq, &prevHash, &hash
+
+	head.Seq, head.Hash = seq, hash
+	return nil
+}
+
+// save records the new heads of the chains events were appended to
+func (a *chainAppender) save(ctx context.Context) error {
+	query := `
+		UPDATE event_chain_heads
+		SET seq = $2, hash = $3, updated_at = NOW()
+		WHERE storage_target_id = $1`
+
+	for targetID, head := range a.heads {
+		if _, err := a.tx.ExecContext(ctx, query, targetID, head.Seq, head.Hash); err != nil {
+			return fmt.Errorf("failed to update event chain head: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetChainHead retrieves the head of a target's event chain, with a zero
+// sequence if no events have been chained
+func (r *ChangeEventRepository) GetChainHead(ctx context.Context, targetID int64) (*EventChainHead, error) {
+	var head EventChainHead
+	query := `SELECT * FROM event_chain_heads WHERE storage_target_id = $1`
+	if err := r.db.GetContext(ctx, &head, query, targetID); err != nil {
+		if err == sql.ErrNoRows {
+			return &EventChainHead{StorageTargetID: targetID}, nil
+		}
+		return nil, fmt.Errorf("failed to get event chain head: %w", err)
+	}
+	return &head, nil
+}
+
+// ListChain retrieves up to limit events of a target's chain after position
+// afterSeq, in chain order
+func (r *ChangeEventRepository) ListChain(ctx context.Context, targetID, afterSeq int64, limit int) ([]*ChangeEvent, error) {
+	query := `
+		SELECT * FROM change_events
+		WHERE storage_target_id = $1 AND chain_seq > $2
+		ORDER BY chain_seq
+		LIMIT $3`
+
+	var events []*ChangeEvent
+	if err := r.db.SelectContext(ctx, &events, query, targetID, afterSeq, limit); err != nil {
+		return nil, fmt.Errorf("failed to list event chain: %w", err)
+	}
+	return events, nil
+}
+
+// CountUnchained counts a target's events that are not in its chain, split
+// into those recorded before its first chained event and those after it.
+// Fixity chains every event it records, so the latter were added another way.
+func (r *ChangeEventRepository) CountUnchained(ctx context.Context, targetID int64) (before, after int64, err error) {
+	query := `
+		WITH first AS (
+			SELECT MIN(id) AS id FROM change_events
+			WHERE storage_target_id = $1 AND chain_seq IS NOT NULL
+		)
+		SELECT
+			COUNT(*) FILTER (WHERE first.id IS NULL OR ce.id < first.id),
+			COUNT(*) FILTER (WHERE ce.id > first.id)
+		FROM change_events ce
+		JOIN scans s ON s.id = ce.scan_id
+		CROSS JOIN first
+		WHERE s.storage_target_id = $1 AND ce.chain_seq IS NULL`
+
+	if err := r.db.QueryRowContext(ctx, query, targetID).Scan(&before, &after); err != nil {
+		return 0, 0, fmt.Errorf("failed to count unchained events: %w", err)
+	}
+	return before, after, nil
+}
+
+// StateDigest returns a SHA-256 digest of the path, size and recorded
+// checksum of each of a target's present files, and how many there are
+func (r *FileRepository) StateDigest(ctx context.Context, targetID int64) (string, int64, error) {
+	query := `
+		SELECT id, path, size, COALESCE(checksum_type, ''), COALESCE(current_checksum, '')
+		FROM files
+		WHERE storage_target_id = $1 AND deleted_at IS NULL
+		ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query, targetID)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read file state: %w", err)
+	}
+	defer rows.Close()
+
+	h := sha256.New()
+	var count int64
+	for rows.Next() {
+		var id, size int64
+		var path, checksumType, checksum string
+		if err := rows.Scan(&id, &path, &size, &checksumType, &checksum); err != nil {
+			return "", 0, fmt.Errorf("failed to scan file state: %w", err)
+		}
+		fmt.Fprintf(h, "%d\t%q\t%d\t%s:%s\n", id, path, size, checksumType, checksum)
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return "", 0, fmt.Errorf("failed to read file state: %w", err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), count, nil
+}
+
+// ListUnexplained returns up to limit of a target's present files whose
+// recorded checksum, size or path differs from what the latest chained event
+// recording the file's checksum left it with. A verification that found a
+// mismatch leaves the known-good checksum in place; other events leave the
+// new checksum, or the old one if they have none. Archive member events are
+// about the members, not the file.
+func (r *FileRepository) ListUnexplained(ctx context.Context, targetID int64, limit int) ([]*File, error) {
+	query := `
+		WITH latest AS (
+			SELECT DISTINCT ON (e.file_id)
+				e.file_id,
+				CASE WHEN e.event_type = 'verified'
+					THEN COALESCE(e.old_checksum, e.new_checksum)
+					ELSE COALESCE(e.new_checksum, e.old_checksum)
+				END AS checksum,
+				e.new_size,
+				e.new_path
+			FROM change_events e
+			WHERE e.storage_target_id = $1 AND e.chain_seq IS NOT NULL
+				AND e.event_type NOT IN ('member_added', 'member_deleted', 'member_modified')
+				AND (e.old_checksum IS NOT NULL OR e.new_checksum IS NOT NULL)
+			ORDER BY e.file_id, e.chain_seq DESC
+		)
+		SELECT f.* FROM files f
+		JOIN latest l ON l.file_id = f.id
+		WHERE f.storage_target_id = $1 AND f.deleted_at IS NULL
+			AND (f.current_checksum IS DISTINCT FROM l.checksum
+				OR (l.new_size IS NOT NULL AND f.size <> l.new_size)
+				OR (l.new_path IS NOT NULL AND f.path <> l.new_path))
+		ORDER BY f.path
+		LIMIT $2`
+
+	var files []*File
+	if err := r.db.SelectContext(ctx, &files, query, targetID, limit); err != nil {
+		return nil, fmt.Errorf("failed to list unexplained files: %w", err)
+	}
+	return files, nil
+}
+
+// AuditCheckpointRepository handles signed event chain checkpoints
+type AuditCheckpointRepository struct {
+	db *sqlx.DB
+}
+
+// Create stores a checkpoint
+func (r *AuditCheckpointRepository) Create(ctx context.Context, checkpoint *AuditCheckpoint) error {
+	query := `
+		INSERT INTO audit_checkpoints (
+			storage_target_id, chain_seq, chain_hash, files_digest, file_count,
+			payload, signature, key_id
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8
+		) RETURNING id, created_at`
+
+	err := r.db.QueryRowContext(
+		ctx, query,
+		checkpoint.StorageTargetID, checkpoint.ChainSeq, checkpoint.ChainHash, checkpoint.FilesDigest, checkpoint.FileCount,
+		checkpoint.Payload, checkpoint.Signature, checkpoint.KeyID,
+	).Scan(&checkpoint.ID, &checkpoint.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create audit checkpoint: %w", err)
+	}
+	return nil
+}
+
+// GetLatest retrieves a target's latest checkpoint, or nil if it has none
+func (r *AuditCheckpointRepository) GetLatest(ctx context.Context, targetID int64) (*AuditCheckpoint, error) {
+	query := `
+		SELECT * FROM audit_checkpoints
+		WHERE storage_target_id = $1
+		ORDER BY chain_seq DESC, id DESC
+		LIMIT 1`
+
+	var checkpoint AuditCheckpoint
+	if err := r.db.GetContext(ctx, &checkpoint, query, targetID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get audit checkpoint: %w", err)
+	}
+	return &checkpoint, nil
+}
+
+// ListByTarget retrieves a target's checkpoints in chain order
+func (r *AuditCheckpointRepository) ListByTarget(ctx context.Context, targetID int64) ([]*AuditCheckpoint, error) {
+	query := `
+		SELECT * FROM audit_checkpoints
+		WHERE storage_target_id = $1
+		ORDER BY chain_seq, id`
+
+	var checkpoints []*AuditCheckpoint
+	if err := r.db.SelectContext(ctx, &checkpoints, query, targetID); err != nil {
+		return nil, fmt.Errorf("failed to list audit checkpoints: %w", err)
+	}
+	return checkpoints, nil
+}
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// ExpectedChecksumRepository handles checksums imported from manifests
+type ExpectedChecksumRepository struct {
+	db *sqlx.DB
+}
+
+// Upsert records expected checksums in a single transaction, replacing any
+// already expected for the same paths
+func (r *ExpectedChecksumRepository) Upsert(ctx context.Context, expected []*ExpectedChecksum) error {
+	if len(expected) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO expected_checksums (
+			storage_target_id, path, checksum, checksum_type, source
+		) VALUES (
+			$1, $2, $3, $4, $5
+		)
+		ON CONFLICT (storage_target_id, path) DO UPDATE SET
+			checksum = EXCLUDED.checksum,
+			checksum_type = EXCLUDED.checksum_type,
+			source = EXCLUDED.source,
+			imported_at = NOW()
+		RETURNING id, imported_at`
+
+	stmt, err := tx.PreparexContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, e := range expected {
+		err := stmt.QueryRowContext(
+			ctx,
+			e.StorageTargetID, e.Path, e.Checksum, e.ChecksumType, e.Source,
+		).Scan(&e.ID, &e.ImportedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert expected checksum for %s: %w", e.Path, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ListByPaths retrieves the checksums expected for any of the given paths
+func (r *ExpectedChecksumRepository) ListByPaths(ctx context.Context, targetID int64, paths []string) ([]*ExpectedChecksum, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		SELECT * FROM expected_checksums
+		WHERE storage_target_id = $1 AND path = ANY($2)
+		ORDER BY path`
+
+	var expected []*ExpectedChecksum
+	if err := r.db.SelectContext(ctx, &expected, query, targetID, pq.Array(paths)); err != nil {
+		return nil, fmt.Errorf("failed to list expected checksums: %w", err)
+	}
+
+	return expected, nil
+}
+
+// Count returns the number of checksums still expected for a target
+func (r *ExpectedChecksumRepository) Count(ctx context.Context, targetID int64) (int64, error) {
+	var count int64
+	query := `SELECT COUNT(*) FROM expected_checksums WHERE storage_target_id = $1`
+	if err := r.db.GetContext(ctx, &count, query, targetID); err != nil {
+		return 0, fmt.Errorf("failed to count expected checksums: %w", err)
+	}
+	return count, nil
+}
+
+// DeleteByPaths removes the checksums expected for the given paths
+func (r *ExpectedChecksumRepository) DeleteByPaths(ctx context.Context, targetID int64, paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+
+	query := `DELETE FROM expected_checksums WHERE storage_target_id = $1 AND path = ANY($2)`
+	if _, err := r.db.ExecContext(ctx, query, targetID, pq.Array(paths)); err != nil {
+		return fmt.Errorf("failed to delete expected checksums: %w", err)
+	}
+
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 // FileRepository handles file operations
@@ -56,6 +57,66 @@ func (r *FileRepository) GetByPath(ctx context.Context, targetID int64, path str
 	return &file, nil
 }
 
+// ListUnderPath returns the active files at path or beneath it, treating
+// path as a directory
+func (r *FileRepository) ListUnderPath(ctx context.Context, targetID int64, path string) ([]*File, error) {
+	query := `
+		SELECT * FROM files
+		WHERE storage_target_id = $1
+		  AND deleted_at IS NULL
+		  AND (path = $2 OR left(path, length($2) + 1) = $2 || '/')
+		ORDER BY path`
+
+	var files []*File
+	if err := r.db.SelectContext(ctx, &files, query, targetID, path); err != nil {
+		return nil, fmt.Errorf("failed to list files under path: %w", err)
+	}
+
+	return files, nil
+}
+
+// ListUnderPathBatch returns the next active files at path or beneath it
+// after afterPath, in path order, so large subtrees can be read a page at a
+// time. An empty path covers the whole target.
+func (r *FileRepository) ListUnderPathBatch(ctx context.Context, targetID int64, path, afterPath string, limit int) ([]*File, error) {
+	query := `
+		SELECT * FROM files
+		WHERE storage_target_id = $1
+		  AND deleted_at IS NULL
+		  AND ($2 = '' OR path = $2 OR left(path, length($2) + 1) = $2 || '/')
+		  AND path > $3
+		ORDER BY path
+		LIMIT $4`
+
+	var files []*File
+	if err := r.db.SelectContext(ctx, &files, query, targetID, path, afterPath, limit); err != nil {
+		return nil, fmt.Errorf("failed to list files under path: %w", err)
+	}
+
+	return files, nil
+}
+
+// ListByPaths returns the active files of a target at any of the given paths
+func (r *FileRepository) ListByPaths(ctx context.Context, targetID int64, paths []string) ([]*File, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		SELECT * FROM files
+		WHERE storage_target_id = $1
+		  AND path = ANY($2)
+		  AND deleted_at IS NULL
+		ORDER BY path`
+
+	var files []*File
+	if err := r.db.SelectContext(ctx, &files, query, targetID, pq.Array(paths)); err != nil {
+		return nil, fmt.Errorf("failed to list files by path: %w", err)
+	}
+
+	return files, nil
+}
+
 // List retrieves files matching the given filters
 func (r *FileRepository) List(ctx context.Context, filters FileFilters) ([]*File, error) {
 	query := `SELECT * FROM files WHERE 1=1`
@@ -166,19 +227,28 @@ func (r *FileRepository) Count(ctx context.Context, filters FileFilters) (int64,
 
 // Create creates a new file record
 func (r *FileRepository) Create(ctx context.Context, file *File) error {
+	if file.FileType == "" {
+		file.FileType = FileTypeRegular
+	}
+
 	query := `
 		INSERT INTO files (
 			storage_target_id, path, size, first_seen, last_seen,
 			current_checksum, checksum_type, last_checksummed_at,
-			created_at, updated_at
+			file_type, link_target,
+			mode, uid, gid, xattr_digest, server_checksum,
+			mod_time, created_at, updated_at
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW()
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, NOW(), NOW()
 		) RETURNING id, created_at, updated_at`
 
 	err := r.db.QueryRowContext(
 		ctx, query,
 		file.StorageTargetID, file.Path, file.Size, file.FirstSeen, file.LastSeen,
 		file.CurrentChecksum, file.ChecksumType, file.LastChecksummedAt,
+		file.FileType, file.LinkTarget,
+		file.Mode, file.UID, file.GID, file.XattrDigest, file.ServerChecksum,
+		file.ModTime,
 	).Scan(&file.ID, &file.CreatedAt, &file.UpdatedAt)
 
 	if err != nil {
@@ -204,9 +274,11 @@ func (r *FileRepository) CreateBatch(ctx context.Context, files []*File) error {
 		INSERT INTO files (
 			storage_target_id, path, size, first_seen, last_seen,
 			current_checksum, checksum_type, last_checksummed_at,
-			created_at, updated_at
+			file_type, link_target,
+			mode, uid, gid, xattr_digest, server_checksum,
+			mod_time, created_at, updated_at
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW()
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, NOW(), NOW()
 		) RETURNING id, created_at, updated_at`
 
 	stmt, err := tx.PreparexContext(ctx, query)
@@ -216,10 +288,16 @@ func (r *FileRepository) CreateBatch(ctx context.Context, files []*File) error {
 	defer stmt.Close()
 
 	for _, file := range files {
+		if file.FileType == "" {
+			file.FileType = FileTypeRegular
+		}
 		err := stmt.QueryRowContext(
 			ctx,
 			file.StorageTargetID, file.Path, file.Size, file.FirstSeen, file.LastSeen,
 			file.CurrentChecksum, file.ChecksumType, file.LastChecksummedAt,
+			file.FileType, file.LinkTarget,
+			file.Mode, file.UID, file.GID, file.XattrDigest, file.ServerChecksum,
+			file.ModTime,
 		).Scan(&file.ID, &file.CreatedAt, &file.UpdatedAt)
 
 		if err != nil {
@@ -236,6 +314,10 @@ func (r *FileRepository) CreateBatch(ctx context.Context, files []*File) error {
 
 // Update updates an existing file record
 func (r *FileRepository) Update(ctx context.Context, file *File) error {
+	if file.FileType == "" {
+		file.FileType = FileTypeRegular
+	}
+
 	query := `
 		UPDATE files SET
 			size = $2,
@@ -244,6 +326,15 @@ func (r *FileRepository) Update(ctx context.Context, file *File) error {
 			checksum_type = $5,
 			last_checksummed_at = $6,
 			deleted_at = $7,
+			file_type = $8,
+			link_target = $9,
+			mode = $10,
+			uid = $11,
+			gid = $12,
+			xattr_digest = $13,
+			server_checksum = $14,
+			excluded = $15,
+			mod_time = $16,
 			updated_at = NOW()
 		WHERE id = $1
 		RETURNING updated_at`
@@ -252,7 +343,9 @@ func (r *FileRepository) Update(ctx context.Context, file *File) error {
 		ctx, query,
 		file.ID, file.Size, file.LastSeen,
 		file.CurrentChecksum, file.ChecksumType, file.LastChecksummedAt,
-		file.DeletedAt,
+		file.DeletedAt, file.FileType, file.LinkTarget,
+		file.Mode, file.UID, file.GID, file.XattrDigest, file.ServerChecksum,
+		file.Excluded, file.ModTime,
 	).Scan(&file.UpdatedAt)
 
 	if err != nil {
@@ -262,6 +355,84 @@ func (r *FileRepository) Update(ctx context.Context, file *File) error {
 	return nil
 }
 
+// UpdateMetadata replaces the permissions, ownership and extended attribute
+// digest of a file
+func (r *FileRepository) UpdateMetadata(ctx context.Context, id int64, metadata FileMetadata) error {
+	query := `
+		UPDATE files SET
+			mode = $2,
+			uid = $3,
+			gid = $4,
+			xattr_digest = $5,
+			updated_at = NOW()
+		WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, id, metadata.Mode, metadata.UID, metadata.GID, metadata.XattrDigest)
+	if err != nil {
+		return fmt.Errorf("failed to update file metadata: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateServerChecksum records the checksum the server keeps for a file
+func (r *FileRepository) UpdateServerChecksum(ctx context.Context, id int64, checksum string) error {
+	query := `UPDATE files SET server_checksum = $2, updated_at = NOW() WHERE id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, id, checksum); err != nil {
+		return fmt.Errorf("failed to update server checksum: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateModTime records the modification time a file was seen with
+func (r *FileRepository) UpdateModTime(ctx context.Context, id int64, modTime time.Time) error {
+	query := `UPDATE files SET mod_time = $2, updated_at = NOW() WHERE id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, id, modTime); err != nil {
+		return fmt.Errorf("failed to update file modification time: %w", err)
+	}
+
+	return nil
+}
+
+// Move gives a file record a new path and marks it present again, keeping
+// its history
+func (r *FileRepository) Move(ctx context.Context, id int64, path string) error {
+	query := `UPDATE files SET path = $2, deleted_at = NULL, excluded = FALSE, updated_at = NOW() WHERE id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, id, path); err != nil {
+		return fmt.Errorf("failed to move file: %w", err)
+	}
+
+	return nil
+}
+
+// ListDeletedByChecksum returns the files of a target deleted since the given
+// time whose content has one of the checksums, most recently deleted first.
+// Files excluded by the target's path rules are left out.
+func (r *FileRepository) ListDeletedByChecksum(ctx context.Context, targetID int64, checksums []string, since time.Time) ([]*File, error) {
+	if len(checksums) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		SELECT * FROM files
+		WHERE current_checksum = ANY($2)
+		  AND storage_target_id = $1
+		  AND deleted_at >= $3
+		  AND NOT excluded
+		ORDER BY deleted_at DESC, id`
+
+	var files []*File
+	if err := r.db.SelectContext(ctx, &files, query, targetID, pq.Array(checksums), since); err != nil {
+		return nil, fmt.Errorf("failed to list deleted files by checksum: %w", err)
+	}
+
+	return files, nil
+}
+
 // Delete hard deletes a file record (not recommended, use soft delete via Update)
 func (r *FileRepository) Delete(ctx context.Context, id int64) error {
 	query := `DELETE FROM files WHERE id = $1`
@@ -337,3 +508,135 @@ func (r *FileRepository) GetVerificationStats(
 
 	return &stats, nil
 }
+
+// CoverageStats summarizes how much of a target has been verified within a
+// verification window
+type CoverageStats struct {
+	TotalFiles         int64      `db:"total_files"`
+	TotalBytes         int64      `db:"total_bytes"`
+	CoveredFiles       int64      `db:"covered_files"`
+	CoveredBytes       int64      `db:"covered_bytes"`
+	OverdueFiles       int64      `db:"overdue_files"`
+	OverdueBytes       int64      `db:"overdue_bytes"`
+	AtRiskFiles        int64      `db:"at_risk_files"`
+	AtRiskBytes        int64      `db:"at_risk_bytes"`
+	OldestVerification *time.Time `db:"oldest_verification"`
+}
+
+// GetCoverageStats returns verification coverage for a storage target. Files
+// last verified before windowStart (or never) are overdue; files last verified
+// before riskStart are still covered but will fall out of the window soon.
+func (r *FileRepository) GetCoverageStats(
+	ctx context.Context,
+	targetID int64,
+	windowStart time.Time,
+	riskStart time.Time,
+) (*CoverageStats, error) {
+	query := `
+		SELECT
+			COUNT(*) as total_files,
+			COALESCE(SUM(size), 0) as total_bytes,
+			COUNT(*) FILTER (WHERE last_checksummed_at >= $2) as covered_files,
+			COALESCE(SUM(size) FILTER (WHERE last_checksummed_at >= $2), 0) as covered_bytes,
+			COUNT(*) FILTER (WHERE last_checksummed_at IS NULL OR last_checksummed_at < $2) as overdue_files,
+			COALESCE(SUM(size) FILTER (WHERE last_checksummed_at IS NULL OR last_checksummed_at < $2), 0) as overdue_bytes,
+			COUNT(*) FILTER (WHERE last_checksummed_at >= $2 AND last_checksummed_at < $3) as at_risk_files,
+			COALESCE(SUM(size) FILTER (WHERE last_checksummed_at >= $2 AND last_checksummed_at < $3), 0) as at_risk_bytes,
+			MIN(last_checksummed_at) as oldest_verification
+		FROM files
+		WHERE storage_target_id = $1 AND deleted_at IS NULL`
+
+	var stats CoverageStats
+	if err := r.db.GetContext(ctx, &stats, query, targetID, windowStart, riskStart); err != nil {
+		return nil, fmt.Errorf("failed to get coverage stats: %w", err)
+	}
+
+	return &stats, nil
+}
+
+// ListVerificationDue returns active files last verified before the given time
+// (or never), oldest first
+func (r *FileRepository) ListVerificationDue(
+	ctx context.Context,
+	targetID int64,
+	before time.Time,
+	limit int,
+) ([]*File, error) {
+	query := `
+		SELECT * FROM files
+		WHERE storage_target_id = $1
+		  AND deleted_at IS NULL
+		  AND (last_checksummed_at IS NULL OR last_checksummed_at < $2)
+		ORDER BY last_checksummed_at ASC NULLS FIRST, path
+		LIMIT $3`
+
+	var files []*File
+	if err := r.db.SelectContext(ctx, &files, query, targetID, before, limit); err != nil {
+		return nil, fmt.Errorf("failed to list files due for verification: %w", err)
+	}
+
+	return files, nil
+}
+
+// VerificationCursor marks a position in verification order, so a long
+// verification run can page through files while it updates them
+type VerificationCursor struct {
+	LastChecksummedAt *time.Time
+	ID                int64
+}
+
+// ListVerificationBatch returns the next active files in verification order
+// (least recently verified first) after cursor, limited to files last verified
+// before the given time. A zero cursor starts from the beginning.
+func (r *FileRepository) ListVerificationBatch(
+	ctx context.Context,
+	targetID int64,
+	before time.Time,
+	cursor VerificationCursor,
+	limit int,
+) ([]*File, error) {
+	query := `
+		SELECT * FROM files
+		WHERE storage_target_id = $1
+		  AND deleted_at IS NULL
+		  AND (last_checksummed_at IS NULL OR last_checksummed_at < $2)
+		  AND file_type = 'regular'
+		  AND (COALESCE(last_checksummed_at, '-infinity'::timestamptz), id) >
+		      (COALESCE($3::timestamptz, '-infinity'::timestamptz), $4)
+		ORDER BY COALESCE(last_checksummed_at, '-infinity'::timestamptz), id
+		LIMIT $5`
+
+	var files []*File
+	if err := r.db.SelectContext(ctx, &files, query, targetID, before, cursor.LastChecksummedAt, cursor.ID, limit); err != nil {
+		return nil, fmt.Errorf("failed to list files for verification: %w", err)
+	}
+
+	return files, nil
+}
+
+// ListAcrossTargets returns the next active files of the given targets
+// ordered by path and target, after the cursor's path and target. Copies of
+// the same path on different targets are therefore adjacent. An empty cursor
+// path starts from the beginning.
+func (r *FileRepository) ListAcrossTargets(
+	ctx context.Context,
+	targetIDs []int64,
+	afterPath string,
+	afterTargetID int64,
+	limit int,
+) ([]*File, error) {
+	query := `
+		SELECT * FROM files
+		WHERE storage_target_id = ANY($1)
+		  AND deleted_at IS NULL
+		  AND (path, storage_target_id) > ($2, $3)
+		ORDER BY path, storage_target_id
+		LIMIT $4`
+
+	var files []*File
+	if err := r.db.SelectContext(ctx, &files, query, pq.Array(targetIDs), afterPath, afterTargetID, limit); err != nil {
+		return nil, fmt.Errorf("failed to list files across targets: %w", err)
+	}
+
+	return files, nil
+}
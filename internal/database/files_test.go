@@ -138,6 +138,37 @@ func TestFileRepository_GetByPath(t *testing.T) {
 	})
 }
 
+func TestFileRepository_ListUnderPath(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupDB(t, db)
+
+	target := testutil.MustCreateStorageTarget(t, db, "test-target")
+	for _, path := range []string{"photos", "photos/a.jpg", "photos/2024/b.jpg", "photos_old/c.jpg", "photo"} {
+		testutil.MustCreateFile(t, db, target.ID, path)
+	}
+
+	files, err := db.Files.ListUnderPath(context.Background(), target.ID, "photos")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	expected := []string{"photos", "photos/2024/b.jpg", "photos/a.jpg"}
+	if len(paths) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, paths)
+	}
+	for i := range expected {
+		if paths[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected, paths)
+			break
+		}
+	}
+}
+
 func TestFileRepository_List(t *testing.T) {
 	db := testutil.NewTestDB(t)
 	defer db.Close()
@@ -280,6 +311,52 @@ func TestFileRepository_Update(t *testing.T) {
 	})
 }
 
+func TestFileRepository_Move(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupDB(t, db)
+
+	ctx := context.Background()
+	target := testutil.MustCreateStorageTarget(t, db, "test-target")
+	file := testutil.MustCreateFile(t, db, target.ID, "/old/file.txt")
+	testutil.MustCreateFile(t, db, target.ID, "/old/other.txt")
+
+	deletedAt := time.Now()
+	file.DeletedAt = &deletedAt
+	if err := db.Files.Update(ctx, file); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Run("lists recently deleted files by checksum", func(t *testing.T) {
+		files, err := db.Files.ListDeletedByChecksum(ctx, target.ID, []string{"abc123"}, time.Now().Add(-time.Hour))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(files) != 1 || files[0].ID != file.ID {
+			t.Errorf("expected only the deleted file, got %d files", len(files))
+		}
+
+		files, _ = db.Files.ListDeletedByChecksum(ctx, target.ID, []string{"abc123"}, time.Now().Add(time.Hour))
+		if len(files) != 0 {
+			t.Errorf("expected files deleted before the window to be left out, got %d", len(files))
+		}
+	})
+
+	t.Run("moves and restores the record", func(t *testing.T) {
+		if err := db.Files.Move(ctx, file.ID, "/new/file.txt"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		moved, err := db.Files.GetByID(ctx, file.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if moved.Path != "/new/file.txt" || moved.DeletedAt != nil {
+			t.Errorf("expected an active record at /new/file.txt, got %s (deleted %v)", moved.Path, moved.DeletedAt)
+		}
+	})
+}
+
 func TestFileRepository_CreateBatch(t *testing.T) {
 	db := testutil.NewTestDB(t)
 	defer db.Close()
@@ -463,3 +540,81 @@ func TestFileRepository_GetVerificationStats(t *testing.T) {
 		}
 	})
 }
+
+func TestFileRepository_CoverageStats(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupDB(t, db)
+
+	target := testutil.MustCreateStorageTarget(t, db, "test-target")
+
+	now := time.Now()
+	windowStart := now.Add(-30 * 24 * time.Hour)
+	riskStart := now.Add(-25 * 24 * time.Hour)
+
+	// Verified recently, comfortably inside the window
+	file1 := testutil.MustCreateFile(t, db, target.ID, "/file1.txt")
+	recent := now.Add(-3 * 24 * time.Hour)
+	file1.LastChecksummedAt = &recent
+	db.Files.Update(context.Background(), file1)
+
+	// Inside the window but close to falling out of it
+	file2 := testutil.MustCreateFile(t, db, target.ID, "/file2.txt")
+	aging := now.Add(-27 * 24 * time.Hour)
+	file2.LastChecksummedAt = &aging
+	db.Files.Update(context.Background(), file2)
+
+	// Outside the window
+	file3 := testutil.MustCreateFile(t, db, target.ID, "/file3.txt")
+	old := now.Add(-45 * 24 * time.Hour)
+	file3.LastChecksummedAt = &old
+	db.Files.Update(context.Background(), file3)
+
+	// Never verified
+	file4 := testutil.MustCreateFile(t, db, target.ID, "/file4.txt")
+	file4.LastChecksummedAt = nil
+	db.Files.Update(context.Background(), file4)
+
+	t.Run("calculates coverage against the window", func(t *testing.T) {
+		stats, err := db.Files.GetCoverageStats(context.Background(), target.ID, windowStart, riskStart)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if stats.TotalFiles != 4 {
+			t.Errorf("expected 4 total files, got %d", stats.TotalFiles)
+		}
+
+		if stats.CoveredFiles != 2 {
+			t.Errorf("expected 2 covered files, got %d", stats.CoveredFiles)
+		}
+
+		if stats.OverdueFiles != 2 {
+			t.Errorf("expected 2 overdue files, got %d", stats.OverdueFiles)
+		}
+
+		if stats.AtRiskFiles != 1 {
+			t.Errorf("expected 1 at-risk file, got %d", stats.AtRiskFiles)
+		}
+
+		if stats.TotalBytes != stats.CoveredBytes+stats.OverdueBytes {
+			t.Errorf("expected covered and overdue bytes to sum to %d, got %d+%d",
+				stats.TotalBytes, stats.CoveredBytes, stats.OverdueBytes)
+		}
+	})
+
+	t.Run("lists files due oldest first", func(t *testing.T) {
+		files, err := db.Files.ListVerificationDue(context.Background(), target.ID, riskStart, 10)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(files) != 3 {
+			t.Fatalf("expected 3 files due, got %d", len(files))
+		}
+
+		if files[0].Path != "/file4.txt" || files[1].Path != "/file3.txt" || files[2].Path != "/file2.txt" {
+			t.Errorf("unexpected order: %s, %s, %s", files[0].Path, files[1].Path, files[2].Path)
+		}
+	})
+}
@@ -1,6 +1,8 @@
 package database
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/lib/pq"
@@ -14,19 +16,96 @@ type File struct {
 	Size              int64     `db:"size"`
 	FirstSeen         time.Time `db:"first_seen"`
 	LastSeen          time.Time `db:"last_seen"`
+	ModTime           *time.Time `db:"mod_time"` // Modification time the file was last seen with
 	CurrentChecksum   *string   `db:"current_checksum"`
 	ChecksumType      *string   `db:"checksum_type"`
 	LastChecksummedAt *time.Time `db:"last_checksummed_at"`
 	DeletedAt         *time.Time `db:"deleted_at"`
+	Excluded          bool      `db:"excluded"` // Deleted because the target's path rules exclude it
+	FileType          FileType  `db:"file_type"`
+	LinkTarget        *string   `db:"link_target"`
+	ServerChecksum    *string   `db:"server_checksum"` // Checksum kept by the server, as "algorithm:hex"
+	FileMetadata
 	CreatedAt         time.Time `db:"created_at"`
 	UpdatedAt         time.Time `db:"updated_at"`
 }
 
+// FileMetadata is a file's permissions, ownership and extended attributes;
+// nil fields were not captured
+type FileMetadata struct {
+	Mode        *int    `db:"mode"` // POSIX permission bits
+	UID         *int64  `db:"uid"`
+	GID         *int64  `db:"gid"`
+	XattrDigest *string `db:"xattr_digest"` // Digest of extended attributes and ACLs
+}
+
+// Differs reports whether any field captured in both m and other differs
+func (m FileMetadata) Differs(other FileMetadata) bool {
+	if m.Mode != nil && other.Mode != nil && *m.Mode != *other.Mode {
+		return true
+	}
+	if m.UID != nil && other.UID != nil && *m.UID != *other.UID {
+		return true
+	}
+	if m.GID != nil && other.GID != nil && *m.GID != *other.GID {
+		return true
+	}
+	if m.XattrDigest != nil && other.XattrDigest != nil && *m.XattrDigest != *other.XattrDigest {
+		return true
+	}
+	return false
+}
+
+// Merge returns m with fields it did not capture taken from other
+func (m FileMetadata) Merge(other FileMetadata) FileMetadata {
+	if m.Mode == nil {
+		m.Mode = other.Mode
+	}
+	if m.UID == nil {
+		m.UID = other.UID
+	}
+	if m.GID == nil {
+		m.GID = other.GID
+	}
+	if m.XattrDigest == nil {
+		m.XattrDigest = other.XattrDigest
+	}
+	return m
+}
+
+// String describes the captured fields, e.g. "mode 0644, owner 1000:100"
+func (m FileMetadata) String() string {
+	parts := []string{}
+	if m.Mode != nil {
+		parts = append(parts, fmt.Sprintf("mode %04o", *m.Mode))
+	}
+	if m.UID != nil && m.GID != nil {
+		parts = append(parts, fmt.Sprintf("owner %d:%d", *m.UID, *m.GID))
+	}
+	if m.XattrDigest != nil {
+		digest := *m.XattrDigest
+		if len(digest) > 12 {
+			digest = digest[:12]
+		}
+		parts = append(parts, "xattrs "+digest)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// FileType distinguishes regular files from symlinks recorded as links
+type FileType string
+
+const (
+	FileTypeRegular FileType = "regular"
+	FileTypeSymlink FileType = "symlink"
+)
+
 // Scan represents a scan execution
 type Scan struct {
 	ID               int64       `db:"id"`
 	StorageTargetID  int64       `db:"storage_target_id"`
 	Status           ScanStatus  `db:"status"`
+	ScanType         ScanType    `db:"scan_type"`
 	StartedAt        time.Time   `db:"started_at"`
 	CompletedAt      *time.Time  `db:"completed_at"`
 	FilesScanned     int64       `db:"files_scanned"`
@@ -38,6 +117,17 @@ type Scan struct {
 	ErrorMessages    pq.StringArray `db:"error_messages"`
 	IsLargeChange    bool        `db:"is_large_change"`
 	ResumedFrom      *int64      `db:"resumed_from"`
+	WorkerConcurrency     *int     `db:"worker_concurrency"`
+	WorkerConcurrencyAvg  *float64 `db:"worker_concurrency_avg"`
+	WorkerConcurrencyPeak *int     `db:"worker_concurrency_peak"`
+	BytesHashed           int64    `db:"bytes_hashed"`
+	HashBytesPerSec       *float64 `db:"hash_bytes_per_sec"`
+	FilesMismatched       int64    `db:"files_mismatched"`
+	BudgetExhausted       bool     `db:"budget_exhausted"`
+	FilesSkipped          int64    `db:"files_skipped"`
+	DirsSkipped           int64    `db:"dirs_skipped"`
+	FilesMetadataChanged  int64    `db:"files_metadata_changed"`
+	FilesMoved            int64    `db:"files_moved"`
 	CreatedAt        time.Time   `db:"created_at"`
 }
 
@@ -51,6 +141,47 @@ const (
 	ScanStatusPartial   ScanStatus = "partial"
 )
 
+// ScanType distinguishes full discovery scans from verification-only runs
+// and incremental scans of watched paths
+type ScanType string
+
+const (
+	ScanTypeDiscovery    ScanType = "discovery"
+	ScanTypeVerification ScanType = "verification"
+	ScanTypeIncremental  ScanType = "incremental"
+)
+
+// ScanError records a file that could not be processed during a scan
+type ScanError struct {
+	ID         int64          `db:"id"`
+	ScanID     int64          `db:"scan_id"`
+	Path       string         `db:"path"`
+	Phase      ScanErrorPhase `db:"phase"`
+	ErrorClass string         `db:"error_class"`
+	Message    string         `db:"message"`
+	Retryable  bool           `db:"retryable"`
+	Attempts   int            `db:"attempts"`
+	CreatedAt  time.Time      `db:"created_at"`
+}
+
+// ScanErrorPhase identifies the scan stage in which an error occurred
+type ScanErrorPhase string
+
+const (
+	ScanErrorPhaseWalk ScanErrorPhase = "walk"
+	ScanErrorPhaseHash ScanErrorPhase = "hash"
+	ScanErrorPhaseSkip ScanErrorPhase = "skip" // Special files left out of the scan
+)
+
+// Directory records a directory's mtime and entry count as of the last walk
+// of its target
+type Directory struct {
+	StorageTargetID int64     `db:"storage_target_id"`
+	Path            string    `db:"path"`
+	ModTime         time.Time `db:"mod_time"`
+	Entries         int       `db:"entries"`
+}
+
 // ChangeEvent represents a file lifecycle event
 type ChangeEvent struct {
 	ID           int64           `db:"id"`
@@ -62,7 +193,18 @@ type ChangeEvent struct {
 	NewChecksum  *string         `db:"new_checksum"`
 	OldSize      *int64          `db:"old_size"`
 	NewSize      *int64          `db:"new_size"`
+	OldMetadata  *string         `db:"old_metadata"` // Set on metadata_changed events
+	NewMetadata  *string         `db:"new_metadata"`
+	MemberPath   *string         `db:"member_path"` // Set on member events, the path within the archive
+	OldPath      *string         `db:"old_path"`    // Set on moved events
+	NewPath      *string         `db:"new_path"`
 	CreatedAt    time.Time       `db:"created_at"`
+
+	// Position in the target's hash chain; nil for events recorded before it
+	ChainTargetID *int64  `db:"storage_target_id"`
+	ChainSeq      *int64  `db:"chain_seq"`
+	PrevHash      *string `db:"prev_hash"`
+	Hash          *string `db:"hash"`
 }
 
 // ChangeEventType represents the type of change event
@@ -73,8 +215,166 @@ const (
 	ChangeEventDeleted  ChangeEventType = "deleted"
 	ChangeEventModified ChangeEventType = "modified"
 	ChangeEventVerified ChangeEventType = "verified"
+
+	// ChangeEventMetadataChanged records a change to permissions, ownership
+	// or extended attributes of a file whose content is unchanged
+	ChangeEventMetadataChanged ChangeEventType = "metadata_changed"
+
+	// Member events record changes to the members of an archive, against
+	// the archive's file
+	ChangeEventMemberAdded    ChangeEventType = "member_added"
+	ChangeEventMemberDeleted  ChangeEventType = "member_deleted"
+	ChangeEventMemberModified ChangeEventType = "member_modified"
+
+	// ChangeEventMoved records a file found at a new path with the content
+	// of a file that disappeared; the file keeps its record and history
+	ChangeEventMoved ChangeEventType = "moved"
+
+	// ChangeEventExcluded records a file left out of scans by a change to
+	// the target's path rules; the file is not reported deleted
+	ChangeEventExcluded ChangeEventType = "excluded"
 )
 
+// ArchiveMember is a file inside a ZIP or TAR archive, hashed along with the
+// archive
+type ArchiveMember struct {
+	ID                int64      `db:"id"`
+	FileID            int64      `db:"file_id"` // The archive
+	Path              string     `db:"path"`    // Path within the archive
+	Size              int64      `db:"size"`
+	ModTime           *time.Time `db:"mod_time"`
+	Checksum          string     `db:"checksum"`
+	ChecksumType      string     `db:"checksum_type"`
+	FirstSeen         time.Time  `db:"first_seen"`
+	LastChecksummedAt time.Time  `db:"last_checksummed_at"`
+}
+
+// ExpectedChecksum is a known-good checksum imported from a manifest for a
+// file not yet recorded
+type ExpectedChecksum struct {
+	ID              int64     `db:"id"`
+	StorageTargetID int64     `db:"storage_target_id"`
+	Path            string    `db:"path"`
+	Checksum        string    `db:"checksum"`
+	ChecksumType    string    `db:"checksum_type"`
+	Source          *string   `db:"source"` // Manifest the checksum was imported from
+	ImportedAt      time.Time `db:"imported_at"`
+}
+
+// BagValidation is the result of validating a BagIt bag during a scan
+type BagValidation struct {
+	ID              int64     `db:"id"`
+	ScanID          int64     `db:"scan_id"`
+	StorageTargetID int64     `db:"storage_target_id"`
+	Path            string    `db:"path"` // Directory holding bagit.txt; empty for the target root
+	Valid           bool      `db:"valid"`
+	BagItVersion    *string   `db:"bagit_version"`
+	PayloadFiles    int       `db:"payload_files"`
+	ProblemCount    int       `db:"problem_count"` // All problems found, though not all may be stored
+	ValidatedAt     time.Time `db:"validated_at"`
+}
+
+// BagProblem is one reason a bag failed validation
+type BagProblem struct {
+	ID           int64   `db:"id"`
+	ValidationID int64   `db:"validation_id"`
+	Path         string  `db:"path"` // Relative to the bag; empty for the bag as a whole
+	Kind         string  `db:"kind"` // missing, extra, mismatch or invalid
+	Algorithm    *string `db:"algorithm"`
+	Expected     *string `db:"expected"`
+	Actual       *string `db:"actual"`
+	Message      string  `db:"message"`
+}
+
+// SigningKey is the public half of a key Fixity has signed with
+type SigningKey struct {
+	KeyID     string    `db:"key_id"`
+	PublicKey string    `db:"public_key"` // Base64 Ed25519 public key
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// AuditReport is a signed fixity audit report of a target over a period
+type AuditReport struct {
+	ID              int64     `db:"id"`
+	StorageTargetID int64     `db:"storage_target_id"`
+	PeriodStart     time.Time `db:"period_start"`
+	PeriodEnd       time.Time `db:"period_end"`
+	Format          string    `db:"format"` // html, csv or json
+	Content         []byte    `db:"content"`
+	SHA256          string    `db:"sha256"`
+	Signature       string    `db:"signature"` // Detached signature of Content, as JSON
+	KeyID           string    `db:"key_id"`
+	GeneratedBy     string    `db:"generated_by"`
+	GeneratedAt     time.Time `db:"generated_at"`
+	Size            int64     `db:"size"` // Length of Content; set when listing without it
+}
+
+// Attestation is a signed statement of the digests a target's files had,
+// for a file, a subtree or the files a scan hashed
+type Attestation struct {
+	ID              int64     `db:"id"`
+	StorageTargetID int64     `db:"storage_target_id"`
+	Scope           string    `db:"scope"`   // file, subtree or scan
+	Path            *string   `db:"path"`    // Set for file and subtree attestations
+	ScanID          *int64    `db:"scan_id"` // Set for scan attestations
+	FileCount       int64     `db:"file_count"`
+	Content         []byte    `db:"content"`
+	SHA256          string    `db:"sha256"`
+	Signature       string    `db:"signature"` // Detached signature of Content, as JSON
+	KeyID           string    `db:"key_id"`
+	IssuedBy        string    `db:"issued_by"`
+	IssuedAt        time.Time `db:"issued_at"`
+	Size            int64     `db:"size"` // Length of Content; set when listing without it
+}
+
+// ObservedDigest is a digest recorded for a file, and when it was computed
+// if known
+type ObservedDigest struct {
+	Path      string     `db:"path"`
+	Size      int64      `db:"size"`
+	Algorithm string     `db:"algorithm"`
+	Digest    string     `db:"digest"`
+	HashedAt  *time.Time `db:"hashed_at"`
+}
+
+// EventChainHead is the last event of a target's change event chain
+type EventChainHead struct {
+	StorageTargetID int64     `db:"storage_target_id"`
+	Seq             int64     `db:"seq"`  // 0 before the first event
+	Hash            string    `db:"hash"` // Empty before the first event
+	UpdatedAt       time.Time `db:"updated_at"`
+}
+
+// AuditCheckpoint is a signed snapshot of a target's event chain head and
+// recorded checksums
+type AuditCheckpoint struct {
+	ID              int64     `db:"id"`
+	StorageTargetID int64     `db:"storage_target_id"`
+	ChainSeq        int64     `db:"chain_seq"`
+	ChainHash       string    `db:"chain_hash"`
+	FilesDigest     string    `db:"files_digest"`
+	FileCount       int64     `db:"file_count"`
+	Payload         string    `db:"payload"`   // The signed JSON snapshot
+	Signature       string    `db:"signature"` // Detached signature of Payload, as JSON
+	KeyID           string    `db:"key_id"`
+	CreatedAt       time.Time `db:"created_at"`
+}
+
+// ScanTimestamp is an RFC 3161 time-stamp of the Merkle root of a target's
+// checksums when a scan completed
+type ScanTimestamp struct {
+	ScanID       int64      `db:"scan_id"`
+	MerkleRoot   string     `db:"merkle_root"` // Hex RFC 6962 tree hash
+	LeafCount    int64      `db:"leaf_count"`
+	TSAURL       string     `db:"tsa_url"`
+	Token        []byte     `db:"token"`         // DER time-stamp token; nil until the TSA issues one
+	GenTime      *time.Time `db:"gen_time"`      // Time the TSA asserts
+	SerialNumber *string    `db:"serial_number"` // The token's serial number, in decimal
+	Error        *string    `db:"error"`         // Why the last request to the TSA failed
+	CreatedAt    time.Time  `db:"created_at"`
+	UpdatedAt    time.Time  `db:"updated_at"`
+}
+
 // StorageTarget represents a monitored storage location
 type StorageTarget struct {
 	ID                              int64          `db:"id"`
@@ -84,6 +384,7 @@ type StorageTarget struct {
 	Server                          *string        `db:"server"`
 	Share                           *string        `db:"share"`
 	CredentialsRef                  *string        `db:"credentials_ref"`
+	HostKey                         *string        `db:"host_key"`
 	Enabled                         bool           `db:"enabled"`
 	ScanSchedule                    *string        `db:"scan_schedule"`
 	ParallelWorkers                 int            `db:"parallel_workers"`
@@ -94,6 +395,26 @@ type StorageTarget struct {
 	LargeChangeThresholdCount       *int           `db:"large_change_threshold_count"`
 	LargeChangeThresholdPercent     *float64       `db:"large_change_threshold_percent"`
 	LargeChangeThresholdBytes       *int64         `db:"large_change_threshold_bytes"`
+	ThrottleBytesPerSec             *int64         `db:"throttle_bytes_per_sec"`
+	ThrottleIOPS                    *int           `db:"throttle_iops"`
+	ThrottleSchedule                *string        `db:"throttle_schedule"`
+	AdaptiveWorkers                 bool           `db:"adaptive_workers"`
+	MinWorkers                      *int           `db:"min_workers"`
+	MaxWorkers                      *int           `db:"max_workers"`
+	ReadCacheMode                   string         `db:"read_cache_mode"`
+	ReadSize                        *int           `db:"read_size"`
+	VerificationWindowDays          *int           `db:"verification_window_days"`
+	IncludePatterns                 pq.StringArray `db:"include_patterns"`
+	ExcludePatterns                 pq.StringArray `db:"exclude_patterns"`
+	HonorIgnoreFiles                bool           `db:"honor_ignore_files"`
+	LinkPolicy                      string         `db:"link_policy"`
+	WalkConcurrency                 *int           `db:"walk_concurrency"`
+	WatchChanges                    bool           `db:"watch_changes"`
+	SkipUnchangedDirs               bool           `db:"skip_unchanged_dirs"`
+	CaptureXattrs                   bool           `db:"capture_xattrs"`
+	ScanArchives                    bool           `db:"scan_archives"`
+	ValidateBags                    bool           `db:"validate_bags"`
+	FullWalkDays                    *int           `db:"full_walk_days"`
 	CreatedAt                       time.Time      `db:"created_at"`
 	UpdatedAt                       time.Time      `db:"updated_at"`
 }
@@ -102,9 +423,64 @@ type StorageTarget struct {
 type StorageType string
 
 const (
-	StorageTypeLocal StorageType = "local"
-	StorageTypeNFS   StorageType = "nfs"
-	StorageTypeSMB   StorageType = "smb"
+	StorageTypeLocal  StorageType = "local"
+	StorageTypeNFS    StorageType = "nfs"
+	StorageTypeSMB    StorageType = "smb"
+	StorageTypeSFTP   StorageType = "sftp"
+	StorageTypeWebDAV StorageType = "webdav"
+)
+
+// ReplicaGroup links storage targets that should hold identical content
+type ReplicaGroup struct {
+	ID          int64     `db:"id"`
+	Name        string    `db:"name"`
+	Description *string   `db:"description"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
+// ReplicaComparison is a run comparing the files of a replica group's targets
+type ReplicaComparison struct {
+	ID             int64                   `db:"id"`
+	GroupID        int64                   `db:"group_id"`
+	Status         ReplicaComparisonStatus `db:"status"`
+	StartedAt      time.Time               `db:"started_at"`
+	CompletedAt    *time.Time              `db:"completed_at"`
+	FilesCompared  int64                   `db:"files_compared"`  // Distinct paths across the group
+	FilesMissing   int64                   `db:"files_missing"`   // Paths absent from at least one target
+	FilesDivergent int64                   `db:"files_divergent"` // Paths whose copies disagree
+	ErrorMessage   *string                 `db:"error_message"`
+}
+
+// ReplicaComparisonStatus represents the status of a replica comparison
+type ReplicaComparisonStatus string
+
+const (
+	ReplicaComparisonRunning   ReplicaComparisonStatus = "running"
+	ReplicaComparisonCompleted ReplicaComparisonStatus = "completed"
+	ReplicaComparisonFailed    ReplicaComparisonStatus = "failed"
+)
+
+// ReplicaDiscrepancy is a copy of a path that is missing from a target or
+// disagrees with the other copies. LikelyChecksum is the checksum most copies
+// agree on, or nil if no single checksum has the most votes.
+type ReplicaDiscrepancy struct {
+	ID              int64          `db:"id"`
+	ComparisonID    int64          `db:"comparison_id"`
+	Path            string         `db:"path"`
+	StorageTargetID int64          `db:"storage_target_id"`
+	Problem         ReplicaProblem `db:"problem"`
+	Checksum        *string        `db:"checksum"`
+	ChecksumType    *string        `db:"checksum_type"`
+	LikelyChecksum  *string        `db:"likely_checksum"`
+}
+
+// ReplicaProblem is what is wrong with a copy
+type ReplicaProblem string
+
+const (
+	ReplicaMissing   ReplicaProblem = "missing"
+	ReplicaDivergent ReplicaProblem = "divergent"
 )
 
 // ScanCheckpoint enables scan resumption after interruption
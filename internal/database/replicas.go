@@ -0,0 +1,277 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// ReplicaGroupRepository handles replica groups and their member targets
+type ReplicaGroupRepository struct {
+	db *sqlx.DB
+}
+
+// GetByID retrieves a replica group by ID
+func (r *ReplicaGroupRepository) GetByID(ctx context.Context, id int64) (*ReplicaGroup, error) {
+	var group ReplicaGroup
+	query := `SELECT * FROM replica_groups WHERE id = $1`
+	if err := r.db.GetContext(ctx, &group, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("replica group not found: %d", id)
+		}
+		return nil, fmt.Errorf("failed to get replica group: %w", err)
+	}
+	return &group, nil
+}
+
+// ListAll retrieves all replica groups
+func (r *ReplicaGroupRepository) ListAll(ctx context.Context) ([]*ReplicaGroup, error) {
+	query := `SELECT * FROM replica_groups ORDER BY name`
+
+	var groups []*ReplicaGroup
+	if err := r.db.SelectContext(ctx, &groups, query); err != nil {
+		return nil, fmt.Errorf("failed to list replica groups: %w", err)
+	}
+
+	return groups, nil
+}
+
+// Create creates a new replica group
+func (r *ReplicaGroupRepository) Create(ctx context.Context, group *ReplicaGroup) error {
+	query := `
+		INSERT INTO replica_groups (name, description, created_at, updated_at)
+		VALUES ($1, $2, NOW(), NOW())
+		RETURNING id, created_at, updated_at`
+
+	err := r.db.QueryRowContext(ctx, query, group.Name, group.Description).
+		Scan(&group.ID, &group.CreatedAt, &group.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create replica group: %w", err)
+	}
+
+	return nil
+}
+
+// Update updates an existing replica group
+func (r *ReplicaGroupRepository) Update(ctx context.Context, group *ReplicaGroup) error {
+	query := `
+		UPDATE replica_groups SET name = $2, description = $3, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at`
+
+	if err := r.db.QueryRowContext(ctx, query, group.ID, group.Name, group.Description).Scan(&group.UpdatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("replica group not found: %d", group.ID)
+		}
+		return fmt.Errorf("failed to update replica group: %w", err)
+	}
+
+	return nil
+}
+
+// Delete deletes a replica group along with its comparisons
+func (r *ReplicaGroupRepository) Delete(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM replica_groups WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete replica group: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("replica group not found: %d", id)
+	}
+
+	return nil
+}
+
+// ListTargets retrieves the storage targets of a replica group
+func (r *ReplicaGroupRepository) ListTargets(ctx context.Context, groupID int64) ([]*StorageTarget, error) {
+	query := `
+		SELECT t.* FROM storage_targets t
+		JOIN replica_group_targets g ON g.storage_target_id = t.id
+		WHERE g.group_id = $1
+		ORDER BY t.name`
+
+	var targets []*StorageTarget
+	if err := r.db.SelectContext(ctx, &targets, query, groupID); err != nil {
+		return nil, fmt.Errorf("failed to list replica group targets: %w", err)
+	}
+
+	return targets, nil
+}
+
+// SetTargets replaces the storage targets of a replica group in a single
+// transaction
+func (r *ReplicaGroupRepository) SetTargets(ctx context.Context, groupID int64, targetIDs []int64) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM replica_group_targets WHERE group_id = $1`, groupID); err != nil {
+		return fmt.Errorf("failed to clear replica group targets: %w", err)
+	}
+
+	query := `
+		INSERT INTO replica_group_targets (group_id, storage_target_id)
+		SELECT $1, UNNEST($2::BIGINT[])
+		ON CONFLICT DO NOTHING`
+	if _, err := tx.ExecContext(ctx, query, groupID, pq.Array(targetIDs)); err != nil {
+		return fmt.Errorf("failed to set replica group targets: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ReplicaComparisonRepository handles replica comparisons and the
+// discrepancies they find
+type ReplicaComparisonRepository struct {
+	db *sqlx.DB
+}
+
+// GetByID retrieves a replica comparison by ID
+func (r *ReplicaComparisonRepository) GetByID(ctx context.Context, id int64) (*ReplicaComparison, error) {
+	var comparison ReplicaComparison
+	query := `SELECT * FROM replica_comparisons WHERE id = $1`
+	if err := r.db.GetContext(ctx, &comparison, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("replica comparison not found: %d", id)
+		}
+		return nil, fmt.Errorf("failed to get replica comparison: %w", err)
+	}
+	return &comparison, nil
+}
+
+// ListByGroup retrieves the most recent comparisons of a replica group
+func (r *ReplicaComparisonRepository) ListByGroup(ctx context.Context, groupID int64, limit int) ([]*ReplicaComparison, error) {
+	query := `
+		SELECT * FROM replica_comparisons
+		WHERE group_id = $1
+		ORDER BY started_at DESC, id DESC
+		LIMIT $2`
+
+	var comparisons []*ReplicaComparison
+	if err := r.db.SelectContext(ctx, &comparisons, query, groupID, limit); err != nil {
+		return nil, fmt.Errorf("failed to list replica comparisons: %w", err)
+	}
+
+	return comparisons, nil
+}
+
+// Create creates a new replica comparison record
+func (r *ReplicaComparisonRepository) Create(ctx context.Context, comparison *ReplicaComparison) error {
+	query := `
+		INSERT INTO replica_comparisons (group_id, status, started_at)
+		VALUES ($1, $2, $3)
+		RETURNING id`
+
+	err := r.db.QueryRowContext(ctx, query, comparison.GroupID, comparison.Status, comparison.StartedAt).
+		Scan(&comparison.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create replica comparison: %w", err)
+	}
+
+	return nil
+}
+
+// Update updates a replica comparison's status and counts
+func (r *ReplicaComparisonRepository) Update(ctx context.Context, comparison *ReplicaComparison) error {
+	query := `
+		UPDATE replica_comparisons SET
+			status = $2,
+			completed_at = $3,
+			files_compared = $4,
+			files_missing = $5,
+			files_divergent = $6,
+			error_message = $7
+		WHERE id = $1`
+
+	_, err := r.db.ExecContext(
+		ctx, query,
+		comparison.ID, comparison.Status, comparison.CompletedAt,
+		comparison.FilesCompared, comparison.FilesMissing, comparison.FilesDivergent,
+		comparison.ErrorMessage,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update replica comparison: %w", err)
+	}
+
+	return nil
+}
+
+// CreateDiscrepancies records discrepancies in a single transaction
+func (r *ReplicaComparisonRepository) CreateDiscrepancies(ctx context.Context, discrepancies []*ReplicaDiscrepancy) error {
+	if len(discrepancies) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO replica_discrepancies (
+			comparison_id, path, storage_target_id, problem,
+			checksum, checksum_type, likely_checksum
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7
+		) RETURNING id`
+
+	stmt, err := tx.PreparexContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, d := range discrepancies {
+		err := stmt.QueryRowContext(
+			ctx,
+			d.ComparisonID, d.Path, d.StorageTargetID, d.Problem,
+			d.Checksum, d.ChecksumType, d.LikelyChecksum,
+		).Scan(&d.ID)
+		if err != nil {
+			return fmt.Errorf("failed to insert discrepancy for %s: %w", d.Path, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ListDiscrepancies retrieves the discrepancies of a comparison ordered by
+// path. A limit of 0 returns all of them.
+func (r *ReplicaComparisonRepository) ListDiscrepancies(ctx context.Context, comparisonID int64, limit int) ([]*ReplicaDiscrepancy, error) {
+	query := `
+		SELECT * FROM replica_discrepancies
+		WHERE comparison_id = $1
+		ORDER BY path, storage_target_id`
+	args := []interface{}{comparisonID}
+
+	if limit > 0 {
+		query += ` LIMIT $2`
+		args = append(args, limit)
+	}
+
+	var discrepancies []*ReplicaDiscrepancy
+	if err := r.db.SelectContext(ctx, &discrepancies, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to list discrepancies: %w", err)
+	}
+
+	return discrepancies, nil
+}
@@ -0,0 +1,74 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jeffanddom/fixity/internal/database"
+	"github.com/jeffanddom/fixity/tests/testutil"
+)
+
+func TestReplicaGroupRepository(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupDB(t, db)
+
+	ctx := context.Background()
+	primary := testutil.MustCreateStorageTarget(t, db, "primary")
+	dr := testutil.MustCreateStorageTarget(t, db, "dr")
+
+	group := &database.ReplicaGroup{Name: "collection"}
+	if err := db.ReplicaGroups.Create(ctx, group); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Run("sets and replaces targets", func(t *testing.T) {
+		if err := db.ReplicaGroups.SetTargets(ctx, group.ID, []int64{primary.ID, dr.ID, dr.ID}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		targets, _ := db.ReplicaGroups.ListTargets(ctx, group.ID)
+		if len(targets) != 2 || targets[0].Name != "dr" {
+			t.Errorf("expected dr and primary, got %d targets", len(targets))
+		}
+
+		db.ReplicaGroups.SetTargets(ctx, group.ID, []int64{primary.ID})
+		targets, _ = db.ReplicaGroups.ListTargets(ctx, group.ID)
+		if len(targets) != 1 || targets[0].ID != primary.ID {
+			t.Errorf("expected only primary, got %d targets", len(targets))
+		}
+	})
+
+	t.Run("lists files across targets in path order", func(t *testing.T) {
+		testutil.MustCreateFile(t, db, dr.ID, "/b.txt")
+		testutil.MustCreateFile(t, db, primary.ID, "/b.txt")
+		testutil.MustCreateFile(t, db, primary.ID, "/a.txt")
+
+		files, err := db.Files.ListAcrossTargets(ctx, []int64{primary.ID, dr.ID}, "", 0, 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(files) != 2 || files[0].Path != "/a.txt" || files[1].Path != "/b.txt" {
+			t.Fatalf("unexpected first page: %d files", len(files))
+		}
+
+		last := files[1]
+		files, _ = db.Files.ListAcrossTargets(ctx, []int64{primary.ID, dr.ID}, last.Path, last.StorageTargetID, 2)
+		if len(files) != 1 || files[0].Path != "/b.txt" || files[0].StorageTargetID == last.StorageTargetID {
+			t.Errorf("expected the other copy of /b.txt, got %d files", len(files))
+		}
+	})
+
+	t.Run("deletes group with its comparisons", func(t *testing.T) {
+		comparison := &database.ReplicaComparison{GroupID: group.ID, Status: database.ReplicaComparisonRunning}
+		if err := db.ReplicaComparisons.Create(ctx, comparison); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := db.ReplicaGroups.Delete(ctx, group.ID); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := db.ReplicaComparisons.GetByID(ctx, comparison.ID); err == nil {
+			t.Error("expected the comparison to be deleted with its group")
+		}
+	})
+}
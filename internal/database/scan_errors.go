@@ -0,0 +1,125 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ScanErrorRepository handles per-file scan error operations
+type ScanErrorRepository struct {
+	db *sqlx.DB
+}
+
+// ScanErrorFilters holds filtering options for scan error queries
+type ScanErrorFilters struct {
+	ScanID *int64
+	Phase  *ScanErrorPhase
+	Limit  int
+	Offset int
+}
+
+// List retrieves scan errors matching the given filters
+func (r *ScanErrorRepository) List(ctx context.Context, filters ScanErrorFilters) ([]*ScanError, error) {
+	query := `SELECT * FROM scan_errors WHERE 1=1`
+	args := []interface{}{}
+	argNum := 1
+
+	if filters.ScanID != nil {
+		query += fmt.Sprintf(" AND scan_id = $%d", argNum)
+		args = append(args, *filters.ScanID)
+		argNum++
+	}
+
+	if filters.Phase != nil {
+		query += fmt.Sprintf(" AND phase = $%d", argNum)
+		args = append(args, *filters.Phase)
+		argNum++
+	}
+
+	query += " ORDER BY id"
+
+	if filters.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", argNum)
+		args = append(args, filters.Limit)
+		argNum++
+	}
+
+	if filters.Offset > 0 {
+		query += fmt.Sprintf(" OFFSET $%d", argNum)
+		args = append(args, filters.Offset)
+		argNum++
+	}
+
+	var scanErrors []*ScanError
+	if err := r.db.SelectContext(ctx, &scanErrors, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to list scan errors: %w", err)
+	}
+
+	return scanErrors, nil
+}
+
+// GetByScan retrieves all errors recorded for a scan
+func (r *ScanErrorRepository) GetByScan(ctx context.Context, scanID int64) ([]*ScanError, error) {
+	return r.List(ctx, ScanErrorFilters{ScanID: &scanID})
+}
+
+// CountByScan returns the number of errors recorded for a scan
+func (r *ScanErrorRepository) CountByScan(ctx context.Context, scanID int64) (int64, error) {
+	var count int64
+	query := `SELECT COUNT(*) FROM scan_errors WHERE scan_id = $1`
+	if err := r.db.GetContext(ctx, &count, query, scanID); err != nil {
+		return 0, fmt.Errorf("failed to count scan errors: %w", err)
+	}
+	return count, nil
+}
+
+// CreateBatch creates multiple scan error records in a single transaction
+func (r *ScanErrorRepository) CreateBatch(ctx context.Context, scanErrors []*ScanError) error {
+	if len(scanErrors) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO scan_errors (
+			scan_id, path, phase, error_class, message, retryable, attempts,
+			created_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, NOW()
+		) RETURNING id, created_at`
+
+	stmt, err := tx.PreparexContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, scanErr := range scanErrors {
+		if scanErr.Attempts < 1 {
+			scanErr.Attempts = 1
+		}
+
+		err := stmt.QueryRowContext(
+			ctx,
+			scanErr.ScanID, scanErr.Path, scanErr.Phase, scanErr.ErrorClass,
+			scanErr.Message, scanErr.Retryable, scanErr.Attempts,
+		).Scan(&scanErr.ID, &scanErr.CreatedAt)
+
+		if err != nil {
+			return fmt.Errorf("failed to insert scan error: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jeffanddom/fixity/internal/database"
+	"github.com/jeffanddom/fixity/tests/testutil"
+)
+
+func TestScanErrorRepository_CreateBatch(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupDB(t, db)
+
+	target := testutil.MustCreateStorageTarget(t, db, "test-target")
+	scan := testutil.MustCreateScan(t, db, target.ID)
+
+	t.Run("creates scan errors", func(t *testing.T) {
+		scanErrors := []*database.ScanError{
+			{
+				ScanID:     scan.ID,
+				Path:       "/data/locked.bin",
+				Phase:      database.ScanErrorPhaseHash,
+				ErrorClass: "permission_denied",
+				Message:    "failed to open file: permission denied",
+			},
+			{
+				ScanID:     scan.ID,
+				Path:       "/data/flaky.bin",
+				Phase:      database.ScanErrorPhaseHash,
+				ErrorClass: "io",
+				Message:    "input/output error",
+				Retryable:  true,
+				Attempts:   3,
+			},
+		}
+
+		err := db.ScanErrors.CreateBatch(context.Background(), scanErrors)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		for _, scanErr := range scanErrors {
+			if scanErr.ID == 0 {
+				t.Error("expected ID to be set")
+			}
+		}
+
+		if scanErrors[0].Attempts != 1 {
+			t.Errorf("expected attempts to default to 1, got %d", scanErrors[0].Attempts)
+		}
+	})
+
+	t.Run("handles empty batch", func(t *testing.T) {
+		err := db.ScanErrors.CreateBatch(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestScanErrorRepository_GetByScan(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupDB(t, db)
+
+	target := testutil.MustCreateStorageTarget(t, db, "test-target")
+	scan1 := testutil.MustCreateScan(t, db, target.ID)
+	scan2 := testutil.MustCreateScan(t, db, target.ID)
+
+	err := db.ScanErrors.CreateBatch(context.Background(), []*database.ScanError{
+		{ScanID: scan1.ID, Path: "/a", Phase: database.ScanErrorPhaseHash, ErrorClass: "io", Message: "eio"},
+		{ScanID: scan1.ID, Path: "/b", Phase: database.ScanErrorPhaseWalk, ErrorClass: "permission_denied", Message: "denied"},
+		{ScanID: scan2.ID, Path: "/c", Phase: database.ScanErrorPhaseHash, ErrorClass: "timeout", Message: "timeout"},
+	})
+	if err != nil {
+		t.Fatalf("failed to create scan errors: %v", err)
+	}
+
+	t.Run("returns errors for scan", func(t *testing.T) {
+		scanErrors, err := db.ScanErrors.GetByScan(context.Background(), scan1.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(scanErrors) != 2 {
+			t.Errorf("expected 2 errors, got %d", len(scanErrors))
+		}
+	})
+
+	t.Run("filters by phase", func(t *testing.T) {
+		phase := database.ScanErrorPhaseWalk
+		scanErrors, err := db.ScanErrors.List(context.Background(), database.ScanErrorFilters{
+			ScanID: &scan1.ID,
+			Phase:  &phase,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(scanErrors) != 1 || scanErrors[0].Path != "/b" {
+			t.Errorf("expected only the walk error, got %d errors", len(scanErrors))
+		}
+	})
+
+	t.Run("counts errors for scan", func(t *testing.T) {
+		count, err := db.ScanErrors.CountByScan(context.Background(), scan2.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if count != 1 {
+			t.Errorf("expected 1 error, got %d", count)
+		}
+	})
+}
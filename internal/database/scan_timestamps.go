@@ -0,0 +1,102 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ScanTimestampRepository handles trusted time-stamps of scan results
+type ScanTimestampRepository struct {
+	db *sqlx.DB
+}
+
+// Save stores a scan's time-stamp, replacing any earlier attempt for it
+func (r *ScanTimestampRepository) Save(ctx context.Context, ts *ScanTimestamp) error {
+	query := `
+		INSERT INTO scan_timestamps (
+			scan_id, merkle_root, leaf_count, tsa_url, token, gen_time,
+			serial_number, error
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8
+		)
+		ON CONFLICT (scan_id) DO UPDATE SET
+			merkle_root = EXCLUDED.merkle_root,
+			leaf_count = EXCLUDED.leaf_count,
+			tsa_url = EXCLUDED.tsa_url,
+			token = EXCLUDED.token,
+			gen_time = EXCLUDED.gen_time,
+			serial_number = EXCLUDED.serial_number,
+			error = EXCLUDED.error,
+			updated_at = NOW()
+		RETURNING created_at, updated_at`
+
+	err := r.db.QueryRowContext(
+		ctx, query,
+		ts.ScanID, ts.MerkleRoot, ts.LeafCount, ts.TSAURL, ts.Token, ts.GenTime,
+		ts.SerialNumber, ts.Error,
+	).Scan(&ts.CreatedAt, &ts.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save scan timestamp: %w", err)
+	}
+	return nil
+}
+
+// GetByScanID retrieves a scan's time-stamp, or nil if it has none
+func (r *ScanTimestampRepository) GetByScanID(ctx context.Context, scanID int64) (*ScanTimestamp, error) {
+	var ts ScanTimestamp
+	query := `SELECT * FROM scan_timestamps WHERE scan_id = $1`
+	if err := r.db.GetContext(ctx, &ts, query, scanID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get scan timestamp: %w", err)
+	}
+	return &ts, nil
+}
+
+// ListPending retrieves up to limit time-stamps the TSA has not yet issued
+// a token for, oldest first
+func (r *ScanTimestampRepository) ListPending(ctx context.Context, limit int) ([]*ScanTimestamp, error) {
+	query := `
+		SELECT * FROM scan_timestamps
+		WHERE token IS NULL
+		ORDER BY scan_id
+		LIMIT $1`
+
+	var pending []*ScanTimestamp
+	if err := r.db.SelectContext(ctx, &pending, query, limit); err != nil {
+		return nil, fmt.Errorf("failed to list pending scan timestamps: %w", err)
+	}
+	return pending, nil
+}
+
+// ForEachChecksum calls fn with the path and recorded checksum of each of a
+// target's present, hashed files, in byte order of path
+func (r *FileRepository) ForEachChecksum(ctx context.Context, targetID int64, fn func(path, algorithm, digest string)) error {
+	query := `
+		SELECT path, checksum_type, current_checksum
+		FROM files
+		WHERE storage_target_id = $1 AND deleted_at IS NULL AND current_checksum IS NOT NULL
+		ORDER BY path COLLATE "C"`
+
+	rows, err := r.db.QueryContext(ctx, query, targetID)
+	if err != nil {
+		return fmt.Errorf("failed to read file checksums: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var path, algorithm, digest string
+		if err := rows.Scan(&path, &algorithm, &digest); err != nil {
+			return fmt.Errorf("failed to scan file checksum: %w", err)
+		}
+		fn(path, algorithm, digest)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read file checksums: %w", err)
+	}
+	return nil
+}
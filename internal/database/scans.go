@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -17,6 +18,7 @@ type ScanRepository struct {
 type ScanFilters struct {
 	StorageTargetID *int64
 	Status          *ScanStatus
+	ScanType        *ScanType
 	LargeChangeOnly bool
 	Limit           int
 	Offset          int
@@ -53,6 +55,12 @@ func (r *ScanRepository) List(ctx context.Context, filters ScanFilters) ([]*Scan
 		argNum++
 	}
 
+	if filters.ScanType != nil {
+		query += fmt.Sprintf(" AND scan_type = $%d", argNum)
+		args = append(args, *filters.ScanType)
+		argNum++
+	}
+
 	if filters.LargeChangeOnly {
 		query += " AND is_large_change = TRUE"
 	}
@@ -98,23 +106,45 @@ func (r *ScanRepository) GetLatest(ctx context.Context, targetID int64) (*Scan,
 	return &scan, nil
 }
 
+// LastFullWalk returns when the most recent discovery scan of a target that
+// walked every directory started, or nil if there has been none
+func (r *ScanRepository) LastFullWalk(ctx context.Context, targetID int64) (*time.Time, error) {
+	var startedAt *time.Time
+	query := `
+		SELECT MAX(started_at) FROM scans
+		WHERE storage_target_id = $1
+			AND scan_type = 'discovery'
+			AND status IN ('completed', 'partial')
+			AND dirs_skipped = 0`
+
+	if err := r.db.GetContext(ctx, &startedAt, query, targetID); err != nil {
+		return nil, fmt.Errorf("failed to get last full walk: %w", err)
+	}
+
+	return startedAt, nil
+}
+
 // Create creates a new scan record
 func (r *ScanRepository) Create(ctx context.Context, scan *Scan) error {
+	if scan.ScanType == "" {
+		scan.ScanType = ScanTypeDiscovery
+	}
+
 	query := `
 		INSERT INTO scans (
 			storage_target_id, status, started_at, completed_at,
 			files_scanned, files_added, files_deleted, files_modified, files_verified,
-			errors_count, error_messages, is_large_change, resumed_from,
+			errors_count, error_messages, is_large_change, resumed_from, scan_type,
 			created_at
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, NOW()
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, NOW()
 		) RETURNING id, created_at`
 
 	err := r.db.QueryRowContext(
 		ctx, query,
 		scan.StorageTargetID, scan.Status, scan.StartedAt, scan.CompletedAt,
 		scan.FilesScanned, scan.FilesAdded, scan.FilesDeleted, scan.FilesModified, scan.FilesVerified,
-		scan.ErrorsCount, scan.ErrorMessages, scan.IsLargeChange, scan.ResumedFrom,
+		scan.ErrorsCount, scan.ErrorMessages, scan.IsLargeChange, scan.ResumedFrom, scan.ScanType,
 	).Scan(&scan.ID, &scan.CreatedAt)
 
 	if err != nil {
@@ -137,7 +167,18 @@ func (r *ScanRepository) Update(ctx context.Context, scan *Scan) error {
 			files_verified = $8,
 			errors_count = $9,
 			error_messages = $10,
-			is_large_change = $11
+			is_large_change = $11,
+			worker_concurrency = $12,
+			worker_concurrency_avg = $13,
+			worker_concurrency_peak = $14,
+			bytes_hashed = $15,
+			hash_bytes_per_sec = $16,
+			files_mismatched = $17,
+			budget_exhausted = $18,
+			files_skipped = $19,
+			dirs_skipped = $20,
+			files_metadata_changed = $21,
+			files_moved = $22
 		WHERE id = $1`
 
 	result, err := r.db.ExecContext(
@@ -145,6 +186,10 @@ func (r *ScanRepository) Update(ctx context.Context, scan *Scan) error {
 		scan.ID, scan.Status, scan.CompletedAt,
 		scan.FilesScanned, scan.FilesAdded, scan.FilesDeleted, scan.FilesModified, scan.FilesVerified,
 		scan.ErrorsCount, scan.ErrorMessages, scan.IsLargeChange,
+		scan.WorkerConcurrency, scan.WorkerConcurrencyAvg, scan.WorkerConcurrencyPeak,
+		scan.BytesHashed, scan.HashBytesPerSec,
+		scan.FilesMismatched, scan.BudgetExhausted, scan.FilesSkipped,
+		scan.DirsSkipped, scan.FilesMetadataChanged, scan.FilesMoved,
 	)
 
 	if err != nil {
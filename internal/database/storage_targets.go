@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 // StorageTargetRepository handles storage target operations
@@ -65,15 +66,35 @@ func (r *StorageTargetRepository) ListEnabled(ctx context.Context) ([]*StorageTa
 
 // Create creates a new storage target
 func (r *StorageTargetRepository) Create(ctx context.Context, target *StorageTarget) error {
+	if target.ReadCacheMode == "" {
+		target.ReadCacheMode = "normal"
+	}
+	if target.LinkPolicy == "" {
+		target.LinkPolicy = "follow"
+	}
+	if target.IncludePatterns == nil {
+		target.IncludePatterns = pq.StringArray{}
+	}
+	if target.ExcludePatterns == nil {
+		target.ExcludePatterns = pq.StringArray{}
+	}
+
 	query := `
 		INSERT INTO storage_targets (
 			name, type, path, server, share, credentials_ref,
 			enabled, scan_schedule, parallel_workers, random_sample_percent,
 			checksum_algorithm, checkpoint_interval, batch_size,
 			large_change_threshold_count, large_change_threshold_percent, large_change_threshold_bytes,
+			throttle_bytes_per_sec, throttle_iops, throttle_schedule,
+			adaptive_workers, min_workers, max_workers,
+			read_cache_mode, read_size, verification_window_days,
+			include_patterns, exclude_patterns, honor_ignore_files,
+			link_policy, walk_concurrency, watch_changes,
+			skip_unchanged_dirs, full_walk_days, capture_xattrs, host_key,
+			scan_archives, validate_bags,
 			created_at, updated_at
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, NOW(), NOW()
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32, $33, $34, $35, $36, $37, NOW(), NOW()
 		) RETURNING id, created_at, updated_at`
 
 	err := r.db.QueryRowContext(
@@ -82,6 +103,13 @@ func (r *StorageTargetRepository) Create(ctx context.Context, target *StorageTar
 		target.Enabled, target.ScanSchedule, target.ParallelWorkers, target.RandomSamplePercent,
 		target.ChecksumAlgorithm, target.CheckpointInterval, target.BatchSize,
 		target.LargeChangeThresholdCount, target.LargeChangeThresholdPercent, target.LargeChangeThresholdBytes,
+		target.ThrottleBytesPerSec, target.ThrottleIOPS, target.ThrottleSchedule,
+		target.AdaptiveWorkers, target.MinWorkers, target.MaxWorkers,
+		target.ReadCacheMode, target.ReadSize, target.VerificationWindowDays,
+		target.IncludePatterns, target.ExcludePatterns, target.HonorIgnoreFiles,
+		target.LinkPolicy, target.WalkConcurrency, target.WatchChanges,
+		target.SkipUnchangedDirs, target.FullWalkDays, target.CaptureXattrs, target.HostKey,
+		target.ScanArchives, target.ValidateBags,
 	).Scan(&target.ID, &target.CreatedAt, &target.UpdatedAt)
 
 	if err != nil {
@@ -93,6 +121,19 @@ func (r *StorageTargetRepository) Create(ctx context.Context, target *StorageTar
 
 // Update updates an existing storage target
 func (r *StorageTargetRepository) Update(ctx context.Context, target *StorageTarget) error {
+	if target.ReadCacheMode == "" {
+		target.ReadCacheMode = "normal"
+	}
+	if target.LinkPolicy == "" {
+		target.LinkPolicy = "follow"
+	}
+	if target.IncludePatterns == nil {
+		target.IncludePatterns = pq.StringArray{}
+	}
+	if target.ExcludePatterns == nil {
+		target.ExcludePatterns = pq.StringArray{}
+	}
+
 	query := `
 		UPDATE storage_targets SET
 			name = $2,
@@ -111,6 +152,27 @@ func (r *StorageTargetRepository) Update(ctx context.Context, target *StorageTar
 			large_change_threshold_count = $15,
 			large_change_threshold_percent = $16,
 			large_change_threshold_bytes = $17,
+			throttle_bytes_per_sec = $18,
+			throttle_iops = $19,
+			throttle_schedule = $20,
+			adaptive_workers = $21,
+			min_workers = $22,
+			max_workers = $23,
+			read_cache_mode = $24,
+			read_size = $25,
+			verification_window_days = $26,
+			include_patterns = $27,
+			exclude_patterns = $28,
+			honor_ignore_files = $29,
+			link_policy = $30,
+			walk_concurrency = $31,
+			watch_changes = $32,
+			skip_unchanged_dirs = $33,
+			full_walk_days = $34,
+			capture_xattrs = $35,
+			host_key = $36,
+			scan_archives = $37,
+			validate_bags = $38,
 			updated_at = NOW()
 		WHERE id = $1
 		RETURNING updated_at`
@@ -121,6 +183,13 @@ func (r *StorageTargetRepository) Update(ctx context.Context, target *StorageTar
 		target.Enabled, target.ScanSchedule, target.ParallelWorkers, target.RandomSamplePercent,
 		target.ChecksumAlgorithm, target.CheckpointInterval, target.BatchSize,
 		target.LargeChangeThresholdCount, target.LargeChangeThresholdPercent, target.LargeChangeThresholdBytes,
+		target.ThrottleBytesPerSec, target.ThrottleIOPS, target.ThrottleSchedule,
+		target.AdaptiveWorkers, target.MinWorkers, target.MaxWorkers,
+		target.ReadCacheMode, target.ReadSize, target.VerificationWindowDays,
+		target.IncludePatterns, target.ExcludePatterns, target.HonorIgnoreFiles,
+		target.LinkPolicy, target.WalkConcurrency, target.WatchChanges,
+		target.SkipUnchangedDirs, target.FullWalkDays, target.CaptureXattrs, target.HostKey,
+		target.ScanArchives, target.ValidateBags,
 	).Scan(&target.UpdatedAt)
 
 	if err != nil {
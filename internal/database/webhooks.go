@@ -204,3 +204,30 @@ func (r *WebhookDeliveryRepository) GetPending(ctx context.Context, limit int) (
 
 	return deliveries, nil
 }
+
+// Enqueue creates a pending delivery of an event for every enabled webhook
+// that accepts it: one with no includes or that includes the event type, and
+// that does not exclude it. It returns the number of deliveries queued.
+func (r *WebhookDeliveryRepository) Enqueue(ctx context.Context, eventType string, payload []byte) (int64, error) {
+	query := `
+		INSERT INTO webhook_deliveries (
+			webhook_id, event_type, payload, status, attempt,
+			created_at
+		)
+		SELECT id, $1, $2, 'pending', 0, NOW() FROM webhooks
+		WHERE enabled = TRUE
+		  AND (COALESCE(cardinality(event_includes), 0) = 0 OR $1 = ANY(event_includes))
+		  AND NOT ($1 = ANY(COALESCE(event_excludes, '{}')))`
+
+	result, err := r.db.ExecContext(ctx, query, eventType, payload)
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue webhook deliveries: %w", err)
+	}
+
+	queued, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return queued, nil
+}
@@ -0,0 +1,220 @@
+// Package manifest reads and writes checksum manifests: BagIt
+// manifest-<alg>.txt files and the output of md5sum, sha256sum and b3sum
+package manifest
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/jeffanddom/fixity/internal/checksum"
+)
+
+// Format is a manifest file format
+type Format string
+
+const (
+	// FormatBagIt is a BagIt payload manifest. Paths are relative to the bag
+	// and percent-encode carriage returns, line feeds and percent signs.
+	FormatBagIt Format = "bagit"
+
+	// FormatCoreutils is the output of md5sum and its relatives. Lines whose
+	// path holds a backslash or line feed start with a backslash and escape
+	// them.
+	FormatCoreutils Format = "coreutils"
+)
+
+// ParseFormat checks a format name
+func ParseFormat(name string) (Format, error) {
+	switch Format(name) {
+	case FormatBagIt, FormatCoreutils:
+		return Format(name), nil
+	default:
+		return "", fmt.Errorf("unknown manifest format: %s (expected bagit or coreutils)", name)
+	}
+}
+
+// Entry is one file listed in a manifest
+type Entry struct {
+	Path     string
+	Checksum string
+}
+
+// FormatOf guesses a manifest's format from its file name: BagIt manifests
+// are named manifest-<alg>.txt, anything else is taken as coreutils output
+func FormatOf(filename string) Format {
+	if _, ok := bagItAlgorithm(path.Base(filename)); ok {
+		return FormatBagIt
+	}
+	return FormatCoreutils
+}
+
+// AlgorithmOf guesses a manifest's algorithm from its file name, such as
+// manifest-sha256.txt, SHA256SUMS or photos.md5. The algorithm named by a
+// BagIt manifest is returned even if unsupported, so Parse can reject it.
+func AlgorithmOf(filename string) (checksum.Algorithm, bool) {
+	base := strings.ToLower(path.Base(filename))
+	if algorithm, ok := bagItAlgorithm(base); ok {
+		return algorithm, true
+	}
+
+	names := map[string]checksum.Algorithm{
+		"md5":    checksum.AlgorithmMD5,
+		"sha256": checksum.AlgorithmSHA256,
+		"b3":     checksum.AlgorithmBLAKE3,
+		"blake3": checksum.AlgorithmBLAKE3,
+	}
+	for name, algorithm := range names {
+		if base == name+"sums" || base == name+"sum.txt" || strings.HasSuffix(base, "."+name) {
+			return algorithm, true
+		}
+	}
+	return "", false
+}
+
+func bagItAlgorithm(base string) (checksum.Algorithm, bool) {
+	base = strings.ToLower(base)
+	if !strings.HasPrefix(base, "manifest-") || !strings.HasSuffix(base, ".txt") {
+		return "", false
+	}
+	return checksum.Algorithm(strings.TrimSuffix(strings.TrimPrefix(base, "manifest-"), ".txt")), true
+}
+
+// digestLength is the length in hex digits of each algorithm's checksums
+var digestLength = map[checksum.Algorithm]int{
+	checksum.AlgorithmMD5:    32,
+	checksum.AlgorithmSHA256: 64,
+	checksum.AlgorithmBLAKE3: 64,
+}
+
+var (
+	bagItDecoder = strings.NewReplacer("%0D", "\r", "%0A", "\n", "%0d", "\r", "%0a", "\n", "%25", "%")
+	bagItEncoder = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+
+	coreutilsDecoder = strings.NewReplacer(`\\`, `\`, `\n`, "\n", `\r`, "\r")
+	coreutilsEncoder = strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", `\r`)
+)
+
+// Parse reads a manifest of checksums made with the given algorithm.
+// Checksums are lowercased, and paths are cleaned and must stay within the
+// directory the manifest describes.
+func Parse(r io.Reader, format Format, algorithm checksum.Algorithm) ([]Entry, error) {
+	if err := checksum.ValidateAlgorithm(algorithm); err != nil {
+		return nil, err
+	}
+	return parse(r, format, string(algorithm), digestLength[algorithm])
+}
+
+// ParseBagIt reads a BagIt manifest made with any algorithm, checking only
+// that each checksum is hex. Bags often use algorithms Fixity does not record
+// files with, such as SHA-512.
+func ParseBagIt(r io.Reader) ([]Entry, error) {
+	return parse(r, FormatBagIt, "hex", 0)
+}
+
+// parse reads a manifest whose checksums are hex of the given length, or of
+// any length if it is zero
+func parse(r io.Reader, format Format, name string, length int) ([]Entry, error) {
+	entries := []Entry{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		var entry Entry
+		var err error
+		switch format {
+		case FormatBagIt:
+			entry, err = parseBagItLine(line)
+		case FormatCoreutils:
+			entry, err = parseCoreutilsLine(line)
+		default:
+			return nil, fmt.Errorf("unknown manifest format: %s", format)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+
+		entry.Checksum = strings.ToLower(entry.Checksum)
+		if _, err := hex.DecodeString(entry.Checksum); err != nil || entry.Checksum == "" || (length > 0 && len(entry.Checksum) != length) {
+			return nil, fmt.Errorf("line %d: not a %s checksum: %s", lineNum, name, entry.Checksum)
+		}
+
+		cleaned := path.Clean(entry.Path)
+		if cleaned == "." || path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+			return nil, fmt.Errorf("line %d: path outside the manifest's directory: %s", lineNum, entry.Path)
+		}
+		entry.Path = cleaned
+
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	return entries, nil
+}
+
+// parseBagItLine reads "<checksum> <path>", separated by one or more spaces
+// or tabs
+func parseBagItLine(line string) (Entry, error) {
+	i := strings.IndexAny(line, " \t")
+	if i <= 0 {
+		return Entry{}, fmt.Errorf("expected a checksum and a path")
+	}
+	filePath := strings.TrimLeft(line[i:], " \t")
+	if filePath == "" {
+		return Entry{}, fmt.Errorf("expected a checksum and a path")
+	}
+	return Entry{Checksum: line[:i], Path: bagItDecoder.Replace(filePath)}, nil
+}
+
+// parseCoreutilsLine reads "<checksum>  <path>", or "<checksum> *<path>" for
+// files hashed in binary mode
+func parseCoreutilsLine(line string) (Entry, error) {
+	escaped := strings.HasPrefix(line, `\`)
+	if escaped {
+		line = line[1:]
+	}
+
+	i := strings.IndexByte(line, ' ')
+	if i <= 0 || i+2 > len(line) || (line[i+1] != ' ' && line[i+1] != '*') {
+		return Entry{}, fmt.Errorf("expected a checksum, two characters of separator and a path")
+	}
+
+	filePath := line[i+2:]
+	if escaped {
+		filePath = coreutilsDecoder.Replace(filePath)
+	}
+	return Entry{Checksum: line[:i], Path: filePath}, nil
+}
+
+// Write writes entries as a manifest in the given format
+func Write(w io.Writer, format Format, entries []Entry) error {
+	bw := bufio.NewWriter(w)
+	for _, entry := range entries {
+		var err error
+		switch format {
+		case FormatBagIt:
+			_, err = fmt.Fprintf(bw, "%s  %s\n", entry.Checksum, bagItEncoder.Replace(entry.Path))
+		case FormatCoreutils:
+			if strings.ContainsAny(entry.Path, "\\\n\r") {
+				_, err = fmt.Fprintf(bw, "\\%s  %s\n", entry.Checksum, coreutilsEncoder.Replace(entry.Path))
+			} else {
+				_, err = fmt.Fprintf(bw, "%s  %s\n", entry.Checksum, entry.Path)
+			}
+		default:
+			return fmt.Errorf("unknown manifest format: %s", format)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write manifest: %w", err)
+		}
+	}
+	return bw.Flush()
+}
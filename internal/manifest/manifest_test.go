@@ -0,0 +1,130 @@
+package manifest
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jeffanddom/fixity/internal/checksum"
+)
+
+const (
+	sumA = "0cc175b9c0f1b6a831c399e269772661"
+	sumB = "92eb5ffee6ae2fec3ad71c777531578f"
+)
+
+func TestParse_BagIt(t *testing.T) {
+	input := sumA + "  data/a.txt\r\n" +
+		strings.ToUpper(sumB) + "\tdata/100%25 line%0Abreak.txt\n" +
+		"\n"
+
+	entries, err := Parse(strings.NewReader(input), FormatBagIt, checksum.AlgorithmMD5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Path != "data/a.txt" || entries[0].Checksum != sumA {
+		t.Errorf("unexpected entry: %+v", entries[0])
+	}
+	if entries[1].Path != "data/100% line\nbreak.txt" || entries[1].Checksum != sumB {
+		t.Errorf("expected a decoded path and lowercased checksum, got %+v", entries[1])
+	}
+}
+
+func TestParse_Coreutils(t *testing.T) {
+	input := sumA + "  ./photos/a b.jpg\n" +
+		sumB + " *binary.dat\n" +
+		`\` + sumA + `  back\\slash\nname` + "\n"
+
+	entries, err := Parse(strings.NewReader(input), FormatCoreutils, checksum.AlgorithmMD5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"photos/a b.jpg", "binary.dat", "back\\slash\nname"}
+	for i, path := range want {
+		if entries[i].Path != path {
+			t.Errorf("entry %d: expected %q, got %q", i, path, entries[i].Path)
+		}
+	}
+}
+
+func TestParse_Rejects(t *testing.T) {
+	tests := map[string]string{
+		"short checksum":   "abc  a.txt\n",
+		"not hex":          strings.Repeat("z", 32) + "  a.txt\n",
+		"escaping path":    sumA + "  ../etc/passwd\n",
+		"absolute path":    sumA + "  /etc/passwd\n",
+		"single separator": sumA + " a.txt\n",
+		"missing path":     sumA + "\n",
+	}
+
+	for name, input := range tests {
+		if _, err := Parse(strings.NewReader(input), FormatCoreutils, checksum.AlgorithmMD5); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	if _, err := Parse(strings.NewReader(""), FormatBagIt, "sha512"); err == nil {
+		t.Error("expected an unsupported algorithm to be rejected")
+	}
+}
+
+func TestWrite_RoundTrip(t *testing.T) {
+	entries := []Entry{
+		{Path: "data/a.txt", Checksum: sumA},
+		{Path: "data/odd\\name\n%.txt", Checksum: sumB},
+	}
+
+	for _, format := range []Format{FormatBagIt, FormatCoreutils} {
+		var buf bytes.Buffer
+		if err := Write(&buf, format, entries); err != nil {
+			t.Fatalf("%s: unexpected error: %v", format, err)
+		}
+		if strings.Count(buf.String(), "\n") != 2 {
+			t.Errorf("%s: expected one line per entry, got %q", format, buf.String())
+		}
+
+		parsed, err := Parse(&buf, format, checksum.AlgorithmMD5)
+		if err != nil {
+			t.Fatalf("%s: failed to parse written manifest: %v", format, err)
+		}
+		if len(parsed) != 2 || parsed[1] != entries[1] {
+			t.Errorf("%s: expected entries to survive a round trip, got %+v", format, parsed)
+		}
+	}
+}
+
+func TestFormatAndAlgorithmOf(t *testing.T) {
+	tests := []struct {
+		name      string
+		format    Format
+		algorithm checksum.Algorithm
+		ok        bool
+	}{
+		{"bag/manifest-sha256.txt", FormatBagIt, checksum.AlgorithmSHA256, true},
+		{"manifest-sha512.txt", FormatBagIt, "sha512", true},
+		{"MD5SUMS", FormatCoreutils, checksum.AlgorithmMD5, true},
+		{"photos.sha256", FormatCoreutils, checksum.AlgorithmSHA256, true},
+		{"b3sum.txt", FormatCoreutils, checksum.AlgorithmBLAKE3, true},
+		{"checksums.txt", FormatCoreutils, "", false},
+	}
+
+	for _, tt := range tests {
+		if got := FormatOf(tt.name); got != tt.format {
+			t.Errorf("FormatOf(%q) = %s, want %s", tt.name, got, tt.format)
+		}
+		if got, ok := AlgorithmOf(tt.name); got != tt.algorithm || ok != tt.ok {
+			t.Errorf("AlgorithmOf(%q) = %s, %v, want %s, %v", tt.name, got, ok, tt.algorithm, tt.ok)
+		}
+	}
+}
+
+func TestCleanDir(t *testing.T) {
+	for input, want := range map[string]string{"": "", "/": "", "photos/": "photos", "/a/b/../c": "a/c"} {
+		if got := CleanDir(input); got != want {
+			t.Errorf("CleanDir(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package manifest
+
+import (
+	"context"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/jeffanddom/fixity/internal/checksum"
+	"github.com/jeffanddom/fixity/internal/database"
+)
+
+const (
+	importBatchSize = 1000 // Manifest entries looked up and stored per query
+	exportBatchSize = 5000 // Files read per query
+)
+
+// Conflict is a manifest entry for a file already recorded with a different
+// checksum
+type Conflict struct {
+	Path     string
+	Expected string // From the manifest
+	Recorded string
+}
+
+// ImportResult summarizes a manifest import
+type ImportResult struct {
+	Expected  int // Checksums stored for files not yet recorded
+	Matched   int // Files already recorded with the same checksum
+	Skipped   int // Files already recorded with another algorithm
+	Conflicts []Conflict
+}
+
+// ExportResult summarizes a manifest export
+type ExportResult struct {
+	Written int64
+	Skipped int64 // Files not hashed, or hashed with another algorithm
+}
+
+// CleanDir normalizes a directory within a target to the form file paths
+// are recorded in: slash-separated, without leading or trailing slashes. The
+// target's root is the empty string.
+func CleanDir(dir string) string {
+	return strings.Trim(path.Clean("/"+dir), "/")
+}
+
+// Import stores the checksums of a manifest describing the directory dir of
+// a target as the checksums expected of those files, so each is checked
+// against them when a scan first finds it. Files already recorded are
+// compared with their recorded checksum instead.
+func Import(
+	ctx context.Context,
+	db *database.Database,
+	targetID int64,
+	dir string,
+	entries []Entry,
+	algorithm checksum.Algorithm,
+	source string,
+) (*ImportResult, error) {
+	dir = CleanDir(dir)
+	result := &ImportResult{Conflicts: []Conflict{}}
+
+	for start := 0; start < len(entries); start += importBatchSize {
+		batch := entries[start:min(start+importBatchSize, len(entries))]
+
+		paths := make([]string, len(batch))
+		for i, entry := range batch {
+			paths[i] = path.Join(dir, entry.Path)
+		}
+
+		files, err := db.Files.ListByPaths(ctx, targetID, paths)
+		if err != nil {
+			return nil, err
+		}
+		recorded := make(map[string]*database.File, len(files))
+		for _, file := range files {
+			recorded[file.Path] = file
+		}
+
+		expected := []*database.ExpectedChecksum{}
+		for i, entry := range batch {
+			file, ok := recorded[paths[i]]
+			switch {
+			case !ok:
+				expected = append(expected, &database.ExpectedChecksum{
+					StorageTargetID: targetID,
+					Path:            paths[i],
+					Checksum:        entry.Checksum,
+					ChecksumType:    string(algorithm),
+					Source:          &source,
+				})
+			case file.CurrentChecksum == nil || file.ChecksumType == nil || *file.ChecksumType != string(algorithm):
+				result.Skipped++
+			case *file.CurrentChecksum == entry.Checksum:
+				result.Matched++
+			default:
+				result.Conflicts = append(result.Conflicts, Conflict{
+					Path:     paths[i],
+					Expected: entry.Checksum,
+					Recorded: *file.CurrentChecksum,
+				})
+			}
+		}
+
+		if err := db.ExpectedChecksums.Upsert(ctx, expected); err != nil {
+			return nil, err
+		}
+		result.Expected += len(expected)
+	}
+
+	return result, nil
+}
+
+// Export writes the recorded checksums of the regular files in the directory
+// dir of a target, made with the given algorithm, as a manifest. Paths are
+// relative to dir; in a BagIt manifest they are placed under data/, as the
+// payload of a bag.
+func Export(
+	ctx context.Context,
+	db *database.Database,
+	w io.Writer,
+	targetID int64,
+	dir string,
+	format Format,
+	algorithm checksum.Algorithm,
+) (*ExportResult, error) {
+	dir = CleanDir(dir)
+	result := &ExportResult{}
+
+	afterPath := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		files, err := db.Files.ListUnderPathBatch(ctx, targetID, dir, afterPath, exportBatchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		entries := make([]Entry, 0, len(files))
+		for _, file := range files {
+			if file.FileType != database.FileTypeRegular || file.CurrentChecksum == nil ||
+				file.ChecksumType == nil || *file.ChecksumType != string(algorithm) {
+				result.Skipped++
+				continue
+			}
+
+			rel := file.Path
+			if dir != "" {
+				rel = strings.TrimPrefix(file.Path, dir+"/")
+				if file.Path == dir {
+					rel = path.Base(file.Path)
+				}
+			}
+			if format == FormatBagIt {
+				rel = "data/" + rel
+			}
+			entries = append(entries, Entry{Path: rel, Checksum: *file.CurrentChecksum})
+		}
+
+		if err := Write(w, format, entries); err != nil {
+			return nil, err
+		}
+		result.Written += int64(len(entries))
+
+		if len(files) < exportBatchSize {
+			break
+		}
+		afterPath = files[len(files)-1].Path
+	}
+
+	return result, nil
+}
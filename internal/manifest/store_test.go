@@ -0,0 +1,69 @@
+package manifest_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/jeffanddom/fixity/internal/checksum"
+	"github.com/jeffanddom/fixity/internal/manifest"
+	"github.com/jeffanddom/fixity/tests/testutil"
+)
+
+func TestImportAndExport(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupDB(t, db)
+
+	ctx := context.Background()
+	target := testutil.MustCreateStorageTarget(t, db, "archive")
+
+	// Recorded with the helper's checksum, abc123
+	testutil.MustCreateFile(t, db, target.ID, "bags/one/data/recorded.txt")
+	testutil.MustCreateFile(t, db, target.ID, "bags/one/data/conflict.txt")
+
+	t.Run("imports expectations for unrecorded files", func(t *testing.T) {
+		entries := []manifest.Entry{
+			{Path: "data/new.txt", Checksum: "0cc175b9c0f1b6a831c399e269772661"},
+			{Path: "data/recorded.txt", Checksum: "abc123"},
+			{Path: "data/conflict.txt", Checksum: "92eb5ffee6ae2fec3ad71c777531578f"},
+		}
+
+		result, err := manifest.Import(ctx, db, target.ID, "/bags/one/", entries, checksum.AlgorithmMD5, "manifest-md5.txt")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Expected != 1 || result.Matched != 1 || len(result.Conflicts) != 1 {
+			t.Fatalf("unexpected result: %+v", result)
+		}
+		if result.Conflicts[0].Path != "bags/one/data/conflict.txt" || result.Conflicts[0].Recorded != "abc123" {
+			t.Errorf("unexpected conflict: %+v", result.Conflicts[0])
+		}
+
+		expected, _ := db.ExpectedChecksums.ListByPaths(ctx, target.ID, []string{"bags/one/data/new.txt"})
+		if len(expected) != 1 || expected[0].ChecksumType != "md5" || *expected[0].Source != "manifest-md5.txt" {
+			t.Errorf("expected the new file's checksum to be stored, got %v", expected)
+		}
+	})
+
+	t.Run("exports a subtree as a bag manifest", func(t *testing.T) {
+		var buf bytes.Buffer
+		result, err := manifest.Export(ctx, db, &buf, target.ID, "bags/one/data", manifest.FormatBagIt, checksum.AlgorithmMD5)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Written != 2 {
+			t.Errorf("expected 2 checksums written, got %d", result.Written)
+		}
+		want := "abc123  data/conflict.txt\nabc123  data/recorded.txt\n"
+		if buf.String() != want {
+			t.Errorf("expected %q, got %q", want, buf.String())
+		}
+
+		buf.Reset()
+		result, _ = manifest.Export(ctx, db, &buf, target.ID, "", manifest.FormatCoreutils, checksum.AlgorithmSHA256)
+		if result.Written != 0 || result.Skipped != 2 {
+			t.Errorf("expected files of another algorithm to be skipped, got %+v", result)
+		}
+	})
+}
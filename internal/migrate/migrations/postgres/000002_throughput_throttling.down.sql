@@ -0,0 +1,4 @@
+ALTER TABLE storage_targets
+    DROP COLUMN IF EXISTS throttle_schedule,
+    DROP COLUMN IF EXISTS throttle_iops,
+    DROP COLUMN IF EXISTS throttle_bytes_per_sec;
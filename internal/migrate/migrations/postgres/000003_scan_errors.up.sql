@@ -0,0 +1,14 @@
+-- Structured per-file errors recorded during scans
+CREATE TABLE scan_errors (
+    id              BIGSERIAL PRIMARY KEY,
+    scan_id         BIGINT NOT NULL REFERENCES scans(id) ON DELETE CASCADE,
+    path            TEXT NOT NULL,
+    phase           TEXT NOT NULL CHECK (phase IN ('walk', 'hash')),
+    error_class     TEXT NOT NULL,
+    message         TEXT NOT NULL,
+    retryable       BOOLEAN NOT NULL DEFAULT FALSE,
+    attempts        INT NOT NULL DEFAULT 1 CHECK (attempts >= 1),
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_scan_errors_scan ON scan_errors(scan_id);
@@ -0,0 +1,12 @@
+ALTER TABLE scans
+    DROP COLUMN IF EXISTS hash_bytes_per_sec,
+    DROP COLUMN IF EXISTS bytes_hashed,
+    DROP COLUMN IF EXISTS worker_concurrency_peak,
+    DROP COLUMN IF EXISTS worker_concurrency_avg,
+    DROP COLUMN IF EXISTS worker_concurrency;
+
+ALTER TABLE storage_targets
+    DROP CONSTRAINT IF EXISTS storage_targets_worker_bounds,
+    DROP COLUMN IF EXISTS max_workers,
+    DROP COLUMN IF EXISTS min_workers,
+    DROP COLUMN IF EXISTS adaptive_workers;
@@ -0,0 +1,14 @@
+-- Adaptive checksum worker pool sizing
+ALTER TABLE storage_targets
+    ADD COLUMN adaptive_workers  BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN min_workers       INT CHECK (min_workers IS NULL OR min_workers > 0),
+    ADD COLUMN max_workers       INT CHECK (max_workers IS NULL OR max_workers > 0),
+    ADD CONSTRAINT storage_targets_worker_bounds CHECK (min_workers IS NULL OR max_workers IS NULL OR min_workers <= max_workers);
+
+-- Concurrency and throughput observed while hashing
+ALTER TABLE scans
+    ADD COLUMN worker_concurrency      INT,
+    ADD COLUMN worker_concurrency_avg  DOUBLE PRECISION,
+    ADD COLUMN worker_concurrency_peak INT,
+    ADD COLUMN bytes_hashed            BIGINT NOT NULL DEFAULT 0 CHECK (bytes_hashed >= 0),
+    ADD COLUMN hash_bytes_per_sec      DOUBLE PRECISION;
@@ -0,0 +1,3 @@
+ALTER TABLE storage_targets
+    DROP COLUMN IF EXISTS read_size,
+    DROP COLUMN IF EXISTS read_cache_mode;
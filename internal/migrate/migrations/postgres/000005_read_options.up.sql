@@ -0,0 +1,4 @@
+-- Page cache behaviour and read size used when hashing
+ALTER TABLE storage_targets
+    ADD COLUMN read_cache_mode TEXT NOT NULL DEFAULT 'normal' CHECK (read_cache_mode IN ('normal', 'drop', 'direct')),
+    ADD COLUMN read_size       INT CHECK (read_size IS NULL OR read_size > 0);
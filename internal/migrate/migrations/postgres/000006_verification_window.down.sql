@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_files_verification_due;
+
+ALTER TABLE storage_targets
+    DROP COLUMN IF EXISTS verification_window_days;
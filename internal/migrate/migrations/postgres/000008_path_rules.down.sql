@@ -0,0 +1,4 @@
+ALTER TABLE storage_targets
+    DROP COLUMN IF EXISTS honor_ignore_files,
+    DROP COLUMN IF EXISTS exclude_patterns,
+    DROP COLUMN IF EXISTS include_patterns;
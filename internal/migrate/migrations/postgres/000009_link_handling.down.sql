@@ -0,0 +1,16 @@
+DELETE FROM scan_errors WHERE phase = 'skip';
+
+ALTER TABLE scan_errors
+    DROP CONSTRAINT scan_errors_phase_check,
+    ADD CONSTRAINT scan_errors_phase_check CHECK (phase IN ('walk', 'hash'));
+
+ALTER TABLE scans
+    DROP COLUMN IF EXISTS files_skipped;
+
+ALTER TABLE files
+    DROP CONSTRAINT IF EXISTS files_link_target_check,
+    DROP COLUMN IF EXISTS link_target,
+    DROP COLUMN IF EXISTS file_type;
+
+ALTER TABLE storage_targets
+    DROP COLUMN IF EXISTS link_policy;
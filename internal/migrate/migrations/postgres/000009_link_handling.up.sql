@@ -0,0 +1,17 @@
+-- How symlinks are handled when walking a target
+ALTER TABLE storage_targets
+    ADD COLUMN link_policy TEXT NOT NULL DEFAULT 'follow' CHECK (link_policy IN ('record', 'follow', 'skip'));
+
+-- Symlinks recorded as links keep their target path; their checksum covers the target path
+ALTER TABLE files
+    ADD COLUMN file_type   TEXT NOT NULL DEFAULT 'regular' CHECK (file_type IN ('regular', 'symlink')),
+    ADD COLUMN link_target TEXT,
+    ADD CONSTRAINT files_link_target_check CHECK ((file_type = 'symlink') = (link_target IS NOT NULL));
+
+-- Sockets, FIFOs and devices are skipped and noted in scan_errors
+ALTER TABLE scans
+    ADD COLUMN files_skipped INT NOT NULL DEFAULT 0 CHECK (files_skipped >= 0);
+
+ALTER TABLE scan_errors
+    DROP CONSTRAINT scan_errors_phase_check,
+    ADD CONSTRAINT scan_errors_phase_check CHECK (phase IN ('walk', 'hash', 'skip'));
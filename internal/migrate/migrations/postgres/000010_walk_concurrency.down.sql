@@ -0,0 +1,2 @@
+ALTER TABLE storage_targets
+    DROP COLUMN IF EXISTS walk_concurrency;
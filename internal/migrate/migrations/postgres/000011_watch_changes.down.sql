@@ -0,0 +1,8 @@
+DELETE FROM scans WHERE scan_type = 'incremental';
+
+ALTER TABLE scans
+    DROP CONSTRAINT scans_scan_type_check,
+    ADD CONSTRAINT scans_scan_type_check CHECK (scan_type IN ('discovery', 'verification'));
+
+ALTER TABLE storage_targets
+    DROP COLUMN IF EXISTS watch_changes;
@@ -0,0 +1,17 @@
+-- Directory mtimes and entry counts from the last walk, so directories that
+-- have not changed since can have their files skipped
+CREATE TABLE directories (
+    storage_target_id BIGINT NOT NULL REFERENCES storage_targets(id) ON DELETE CASCADE,
+    path              TEXT NOT NULL,
+    mod_time          TIMESTAMP WITH TIME ZONE NOT NULL,
+    entries           INT NOT NULL CHECK (entries >= 0),
+    PRIMARY KEY (storage_target_id, path)
+);
+
+-- Targets opt in; NULL full_walk_days walks in full weekly
+ALTER TABLE storage_targets
+    ADD COLUMN skip_unchanged_dirs BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN full_walk_days INT CHECK (full_walk_days BETWEEN 1 AND 365);
+
+ALTER TABLE scans
+    ADD COLUMN dirs_skipped INT NOT NULL DEFAULT 0 CHECK (dirs_skipped >= 0);
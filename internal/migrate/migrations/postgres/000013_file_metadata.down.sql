@@ -0,0 +1,19 @@
+ALTER TABLE scans
+    DROP COLUMN IF EXISTS files_metadata_changed;
+
+DELETE FROM change_events WHERE event_type = 'metadata_changed';
+
+ALTER TABLE change_events
+    DROP COLUMN IF EXISTS new_metadata,
+    DROP COLUMN IF EXISTS old_metadata,
+    DROP CONSTRAINT change_events_event_type_check,
+    ADD CONSTRAINT change_events_event_type_check CHECK (event_type IN ('added', 'deleted', 'modified', 'verified'));
+
+ALTER TABLE storage_targets
+    DROP COLUMN IF EXISTS capture_xattrs;
+
+ALTER TABLE files
+    DROP COLUMN IF EXISTS xattr_digest,
+    DROP COLUMN IF EXISTS gid,
+    DROP COLUMN IF EXISTS uid,
+    DROP COLUMN IF EXISTS mode;
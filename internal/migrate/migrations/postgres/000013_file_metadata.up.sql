@@ -0,0 +1,20 @@
+-- Permissions, ownership and a digest of extended attributes and ACLs; NULL
+-- where they were not captured
+ALTER TABLE files
+    ADD COLUMN mode INT CHECK (mode BETWEEN 0 AND 4095),
+    ADD COLUMN uid BIGINT CHECK (uid >= 0),
+    ADD COLUMN gid BIGINT CHECK (gid >= 0),
+    ADD COLUMN xattr_digest TEXT;
+
+-- Reading extended attributes costs extra syscalls per file, so targets opt in
+ALTER TABLE storage_targets
+    ADD COLUMN capture_xattrs BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE change_events
+    DROP CONSTRAINT change_events_event_type_check,
+    ADD CONSTRAINT change_events_event_type_check CHECK (event_type IN ('added', 'deleted', 'modified', 'verified', 'metadata_changed')),
+    ADD COLUMN old_metadata TEXT,
+    ADD COLUMN new_metadata TEXT;
+
+ALTER TABLE scans
+    ADD COLUMN files_metadata_changed INT NOT NULL DEFAULT 0 CHECK (files_metadata_changed >= 0);
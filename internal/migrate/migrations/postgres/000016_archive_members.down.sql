@@ -0,0 +1,11 @@
+DELETE FROM change_events WHERE event_type IN ('member_added', 'member_deleted', 'member_modified');
+
+ALTER TABLE change_events
+    DROP COLUMN IF EXISTS member_path,
+    DROP CONSTRAINT change_events_event_type_check,
+    ADD CONSTRAINT change_events_event_type_check CHECK (event_type IN ('added', 'deleted', 'modified', 'verified', 'metadata_changed'));
+
+ALTER TABLE storage_targets
+    DROP COLUMN IF EXISTS scan_archives;
+
+DROP TABLE IF EXISTS archive_members;
@@ -0,0 +1,24 @@
+-- Members of ZIP and TAR archives, hashed along with the archive so damage
+-- can be traced to the members it affects
+CREATE TABLE archive_members (
+    id                  BIGSERIAL PRIMARY KEY,
+    file_id             BIGINT NOT NULL REFERENCES files(id) ON DELETE CASCADE,
+    path                TEXT NOT NULL,
+    size                BIGINT NOT NULL CHECK (size >= 0),
+    mod_time            TIMESTAMP WITH TIME ZONE,
+    checksum            TEXT NOT NULL,
+    checksum_type       TEXT NOT NULL,
+    first_seen          TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_checksummed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    UNIQUE (file_id, path)
+);
+
+-- Reading every archive twice costs I/O, so targets opt in
+ALTER TABLE storage_targets
+    ADD COLUMN scan_archives BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Member events are recorded against the archive, naming the member
+ALTER TABLE change_events
+    DROP CONSTRAINT change_events_event_type_check,
+    ADD CONSTRAINT change_events_event_type_check CHECK (event_type IN ('added', 'deleted', 'modified', 'verified', 'metadata_changed', 'member_added', 'member_deleted', 'member_modified')),
+    ADD COLUMN member_path TEXT;
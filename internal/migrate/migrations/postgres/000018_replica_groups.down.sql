@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS replica_discrepancies;
+DROP TABLE IF EXISTS replica_comparisons;
+DROP TABLE IF EXISTS replica_group_targets;
+DROP TABLE IF EXISTS replica_groups;
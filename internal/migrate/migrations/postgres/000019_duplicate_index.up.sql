@@ -0,0 +1,6 @@
+-- Groups active files by content, so duplicates can be listed in key order
+-- and each group's files read without scanning the table. The target is
+-- carried in the index so filtering by target needs no heap lookups.
+CREATE INDEX idx_files_content ON files(checksum_type, current_checksum, size, id)
+    INCLUDE (storage_target_id)
+    WHERE deleted_at IS NULL AND current_checksum IS NOT NULL;
@@ -0,0 +1,13 @@
+-- Known-good checksums imported from manifests. A file seen for the first
+-- time is checked against its expected checksum instead of being trusted as
+-- found; the expectation is removed once applied.
+CREATE TABLE expected_checksums (
+    id                  BIGSERIAL PRIMARY KEY,
+    storage_target_id   BIGINT NOT NULL REFERENCES storage_targets(id) ON DELETE CASCADE,
+    path                TEXT NOT NULL,
+    checksum            TEXT NOT NULL,
+    checksum_type       TEXT NOT NULL CHECK (checksum_type IN ('md5', 'sha256', 'blake3')),
+    source              TEXT,
+    imported_at         TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (storage_target_id, path)
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS audit_reports;
+DROP TABLE IF EXISTS signing_keys;
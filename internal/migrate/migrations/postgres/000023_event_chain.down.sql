@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS audit_checkpoints;
+DROP TABLE IF EXISTS event_chain_heads;
+
+DROP INDEX IF EXISTS idx_changes_chain;
+
+ALTER TABLE change_events
+    DROP COLUMN IF EXISTS hash,
+    DROP COLUMN IF EXISTS prev_hash,
+    DROP COLUMN IF EXISTS chain_seq,
+    DROP COLUMN IF EXISTS storage_target_id;
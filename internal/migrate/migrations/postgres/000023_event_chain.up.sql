@@ -0,0 +1,38 @@
+-- Change events are hash-chained per target: each records its position in
+-- the chain, the hash of the event before it and its own hash. Events
+-- recorded before the chain existed are left NULL.
+ALTER TABLE change_events
+    ADD COLUMN storage_target_id BIGINT REFERENCES storage_targets(id) ON DELETE CASCADE,
+    ADD COLUMN chain_seq BIGINT CHECK (chain_seq > 0),
+    ADD COLUMN prev_hash TEXT,
+    ADD COLUMN hash TEXT,
+    ADD CHECK ((chain_seq IS NULL AND prev_hash IS NULL AND hash IS NULL AND storage_target_id IS NULL)
+        OR (chain_seq IS NOT NULL AND prev_hash IS NOT NULL AND hash IS NOT NULL AND storage_target_id IS NOT NULL));
+
+CREATE UNIQUE INDEX idx_changes_chain ON change_events(storage_target_id, chain_seq) WHERE chain_seq IS NOT NULL;
+
+-- The last event of each target's chain; its row is locked while events are
+-- appended so they are chained one after another
+CREATE TABLE event_chain_heads (
+    storage_target_id   BIGINT PRIMARY KEY REFERENCES storage_targets(id) ON DELETE CASCADE,
+    seq                 BIGINT NOT NULL DEFAULT 0 CHECK (seq >= 0),
+    hash                TEXT NOT NULL DEFAULT '',
+    updated_at          TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Signed snapshots of a target's chain head and recorded checksums, written
+-- after each scan so later edits can be detected
+CREATE TABLE audit_checkpoints (
+    id                  BIGSERIAL PRIMARY KEY,
+    storage_target_id   BIGINT NOT NULL REFERENCES storage_targets(id) ON DELETE CASCADE,
+    chain_seq           BIGINT NOT NULL CHECK (chain_seq >= 0),
+    chain_hash          TEXT NOT NULL,
+    files_digest        TEXT NOT NULL,
+    file_count          BIGINT NOT NULL CHECK (file_count >= 0),
+    payload             TEXT NOT NULL,
+    signature           TEXT NOT NULL,
+    key_id              TEXT NOT NULL REFERENCES signing_keys(key_id),
+    created_at          TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_checkpoints_target ON audit_checkpoints(storage_target_id, chain_seq);
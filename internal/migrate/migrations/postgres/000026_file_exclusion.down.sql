@@ -0,0 +1,10 @@
+DELETE FROM change_events WHERE event_type = 'excluded';
+
+ALTER TABLE change_events
+    DROP CONSTRAINT change_events_event_type_check,
+    ADD CONSTRAINT change_events_event_type_check CHECK (event_type IN ('added', 'deleted', 'modified', 'verified', 'metadata_changed', 'member_added', 'member_deleted', 'member_modified', 'moved'));
+
+UPDATE files SET deleted_at = NULL WHERE excluded;
+
+ALTER TABLE files
+    DROP COLUMN IF EXISTS excluded;
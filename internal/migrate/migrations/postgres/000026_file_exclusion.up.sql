@@ -0,0 +1,9 @@
+-- Files the target's path rules came to exclude are soft-deleted and flagged,
+-- so they leave the statistics without being reported as deleted or offered
+-- as the source of a move
+ALTER TABLE files
+    ADD COLUMN excluded BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE change_events
+    DROP CONSTRAINT change_events_event_type_check,
+    ADD CONSTRAINT change_events_event_type_check CHECK (event_type IN ('added', 'deleted', 'modified', 'verified', 'metadata_changed', 'member_added', 'member_deleted', 'member_modified', 'moved', 'excluded'));
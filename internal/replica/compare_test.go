@@ -0,0 +1,109 @@
+package replica
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/lib/pq"
+
+	"github.com/jeffanddom/fixity/internal/database"
+	"github.com/jeffanddom/fixity/tests/testutil"
+)
+
+func TestComparer_Compare(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupDB(t, db)
+
+	ctx := context.Background()
+	primary := testutil.MustCreateStorageTarget(t, db, "primary")
+	dr := testutil.MustCreateStorageTarget(t, db, "dr")
+	cloud := testutil.MustCreateStorageTarget(t, db, "cloud")
+
+	for _, target := range []*database.StorageTarget{primary, dr, cloud} {
+		testutil.MustCreateFile(t, db, target.ID, "a.txt")
+		testutil.MustCreateFile(t, db, target.ID, "c.txt")
+	}
+	testutil.MustCreateFile(t, db, primary.ID, "b.txt")
+	testutil.MustCreateFile(t, db, cloud.ID, "b.txt")
+
+	// The DR copy of c.txt has rotted
+	rotted, _ := db.Files.GetByPath(ctx, dr.ID, "c.txt")
+	other := "bad999"
+	rotted.CurrentChecksum = &other
+	if err := db.Files.Update(ctx, rotted); err != nil {
+		t.Fatalf("failed to update file: %v", err)
+	}
+
+	group := &database.ReplicaGroup{Name: "collection"}
+	if err := db.ReplicaGroups.Create(ctx, group); err != nil {
+		t.Fatalf("failed to create group: %v", err)
+	}
+	if err := db.ReplicaGroups.SetTargets(ctx, group.ID, []int64{primary.ID, dr.ID, cloud.ID}); err != nil {
+		t.Fatalf("failed to set targets: %v", err)
+	}
+
+	webhook := &database.Webhook{
+		Name: "alerts", URL: "https://example.com/hook", Enabled: true,
+		EventIncludes: pq.StringArray{EventDiscrepancy}, EventExcludes: pq.StringArray{},
+		RetryAttempts: 3, RetryBackoffSec: 60, TimeoutSec: 30,
+	}
+	if err := db.Webhooks.Create(ctx, webhook); err != nil {
+		t.Fatalf("failed to create webhook: %v", err)
+	}
+
+	// A small batch splits copies of the same path across queries
+	comparer := NewComparer(db)
+	comparer.batchSize = 2
+
+	comparison, err := comparer.Compare(ctx, group.ID)
+	if err != nil {
+		t.Fatalf("comparison failed: %v", err)
+	}
+	if comparison.Status != database.ReplicaComparisonCompleted || comparison.FilesCompared != 3 {
+		t.Errorf("expected 3 paths compared, got %+v", comparison)
+	}
+	if comparison.FilesMissing != 1 || comparison.FilesDivergent != 1 {
+		t.Errorf("expected 1 missing and 1 divergent, got %d and %d", comparison.FilesMissing, comparison.FilesDivergent)
+	}
+
+	discrepancies, err := db.ReplicaComparisons.ListDiscrepancies(ctx, comparison.ID, 0)
+	if err != nil {
+		t.Fatalf("failed to list discrepancies: %v", err)
+	}
+	if len(discrepancies) != 2 {
+		t.Fatalf("expected 2 discrepancies, got %d", len(discrepancies))
+	}
+	if d := discrepancies[0]; d.Path != "b.txt" || d.StorageTargetID != dr.ID || d.Problem != database.ReplicaMissing {
+		t.Errorf("expected b.txt missing from dr, got %+v", d)
+	}
+	if d := discrepancies[1]; d.Path != "c.txt" || d.StorageTargetID != dr.ID || *d.LikelyChecksum != "abc123" {
+		t.Errorf("expected the dr copy of c.txt to be outvoted, got %+v", d)
+	}
+
+	deliveries, _ := db.WebhookDeliveries.GetPending(ctx, 10)
+	if len(deliveries) != 1 || deliveries[0].EventType != EventDiscrepancy {
+		t.Fatalf("expected one alert to be queued, got %d", len(deliveries))
+	}
+	var payload alertPayload
+	if err := json.Unmarshal(deliveries[0].Payload, &payload); err != nil || payload.ComparisonID != comparison.ID {
+		t.Errorf("unexpected alert payload: %s (%v)", deliveries[0].Payload, err)
+	}
+}
+
+func TestComparer_NeedsTwoTargets(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupDB(t, db)
+
+	ctx := context.Background()
+	target := testutil.MustCreateStorageTarget(t, db, "primary")
+	group := &database.ReplicaGroup{Name: "lonely"}
+	db.ReplicaGroups.Create(ctx, group)
+	db.ReplicaGroups.SetTargets(ctx, group.ID, []int64{target.ID})
+
+	if _, err := NewComparer(db).Compare(ctx, group.ID); err == nil {
+		t.Error("expected a group with one target to be refused")
+	}
+}
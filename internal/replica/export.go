@@ -0,0 +1,94 @@
+package replica
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/jeffanddom/fixity/internal/database"
+)
+
+// exportRow is one discrepancy as exported, naming the target rather than
+// just its ID
+type exportRow struct {
+	Path           string `json:"path"`
+	TargetID       int64  `json:"target_id"`
+	Target         string `json:"target"`
+	Problem        string `json:"problem"`
+	Checksum       string `json:"checksum,omitempty"`
+	ChecksumType   string `json:"checksum_type,omitempty"`
+	LikelyChecksum string `json:"likely_checksum,omitempty"`
+}
+
+func exportRows(discrepancies []*database.ReplicaDiscrepancy, targetNames map[int64]string) []exportRow {
+	deref := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+
+	rows := make([]exportRow, len(discrepancies))
+	for i, d := range discrepancies {
+		rows[i] = exportRow{
+			Path:           d.Path,
+			TargetID:       d.StorageTargetID,
+			Target:         targetNames[d.StorageTargetID],
+			Problem:        string(d.Problem),
+			Checksum:       deref(d.Checksum),
+			ChecksumType:   deref(d.ChecksumType),
+			LikelyChecksum: deref(d.LikelyChecksum),
+		}
+	}
+	return rows
+}
+
+// WriteCSV writes discrepancies as CSV with a header row
+func WriteCSV(w io.Writer, discrepancies []*database.ReplicaDiscrepancy, targetNames map[int64]string) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"path", "target_id", "target", "problem", "checksum", "checksum_type", "likely_checksum"})
+	for _, row := range exportRows(discrepancies, targetNames) {
+		cw.Write([]string{
+			row.Path,
+			strconv.FormatInt(row.TargetID, 10),
+			row.Target,
+			row.Problem,
+			row.Checksum,
+			row.ChecksumType,
+			row.LikelyChecksum,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON writes a comparison and its discrepancies as a JSON document
+func WriteJSON(w io.Writer, comparison *database.ReplicaComparison, discrepancies []*database.ReplicaDiscrepancy, targetNames map[int64]string) error {
+	doc := struct {
+		ComparisonID   int64       `json:"comparison_id"`
+		GroupID        int64       `json:"group_id"`
+		Status         string      `json:"status"`
+		StartedAt      time.Time   `json:"started_at"`
+		CompletedAt    *time.Time  `json:"completed_at,omitempty"`
+		FilesCompared  int64       `json:"files_compared"`
+		FilesMissing   int64       `json:"files_missing"`
+		FilesDivergent int64       `json:"files_divergent"`
+		Discrepancies  []exportRow `json:"discrepancies"`
+	}{
+		ComparisonID:   comparison.ID,
+		GroupID:        comparison.GroupID,
+		Status:         string(comparison.Status),
+		StartedAt:      comparison.StartedAt,
+		CompletedAt:    comparison.CompletedAt,
+		FilesCompared:  comparison.FilesCompared,
+		FilesMissing:   comparison.FilesMissing,
+		FilesDivergent: comparison.FilesDivergent,
+		Discrepancies:  exportRows(discrepancies, targetNames),
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
@@ -0,0 +1,274 @@
+package replica
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jeffanddom/fixity/internal/database"
+)
+
+// EventDiscrepancy is the webhook event queued when a comparison finds
+// missing or divergent copies
+const EventDiscrepancy = "replica.discrepancy"
+
+const (
+	defaultBatchSize = 5000 // Files read per query
+	flushSize        = 1000 // Discrepancies written per transaction
+)
+
+// Comparer compares the files of a replica group's targets
+type Comparer struct {
+	db        *database.Database
+	batchSize int
+}
+
+// NewComparer creates a new comparer
+func NewComparer(db *database.Database) *Comparer {
+	return &Comparer{db: db, batchSize: defaultBatchSize}
+}
+
+// Compare joins the active files of a replica group's targets by relative
+// path and checksum, records every copy that is missing or disagrees with the
+// majority, and queues a webhook event if any were found
+func (c *Comparer) Compare(ctx context.Context, groupID int64) (*database.ReplicaComparison, error) {
+	group, err := c.db.ReplicaGroups.GetByID(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	targets, err := c.db.ReplicaGroups.ListTargets(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) < 2 {
+		return nil, fmt.Errorf("replica group %s needs at least two targets", group.Name)
+	}
+
+	targetIDs := make([]int64, len(targets))
+	for i, target := range targets {
+		targetIDs[i] = target.ID
+	}
+
+	comparison := &database.ReplicaComparison{
+		GroupID:   groupID,
+		Status:    database.ReplicaComparisonRunning,
+		StartedAt: time.Now(),
+	}
+	if err := c.db.ReplicaComparisons.Create(ctx, comparison); err != nil {
+		return nil, err
+	}
+
+	compareErr := c.compare(ctx, comparison, targetIDs)
+
+	now := time.Now()
+	comparison.CompletedAt = &now
+	comparison.Status = database.ReplicaComparisonCompleted
+	if compareErr != nil {
+		msg := compareErr.Error()
+		comparison.Status = database.ReplicaComparisonFailed
+		comparison.ErrorMessage = &msg
+	}
+	if err := c.db.ReplicaComparisons.Update(ctx, comparison); err != nil {
+		return comparison, err
+	}
+	if compareErr != nil {
+		return comparison, compareErr
+	}
+
+	if comparison.FilesMissing > 0 || comparison.FilesDivergent > 0 {
+		if err := c.alert(ctx, group, comparison); err != nil {
+			return comparison, err
+		}
+	}
+
+	return comparison, nil
+}
+
+// compare walks the group's files in path order, judging each path once all
+// of its copies have been read
+func (c *Comparer) compare(ctx context.Context, comparison *database.ReplicaComparison, targetIDs []int64) error {
+	pending := []*database.ReplicaDiscrepancy{}
+	flush := func() error {
+		if err := c.db.ReplicaComparisons.CreateDiscrepancies(ctx, pending); err != nil {
+			return err
+		}
+		pending = pending[:0]
+		return nil
+	}
+
+	var copies []*database.File
+	judgePath := func() error {
+		if len(copies) == 0 {
+			return nil
+		}
+
+		comparison.FilesCompared++
+		var missing, divergent bool
+		for _, d := range judge(targetIDs, copies) {
+			d.ComparisonID = comparison.ID
+			d.Path = copies[0].Path
+			missing = missing || d.Problem == database.ReplicaMissing
+			divergent = divergent || d.Problem == database.ReplicaDivergent
+			pending = append(pending, d)
+		}
+		if missing {
+			comparison.FilesMissing++
+		}
+		if divergent {
+			comparison.FilesDivergent++
+		}
+
+		copies = copies[:0]
+		if len(pending) >= flushSize {
+			return flush()
+		}
+		return nil
+	}
+
+	afterPath, afterTargetID := "", int64(0)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		files, err := c.db.Files.ListAcrossTargets(ctx, targetIDs, afterPath, afterTargetID, c.batchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, file := range files {
+			if len(copies) > 0 && copies[0].Path != file.Path {
+				if err := judgePath(); err != nil {
+					return err
+				}
+			}
+			copies = append(copies, file)
+		}
+
+		if len(files) < c.batchSize {
+			break
+		}
+		last := files[len(files)-1]
+		afterPath, afterTargetID = last.Path, last.StorageTargetID
+	}
+
+	if err := judgePath(); err != nil {
+		return err
+	}
+	return flush()
+}
+
+// judge compares the copies of one path, at most one per target, and returns
+// those that are missing or disagree with the majority. Checksums of different
+// algorithms cannot be compared, so only copies hashed with the algorithm most
+// of them use take part in the vote; the others, and copies not yet hashed,
+// are not judged.
+func judge(targetIDs []int64, copies []*database.File) []*database.ReplicaDiscrepancy {
+	byTarget := make(map[int64]*database.File, len(copies))
+	algorithms := make(map[string]int)
+	for _, file := range copies {
+		byTarget[file.StorageTargetID] = file
+		if file.CurrentChecksum != nil && file.ChecksumType != nil {
+			algorithms[*file.ChecksumType]++
+		}
+	}
+
+	algorithm := ""
+	for name, count := range algorithms {
+		if count > algorithms[algorithm] || (count == algorithms[algorithm] && name < algorithm) {
+			algorithm = name
+		}
+	}
+
+	voting := func(file *database.File) bool {
+		return file.CurrentChecksum != nil && file.ChecksumType != nil && *file.ChecksumType == algorithm
+	}
+
+	votes := make(map[string]int)
+	for _, file := range copies {
+		if voting(file) {
+			votes[*file.CurrentChecksum]++
+		}
+	}
+
+	// The likely checksum is the one with the most votes, if no other ties it
+	var likely *string
+	best, tied := 0, false
+	for sum, count := range votes {
+		switch {
+		case count > best:
+			sum := sum
+			likely, best, tied = &sum, count, false
+		case count == best:
+			tied = true
+		}
+	}
+	if tied {
+		likely = nil
+	}
+
+	discrepancies := []*database.ReplicaDiscrepancy{}
+	for _, targetID := range targetIDs {
+		file, ok := byTarget[targetID]
+		if !ok {
+			discrepancies = append(discrepancies, &database.ReplicaDiscrepancy{
+				StorageTargetID: targetID,
+				Problem:         database.ReplicaMissing,
+				LikelyChecksum:  likely,
+			})
+			continue
+		}
+
+		if len(votes) < 2 || !voting(file) {
+			continue
+		}
+		if likely != nil && *file.CurrentChecksum == *likely {
+			continue
+		}
+		discrepancies = append(discrepancies, &database.ReplicaDiscrepancy{
+			StorageTargetID: targetID,
+			Problem:         database.ReplicaDivergent,
+			Checksum:        file.CurrentChecksum,
+			ChecksumType:    file.ChecksumType,
+			LikelyChecksum:  likely,
+		})
+	}
+
+	return discrepancies
+}
+
+// alertPayload is the body of a replica.discrepancy webhook delivery
+type alertPayload struct {
+	Event          string    `json:"event"`
+	GroupID        int64     `json:"group_id"`
+	GroupName      string    `json:"group_name"`
+	ComparisonID   int64     `json:"comparison_id"`
+	FilesCompared  int64     `json:"files_compared"`
+	FilesMissing   int64     `json:"files_missing"`
+	FilesDivergent int64     `json:"files_divergent"`
+	CompletedAt    time.Time `json:"completed_at"`
+}
+
+// alert queues a replica.discrepancy event for the webhooks that accept it
+func (c *Comparer) alert(ctx context.Context, group *database.ReplicaGroup, comparison *database.ReplicaComparison) error {
+	payload, err := json.Marshal(alertPayload{
+		Event:          EventDiscrepancy,
+		GroupID:        group.ID,
+		GroupName:      group.Name,
+		ComparisonID:   comparison.ID,
+		FilesCompared:  comparison.FilesCompared,
+		FilesMissing:   comparison.FilesMissing,
+		FilesDivergent: comparison.FilesDivergent,
+		CompletedAt:    *comparison.CompletedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode alert: %w", err)
+	}
+
+	if _, err := c.db.WebhookDeliveries.Enqueue(ctx, EventDiscrepancy, payload); err != nil {
+		return err
+	}
+	return nil
+}
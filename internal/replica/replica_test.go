@@ -0,0 +1,132 @@
+package replica
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeffanddom/fixity/internal/database"
+)
+
+func copyOf(targetID int64, checksumType, sum string) *database.File {
+	file := &database.File{StorageTargetID: targetID, Path: "photos/a.jpg"}
+	if sum != "" {
+		file.CurrentChecksum = &sum
+		file.ChecksumType = &checksumType
+	}
+	return file
+}
+
+func problems(discrepancies []*database.ReplicaDiscrepancy) map[int64]database.ReplicaProblem {
+	got := map[int64]database.ReplicaProblem{}
+	for _, d := range discrepancies {
+		got[d.StorageTargetID] = d.Problem
+	}
+	return got
+}
+
+func TestJudge(t *testing.T) {
+	targets := []int64{1, 2, 3}
+
+	t.Run("agreeing copies", func(t *testing.T) {
+		found := judge(targets, []*database.File{copyOf(1, "md5", "aa"), copyOf(2, "md5", "aa"), copyOf(3, "md5", "aa")})
+		if len(found) != 0 {
+			t.Errorf("expected no discrepancies, got %v", problems(found))
+		}
+	})
+
+	t.Run("missing copy", func(t *testing.T) {
+		found := judge(targets, []*database.File{copyOf(1, "md5", "aa"), copyOf(3, "md5", "aa")})
+		if len(found) != 1 || found[0].StorageTargetID != 2 || found[0].Problem != database.ReplicaMissing {
+			t.Fatalf("expected target 2 missing, got %v", problems(found))
+		}
+		if found[0].LikelyChecksum == nil || *found[0].LikelyChecksum != "aa" {
+			t.Error("expected the missing copy to carry the checksum it should have")
+		}
+	})
+
+	t.Run("majority wins", func(t *testing.T) {
+		found := judge(targets, []*database.File{copyOf(1, "md5", "aa"), copyOf(2, "md5", "bb"), copyOf(3, "md5", "aa")})
+		if len(found) != 1 || found[0].StorageTargetID != 2 || found[0].Problem != database.ReplicaDivergent {
+			t.Fatalf("expected only target 2 divergent, got %v", problems(found))
+		}
+		if *found[0].Checksum != "bb" || *found[0].LikelyChecksum != "aa" {
+			t.Errorf("expected bb against likely aa, got %s against %s", *found[0].Checksum, *found[0].LikelyChecksum)
+		}
+	})
+
+	t.Run("tie has no likely copy", func(t *testing.T) {
+		found := judge(targets, []*database.File{copyOf(1, "md5", "aa"), copyOf(2, "md5", "bb")})
+		got := problems(found)
+		if got[1] != database.ReplicaDivergent || got[2] != database.ReplicaDivergent || got[3] != database.ReplicaMissing {
+			t.Fatalf("expected both copies divergent and target 3 missing, got %v", got)
+		}
+		for _, d := range found {
+			if d.LikelyChecksum != nil {
+				t.Errorf("expected no likely checksum on a tie, got %s", *d.LikelyChecksum)
+			}
+		}
+	})
+
+	t.Run("other algorithms and unhashed copies are not judged", func(t *testing.T) {
+		found := judge(targets, []*database.File{copyOf(1, "md5", "aa"), copyOf(2, "sha256", "ff"), copyOf(3, "", "")})
+		if len(found) != 0 {
+			t.Errorf("expected no discrepancies, got %v", problems(found))
+		}
+	})
+}
+
+func TestWriteCSV(t *testing.T) {
+	likely := "aa"
+	sum, sumType := "bb", "md5"
+	discrepancies := []*database.ReplicaDiscrepancy{
+		{Path: "photos/a,b.jpg", StorageTargetID: 2, Problem: database.ReplicaDivergent, Checksum: &sum, ChecksumType: &sumType, LikelyChecksum: &likely},
+		{Path: "photos/c.jpg", StorageTargetID: 3, Problem: database.ReplicaMissing},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, discrepancies, map[int64]string{2: "dr-nas", 3: "s3"}); err != nil {
+		t.Fatalf("failed to write CSV: %v", err)
+	}
+
+	want := "path,target_id,target,problem,checksum,checksum_type,likely_checksum\n" +
+		"\"photos/a,b.jpg\",2,dr-nas,divergent,bb,md5,aa\n" +
+		"photos/c.jpg,3,s3,missing,,,\n"
+	if buf.String() != want {
+		t.Errorf("unexpected CSV:\n%s", buf.String())
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	completed := time.Now()
+	comparison := &database.ReplicaComparison{
+		ID: 7, GroupID: 3, Status: database.ReplicaComparisonCompleted,
+		StartedAt: completed.Add(-time.Minute), CompletedAt: &completed,
+		FilesCompared: 10, FilesMissing: 1,
+	}
+	discrepancies := []*database.ReplicaDiscrepancy{
+		{Path: "photos/c.jpg", StorageTargetID: 3, Problem: database.ReplicaMissing},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, comparison, discrepancies, map[int64]string{3: "s3"}); err != nil {
+		t.Fatalf("failed to write JSON: %v", err)
+	}
+
+	var doc struct {
+		ComparisonID  int64 `json:"comparison_id"`
+		FilesMissing  int64 `json:"files_missing"`
+		Discrepancies []map[string]interface{}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("failed to parse JSON: %v", err)
+	}
+	if doc.ComparisonID != 7 || doc.FilesMissing != 1 || len(doc.Discrepancies) != 1 {
+		t.Errorf("unexpected document: %s", buf.String())
+	}
+	if doc.Discrepancies[0]["target"] != "s3" || strings.Contains(buf.String(), `"checksum":`) {
+		t.Errorf("expected the target named and empty checksums left out: %s", buf.String())
+	}
+}
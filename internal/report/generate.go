@@ -0,0 +1,93 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/jeffanddom/fixity/internal/database"
+	"github.com/jeffanddom/fixity/internal/signing"
+)
+
+// Generate builds, renders and signs the report of a target for scans
+// started in [start, end), and stores it
+func Generate(
+	ctx context.Context,
+	db *database.Database,
+	key *signing.Key,
+	target *database.StorageTarget,
+	start, end time.Time,
+	format Format,
+	generatedBy string,
+) (*database.AuditReport, error) {
+	report, err := Build(ctx, db, target, start, end)
+	if err != nil {
+		return nil, err
+	}
+	report.KeyID = key.ID()
+
+	var buf bytes.Buffer
+	if err := Render(&buf, report, format); err != nil {
+		return nil, fmt.Errorf("failed to render report: %w", err)
+	}
+
+	sig := key.Sign(buf.Bytes())
+	encoded, err := sig.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.SigningKeys.Register(ctx, key.ID(), signing.EncodePublicKey(key.PublicKey())); err != nil {
+		return nil, err
+	}
+
+	stored := &database.AuditReport{
+		StorageTargetID: target.ID,
+		PeriodStart:     report.PeriodStart,
+		PeriodEnd:       report.PeriodEnd,
+		Format:          string(format),
+		Content:         buf.Bytes(),
+		SHA256:          sig.SHA256,
+		Signature:       string(encoded),
+		KeyID:           key.ID(),
+		GeneratedBy:     generatedBy,
+		GeneratedAt:     report.GeneratedAt,
+	}
+	if err := db.AuditReports.Create(ctx, stored); err != nil {
+		return nil, err
+	}
+
+	return stored, nil
+}
+
+// Verify checks a report against its detached signature, and that the
+// signing key is one Fixity has signed with
+func Verify(ctx context.Context, db *database.Database, content, signature []byte) (*signing.Signature, error) {
+	sig, err := signing.ParseSignature(signature)
+	if err != nil {
+		return nil, err
+	}
+	if err := sig.Verify(content); err != nil {
+		return nil, err
+	}
+
+	if err := db.SigningKeys.Check(ctx, sig.KeyID, sig.PublicKey); err != nil {
+		return nil, err
+	}
+
+	return sig, nil
+}
+
+var unsafeFilename = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Filename names a stored report for download, such as
+// fixity-audit-photos-2024-01-01-2024-02-01.html
+func Filename(report *database.AuditReport, targetName string) string {
+	return fmt.Sprintf("fixity-audit-%s-%s-%s.%s",
+		unsafeFilename.ReplaceAllString(targetName, "_"),
+		report.PeriodStart.UTC().Format("2006-01-02"),
+		report.PeriodEnd.UTC().Format("2006-01-02"),
+		report.Format)
+}
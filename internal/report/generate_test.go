@@ -0,0 +1,171 @@
+package report_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jeffanddom/fixity/internal/checksum"
+	"github.com/jeffanddom/fixity/internal/database"
+	"github.com/jeffanddom/fixity/internal/report"
+	"github.com/jeffanddom/fixity/internal/scanner"
+	"github.com/jeffanddom/fixity/internal/signing"
+	"github.com/jeffanddom/fixity/internal/storage"
+	"github.com/jeffanddom/fixity/tests/testutil"
+)
+
+func TestGenerateAndVerify(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupDB(t, db)
+
+	ctx := context.Background()
+	target := testutil.MustCreateStorageTarget(t, db, "archive")
+	start := time.Now().Add(-time.Hour)
+	end := time.Now().Add(time.Hour)
+
+	// One file verified intact, one found corrupted and one never hashed
+	intact := testutil.MustCreateFile(t, db, target.ID, "intact.txt")
+	corrupted := testutil.MustCreateFile(t, db, target.ID, "corrupted.txt")
+	unhashed := testutil.MustCreateFile(t, db, target.ID, "unhashed.txt")
+	unhashed.CurrentChecksum, unhashed.ChecksumType, unhashed.LastChecksummedAt = nil, nil, nil
+	if err := db.Files.Update(ctx, unhashed); err != nil {
+		t.Fatalf("failed to update file: %v", err)
+	}
+
+	scan := testutil.MustCreateScan(t, db, target.ID)
+	good, bad := "abc123", "def456"
+	db.ChangeEvents.CreateBatch(ctx, []*database.ChangeEvent{
+		{ScanID: scan.ID, FileID: intact.ID, EventType: database.ChangeEventVerified, DetectedAt: time.Now(), OldChecksum: &good, NewChecksum: &good},
+		{ScanID: scan.ID, FileID: corrupted.ID, EventType: database.ChangeEventVerified, DetectedAt: time.Now(), OldChecksum: &good, NewChecksum: &bad},
+	})
+
+	key, err := signing.Generate()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	stored, err := report.Generate(ctx, db, key, target, start, end, report.FormatJSON, "tester")
+	if err != nil {
+		t.Fatalf("failed to generate report: %v", err)
+	}
+
+	var content report.Report
+	if err := json.Unmarshal(stored.Content, &content); err != nil {
+		t.Fatalf("failed to decode report: %v", err)
+	}
+	if content.Coverage.TotalFiles != 3 || content.Coverage.NeverVerified != 1 {
+		t.Errorf("unexpected coverage: %+v", content.Coverage)
+	}
+	if content.Corruption.Count != 1 || content.Corruption.Incidents[0].Path != "corrupted.txt" {
+		t.Errorf("unexpected corruption: %+v", content.Corruption)
+	}
+	if len(content.Unverified.Paths) != 1 || content.Unverified.Paths[0] != "unhashed.txt" {
+		t.Errorf("unexpected never verified files: %+v", content.Unverified)
+	}
+	if content.Scans.Total != 1 || content.KeyID != key.ID() {
+		t.Errorf("unexpected report: %+v", content)
+	}
+
+	t.Run("verifies the stored report", func(t *testing.T) {
+		loaded, err := db.AuditReports.GetByID(ctx, stored.ID)
+		if err != nil {
+			t.Fatalf("failed to load report: %v", err)
+		}
+		if _, err := report.Verify(ctx, db, loaded.Content, []byte(loaded.Signature)); err != nil {
+			t.Errorf("expected the report to verify: %v", err)
+		}
+	})
+
+	t.Run("rejects an edited report", func(t *testing.T) {
+		edited := append([]byte{}, stored.Content...)
+		edited[len(edited)-2] = ' '
+		if _, err := report.Verify(ctx, db, edited, []byte(stored.Signature)); err == nil {
+			t.Error("expected an edited report to fail verification")
+		}
+	})
+
+	t.Run("rejects an unknown key", func(t *testing.T) {
+		other, _ := signing.Generate()
+		sig, _ := other.Sign(stored.Content).Marshal()
+		if _, err := report.Verify(ctx, db, stored.Content, sig); err == nil {
+			t.Error("expected a report signed by an unknown key to fail verification")
+		}
+	})
+}
+
+func TestBuild_FromScans(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupDB(t, db)
+
+	ctx := context.Background()
+	target := testutil.MustCreateStorageTarget(t, db, "archive")
+	start := time.Now().Add(-time.Hour)
+
+	tmpDir := t.TempDir()
+	for name, content := range map[string]string{
+		"keep.txt":    "kept as is",
+		"edit.txt":    "first draft",
+		"corrupt.txt": "good bytes",
+	} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	backend, _ := storage.NewLocalFSBackend(tmpDir)
+
+	engine := scanner.NewEngine(db, scanner.Config{
+		ChecksumAlgorithm:   checksum.AlgorithmMD5,
+		ParallelWorkers:     2,
+		RandomSamplePercent: 100,
+	})
+	if _, err := engine.Scan(ctx, target.ID, backend); err != nil {
+		t.Fatalf("first scan failed: %v", err)
+	}
+	original, _ := db.Files.GetByPath(ctx, target.ID, "corrupt.txt")
+
+	// An edit and a new file, and corruption that leaves size and mtime as
+	// they were
+	os.WriteFile(filepath.Join(tmpDir, "edit.txt"), []byte("second, longer draft"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "new.txt"), []byte("new file"), 0644)
+	corruptPath := filepath.Join(tmpDir, "corrupt.txt")
+	info, _ := os.Stat(corruptPath)
+	os.WriteFile(corruptPath, []byte("bad! bytes"), 0644)
+	os.Chtimes(corruptPath, info.ModTime(), info.ModTime())
+
+	result, err := engine.Scan(ctx, target.ID, backend)
+	if err != nil {
+		t.Fatalf("second scan failed: %v", err)
+	}
+	if result.FilesMismatched != 1 {
+		t.Errorf("expected 1 mismatched file, got %d", result.FilesMismatched)
+	}
+
+	stored, _ := db.Files.GetByPath(ctx, target.ID, "corrupt.txt")
+	if *stored.CurrentChecksum != *original.CurrentChecksum {
+		t.Errorf("expected the known-good checksum to be kept, got %s", *stored.CurrentChecksum)
+	}
+
+	built, err := report.Build(ctx, db, target, start, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("failed to build report: %v", err)
+	}
+
+	counts := make(map[string]int64)
+	for _, change := range built.Changes {
+		counts[change.Type] = change.Count
+	}
+	if counts["added"] != 4 || counts["modified"] != 1 || counts["verified"] != 2 {
+		t.Errorf("expected 4 added, 1 modified and 2 verified, got %v", counts)
+	}
+	if built.Corruption.Count != 1 || len(built.Corruption.Incidents) != 1 || built.Corruption.Incidents[0].Path != "corrupt.txt" {
+		t.Errorf("expected corrupt.txt to be reported corrupted, got %+v", built.Corruption)
+	}
+	if built.Coverage.TotalFiles != 4 || built.Coverage.VerifiedFiles != 4 {
+		t.Errorf("expected all 4 files verified, got %+v", built.Coverage)
+	}
+}
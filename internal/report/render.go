@@ -0,0 +1,257 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Format is a report's output format
+type Format string
+
+const (
+	// FormatHTML is a standalone page, readable without Fixity
+	FormatHTML Format = "html"
+
+	// FormatCSV is one row per figure, scan, incident and unverified file,
+	// under the columns section, name, value and detail
+	FormatCSV Format = "csv"
+
+	// FormatJSON is the Report as a JSON document
+	FormatJSON Format = "json"
+)
+
+// ParseFormat checks a format name
+func ParseFormat(name string) (Format, error) {
+	switch Format(name) {
+	case FormatHTML, FormatCSV, FormatJSON:
+		return Format(name), nil
+	default:
+		return "", fmt.Errorf("unknown report format: %s (expected html, csv or json)", name)
+	}
+}
+
+// ContentType returns the MIME type of the format
+func (f Format) ContentType() string {
+	switch f {
+	case FormatHTML:
+		return "text/html; charset=utf-8"
+	case FormatCSV:
+		return "text/csv"
+	default:
+		return "application/json"
+	}
+}
+
+// Render writes a report in the given format
+func Render(w io.Writer, report *Report, format Format) error {
+	switch format {
+	case FormatHTML:
+		return htmlTemplate.Execute(w, report)
+	case FormatCSV:
+		return writeCSV(w, report)
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	default:
+		return fmt.Errorf("unknown report format: %s", format)
+	}
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func writeCSV(w io.Writer, report *Report) error {
+	cw := csv.NewWriter(w)
+	row := func(section, name string, value interface{}, detail string) {
+		cw.Write([]string{section, name, fmt.Sprint(value), detail})
+	}
+
+	cw.Write([]string{"section", "name", "value", "detail"})
+
+	row("report", "target", report.Target.Name, report.Target.Type+" "+report.Target.Path)
+	row("report", "period_start", formatTime(report.PeriodStart), "")
+	row("report", "period_end", formatTime(report.PeriodEnd), "")
+	row("report", "generated_at", formatTime(report.GeneratedAt), "")
+	row("report", "key_id", report.KeyID, "")
+
+	c := report.Coverage
+	row("coverage", "total_files", c.TotalFiles, "")
+	row("coverage", "total_bytes", c.TotalBytes, "")
+	row("coverage", "verified_files", c.VerifiedFiles, "")
+	row("coverage", "verified_bytes", c.VerifiedBytes, "")
+	row("coverage", "coverage_percent", strconv.FormatFloat(c.CoveragePercent, 'f', 2, 64), "")
+	row("coverage", "never_verified", c.NeverVerified, "")
+
+	v := report.Verification
+	row("verification", "total_files", v.TotalFiles, "")
+	row("verification", "verified_last_7_days", v.VerifiedLast7Days, "")
+	row("verification", "verified_last_30_days", v.VerifiedLast30Days, "")
+	row("verification", "verified_last_90_days", v.VerifiedLast90Days, "")
+	row("verification", "never_verified", v.NeverVerified, "")
+	if v.OldestVerification != nil {
+		row("verification", "oldest_verification", formatTime(*v.OldestVerification), "")
+	}
+
+	s := report.Scans
+	row("scans", "total", s.Total, "")
+	row("scans", "completed", s.Completed, "")
+	row("scans", "partial", s.Partial, "")
+	row("scans", "failed", s.Failed, "")
+	row("scans", "running", s.Running, "")
+	row("scans", "files_verified", s.FilesVerified, "")
+	row("scans", "files_mismatched", s.FilesMismatched, "")
+	for _, scan := range s.Scans {
+		row("scan", strconv.FormatInt(scan.ID, 10), scan.Status,
+			fmt.Sprintf("%s started %s; %d scanned, %d verified, %d mismatched, %d errors",
+				scan.Type, formatTime(scan.StartedAt), scan.FilesScanned, scan.FilesVerified, scan.FilesMismatched, scan.Errors))
+	}
+
+	for _, change := range report.Changes {
+		row("changes", change.Type, change.Count, "")
+	}
+
+	row("corruption", "count", report.Corruption.Count, "")
+	for _, incident := range report.Corruption.Incidents {
+		row("incident", incident.Path, incident.Actual,
+			fmt.Sprintf("expected %s; scan %d started %s", incident.Expected, incident.ScanID, formatTime(incident.DetectedAt)))
+	}
+
+	row("never_verified", "count", report.Unverified.Count, "")
+	for _, path := range report.Unverified.Paths {
+		row("unverified", path, "", "")
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"time": formatTime,
+	"percent": func(value float64) string {
+		return strconv.FormatFloat(value, 'f', 2, 64) + "%"
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="utf-8">
+    <title>Fixity Audit Report - {{.Target.Name}}</title>
+    <style>
+        body { font-family: Arial, sans-serif; margin: 2rem; color: #212529; }
+        h1 { margin-bottom: 0.25rem; }
+        .period { color: #6c757d; margin-bottom: 2rem; }
+        table { border-collapse: collapse; margin-bottom: 2rem; min-width: 50%; }
+        th, td { padding: 0.5rem 0.75rem; text-align: left; border-bottom: 1px solid #dee2e6; }
+        th { background: #f8f9fa; }
+        .figure { font-size: 2rem; font-weight: bold; }
+        .bad { color: #dc3545; }
+        .checksum, .file-path { font-family: monospace; font-size: 0.9rem; word-break: break-all; }
+        .note { color: #6c757d; }
+    </style>
+</head>
+<body>
+    <h1>Fixity Audit Report: {{.Target.Name}}</h1>
+    <div class="period">{{time .PeriodStart}} to {{time .PeriodEnd}}</div>
+
+    <table>
+        <tr><th>Target</th><td>{{.Target.Name}} ({{.Target.Type}} {{.Target.Path}})</td></tr>
+        <tr><th>Checksum algorithm</th><td>{{.Target.ChecksumAlgorithm}}</td></tr>
+        <tr><th>Generated</th><td>{{time .GeneratedAt}}</td></tr>
+        <tr><th>Signing key</th><td class="checksum">{{.KeyID}}</td></tr>
+    </table>
+
+    <h2>Coverage</h2>
+    <p class="figure{{if lt .Coverage.CoveragePercent 100.0}} bad{{end}}">{{percent .Coverage.CoveragePercent}}</p>
+    <table>
+        <tr><th>Files present at the end of the period</th><td>{{.Coverage.TotalFiles}} ({{.Coverage.TotalBytes}} bytes)</td></tr>
+        <tr><th>Verified during the period</th><td>{{.Coverage.VerifiedFiles}} ({{.Coverage.VerifiedBytes}} bytes)</td></tr>
+        <tr><th>Never verified</th><td{{if .Coverage.NeverVerified}} class="bad"{{end}}>{{.Coverage.NeverVerified}}</td></tr>
+    </table>
+
+    <h2>Verification Status at Generation</h2>
+    <table>
+        <tr><th>Files</th><td>{{.Verification.TotalFiles}}</td></tr>
+        <tr><th>Verified in the last 7 days</th><td>{{.Verification.VerifiedLast7Days}}</td></tr>
+        <tr><th>Verified in the last 30 days</th><td>{{.Verification.VerifiedLast30Days}}</td></tr>
+        <tr><th>Verified in the last 90 days</th><td>{{.Verification.VerifiedLast90Days}}</td></tr>
+        <tr><th>Never verified</th><td>{{.Verification.NeverVerified}}</td></tr>
+        <tr><th>Oldest verification</th><td>{{with .Verification.OldestVerification}}{{time .}}{{else}}None{{end}}</td></tr>
+    </table>
+
+    <h2>Corruption Incidents</h2>
+    {{if .Corruption.Incidents}}
+    {{if .Corruption.Truncated}}<p class="note">Showing {{len .Corruption.Incidents}} of {{.Corruption.Count}} incidents.</p>{{end}}
+    <table>
+        <tr><th>Path</th><th>Expected</th><th>Found</th><th>Scan</th><th>Detected</th></tr>
+        {{range .Corruption.Incidents}}
+        <tr>
+            <td class="file-path">{{.Path}}</td>
+            <td class="checksum">{{.Expected}}</td>
+            <td class="checksum bad">{{.Actual}}</td>
+            <td>{{.ScanID}}</td>
+            <td>{{time .DetectedAt}}</td>
+        </tr>
+        {{end}}
+    </table>
+    {{else}}
+    <p>No corruption was found during the period.</p>
+    {{end}}
+
+    <h2>Changes</h2>
+    <table>
+        <tr><th>Change</th><th>Count</th></tr>
+        {{range .Changes}}<tr><td>{{.Type}}</td><td>{{.Count}}</td></tr>
+        {{end}}
+    </table>
+
+    <h2>Scans</h2>
+    <table>
+        <tr><th>Total</th><td>{{.Scans.Total}}</td></tr>
+        <tr><th>Completed</th><td>{{.Scans.Completed}}</td></tr>
+        <tr><th>Partial</th><td>{{.Scans.Partial}}</td></tr>
+        <tr><th>Failed</th><td>{{.Scans.Failed}}</td></tr>
+        <tr><th>Running</th><td>{{.Scans.Running}}</td></tr>
+        <tr><th>Files verified</th><td>{{.Scans.FilesVerified}}</td></tr>
+        <tr><th>Files mismatched</th><td>{{.Scans.FilesMismatched}}</td></tr>
+    </table>
+    {{if .Scans.Scans}}
+    <table>
+        <tr><th>Scan</th><th>Type</th><th>Status</th><th>Started</th><th>Completed</th><th>Scanned</th><th>Verified</th><th>Mismatched</th><th>Errors</th></tr>
+        {{range .Scans.Scans}}
+        <tr>
+            <td>{{.ID}}</td>
+            <td>{{.Type}}</td>
+            <td>{{.Status}}</td>
+            <td>{{time .StartedAt}}</td>
+            <td>{{with .CompletedAt}}{{time .}}{{end}}</td>
+            <td>{{.FilesScanned}}</td>
+            <td>{{.FilesVerified}}</td>
+            <td>{{.FilesMismatched}}</td>
+            <td>{{.Errors}}</td>
+        </tr>
+        {{end}}
+    </table>
+    {{end}}
+
+    <h2>Never Verified Files</h2>
+    {{if .Unverified.Paths}}
+    {{if .Unverified.Truncated}}<p class="note">Showing {{len .Unverified.Paths}} of {{.Unverified.Count}} files.</p>{{end}}
+    <table>
+        <tr><th>Path</th></tr>
+        {{range .Unverified.Paths}}<tr><td class="file-path">{{.}}</td></tr>
+        {{end}}
+    </table>
+    {{else}}
+    <p>Every file present at the end of the period had been verified.</p>
+    {{end}}
+
+    <p class="note">This report is signed with the detached signature distributed alongside it. Check it with <code>fixity report verify</code>.</p>
+</body>
+</html>
+`))
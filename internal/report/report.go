@@ -0,0 +1,307 @@
+// Package report builds signed fixity audit reports: for a target and
+// period, how much of it was verified, which files never have been, what
+// corruption was found and what changed
+package report
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jeffanddom/fixity/internal/database"
+)
+
+// maxListedFiles caps the corruption incidents and never verified files
+// listed by path; the counts always cover every file
+const maxListedFiles = 10000
+
+// Report is the content of an audit report
+type Report struct {
+	Target       Target       `json:"target"`
+	PeriodStart  time.Time    `json:"period_start"`
+	PeriodEnd    time.Time    `json:"period_end"`
+	GeneratedAt  time.Time    `json:"generated_at"`
+	KeyID        string       `json:"key_id"` // Key the report is signed with
+	Coverage     Coverage     `json:"coverage"`
+	Verification Verification `json:"verification"`
+	Scans        Scans        `json:"scans"`
+	Changes      []Change     `json:"changes"`
+	Corruption   Corruption   `json:"corruption"`
+	Unverified   Unverified   `json:"never_verified"`
+}
+
+// Target identifies the target a report covers
+type Target struct {
+	ID                int64  `json:"id"`
+	Name              string `json:"name"`
+	Type              string `json:"type"`
+	Path              string `json:"path"`
+	ChecksumAlgorithm string `json:"checksum_algorithm"`
+}
+
+// Coverage is how much of the target was verified during the period
+type Coverage struct {
+	TotalFiles      int64   `json:"total_files"`
+	TotalBytes      int64   `json:"total_bytes"`
+	VerifiedFiles   int64   `json:"verified_files"`
+	VerifiedBytes   int64   `json:"verified_bytes"`
+	CoveragePercent float64 `json:"coverage_percent"`
+	NeverVerified   int64   `json:"never_verified"`
+}
+
+// Verification is how recently the target's files were verified, as of
+// when the report was generated
+type Verification struct {
+	TotalFiles         int64      `json:"total_files"`
+	VerifiedLast7Days  int64      `json:"verified_last_7_days"`
+	VerifiedLast30Days int64      `json:"verified_last_30_days"`
+	VerifiedLast90Days int64      `json:"verified_last_90_days"`
+	NeverVerified      int64      `json:"never_verified"`
+	OldestVerification *time.Time `json:"oldest_verification,omitempty"`
+}
+
+// Scans summarizes the scans started during the period
+type Scans struct {
+	Total           int    `json:"total"`
+	Completed       int    `json:"completed"`
+	Partial         int    `json:"partial"`
+	Failed          int    `json:"failed"`
+	Running         int    `json:"running"`
+	FilesVerified   int64  `json:"files_verified"`
+	FilesMismatched int64  `json:"files_mismatched"`
+	Scans           []Scan `json:"scans"`
+}
+
+// Scan is one scan started during the period
+type Scan struct {
+	ID              int64      `json:"id"`
+	Type            string     `json:"type"`
+	Status          string     `json:"status"`
+	StartedAt       time.Time  `json:"started_at"`
+	CompletedAt     *time.Time `json:"completed_at,omitempty"`
+	FilesScanned    int64      `json:"files_scanned"`
+	FilesVerified   int64      `json:"files_verified"`
+	FilesMismatched int64      `json:"files_mismatched"`
+	Errors          int        `json:"errors"`
+}
+
+// Change counts the change events of one type recorded during the period
+type Change struct {
+	Type  string `json:"type"`
+	Count int64  `json:"count"`
+}
+
+// Corruption lists verifications during the period that found a file's
+// content no longer matched its known-good checksum
+type Corruption struct {
+	Count     int64      `json:"count"`
+	Incidents []Incident `json:"incidents"`
+}
+
+// Incident is one verification that found corruption
+type Incident struct {
+	Path       string    `json:"path"`
+	Expected   string    `json:"expected"`
+	Actual     string    `json:"actual"`
+	ScanID     int64     `json:"scan_id"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// Unverified lists the files present at the end of the period that no scan
+// had read by then
+type Unverified struct {
+	Count int64    `json:"count"`
+	Paths []string `json:"paths"`
+}
+
+// Truncated reports whether fewer incidents are listed than were found
+func (c Corruption) Truncated() bool {
+	return int64(len(c.Incidents)) < c.Count
+}
+
+// Truncated reports whether fewer paths are listed than were found
+func (u Unverified) Truncated() bool {
+	return int64(len(u.Paths)) < u.Count
+}
+
+// changeOrder is the order change types are reported in
+var changeOrder = []database.ChangeEventType{
+	database.ChangeEventAdded,
+	database.ChangeEventModified,
+	database.ChangeEventDeleted,
+	database.ChangeEventExcluded,
+	database.ChangeEventMoved,
+	database.ChangeEventMetadataChanged,
+	database.ChangeEventVerified,
+	database.ChangeEventMemberAdded,
+	database.ChangeEventMemberModified,
+	database.ChangeEventMemberDeleted,
+}
+
+// Build gathers the report of a target for scans started in [start, end)
+func Build(ctx context.Context, db *database.Database, target *database.StorageTarget, start, end time.Time) (*Report, error) {
+	if !end.After(start) {
+		return nil, fmt.Errorf("report period must end after it starts")
+	}
+
+	report := &Report{
+		Target: Target{
+			ID:                target.ID,
+			Name:              target.Name,
+			Type:              string(target.Type),
+			Path:              target.Path,
+			ChecksumAlgorithm: target.ChecksumAlgorithm,
+		},
+		PeriodStart: start.UTC(),
+		PeriodEnd:   end.UTC(),
+		GeneratedAt: time.Now().UTC(),
+		Changes:     []Change{},
+	}
+
+	coverage, err := db.Files.GetPeriodCoverage(ctx, target.ID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	report.Coverage = Coverage{
+		TotalFiles:      coverage.TotalFiles,
+		TotalBytes:      coverage.TotalBytes,
+		VerifiedFiles:   coverage.VerifiedFiles,
+		VerifiedBytes:   coverage.VerifiedBytes,
+		CoveragePercent: coverage.CoveragePercent(),
+		NeverVerified:   coverage.NeverVerified,
+	}
+
+	stats, err := db.Files.GetVerificationStats(ctx, target.ID)
+	if err != nil {
+		return nil, err
+	}
+	report.Verification = Verification{
+		TotalFiles:         stats.TotalFiles,
+		VerifiedLast7Days:  stats.VerifiedLast7Days,
+		VerifiedLast30Days: stats.VerifiedLast30Days,
+		VerifiedLast90Days: stats.VerifiedLast90Days,
+		NeverVerified:      stats.NeverVerified,
+		OldestVerification: stats.OldestVerification,
+	}
+
+	scans, err := db.Scans.ListInPeriod(ctx, target.ID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	report.Scans = summarizeScans(scans)
+
+	counts, err := db.ChangeEvents.CountByTypeInPeriod(ctx, target.ID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	for _, eventType := range changeOrder {
+		report.Changes = append(report.Changes, Change{Type: string(eventType), Count: counts[eventType]})
+		delete(counts, eventType)
+	}
+	for eventType, count := range counts {
+		report.Changes = append(report.Changes, Change{Type: string(eventType), Count: count})
+	}
+
+	report.Corruption.Count, err = db.ChangeEvents.CountCorruption(ctx, target.ID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	incidents, err := db.ChangeEvents.ListCorruption(ctx, target.ID, start, end, maxListedFiles)
+	if err != nil {
+		return nil, err
+	}
+	report.Corruption.Incidents = make([]Incident, len(incidents))
+	for i, incident := range incidents {
+		report.Corruption.Incidents[i] = Incident{
+			Path:       incident.Path,
+			Expected:   incident.Expected,
+			Actual:     incident.Actual,
+			ScanID:     incident.ScanID,
+			DetectedAt: incident.DetectedAt.UTC(),
+		}
+	}
+
+	report.Unverified.Count = coverage.NeverVerified
+	unverified, err := db.Files.ListNeverVerified(ctx, target.ID, end, maxListedFiles)
+	if err != nil {
+		return nil, err
+	}
+	report.Unverified.Paths = make([]string, len(unverified))
+	for i, file := range unverified {
+		report.Unverified.Paths[i] = file.Path
+	}
+
+	return report, nil
+}
+
+func summarizeScans(scans []*database.Scan) Scans {
+	summary := Scans{Total: len(scans), Scans: make([]Scan, len(scans))}
+	for i, scan := range scans {
+		switch scan.Status {
+		case database.ScanStatusCompleted:
+			summary.Completed++
+		case database.ScanStatusPartial:
+			summary.Partial++
+		case database.ScanStatusFailed:
+			summary.Failed++
+		case database.ScanStatusRunning:
+			summary.Running++
+		}
+		summary.FilesVerified += scan.FilesVerified
+		summary.FilesMismatched += scan.FilesMismatched
+
+		row := Scan{
+			ID:              scan.ID,
+			Type:            string(scan.ScanType),
+			Status:          string(scan.Status),
+			StartedAt:       scan.StartedAt.UTC(),
+			FilesScanned:    scan.FilesScanned,
+			FilesVerified:   scan.FilesVerified,
+			FilesMismatched: scan.FilesMismatched,
+			Errors:          scan.ErrorsCount,
+		}
+		if scan.CompletedAt != nil {
+			completed := scan.CompletedAt.UTC()
+			row.CompletedAt = &completed
+		}
+		summary.Scans[i] = row
+	}
+	return summary
+}
+
+// PreviousPeriod returns the last whole calendar day, week (starting
+// Monday), month, quarter or year before now, in UTC, so a report run on a
+// schedule covers the period just ended
+func PreviousPeriod(name string, now time.Time) (time.Time, time.Time, error) {
+	now = now.UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch strings.ToLower(name) {
+	case "day":
+		return today.AddDate(0, 0, -1), today, nil
+	case "week":
+		end := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+		return end.AddDate(0, 0, -7), end, nil
+	case "month":
+		end := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return end.AddDate(0, -1, 0), end, nil
+	case "quarter":
+		end := time.Date(now.Year(), now.Month()-(now.Month()-1)%3, 1, 0, 0, 0, 0, time.UTC)
+		return end.AddDate(0, -3, 0), end, nil
+	case "year":
+		end := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+		return end.AddDate(-1, 0, 0), end, nil
+	default:
+		return time.Time{}, time.Time{}, fmt.Errorf("unknown period: %s (expected day, week, month, quarter or year)", name)
+	}
+}
+
+// ParseDate reads a date given as YYYY-MM-DD, as midnight UTC
+func ParseDate(value string) (time.Time, error) {
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", value)
+	}
+	return t, nil
+}
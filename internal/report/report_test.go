@@ -0,0 +1,163 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeffanddom/fixity/internal/database"
+)
+
+func TestPreviousPeriod(t *testing.T) {
+	// A Wednesday
+	now := time.Date(2024, time.May, 15, 13, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		period     string
+		start, end string
+	}{
+		{"day", "2024-05-14", "2024-05-15"},
+		{"week", "2024-05-06", "2024-05-13"},
+		{"month", "2024-04-01", "2024-05-01"},
+		{"quarter", "2024-01-01", "2024-04-01"},
+		{"year", "2023-01-01", "2024-01-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.period, func(t *testing.T) {
+			start, end, err := PreviousPeriod(tt.period, now)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if start.Format("2006-01-02") != tt.start || end.Format("2006-01-02") != tt.end {
+				t.Errorf("expected %s to %s, got %s to %s", tt.start, tt.end, start.Format("2006-01-02"), end.Format("2006-01-02"))
+			}
+		})
+	}
+
+	// On a Monday the previous week is the one just ended
+	monday := time.Date(2024, time.May, 13, 0, 0, 0, 0, time.UTC)
+	if start, _, _ := PreviousPeriod("week", monday); start.Format("2006-01-02") != "2024-05-06" {
+		t.Errorf("expected the week starting 2024-05-06, got %s", start.Format("2006-01-02"))
+	}
+
+	if _, _, err := PreviousPeriod("fortnight", now); err == nil {
+		t.Error("expected an error for an unknown period")
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, name := range []string{"html", "csv", "json"} {
+		if _, err := ParseFormat(name); err != nil {
+			t.Errorf("expected %s to be accepted: %v", name, err)
+		}
+	}
+	if _, err := ParseFormat("pdf"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func sampleReport() *Report {
+	completed := time.Date(2024, time.April, 2, 1, 0, 0, 0, time.UTC)
+	return &Report{
+		Target:      Target{ID: 1, Name: "<photos>", Type: "local", Path: "/srv/photos", ChecksumAlgorithm: "sha256"},
+		PeriodStart: time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC),
+		PeriodEnd:   time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC),
+		GeneratedAt: time.Date(2024, time.May, 1, 2, 0, 0, 0, time.UTC),
+		KeyID:       "0123456789abcdef",
+		Coverage:    Coverage{TotalFiles: 4, VerifiedFiles: 3, CoveragePercent: 75, NeverVerified: 1},
+		Scans: Scans{
+			Total:     1,
+			Completed: 1,
+			Scans: []Scan{{
+				ID: 7, Type: "verification", Status: "completed",
+				StartedAt: time.Date(2024, time.April, 2, 0, 0, 0, 0, time.UTC), CompletedAt: &completed,
+				FilesScanned: 3, FilesVerified: 2, FilesMismatched: 1,
+			}},
+		},
+		Changes: []Change{{Type: "added", Count: 4}, {Type: "verified", Count: 3}},
+		Corruption: Corruption{
+			Count:     2,
+			Incidents: []Incident{{Path: "a<b>.jpg", Expected: "aa", Actual: "bb", ScanID: 7, DetectedAt: time.Date(2024, time.April, 2, 0, 0, 0, 0, time.UTC)}},
+		},
+		Unverified: Unverified{Count: 1, Paths: []string{"new.jpg"}},
+	}
+}
+
+func TestRender_HTML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Render(&buf, sampleReport(), FormatHTML); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	body := buf.String()
+	for _, want := range []string{"&lt;photos&gt;", "75.00%", "a&lt;b&gt;.jpg", "Showing 1 of 2 incidents", "new.jpg", "0123456789abcdef"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected the page to contain %q", want)
+		}
+	}
+	if strings.Contains(body, "<photos>") {
+		t.Error("expected the target name to be escaped")
+	}
+}
+
+func TestRender_CSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Render(&buf, sampleReport(), FormatCSV); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read CSV: %v", err)
+	}
+
+	find := func(section, name string) []string {
+		for _, record := range records {
+			if record[0] == section && record[1] == name {
+				return record
+			}
+		}
+		return nil
+	}
+
+	if record := find("coverage", "coverage_percent"); record == nil || record[2] != "75.00" {
+		t.Errorf("expected the coverage percent, got %v", record)
+	}
+	if record := find("incident", "a<b>.jpg"); record == nil || record[2] != "bb" || !strings.Contains(record[3], "expected aa") {
+		t.Errorf("expected the incident, got %v", record)
+	}
+	if record := find("unverified", "new.jpg"); record == nil {
+		t.Error("expected the never verified file to be listed")
+	}
+}
+
+func TestRender_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Render(&buf, sampleReport(), FormatJSON); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var decoded Report
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("failed to decode JSON: %v", err)
+	}
+	if decoded.Coverage.CoveragePercent != 75 || decoded.Corruption.Count != 2 || len(decoded.Scans.Scans) != 1 {
+		t.Errorf("unexpected report: %+v", decoded)
+	}
+}
+
+func TestFilename(t *testing.T) {
+	stored := &database.AuditReport{
+		PeriodStart: time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC),
+		PeriodEnd:   time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC),
+		Format:      "csv",
+	}
+
+	if got := Filename(stored, `Team "A" / photos`); got != "fixity-audit-Team_A_photos-2024-04-01-2024-05-01.csv" {
+		t.Errorf("unexpected filename: %s", got)
+	}
+}
@@ -0,0 +1,455 @@
+package scanner
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jeffanddom/fixity/internal/checksum"
+	"github.com/jeffanddom/fixity/internal/database"
+	"github.com/jeffanddom/fixity/internal/storage"
+)
+
+// archiveFormat is a container format whose members can be hashed
+type archiveFormat int
+
+const (
+	archiveNone archiveFormat = iota
+	archiveZIP
+	archiveTAR
+	archiveTarGzip
+)
+
+// archiveFormatOf recognizes archives by their file name
+func archiveFormatOf(path string) archiveFormat {
+	name := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return archiveZIP
+	case strings.HasSuffix(name, ".tar"):
+		return archiveTAR
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return archiveTarGzip
+	}
+	return archiveNone
+}
+
+// archiveMember is a regular file inside an archive as read by a scan
+type archiveMember struct {
+	Path     string
+	Size     int64
+	ModTime  time.Time
+	Checksum string
+	Damaged  bool  // ZIP member whose content fails its stored CRC-32
+	Err      error // The member could not be read; its content is unknown
+}
+
+// readArchiveMembers hashes the regular files in an archive. A ZIP member
+// that can't be read is returned with Err set; an error is returned when the
+// archive itself can't be read, since whatever follows is then unknown.
+func readArchiveMembers(ctx context.Context, r io.Reader, format archiveFormat, algorithm checksum.Algorithm, readSize int) ([]*archiveMember, error) {
+	r = &archiveReader{ctx: ctx, r: r}
+
+	switch format {
+	case archiveZIP:
+		readerAt, size, cleanup, err := zipReaderAt(r)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+		return readZIPMembers(ctx, readerAt, size, algorithm, readSize)
+	case archiveTAR:
+		return readTARMembers(ctx, r, algorithm, readSize)
+	case archiveTarGzip:
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gzip header: %w", err)
+		}
+		defer gz.Close()
+		return readTARMembers(ctx, gz, algorithm, readSize)
+	}
+	return nil, fmt.Errorf("not an archive")
+}
+
+// zipCopyLimit caps the temporary copy made of a ZIP archive that can't be
+// read at an offset in place
+var zipCopyLimit int64 = 4 << 30
+
+// zipReaderAt returns the archive for random access, since a ZIP's directory
+// is at its end. Readers without random access are copied to a temporary
+// file of at most zipCopyLimit bytes.
+func zipReaderAt(r io.Reader) (io.ReaderAt, int64, func(), error) {
+	if ar, ok := r.(*archiveReader); ok {
+		if readerAt, size, ok := storage.ReaderAt(ar.r); ok {
+			return readerAt, size, func() {}, nil
+		}
+	}
+
+	tmp, err := os.CreateTemp("", "fixity-archive-*")
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	cleanup := func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}
+
+	size, err := io.Copy(tmp, io.LimitReader(r, zipCopyLimit+1))
+	if err != nil {
+		cleanup()
+		return nil, 0, nil, fmt.Errorf("failed to copy archive: %w", err)
+	}
+	if size > zipCopyLimit {
+		cleanup()
+		return nil, 0, nil, fmt.Errorf("archive is larger than the %d byte limit for copying it to read", zipCopyLimit)
+	}
+	return tmp, size, cleanup, nil
+}
+
+func readZIPMembers(ctx context.Context, r io.ReaderAt, size int64, algorithm checksum.Algorithm, readSize int) ([]*archiveMember, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil && !errors.Is(err, zip.ErrInsecurePath) {
+		return nil, fmt.Errorf("failed to read ZIP directory: %w", err)
+	}
+
+	members := make([]*archiveMember, 0, len(zr.File))
+	for _, f := range zr.File {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if !f.Mode().IsRegular() {
+			continue
+		}
+
+		member := &archiveMember{
+			Path:    f.Name,
+			Size:    int64(f.UncompressedSize64),
+			ModTime: f.Modified,
+		}
+		member.Checksum, member.Damaged, member.Err = hashZIPMember(f, algorithm, readSize)
+		members = append(members, member)
+	}
+
+	return members, nil
+}
+
+// hashZIPMember hashes a ZIP member. Content that fails the member's CRC-32
+// is still hashed, so the damage shows as a changed checksum.
+func hashZIPMember(f *zip.File, algorithm checksum.Algorithm, readSize int) (string, bool, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return "", false, err
+	}
+	defer rc.Close()
+
+	reader := &crcReader{r: rc}
+	sum, err := checksum.ComputeWithReadSize(algorithm, reader, readSize)
+	return sum, reader.damaged, err
+}
+
+// crcReader ends a ZIP member at a CRC mismatch instead of failing
+type crcReader struct {
+	r       io.Reader
+	damaged bool
+}
+
+func (c *crcReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	if errors.Is(err, zip.ErrChecksum) {
+		c.damaged = true
+		err = io.EOF
+	}
+	return n, err
+}
+
+func readTARMembers(ctx context.Context, r io.Reader, algorithm checksum.Algorithm, readSize int) ([]*archiveMember, error) {
+	tr := tar.NewReader(r)
+
+	// A TAR may hold several copies of a path; the last one wins on extraction
+	members := []*archiveMember{}
+	index := make(map[string]int)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read TAR header: %w", err)
+		}
+		if !hdr.FileInfo().Mode().IsRegular() {
+			continue
+		}
+
+		sum, err := checksum.ComputeWithReadSize(algorithm, tr, readSize)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("failed to read TAR member %s: %w", hdr.Name, err)
+		}
+
+		member := &archiveMember{
+			Path:     hdr.Name,
+			Size:     hdr.Size,
+			ModTime:  hdr.ModTime,
+			Checksum: sum,
+		}
+		if i, ok := index[member.Path]; ok {
+			members[i] = member
+			continue
+		}
+		index[member.Path] = len(members)
+		members = append(members, member)
+	}
+
+	return members, nil
+}
+
+// archiveReader stops reading an archive once ctx is done
+type archiveReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (a *archiveReader) Read(p []byte) (int, error) {
+	if err := a.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return a.r.Read(p)
+}
+
+// diffArchiveMembers compares the members read from an archive with those
+// recorded for it, returning an event for each member added, deleted or
+// modified and the members to record. Members that could not be read keep
+// their recorded state. An archive with no recorded members is recorded
+// without events.
+func diffArchiveMembers(
+	scanID int64,
+	fileID int64,
+	checksumType string,
+	current []*archiveMember,
+	previous []*database.ArchiveMember,
+	now time.Time,
+) ([]*database.ChangeEvent, []*database.ArchiveMember) {
+	previousByPath := make(map[string]*database.ArchiveMember, len(previous))
+	for _, member := range previous {
+		previousByPath[member.Path] = member
+	}
+
+	events := []*database.ChangeEvent{}
+	records := make([]*database.ArchiveMember, 0, len(current))
+	seen := make(map[string]bool, len(current))
+
+	for _, member := range current {
+		seen[member.Path] = true
+		previousMember := previousByPath[member.Path]
+
+		if member.Err != nil {
+			if previousMember != nil {
+				records = append(records, previousMember)
+			}
+			continue
+		}
+
+		record := &database.ArchiveMember{
+			FileID:            fileID,
+			Path:              member.Path,
+			Size:              member.Size,
+			Checksum:          member.Checksum,
+			ChecksumType:      checksumType,
+			FirstSeen:         now,
+			LastChecksummedAt: now,
+		}
+		if !member.ModTime.IsZero() {
+			modTime := member.ModTime
+			record.ModTime = &modTime
+		}
+		records = append(records, record)
+
+		path := member.Path
+		switch {
+		case previousMember != nil:
+			record.FirstSeen = previousMember.FirstSeen
+			if previousMember.ChecksumType != checksumType || previousMember.Checksum == member.Checksum {
+				continue
+			}
+			oldChecksum, oldSize := previousMember.Checksum, previousMember.Size
+			events = append(events, &database.ChangeEvent{
+				ScanID:      scanID,
+				FileID:      fileID,
+				EventType:   database.ChangeEventMemberModified,
+				DetectedAt:  now,
+				OldChecksum: &oldChecksum,
+				NewChecksum: &record.Checksum,
+				OldSize:     &oldSize,
+				NewSize:     &record.Size,
+				MemberPath:  &path,
+			})
+		case len(previous) > 0:
+			events = append(events, &database.ChangeEvent{
+				ScanID:      scanID,
+				FileID:      fileID,
+				EventType:   database.ChangeEventMemberAdded,
+				DetectedAt:  now,
+				NewChecksum: &record.Checksum,
+				NewSize:     &record.Size,
+				MemberPath:  &path,
+			})
+		}
+	}
+
+	for _, member := range previous {
+		if seen[member.Path] {
+			continue
+		}
+		oldChecksum, oldSize, path := member.Checksum, member.Size, member.Path
+		events = append(events, &database.ChangeEvent{
+			ScanID:      scanID,
+			FileID:      fileID,
+			EventType:   database.ChangeEventMemberDeleted,
+			DetectedAt:  now,
+			OldChecksum: &oldChecksum,
+			OldSize:     &oldSize,
+			MemberPath:  &path,
+		})
+	}
+
+	return events, records
+}
+
+// hashArchives hashes the members of the archives among files that were just
+// hashed and persisted
+func (e *Engine) hashArchives(
+	ctx context.Context,
+	scanID int64,
+	files []*FileRecord,
+	targetID int64,
+	backend storage.StorageBackend,
+	scanResult *ScanResult,
+) error {
+	for _, file := range files {
+		if file.Checksum == "" || file.FileType == database.FileTypeSymlink || archiveFormatOf(file.Path) == archiveNone {
+			continue
+		}
+
+		dbFile, err := e.db.Files.GetByPath(ctx, targetID, file.Path)
+		if err != nil || dbFile == nil {
+			continue
+		}
+
+		if err := e.updateArchiveMembers(ctx, scanID, dbFile.ID, file, backend, scanResult, true); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// updateArchiveMembers hashes the members of an archive and records how they
+// changed since the archive was last read. With save false, as for archives
+// that failed verification, events are recorded but the recorded members are
+// kept so the changes are reported again.
+func (e *Engine) updateArchiveMembers(
+	ctx context.Context,
+	scanID int64,
+	fileID int64,
+	file *FileRecord,
+	backend storage.StorageBackend,
+	scanResult *ScanResult,
+	save bool,
+) error {
+	format := archiveFormatOf(file.Path)
+	if format == archiveNone {
+		return nil
+	}
+
+	if !file.archiveRead {
+		e.readArchive(ctx, file, checksum.Algorithm(file.ChecksumType), backend)
+	}
+	members, err := file.archiveMembers, file.archiveErr
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return e.recordArchiveError(ctx, scanID, file.Path, "", err, scanResult)
+	}
+
+	for _, member := range members {
+		if member.Err != nil {
+			if err := e.recordArchiveError(ctx, scanID, file.Path, member.Path, member.Err, scanResult); err != nil {
+				return err
+			}
+		}
+		if member.Damaged {
+			scanResult.addError(fmt.Sprintf("archive member fails its CRC check: %s: %s", file.Path, member.Path))
+		}
+	}
+
+	previous, err := e.db.ArchiveMembers.ListByFile(ctx, fileID)
+	if err != nil {
+		return err
+	}
+
+	events, records := diffArchiveMembers(scanID, fileID, file.ChecksumType, members, previous, time.Now())
+	if len(events) > 0 {
+		if err := e.db.ChangeEvents.CreateBatch(ctx, events); err != nil {
+			return fmt.Errorf("failed to record member events of %s: %w", file.Path, err)
+		}
+	}
+
+	if save || len(previous) == 0 {
+		if err := e.db.ArchiveMembers.Replace(ctx, fileID, records); err != nil {
+			return fmt.Errorf("failed to record members of %s: %w", file.Path, err)
+		}
+	}
+
+	return nil
+}
+
+// readArchive reads the members of an archive into file, hashing them with
+// the algorithm the archive itself was hashed with. Scans run it on the
+// checksum pool's worker right after the archive hashes.
+func (e *Engine) readArchive(ctx context.Context, file *FileRecord, algorithm checksum.Algorithm, backend storage.StorageBackend) {
+	memberCtx, cancel := context.WithTimeout(ctx, e.config.FileTimeout)
+	defer cancel()
+
+	var members []*archiveMember
+	rc, err := backend.Open(memberCtx, file.Path)
+	if err == nil {
+		members, err = readArchiveMembers(memberCtx, rc, archiveFormatOf(file.Path), algorithm, e.config.ReadSize)
+		rc.Close()
+	}
+	file.archiveRead, file.archiveMembers, file.archiveErr = true, members, err
+}
+
+// recordArchiveError records an archive, or one of its members, that could
+// not be read. The archive's own checksum is unaffected.
+func (e *Engine) recordArchiveError(ctx context.Context, scanID int64, path, member string, err error, scanResult *ScanResult) error {
+	message := fmt.Sprintf("failed to read archive members: %v", err)
+	if member != "" {
+		message = fmt.Sprintf("failed to read archive member %s: %v", member, err)
+	}
+
+	scanErr := &database.ScanError{
+		ScanID:     scanID,
+		Path:       path,
+		Phase:      database.ScanErrorPhaseHash,
+		ErrorClass: string(checksum.ClassifyError(err)),
+		Message:    message,
+		Retryable:  checksum.IsRetryable(err),
+		Attempts:   1,
+	}
+	scanResult.addError(fmt.Sprintf("archive error: %s: %s", path, message))
+
+	if err := e.db.ScanErrors.CreateBatch(ctx, []*database.ScanError{scanErr}); err != nil {
+		return fmt.Errorf("failed to record scan errors: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package scanner
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeffanddom/fixity/internal/checksum"
+	"github.com/jeffanddom/fixity/internal/database"
+)
+
+func md5Hex(data string) string {
+	sum := md5.Sum([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+// buildZIP returns a ZIP holding the named contents, stored uncompressed so
+// tests can damage a member in place
+func buildZIP(t *testing.T, files map[string]string, order []string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	zw.Create("docs/")
+	for _, name := range order {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store, Modified: time.Unix(1700000000, 0)})
+		if err != nil {
+			t.Fatalf("failed to add %s: %v", name, err)
+		}
+		w.Write([]byte(files[name]))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("failed to write ZIP: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestArchiveFormatOf(t *testing.T) {
+	tests := map[string]archiveFormat{
+		"deposits/bundle.zip": archiveZIP,
+		"BUNDLE.ZIP":          archiveZIP,
+		"backup.tar":          archiveTAR,
+		"backup.tar.gz":       archiveTarGzip,
+		"backup.tgz":          archiveTarGzip,
+		"notes.txt":           archiveNone,
+		"zip":                 archiveNone,
+	}
+
+	for path, want := range tests {
+		if got := archiveFormatOf(path); got != want {
+			t.Errorf("archiveFormatOf(%q) = %d, want %d", path, got, want)
+		}
+	}
+}
+
+func TestReadArchiveMembers_ZIP(t *testing.T) {
+	data := buildZIP(t, map[string]string{
+		"docs/a.txt": "alpha",
+		"docs/b.txt": "bravo",
+	}, []string{"docs/a.txt", "docs/b.txt"})
+
+	members, err := readArchiveMembers(context.Background(), bytes.NewReader(data), archiveZIP, checksum.AlgorithmMD5, 0)
+	if err != nil {
+		t.Fatalf("failed to read members: %v", err)
+	}
+	if len(members) != 2 {
+		t.Fatalf("expected 2 regular members, got %d", len(members))
+	}
+	if members[0].Path != "docs/a.txt" || members[0].Size != 5 || members[0].Checksum != md5Hex("alpha") {
+		t.Errorf("unexpected member: %+v", members[0])
+	}
+	if members[1].Damaged || members[1].Err != nil {
+		t.Errorf("expected an intact member, got %+v", members[1])
+	}
+
+	// Files are read in place rather than copied
+	path := filepath.Join(t.TempDir(), "bundle.zip")
+	os.WriteFile(path, data, 0644)
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+	defer f.Close()
+	members, err = readArchiveMembers(context.Background(), f, archiveZIP, checksum.AlgorithmMD5, 0)
+	if err != nil || len(members) != 2 {
+		t.Errorf("expected 2 members from a file, got %d (%v)", len(members), err)
+	}
+
+	// Copies of archives without random access are limited
+	defer func(limit int64) { zipCopyLimit = limit }(zipCopyLimit)
+	zipCopyLimit = int64(len(data)) - 1
+	if _, err := readArchiveMembers(context.Background(), bytes.NewReader(data), archiveZIP, checksum.AlgorithmMD5, 0); err == nil || !strings.Contains(err.Error(), "limit") {
+		t.Errorf("expected an archive over the copy limit to fail, got %v", err)
+	}
+	f.Seek(0, io.SeekStart)
+	if members, err := readArchiveMembers(context.Background(), f, archiveZIP, checksum.AlgorithmMD5, 0); err != nil || len(members) != 2 {
+		t.Errorf("expected a file to be read in place regardless of the limit, got %d (%v)", len(members), err)
+	}
+}
+
+func TestReadArchiveMembers_DamagedZIP(t *testing.T) {
+	data := buildZIP(t, map[string]string{
+		"docs/a.txt": "alpha",
+		"docs/b.txt": "bravo",
+	}, []string{"docs/a.txt", "docs/b.txt"})
+
+	// Flip a byte of b.txt's stored content
+	i := bytes.Index(data, []byte("bravo"))
+	data[i] = 'B'
+
+	members, err := readArchiveMembers(context.Background(), bytes.NewReader(data), archiveZIP, checksum.AlgorithmMD5, 0)
+	if err != nil {
+		t.Fatalf("failed to read members: %v", err)
+	}
+	if members[0].Damaged {
+		t.Error("expected a.txt to be intact")
+	}
+	if !members[1].Damaged || members[1].Err != nil || members[1].Checksum != md5Hex("Bravo") {
+		t.Errorf("expected b.txt to be hashed and flagged damaged, got %+v", members[1])
+	}
+}
+
+func TestReadArchiveMembers_TarGzip(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	add := func(name, content string) {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), ModTime: time.Unix(1700000000, 0), Typeflag: tar.TypeReg})
+		tw.Write([]byte(content))
+	}
+	tw.WriteHeader(&tar.Header{Name: "data/", Mode: 0755, Typeflag: tar.TypeDir})
+	add("data/a.csv", "1,2,3")
+	tw.WriteHeader(&tar.Header{Name: "data/link", Linkname: "a.csv", Typeflag: tar.TypeSymlink})
+	add("data/b.csv", "old")
+	add("data/b.csv", "new") // appended copy replaces the first
+	tw.Close()
+	gz.Close()
+
+	members, err := readArchiveMembers(context.Background(), &buf, archiveTarGzip, checksum.AlgorithmMD5, 0)
+	if err != nil {
+		t.Fatalf("failed to read members: %v", err)
+	}
+	if len(members) != 2 {
+		t.Fatalf("expected 2 regular members, got %d", len(members))
+	}
+	if members[1].Path != "data/b.csv" || members[1].Checksum != md5Hex("new") {
+		t.Errorf("expected the last copy of b.csv, got %+v", members[1])
+	}
+
+	truncated := bytes.NewReader(buf.Bytes()[:buf.Len()/2])
+	if _, err := readArchiveMembers(context.Background(), truncated, archiveTarGzip, checksum.AlgorithmMD5, 0); err == nil {
+		t.Error("expected a truncated archive to fail")
+	}
+}
+
+func TestDiffArchiveMembers(t *testing.T) {
+	firstSeen := time.Now().Add(-24 * time.Hour)
+	now := time.Now()
+	previous := []*database.ArchiveMember{
+		{Path: "a.txt", Size: 5, Checksum: "aa", ChecksumType: "md5", FirstSeen: firstSeen},
+		{Path: "b.txt", Size: 5, Checksum: "bb", ChecksumType: "md5", FirstSeen: firstSeen},
+		{Path: "c.txt", Size: 5, Checksum: "cc", ChecksumType: "md5", FirstSeen: firstSeen},
+		{Path: "d.txt", Size: 5, Checksum: "dd", ChecksumType: "md5", FirstSeen: firstSeen},
+	}
+	current := []*archiveMember{
+		{Path: "a.txt", Size: 5, Checksum: "aa"},
+		{Path: "b.txt", Size: 6, Checksum: "b2"},
+		{Path: "d.txt", Err: os.ErrInvalid}, // unreadable, so unknown rather than deleted
+		{Path: "e.txt", Size: 1, Checksum: "ee"},
+	}
+
+	events, records := diffArchiveMembers(7, 42, "md5", current, previous, now)
+
+	got := map[string]database.ChangeEventType{}
+	for _, event := range events {
+		if event.FileID != 42 || event.ScanID != 7 || event.MemberPath == nil {
+			t.Fatalf("unexpected event: %+v", event)
+		}
+		got[*event.MemberPath] = event.EventType
+	}
+	want := map[string]database.ChangeEventType{
+		"b.txt": database.ChangeEventMemberModified,
+		"c.txt": database.ChangeEventMemberDeleted,
+		"e.txt": database.ChangeEventMemberAdded,
+	}
+	if len(got) != len(want) {
+		t.Errorf("expected events %v, got %v", want, got)
+	}
+	for path, eventType := range want {
+		if got[path] != eventType {
+			t.Errorf("expected %s for %s, got %q", eventType, path, got[path])
+		}
+	}
+
+	paths := []string{}
+	for _, record := range records {
+		paths = append(paths, record.Path)
+	}
+	if len(paths) != 4 || paths[0] != "a.txt" || paths[2] != "d.txt" || paths[3] != "e.txt" {
+		t.Errorf("expected a, b, d and e to be recorded, got %v", paths)
+	}
+	if !records[0].FirstSeen.Equal(firstSeen) || !records[3].FirstSeen.Equal(now) {
+		t.Error("expected first seen to carry over for known members only")
+	}
+	if records[2].Checksum != "dd" {
+		t.Errorf("expected the unreadable member to keep its recorded checksum, got %s", records[2].Checksum)
+	}
+
+	// An archive read for the first time, or hashed with another algorithm,
+	// is recorded without events
+	if events, records := diffArchiveMembers(7, 42, "md5", current, nil, now); len(events) != 0 || len(records) != 3 {
+		t.Errorf("expected 3 members recorded silently, got %d events and %d records", len(events), len(records))
+	}
+	if events, _ := diffArchiveMembers(7, 42, "sha256", current[:2], previous[:2], now); len(events) != 0 {
+		t.Errorf("expected checksums of another algorithm not to be compared, got %d events", len(events))
+	}
+}
@@ -0,0 +1,208 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jeffanddom/fixity/internal/bagit"
+	"github.com/jeffanddom/fixity/internal/database"
+	"github.com/jeffanddom/fixity/internal/storage"
+)
+
+// EventBagInvalid is the webhook event queued when a bag fails validation
+const EventBagInvalid = "bag.invalid"
+
+// maxBagProblems caps the problems stored per bag validation; the count of
+// all problems is still recorded
+const maxBagProblems = 1000
+
+// bagInvalidPayload is the body of a bag.invalid webhook
+type bagInvalidPayload struct {
+	Event           string    `json:"event"`
+	StorageTargetID int64     `json:"storage_target_id"`
+	ScanID          int64     `json:"scan_id"`
+	ValidationID    int64     `json:"validation_id"`
+	Path            string    `json:"path"`
+	ProblemCount    int       `json:"problem_count"`
+	ValidatedAt     time.Time `json:"validated_at"`
+}
+
+// findBags returns the directories holding a bagit.txt, with the files under
+// each as paths relative to it. Files of a bag nested in another belong to
+// both.
+func findBags(current map[string]*FileRecord) map[string][]string {
+	bags := make(map[string][]string)
+	for filePath, file := range current {
+		if file.FileType == database.FileTypeRegular && path.Base(filePath) == bagit.Declaration {
+			bags[bagDir(filePath)] = []string{}
+		}
+	}
+	if len(bags) == 0 {
+		return bags
+	}
+
+	for filePath, file := range current {
+		if file.FileType != database.FileTypeRegular {
+			continue
+		}
+		for dir := bagDir(filePath); ; dir = bagDir(dir) {
+			if files, ok := bags[dir]; ok {
+				rel := filePath
+				if dir != "" {
+					rel = strings.TrimPrefix(filePath, dir+"/")
+				}
+				bags[dir] = append(files, rel)
+			}
+			if dir == "" {
+				break
+			}
+		}
+	}
+
+	return bags
+}
+
+// bagDir returns the directory of a path, with the target's root as the
+// empty string
+func bagDir(filePath string) string {
+	dir := path.Dir(filePath)
+	if dir == "." || dir == "/" {
+		return ""
+	}
+	return dir
+}
+
+// recordedChecksum returns the checksum known for a file after change
+// detection: the one computed this scan, or the recorded one if the file is
+// unchanged
+func recordedChecksum(file *FileRecord, previous *database.File, algorithm string) (string, bool) {
+	if file.Checksum != "" {
+		return file.Checksum, file.ChecksumType == algorithm
+	}
+	if file.IsNew || file.IsModified || previous == nil ||
+		previous.CurrentChecksum == nil || previous.ChecksumType == nil || *previous.ChecksumType != algorithm {
+		return "", false
+	}
+	return *previous.CurrentChecksum, true
+}
+
+// validateBags validates each BagIt bag found by the scan and records the
+// results. Payload checksums computed or recorded with a manifest's algorithm
+// are reused; other files are read again.
+func (e *Engine) validateBags(
+	ctx context.Context,
+	scanID int64,
+	targetID int64,
+	current map[string]*FileRecord,
+	previous map[string]*database.File,
+	backend storage.StorageBackend,
+	result *ScanResult,
+) error {
+	bags := findBags(current)
+	dirs := make([]string, 0, len(bags))
+	for dir := range bags {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	for _, dir := range dirs {
+		bag := bagit.Bag{
+			Files: bags[dir],
+			Open: func(ctx context.Context, rel string) (io.ReadCloser, error) {
+				return backend.Open(ctx, path.Join(dir, rel))
+			},
+			Recorded: func(rel, algorithm string) (string, bool) {
+				filePath := path.Join(dir, rel)
+				file, ok := current[filePath]
+				if !ok {
+					return "", false
+				}
+				return recordedChecksum(file, previous[filePath], algorithm)
+			},
+		}
+
+		validated, err := bagit.Validate(ctx, bag)
+		if err != nil {
+			return err
+		}
+
+		if err := e.recordBagValidation(ctx, scanID, targetID, dir, validated, result); err != nil {
+			result.addError(err.Error())
+		}
+	}
+
+	return nil
+}
+
+// recordBagValidation stores a bag's validation, counting it in the scan's
+// result and alerting webhooks if the bag is invalid
+func (e *Engine) recordBagValidation(ctx context.Context, scanID int64, targetID int64, dir string, validated *bagit.Result, result *ScanResult) error {
+	validation := &database.BagValidation{
+		ScanID:          scanID,
+		StorageTargetID: targetID,
+		Path:            dir,
+		Valid:           validated.Valid(),
+		PayloadFiles:    validated.PayloadFiles,
+		ProblemCount:    len(validated.Problems),
+		ValidatedAt:     time.Now(),
+	}
+	if validated.Version != "" {
+		validation.BagItVersion = &validated.Version
+	}
+
+	problems := make([]*database.BagProblem, 0, min(len(validated.Problems), maxBagProblems))
+	for _, p := range validated.Problems[:min(len(validated.Problems), maxBagProblems)] {
+		problem := &database.BagProblem{
+			Path:    p.Path,
+			Kind:    string(p.Kind),
+			Message: p.Message,
+		}
+		if p.Kind == bagit.ProblemMismatch {
+			algorithm, expected, actual := p.Algorithm, p.Expected, p.Actual
+			problem.Algorithm, problem.Expected, problem.Actual = &algorithm, &expected, &actual
+		}
+		problems = append(problems, problem)
+	}
+
+	result.BagsValidated++
+	if !validation.Valid {
+		result.BagsInvalid++
+		bagPath := dir
+		if bagPath == "" {
+			bagPath = "/"
+		}
+		result.addError(fmt.Sprintf("invalid bag: %s: %d problems", bagPath, validation.ProblemCount))
+	}
+
+	if err := e.db.BagValidations.Create(ctx, validation, problems); err != nil {
+		return err
+	}
+
+	if validation.Valid {
+		return nil
+	}
+
+	payload, err := json.Marshal(bagInvalidPayload{
+		Event:           EventBagInvalid,
+		StorageTargetID: targetID,
+		ScanID:          scanID,
+		ValidationID:    validation.ID,
+		Path:            dir,
+		ProblemCount:    validation.ProblemCount,
+		ValidatedAt:     validation.ValidatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode bag alert: %w", err)
+	}
+
+	if _, err := e.db.WebhookDeliveries.Enqueue(ctx, EventBagInvalid, payload); err != nil {
+		return err
+	}
+	return nil
+}
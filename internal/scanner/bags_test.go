@@ -0,0 +1,86 @@
+package scanner
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/jeffanddom/fixity/internal/database"
+)
+
+func TestFindBags(t *testing.T) {
+	current := map[string]*FileRecord{}
+	for _, path := range []string{
+		"notes.txt",
+		"outer/bagit.txt",
+		"outer/manifest-md5.txt",
+		"outer/data/a.txt",
+		"outer/data/inner/bagit.txt",
+		"outer/data/inner/data/b.txt",
+		"other/data/c.txt",
+	} {
+		current[path] = &FileRecord{Path: path, FileType: database.FileTypeRegular}
+	}
+	current["outer/data/link"] = &FileRecord{Path: "outer/data/link", FileType: database.FileTypeSymlink}
+
+	bags := findBags(current)
+	if len(bags) != 2 {
+		t.Fatalf("expected 2 bags, got %v", bags)
+	}
+
+	outer := bags["outer"]
+	sort.Strings(outer)
+	want := "bagit.txt,data/a.txt,data/inner/bagit.txt,data/inner/data/b.txt,manifest-md5.txt"
+	if got := strings.Join(outer, ","); got != want {
+		t.Errorf("expected outer bag files %s, got %s", want, got)
+	}
+
+	inner := bags["outer/data/inner"]
+	sort.Strings(inner)
+	if got := strings.Join(inner, ","); got != "bagit.txt,data/b.txt" {
+		t.Errorf("unexpected inner bag files: %s", got)
+	}
+}
+
+func TestFindBags_Root(t *testing.T) {
+	current := map[string]*FileRecord{
+		"bagit.txt":  {Path: "bagit.txt", FileType: database.FileTypeRegular},
+		"data/a.txt": {Path: "data/a.txt", FileType: database.FileTypeRegular},
+	}
+
+	bags := findBags(current)
+	if files, ok := bags[""]; !ok || len(files) != 2 {
+		t.Errorf("expected a bag at the root holding both files, got %v", bags)
+	}
+}
+
+func TestRecordedChecksum(t *testing.T) {
+	md5, sha256 := "md5", "sha256"
+	recorded := "0cc175b9c0f1b6a831c399e269772661"
+	previous := &database.File{CurrentChecksum: &recorded, ChecksumType: &md5}
+
+	tests := []struct {
+		name      string
+		file      *FileRecord
+		previous  *database.File
+		algorithm string
+		want      string
+		ok        bool
+	}{
+		{"hashed this scan", &FileRecord{Checksum: "aa", ChecksumType: md5}, previous, md5, "aa", true},
+		{"hashed with another algorithm", &FileRecord{Checksum: "aa", ChecksumType: sha256}, previous, md5, "", false},
+		{"unchanged", &FileRecord{}, previous, md5, recorded, true},
+		{"unchanged, other algorithm", &FileRecord{}, previous, sha256, "", false},
+		{"modified but not hashed", &FileRecord{IsModified: true}, previous, md5, "", false},
+		{"new but not hashed", &FileRecord{IsNew: true}, nil, md5, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := recordedChecksum(tt.file, tt.previous, tt.algorithm)
+			if ok != tt.ok || (ok && got != tt.want) {
+				t.Errorf("expected %q, %v; got %q, %v", tt.want, tt.ok, got, ok)
+			}
+		})
+	}
+}
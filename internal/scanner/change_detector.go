@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strings"
 	"time"
 
 	"github.com/jeffanddom/fixity/internal/checksum"
@@ -17,6 +18,12 @@ type ChangeSet struct {
 	Deleted   []*database.File
 	Modified  []*FileRecord
 	Unchanged []*FileRecord
+	Moved     []*FileRecord    // Added files that took over a deleted file's record
+	Excluded  []*database.File // Files the target's path rules now leave out
+
+	// Unchanged files whose permissions, ownership or extended attributes
+	// changed
+	MetadataChanged []*FileRecord
 }
 
 // detectChanges compares current files with previous scan to detect changes
@@ -24,16 +31,21 @@ func (e *Engine) detectChanges(
 	ctx context.Context,
 	current map[string]*FileRecord,
 	previous map[string]*database.File,
+	unreadable []string,
 	scanID int64,
 	checksumPool *checksum.WorkerPool,
 	backend storage.StorageBackend,
 	target *database.StorageTarget,
+	scanResult *ScanResult,
 ) (*ChangeSet, error) {
 	changes := &ChangeSet{
-		Added:     []*FileRecord{},
-		Deleted:   []*database.File{},
-		Modified:  []*FileRecord{},
-		Unchanged: []*FileRecord{},
+		Added:           []*FileRecord{},
+		Deleted:         []*database.File{},
+		Modified:        []*FileRecord{},
+		Unchanged:       []*FileRecord{},
+		Moved:           []*FileRecord{},
+		Excluded:        []*database.File{},
+		MetadataChanged: []*FileRecord{},
 	}
 
 	// Find added and modified files
@@ -52,20 +64,46 @@ func (e *Engine) detectChanges(
 		} else {
 			// Unchanged file
 			changes.Unchanged = append(changes.Unchanged, currentFile)
+			if currentFile.Metadata.Differs(previousFile.FileMetadata) {
+				changes.MetadataChanged = append(changes.MetadataChanged, currentFile)
+			}
 		}
 	}
 
-	// Find deleted files
+	// Find deleted files. Paths left out by the target's path rules are not
+	// deleted, only no longer scanned, and files under a path the walk could
+	// not read are unknown rather than deleted.
+	excluder, _ := backend.(storage.PathExcluder)
 	for path, previousFile := range previous {
 		if _, exists := current[path]; !exists {
+			if excluder != nil && excluder.Excluded(path) {
+				changes.Excluded = append(changes.Excluded, previousFile)
+				continue
+			}
+			if underAny(path, unreadable) {
+				continue
+			}
 			changes.Deleted = append(changes.Deleted, previousFile)
 		}
 	}
 
+	// Deleted files that may have moved are held back until the added files
+	// are hashed
+	held := holdMoveCandidates(changes)
+
 	// Record change events
 	if err := e.recordChanges(ctx, scanID, changes); err != nil {
 		return nil, fmt.Errorf("failed to record changes: %w", err)
 	}
+	if err := e.recordMetadata(ctx, scanID, changes.Unchanged, previous); err != nil {
+		return nil, fmt.Errorf("failed to record metadata changes: %w", err)
+	}
+	if err := e.recordServerChecksums(ctx, changes.Unchanged, previous); err != nil {
+		return nil, fmt.Errorf("failed to record server checksums: %w", err)
+	}
+	if err := e.recordModTimes(ctx, changes.Unchanged, previous); err != nil {
+		return nil, fmt.Errorf("failed to record modification times: %w", err)
+	}
 
 	// Select random sample for verification
 	sampled, err := e.selectRandomSample(ctx, changes.Unchanged, previous, target)
@@ -73,8 +111,18 @@ func (e *Engine) detectChanges(
 		return nil, fmt.Errorf("failed to select random sample: %w", err)
 	}
 
+	// Sampled files are hashed with the algorithm of their stored checksum,
+	// so a different checksum means the content changed
+	for _, file := range sampled {
+		previousFile := previous[file.Path]
+		if previousFile.CurrentChecksum != nil && previousFile.ChecksumType != nil {
+			file.PreviousChecksum = *previousFile.CurrentChecksum
+			file.ChecksumType = *previousFile.ChecksumType
+		}
+	}
+
 	// Compute checksums for new, modified, and sampled files
-	if err := e.computeChecksums(ctx, scanID, changes, sampled, checksumPool, backend, target); err != nil {
+	if err := e.computeChecksums(ctx, scanID, changes, held, sampled, checksumPool, backend, target, scanResult); err != nil {
 		return nil, fmt.Errorf("failed to compute checksums: %w", err)
 	}
 
@@ -88,37 +136,44 @@ func (e *Engine) isModified(current *FileRecord, previous *database.File) bool {
 		return true
 	}
 
-	// Check modification time (truncate to second for filesystem compatibility)
-	currentModTime := current.ModTime.Truncate(1)
-	previousModTime := previous.LastSeen.Truncate(1)
+	// A recorded link that now points elsewhere, or a path that changed
+	// between a file and a link, is modified
+	if current.FileType != previous.FileType {
+		return true
+	}
+	if previous.LinkTarget != nil && current.LinkTarget != *previous.LinkTarget {
+		return true
+	}
 
-	if !currentModTime.Equal(previousModTime) {
+	// A checksum kept by the server changes with the content even when size
+	// and mtime are preserved
+	if serverChecksumChanged(current.ServerChecksum, previous.ServerChecksum) {
 		return true
 	}
 
-	return false
+	// Check modification time against the one recorded, at the database's
+	// microsecond precision. Files recorded before modification times were
+	// kept are compared on the other fields until it is filled in.
+	if previous.ModTime == nil || current.ModTime.IsZero() {
+		return false
+	}
+	return !current.ModTime.Truncate(time.Microsecond).Equal(previous.ModTime.Truncate(time.Microsecond))
 }
 
-// recordChanges creates change event records in the database
+// recordChanges records deletions and exclusions, which need no hashing.
+// Additions and modifications are recorded by recordContentEvents once the
+// files are hashed and their records exist.
 func (e *Engine) recordChanges(ctx context.Context, scanID int64, changes *ChangeSet) error {
-	events := []*database.ChangeEvent{}
-
-	// Record additions
-	for _, file := range changes.Added {
-		// Will be updated with checksum after computation
-		events = append(events, &database.ChangeEvent{
-			ScanID:      scanID,
-			FileID:      0, // Will be set after file record is created
-			EventType:   database.ChangeEventAdded,
-			DetectedAt:  file.ModTime,
-			NewChecksum: nil, // Will be updated after checksum computation
-			NewSize:     &file.Size,
-		})
+	if err := e.recordDeletions(ctx, scanID, changes.Deleted); err != nil {
+		return err
 	}
+	return e.recordExclusions(ctx, scanID, changes.Excluded)
+}
 
-	// Record deletions (process immediately since we have file IDs)
+// recordDeletions records deletion events for files and marks them deleted
+func (e *Engine) recordDeletions(ctx context.Context, scanID int64, deleted []*database.File) error {
 	deleteEvents := []*database.ChangeEvent{}
-	for _, file := range changes.Deleted {
+	for _, file := range deleted {
 		oldSize := file.Size
 		deleteEvents = append(deleteEvents, &database.ChangeEvent{
 			ScanID:      scanID,
@@ -136,25 +191,153 @@ func (e *Engine) recordChanges(ctx context.Context, scanID int64, changes *Chang
 		}
 	}
 
-	// Record modifications
-	for _, file := range changes.Modified {
+	// Soft delete so the file is not reported deleted again by later scans;
+	// if it reappears it is recorded as added
+	now := time.Now()
+	for _, file := range deleted {
+		file.DeletedAt = &now
+		if err := e.db.Files.Update(ctx, file); err != nil {
+			return fmt.Errorf("failed to mark file %s deleted: %w", file.Path, err)
+		}
+	}
+
+	return nil
+}
+
+// recordExclusions records an exclusion event for each file the target's path
+// rules now leave out and soft deletes it, flagged as excluded so it is not
+// counted as deleted or taken for a moved file. If the rules let it back in,
+// it is recorded as added.
+func (e *Engine) recordExclusions(ctx context.Context, scanID int64, excluded []*database.File) error {
+	if len(excluded) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	events := make([]*database.ChangeEvent, 0, len(excluded))
+	for _, file := range excluded {
+		oldSize := file.Size
 		events = append(events, &database.ChangeEvent{
 			ScanID:      scanID,
-			FileID:      0, // Will be set after file record is updated
-			EventType:   database.ChangeEventModified,
-			DetectedAt:  file.ModTime,
-			OldChecksum: &file.PreviousChecksum,
-			NewChecksum: nil, // Will be updated after checksum computation
-			NewSize:     &file.Size,
+			FileID:      file.ID,
+			EventType:   database.ChangeEventExcluded,
+			DetectedAt:  now,
+			OldChecksum: file.CurrentChecksum,
+			OldSize:     &oldSize,
 		})
 	}
+	if err := e.db.ChangeEvents.CreateBatch(ctx, events); err != nil {
+		return fmt.Errorf("failed to record exclusion events: %w", err)
+	}
 
-	// Note: Addition and modification events are stored separately after
-	// checksums are computed and file records are created/updated
+	for _, file := range excluded {
+		file.DeletedAt = &now
+		file.Excluded = true
+		if err := e.db.Files.Update(ctx, file); err != nil {
+			return fmt.Errorf("failed to mark file %s excluded: %w", file.Path, err)
+		}
+	}
 
 	return nil
 }
 
+// recordMetadata stores the permissions, ownership and extended attributes
+// of unchanged files, recording an event for each file whose metadata
+// changed. Files recorded before a field was captured get it filled in
+// without an event.
+func (e *Engine) recordMetadata(
+	ctx context.Context,
+	scanID int64,
+	unchanged []*FileRecord,
+	previous map[string]*database.File,
+) error {
+	events := []*database.ChangeEvent{}
+
+	for _, file := range unchanged {
+		previousFile := previous[file.Path]
+		metadata := file.Metadata.Merge(previousFile.FileMetadata)
+		changed := file.Metadata.Differs(previousFile.FileMetadata)
+		if !changed && metadata.String() == previousFile.FileMetadata.String() {
+			continue
+		}
+
+		if changed {
+			oldMetadata := previousFile.FileMetadata.String()
+			newMetadata := metadata.String()
+			events = append(events, &database.ChangeEvent{
+				ScanID:      scanID,
+				FileID:      previousFile.ID,
+				EventType:   database.ChangeEventMetadataChanged,
+				DetectedAt:  time.Now(),
+				OldChecksum: previousFile.CurrentChecksum,
+				NewChecksum: previousFile.CurrentChecksum,
+				OldMetadata: &oldMetadata,
+				NewMetadata: &newMetadata,
+			})
+		}
+
+		if err := e.db.Files.UpdateMetadata(ctx, previousFile.ID, metadata); err != nil {
+			return fmt.Errorf("failed to update metadata of %s: %w", file.Path, err)
+		}
+		previousFile.FileMetadata = metadata
+	}
+
+	if len(events) > 0 {
+		if err := e.db.ChangeEvents.CreateBatch(ctx, events); err != nil {
+			return fmt.Errorf("failed to record metadata events: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// serverChecksumChanged reports whether a server-supplied checksum differs
+// from the one recorded. Checksums from different algorithms, or missing on
+// either side, say nothing about the content.
+func serverChecksumChanged(current string, previous *string) bool {
+	if current == "" || previous == nil || *previous == "" {
+		return false
+	}
+	currentAlgorithm, _, _ := strings.Cut(current, ":")
+	previousAlgorithm, _, _ := strings.Cut(*previous, ":")
+	return currentAlgorithm == previousAlgorithm && current != *previous
+}
+
+// recordServerChecksums stores the server-supplied checksums of unchanged
+// files that were recorded without one, or with another algorithm's, so
+// later scans can compare them
+func (e *Engine) recordServerChecksums(ctx context.Context, unchanged []*FileRecord, previous map[string]*database.File) error {
+	for _, file := range unchanged {
+		previousFile := previous[file.Path]
+		if file.ServerChecksum == "" || (previousFile.ServerChecksum != nil && *previousFile.ServerChecksum == file.ServerChecksum) {
+			continue
+		}
+		if err := e.db.Files.UpdateServerChecksum(ctx, previousFile.ID, file.ServerChecksum); err != nil {
+			return fmt.Errorf("failed to update server checksum of %s: %w", file.Path, err)
+		}
+		checksum := file.ServerChecksum
+		previousFile.ServerChecksum = &checksum
+	}
+	return nil
+}
+
+// recordModTimes stores the modification times of unchanged files recorded
+// without one, so later scans can compare them
+func (e *Engine) recordModTimes(ctx context.Context, unchanged []*FileRecord, previous map[string]*database.File) error {
+	for _, file := range unchanged {
+		previousFile := previous[file.Path]
+		if previousFile.ModTime != nil || file.ModTime.IsZero() {
+			continue
+		}
+		if err := e.db.Files.UpdateModTime(ctx, previousFile.ID, file.ModTime); err != nil {
+			return fmt.Errorf("failed to update modification time of %s: %w", file.Path, err)
+		}
+		modTime := file.ModTime
+		previousFile.ModTime = &modTime
+	}
+	return nil
+}
+
 // selectRandomSample selects a random sample of unchanged files for verification
 // Uses weighted sampling prioritizing files that haven't been checksummed recently
 func (e *Engine) selectRandomSample(
@@ -172,6 +355,12 @@ func (e *Engine) selectRandomSample(
 		sampleSize = 1 // At least one file if there are any
 	}
 
+	// A verification window replaces random sampling with a schedule that
+	// guarantees coverage; the sample size still acts as a floor
+	if target.VerificationWindowDays != nil {
+		return e.selectByPolicy(ctx, unchanged, previous, target, sampleSize)
+	}
+
 	// Build list of unverified files from database (prioritize files never checksummed)
 	unverifiedFiles, err := e.db.Files.GetUnverifiedFiles(ctx, target.ID, sampleSize*2)
 	if err != nil {
@@ -210,15 +399,18 @@ func (e *Engine) selectRandomSample(
 	return sampled, nil
 }
 
-// computeChecksums computes checksums for files that need verification
+// computeChecksums computes checksums for files that need verification.
+// Held files are paired with the added files as moves once those are hashed.
 func (e *Engine) computeChecksums(
 	ctx context.Context,
 	scanID int64,
 	changes *ChangeSet,
+	held []*database.File,
 	sampled []*FileRecord,
 	checksumPool *checksum.WorkerPool,
 	backend storage.StorageBackend,
 	target *database.StorageTarget,
+	scanResult *ScanResult,
 ) error {
 	// Collect all files that need checksums
 	toChecksum := make([]*FileRecord, 0)
@@ -230,62 +422,240 @@ func (e *Engine) computeChecksums(
 		return nil
 	}
 
-	// Submit jobs to worker pool
-	for _, file := range toChecksum {
-		job := &checksum.Job{
-			Path:      file.Path,
-			Algorithm: e.config.ChecksumAlgorithm,
-			Opener: func(path string) func() (io.ReadCloser, error) {
-				return func() (io.ReadCloser, error) {
-					return backend.Open(ctx, path)
-				}
-			}(file.Path),
-			Timeout: e.config.FileTimeout,
+	// Added files with a checksum imported from a manifest are checked
+	// against it rather than trusted as found
+	expected, err := e.loadExpectedChecksums(ctx, target.ID, changes.Added)
+	if err != nil {
+		return fmt.Errorf("failed to load expected checksums: %w", err)
+	}
+
+	scanErrors, err := e.hashFiles(ctx, scanID, toChecksum, checksumPool, backend, scanResult, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := e.db.ScanErrors.CreateBatch(ctx, scanErrors); err != nil {
+		return fmt.Errorf("failed to record scan errors: %w", err)
+	}
+
+	if err := e.detectMoves(ctx, scanID, changes, held, target.ID); err != nil {
+		return fmt.Errorf("failed to detect moves: %w", err)
+	}
+	checks := applyExpectedChecksums(changes.Added, expected, scanResult)
+
+	// Persist file records to database
+	if err := e.persistFileRecords(ctx, scanID, toChecksum, target.ID); err != nil {
+		return fmt.Errorf("failed to persist file records: %w", err)
+	}
+
+	if err := e.recordContentEvents(ctx, scanID, changes, target.ID); err != nil {
+		return fmt.Errorf("failed to record changes: %w", err)
+	}
+
+	if err := e.recordExpectedChecks(ctx, scanID, target.ID, checks); err != nil {
+		return fmt.Errorf("failed to record expected checksums: %w", err)
+	}
+
+	if e.config.ScanArchives {
+		if err := e.hashArchives(ctx, scanID, toChecksum, target.ID, backend, scanResult); err != nil {
+			return fmt.Errorf("failed to hash archive members: %w", err)
 		}
+	}
 
-		if err := checksumPool.Submit(job); err != nil {
-			return fmt.Errorf("failed to submit checksum job for %s: %w", file.Path, err)
+	for _, file := range sampled {
+		switch {
+		case file.Checksum == "":
+		case file.mismatched():
+			scanResult.FilesMismatched++
+			scanResult.addError(fmt.Sprintf("checksum mismatch: %s: expected %s, got %s",
+				file.Path, file.PreviousChecksum, file.Checksum))
+		default:
+			scanResult.FilesVerified++
 		}
 	}
 
+	// Create change events for verified files (sampled unchanged files)
+	if err := e.createVerificationEvents(ctx, scanID, sampled, target.ID); err != nil {
+		return fmt.Errorf("failed to create verification events: %w", err)
+	}
+
+	return nil
+}
+
+// hashFiles hashes files on the checksum pool, setting Checksum and
+// ChecksumType on each file that succeeds. Files with a ChecksumType are
+// hashed with that algorithm so they can be compared with their stored
+// checksum. Hardlinked names are hashed once and share the result, and
+// symlinks recorded as links are hashed by their target path. Failures are
+// counted on scanResult and returned for recording.
+// If stop is non-nil it is consulted before each file is submitted; once it
+// returns true no further files are submitted and the rest keep an empty
+// checksum.
+func (e *Engine) hashFiles(
+	ctx context.Context,
+	scanID int64,
+	files []*FileRecord,
+	checksumPool *checksum.WorkerPool,
+	backend storage.StorageBackend,
+	scanResult *ScanResult,
+	stop func(file *FileRecord) bool,
+) ([]*database.ScanError, error) {
+	retries := e.config.FileRetries
+	if retries < 0 {
+		retries = 0
+	}
+
+	type submitResult struct {
+		submitted int
+		err       error
+	}
+
+	unique, aliases := groupHardlinks(files, e.config.ChecksumAlgorithm)
+
+	// Submit jobs from a separate goroutine so results can be drained while
+	// submitting; otherwise the pool's buffers fill and both sides block
+	submitDone := make(chan submitResult, 1)
+	go func() {
+		submitted := 0
+		for _, file := range unique {
+			if ctx.Err() != nil {
+				submitDone <- submitResult{submitted, ctx.Err()}
+				return
+			}
+			if stop != nil && stop(file) {
+				break
+			}
+
+			algorithm := e.config.ChecksumAlgorithm
+			if file.ChecksumType != "" {
+				algorithm = checksum.Algorithm(file.ChecksumType)
+			}
+
+			job := &checksum.Job{
+				Path:      file.Path,
+				Algorithm: algorithm,
+				Opener: func(file *FileRecord) func() (io.ReadCloser, error) {
+					return func() (io.ReadCloser, error) {
+						if file.FileType == database.FileTypeSymlink {
+							return io.NopCloser(strings.NewReader(file.LinkTarget)), nil
+						}
+						return backend.Open(ctx, file.Path)
+					}
+				}(file),
+				Timeout:      e.config.FileTimeout,
+				Retries:      retries,
+				RetryBackoff: e.config.RetryBackoff,
+				ReadSize:     e.config.ReadSize,
+			}
+			if e.config.ScanArchives && file.FileType != database.FileTypeSymlink && archiveFormatOf(file.Path) != archiveNone {
+				job.After = func(file *FileRecord, algorithm checksum.Algorithm) func() {
+					return func() { e.readArchive(ctx, file, algorithm, backend) }
+				}(file, algorithm)
+			}
+
+			if err := checksumPool.Submit(job); err != nil {
+				submitDone <- submitResult{submitted, fmt.Errorf("failed to submit checksum job for %s: %w", file.Path, err)}
+				return
+			}
+			submitted++
+		}
+		submitDone <- submitResult{submitted, nil}
+	}()
+
 	// Collect results
 	fileMap := make(map[string]*FileRecord)
-	for _, file := range toChecksum {
+	for _, file := range files {
 		fileMap[file.Path] = file
 	}
 
-	for i := 0; i < len(toChecksum); i++ {
+	scanErrors := []*database.ScanError{}
+	submitted := -1 // Unknown until the submitter finishes
+	for received := 0; submitted < 0 || received < submitted; {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			// Keep draining so in-flight jobs and the submitter can finish
+			go func() {
+				for range checksumPool.Results() {
+				}
+			}()
+			if submitDone != nil {
+				<-submitDone
+			}
+			return nil, ctx.Err()
+		case done := <-submitDone:
+			if done.err != nil {
+				return nil, done.err
+			}
+			// All jobs submitted; keep collecting results
+			submitted = done.submitted
+			submitDone = nil
 		case result := <-checksumPool.Results():
-			file, exists := fileMap[result.Path]
+			received++
+			primary, exists := fileMap[result.Path]
 			if !exists {
 				continue
 			}
 
-			if result.Error != nil {
-				// Log error but continue with other files
-				continue
-			}
+			for _, file := range append([]*FileRecord{primary}, aliases[primary.Path]...) {
+				if result.Error != nil {
+					// Record the failure and continue with other files
+					scanErr := &database.ScanError{
+						ScanID:     scanID,
+						Path:       file.Path,
+						Phase:      database.ScanErrorPhaseHash,
+						ErrorClass: string(checksum.ClassifyError(result.Error)),
+						Message:    result.Error.Error(),
+						Retryable:  checksum.IsRetryable(result.Error),
+						Attempts:   result.Attempts,
+					}
+					scanErrors = append(scanErrors, scanErr)
+					scanResult.FilesFailed++
+					scanResult.addError(fmt.Sprintf("hash error: %s: %v", scanErr.Path, result.Error))
+					continue
+				}
 
-			// Update file record with checksum
-			file.Checksum = result.Checksum
-			file.ChecksumType = string(e.config.ChecksumAlgorithm)
+				// Update file record with checksum
+				file.Checksum = result.Checksum
+				if file.ChecksumType == "" {
+					file.ChecksumType = string(e.config.ChecksumAlgorithm)
+				}
+				file.archiveRead, file.archiveMembers, file.archiveErr = primary.archiveRead, primary.archiveMembers, primary.archiveErr
+			}
 		}
 	}
 
-	// Persist file records to database
-	if err := e.persistFileRecords(ctx, scanID, toChecksum, target.ID); err != nil {
-		return fmt.Errorf("failed to persist file records: %w", err)
+	return scanErrors, nil
+}
+
+// groupHardlinks splits files into those to hash and, keyed by the path of
+// the file that is hashed, the other names of the same hardlinked file. Names
+// are only grouped when they would be hashed with the same algorithm.
+func groupHardlinks(files []*FileRecord, defaultAlgorithm checksum.Algorithm) ([]*FileRecord, map[string][]*FileRecord) {
+	type inodeKey struct {
+		device, inode uint64
+		algorithm     string
 	}
 
-	// Create change events for verified files (sampled unchanged files)
-	if err := e.createVerificationEvents(ctx, scanID, sampled, target.ID); err != nil {
-		return fmt.Errorf("failed to create verification events: %w", err)
+	unique := make([]*FileRecord, 0, len(files))
+	aliases := make(map[string][]*FileRecord)
+	first := make(map[inodeKey]*FileRecord)
+
+	for _, file := range files {
+		if file.Links > 1 && file.Inode != 0 && file.FileType != database.FileTypeSymlink {
+			key := inodeKey{file.Device, file.Inode, file.ChecksumType}
+			if key.algorithm == "" {
+				key.algorithm = string(defaultAlgorithm)
+			}
+			if primary, ok := first[key]; ok {
+				aliases[primary.Path] = append(aliases[primary.Path], file)
+				continue
+			}
+			first[key] = file
+		}
+		unique = append(unique, file)
 	}
 
-	return nil
+	return unique, aliases
 }
 
 // persistFileRecords creates or updates file records in the database
@@ -298,8 +668,9 @@ func (e *Engine) persistFileRecords(
 	now := time.Now()
 
 	for _, file := range files {
-		// Skip files without checksums
-		if file.Checksum == "" {
+		// Skip files without checksums, and keep the known-good checksum
+		// of sampled files that no longer match it
+		if file.Checksum == "" || file.mismatched() {
 			continue
 		}
 
@@ -312,14 +683,30 @@ func (e *Engine) persistFileRecords(
 			CurrentChecksum:   &file.Checksum,
 			ChecksumType:      &file.ChecksumType,
 			LastChecksummedAt: &now,
+			FileType:          file.FileType,
+			FileMetadata:      file.Metadata,
+		}
+		if file.FileType == database.FileTypeSymlink {
+			dbFile.LinkTarget = &file.LinkTarget
+		}
+		if file.ServerChecksum != "" {
+			dbFile.ServerChecksum = &file.ServerChecksum
+		}
+		if !file.ModTime.IsZero() {
+			modTime := file.ModTime
+			dbFile.ModTime = &modTime
 		}
 
 		// Check if file already exists
 		existing, err := e.db.Files.GetByPath(ctx, targetID, file.Path)
 		if err == nil && existing != nil {
-			// Update existing file
+			// Update existing file, keeping metadata this scan did not capture
 			dbFile.ID = existing.ID
 			dbFile.FirstSeen = existing.FirstSeen
+			dbFile.FileMetadata = file.Metadata.Merge(existing.FileMetadata)
+			if dbFile.ModTime == nil {
+				dbFile.ModTime = existing.ModTime
+			}
 			if err := e.db.Files.Update(ctx, dbFile); err != nil {
 				return fmt.Errorf("failed to update file %s: %w", file.Path, err)
 			}
@@ -334,6 +721,49 @@ func (e *Engine) persistFileRecords(
 	return nil
 }
 
+// recordContentEvents records an event for each added or modified file that
+// was hashed, against the file's record
+func (e *Engine) recordContentEvents(ctx context.Context, scanID int64, changes *ChangeSet, targetID int64) error {
+	events := []*database.ChangeEvent{}
+	for _, files := range [][]*FileRecord{changes.Added, changes.Modified} {
+		for _, file := range files {
+			if file.Checksum == "" {
+				continue
+			}
+
+			dbFile, err := e.db.Files.GetByPath(ctx, targetID, file.Path)
+			if err != nil {
+				return err
+			}
+			if dbFile == nil {
+				continue
+			}
+
+			event := &database.ChangeEvent{
+				ScanID:      scanID,
+				FileID:      dbFile.ID,
+				EventType:   database.ChangeEventAdded,
+				DetectedAt:  file.ModTime,
+				NewChecksum: &file.Checksum,
+				NewSize:     &file.Size,
+			}
+			if file.IsModified {
+				event.EventType = database.ChangeEventModified
+				event.OldChecksum = &file.PreviousChecksum
+			}
+			events = append(events, event)
+		}
+	}
+
+	if len(events) > 0 {
+		if err := e.db.ChangeEvents.CreateBatch(ctx, events); err != nil {
+			return fmt.Errorf("failed to create change events: %w", err)
+		}
+	}
+
+	return nil
+}
+
 // createVerificationEvents creates change events for verified (sampled) files
 func (e *Engine) createVerificationEvents(
 	ctx context.Context,
@@ -358,14 +788,18 @@ func (e *Engine) createVerificationEvents(
 			continue
 		}
 
-		events = append(events, &database.ChangeEvent{
+		event := &database.ChangeEvent{
 			ScanID:      scanID,
 			FileID:      dbFile.ID,
 			EventType:   database.ChangeEventVerified,
 			DetectedAt:  file.ModTime,
 			NewChecksum: &file.Checksum,
 			NewSize:     &file.Size,
-		})
+		}
+		if file.PreviousChecksum != "" {
+			event.OldChecksum = &file.PreviousChecksum
+		}
+		events = append(events, event)
 	}
 
 	if len(events) > 0 {
@@ -0,0 +1,33 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/jeffanddom/fixity/internal/checksum"
+	"github.com/jeffanddom/fixity/internal/database"
+)
+
+func TestGroupHardlinks(t *testing.T) {
+	files := []*FileRecord{
+		{Path: "a", Device: 1, Inode: 10, Links: 2},
+		{Path: "b", Device: 1, Inode: 10, Links: 2},
+		{Path: "c", Device: 2, Inode: 10, Links: 2},                         // same inode, other device
+		{Path: "d", Device: 1, Inode: 10, Links: 2, ChecksumType: "sha256"}, // stored with another algorithm
+		{Path: "e", Device: 1, Inode: 11, Links: 1},
+		{Path: "f", Device: 1, Inode: 11, Links: 1, FileType: database.FileTypeSymlink},
+	}
+
+	unique, aliases := groupHardlinks(files, checksum.AlgorithmMD5)
+
+	if len(unique) != 5 {
+		t.Fatalf("expected 5 files to hash, got %d", len(unique))
+	}
+	for _, file := range unique {
+		if file.Path == "b" {
+			t.Error("expected b to share a's checksum")
+		}
+	}
+	if len(aliases["a"]) != 1 || aliases["a"][0].Path != "b" {
+		t.Errorf("expected b as the only alias of a, got %v", aliases["a"])
+	}
+}
@@ -0,0 +1,150 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/jeffanddom/fixity/internal/database"
+	"github.com/jeffanddom/fixity/internal/storage"
+)
+
+// DefaultFullWalkDays is how often a target that skips unchanged directories
+// is walked in full when it sets no interval of its own
+const DefaultFullWalkDays = 7
+
+// dirTracker records directory mtimes and entry counts during a walk and
+// decides which directories are unchanged since the last one. A directory's
+// mtime changes when entries are added, removed or renamed in it, but not
+// when a file is rewritten in place, so skipping trades detecting in-place
+// modifications for less metadata traffic until the next full walk.
+type dirTracker struct {
+	previous map[string]*database.Directory // Read concurrently by unchangedDir
+	skip     bool                           // Unchanged directories may be skipped on this scan
+
+	observed  map[string]*database.Directory
+	unchanged map[string]bool
+}
+
+// newDirTracker loads the directories recorded by the last walk of target.
+// Nothing is skipped when the last full walk is older than the target's
+// full walk interval.
+func (e *Engine) newDirTracker(ctx context.Context, target *database.StorageTarget) (*dirTracker, error) {
+	dirs, err := e.db.Directories.ListByTarget(ctx, target.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	lastFullWalk, err := e.db.Scans.LastFullWalk(ctx, target.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	days := DefaultFullWalkDays
+	if target.FullWalkDays != nil {
+		days = *target.FullWalkDays
+	}
+
+	t := &dirTracker{
+		previous:  make(map[string]*database.Directory, len(dirs)),
+		skip:      lastFullWalk != nil && time.Since(*lastFullWalk) < time.Duration(days)*24*time.Hour,
+		observed:  make(map[string]*database.Directory, len(dirs)),
+		unchanged: make(map[string]bool),
+	}
+	for _, dir := range dirs {
+		t.previous[dir.Path] = dir
+	}
+
+	return t, nil
+}
+
+// unchangedDir reports whether a directory has the mtime and entry count
+// recorded by the last walk; it is a storage.UnchangedDirFunc
+func (t *dirTracker) unchangedDir(relPath string, info *storage.FileInfo) bool {
+	if !t.skip {
+		return false
+	}
+	prev, ok := t.previous[relPath]
+	if !ok {
+		return false
+	}
+	// The database keeps microseconds
+	return prev.Entries == info.Entries && prev.ModTime.Equal(info.ModTime.Truncate(time.Microsecond))
+}
+
+// record notes a directory reported by the walk
+func (t *dirTracker) record(relPath string, info *storage.FileInfo, result *ScanResult) {
+	t.observed[relPath] = &database.Directory{
+		Path:    relPath,
+		ModTime: info.ModTime.Truncate(time.Microsecond),
+		Entries: info.Entries,
+	}
+	if info.Unchanged {
+		t.unchanged[relPath] = true
+		result.DirsSkipped++
+	}
+}
+
+// carryOver adds the previously recorded files of unchanged directories to
+// current as they were, since the walk did not report them
+func (t *dirTracker) carryOver(current map[string]*FileRecord, previous map[string]*database.File, backend storage.StorageBackend) {
+	if len(t.unchanged) == 0 {
+		return
+	}
+
+	excluder, _ := backend.(storage.PathExcluder)
+	for filePath, file := range previous {
+		if _, ok := current[filePath]; ok || !t.unchanged[path.Dir(filePath)] {
+			continue
+		}
+		if excluder != nil && excluder.Excluded(filePath) {
+			continue
+		}
+
+		record := &FileRecord{
+			Path:     filePath,
+			Size:     file.Size,
+			FileType: file.FileType,
+		}
+		if file.ModTime != nil {
+			record.ModTime = *file.ModTime
+		}
+		if file.LinkTarget != nil {
+			record.LinkTarget = *file.LinkTarget
+		}
+		if file.ServerChecksum != nil {
+			record.ServerChecksum = *file.ServerChecksum
+		}
+		current[filePath] = record
+	}
+}
+
+// save records the directories seen by the walk for the next scan. Paths
+// the walk could not read, and directories holding files that could not be
+// hashed, are left out so the next scan reads them again.
+func (t *dirTracker) save(ctx context.Context, db *database.Database, targetID int64, unreadable []string, changes *ChangeSet) error {
+	for _, p := range unreadable {
+		delete(t.observed, path.Dir(p))
+	}
+	for _, files := range [][]*FileRecord{changes.Added, changes.Modified, changes.Moved} {
+		for _, file := range files {
+			if file.Checksum == "" {
+				delete(t.observed, path.Dir(file.Path))
+			}
+		}
+	}
+
+	dirs := make([]*database.Directory, 0, len(t.observed))
+	for relPath, dir := range t.observed {
+		if underAny(relPath, unreadable) {
+			continue
+		}
+		dirs = append(dirs, dir)
+	}
+
+	if err := db.Directories.Replace(ctx, targetID, dirs); err != nil {
+		return fmt.Errorf("failed to record directories: %w", err)
+	}
+	return nil
+}
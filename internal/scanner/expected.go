@@ -0,0 +1,110 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jeffanddom/fixity/internal/database"
+)
+
+// expectedCheck is an added file compared with the checksum imported for it
+type expectedCheck struct {
+	file     *FileRecord
+	expected string
+	actual   string
+}
+
+// loadExpectedChecksums looks up the checksums imported for added regular
+// files and has each such file hashed with the expected checksum's
+// algorithm, so the two can be compared
+func (e *Engine) loadExpectedChecksums(ctx context.Context, targetID int64, added []*FileRecord) (map[string]*database.ExpectedChecksum, error) {
+	paths := make([]string, 0, len(added))
+	for _, file := range added {
+		if file.FileType == database.FileTypeRegular {
+			paths = append(paths, file.Path)
+		}
+	}
+
+	rows, err := e.db.ExpectedChecksums.ListByPaths(ctx, targetID, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	expected := make(map[string]*database.ExpectedChecksum, len(rows))
+	for _, row := range rows {
+		expected[row.Path] = row
+	}
+	for _, file := range added {
+		if row, ok := expected[file.Path]; ok && file.FileType == database.FileTypeRegular {
+			file.ChecksumType = row.ChecksumType
+		}
+	}
+
+	return expected, nil
+}
+
+// applyExpectedChecksums compares hashed added files with their expected
+// checksums. A mismatched file is recorded with the expected checksum rather
+// than the one found, so it is flagged again until someone investigates.
+func applyExpectedChecksums(added []*FileRecord, expected map[string]*database.ExpectedChecksum, result *ScanResult) []expectedCheck {
+	checks := []expectedCheck{}
+	for _, file := range added {
+		row, ok := expected[file.Path]
+		if !ok || file.Checksum == "" || file.ChecksumType != row.ChecksumType {
+			continue
+		}
+
+		checks = append(checks, expectedCheck{file: file, expected: row.Checksum, actual: file.Checksum})
+		if file.Checksum == row.Checksum {
+			result.FilesVerified++
+			continue
+		}
+
+		result.FilesMismatched++
+		result.addError(fmt.Sprintf("checksum mismatch: %s: expected %s, got %s",
+			file.Path, row.Checksum, file.Checksum))
+		file.Checksum = row.Checksum
+	}
+
+	return checks
+}
+
+// recordExpectedChecks records a verification event for each added file
+// compared with its expected checksum, and removes the expectations applied
+func (e *Engine) recordExpectedChecks(ctx context.Context, scanID int64, targetID int64, checks []expectedCheck) error {
+	if len(checks) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	paths := make([]string, 0, len(checks))
+	events := make([]*database.ChangeEvent, 0, len(checks))
+	for _, check := range checks {
+		paths = append(paths, check.file.Path)
+
+		dbFile, err := e.db.Files.GetByPath(ctx, targetID, check.file.Path)
+		if err != nil || dbFile == nil {
+			continue
+		}
+
+		expected, actual := check.expected, check.actual
+		events = append(events, &database.ChangeEvent{
+			ScanID:      scanID,
+			FileID:      dbFile.ID,
+			EventType:   database.ChangeEventVerified,
+			DetectedAt:  now,
+			OldChecksum: &expected,
+			NewChecksum: &actual,
+			NewSize:     &check.file.Size,
+		})
+	}
+
+	if len(events) > 0 {
+		if err := e.db.ChangeEvents.CreateBatch(ctx, events); err != nil {
+			return fmt.Errorf("failed to create verification events: %w", err)
+		}
+	}
+
+	return e.db.ExpectedChecksums.DeleteByPaths(ctx, targetID, paths)
+}
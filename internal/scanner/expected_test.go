@@ -0,0 +1,37 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/jeffanddom/fixity/internal/database"
+)
+
+func TestApplyExpectedChecksums(t *testing.T) {
+	added := []*FileRecord{
+		{Path: "intact.txt", Checksum: "aa", ChecksumType: "sha256"},
+		{Path: "damaged.txt", Checksum: "bb", ChecksumType: "sha256"},
+		{Path: "unreadable.txt", ChecksumType: "sha256"},
+		{Path: "unlisted.txt", Checksum: "cc", ChecksumType: "md5"},
+	}
+	expected := map[string]*database.ExpectedChecksum{
+		"intact.txt":     {Path: "intact.txt", Checksum: "aa", ChecksumType: "sha256"},
+		"damaged.txt":    {Path: "damaged.txt", Checksum: "b0", ChecksumType: "sha256"},
+		"unreadable.txt": {Path: "unreadable.txt", Checksum: "dd", ChecksumType: "sha256"},
+	}
+
+	result := &ScanResult{}
+	checks := applyExpectedChecksums(added, expected, result)
+
+	if len(checks) != 2 {
+		t.Fatalf("expected 2 files checked, got %d", len(checks))
+	}
+	if result.FilesVerified != 1 || result.FilesMismatched != 1 || result.ErrorsCount != 1 {
+		t.Errorf("expected 1 verified and 1 mismatched, got %+v", result)
+	}
+	if added[1].Checksum != "b0" || checks[1].actual != "bb" {
+		t.Errorf("expected the damaged file to be recorded with its expected checksum, got %s", added[1].Checksum)
+	}
+	if added[2].Checksum != "" {
+		t.Error("expected a file that could not be hashed to be left alone")
+	}
+}
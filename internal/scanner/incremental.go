@@ -0,0 +1,179 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/jeffanddom/fixity/internal/checksum"
+	"github.com/jeffanddom/fixity/internal/database"
+	"github.com/jeffanddom/fixity/internal/storage"
+)
+
+// ScanPaths performs an incremental scan of paths reported by a watcher,
+// looking up and hashing only those paths instead of walking the tree. A
+// path that no longer exists marks the files recorded at or beneath it as
+// deleted; an existing directory is skipped, since the watcher reports the
+// files within it. The backend must support storage.Lookup.
+func (e *Engine) ScanPaths(ctx context.Context, targetID int64, backend storage.StorageBackend, paths []string) (*ScanResult, error) {
+	start := time.Now()
+
+	target, err := e.db.StorageTargets.GetByID(ctx, targetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get storage target: %w", err)
+	}
+
+	if err := backend.Probe(ctx); err != nil {
+		return nil, fmt.Errorf("storage backend not accessible: %w", err)
+	}
+	if _, ok := backend.(storage.PathLookup); !ok {
+		return nil, storage.ErrLookupUnsupported
+	}
+
+	scan := &database.Scan{
+		StorageTargetID: targetID,
+		Status:          database.ScanStatusRunning,
+		ScanType:        database.ScanTypeIncremental,
+		StartedAt:       start,
+	}
+	if err := e.db.Scans.Create(ctx, scan); err != nil {
+		return nil, fmt.Errorf("failed to create scan record: %w", err)
+	}
+
+	result := &ScanResult{
+		ScanID: scan.ID,
+		Errors: []string{},
+	}
+
+	checksumPool := e.newChecksumPool()
+	checksumPool.Start()
+	defer checksumPool.Stop()
+
+	currentFiles, previousFiles, unreadable, err := e.lookupPaths(ctx, targetID, backend, scan.ID, paths, result)
+	if err != nil {
+		e.finalizeScan(ctx, scan, result, database.ScanStatusFailed)
+		return nil, fmt.Errorf("failed to look up changed paths: %w", err)
+	}
+
+	changes, err := e.detectChanges(ctx, currentFiles, previousFiles, unreadable, scan.ID, checksumPool, backend, target, result)
+	if err != nil {
+		e.finalizeScan(ctx, scan, result, database.ScanStatusFailed)
+		return nil, fmt.Errorf("failed to detect changes: %w", err)
+	}
+
+	result.WorkerStats = checksumPool.Stats()
+	result.FilesAdded = int64(len(changes.Added))
+	result.FilesDeleted = int64(len(changes.Deleted))
+	result.FilesModified = int64(len(changes.Modified))
+	result.FilesMetadataChanged = int64(len(changes.MetadataChanged))
+	result.FilesMoved = int64(len(changes.Moved))
+
+	// Thresholds are measured against the whole target, not just the paths
+	// looked at here
+	totalFiles, err := e.db.Files.Count(ctx, database.FileFilters{StorageTargetID: &targetID, ActiveOnly: true})
+	if err != nil {
+		result.addError(fmt.Sprintf("failed to count files: %v", err))
+	}
+	result.IsLargeChange = e.isLargeChange(target, changes, int(totalFiles))
+
+	status := database.ScanStatusCompleted
+	if result.FilesFailed > 0 || result.PathsUnreadable > 0 {
+		status = database.ScanStatusPartial
+	}
+
+	result.Duration = time.Since(start)
+	e.finalizeScan(ctx, scan, result, status)
+
+	return result, nil
+}
+
+// lookupPaths builds the current and previous file maps for an incremental
+// scan from the changed paths. Only files recorded at the changed paths, or
+// beneath changed paths that no longer exist, are loaded as previous files,
+// so nothing else can be mistaken for deleted.
+func (e *Engine) lookupPaths(
+	ctx context.Context,
+	targetID int64,
+	backend storage.StorageBackend,
+	scanID int64,
+	paths []string,
+	result *ScanResult,
+) (map[string]*FileRecord, map[string]*database.File, []string, error) {
+	current := make(map[string]*FileRecord)
+	previous := make(map[string]*database.File)
+	scanErrors := []*database.ScanError{}
+	unreadable := []string{}
+
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, nil, err
+		}
+
+		info, err := storage.Lookup(ctx, backend, path)
+		if errors.Is(err, fs.ErrNotExist) {
+			// Gone: whatever was recorded here, or beneath it if it was a
+			// directory, has been deleted
+			files, err := e.db.Files.ListUnderPath(ctx, targetID, path)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			for _, f := range files {
+				previous[f.Path] = f
+			}
+			continue
+		}
+		if err != nil {
+			unreadable = append(unreadable, path)
+			result.PathsUnreadable++
+			result.addError(fmt.Sprintf("walk error: %v", err))
+			scanErrors = append(scanErrors, &database.ScanError{
+				ScanID:     scanID,
+				Path:       path,
+				Phase:      database.ScanErrorPhaseWalk,
+				ErrorClass: string(checksum.ClassifyError(err)),
+				Message:    err.Error(),
+				Retryable:  checksum.IsRetryable(err),
+				Attempts:   1,
+			})
+			continue
+		}
+
+		// Left out by the target's path rules or link policy
+		if info == nil || info.IsDir {
+			continue
+		}
+
+		known, err := e.db.Files.GetByPath(ctx, targetID, path)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if known != nil && known.DeletedAt == nil {
+			previous[path] = known
+		}
+
+		// A file replaced by a socket, FIFO or device is deleted
+		if info.Type.IsSpecial() {
+			result.FilesSkipped++
+			scanErrors = append(scanErrors, &database.ScanError{
+				ScanID:     scanID,
+				Path:       path,
+				Phase:      database.ScanErrorPhaseSkip,
+				ErrorClass: string(info.Type),
+				Message:    fmt.Sprintf("skipped %s: no content to hash", info.Type),
+				Attempts:   1,
+			})
+			continue
+		}
+
+		result.FilesScanned++
+		current[path] = newFileRecord(path, info)
+	}
+
+	if err := e.db.ScanErrors.CreateBatch(ctx, scanErrors); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to record walk errors: %w", err)
+	}
+
+	return current, previous, unreadable, nil
+}
@@ -0,0 +1,279 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/jeffanddom/fixity/internal/database"
+)
+
+// moveWindow is how long after a file is deleted an added file with its
+// content is still taken as the file having moved, so a move split across
+// incremental scans is recognized
+const moveWindow = 24 * time.Hour
+
+// move pairs an added file with the deleted file it was moved from
+type move struct {
+	file *FileRecord
+	from *database.File
+}
+
+// holdMoveCandidates removes from changes.Deleted the files that may have
+// moved: those with a checksum and the size of an added file. They are
+// reported deleted only if no added file turns out to have their content.
+func holdMoveCandidates(changes *ChangeSet) []*database.File {
+	sizes := make(map[int64]bool, len(changes.Added))
+	for _, file := range changes.Added {
+		sizes[file.Size] = true
+	}
+
+	held := []*database.File{}
+	deleted := changes.Deleted[:0]
+	for _, file := range changes.Deleted {
+		if file.CurrentChecksum != nil && sizes[file.Size] {
+			held = append(held, file)
+			continue
+		}
+		deleted = append(deleted, file)
+	}
+	changes.Deleted = deleted
+
+	return held
+}
+
+// pairMoves pairs hashed added files with candidates of identical content:
+// the same checksum, algorithm, size and file type. Each candidate is used
+// once, preferring earlier candidates and those with the same file name or
+// directory. Where several added files share content, such as copies of a
+// boilerplate file, a candidate must have the added file's name or directory
+// so unrelated records don't pass on their history. Empty files all share
+// content and are never paired.
+func pairMoves(added []*FileRecord, candidates []*database.File) []move {
+	type contentKey struct {
+		checksum     string
+		checksumType string
+		size         int64
+		fileType     database.FileType
+	}
+
+	byContent := make(map[contentKey][]*database.File)
+	for _, candidate := range candidates {
+		if candidate.CurrentChecksum == nil || candidate.ChecksumType == nil || candidate.Size == 0 {
+			continue
+		}
+		key := contentKey{*candidate.CurrentChecksum, *candidate.ChecksumType, candidate.Size, candidate.FileType}
+		byContent[key] = append(byContent[key], candidate)
+	}
+
+	addedByContent := make(map[contentKey]int)
+	for _, file := range added {
+		if file.Checksum != "" {
+			addedByContent[contentKey{file.Checksum, file.ChecksumType, file.Size, file.FileType}]++
+		}
+	}
+
+	used := make(map[int64]bool)
+	moves := []move{}
+	for _, file := range sortedByPath(added) {
+		if file.Checksum == "" || file.Size == 0 {
+			continue
+		}
+		key := contentKey{file.Checksum, file.ChecksumType, file.Size, file.FileType}
+
+		var sameName, sameDir, other *database.File
+		for _, candidate := range byContent[key] {
+			if used[candidate.ID] {
+				continue
+			}
+			switch {
+			case path.Base(candidate.Path) == path.Base(file.Path):
+				if sameName == nil {
+					sameName = candidate
+				}
+			case path.Dir(candidate.Path) == path.Dir(file.Path):
+				if sameDir == nil {
+					sameDir = candidate
+				}
+			default:
+				if other == nil {
+					other = candidate
+				}
+			}
+		}
+
+		from := sameName
+		if from == nil {
+			from = sameDir
+		}
+		if from == nil && addedByContent[key] == 1 {
+			from = other
+		}
+
+		if from != nil {
+			used[from.ID] = true
+			moves = append(moves, move{file: file, from: from})
+		}
+	}
+
+	return moves
+}
+
+// pairUnhashedMoves pairs added files that could not be hashed with held
+// files of the same size, type and file name, where exactly one file on each
+// side shares them. Without content to compare this is a guess, so anything
+// ambiguous is left as a delete and an add.
+func pairUnhashedMoves(added []*FileRecord, held []*database.File) []move {
+	type nameKey struct {
+		name     string
+		size     int64
+		fileType database.FileType
+	}
+
+	addedByName := make(map[nameKey][]*FileRecord)
+	for _, file := range added {
+		if file.Checksum == "" {
+			key := nameKey{path.Base(file.Path), file.Size, file.FileType}
+			addedByName[key] = append(addedByName[key], file)
+		}
+	}
+
+	heldByName := make(map[nameKey][]*database.File)
+	for _, file := range held {
+		key := nameKey{path.Base(file.Path), file.Size, file.FileType}
+		heldByName[key] = append(heldByName[key], file)
+	}
+
+	moves := []move{}
+	for key, files := range addedByName {
+		if len(files) == 1 && len(heldByName[key]) == 1 {
+			moves = append(moves, move{file: files[0], from: heldByName[key][0]})
+		}
+	}
+	sort.Slice(moves, func(i, j int) bool { return moves[i].file.Path < moves[j].file.Path })
+
+	return moves
+}
+
+// sortedByPath returns files ordered by path, so pairing does not depend on
+// map iteration order
+func sortedByPath(files []*FileRecord) []*FileRecord {
+	sorted := make([]*FileRecord, len(files))
+	copy(sorted, files)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Path < sorted[j].Path })
+	return sorted
+}
+
+// detectMoves pairs the added files, once hashed, with the held files and
+// files deleted within moveWindow that have identical content, falling back
+// to names for added files that could not be hashed. Each pair is recorded
+// as a move of the deleted file's record to the new path, so its history
+// carries over. Held files left unpaired are recorded as deleted.
+func (e *Engine) detectMoves(ctx context.Context, scanID int64, changes *ChangeSet, held []*database.File, targetID int64) error {
+	if len(changes.Added) == 0 {
+		changes.Deleted = append(changes.Deleted, held...)
+		return e.recordDeletions(ctx, scanID, held)
+	}
+
+	// Files deleted by earlier scans, e.g. the other half of a move seen by
+	// a separate incremental scan
+	addedPaths := make(map[string]bool, len(changes.Added))
+	checksums := []string{}
+	for _, file := range changes.Added {
+		addedPaths[file.Path] = true
+		if file.Checksum != "" {
+			checksums = append(checksums, file.Checksum)
+		}
+	}
+	recent, err := e.db.Files.ListDeletedByChecksum(ctx, targetID, checksums, time.Now().Add(-moveWindow))
+	if err != nil {
+		return err
+	}
+
+	// A deleted file whose own path was added again has reappeared rather
+	// than moved
+	candidates := append([]*database.File{}, held...)
+	for _, file := range recent {
+		if !addedPaths[file.Path] {
+			candidates = append(candidates, file)
+		}
+	}
+
+	moves := pairMoves(changes.Added, candidates)
+	claimed := make(map[int64]bool, len(moves))
+	for _, m := range moves {
+		claimed[m.from.ID] = true
+	}
+	unclaimed := []*database.File{}
+	for _, file := range held {
+		if !claimed[file.ID] {
+			unclaimed = append(unclaimed, file)
+		}
+	}
+	moves = append(moves, pairUnhashedMoves(changes.Added, unclaimed)...)
+
+	// Only files actually moved are paired; the rest are deleted below
+	paired := make(map[int64]bool, len(moves))
+	moved := make(map[string]bool, len(moves))
+	events := []*database.ChangeEvent{}
+	for _, m := range moves {
+		// A record already at the new path keeps that path's own history
+		existing, err := e.db.Files.GetByPath(ctx, targetID, m.file.Path)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			continue
+		}
+
+		if err := e.db.Files.Move(ctx, m.from.ID, m.file.Path); err != nil {
+			return fmt.Errorf("failed to move %s to %s: %w", m.from.Path, m.file.Path, err)
+		}
+
+		oldPath, newPath, oldSize := m.from.Path, m.file.Path, m.from.Size
+		event := &database.ChangeEvent{
+			ScanID:      scanID,
+			FileID:      m.from.ID,
+			EventType:   database.ChangeEventMoved,
+			DetectedAt:  time.Now(),
+			OldChecksum: m.from.CurrentChecksum,
+			OldSize:     &oldSize,
+			NewSize:     &m.file.Size,
+			OldPath:     &oldPath,
+			NewPath:     &newPath,
+		}
+		if m.file.Checksum != "" {
+			event.NewChecksum = &m.file.Checksum
+		}
+		events = append(events, event)
+
+		moved[m.file.Path] = true
+		paired[m.from.ID] = true
+		changes.Moved = append(changes.Moved, m.file)
+	}
+
+	if len(events) > 0 {
+		if err := e.db.ChangeEvents.CreateBatch(ctx, events); err != nil {
+			return fmt.Errorf("failed to record move events: %w", err)
+		}
+	}
+
+	added := changes.Added[:0]
+	for _, file := range changes.Added {
+		if !moved[file.Path] {
+			added = append(added, file)
+		}
+	}
+	changes.Added = added
+
+	unpaired := []*database.File{}
+	for _, file := range held {
+		if !paired[file.ID] {
+			unpaired = append(unpaired, file)
+		}
+	}
+	changes.Deleted = append(changes.Deleted, unpaired...)
+	return e.recordDeletions(ctx, scanID, unpaired)
+}
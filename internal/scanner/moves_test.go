@@ -0,0 +1,92 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/jeffanddom/fixity/internal/database"
+)
+
+func deletedFile(id int64, path, sum string, size int64) *database.File {
+	checksumType := "md5"
+	file := &database.File{ID: id, Path: path, Size: size, FileType: database.FileTypeRegular, ChecksumType: &checksumType}
+	if sum != "" {
+		file.CurrentChecksum = &sum
+	}
+	return file
+}
+
+func TestHoldMoveCandidates(t *testing.T) {
+	changes := &ChangeSet{
+		Added: []*FileRecord{{Path: "new/a.txt", Size: 5}},
+		Deleted: []*database.File{
+			deletedFile(1, "old/a.txt", "aa", 5),
+			deletedFile(2, "old/b.txt", "bb", 6), // no added file of this size
+			deletedFile(3, "old/c.txt", "", 5),   // never hashed
+		},
+	}
+
+	held := holdMoveCandidates(changes)
+	if len(held) != 1 || held[0].ID != 1 {
+		t.Errorf("expected only old/a.txt to be held, got %v", held)
+	}
+	if len(changes.Deleted) != 2 {
+		t.Errorf("expected 2 files left deleted, got %d", len(changes.Deleted))
+	}
+}
+
+func TestPairMoves(t *testing.T) {
+	added := []*FileRecord{
+		{Path: "b/copy.txt", Size: 5, Checksum: "aa", ChecksumType: "md5", FileType: database.FileTypeRegular},
+		{Path: "a/notes.txt", Size: 5, Checksum: "aa", ChecksumType: "md5", FileType: database.FileTypeRegular},
+		{Path: "old/renamed.txt", Size: 5, Checksum: "aa", ChecksumType: "md5", FileType: database.FileTypeRegular},
+		{Path: "d/hashed.txt", Size: 5, Checksum: "dd", ChecksumType: "sha256", FileType: database.FileTypeRegular},
+		{Path: "e/report.pdf", Size: 7, Checksum: "ee", ChecksumType: "md5", FileType: database.FileTypeRegular},
+		{Path: "f/.gitkeep", Size: 0, Checksum: "d41d", ChecksumType: "md5", FileType: database.FileTypeRegular},
+	}
+	candidates := []*database.File{
+		deletedFile(1, "old/first.txt", "aa", 5),
+		deletedFile(2, "old/notes.txt", "aa", 5),
+		deletedFile(3, "x/unrelated.txt", "aa", 5),
+		deletedFile(4, "old/hashed.txt", "dd", 5), // recorded with md5
+		deletedFile(5, "drafts/q3.pdf", "ee", 7),
+		deletedFile(6, "g/.gitkeep", "d41d", 0),
+	}
+
+	moves := pairMoves(added, candidates)
+	got := map[string]int64{}
+	for _, m := range moves {
+		got[m.file.Path] = m.from.ID
+	}
+
+	// a/notes.txt keeps its name and old/renamed.txt its directory; with
+	// three copies of the content, b/copy.txt matches neither and stays
+	// added. Content found once pairs by content alone; empty files never pair.
+	want := map[string]int64{"a/notes.txt": 2, "old/renamed.txt": 1, "e/report.pdf": 5}
+	if len(got) != len(want) {
+		t.Errorf("expected moves %v, got %v", want, got)
+	}
+	for path, id := range want {
+		if got[path] != id {
+			t.Errorf("expected %s to move from %d, got %d", path, id, got[path])
+		}
+	}
+}
+
+func TestPairUnhashedMoves(t *testing.T) {
+	added := []*FileRecord{
+		{Path: "new/photo.jpg", Size: 9, FileType: database.FileTypeRegular},
+		{Path: "x/dup.txt", Size: 3, FileType: database.FileTypeRegular},
+		{Path: "y/dup.txt", Size: 3, FileType: database.FileTypeRegular},
+		{Path: "new/hashed.txt", Size: 4, Checksum: "ff", FileType: database.FileTypeRegular},
+	}
+	held := []*database.File{
+		deletedFile(1, "old/photo.jpg", "aa", 9),
+		deletedFile(2, "old/dup.txt", "bb", 3),
+		deletedFile(3, "old/hashed.txt", "cc", 4),
+	}
+
+	moves := pairUnhashedMoves(added, held)
+	if len(moves) != 1 || moves[0].file.Path != "new/photo.jpg" || moves[0].from.ID != 1 {
+		t.Errorf("expected only the unambiguous photo.jpg to pair, got %+v", moves)
+	}
+}
@@ -3,6 +3,7 @@ package scanner
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jeffanddom/fixity/internal/checksum"
@@ -20,24 +21,48 @@ type Engine struct {
 type Config struct {
 	ChecksumAlgorithm   checksum.Algorithm
 	ParallelWorkers     int
+	AdaptiveWorkers     bool // Resize the checksum pool between MinWorkers and MaxWorkers at runtime
+	MinWorkers          int
+	MaxWorkers          int
 	RandomSamplePercent float64
 	CheckpointInterval  int // Checkpoint every N files
 	BatchSize           int // Database batch size
 	FileTimeout         time.Duration
+	FileRetries         int           // Retries for transient hashing failures (negative disables)
+	RetryBackoff        time.Duration // Delay before the first retry, doubled each time
+	ReadSize            int           // Bytes per read while hashing (0 uses the default)
+	ScanArchives        bool          // Also hash the members of ZIP and TAR archives
+	ValidateBags        bool          // Validate BagIt bags found by each scan
 }
 
+// maxErrorMessages caps how many messages are kept on the scan record itself;
+// every error is still recorded in scan_errors
+const maxErrorMessages = 100
+
 // ScanResult contains the results of a scan
 type ScanResult struct {
-	ScanID        int64
-	FilesScanned  int64
-	FilesAdded    int64
-	FilesDeleted  int64
-	FilesModified int64
-	FilesVerified int64
-	ErrorsCount   int
-	Errors        []string
-	IsLargeChange bool
-	Duration      time.Duration
+	ScanID               int64
+	FilesScanned         int64
+	FilesAdded           int64
+	FilesDeleted         int64
+	FilesModified        int64
+	FilesVerified        int64
+	FilesFailed          int64 // Files that could not be hashed
+	FilesMismatched      int64 // Verified files whose content no longer matches the stored checksum
+	FilesChanged         int64 // Files a verification scan left for discovery, having changed since it last saw them
+	FilesSkipped         int64 // Sockets, FIFOs and devices left out of the scan
+	FilesMetadataChanged int64 // Files whose permissions, ownership or xattrs changed but content did not
+	FilesMoved           int64 // Deleted files found again at a new path
+	PathsUnreadable      int64 // Paths the walk could not read; their contents are unknown
+	DirsSkipped          int64 // Unchanged directories whose files were not read
+	BagsValidated        int64 // BagIt bags checked against their manifests
+	BagsInvalid          int64 // Validated bags with missing, extra or mismatched files
+	BudgetExhausted      bool  // Verification stopped at its time or byte budget
+	ErrorsCount          int
+	Errors               []string
+	IsLargeChange        bool
+	Duration             time.Duration
+	WorkerStats          checksum.PoolStats // Checksum pool concurrency and throughput
 }
 
 // FileRecord represents a file discovered during scanning
@@ -52,6 +77,24 @@ type FileRecord struct {
 	IsModified       bool
 	IsVerified       bool
 	PreviousChecksum string
+	FileType         database.FileType // Regular file, or a symlink recorded as a link
+	LinkTarget       string            // Target path of a recorded symlink
+	Device           uint64            // Device, inode and link count identify
+	Inode            uint64            // hardlinked names so they are hashed once
+	Links            uint64
+	Metadata         database.FileMetadata // Permissions, ownership and xattrs as walked
+	ServerChecksum   string                // Checksum kept by the server, as "algorithm:hex"
+
+	// Members of an archive, read on the hashing worker when archives are scanned
+	archiveRead    bool
+	archiveMembers []*archiveMember
+	archiveErr     error
+}
+
+// mismatched reports whether an unchanged file sampled for verification
+// hashed to something other than its stored checksum
+func (f *FileRecord) mismatched() bool {
+	return !f.IsNew && !f.IsModified && f.PreviousChecksum != "" && f.Checksum != "" && f.Checksum != f.PreviousChecksum
 }
 
 // NewEngine creates a new scanner engine
@@ -60,6 +103,17 @@ func NewEngine(db *database.Database, config Config) *Engine {
 	if config.ParallelWorkers <= 0 {
 		config.ParallelWorkers = 4
 	}
+	if config.AdaptiveWorkers {
+		if config.MinWorkers <= 0 {
+			config.MinWorkers = 1
+		}
+		if config.MaxWorkers <= 0 {
+			config.MaxWorkers = config.ParallelWorkers * 4
+		}
+		if config.MaxWorkers < config.MinWorkers {
+			config.MaxWorkers = config.MinWorkers
+		}
+	}
 	if config.RandomSamplePercent <= 0 {
 		config.RandomSamplePercent = 1.0
 	}
@@ -72,6 +126,12 @@ func NewEngine(db *database.Database, config Config) *Engine {
 	if config.FileTimeout <= 0 {
 		config.FileTimeout = 5 * time.Minute
 	}
+	if config.FileRetries == 0 {
+		config.FileRetries = 2
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = time.Second
+	}
 	if config.ChecksumAlgorithm == "" {
 		config.ChecksumAlgorithm = checksum.AlgorithmSHA256
 	}
@@ -97,6 +157,15 @@ func (e *Engine) Scan(ctx context.Context, targetID int64, backend storage.Stora
 		return nil, fmt.Errorf("storage backend not accessible: %w", err)
 	}
 
+	// Targets that skip unchanged directories need what the last walk saw
+	var dirs *dirTracker
+	if storage.CanSkipDirs(backend) && target.SkipUnchangedDirs {
+		dirs, err = e.newDirTracker(ctx, target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load directories: %w", err)
+		}
+	}
+
 	// Create scan record
 	scan := &database.Scan{
 		StorageTargetID: targetID,
@@ -113,12 +182,12 @@ func (e *Engine) Scan(ctx context.Context, targetID int64, backend storage.Stora
 	}
 
 	// Create and start checksum worker pool for this scan
-	checksumPool := checksum.NewWorkerPool(e.config.ParallelWorkers)
+	checksumPool := e.newChecksumPool()
 	checksumPool.Start()
 	defer checksumPool.Stop()
 
 	// Scan directory tree
-	currentFiles, err := e.scanDirectory(ctx, backend, scan.ID, result)
+	currentFiles, unreadable, err := e.scanDirectory(ctx, backend, scan.ID, dirs, result)
 	if err != nil {
 		e.finalizeScan(ctx, scan, result, database.ScanStatusFailed)
 		return nil, fmt.Errorf("failed to scan directory: %w", err)
@@ -130,55 +199,133 @@ func (e *Engine) Scan(ctx context.Context, targetID int64, backend storage.Stora
 		e.finalizeScan(ctx, scan, result, database.ScanStatusFailed)
 		return nil, fmt.Errorf("failed to load previous files: %w", err)
 	}
+	if dirs != nil {
+		dirs.carryOver(currentFiles, previousFiles, backend)
+	}
 
 	// Detect changes
-	changes, err := e.detectChanges(ctx, currentFiles, previousFiles, scan.ID, checksumPool, backend, target)
+	changes, err := e.detectChanges(ctx, currentFiles, previousFiles, unreadable, scan.ID, checksumPool, backend, target, result)
 	if err != nil {
 		e.finalizeScan(ctx, scan, result, database.ScanStatusFailed)
 		return nil, fmt.Errorf("failed to detect changes: %w", err)
 	}
 
+	if dirs != nil {
+		if err := dirs.save(ctx, e.db, targetID, unreadable, changes); err != nil {
+			result.addError(err.Error())
+		}
+	}
+
+	// Check the bags found against their manifests
+	if e.config.ValidateBags {
+		if err := e.validateBags(ctx, scan.ID, targetID, currentFiles, previousFiles, backend, result); err != nil {
+			e.finalizeScan(ctx, scan, result, database.ScanStatusFailed)
+			return nil, fmt.Errorf("failed to validate bags: %w", err)
+		}
+	}
+
 	// Update counters
+	result.WorkerStats = checksumPool.Stats()
 	result.FilesAdded = int64(len(changes.Added))
 	result.FilesDeleted = int64(len(changes.Deleted))
 	result.FilesModified = int64(len(changes.Modified))
+	result.FilesMetadataChanged = int64(len(changes.MetadataChanged))
+	result.FilesMoved = int64(len(changes.Moved))
 
 	// Check for large changes
 	result.IsLargeChange = e.isLargeChange(target, changes, len(currentFiles))
 
-	// Finalize scan
+	// Finalize scan; files that could not be read or hashed make the scan partial
+	status := database.ScanStatusCompleted
+	if result.FilesFailed > 0 || result.PathsUnreadable > 0 {
+		status = database.ScanStatusPartial
+	}
+
 	result.Duration = time.Since(start)
-	e.finalizeScan(ctx, scan, result, database.ScanStatusCompleted)
+	e.finalizeScan(ctx, scan, result, status)
 
 	return result, nil
 }
 
-// scanDirectory walks the directory tree and discovers all files
-func (e *Engine) scanDirectory(ctx context.Context, backend storage.StorageBackend, scanID int64, result *ScanResult) (map[string]*FileRecord, error) {
+// newChecksumPool creates the checksum worker pool described by the config
+func (e *Engine) newChecksumPool() *checksum.WorkerPool {
+	if e.config.AdaptiveWorkers {
+		return checksum.NewAdaptiveWorkerPool(checksum.AdaptiveConfig{
+			MinWorkers: e.config.MinWorkers,
+			MaxWorkers: e.config.MaxWorkers,
+			Initial:    e.config.ParallelWorkers,
+		})
+	}
+	return checksum.NewWorkerPool(e.config.ParallelWorkers)
+}
+
+// scanDirectory walks the directory tree and discovers all files. It also
+// returns the paths that could not be read; whatever lies at or beneath them
+// is unknown for this scan. With a dirTracker, the files of unchanged
+// directories are left out of the walk.
+func (e *Engine) scanDirectory(ctx context.Context, backend storage.StorageBackend, scanID int64, dirs *dirTracker, result *ScanResult) (map[string]*FileRecord, []string, error) {
 	files := make(map[string]*FileRecord)
+	skipped := []*database.ScanError{}
+	unreadable := []string{}
 	fileCount := 0
 
-	err := backend.Walk(ctx, func(path string, info *storage.FileInfo) error {
+	walk := backend.Walk
+	if dirs != nil {
+		walk = func(ctx context.Context, fn storage.WalkFunc) error {
+			return storage.WalkSkipping(ctx, backend, dirs.unchangedDir, fn)
+		}
+	}
+
+	err := walk(ctx, func(path string, info *storage.FileInfo) error {
+		// Record unreadable paths rather than letting their files look deleted
+		if info.Err != nil {
+			unreadable = append(unreadable, path)
+			result.PathsUnreadable++
+			result.addError(fmt.Sprintf("walk error: %v", info.Err))
+			skipped = append(skipped, &database.ScanError{
+				ScanID:     scanID,
+				Path:       path,
+				Phase:      database.ScanErrorPhaseWalk,
+				ErrorClass: string(checksum.ClassifyError(info.Err)),
+				Message:    info.Err.Error(),
+				Retryable:  checksum.IsRetryable(info.Err),
+				Attempts:   1,
+			})
+			return nil
+		}
+
 		// Skip directories
 		if info.IsDir {
+			if dirs != nil {
+				dirs.record(path, info, result)
+			}
+			return nil
+		}
+
+		// Sockets, FIFOs and devices have no content to hash; note them so
+		// their absence from the database is explained
+		if info.Type.IsSpecial() {
+			result.FilesSkipped++
+			skipped = append(skipped, &database.ScanError{
+				ScanID:     scanID,
+				Path:       path,
+				Phase:      database.ScanErrorPhaseSkip,
+				ErrorClass: string(info.Type),
+				Message:    fmt.Sprintf("skipped %s: no content to hash", info.Type),
+				Attempts:   1,
+			})
 			return nil
 		}
 
 		fileCount++
 		result.FilesScanned++
 
-		// Create file record
-		files[path] = &FileRecord{
-			Path:    path,
-			Size:    info.Size,
-			ModTime: info.ModTime,
-		}
+		files[path] = newFileRecord(path, info)
 
 		// Checkpoint periodically
 		if fileCount%e.config.CheckpointInterval == 0 {
 			if err := e.saveCheckpoint(ctx, scanID, path, int64(fileCount)); err != nil {
-				result.Errors = append(result.Errors, fmt.Sprintf("checkpoint error: %v", err))
-				result.ErrorsCount++
+				result.addError(fmt.Sprintf("checkpoint error: %v", err))
 			}
 		}
 
@@ -186,10 +333,67 @@ func (e *Engine) scanDirectory(ctx context.Context, backend storage.StorageBacke
 	})
 
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return files, nil
+	if err := e.db.ScanErrors.CreateBatch(ctx, skipped); err != nil {
+		return nil, nil, fmt.Errorf("failed to record walk errors: %w", err)
+	}
+
+	return files, unreadable, nil
+}
+
+// newFileRecord creates the record of a walked file
+func newFileRecord(path string, info *storage.FileInfo) *FileRecord {
+	record := &FileRecord{
+		Path:     path,
+		Size:     info.Size,
+		ModTime:  info.ModTime,
+		FileType: database.FileTypeRegular,
+		Device:   info.Device,
+		Inode:    info.Inode,
+		Links:    info.Links,
+
+		ServerChecksum: info.ServerChecksum,
+	}
+	if info.Type == storage.FileTypeSymlink {
+		record.FileType = database.FileTypeSymlink
+		record.LinkTarget = info.LinkTarget
+	}
+
+	if info.HasMode {
+		mode := int(info.Mode)
+		record.Metadata.Mode = &mode
+	}
+	if info.HasOwner {
+		uid, gid := int64(info.UID), int64(info.GID)
+		record.Metadata.UID = &uid
+		record.Metadata.GID = &gid
+	}
+	if info.XattrDigest != "" {
+		digest := info.XattrDigest
+		record.Metadata.XattrDigest = &digest
+	}
+
+	return record
+}
+
+// underAny reports whether path is one of prefixes or lies beneath one
+func underAny(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// addError counts an error and keeps its message, up to maxErrorMessages
+func (r *ScanResult) addError(msg string) {
+	r.ErrorsCount++
+	if len(r.Errors) < maxErrorMessages {
+		r.Errors = append(r.Errors, msg)
+	}
 }
 
 // loadPreviousFiles loads file records from the previous scan
@@ -232,6 +436,27 @@ func (e *Engine) finalizeScan(ctx context.Context, scan *database.Scan, result *
 	scan.FilesVerified = result.FilesVerified
 	scan.ErrorsCount = result.ErrorsCount
 	scan.IsLargeChange = result.IsLargeChange
+	scan.FilesMismatched = result.FilesMismatched
+	scan.BudgetExhausted = result.BudgetExhausted
+	scan.FilesSkipped = result.FilesSkipped
+	scan.DirsSkipped = result.DirsSkipped
+	scan.FilesMetadataChanged = result.FilesMetadataChanged
+	scan.FilesMoved = result.FilesMoved
+
+	// Record the checksum pool's concurrency so targets can be tuned from data
+	if stats := result.WorkerStats; stats.Concurrency > 0 {
+		concurrency := stats.Concurrency
+		avg := stats.AvgConcurrency
+		peak := stats.PeakConcurrency
+		scan.WorkerConcurrency = &concurrency
+		scan.WorkerConcurrencyAvg = &avg
+		scan.WorkerConcurrencyPeak = &peak
+		scan.BytesHashed = stats.BytesHashed
+		if stats.Active > 0 {
+			throughput := stats.BytesPerSec()
+			scan.HashBytesPerSec = &throughput
+		}
+	}
 
 	// Store errors
 	if len(result.Errors) > 0 {
@@ -244,7 +469,7 @@ func (e *Engine) finalizeScan(ctx context.Context, scan *database.Scan, result *
 
 // isLargeChange determines if the changes exceed configured thresholds
 func (e *Engine) isLargeChange(target *database.StorageTarget, changes *ChangeSet, totalFiles int) bool {
-	totalChanges := len(changes.Added) + len(changes.Deleted) + len(changes.Modified)
+	totalChanges := len(changes.Added) + len(changes.Deleted) + len(changes.Modified) + len(changes.Moved)
 
 	// Check count threshold
 	if target.LargeChangeThresholdCount != nil && totalChanges > *target.LargeChangeThresholdCount {
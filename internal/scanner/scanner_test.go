@@ -1,10 +1,18 @@
 package scanner_test
 
 import (
+	"archive/zip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"testing"
 	"time"
 
@@ -168,6 +176,48 @@ func TestEngine_Scan(t *testing.T) {
 		}
 	})
 
+	t.Run("records worker concurrency with adaptive pool", func(t *testing.T) {
+		target := testutil.MustCreateStorageTarget(t, db, "test-target-adaptive")
+
+		tmpDir := setupTestDirectory(t)
+		backend, _ := storage.NewLocalFSBackend(tmpDir)
+
+		config := scanner.Config{
+			ChecksumAlgorithm: checksum.AlgorithmMD5,
+			ParallelWorkers:   2,
+			AdaptiveWorkers:   true,
+			MinWorkers:        1,
+			MaxWorkers:        4,
+		}
+		engine := scanner.NewEngine(db, config)
+
+		result, err := engine.Scan(context.Background(), target.ID, backend)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !result.WorkerStats.Adaptive {
+			t.Error("expected adaptive worker stats")
+		}
+
+		scan, err := db.Scans.GetByID(context.Background(), result.ScanID)
+		if err != nil {
+			t.Fatalf("failed to get scan record: %v", err)
+		}
+
+		if scan.WorkerConcurrency == nil {
+			t.Fatal("expected worker concurrency to be recorded")
+		}
+
+		if *scan.WorkerConcurrency < 1 || *scan.WorkerConcurrency > 4 {
+			t.Errorf("worker concurrency %d outside configured bounds", *scan.WorkerConcurrency)
+		}
+
+		if scan.BytesHashed == 0 {
+			t.Error("expected bytes hashed to be recorded")
+		}
+	})
+
 	t.Run("returns error for non-existent storage target", func(t *testing.T) {
 		tmpDir := t.TempDir()
 		backend, _ := storage.NewLocalFSBackend(tmpDir)
@@ -345,6 +395,916 @@ func TestEngine_Checkpointing(t *testing.T) {
 
 // Helper functions
 
+func TestEngine_HashErrors(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupDB(t, db)
+
+	t.Run("records unreadable files and marks scan partial", func(t *testing.T) {
+		target := testutil.MustCreateStorageTarget(t, db, "test-target")
+
+		tmpDir := setupTestDirectory(t)
+		localBackend, _ := storage.NewLocalFSBackend(tmpDir)
+		backend := &failingBackend{
+			StorageBackend: localBackend,
+			failName:       "file2.txt",
+			err:            os.ErrPermission,
+		}
+
+		config := scanner.Config{
+			ChecksumAlgorithm: checksum.AlgorithmMD5,
+			ParallelWorkers:   2,
+			RetryBackoff:      time.Millisecond,
+		}
+		engine := scanner.NewEngine(db, config)
+
+		result, err := engine.Scan(context.Background(), target.ID, backend)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if result.FilesFailed != 1 {
+			t.Errorf("expected 1 failed file, got %d", result.FilesFailed)
+		}
+
+		scan, err := db.Scans.GetByID(context.Background(), result.ScanID)
+		if err != nil {
+			t.Fatalf("failed to get scan record: %v", err)
+		}
+
+		if scan.Status != database.ScanStatusPartial {
+			t.Errorf("expected status partial, got %s", scan.Status)
+		}
+
+		if len(scan.ErrorMessages) == 0 {
+			t.Error("expected error message on scan record")
+		}
+
+		scanErrors, err := db.ScanErrors.GetByScan(context.Background(), result.ScanID)
+		if err != nil {
+			t.Fatalf("failed to get scan errors: %v", err)
+		}
+
+		if len(scanErrors) != 1 {
+			t.Fatalf("expected 1 scan error, got %d", len(scanErrors))
+		}
+
+		if scanErrors[0].ErrorClass != string(checksum.ErrorClassPermissionDenied) {
+			t.Errorf("expected permission_denied, got %s", scanErrors[0].ErrorClass)
+		}
+
+		if scanErrors[0].Retryable {
+			t.Error("permission errors should not be retryable")
+		}
+	})
+
+	t.Run("retries transient failures", func(t *testing.T) {
+		target := testutil.MustCreateStorageTarget(t, db, "test-target-2")
+
+		tmpDir := setupTestDirectory(t)
+		localBackend, _ := storage.NewLocalFSBackend(tmpDir)
+		backend := &failingBackend{
+			StorageBackend: localBackend,
+			failName:       "file1.txt",
+			err:            syscall.EIO,
+			failTimes:      1,
+		}
+
+		config := scanner.Config{
+			ChecksumAlgorithm: checksum.AlgorithmMD5,
+			ParallelWorkers:   2,
+			FileRetries:       2,
+			RetryBackoff:      time.Millisecond,
+		}
+		engine := scanner.NewEngine(db, config)
+
+		result, err := engine.Scan(context.Background(), target.ID, backend)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if result.FilesFailed != 0 {
+			t.Errorf("expected transient failure to be retried, got %d failed files", result.FilesFailed)
+		}
+
+		scan, _ := db.Scans.GetByID(context.Background(), result.ScanID)
+		if scan.Status != database.ScanStatusCompleted {
+			t.Errorf("expected status completed, got %s", scan.Status)
+		}
+	})
+}
+
+func TestEngine_LinksAndSpecialFiles(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupDB(t, db)
+
+	target := testutil.MustCreateStorageTarget(t, db, "test-target")
+
+	tmpDir := t.TempDir()
+	writeTestFile(t, filepath.Join(tmpDir, "a.txt"), "shared content")
+	if err := os.Link(filepath.Join(tmpDir, "a.txt"), filepath.Join(tmpDir, "b.txt")); err != nil {
+		t.Skipf("Cannot create hardlink: %v", err)
+	}
+	if err := os.Symlink("a.txt", filepath.Join(tmpDir, "link")); err != nil {
+		t.Skipf("Cannot create symlink: %v", err)
+	}
+	if err := syscall.Mkfifo(filepath.Join(tmpDir, "pipe"), 0644); err != nil {
+		t.Skipf("Cannot create FIFO: %v", err)
+	}
+
+	localBackend, err := storage.NewBackend(storage.BackendConfig{
+		Type:       storage.TypeLocal,
+		Path:       tmpDir,
+		LinkPolicy: storage.LinkPolicyRecord,
+	})
+	if err != nil {
+		t.Fatalf("failed to create backend: %v", err)
+	}
+	backend := &countingBackend{StorageBackend: localBackend}
+
+	engine := scanner.NewEngine(db, scanner.Config{
+		ChecksumAlgorithm: checksum.AlgorithmMD5,
+		ParallelWorkers:   2,
+	})
+
+	result, err := engine.Scan(context.Background(), target.ID, backend)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.FilesSkipped != 1 {
+		t.Errorf("expected the FIFO to be skipped, got %d skipped", result.FilesSkipped)
+	}
+	if result.FilesAdded != 3 {
+		t.Errorf("expected 3 files added, got %d", result.FilesAdded)
+	}
+	if opens := backend.opens.Load(); opens != 1 {
+		t.Errorf("expected hardlinked names to be read once, got %d opens", opens)
+	}
+
+	scanErrors, _ := db.ScanErrors.GetByScan(context.Background(), result.ScanID)
+	if len(scanErrors) != 1 || scanErrors[0].Phase != database.ScanErrorPhaseSkip || scanErrors[0].Path != "pipe" {
+		t.Errorf("expected a skip record for the FIFO, got %+v", scanErrors)
+	}
+
+	a, _ := db.Files.GetByPath(context.Background(), target.ID, "a.txt")
+	b, _ := db.Files.GetByPath(context.Background(), target.ID, "b.txt")
+	if a == nil || b == nil || *a.CurrentChecksum != *b.CurrentChecksum {
+		t.Error("expected hardlinked names to share a checksum")
+	}
+
+	link, _ := db.Files.GetByPath(context.Background(), target.ID, "link")
+	if link == nil {
+		t.Fatal("expected the symlink to be recorded")
+	}
+	if link.FileType != database.FileTypeSymlink || link.LinkTarget == nil || *link.LinkTarget != "a.txt" {
+		t.Errorf("expected a link to a.txt, got %s -> %v", link.FileType, link.LinkTarget)
+	}
+}
+
+func TestEngine_WalkErrors(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupDB(t, db)
+
+	target := testutil.MustCreateStorageTarget(t, db, "test-target")
+
+	tmpDir := setupTestDirectory(t)
+	localBackend, _ := storage.NewLocalFSBackend(tmpDir)
+
+	engine := scanner.NewEngine(db, scanner.Config{
+		ChecksumAlgorithm: checksum.AlgorithmMD5,
+		ParallelWorkers:   2,
+	})
+
+	if _, err := engine.Scan(context.Background(), target.ID, localBackend); err != nil {
+		t.Fatalf("first scan failed: %v", err)
+	}
+
+	// The subdirectory becomes unreadable: its files must not look deleted
+	backend := &unreadableDirBackend{StorageBackend: localBackend, dir: "subdir"}
+	result, err := engine.Scan(context.Background(), target.ID, backend)
+	if err != nil {
+		t.Fatalf("second scan failed: %v", err)
+	}
+
+	if result.FilesDeleted != 0 {
+		t.Errorf("expected no deletions under an unreadable directory, got %d", result.FilesDeleted)
+	}
+	if result.PathsUnreadable != 1 {
+		t.Errorf("expected 1 unreadable path, got %d", result.PathsUnreadable)
+	}
+
+	scan, _ := db.Scans.GetByID(context.Background(), result.ScanID)
+	if scan.Status != database.ScanStatusPartial {
+		t.Errorf("expected status partial, got %s", scan.Status)
+	}
+
+	scanErrors, _ := db.ScanErrors.GetByScan(context.Background(), result.ScanID)
+	if len(scanErrors) != 1 || scanErrors[0].Phase != database.ScanErrorPhaseWalk || scanErrors[0].Path != "subdir" {
+		t.Errorf("expected a walk error for subdir, got %+v", scanErrors)
+	}
+	if scanErrors[0].ErrorClass != string(checksum.ErrorClassIO) || !scanErrors[0].Retryable {
+		t.Errorf("expected a retryable io error, got %s", scanErrors[0].ErrorClass)
+	}
+
+	// Once readable again, a file that really went away is deleted, once
+	if err := os.Remove(filepath.Join(tmpDir, "subdir", "file3.txt")); err != nil {
+		t.Fatalf("failed to remove file: %v", err)
+	}
+	result, err = engine.Scan(context.Background(), target.ID, localBackend)
+	if err != nil {
+		t.Fatalf("third scan failed: %v", err)
+	}
+	if result.FilesDeleted != 1 {
+		t.Errorf("expected 1 deletion, got %d", result.FilesDeleted)
+	}
+
+	result, _ = engine.Scan(context.Background(), target.ID, localBackend)
+	if result.FilesDeleted != 0 {
+		t.Errorf("expected a deleted file to be reported only once, got %d", result.FilesDeleted)
+	}
+}
+
+func TestEngine_Verify(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupDB(t, db)
+
+	config := scanner.Config{
+		ChecksumAlgorithm: checksum.AlgorithmSHA256,
+		ParallelWorkers:   2,
+	}
+
+	t.Run("verifies known files without walking", func(t *testing.T) {
+		target := testutil.MustCreateStorageTarget(t, db, "verify-target")
+		tmpDir := setupTestDirectory(t)
+		backend, _ := storage.NewLocalFSBackend(tmpDir)
+		engine := scanner.NewEngine(db, config)
+
+		if _, err := engine.Scan(context.Background(), target.ID, backend); err != nil {
+			t.Fatalf("discovery scan failed: %v", err)
+		}
+
+		// A file added after discovery is not picked up by verification
+		writeTestFile(t, filepath.Join(tmpDir, "new.txt"), "new")
+
+		result, err := engine.Verify(context.Background(), target.ID, backend, scanner.VerifyBudget{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if result.FilesVerified != 3 {
+			t.Errorf("expected 3 files verified, got %d", result.FilesVerified)
+		}
+		if result.FilesMismatched != 0 {
+			t.Errorf("expected no mismatches, got %d", result.FilesMismatched)
+		}
+		if result.BudgetExhausted {
+			t.Error("expected verification to finish without a budget")
+		}
+
+		scan, _ := db.Scans.GetByID(context.Background(), result.ScanID)
+		if scan.ScanType != database.ScanTypeVerification {
+			t.Errorf("expected verification scan type, got %s", scan.ScanType)
+		}
+
+		events, _ := db.ChangeEvents.GetByScan(context.Background(), result.ScanID)
+		if len(events) != 3 {
+			t.Errorf("expected 3 verification events, got %d", len(events))
+		}
+	})
+
+	t.Run("flags silent corruption", func(t *testing.T) {
+		target := testutil.MustCreateStorageTarget(t, db, "verify-corrupt-target")
+		tmpDir := setupTestDirectory(t)
+		backend, _ := storage.NewLocalFSBackend(tmpDir)
+		engine := scanner.NewEngine(db, config)
+
+		if _, err := engine.Scan(context.Background(), target.ID, backend); err != nil {
+			t.Fatalf("discovery scan failed: %v", err)
+		}
+
+		// Change content without changing size or modification time
+		path := filepath.Join(tmpDir, "file1.txt")
+		info, _ := os.Stat(path)
+		writeTestFile(t, path, "CONTENT1")
+		os.Chtimes(path, info.ModTime(), info.ModTime())
+
+		stored, _ := db.Files.GetByPath(context.Background(), target.ID, "file1.txt")
+		if stored == nil || stored.CurrentChecksum == nil {
+			t.Fatal("expected file1.txt to have a stored checksum")
+		}
+
+		result, err := engine.Verify(context.Background(), target.ID, backend, scanner.VerifyBudget{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if result.FilesMismatched != 1 {
+			t.Errorf("expected 1 mismatch, got %d", result.FilesMismatched)
+		}
+		if result.FilesVerified != 2 {
+			t.Errorf("expected 2 files verified, got %d", result.FilesVerified)
+		}
+
+		// The known-good checksum is kept
+		after, _ := db.Files.GetByPath(context.Background(), target.ID, "file1.txt")
+		if *after.CurrentChecksum != *stored.CurrentChecksum {
+			t.Error("expected stored checksum to be kept after a mismatch")
+		}
+	})
+
+	t.Run("leaves files edited since discovery to the next scan", func(t *testing.T) {
+		target := testutil.MustCreateStorageTarget(t, db, "verify-edited-target")
+		tmpDir := setupTestDirectory(t)
+		backend, _ := storage.NewLocalFSBackend(tmpDir)
+		engine := scanner.NewEngine(db, config)
+
+		if _, err := engine.Scan(context.Background(), target.ID, backend); err != nil {
+			t.Fatalf("discovery scan failed: %v", err)
+		}
+
+		// One file is rewritten, another keeps its size but is touched later
+		writeTestFile(t, filepath.Join(tmpDir, "file1.txt"), "edited by a user")
+		later := time.Now().Add(time.Hour)
+		writeTestFile(t, filepath.Join(tmpDir, "file2.txt"), "CONTENT2")
+		os.Chtimes(filepath.Join(tmpDir, "file2.txt"), later, later)
+		stored, _ := db.Files.GetByPath(context.Background(), target.ID, "file1.txt")
+
+		result, err := engine.Verify(context.Background(), target.ID, backend, scanner.VerifyBudget{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.FilesChanged != 2 || result.FilesMismatched != 0 || result.FilesVerified != 1 {
+			t.Errorf("expected 2 changed, 0 mismatched and 1 verified, got %d, %d and %d",
+				result.FilesChanged, result.FilesMismatched, result.FilesVerified)
+		}
+
+		after, _ := db.Files.GetByPath(context.Background(), target.ID, "file1.txt")
+		if *after.CurrentChecksum != *stored.CurrentChecksum || !after.LastChecksummedAt.Equal(*stored.LastChecksummedAt) {
+			t.Error("expected an edited file to be left as discovered")
+		}
+	})
+
+	t.Run("verifies recorded symlinks as links", func(t *testing.T) {
+		target := testutil.MustCreateStorageTarget(t, db, "verify-link-target")
+		tmpDir := t.TempDir()
+		writeTestFile(t, filepath.Join(tmpDir, "a.txt"), "content")
+		if err := os.Symlink("a.txt", filepath.Join(tmpDir, "link")); err != nil {
+			t.Skipf("Cannot create symlink: %v", err)
+		}
+		backend, err := storage.NewBackend(storage.BackendConfig{
+			Type:       storage.TypeLocal,
+			Path:       tmpDir,
+			LinkPolicy: storage.LinkPolicyRecord,
+		})
+		if err != nil {
+			t.Fatalf("failed to create backend: %v", err)
+		}
+		engine := scanner.NewEngine(db, config)
+
+		if _, err := engine.Scan(context.Background(), target.ID, backend); err != nil {
+			t.Fatalf("discovery scan failed: %v", err)
+		}
+
+		result, err := engine.Verify(context.Background(), target.ID, backend, scanner.VerifyBudget{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if result.FilesVerified != 2 || result.FilesMismatched != 0 {
+			t.Errorf("expected 2 files verified and no mismatches, got %d and %d", result.FilesVerified, result.FilesMismatched)
+		}
+		if len(result.Errors) != 0 {
+			t.Errorf("expected no errors, got %v", result.Errors)
+		}
+	})
+
+	t.Run("stops at byte budget", func(t *testing.T) {
+		target := testutil.MustCreateStorageTarget(t, db, "verify-budget-target")
+		tmpDir := setupTestDirectory(t)
+		backend, _ := storage.NewLocalFSBackend(tmpDir)
+		engine := scanner.NewEngine(db, config)
+
+		if _, err := engine.Scan(context.Background(), target.ID, backend); err != nil {
+			t.Fatalf("discovery scan failed: %v", err)
+		}
+
+		result, err := engine.Verify(context.Background(), target.ID, backend, scanner.VerifyBudget{Bytes: 1})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if result.FilesVerified != 1 {
+			t.Errorf("expected 1 file verified within budget, got %d", result.FilesVerified)
+		}
+		if !result.BudgetExhausted {
+			t.Error("expected budget to be exhausted")
+		}
+	})
+}
+
+func TestEngine_ScanPaths(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupDB(t, db)
+
+	target := testutil.MustCreateStorageTarget(t, db, "test-target")
+
+	tmpDir := setupTestDirectory(t)
+	writeTestFile(t, filepath.Join(tmpDir, "subdir", "file4.txt"), "content4")
+	backend, _ := storage.NewLocalFSBackend(tmpDir)
+
+	engine := scanner.NewEngine(db, scanner.Config{
+		ChecksumAlgorithm:   checksum.AlgorithmMD5,
+		ParallelWorkers:     2,
+		RandomSamplePercent: 0.01,
+	})
+
+	if _, err := engine.Scan(context.Background(), target.ID, backend); err != nil {
+		t.Fatalf("first scan failed: %v", err)
+	}
+
+	// Modify one file, add another and remove a whole directory
+	writeTestFile(t, filepath.Join(tmpDir, "file1.txt"), "modified content")
+	writeTestFile(t, filepath.Join(tmpDir, "file5.txt"), "content5")
+	if err := os.RemoveAll(filepath.Join(tmpDir, "subdir")); err != nil {
+		t.Fatalf("failed to remove directory: %v", err)
+	}
+
+	counting := &countingBackend{StorageBackend: backend}
+	result, err := engine.ScanPaths(context.Background(), target.ID, counting, []string{"file1.txt", "file5.txt", "subdir"})
+	if err != nil {
+		t.Fatalf("incremental scan failed: %v", err)
+	}
+
+	if result.FilesAdded != 1 || result.FilesModified != 1 || result.FilesDeleted != 2 {
+		t.Errorf("expected 1 added, 1 modified, 2 deleted, got %d, %d, %d",
+			result.FilesAdded, result.FilesModified, result.FilesDeleted)
+	}
+	if opens := counting.opens.Load(); opens != 2 {
+		t.Errorf("expected only the changed files to be hashed, got %d opens", opens)
+	}
+
+	scan, _ := db.Scans.GetByID(context.Background(), result.ScanID)
+	if scan.ScanType != database.ScanTypeIncremental || scan.Status != database.ScanStatusCompleted {
+		t.Errorf("expected a completed incremental scan, got %s %s", scan.ScanType, scan.Status)
+	}
+
+	// Files that were not queued are left alone
+	if file, _ := db.Files.GetByPath(context.Background(), target.ID, "file2.txt"); file == nil || file.DeletedAt != nil {
+		t.Error("expected file2.txt to remain active")
+	}
+	if file, _ := db.Files.GetByPath(context.Background(), target.ID, "subdir/file3.txt"); file == nil || file.DeletedAt == nil {
+		t.Error("expected subdir/file3.txt to be marked deleted")
+	}
+}
+
+func TestEngine_SkipUnchangedDirs(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupDB(t, db)
+
+	ctx := context.Background()
+	target := testutil.MustCreateStorageTarget(t, db, "test-target")
+	target.SkipUnchangedDirs = true
+	if err := db.StorageTargets.Update(ctx, target); err != nil {
+		t.Fatalf("failed to update target: %v", err)
+	}
+
+	tmpDir := setupTestDirectory(t)
+	os.Mkdir(filepath.Join(tmpDir, "archive"), 0755)
+	writeTestFile(t, filepath.Join(tmpDir, "archive", "a.txt"), "a")
+	writeTestFile(t, filepath.Join(tmpDir, "archive", "b.txt"), "b")
+	backend, _ := storage.NewLocalFSBackend(tmpDir)
+
+	engine := scanner.NewEngine(db, scanner.Config{
+		ChecksumAlgorithm:   checksum.AlgorithmMD5,
+		ParallelWorkers:     2,
+		RandomSamplePercent: 0.01,
+	})
+
+	first, err := engine.Scan(ctx, target.ID, backend)
+	if err != nil {
+		t.Fatalf("first scan failed: %v", err)
+	}
+	if first.DirsSkipped != 0 {
+		t.Errorf("expected the first scan to walk every directory, got %d skipped", first.DirsSkipped)
+	}
+
+	writeTestFile(t, filepath.Join(tmpDir, "subdir", "file4.txt"), "content4")
+
+	result, err := engine.Scan(ctx, target.ID, backend)
+	if err != nil {
+		t.Fatalf("second scan failed: %v", err)
+	}
+
+	if result.DirsSkipped != 1 {
+		t.Errorf("expected the archive directory to be skipped, got %d skipped", result.DirsSkipped)
+	}
+	if result.FilesAdded != 1 || result.FilesDeleted != 0 {
+		t.Errorf("expected 1 added and 0 deleted, got %d and %d", result.FilesAdded, result.FilesDeleted)
+	}
+	if result.FilesScanned != first.FilesScanned-1 {
+		t.Errorf("expected the archive files not to be read, got %d scanned after %d", result.FilesScanned, first.FilesScanned)
+	}
+	if file, _ := db.Files.GetByPath(ctx, target.ID, "archive/a.txt"); file == nil || file.DeletedAt != nil {
+		t.Error("expected files of a skipped directory to remain active")
+	}
+
+	scan, _ := db.Scans.GetByID(ctx, result.ScanID)
+	if scan.DirsSkipped != 1 {
+		t.Errorf("expected the scan to record 1 skipped directory, got %d", scan.DirsSkipped)
+	}
+}
+
+func TestEngine_MetadataChanged(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupDB(t, db)
+
+	ctx := context.Background()
+	target := testutil.MustCreateStorageTarget(t, db, "test-target")
+
+	tmpDir := setupTestDirectory(t)
+	filePath := filepath.Join(tmpDir, "file1.txt")
+	if err := os.Chmod(filePath, 0644); err != nil {
+		t.Fatalf("failed to chmod: %v", err)
+	}
+	backend, _ := storage.NewLocalFSBackend(tmpDir)
+
+	engine := scanner.NewEngine(db, scanner.Config{
+		ChecksumAlgorithm: checksum.AlgorithmMD5,
+		ParallelWorkers:   2,
+	})
+
+	if _, err := engine.Scan(ctx, target.ID, backend); err != nil {
+		t.Fatalf("first scan failed: %v", err)
+	}
+
+	file, _ := db.Files.GetByPath(ctx, target.ID, "file1.txt")
+	if file == nil || file.Mode == nil || *file.Mode != 0644 {
+		t.Fatalf("expected mode 0644 to be recorded, got %+v", file)
+	}
+	info, _ := os.Stat(filePath)
+	if file.ModTime == nil || !file.ModTime.Equal(info.ModTime().Truncate(time.Microsecond)) {
+		t.Errorf("expected the file's mtime %v to be recorded, got %v", info.ModTime(), file.ModTime)
+	}
+
+	if err := os.Chmod(filePath, 0600); err != nil {
+		t.Fatalf("failed to chmod: %v", err)
+	}
+
+	result, err := engine.Scan(ctx, target.ID, backend)
+	if err != nil {
+		t.Fatalf("second scan failed: %v", err)
+	}
+	if result.FilesModified != 0 {
+		t.Errorf("expected no modified files, got %d", result.FilesModified)
+	}
+	if result.FilesMetadataChanged != 1 {
+		t.Errorf("expected 1 file with changed metadata, got %d", result.FilesMetadataChanged)
+	}
+
+	file, _ = db.Files.GetByPath(ctx, target.ID, "file1.txt")
+	if file.Mode == nil || *file.Mode != 0600 {
+		t.Errorf("expected mode 0600 to be recorded, got %v", file.Mode)
+	}
+
+	events, _ := db.ChangeEvents.GetByFile(ctx, file.ID)
+	found := false
+	for _, event := range events {
+		if event.EventType == database.ChangeEventMetadataChanged {
+			found = true
+			if event.OldMetadata == nil || !strings.Contains(*event.OldMetadata, "mode 0644") {
+				t.Errorf("expected old metadata to show mode 0644, got %v", event.OldMetadata)
+			}
+			if event.NewMetadata == nil || !strings.Contains(*event.NewMetadata, "mode 0600") {
+				t.Errorf("expected new metadata to show mode 0600, got %v", event.NewMetadata)
+			}
+		}
+	}
+	if !found {
+		t.Error("expected a metadata_changed event")
+	}
+
+	scan, _ := db.Scans.GetByID(ctx, result.ScanID)
+	if scan.FilesMetadataChanged != 1 {
+		t.Errorf("expected the scan to record 1 metadata change, got %d", scan.FilesMetadataChanged)
+	}
+}
+
+func TestEngine_ArchiveMembers(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupDB(t, db)
+
+	ctx := context.Background()
+	target := testutil.MustCreateStorageTarget(t, db, "test-target")
+
+	tmpDir := t.TempDir()
+	archivePath := filepath.Join(tmpDir, "bundle.zip")
+	writeZIP := func(files map[string]string) {
+		f, err := os.Create(archivePath)
+		if err != nil {
+			t.Fatalf("failed to create archive: %v", err)
+		}
+		zw := zip.NewWriter(f)
+		for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+			if content, ok := files[name]; ok {
+				w, _ := zw.Create(name)
+				w.Write([]byte(content))
+			}
+		}
+		zw.Close()
+		f.Close()
+	}
+	writeZIP(map[string]string{"a.txt": "alpha", "b.txt": "bravo"})
+	backend, _ := storage.NewLocalFSBackend(tmpDir)
+
+	engine := scanner.NewEngine(db, scanner.Config{
+		ChecksumAlgorithm: checksum.AlgorithmMD5,
+		ParallelWorkers:   2,
+		ScanArchives:      true,
+	})
+
+	if _, err := engine.Scan(ctx, target.ID, backend); err != nil {
+		t.Fatalf("first scan failed: %v", err)
+	}
+
+	file, _ := db.Files.GetByPath(ctx, target.ID, "bundle.zip")
+	if file == nil {
+		t.Fatal("expected the archive to be recorded")
+	}
+	members, _ := db.ArchiveMembers.ListByFile(ctx, file.ID)
+	if len(members) != 2 || members[0].Path != "a.txt" || members[0].ChecksumType != "md5" {
+		t.Fatalf("expected a.txt and b.txt to be recorded, got %+v", members)
+	}
+
+	writeZIP(map[string]string{"a.txt": "alpha", "b.txt": "BRAVO", "c.txt": "charlie"})
+	later := time.Now().Add(time.Hour)
+	os.Chtimes(archivePath, later, later)
+
+	if _, err := engine.Scan(ctx, target.ID, backend); err != nil {
+		t.Fatalf("second scan failed: %v", err)
+	}
+
+	events, _ := db.ChangeEvents.GetByFile(ctx, file.ID)
+	got := map[string]database.ChangeEventType{}
+	for _, event := range events {
+		if event.MemberPath != nil {
+			got[*event.MemberPath] = event.EventType
+		}
+	}
+	if got["b.txt"] != database.ChangeEventMemberModified || got["c.txt"] != database.ChangeEventMemberAdded || len(got) != 2 {
+		t.Errorf("expected b.txt modified and c.txt added, got %v", got)
+	}
+
+	members, _ = db.ArchiveMembers.ListByFile(ctx, file.ID)
+	if len(members) != 3 {
+		t.Errorf("expected 3 members to be recorded, got %d", len(members))
+	}
+}
+
+func TestEngine_MovedFiles(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupDB(t, db)
+
+	ctx := context.Background()
+	target := testutil.MustCreateStorageTarget(t, db, "test-target")
+
+	tmpDir := t.TempDir()
+	os.Mkdir(filepath.Join(tmpDir, "old"), 0755)
+	os.Mkdir(filepath.Join(tmpDir, "new"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "old", "report.pdf"), []byte("quarterly report"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "old", "gone.txt"), []byte("removed"), 0644)
+	backend, _ := storage.NewLocalFSBackend(tmpDir)
+
+	engine := scanner.NewEngine(db, scanner.Config{
+		ChecksumAlgorithm: checksum.AlgorithmMD5,
+		ParallelWorkers:   2,
+	})
+
+	if _, err := engine.Scan(ctx, target.ID, backend); err != nil {
+		t.Fatalf("first scan failed: %v", err)
+	}
+	original, _ := db.Files.GetByPath(ctx, target.ID, "old/report.pdf")
+	if original == nil {
+		t.Fatal("expected the file to be recorded")
+	}
+
+	os.Rename(filepath.Join(tmpDir, "old", "report.pdf"), filepath.Join(tmpDir, "new", "report-2024.pdf"))
+	os.Remove(filepath.Join(tmpDir, "old", "gone.txt"))
+
+	result, err := engine.Scan(ctx, target.ID, backend)
+	if err != nil {
+		t.Fatalf("second scan failed: %v", err)
+	}
+	if result.FilesMoved != 1 || result.FilesAdded != 0 || result.FilesDeleted != 1 {
+		t.Errorf("expected 1 moved, 0 added and 1 deleted, got %d, %d and %d", result.FilesMoved, result.FilesAdded, result.FilesDeleted)
+	}
+
+	moved, _ := db.Files.GetByPath(ctx, target.ID, "new/report-2024.pdf")
+	if moved == nil || moved.ID != original.ID || moved.DeletedAt != nil {
+		t.Fatalf("expected the original record to move to the new path, got %+v", moved)
+	}
+	if !moved.FirstSeen.Equal(original.FirstSeen) {
+		t.Error("expected first seen to carry over")
+	}
+
+	events, _ := db.ChangeEvents.GetByFile(ctx, original.ID)
+	var event *database.ChangeEvent
+	for _, e := range events {
+		if e.EventType == database.ChangeEventMoved {
+			event = e
+		}
+	}
+	if event == nil || event.OldPath == nil || *event.OldPath != "old/report.pdf" || event.NewPath == nil || *event.NewPath != "new/report-2024.pdf" {
+		t.Errorf("expected a moved event from old/report.pdf, got %+v", event)
+	}
+}
+
+func TestEngine_MoveOntoDeletedPath(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupDB(t, db)
+
+	ctx := context.Background()
+	target := testutil.MustCreateStorageTarget(t, db, "test-target")
+
+	tmpDir := t.TempDir()
+	writeTestFile(t, filepath.Join(tmpDir, "draft.txt"), "final text")
+	writeTestFile(t, filepath.Join(tmpDir, "final.txt"), "old final text")
+	backend, _ := storage.NewLocalFSBackend(tmpDir)
+
+	engine := scanner.NewEngine(db, scanner.Config{
+		ChecksumAlgorithm: checksum.AlgorithmMD5,
+		ParallelWorkers:   2,
+	})
+
+	if _, err := engine.Scan(ctx, target.ID, backend); err != nil {
+		t.Fatalf("first scan failed: %v", err)
+	}
+	os.Remove(filepath.Join(tmpDir, "final.txt"))
+	if _, err := engine.Scan(ctx, target.ID, backend); err != nil {
+		t.Fatalf("second scan failed: %v", err)
+	}
+
+	// The new path still has the deleted file's record, so the move cannot
+	// carry draft.txt's record over
+	os.Rename(filepath.Join(tmpDir, "draft.txt"), filepath.Join(tmpDir, "final.txt"))
+	result, err := engine.Scan(ctx, target.ID, backend)
+	if err != nil {
+		t.Fatalf("third scan failed: %v", err)
+	}
+	if result.FilesMoved != 0 || result.FilesDeleted != 1 {
+		t.Errorf("expected 0 moved and 1 deleted, got %d and %d", result.FilesMoved, result.FilesDeleted)
+	}
+
+	if draft, _ := db.Files.GetByPath(ctx, target.ID, "draft.txt"); draft == nil || draft.DeletedAt == nil {
+		t.Errorf("expected the source of the failed move to be marked deleted, got %+v", draft)
+	}
+	if final, _ := db.Files.GetByPath(ctx, target.ID, "final.txt"); final == nil || final.DeletedAt != nil {
+		t.Errorf("expected the new path to be active, got %+v", final)
+	}
+}
+
+func TestEngine_NewlyExcludedFiles(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupDB(t, db)
+
+	ctx := context.Background()
+	target := testutil.MustCreateStorageTarget(t, db, "test-target")
+
+	tmpDir := setupTestDirectory(t)
+	backend, _ := storage.NewLocalFSBackend(tmpDir)
+
+	engine := scanner.NewEngine(db, scanner.Config{
+		ChecksumAlgorithm: checksum.AlgorithmMD5,
+		ParallelWorkers:   2,
+	})
+
+	if _, err := engine.Scan(ctx, target.ID, backend); err != nil {
+		t.Fatalf("first scan failed: %v", err)
+	}
+
+	filtered, err := storage.NewFilteredBackend(backend, storage.PathRules{Exclude: []string{"subdir/"}})
+	if err != nil {
+		t.Fatalf("failed to apply path rules: %v", err)
+	}
+	result, err := engine.Scan(ctx, target.ID, filtered)
+	if err != nil {
+		t.Fatalf("second scan failed: %v", err)
+	}
+	if result.FilesDeleted != 0 {
+		t.Errorf("expected no deletions, got %d", result.FilesDeleted)
+	}
+
+	excluded, _ := db.Files.GetByPath(ctx, target.ID, "subdir/file3.txt")
+	if excluded == nil || excluded.DeletedAt == nil || !excluded.Excluded {
+		t.Fatalf("expected the file to be marked excluded, got %+v", excluded)
+	}
+	events, _ := db.ChangeEvents.GetByFile(ctx, excluded.ID)
+	var recorded bool
+	for _, e := range events {
+		if e.EventType == database.ChangeEventDeleted {
+			t.Error("expected no deletion event for an excluded file")
+		}
+		recorded = recorded || e.EventType == database.ChangeEventExcluded
+	}
+	if !recorded {
+		t.Errorf("expected an excluded event, got %+v", events)
+	}
+
+	stats, err := db.Files.GetVerificationStats(ctx, target.ID)
+	if err != nil {
+		t.Fatalf("failed to get verification stats: %v", err)
+	}
+	if stats.TotalFiles != 2 {
+		t.Errorf("expected stats to leave the excluded file out, got %d files", stats.TotalFiles)
+	}
+
+	// Lifting the rule brings the file back on its old record
+	result, err = engine.Scan(ctx, target.ID, backend)
+	if err != nil {
+		t.Fatalf("third scan failed: %v", err)
+	}
+	if result.FilesAdded != 1 {
+		t.Errorf("expected the file to be added back, got %d added", result.FilesAdded)
+	}
+	restored, _ := db.Files.GetByPath(ctx, target.ID, "subdir/file3.txt")
+	if restored == nil || restored.ID != excluded.ID || restored.DeletedAt != nil || restored.Excluded {
+		t.Errorf("expected the file to be active again, got %+v", restored)
+	}
+}
+
+// failingBackend fails Open for a named file, optionally only the first failTimes times
+type failingBackend struct {
+	storage.StorageBackend
+	failName  string
+	err       error
+	failTimes int
+
+	mu    sync.Mutex
+	fails int
+}
+
+func (b *failingBackend) Open(ctx context.Context, path string) (io.ReadCloser, error) {
+	if filepath.Base(path) == b.failName {
+		b.mu.Lock()
+		shouldFail := b.failTimes == 0 || b.fails < b.failTimes
+		b.fails++
+		b.mu.Unlock()
+
+		if shouldFail {
+			return nil, &os.PathError{Op: "open", Path: path, Err: b.err}
+		}
+	}
+	return b.StorageBackend.Open(ctx, path)
+}
+
+// unreadableDirBackend reports dir as unreadable during Walk, as a stale NFS
+// handle would, and hides everything beneath it
+type unreadableDirBackend struct {
+	storage.StorageBackend
+	dir string
+}
+
+func (b *unreadableDirBackend) Walk(ctx context.Context, fn storage.WalkFunc) error {
+	return b.StorageBackend.Walk(ctx, func(path string, info *storage.FileInfo) error {
+		if path == b.dir {
+			return fn(path, &storage.FileInfo{
+				Path:  path,
+				IsDir: true,
+				Err:   &storage.WalkError{Path: path, Op: "readdir", Err: syscall.ESTALE},
+			})
+		}
+		if strings.HasPrefix(path, b.dir+"/") {
+			return nil
+		}
+		return fn(path, info)
+	})
+}
+
+// countingBackend counts the files opened through it
+type countingBackend struct {
+	storage.StorageBackend
+	opens atomic.Int64
+}
+
+func (b *countingBackend) Open(ctx context.Context, path string) (io.ReadCloser, error) {
+	b.opens.Add(1)
+	return b.StorageBackend.Open(ctx, path)
+}
+
+func (b *countingBackend) Lookup(ctx context.Context, path string) (*storage.FileInfo, error) {
+	return storage.Lookup(ctx, b.StorageBackend, path)
+}
+
 func setupTestDirectory(t *testing.T) string {
 	t.Helper()
 
@@ -368,3 +1328,106 @@ func writeTestFile(t *testing.T, path string, content string) {
 		t.Fatalf("failed to write test file: %v", err)
 	}
 }
+
+func TestEngine_ExpectedChecksums(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupDB(t, db)
+
+	ctx := context.Background()
+	target := testutil.MustCreateStorageTarget(t, db, "test-target")
+
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "intact.txt"), []byte("intact"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "damaged.txt"), []byte("damaged"), 0644)
+	backend, _ := storage.NewLocalFSBackend(tmpDir)
+
+	// Imported as SHA-256 although the target hashes with MD5
+	source := "manifest-sha256.txt"
+	intactSum := sha256.Sum256([]byte("intact"))
+	knownGood := strings.Repeat("ab", 32)
+	db.ExpectedChecksums.Upsert(ctx, []*database.ExpectedChecksum{
+		{StorageTargetID: target.ID, Path: "intact.txt", Checksum: hex.EncodeToString(intactSum[:]), ChecksumType: "sha256", Source: &source},
+		{StorageTargetID: target.ID, Path: "damaged.txt", Checksum: knownGood, ChecksumType: "sha256", Source: &source},
+	})
+
+	engine := scanner.NewEngine(db, scanner.Config{
+		ChecksumAlgorithm: checksum.AlgorithmMD5,
+		ParallelWorkers:   2,
+	})
+
+	result, err := engine.Scan(ctx, target.ID, backend)
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	if result.FilesMismatched != 1 || result.FilesVerified != 1 {
+		t.Errorf("expected 1 mismatched and 1 verified, got %d and %d", result.FilesMismatched, result.FilesVerified)
+	}
+
+	damaged, _ := db.Files.GetByPath(ctx, target.ID, "damaged.txt")
+	if damaged == nil || *damaged.CurrentChecksum != knownGood || *damaged.ChecksumType != "sha256" {
+		t.Errorf("expected the damaged file to keep the known-good checksum, got %+v", damaged)
+	}
+
+	if count, _ := db.ExpectedChecksums.Count(ctx, target.ID); count != 0 {
+		t.Errorf("expected applied expectations to be removed, %d left", count)
+	}
+}
+
+func TestEngine_BagValidation(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupDB(t, db)
+
+	ctx := context.Background()
+	target := testutil.MustCreateStorageTarget(t, db, "test-target")
+
+	tmpDir := t.TempDir()
+	sha256Hex := func(content string) string {
+		sum := sha256.Sum256([]byte(content))
+		return hex.EncodeToString(sum[:])
+	}
+	writeBag := func(name, payload string) {
+		os.MkdirAll(filepath.Join(tmpDir, name, "data"), 0755)
+		os.WriteFile(filepath.Join(tmpDir, name, "bagit.txt"), []byte("BagIt-Version: 1.0\nTag-File-Character-Encoding: UTF-8\n"), 0644)
+		os.WriteFile(filepath.Join(tmpDir, name, "manifest-sha256.txt"), []byte(sha256Hex("payload")+"  data/file.txt\n"), 0644)
+		os.WriteFile(filepath.Join(tmpDir, name, "data", "file.txt"), []byte(payload), 0644)
+	}
+	writeBag("good", "payload")
+	writeBag("bad", "corrupted")
+	backend, _ := storage.NewLocalFSBackend(tmpDir)
+
+	engine := scanner.NewEngine(db, scanner.Config{
+		ChecksumAlgorithm: checksum.AlgorithmMD5,
+		ParallelWorkers:   2,
+		ValidateBags:      true,
+	})
+
+	result, err := engine.Scan(ctx, target.ID, backend)
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	if result.BagsValidated != 2 || result.BagsInvalid != 1 {
+		t.Errorf("expected 2 bags validated and 1 invalid, got %d and %d", result.BagsValidated, result.BagsInvalid)
+	}
+
+	validations, err := db.BagValidations.ListLatestByTarget(ctx, target.ID)
+	if err != nil {
+		t.Fatalf("failed to list bag validations: %v", err)
+	}
+	if len(validations) != 2 {
+		t.Fatalf("expected 2 bag validations, got %d", len(validations))
+	}
+	bad, good := validations[0], validations[1]
+	if bad.Path != "bad" || bad.Valid || bad.ProblemCount != 1 {
+		t.Errorf("unexpected validation of the bad bag: %+v", bad)
+	}
+	if good.Path != "good" || !good.Valid || good.PayloadFiles != 1 {
+		t.Errorf("unexpected validation of the good bag: %+v", good)
+	}
+
+	problems, _ := db.BagValidations.ListProblems(ctx, bad.ID, 10)
+	if len(problems) != 1 || problems[0].Path != "data/file.txt" || problems[0].Kind != "mismatch" {
+		t.Errorf("expected a mismatch of data/file.txt, got %+v", problems)
+	}
+}
@@ -0,0 +1,172 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jeffanddom/fixity/internal/database"
+)
+
+// DefaultScanInterval is assumed between scans when a target has too little
+// scan history to estimate it
+const DefaultScanInterval = 24 * time.Hour
+
+// scanHistoryWindow is how many recent scans are used to estimate the interval
+const scanHistoryWindow = 10
+
+// VerificationPlan describes how much of a target each scan must verify so
+// that every file is re-verified within the target's verification window
+type VerificationPlan struct {
+	Window         time.Duration
+	ScanInterval   time.Duration
+	ScansPerWindow int
+	TotalFiles     int64
+	TotalBytes     int64
+	QuotaBytes     int64 // Bytes to verify per scan to stay on pace
+}
+
+// PlanVerification computes the per-scan verification quota. The quota is
+// measured in bytes rather than files so that scans which happen to pick many
+// small files do not leave large files to pile up at the end of the window.
+func PlanVerification(window, scanInterval time.Duration, totalFiles, totalBytes int64) VerificationPlan {
+	if scanInterval <= 0 {
+		scanInterval = DefaultScanInterval
+	}
+
+	scans := int(window / scanInterval)
+	if scans < 1 {
+		scans = 1
+	}
+
+	quota := totalBytes / int64(scans)
+	if totalBytes%int64(scans) != 0 {
+		quota++
+	}
+
+	return VerificationPlan{
+		Window:         window,
+		ScanInterval:   scanInterval,
+		ScansPerWindow: scans,
+		TotalFiles:     totalFiles,
+		TotalBytes:     totalBytes,
+		QuotaBytes:     quota,
+	}
+}
+
+// Deadline returns when a file must next be verified to stay within the window.
+// Files that have never been verified are due immediately.
+func (p VerificationPlan) Deadline(lastVerified *time.Time, now time.Time) time.Time {
+	if lastVerified == nil {
+		return now
+	}
+	return lastVerified.Add(p.Window)
+}
+
+// EstimateScanInterval estimates the time between scans from the start times
+// of recent scans, using the median gap so one manual scan does not skew it
+func EstimateScanInterval(scans []*database.Scan) time.Duration {
+	if len(scans) < 2 {
+		return DefaultScanInterval
+	}
+
+	starts := make([]time.Time, len(scans))
+	for i, scan := range scans {
+		starts[i] = scan.StartedAt
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+
+	gaps := make([]time.Duration, 0, len(starts)-1)
+	for i := 1; i < len(starts); i++ {
+		gaps = append(gaps, starts[i].Sub(starts[i-1]))
+	}
+	sort.Slice(gaps, func(i, j int) bool { return gaps[i] < gaps[j] })
+
+	median := gaps[len(gaps)/2]
+	if median <= 0 {
+		return DefaultScanInterval
+	}
+	return median
+}
+
+// selectForVerification picks the unchanged files a scan must verify under
+// plan. Files are taken in deadline order: every file that would fall outside
+// the window before the next scan is always selected, then further files until
+// the byte quota and minFiles are both met.
+func selectForVerification(
+	unchanged []*FileRecord,
+	previous map[string]*database.File,
+	plan VerificationPlan,
+	minFiles int,
+	now time.Time,
+) []*FileRecord {
+	type candidate struct {
+		file     *FileRecord
+		deadline time.Time
+	}
+
+	candidates := make([]candidate, 0, len(unchanged))
+	for _, file := range unchanged {
+		var lastVerified *time.Time
+		if prev, ok := previous[file.Path]; ok {
+			lastVerified = prev.LastChecksummedAt
+		}
+		candidates = append(candidates, candidate{file: file, deadline: plan.Deadline(lastVerified, now)})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if !candidates[i].deadline.Equal(candidates[j].deadline) {
+			return candidates[i].deadline.Before(candidates[j].deadline)
+		}
+		return candidates[i].file.Path < candidates[j].file.Path
+	})
+
+	nextScan := now.Add(plan.ScanInterval)
+	selected := []*FileRecord{}
+	var selectedBytes int64
+
+	for _, c := range candidates {
+		mustVerify := !c.deadline.After(nextScan)
+		if !mustVerify && selectedBytes >= plan.QuotaBytes && len(selected) >= minFiles {
+			break
+		}
+		selected = append(selected, c.file)
+		selectedBytes += c.file.Size
+	}
+
+	return selected
+}
+
+// selectByPolicy selects unchanged files to verify under the target's
+// verification window, falling back to the random sample size as a minimum
+func (e *Engine) selectByPolicy(
+	ctx context.Context,
+	unchanged []*FileRecord,
+	previous map[string]*database.File,
+	target *database.StorageTarget,
+	minFiles int,
+) ([]*FileRecord, error) {
+	discovery := database.ScanTypeDiscovery
+	scans, err := e.db.Scans.List(ctx, database.ScanFilters{
+		StorageTargetID: &target.ID,
+		ScanType:        &discovery,
+		Limit:           scanHistoryWindow,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load scan history: %w", err)
+	}
+
+	// Size the quota from every known file, not just unchanged ones, since
+	// they all have to be covered within the window
+	var totalFiles, totalBytes int64
+	for _, file := range previous {
+		totalFiles++
+		totalBytes += file.Size
+	}
+
+	window := time.Duration(*target.VerificationWindowDays) * 24 * time.Hour
+	plan := PlanVerification(window, EstimateScanInterval(scans), totalFiles, totalBytes)
+
+	return selectForVerification(unchanged, previous, plan, minFiles, time.Now()), nil
+}
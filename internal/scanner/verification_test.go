@@ -0,0 +1,107 @@
+package scanner
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jeffanddom/fixity/internal/database"
+)
+
+func TestPlanVerification(t *testing.T) {
+	plan := PlanVerification(30*24*time.Hour, 24*time.Hour, 100, 3000)
+	if plan.ScansPerWindow != 30 {
+		t.Errorf("expected 30 scans per window, got %d", plan.ScansPerWindow)
+	}
+	if plan.QuotaBytes != 100 {
+		t.Errorf("expected quota of 100 bytes, got %d", plan.QuotaBytes)
+	}
+
+	// Quota rounds up so the window is never undershot
+	plan = PlanVerification(30*24*time.Hour, 24*time.Hour, 100, 3001)
+	if plan.QuotaBytes != 101 {
+		t.Errorf("expected quota of 101 bytes, got %d", plan.QuotaBytes)
+	}
+
+	// Scans less often than the window verify everything
+	plan = PlanVerification(24*time.Hour, 7*24*time.Hour, 10, 5000)
+	if plan.ScansPerWindow != 1 || plan.QuotaBytes != 5000 {
+		t.Errorf("expected a single scan covering 5000 bytes, got %d scans, %d bytes", plan.ScansPerWindow, plan.QuotaBytes)
+	}
+}
+
+func TestEstimateScanInterval(t *testing.T) {
+	if got := EstimateScanInterval(nil); got != DefaultScanInterval {
+		t.Errorf("expected default interval without history, got %v", got)
+	}
+
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	scans := []*database.Scan{
+		{StartedAt: base.Add(72 * time.Hour)},
+		{StartedAt: base.Add(49 * time.Hour)}, // manual scan between scheduled ones
+		{StartedAt: base.Add(48 * time.Hour)},
+		{StartedAt: base.Add(24 * time.Hour)},
+		{StartedAt: base},
+	}
+
+	if got := EstimateScanInterval(scans); got != 24*time.Hour {
+		t.Errorf("expected 24h interval, got %v", got)
+	}
+}
+
+func TestSelectForVerification(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+
+	unchanged := []*FileRecord{}
+	previous := map[string]*database.File{}
+	addFile := func(path string, size int64, verifiedAgo time.Duration) {
+		unchanged = append(unchanged, &FileRecord{Path: path, Size: size})
+		f := &database.File{Path: path, Size: size}
+		if verifiedAgo >= 0 {
+			at := now.Add(-verifiedAgo)
+			f.LastChecksummedAt = &at
+		}
+		previous[path] = f
+	}
+
+	// Ten files verified 1-10 days ago, one never verified
+	for i := 1; i <= 10; i++ {
+		addFile(fmt.Sprintf("/file%02d", i), 100, time.Duration(i)*day)
+	}
+	addFile("/never", 100, -1)
+
+	t.Run("fills byte quota oldest first", func(t *testing.T) {
+		plan := PlanVerification(30*day, day, 11, 1100)
+		plan.QuotaBytes = 250
+
+		selected := selectForVerification(unchanged, previous, plan, 0, now)
+		if len(selected) != 3 {
+			t.Fatalf("expected 3 files to meet the byte quota, got %d", len(selected))
+		}
+		if selected[0].Path != "/never" || selected[1].Path != "/file10" || selected[2].Path != "/file09" {
+			t.Errorf("unexpected selection order: %s, %s, %s", selected[0].Path, selected[1].Path, selected[2].Path)
+		}
+	})
+
+	t.Run("always includes files due before the next scan", func(t *testing.T) {
+		// With a 7 day window, files verified 6+ days ago fall out before the next scan
+		plan := PlanVerification(7*day, day, 11, 1100)
+		plan.QuotaBytes = 0
+
+		selected := selectForVerification(unchanged, previous, plan, 0, now)
+		if len(selected) != 6 {
+			t.Errorf("expected 6 due files, got %d", len(selected))
+		}
+	})
+
+	t.Run("honors minimum file count", func(t *testing.T) {
+		plan := PlanVerification(365*day, day, 11, 1100)
+		plan.QuotaBytes = 0
+
+		selected := selectForVerification(unchanged, previous, plan, 4, now)
+		if len(selected) != 4 {
+			t.Errorf("expected 4 files, got %d", len(selected))
+		}
+	})
+}
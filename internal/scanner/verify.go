@@ -0,0 +1,275 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/jeffanddom/fixity/internal/checksum"
+	"github.com/jeffanddom/fixity/internal/database"
+	"github.com/jeffanddom/fixity/internal/storage"
+)
+
+// VerifyBudget bounds a verification-only scan. Zero values are unlimited;
+// with no budget at all every known file is verified once.
+type VerifyBudget struct {
+	Duration time.Duration // Stop submitting files after this long
+	Bytes    int64         // Stop submitting files after this many bytes
+}
+
+// Verify re-hashes files already known to the database, least recently
+// verified first, without walking the storage tree. Files are compared with
+// their stored checksum and recorded as verification events. Verification
+// stops when the budget is spent; files already being hashed are finished.
+func (e *Engine) Verify(ctx context.Context, targetID int64, backend storage.StorageBackend, budget VerifyBudget) (*ScanResult, error) {
+	start := time.Now()
+
+	target, err := e.db.StorageTargets.GetByID(ctx, targetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get storage target: %w", err)
+	}
+
+	if err := backend.Probe(ctx); err != nil {
+		return nil, fmt.Errorf("storage backend not accessible: %w", err)
+	}
+
+	scan := &database.Scan{
+		StorageTargetID: targetID,
+		Status:          database.ScanStatusRunning,
+		ScanType:        database.ScanTypeVerification,
+		StartedAt:       start,
+	}
+	if err := e.db.Scans.Create(ctx, scan); err != nil {
+		return nil, fmt.Errorf("failed to create scan record: %w", err)
+	}
+
+	result := &ScanResult{
+		ScanID: scan.ID,
+		Errors: []string{},
+	}
+
+	checksumPool := e.newChecksumPool()
+	checksumPool.Start()
+	defer checksumPool.Stop()
+
+	err = e.verifyFiles(ctx, scan, target, checksumPool, backend, budget, result)
+	result.WorkerStats = checksumPool.Stats()
+	if err != nil {
+		e.finalizeScan(ctx, scan, result, database.ScanStatusFailed)
+		return nil, fmt.Errorf("verification failed: %w", err)
+	}
+
+	status := database.ScanStatusCompleted
+	if result.FilesFailed > 0 {
+		status = database.ScanStatusPartial
+	}
+
+	result.Duration = time.Since(start)
+	e.finalizeScan(ctx, scan, result, status)
+
+	return result, nil
+}
+
+// verifyFiles pages through the target's files in verification order and
+// verifies each batch until the files or the budget run out
+func (e *Engine) verifyFiles(
+	ctx context.Context,
+	scan *database.Scan,
+	target *database.StorageTarget,
+	checksumPool *checksum.WorkerPool,
+	backend storage.StorageBackend,
+	budget VerifyBudget,
+	result *ScanResult,
+) error {
+	var deadline time.Time
+	if budget.Duration > 0 {
+		deadline = scan.StartedAt.Add(budget.Duration)
+	}
+
+	var bytesSubmitted int64
+	stop := func(file *FileRecord) bool {
+		if (!deadline.IsZero() && time.Now().After(deadline)) ||
+			(budget.Bytes > 0 && bytesSubmitted >= budget.Bytes) {
+			result.BudgetExhausted = true
+			return true
+		}
+		bytesSubmitted += file.Size
+		return false
+	}
+
+	// Only files last verified before this run started are considered, so
+	// files verified by this run drop out of later batches
+	var cursor database.VerificationCursor
+	for !result.BudgetExhausted {
+		batch, err := e.db.Files.ListVerificationBatch(ctx, target.ID, scan.StartedAt, cursor, e.config.BatchSize)
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+
+		last := batch[len(batch)-1]
+		cursor = database.VerificationCursor{LastChecksummedAt: last.LastChecksummedAt, ID: last.ID}
+
+		// Files excluded by the target's path rules are no longer verified
+		if excluder, ok := backend.(storage.PathExcluder); ok {
+			kept := batch[:0]
+			for _, file := range batch {
+				if !excluder.Excluded(file.Path) {
+					kept = append(kept, file)
+				}
+			}
+			batch = kept
+		}
+
+		if err := e.verifyBatch(ctx, scan.ID, batch, checksumPool, backend, result, stop); err != nil {
+			return err
+		}
+
+		if err := e.saveCheckpoint(ctx, scan.ID, last.Path, result.FilesScanned); err != nil {
+			result.addError(fmt.Sprintf("checkpoint error: %v", err))
+		}
+	}
+
+	return nil
+}
+
+// verifyBatch hashes a batch of known files and compares them with their
+// stored checksums. Files changed since they were discovered are left for
+// the next discovery scan. Matching files have their verification time updated;
+// mismatched files keep their stored checksum so they are flagged again
+// until someone investigates.
+func (e *Engine) verifyBatch(
+	ctx context.Context,
+	scanID int64,
+	batch []*database.File,
+	checksumPool *checksum.WorkerPool,
+	backend storage.StorageBackend,
+	result *ScanResult,
+	stop func(file *FileRecord) bool,
+) error {
+	// A file edited since the last discovery scan hashes differently without
+	// being corrupt; the next discovery scan records the change
+	kept := batch[:0]
+	for _, file := range batch {
+		if changedSinceDiscovery(ctx, backend, file) {
+			result.FilesChanged++
+			continue
+		}
+		kept = append(kept, file)
+	}
+	batch = kept
+
+	records := make([]*FileRecord, len(batch))
+	for i, file := range batch {
+		records[i] = &FileRecord{
+			Path:     file.Path,
+			Size:     file.Size,
+			ModTime:  file.LastSeen,
+			FileType: file.FileType,
+		}
+		if file.LinkTarget != nil {
+			records[i].LinkTarget = *file.LinkTarget
+		}
+		if file.ChecksumType != nil {
+			records[i].ChecksumType = *file.ChecksumType
+		}
+	}
+
+	scanErrors, err := e.hashFiles(ctx, scanID, records, checksumPool, backend, result, stop)
+	if err != nil {
+		return err
+	}
+
+	if err := e.db.ScanErrors.CreateBatch(ctx, scanErrors); err != nil {
+		return fmt.Errorf("failed to record scan errors: %w", err)
+	}
+	result.FilesScanned += int64(len(scanErrors))
+
+	now := time.Now()
+	events := []*database.ChangeEvent{}
+	for i, file := range batch {
+		record := records[i]
+		if record.Checksum == "" {
+			continue
+		}
+		result.FilesScanned++
+
+		events = append(events, &database.ChangeEvent{
+			ScanID:      scanID,
+			FileID:      file.ID,
+			EventType:   database.ChangeEventVerified,
+			DetectedAt:  now,
+			OldChecksum: file.CurrentChecksum,
+			NewChecksum: &record.Checksum,
+			NewSize:     &record.Size,
+		})
+
+		// Members of a mismatched archive are compared but not saved, so
+		// they are reported until someone investigates
+		matched := file.CurrentChecksum == nil || *file.CurrentChecksum == record.Checksum
+		if e.config.ScanArchives && file.FileType != database.FileTypeSymlink {
+			if err := e.updateArchiveMembers(ctx, scanID, file.ID, record, backend, result, matched); err != nil {
+				return fmt.Errorf("failed to hash archive members: %w", err)
+			}
+		}
+
+		if !matched {
+			result.FilesMismatched++
+			result.addError(fmt.Sprintf("checksum mismatch: %s: expected %s, got %s",
+				file.Path, *file.CurrentChecksum, record.Checksum))
+			continue
+		}
+
+		file.CurrentChecksum = &record.Checksum
+		file.ChecksumType = &record.ChecksumType
+		file.LastChecksummedAt = &now
+		if err := e.db.Files.Update(ctx, file); err != nil {
+			return fmt.Errorf("failed to update file %s: %w", file.Path, err)
+		}
+		result.FilesVerified++
+	}
+
+	if len(events) > 0 {
+		if err := e.db.ChangeEvents.CreateBatch(ctx, events); err != nil {
+			return fmt.Errorf("failed to create verification events: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// changedSinceDiscovery reports whether a known file no longer looks the way
+// the last discovery scan saw it: gone, resized, modified since, or a link
+// now pointing elsewhere. Files that can't be looked up are hashed anyway, so
+// any error is reported by the hash.
+func changedSinceDiscovery(ctx context.Context, backend storage.StorageBackend, file *database.File) bool {
+	info, err := storage.Lookup(ctx, backend, file.Path)
+	if errors.Is(err, storage.ErrLookupUnsupported) {
+		info, err = backend.Stat(ctx, file.Path)
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return true
+	}
+	if err != nil {
+		return false
+	}
+	if info == nil {
+		// Walk would leave the path out now, e.g. a file that became a FIFO
+		return true
+	}
+
+	if file.FileType == database.FileTypeSymlink {
+		return info.Type == storage.FileTypeSymlink && file.LinkTarget != nil && info.LinkTarget != *file.LinkTarget
+	}
+	if info.Size != file.Size {
+		return true
+	}
+	if file.ModTime == nil {
+		return info.ModTime.After(file.LastSeen)
+	}
+	return !info.ModTime.Truncate(time.Microsecond).Equal(file.ModTime.Truncate(time.Microsecond))
+}
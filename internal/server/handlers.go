@@ -1,15 +1,24 @@
 package server
 
 import (
+	"context"
 	"fmt"
+	"html/template"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 
 	"github.com/jeffanddom/fixity/internal/coordinator"
 	"github.com/jeffanddom/fixity/internal/database"
+	"github.com/jeffanddom/fixity/internal/scanner"
+	"github.com/jeffanddom/fixity/internal/storage"
+	"github.com/jeffanddom/fixity/internal/throttle"
+	"github.com/jeffanddom/fixity/internal/timestamp"
+	"github.com/lib/pq"
 )
 
 // handleDashboard shows the main dashboard
@@ -94,6 +103,7 @@ func (s *Server) renderSimpleDashboard(w http.ResponseWriter, data map[string]in
         .status-running { color: #28a745; }
         .status-completed { color: #6c757d; }
         .status-failed { color: #dc3545; }
+        .status-partial { color: #fd7e14; }
         .logout-form { display: inline; }
     </style>
 </head>
@@ -104,7 +114,9 @@ func (s *Server) renderSimpleDashboard(w http.ResponseWriter, data map[string]in
             <a href="/">Dashboard</a>
             <a href="/targets">Storage Targets</a>
             <a href="/scans">Scans</a>
-            <a href="/files">Files</a>` + func() string {
+            <a href="/files">Files</a>
+            <a href="/duplicates">Duplicates</a>
+            <a href="/replicas">Replicas</a>` + func() string {
 		if user.IsAdmin {
 			return `<a href="/users">Users</a>`
 		}
@@ -309,7 +321,9 @@ func (s *Server) renderSimpleTargetsList(w http.ResponseWriter, data map[string]
             <a href="/">Dashboard</a>
             <a href="/targets">Storage Targets</a>
             <a href="/scans">Scans</a>
-            <a href="/files">Files</a>` + func() string {
+            <a href="/files">Files</a>
+            <a href="/duplicates">Duplicates</a>
+            <a href="/replicas">Replicas</a>` + func() string {
 		if user.IsAdmin {
 			return `<a href="/users">Users</a>`
 		}
@@ -424,6 +438,29 @@ func (s *Server) renderSimpleTargetForm(w http.ResponseWriter, data map[string]i
 	server := ""
 	share := ""
 	enabled := true
+	throttleRate := ""
+	throttleIOPS := ""
+	throttleSchedule := ""
+	parallelWorkers := "1"
+	adaptiveWorkers := false
+	minWorkers := ""
+	maxWorkers := ""
+	readCacheMode := string(storage.CacheModeNormal)
+	readSize := ""
+	verificationWindow := ""
+	includePatterns := ""
+	excludePatterns := ""
+	honorIgnoreFiles := true
+	linkPolicy := string(storage.LinkPolicyFollow)
+	walkConcurrency := ""
+	skipUnchangedDirs := false
+	fullWalkDays := ""
+	captureXattrs := false
+	scanArchives := false
+	validateBags := false
+	watchChanges := false
+	credentialsRef := ""
+	hostKey := ""
 
 	if target != nil {
 		name = target.Name
@@ -436,6 +473,55 @@ func (s *Server) renderSimpleTargetForm(w http.ResponseWriter, data map[string]i
 		if target.Share != nil {
 			share = *target.Share
 		}
+		if target.ThrottleBytesPerSec != nil {
+			throttleRate = throttle.FormatRate(*target.ThrottleBytesPerSec)
+		}
+		if target.ThrottleIOPS != nil {
+			throttleIOPS = strconv.Itoa(*target.ThrottleIOPS)
+		}
+		if target.ThrottleSchedule != nil {
+			throttleSchedule = *target.ThrottleSchedule
+		}
+		parallelWorkers = strconv.Itoa(target.ParallelWorkers)
+		adaptiveWorkers = target.AdaptiveWorkers
+		if target.MinWorkers != nil {
+			minWorkers = strconv.Itoa(*target.MinWorkers)
+		}
+		if target.MaxWorkers != nil {
+			maxWorkers = strconv.Itoa(*target.MaxWorkers)
+		}
+		if target.ReadCacheMode != "" {
+			readCacheMode = target.ReadCacheMode
+		}
+		if target.ReadSize != nil {
+			readSize = formatReadSize(*target.ReadSize)
+		}
+		if target.VerificationWindowDays != nil {
+			verificationWindow = strconv.Itoa(*target.VerificationWindowDays)
+		}
+		includePatterns = template.HTMLEscapeString(strings.Join(target.IncludePatterns, "\n"))
+		excludePatterns = template.HTMLEscapeString(strings.Join(target.ExcludePatterns, "\n"))
+		honorIgnoreFiles = target.HonorIgnoreFiles
+		if target.LinkPolicy != "" {
+			linkPolicy = target.LinkPolicy
+		}
+		if target.WalkConcurrency != nil {
+			walkConcurrency = strconv.Itoa(*target.WalkConcurrency)
+		}
+		skipUnchangedDirs = target.SkipUnchangedDirs
+		if target.FullWalkDays != nil {
+			fullWalkDays = strconv.Itoa(*target.FullWalkDays)
+		}
+		captureXattrs = target.CaptureXattrs
+		scanArchives = target.ScanArchives
+		validateBags = target.ValidateBags
+		watchChanges = target.WatchChanges
+		if target.CredentialsRef != nil {
+			credentialsRef = template.HTMLEscapeString(*target.CredentialsRef)
+		}
+		if target.HostKey != nil {
+			hostKey = template.HTMLEscapeString(*target.HostKey)
+		}
 	}
 
 	html := `
@@ -453,6 +539,7 @@ func (s *Server) renderSimpleTargetForm(w http.ResponseWriter, data map[string]i
         .form-group { margin-bottom: 1.5rem; }
         .form-group label { display: block; font-weight: bold; margin-bottom: 0.5rem; }
         .form-group input[type="text"],
+        .form-group textarea,
         .form-group select { width: 100%; padding: 0.5rem; border: 1px solid #dee2e6; border-radius: 4px; }
         .form-group input[type="checkbox"] { margin-right: 0.5rem; }
         .form-group small { display: block; margin-top: 0.25rem; color: #6c757d; font-size: 0.875rem; }
@@ -473,7 +560,9 @@ func (s *Server) renderSimpleTargetForm(w http.ResponseWriter, data map[string]i
             <a href="/">Dashboard</a>
             <a href="/targets">Storage Targets</a>
             <a href="/scans">Scans</a>
-            <a href="/files">Files</a>` + func() string {
+            <a href="/files">Files</a>
+            <a href="/duplicates">Duplicates</a>
+            <a href="/replicas">Replicas</a>` + func() string {
 		if user.IsAdmin {
 			return `<a href="/users">Users</a>`
 		}
@@ -526,13 +615,25 @@ func (s *Server) renderSimpleTargetForm(w http.ResponseWriter, data map[string]i
 		}
 		return ""
 	}() + `>SMB/CIFS (Windows Share)</option>
+                    <option value="sftp"` + func() string {
+		if targetType == "sftp" {
+			return ` selected`
+		}
+		return ""
+	}() + `>SFTP (SSH File Transfer)</option>
+                    <option value="webdav"` + func() string {
+		if targetType == "webdav" {
+			return ` selected`
+		}
+		return ""
+	}() + `>WebDAV (Nextcloud, ownCloud)</option>
                 </select>
-                <small>Local: Local filesystem path | NFS: NFS server mount | SMB: Windows/Samba share</small>
+                <small>Local: Local filesystem path | NFS: NFS server mount | SMB: Windows/Samba share | SFTP: remote directory over SSH | WebDAV: directory on a WebDAV server</small>
             </div>
             <div class="form-group network-fields" id="server-field">
                 <label for="server">Server Address</label>
                 <input type="text" id="server" name="server" value="` + server + `" placeholder="e.g., nfs.example.com or 192.168.1.100">
-                <small>Hostname or IP address of the NFS/SMB server</small>
+                <small>Hostname or IP address of the NFS/SMB server; user@host[:port] for SFTP; URL of the WebDAV root</small>
             </div>
             <div class="form-group network-fields" id="share-field">
                 <label for="share">Share Path/Name</label>
@@ -542,7 +643,17 @@ func (s *Server) renderSimpleTargetForm(w http.ResponseWriter, data map[string]i
             <div class="form-group">
                 <label for="path">Mount Path</label>
                 <input type="text" id="path" name="path" value="` + path + `" required placeholder="e.g., /mnt/nfs or /mnt/smb">
-                <small>Local: directory path | NFS/SMB: local mount point path</small>
+                <small>Local: directory path | NFS/SMB: local mount point path | SFTP/WebDAV: remote directory</small>
+            </div>
+            <div class="form-group credential-fields">
+                <label for="credentials_ref">Credentials Reference</label>
+                <input type="text" id="credentials_ref" name="credentials_ref" value="` + credentialsRef + `" placeholder="e.g., file:/etc/fixity/archive_key or env:ARCHIVE_PASSWORD">
+                <small>Where the SFTP private key or password, or the WebDAV user:password, is kept: file:PATH or env:NAME. The secret itself is never stored.</small>
+            </div>
+            <div class="form-group sftp-fields">
+                <label for="host_key">Host Key</label>
+                <input type="text" id="host_key" name="host_key" value="` + hostKey + `" placeholder="e.g., ssh-ed25519 AAAA... or SHA256:...">
+                <small>The server's public key (as in known_hosts, without the host name) or its SHA256 fingerprint; connections to any other key are refused</small>
             </div>
             <script>
                 function updateFieldVisibility() {
@@ -551,12 +662,31 @@ func (s *Server) renderSimpleTargetForm(w http.ResponseWriter, data map[string]i
                     const pathField = document.getElementById('path');
                     const serverField = document.getElementById('server');
                     const shareField = document.getElementById('share');
+                    const sftpFields = document.querySelectorAll('.sftp-fields');
+                    const credentialFields = document.querySelectorAll('.credential-fields');
+
+                    sftpFields.forEach(field => field.style.display = type === 'sftp' ? 'block' : 'none');
+                    credentialFields.forEach(field => field.style.display = type === 'sftp' || type === 'webdav' ? 'block' : 'none');
 
                     if (type === 'local') {
                         networkFields.forEach(field => field.style.display = 'none');
                         serverField.removeAttribute('required');
                         shareField.removeAttribute('required');
                         pathField.placeholder = '/path/to/directory';
+                    } else if (type === 'sftp') {
+                        networkFields.forEach(field => field.style.display = 'block');
+                        document.getElementById('share-field').style.display = 'none';
+                        serverField.setAttribute('required', 'required');
+                        shareField.removeAttribute('required');
+                        serverField.placeholder = 'fixity@archive.example.com:22';
+                        pathField.placeholder = '/srv/archive';
+                    } else if (type === 'webdav') {
+                        networkFields.forEach(field => field.style.display = 'block');
+                        document.getElementById('share-field').style.display = 'none';
+                        serverField.setAttribute('required', 'required');
+                        shareField.removeAttribute('required');
+                        serverField.placeholder = 'https://cloud.example.com/remote.php/dav/files/alice';
+                        pathField.placeholder = '/Photos';
                     } else {
                         networkFields.forEach(field => field.style.display = 'block');
                         serverField.setAttribute('required', 'required');
@@ -577,6 +707,196 @@ func (s *Server) renderSimpleTargetForm(w http.ResponseWriter, data map[string]i
                 // Run when type changes
                 document.getElementById('type').addEventListener('change', updateFieldVisibility);
             </script>
+            <div class="form-group">
+                <label for="throttle_rate">Bandwidth Limit</label>
+                <input type="text" id="throttle_rate" name="throttle_rate" value="` + throttleRate + `" placeholder="e.g., 50MB/s (blank for unlimited)">
+                <small>Maximum read rate for this target, in addition to any global limit</small>
+            </div>
+            <div class="form-group">
+                <label for="throttle_iops">IOPS Limit</label>
+                <input type="text" id="throttle_iops" name="throttle_iops" value="` + throttleIOPS + `" placeholder="e.g., 500 (blank for unlimited)">
+                <small>Maximum read operations per second for this target</small>
+            </div>
+            <div class="form-group">
+                <label for="throttle_schedule">Throttle Schedule</label>
+                <input type="text" id="throttle_schedule" name="throttle_schedule" value="` + throttleSchedule + `" placeholder="e.g., 08:00-18:00=50MB/s; 18:00-08:00=unlimited">
+                <small>Time-of-day windows that override the limits above (HH:MM-HH:MM=rate[,Niops]; ...)</small>
+            </div>
+            <div class="form-group">
+                <label for="parallel_workers">Checksum Workers</label>
+                <input type="text" id="parallel_workers" name="parallel_workers" value="` + parallelWorkers + `">
+                <small>Files hashed in parallel (starting point when adaptive sizing is enabled)</small>
+            </div>
+            <div class="form-group">
+                <label>
+                    <input type="checkbox" name="adaptive_workers" value="true"` + func() string {
+		if adaptiveWorkers {
+			return ` checked`
+		}
+		return ""
+	}() + `>
+                    Adaptive worker sizing
+                </label>
+                <small>Adjust the number of workers during a scan based on observed throughput and latency</small>
+            </div>
+            <div class="form-group">
+                <label for="min_workers">Minimum Workers</label>
+                <input type="text" id="min_workers" name="min_workers" value="` + minWorkers + `" placeholder="1">
+            </div>
+            <div class="form-group">
+                <label for="max_workers">Maximum Workers</label>
+                <input type="text" id="max_workers" name="max_workers" value="` + maxWorkers + `" placeholder="4x checksum workers">
+            </div>
+            <div class="form-group">
+                <label for="read_cache_mode">Page Cache</label>
+                <select id="read_cache_mode" name="read_cache_mode">
+                    <option value="normal"` + func() string {
+		if readCacheMode == string(storage.CacheModeNormal) {
+			return ` selected`
+		}
+		return ""
+	}() + `>Normal</option>
+                    <option value="drop"` + func() string {
+		if readCacheMode == string(storage.CacheModeDrop) {
+			return ` selected`
+		}
+		return ""
+	}() + `>Drop after reading</option>
+                    <option value="direct"` + func() string {
+		if readCacheMode == string(storage.CacheModeDirect) {
+			return ` selected`
+		}
+		return ""
+	}() + `>Direct I/O (bypass cache)</option>
+                </select>
+                <small>Keep large scans from evicting the host's working set. Direct I/O falls back to dropping pages where unsupported.</small>
+            </div>
+            <div class="form-group">
+                <label for="read_size">Read Size</label>
+                <input type="text" id="read_size" name="read_size" value="` + readSize + `" placeholder="e.g., 1MB (blank for default)">
+                <small>Bytes requested per read while hashing</small>
+            </div>
+            <div class="form-group">
+                <label for="verification_window_days">Verification Window (days)</label>
+                <input type="text" id="verification_window_days" name="verification_window_days" value="` + verificationWindow + `" placeholder="e.g., 90 (blank for random sampling only)">
+                <small>Guarantee every file is re-verified at least this often; each scan verifies enough data to stay on pace</small>
+            </div>
+            <div class="form-group">
+                <label for="include_patterns">Include Paths</label>
+                <textarea id="include_patterns" name="include_patterns" rows="3" placeholder="e.g., photos/**/*.jpg">` + includePatterns + `</textarea>
+                <small>One rule per line; when set, only matching files are scanned (blank for everything)</small>
+            </div>
+            <div class="form-group">
+                <label for="exclude_patterns">Exclude Paths</label>
+                <textarea id="exclude_patterns" name="exclude_patterns" rows="5" placeholder=".snapshot/&#10;#recycle/&#10;.DS_Store&#10;Thumbs.db&#10;*.tmp">` + excludePatterns + `</textarea>
+                <small>One rule per line. Globs follow .gitignore: a trailing / matches directories, ** matches any depth; prefix with re: for a regular expression</small>
+            </div>
+            <div class="form-group">
+                <label>
+                    <input type="checkbox" name="honor_ignore_files" value="true"` + func() string {
+		if honorIgnoreFiles {
+			return ` checked`
+		}
+		return ""
+	}() + `>
+                    Honor .fixityignore files
+                </label>
+                <small>Skip paths listed in .fixityignore files found in the scanned tree</small>
+            </div>
+            <div class="form-group">
+                <label for="link_policy">Symlinks</label>
+                <select id="link_policy" name="link_policy">
+                    <option value="follow"` + func() string {
+		if linkPolicy == string(storage.LinkPolicyFollow) {
+			return ` selected`
+		}
+		return ""
+	}() + `>Follow within target</option>
+                    <option value="record"` + func() string {
+		if linkPolicy == string(storage.LinkPolicyRecord) {
+			return ` selected`
+		}
+		return ""
+	}() + `>Record as links</option>
+                    <option value="skip"` + func() string {
+		if linkPolicy == string(storage.LinkPolicySkip) {
+			return ` selected`
+		}
+		return ""
+	}() + `>Skip</option>
+                </select>
+                <small>Follow: hash what links point to, recording links that leave the target as links. Record: track each link's target path. Sockets, FIFOs and devices are always skipped.</small>
+            </div>
+            <div class="form-group">
+                <label for="walk_concurrency">Concurrent Directory Listings</label>
+                <input type="text" id="walk_concurrency" name="walk_concurrency" value="` + walkConcurrency + `" placeholder="e.g., 16 (blank to walk sequentially)">
+                <small>Directories read at once while walking the target; raise for high-latency NFS or SMB mounts</small>
+            </div>
+            <div class="form-group">
+                <label>
+                    <input type="checkbox" name="skip_unchanged_dirs" value="true"` + func() string {
+		if skipUnchangedDirs {
+			return ` checked`
+		}
+		return ""
+	}() + `>
+                    Skip unchanged directories
+                </label>
+                <small>Don't re-read files in directories whose modification time and entry count are unchanged since the last scan. Files rewritten in place are only noticed by the periodic full walk. Not available for SFTP or WebDAV targets.</small>
+            </div>
+            <div class="form-group">
+                <label for="full_walk_days">Full Walk Interval (days)</label>
+                <input type="text" id="full_walk_days" name="full_walk_days" value="` + fullWalkDays + `" placeholder="e.g., 30 (blank for ` + strconv.Itoa(scanner.DefaultFullWalkDays) + `)">
+                <small>How often every directory is read when skipping unchanged directories</small>
+            </div>
+            <div class="form-group">
+                <label>
+                    <input type="checkbox" name="capture_xattrs" value="true"` + func() string {
+		if captureXattrs {
+			return ` checked`
+		}
+		return ""
+	}() + `>
+                    Capture extended attributes
+                </label>
+                <small>Record a digest of each file's extended attributes and ACLs alongside its permissions and owner, so changes to them are reported. Costs extra system calls per file.</small>
+            </div>
+            <div class="form-group">
+                <label>
+                    <input type="checkbox" name="scan_archives" value="true"` + func() string {
+		if scanArchives {
+			return ` checked`
+		}
+		return ""
+	}() + `>
+                    Hash archive members
+                </label>
+                <small>Also hash each member of ZIP and TAR (.tar, .tar.gz, .tgz) archives, so file history shows which members changed. Archives are read twice when hashed.</small>
+            </div>
+            <div class="form-group">
+                <label>
+                    <input type="checkbox" name="validate_bags" value="true"` + func() string {
+		if validateBags {
+			return ` checked`
+		}
+		return ""
+	}() + `>
+                    Validate BagIt bags
+                </label>
+                <small>On each scan, check each directory holding a bagit.txt against its payload and tag manifests, and report missing, extra and mismatched files. Payload hashed with algorithms other than the target's is read again.</small>
+            </div>
+            <div class="form-group">
+                <label>
+                    <input type="checkbox" name="watch_changes" value="true"` + func() string {
+		if watchChanges {
+			return ` checked`
+		}
+		return ""
+	}() + `>
+                    Watch for changes
+                </label>
+                <small>Local targets only: scan changed files as they happen using filesystem events (inotify), with a full scan if events are lost</small>
+            </div>
             <div class="form-group">
                 <label>
                     <input type="checkbox" name="enabled" value="true"` + func() string {
@@ -612,7 +932,7 @@ func (s *Server) handleCreateTarget(w http.ResponseWriter, r *http.Request) {
 	enabled := r.FormValue("enabled") == "true"
 
 	// Validate type
-	if targetType != "local" && targetType != "nfs" && targetType != "smb" {
+	if !validTargetType(targetType) {
 		user := s.getCurrentUser(r)
 		data := map[string]interface{}{
 			"User":  user,
@@ -643,6 +963,15 @@ func (s *Server) handleCreateTarget(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
+	if (targetType == "sftp" || targetType == "webdav") && server == "" {
+		user := s.getCurrentUser(r)
+		data := map[string]interface{}{
+			"User":  user,
+			"Error": "Server address is required for SFTP/WebDAV targets",
+		}
+		s.renderSimpleTargetForm(w, data, nil)
+		return
+	}
 
 	// Create target with default values for required fields
 	target := &database.StorageTarget{
@@ -662,8 +991,49 @@ func (s *Server) handleCreateTarget(w http.ResponseWriter, r *http.Request) {
 		target.Server = &server
 		target.Share = &share
 	}
+	if targetType == "sftp" || targetType == "webdav" {
+		target.Server = &server
+	}
+
+	err := applyThrottleForm(r, target)
+	if err == nil {
+		err = applyWorkerForm(r, target)
+	}
+	if err == nil {
+		err = applyReadForm(r, target)
+	}
+	if err == nil {
+		err = applyVerificationForm(r, target)
+	}
+	if err == nil {
+		err = applyPathRulesForm(r, target)
+	}
+	if err == nil {
+		err = applyLinkForm(r, target)
+	}
+	if err == nil {
+		err = applyWalkForm(r, target)
+	}
+	if err == nil {
+		err = applyWatchForm(r, target)
+	}
+	if err == nil {
+		err = applySFTPForm(r, target)
+	}
+	if err == nil {
+		err = applyWebDAVForm(r, target)
+	}
+	if err != nil {
+		user := s.getCurrentUser(r)
+		data := map[string]interface{}{
+			"User":  user,
+			"Error": err.Error(),
+		}
+		s.renderSimpleTargetForm(w, data, nil)
+		return
+	}
 
-	err := s.db.StorageTargets.Create(r.Context(), target)
+	err = s.db.StorageTargets.Create(r.Context(), target)
 	if err != nil {
 		user := s.getCurrentUser(r)
 		data := map[string]interface{}{
@@ -673,88 +1043,457 @@ func (s *Server) handleCreateTarget(w http.ResponseWriter, r *http.Request) {
 		s.renderSimpleTargetForm(w, data, nil)
 		return
 	}
+	s.coordinator.RefreshWatchers(r.Context())
 
 	http.Redirect(w, r, "/targets", http.StatusSeeOther)
 }
 
-func (s *Server) handleViewTarget(w http.ResponseWriter, r *http.Request) {
-	targetID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+// applyThrottleForm parses the throttle fields of the target form into target
+func applyThrottleForm(r *http.Request, target *database.StorageTarget) error {
+	target.ThrottleBytesPerSec = nil
+	target.ThrottleIOPS = nil
+	target.ThrottleSchedule = nil
+
+	rate, err := throttle.ParseRate(r.FormValue("throttle_rate"))
 	if err != nil {
-		http.Error(w, "Invalid target ID", http.StatusBadRequest)
-		return
+		return fmt.Errorf("Invalid bandwidth limit: %v", err)
+	}
+	if rate > 0 {
+		target.ThrottleBytesPerSec = &rate
 	}
 
-	user := s.getCurrentUser(r)
-	target, err := s.db.StorageTargets.GetByID(r.Context(), targetID)
-	if err != nil || target == nil {
-		http.Error(w, "Target not found", http.StatusNotFound)
-		return
+	if iopsStr := strings.TrimSpace(r.FormValue("throttle_iops")); iopsStr != "" {
+		iops, err := strconv.Atoi(iopsStr)
+		if err != nil || iops < 0 {
+			return fmt.Errorf("Invalid IOPS limit: %s", iopsStr)
+		}
+		if iops > 0 {
+			target.ThrottleIOPS = &iops
+		}
 	}
 
-	// Get recent scans for this target
-	recentScans, _ := s.db.Scans.List(r.Context(), database.ScanFilters{
-		StorageTargetID: &targetID,
-		Limit:           20,
-	})
+	if schedule := strings.TrimSpace(r.FormValue("throttle_schedule")); schedule != "" {
+		if _, err := throttle.ParseWindows(schedule); err != nil {
+			return fmt.Errorf("Invalid throttle schedule: %v", err)
+		}
+		target.ThrottleSchedule = &schedule
+	}
 
-	data := map[string]interface{}{
-		"User":        user,
-		"Target":      target,
-		"RecentScans": recentScans,
+	return nil
+}
+
+// applyWorkerForm parses the checksum worker fields of the target form into target
+func applyWorkerForm(r *http.Request, target *database.StorageTarget) error {
+	parseWorkers := func(field, label string) (*int, error) {
+		value := strings.TrimSpace(r.FormValue(field))
+		if value == "" {
+			return nil, nil
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("Invalid %s: %s", label, value)
+		}
+		return &n, nil
 	}
 
-	if s.templates != nil {
-		if err := s.templates.ExecuteTemplate(w, "target_view.html", data); err != nil {
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-			return
+	parallel, err := parseWorkers("parallel_workers", "checksum workers")
+	if err != nil {
+		return err
+	}
+	if parallel != nil {
+		target.ParallelWorkers = *parallel
+	}
+
+	if target.MinWorkers, err = parseWorkers("min_workers", "minimum workers"); err != nil {
+		return err
+	}
+	if target.MaxWorkers, err = parseWorkers("max_workers", "maximum workers"); err != nil {
+		return err
+	}
+	if target.MinWorkers != nil && target.MaxWorkers != nil && *target.MinWorkers > *target.MaxWorkers {
+		return fmt.Errorf("Minimum workers cannot exceed maximum workers")
+	}
+
+	target.AdaptiveWorkers = r.FormValue("adaptive_workers") == "true"
+
+	return nil
+}
+
+// applyReadForm parses the read option fields of the target form into target
+func applyReadForm(r *http.Request, target *database.StorageTarget) error {
+	mode, err := storage.ParseCacheMode(r.FormValue("read_cache_mode"))
+	if err != nil {
+		return fmt.Errorf("Invalid page cache mode: %s", r.FormValue("read_cache_mode"))
+	}
+	target.ReadCacheMode = string(mode)
+
+	target.ReadSize = nil
+	size, err := throttle.ParseRate(r.FormValue("read_size"))
+	if err != nil || size > math.MaxInt32 {
+		return fmt.Errorf("Invalid read size: %s", r.FormValue("read_size"))
+	}
+	if size > 0 {
+		n := int(size)
+		target.ReadSize = &n
+	}
+
+	return nil
+}
+
+// applyVerificationForm parses the verification policy fields of the target form into target
+func applyVerificationForm(r *http.Request, target *database.StorageTarget) error {
+	target.VerificationWindowDays = nil
+
+	if value := strings.TrimSpace(r.FormValue("verification_window_days")); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil || days <= 0 {
+			return fmt.Errorf("Invalid verification window: %s", value)
 		}
-		return
+		target.VerificationWindowDays = &days
 	}
 
-	s.renderSimpleTargetView(w, data)
+	return nil
 }
 
-func (s *Server) renderSimpleTargetView(w http.ResponseWriter, data map[string]interface{}) {
-	w.Header().Set("Content-Type", "text/html")
-	user := data["User"].(*database.User)
-	target := data["Target"].(*database.StorageTarget)
-	recentScans := data["RecentScans"].([]*database.Scan)
+// applyPathRulesForm parses the include/exclude path rule fields of the target form into target
+func applyPathRulesForm(r *http.Request, target *database.StorageTarget) error {
+	splitRules := func(field string) pq.StringArray {
+		rules := pq.StringArray{}
+		for _, line := range strings.Split(r.FormValue(field), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				rules = append(rules, line)
+			}
+		}
+		return rules
+	}
 
-	status := "Disabled"
-	statusClass := "status-disabled"
-	if target.Enabled {
-		status = "Enabled"
-		statusClass = "status-enabled"
+	target.IncludePatterns = splitRules("include_patterns")
+	target.ExcludePatterns = splitRules("exclude_patterns")
+	target.HonorIgnoreFiles = r.FormValue("honor_ignore_files") == "true"
+
+	rules := coordinator.TargetPathRules(target)
+	if err := rules.Validate(); err != nil {
+		return fmt.Errorf("Invalid path rules: %v", err)
 	}
 
-	html := `
-<!DOCTYPE html>
-<html>
-<head>
-    <title>Fixity - Storage Target</title>
-    <style>
-        body { font-family: Arial, sans-serif; margin: 0; padding: 0; }
-        .header { background: #2c3e50; color: white; padding: 1rem 2rem; display: flex; justify-content: space-between; align-items: center; }
-        .nav { display: flex; gap: 1rem; }
-        .nav a { color: white; text-decoration: none; }
-        .nav a:hover { text-decoration: underline; }
-        .container { padding: 2rem; max-width: 1200px; margin: 0 auto; }
-        .info-card { background: #f8f9fa; padding: 1.5rem; border-radius: 8px; margin-bottom: 2rem; }
-        .info-row { display: flex; margin-bottom: 0.75rem; }
-        .info-label { font-weight: bold; width: 150px; }
-        .info-value { flex: 1; }
-        .btn { padding: 0.5rem 1rem; background: #007bff; color: white; border: none; border-radius: 4px; text-decoration: none; display: inline-block; cursor: pointer; }
-        .btn:hover { background: #0056b3; }
-        .btn-sm { padding: 0.25rem 0.5rem; font-size: 0.875rem; }
-        .btn-danger { background: #dc3545; }
-        .btn-danger:hover { background: #c82333; }
-        .btn-secondary { background: #6c757d; margin-left: 0.5rem; }
-        .btn-secondary:hover { background: #5a6268; }
-        .status-enabled { color: #28a745; font-weight: bold; }
-        .status-disabled { color: #6c757d; }
-        .status-running { color: #28a745; }
+	return nil
+}
+
+// applyLinkForm parses the symlink policy field of the target form into target
+func applyLinkForm(r *http.Request, target *database.StorageTarget) error {
+	policy, err := storage.ParseLinkPolicy(r.FormValue("link_policy"))
+	if err != nil {
+		return fmt.Errorf("Invalid symlink policy: %s", r.FormValue("link_policy"))
+	}
+	target.LinkPolicy = string(policy)
+
+	return nil
+}
+
+// applyWalkForm parses the directory walk fields of the target form into target
+func applyWalkForm(r *http.Request, target *database.StorageTarget) error {
+	target.WalkConcurrency = nil
+	target.SkipUnchangedDirs = r.FormValue("skip_unchanged_dirs") == "true"
+	target.FullWalkDays = nil
+	target.CaptureXattrs = r.FormValue("capture_xattrs") == "true"
+	target.ScanArchives = r.FormValue("scan_archives") == "true"
+	target.ValidateBags = r.FormValue("validate_bags") == "true"
+
+	if target.SkipUnchangedDirs && (target.Type == database.StorageTypeSFTP || target.Type == database.StorageTypeWebDAV) {
+		return fmt.Errorf("Skipping unchanged directories is not supported for SFTP or WebDAV targets")
+	}
+
+	if value := strings.TrimSpace(r.FormValue("walk_concurrency")); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 || storage.ValidateWalkConcurrency(n) != nil {
+			return fmt.Errorf("Invalid directory listing concurrency: %s (must be 1-%d)", value, storage.MaxWalkConcurrency)
+		}
+		target.WalkConcurrency = &n
+	}
+
+	if value := strings.TrimSpace(r.FormValue("full_walk_days")); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil || days < 1 || days > 365 {
+			return fmt.Errorf("Invalid full walk interval: %s (must be 1-365 days)", value)
+		}
+		target.FullWalkDays = &days
+	}
+
+	return nil
+}
+
+// applyWatchForm parses the change watching field of the target form into target
+func applyWatchForm(r *http.Request, target *database.StorageTarget) error {
+	target.WatchChanges = r.FormValue("watch_changes") == "true"
+	if target.WatchChanges && target.Type != database.StorageTypeLocal {
+		return fmt.Errorf("Watching for changes is only supported for local targets")
+	}
+
+	return nil
+}
+
+// applySFTPForm parses the connection fields of the target form into an
+// SFTP target. The server address may be changed here since SFTP targets
+// have no mount to keep in step with.
+func applySFTPForm(r *http.Request, target *database.StorageTarget) error {
+	if target.Type != database.StorageTypeSFTP {
+		return nil
+	}
+
+	if server := strings.TrimSpace(r.FormValue("server")); server != "" {
+		target.Server = &server
+	}
+	if target.Server == nil || storage.ValidateSFTPServer(*target.Server) != nil {
+		return fmt.Errorf("Invalid SFTP server address (must be user@host[:port])")
+	}
+
+	credentialsRef := strings.TrimSpace(r.FormValue("credentials_ref"))
+	if err := storage.ValidateCredentialsRef(credentialsRef); err != nil {
+		return fmt.Errorf("Invalid credentials reference: must be file:PATH or env:NAME")
+	}
+	target.CredentialsRef = &credentialsRef
+
+	hostKey := strings.TrimSpace(r.FormValue("host_key"))
+	if err := storage.ValidateHostKey(hostKey); err != nil {
+		return fmt.Errorf("Invalid host key: must be the server's public key or its SHA256 fingerprint")
+	}
+	target.HostKey = &hostKey
+
+	return nil
+}
+
+// applyWebDAVForm parses the connection fields of the target form into a
+// WebDAV target; credentials are optional for public shares
+func applyWebDAVForm(r *http.Request, target *database.StorageTarget) error {
+	if target.Type != database.StorageTypeWebDAV {
+		return nil
+	}
+
+	if server := strings.TrimSpace(r.FormValue("server")); server != "" {
+		target.Server = &server
+	}
+	if target.Server == nil || storage.ValidateWebDAVServer(*target.Server) != nil {
+		return fmt.Errorf("Invalid WebDAV server: must be an http:// or https:// URL")
+	}
+
+	target.CredentialsRef = nil
+	if credentialsRef := strings.TrimSpace(r.FormValue("credentials_ref")); credentialsRef != "" {
+		if err := storage.ValidateCredentialsRef(credentialsRef); err != nil {
+			return fmt.Errorf("Invalid credentials reference: must be file:PATH or env:NAME")
+		}
+		target.CredentialsRef = &credentialsRef
+	}
+
+	return nil
+}
+
+// validTargetType reports whether the form's storage type is supported
+func validTargetType(targetType string) bool {
+	switch database.StorageType(targetType) {
+	case database.StorageTypeLocal, database.StorageTypeNFS, database.StorageTypeSMB,
+		database.StorageTypeSFTP, database.StorageTypeWebDAV:
+		return true
+	}
+	return false
+}
+
+// formatReadSize renders a read size in the largest whole unit
+func formatReadSize(n int) string {
+	switch {
+	case n%(1<<20) == 0:
+		return fmt.Sprintf("%dMB", n>>20)
+	case n%(1<<10) == 0:
+		return fmt.Sprintf("%dKB", n>>10)
+	default:
+		return strconv.Itoa(n)
+	}
+}
+
+func (s *Server) handleViewTarget(w http.ResponseWriter, r *http.Request) {
+	targetID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid target ID", http.StatusBadRequest)
+		return
+	}
+
+	user := s.getCurrentUser(r)
+	target, err := s.db.StorageTargets.GetByID(r.Context(), targetID)
+	if err != nil || target == nil {
+		http.Error(w, "Target not found", http.StatusNotFound)
+		return
+	}
+
+	// Get recent scans for this target
+	recentScans, _ := s.db.Scans.List(r.Context(), database.ScanFilters{
+		StorageTargetID: &targetID,
+		Limit:           20,
+	})
+
+	// Latest validation of each bag found on the target
+	bags, _ := s.db.BagValidations.ListLatestByTarget(r.Context(), targetID)
+
+	data := map[string]interface{}{
+		"User":        user,
+		"Target":      target,
+		"RecentScans": recentScans,
+		"Bags":        bags,
+		"Throttle":    s.coordinator.CurrentThrottle(targetID),
+	}
+	if status, ok := s.coordinator.WatchStatus(targetID); ok {
+		data["Watch"] = status
+	}
+
+	if s.templates != nil {
+		if err := s.templates.ExecuteTemplate(w, "target_view.html", data); err != nil {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		return
+	}
+
+	s.renderSimpleTargetView(w, data)
+}
+
+func (s *Server) renderSimpleTargetView(w http.ResponseWriter, data map[string]interface{}) {
+	w.Header().Set("Content-Type", "text/html")
+	user := data["User"].(*database.User)
+	target := data["Target"].(*database.StorageTarget)
+	recentScans := data["RecentScans"].([]*database.Scan)
+	bags, _ := data["Bags"].([]*database.BagValidation)
+	currentThrottle := data["Throttle"].(throttle.Limits)
+
+	// Describe configured throttle alongside what is in effect right now
+	throttleDesc := "Unlimited"
+	if schedule, err := coordinator.TargetSchedule(target); err == nil {
+		throttleDesc = schedule.Default.String()
+		if target.ThrottleSchedule != nil && *target.ThrottleSchedule != "" {
+			throttleDesc += " (schedule: " + *target.ThrottleSchedule + ")"
+		}
+		currentThrottle = throttle.Combine(currentThrottle, schedule.At(time.Now()))
+	}
+
+	workersDesc := fmt.Sprintf("%d (fixed)", target.ParallelWorkers)
+	if target.AdaptiveWorkers {
+		minWorkers, maxWorkers := "1", strconv.Itoa(target.ParallelWorkers*4)
+		if target.MinWorkers != nil {
+			minWorkers = strconv.Itoa(*target.MinWorkers)
+		}
+		if target.MaxWorkers != nil {
+			maxWorkers = strconv.Itoa(*target.MaxWorkers)
+		}
+		workersDesc = fmt.Sprintf("adaptive, %s-%s (starting at %d)", minWorkers, maxWorkers, target.ParallelWorkers)
+	}
+
+	readDesc := target.ReadCacheMode
+	if target.ReadSize != nil {
+		readDesc += ", " + formatReadSize(*target.ReadSize) + " reads"
+	}
+
+	verificationDesc := fmt.Sprintf("Random sample (%.1f%% per scan)", target.RandomSamplePercent)
+	if target.VerificationWindowDays != nil {
+		verificationDesc = fmt.Sprintf("Every file within %d days", *target.VerificationWindowDays)
+	}
+
+	pathRulesDesc := "All files"
+	if len(target.IncludePatterns) > 0 {
+		pathRulesDesc = "Only " + strings.Join(target.IncludePatterns, ", ")
+	}
+	if len(target.ExcludePatterns) > 0 {
+		pathRulesDesc += "; excluding " + strings.Join(target.ExcludePatterns, ", ")
+	}
+	if target.HonorIgnoreFiles {
+		pathRulesDesc += "; honoring " + storage.IgnoreFileName
+	}
+	pathRulesDesc = template.HTMLEscapeString(pathRulesDesc)
+
+	linkDesc := map[string]string{
+		string(storage.LinkPolicyFollow): "Followed within target",
+		string(storage.LinkPolicyRecord): "Recorded as links",
+		string(storage.LinkPolicySkip):   "Skipped",
+	}[target.LinkPolicy]
+	if linkDesc == "" {
+		linkDesc = "Followed within target"
+	}
+
+	watchDesc := "Off"
+	if target.WatchChanges {
+		watchDesc = "On (starts with the server)"
+		if status, ok := data["Watch"].(coordinator.WatchStatus); ok {
+			switch {
+			case !status.Active:
+				watchDesc = fmt.Sprintf("Stopped: %v", status.Err)
+			case status.Err != nil:
+				watchDesc = fmt.Sprintf("Watching; last incremental scan failed: %v", status.Err)
+			default:
+				watchDesc = "Watching"
+			}
+		}
+	}
+	watchDesc = template.HTMLEscapeString(watchDesc)
+
+	walkDesc := "Sequential"
+	if target.WalkConcurrency != nil && *target.WalkConcurrency > 1 {
+		walkDesc = fmt.Sprintf("%d directories listed concurrently", *target.WalkConcurrency)
+	}
+	if target.SkipUnchangedDirs {
+		days := scanner.DefaultFullWalkDays
+		if target.FullWalkDays != nil {
+			days = *target.FullWalkDays
+		}
+		walkDesc += fmt.Sprintf("; unchanged directories skipped, full walk every %d days", days)
+	}
+
+	metadataDesc := "Permissions and owner"
+	if target.CaptureXattrs {
+		metadataDesc += ", extended attributes and ACLs"
+	}
+
+	archivesDesc := "Hashed as whole files"
+	if target.ScanArchives {
+		archivesDesc = "Hashed whole and by member (ZIP, TAR)"
+	}
+
+	bagsDesc := "Not validated"
+	if target.ValidateBags {
+		bagsDesc = "Validated on each scan"
+	}
+
+	status := "Disabled"
+	statusClass := "status-disabled"
+	if target.Enabled {
+		status = "Enabled"
+		statusClass = "status-enabled"
+	}
+
+	html := `
+<!DOCTYPE html>
+<html>
+<head>
+    <title>Fixity - Storage Target</title>
+    <style>
+        body { font-family: Arial, sans-serif; margin: 0; padding: 0; }
+        .header { background: #2c3e50; color: white; padding: 1rem 2rem; display: flex; justify-content: space-between; align-items: center; }
+        .nav { display: flex; gap: 1rem; }
+        .nav a { color: white; text-decoration: none; }
+        .nav a:hover { text-decoration: underline; }
+        .container { padding: 2rem; max-width: 1200px; margin: 0 auto; }
+        .info-card { background: #f8f9fa; padding: 1.5rem; border-radius: 8px; margin-bottom: 2rem; }
+        .info-row { display: flex; margin-bottom: 0.75rem; }
+        .info-label { font-weight: bold; width: 150px; }
+        .info-value { flex: 1; }
+        .btn { padding: 0.5rem 1rem; background: #007bff; color: white; border: none; border-radius: 4px; text-decoration: none; display: inline-block; cursor: pointer; }
+        .btn:hover { background: #0056b3; }
+        .btn-sm { padding: 0.25rem 0.5rem; font-size: 0.875rem; }
+        .btn-danger { background: #dc3545; }
+        .btn-danger:hover { background: #c82333; }
+        .btn-secondary { background: #6c757d; margin-left: 0.5rem; }
+        .btn-secondary:hover { background: #5a6268; }
+        .status-enabled { color: #28a745; font-weight: bold; }
+        .status-disabled { color: #6c757d; }
+        .status-running { color: #28a745; }
         .status-completed { color: #6c757d; }
         .status-failed { color: #dc3545; }
+        .status-partial { color: #fd7e14; }
         table { width: 100%; border-collapse: collapse; background: white; margin-top: 1rem; }
         th, td { padding: 0.75rem; text-align: left; border-bottom: 1px solid #dee2e6; }
         th { background: #f8f9fa; font-weight: 600; }
@@ -770,7 +1509,9 @@ func (s *Server) renderSimpleTargetView(w http.ResponseWriter, data map[string]i
             <a href="/">Dashboard</a>
             <a href="/targets">Storage Targets</a>
             <a href="/scans">Scans</a>
-            <a href="/files">Files</a>` + func() string {
+            <a href="/files">Files</a>
+            <a href="/duplicates">Duplicates</a>
+            <a href="/replicas">Replicas</a>` + func() string {
 		if user.IsAdmin {
 			return `<a href="/users">Users</a>`
 		}
@@ -791,6 +1532,14 @@ func (s *Server) renderSimpleTargetView(w http.ResponseWriter, data map[string]i
             <form method="POST" action="/targets/` + strconv.FormatInt(target.ID, 10) + `/scan" style="display:inline;">
                 <button type="submit" class="btn">Scan Now</button>
             </form>
+            <form method="POST" action="/targets/` + strconv.FormatInt(target.ID, 10) + `/verify" style="display:inline;">
+                <input type="text" name="verify_duration" placeholder="e.g., 4h" size="6" title="Time budget (blank for unlimited)">
+                <input type="text" name="verify_bytes" placeholder="e.g., 500GB" size="8" title="Byte budget (blank for unlimited)">
+                <button type="submit" class="btn">Verify Only</button>
+            </form>
+            <a href="/targets/` + strconv.FormatInt(target.ID, 10) + `/coverage" class="btn">Coverage</a>
+            <a href="/targets/` + strconv.FormatInt(target.ID, 10) + `/reports" class="btn">Reports</a>
+            <a href="/targets/` + strconv.FormatInt(target.ID, 10) + `/attestations" class="btn">Attestations</a>
             <a href="/targets" class="btn btn-secondary">Back to List</a>
             <form method="POST" action="/targets/` + strconv.FormatInt(target.ID, 10) + `" style="display:inline;">
                 <input type="hidden" name="_method" value="DELETE">
@@ -800,77 +1549,477 @@ func (s *Server) renderSimpleTargetView(w http.ResponseWriter, data map[string]i
 
         <div class="info-card">
             <div class="info-row">
-                <div class="info-label">Name:</div>
-                <div class="info-value">` + target.Name + `</div>
+                <div class="info-label">Name:</div>
+                <div class="info-value">` + target.Name + `</div>
+            </div>
+            <div class="info-row">
+                <div class="info-label">Type:</div>
+                <div class="info-value">` + string(target.Type) + `</div>
+            </div>
+            <div class="info-row">
+                <div class="info-label">Path:</div>
+                <div class="info-value">` + target.Path + `</div>
+            </div>` + func() string {
+		if target.Type == database.StorageTypeWebDAV && target.Server != nil {
+			return `
+            <div class="info-row">
+                <div class="info-label">Server:</div>
+                <div class="info-value">` + template.HTMLEscapeString(*target.Server) + `</div>
+            </div>`
+		}
+		if target.Type != database.StorageTypeSFTP || target.Server == nil {
+			return ""
+		}
+		hostKey := "Not pinned"
+		if target.HostKey != nil {
+			hostKey = *target.HostKey
+		}
+		return `
+            <div class="info-row">
+                <div class="info-label">Server:</div>
+                <div class="info-value">` + template.HTMLEscapeString(*target.Server) + `</div>
+            </div>
+            <div class="info-row">
+                <div class="info-label">Host Key:</div>
+                <div class="info-value">` + template.HTMLEscapeString(hostKey) + `</div>
+            </div>`
+	}() + `
+            <div class="info-row">
+                <div class="info-label">Status:</div>
+                <div class="info-value ` + statusClass + `">` + status + `</div>
+            </div>
+            <div class="info-row">
+                <div class="info-label">Checksum Workers:</div>
+                <div class="info-value">` + workersDesc + `</div>
+            </div>
+            <div class="info-row">
+                <div class="info-label">Verification:</div>
+                <div class="info-value">` + verificationDesc + `</div>
+            </div>
+            <div class="info-row">
+                <div class="info-label">Path Rules:</div>
+                <div class="info-value">` + pathRulesDesc + `</div>
+            </div>
+            <div class="info-row">
+                <div class="info-label">Symlinks:</div>
+                <div class="info-value">` + linkDesc + `</div>
+            </div>
+            <div class="info-row">
+                <div class="info-label">Directory Walk:</div>
+                <div class="info-value">` + walkDesc + `</div>
+            </div>
+            <div class="info-row">
+                <div class="info-label">Metadata:</div>
+                <div class="info-value">` + metadataDesc + `</div>
+            </div>
+            <div class="info-row">
+                <div class="info-label">Archives:</div>
+                <div class="info-value">` + archivesDesc + `</div>
+            </div>
+            <div class="info-row">
+                <div class="info-label">BagIt Bags:</div>
+                <div class="info-value">` + bagsDesc + `</div>
+            </div>
+            <div class="info-row">
+                <div class="info-label">Change Watching:</div>
+                <div class="info-value">` + watchDesc + `</div>
+            </div>
+            <div class="info-row">
+                <div class="info-label">Read Mode:</div>
+                <div class="info-value">` + readDesc + `</div>
+            </div>
+            <div class="info-row">
+                <div class="info-label">Read Throttle:</div>
+                <div class="info-value">` + throttleDesc + `</div>
+            </div>
+            <div class="info-row">
+                <div class="info-label">Throttle Now:</div>
+                <div class="info-value">` + currentThrottle.String() + `</div>
+            </div>
+            <div class="info-row">
+                <div class="info-label">Created:</div>
+                <div class="info-value">` + target.CreatedAt.Format("2006-01-02 15:04:05") + `</div>
+            </div>
+        </div>`
+
+	if len(bags) > 0 {
+		html += `
+        <h3>BagIt Bags</h3>
+        <table>
+            <thead>
+                <tr>
+                    <th>Bag</th>
+                    <th>Result</th>
+                    <th>Payload Files</th>
+                    <th>Problems</th>
+                    <th>Validated</th>
+                    <th>Actions</th>
+                </tr>
+            </thead>
+            <tbody>`
+
+		for _, bag := range bags {
+			bagPath := bag.Path
+			if bagPath == "" {
+				bagPath = "/"
+			}
+			result, resultClass := "Valid", "status-completed"
+			if !bag.Valid {
+				result, resultClass = "Invalid", "status-failed"
+			}
+
+			html += fmt.Sprintf(`
+                <tr>
+                    <td>%s</td>
+                    <td class="%s">%s</td>
+                    <td>%d</td>
+                    <td>%d</td>
+                    <td>%s</td>
+                    <td><a href="/targets/%d/bags/%d" class="btn btn-sm">View</a></td>
+                </tr>`,
+				template.HTMLEscapeString(bagPath),
+				resultClass,
+				result,
+				bag.PayloadFiles,
+				bag.ProblemCount,
+				bag.ValidatedAt.Format("2006-01-02 15:04"),
+				target.ID,
+				bag.ID,
+			)
+		}
+
+		html += `
+            </tbody>
+        </table>`
+	}
+
+	html += `
+
+        <h3>Recent Scans</h3>`
+
+	if len(recentScans) == 0 {
+		html += `<p>No scans yet for this target.</p>`
+	} else {
+		html += `
+        <table>
+            <thead>
+                <tr>
+                    <th>Status</th>
+                    <th>Started</th>
+                    <th>Completed</th>
+                    <th>Files Scanned</th>
+                    <th>Changes</th>
+                    <th>Actions</th>
+                </tr>
+            </thead>
+            <tbody>`
+
+		for _, scan := range recentScans {
+			completedStr := "In Progress"
+			if scan.CompletedAt != nil {
+				completedStr = scan.CompletedAt.Format("2006-01-02 15:04")
+			}
+
+			statusClass := "status-" + string(scan.Status)
+			html += fmt.Sprintf(`
+                <tr>
+                    <td class="%s">%s</td>
+                    <td>%s</td>
+                    <td>%s</td>
+                    <td>%d</td>
+                    <td>+%d / ~%d / -%d</td>
+                    <td><a href="/scans/%d" class="btn btn-sm">View</a></td>
+                </tr>`,
+				statusClass,
+				scan.Status,
+				scan.StartedAt.Format("2006-01-02 15:04"),
+				completedStr,
+				scan.FilesScanned,
+				scan.FilesAdded,
+				scan.FilesModified,
+				scan.FilesDeleted,
+				scan.ID,
+			)
+		}
+
+		html += `
+            </tbody>
+        </table>`
+	}
+
+	html += `
+    </div>
+</body>
+</html>`
+
+	w.Write([]byte(html))
+}
+
+// defaultCoverageWindowDays is used for the coverage report of targets
+// without a verification window
+const defaultCoverageWindowDays = 90
+
+// coverageRiskHorizon is how far ahead the coverage report looks for files
+// about to fall outside the verification window
+const coverageRiskHorizon = 7 * 24 * time.Hour
+
+// maxCoverageFilesShown caps the files listed on the coverage report
+const maxCoverageFilesShown = 200
+
+func (s *Server) handleTargetCoverage(w http.ResponseWriter, r *http.Request) {
+	targetID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid target ID", http.StatusBadRequest)
+		return
+	}
+
+	user := s.getCurrentUser(r)
+	target, err := s.db.StorageTargets.GetByID(r.Context(), targetID)
+	if err != nil || target == nil {
+		http.Error(w, "Target not found", http.StatusNotFound)
+		return
+	}
+
+	windowDays := defaultCoverageWindowDays
+	if target.VerificationWindowDays != nil {
+		windowDays = *target.VerificationWindowDays
+	}
+	window := time.Duration(windowDays) * 24 * time.Hour
+
+	discovery := database.ScanTypeDiscovery
+	recentScans, _ := s.db.Scans.List(r.Context(), database.ScanFilters{
+		StorageTargetID: &targetID,
+		ScanType:        &discovery,
+		Limit:           10,
+	})
+	interval := scanner.EstimateScanInterval(recentScans)
+
+	horizon := coverageRiskHorizon
+	if interval > horizon {
+		horizon = interval
+	}
+
+	now := time.Now()
+	windowStart := now.Add(-window)
+	riskStart := windowStart.Add(horizon)
+
+	stats, err := s.db.Files.GetCoverageStats(r.Context(), targetID, windowStart, riskStart)
+	if err != nil {
+		http.Error(w, "Failed to load coverage", http.StatusInternalServerError)
+		return
+	}
+
+	dueFiles, _ := s.db.Files.ListVerificationDue(r.Context(), targetID, riskStart, maxCoverageFilesShown)
+
+	data := map[string]interface{}{
+		"User":     user,
+		"Target":   target,
+		"Plan":     scanner.PlanVerification(window, interval, stats.TotalFiles, stats.TotalBytes),
+		"Stats":    stats,
+		"DueFiles": dueFiles,
+		"Horizon":  horizon,
+	}
+
+	if s.templates != nil {
+		if err := s.templates.ExecuteTemplate(w, "target_coverage.html", data); err != nil {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		return
+	}
+
+	s.renderSimpleTargetCoverage(w, data)
+}
+
+func (s *Server) renderSimpleTargetCoverage(w http.ResponseWriter, data map[string]interface{}) {
+	w.Header().Set("Content-Type", "text/html")
+	user := data["User"].(*database.User)
+	target := data["Target"].(*database.StorageTarget)
+	plan := data["Plan"].(scanner.VerificationPlan)
+	stats := data["Stats"].(*database.CoverageStats)
+	dueFiles := data["DueFiles"].([]*database.File)
+	horizon := data["Horizon"].(time.Duration)
+
+	percent := func(part, total int64) string {
+		if total == 0 {
+			return "100.0%"
+		}
+		return fmt.Sprintf("%.1f%%", float64(part)/float64(total)*100)
+	}
+
+	days := func(d time.Duration) string {
+		return fmt.Sprintf("%.1f days", d.Hours()/24)
+	}
+
+	windowDesc := fmt.Sprintf("Every file verified at least once every %d days", int(plan.Window.Hours()/24))
+	if target.VerificationWindowDays == nil {
+		windowDesc = fmt.Sprintf("Not configured (showing coverage against %d days)", defaultCoverageWindowDays)
+	}
+
+	oldest := "Never"
+	if stats.OldestVerification != nil {
+		oldest = stats.OldestVerification.Format("2006-01-02 15:04")
+	}
+
+	html := `
+<!DOCTYPE html>
+<html>
+<head>
+    <title>Fixity - Verification Coverage</title>
+    <style>
+        body { font-family: Arial, sans-serif; margin: 0; padding: 0; }
+        .header { background: #2c3e50; color: white; padding: 1rem 2rem; display: flex; justify-content: space-between; align-items: center; }
+        .nav { display: flex; gap: 1rem; }
+        .nav a { color: white; text-decoration: none; }
+        .nav a:hover { text-decoration: underline; }
+        .container { padding: 2rem; max-width: 1200px; margin: 0 auto; }
+        .info-card { background: #f8f9fa; padding: 1.5rem; border-radius: 8px; margin-bottom: 2rem; }
+        .info-row { display: flex; margin-bottom: 0.75rem; }
+        .info-label { font-weight: bold; width: 200px; }
+        .info-value { flex: 1; }
+        .btn { padding: 0.5rem 1rem; background: #007bff; color: white; border: none; border-radius: 4px; text-decoration: none; display: inline-block; cursor: pointer; }
+        .btn:hover { background: #0056b3; }
+        .btn-sm { padding: 0.25rem 0.5rem; font-size: 0.875rem; }
+        .btn-secondary { background: #6c757d; }
+        .btn-secondary:hover { background: #5a6268; }
+        .status-failed { color: #dc3545; font-weight: bold; }
+        .status-partial { color: #fd7e14; }
+        table { width: 100%; border-collapse: collapse; background: white; margin-top: 1rem; }
+        th, td { padding: 0.75rem; text-align: left; border-bottom: 1px solid #dee2e6; }
+        th { background: #f8f9fa; font-weight: 600; }
+        tr:hover { background: #f8f9fa; }
+        .logout-form { display: inline; }
+        .actions { margin-bottom: 2rem; }
+    </style>
+</head>
+<body>
+    <div class="header">
+        <h1>Fixity</h1>
+        <div class="nav">
+            <a href="/">Dashboard</a>
+            <a href="/targets">Storage Targets</a>
+            <a href="/scans">Scans</a>
+            <a href="/files">Files</a>
+            <a href="/duplicates">Duplicates</a>
+            <a href="/replicas">Replicas</a>` + func() string {
+		if user.IsAdmin {
+			return `<a href="/users">Users</a>`
+		}
+		return ""
+	}() + `
+            <span>|</span>
+            <span>` + user.Username + `</span>
+            <form method="POST" action="/logout" class="logout-form">
+                <button type="submit" class="btn btn-sm">Logout</button>
+            </form>
+        </div>
+    </div>
+    <div class="container">
+        <h2>Verification Coverage: ` + template.HTMLEscapeString(target.Name) + `</h2>
+
+        <div class="actions">
+            <a href="/targets/` + strconv.FormatInt(target.ID, 10) + `" class="btn btn-secondary">Back to Target</a>
+        </div>
+
+        <div class="info-card">
+            <div class="info-row">
+                <div class="info-label">Policy:</div>
+                <div class="info-value">` + windowDesc + `</div>
             </div>
             <div class="info-row">
-                <div class="info-label">Type:</div>
-                <div class="info-value">` + string(target.Type) + `</div>
+                <div class="info-label">Scan Interval:</div>
+                <div class="info-value">` + days(plan.ScanInterval) + ` (estimated from recent scans)</div>
             </div>
             <div class="info-row">
-                <div class="info-label">Path:</div>
-                <div class="info-value">` + target.Path + `</div>
+                <div class="info-label">Per-Scan Quota:</div>
+                <div class="info-value">` + fmt.Sprintf("%s of %s (%d scans per window)", formatBytes(plan.QuotaBytes), formatBytes(plan.TotalBytes), plan.ScansPerWindow) + `</div>
             </div>
             <div class="info-row">
-                <div class="info-label">Status:</div>
-                <div class="info-value ` + statusClass + `">` + status + `</div>
+                <div class="info-label">Covered:</div>
+                <div class="info-value">` + fmt.Sprintf("%d of %d files (%s), %s of %s (%s)",
+		stats.CoveredFiles, stats.TotalFiles, percent(stats.CoveredFiles, stats.TotalFiles),
+		formatBytes(stats.CoveredBytes), formatBytes(stats.TotalBytes), percent(stats.CoveredBytes, stats.TotalBytes)) + `</div>
             </div>
             <div class="info-row">
-                <div class="info-label">Created:</div>
-                <div class="info-value">` + target.CreatedAt.Format("2006-01-02 15:04:05") + `</div>
+                <div class="info-label">Overdue:</div>
+                <div class="info-value` + func() string {
+		if stats.OverdueFiles > 0 {
+			return ` status-failed`
+		}
+		return ""
+	}() + `">` + fmt.Sprintf("%d files (%s)", stats.OverdueFiles, formatBytes(stats.OverdueBytes)) + `</div>
+            </div>
+            <div class="info-row">
+                <div class="info-label">At Risk:</div>
+                <div class="info-value` + func() string {
+		if stats.AtRiskFiles > 0 {
+			return ` status-partial`
+		}
+		return ""
+	}() + `">` + fmt.Sprintf("%d files (%s) leave the window within %s", stats.AtRiskFiles, formatBytes(stats.AtRiskBytes), days(horizon)) + `</div>
+            </div>
+            <div class="info-row">
+                <div class="info-label">Oldest Verification:</div>
+                <div class="info-value">` + oldest + `</div>
             </div>
         </div>
 
-        <h3>Recent Scans</h3>`
+        <h3>Files Due for Verification</h3>`
 
-	if len(recentScans) == 0 {
-		html += `<p>No scans yet for this target.</p>`
+	if len(dueFiles) == 0 {
+		html += `<p>No files are overdue or at risk.</p>`
 	} else {
 		html += `
         <table>
             <thead>
                 <tr>
+                    <th>Path</th>
+                    <th>Size</th>
+                    <th>Last Verified</th>
+                    <th>Deadline</th>
                     <th>Status</th>
-                    <th>Started</th>
-                    <th>Completed</th>
-                    <th>Files Scanned</th>
-                    <th>Changes</th>
-                    <th>Actions</th>
                 </tr>
             </thead>
             <tbody>`
 
-		for _, scan := range recentScans {
-			completedStr := "In Progress"
-			if scan.CompletedAt != nil {
-				completedStr = scan.CompletedAt.Format("2006-01-02 15:04")
+		now := time.Now()
+		for _, file := range dueFiles {
+			lastVerified := "Never"
+			if file.LastChecksummedAt != nil {
+				lastVerified = file.LastChecksummedAt.Format("2006-01-02 15:04")
+			}
+
+			deadline := plan.Deadline(file.LastChecksummedAt, now)
+			status := `<span class="status-partial">Due in ` + days(deadline.Sub(now)) + `</span>`
+			if !deadline.After(now) {
+				status = `<span class="status-failed">Overdue by ` + days(now.Sub(deadline)) + `</span>`
 			}
 
-			statusClass := "status-" + string(scan.Status)
 			html += fmt.Sprintf(`
                 <tr>
-                    <td class="%s">%s</td>
+                    <td><a href="/files/%d">%s</a></td>
+                    <td>%s</td>
+                    <td>%s</td>
                     <td>%s</td>
                     <td>%s</td>
-                    <td>%d</td>
-                    <td>+%d / ~%d / -%d</td>
-                    <td><a href="/scans/%d" class="btn btn-sm">View</a></td>
                 </tr>`,
-				statusClass,
-				scan.Status,
-				scan.StartedAt.Format("2006-01-02 15:04"),
-				completedStr,
-				scan.FilesScanned,
-				scan.FilesAdded,
-				scan.FilesModified,
-				scan.FilesDeleted,
-				scan.ID,
+				file.ID,
+				template.HTMLEscapeString(file.Path),
+				formatBytes(file.Size),
+				lastVerified,
+				deadline.Format("2006-01-02 15:04"),
+				status,
 			)
 		}
 
 		html += `
             </tbody>
         </table>`
+
+		if int64(len(dueFiles)) < stats.OverdueFiles+stats.AtRiskFiles {
+			html += fmt.Sprintf(`
+        <p>Showing the %d files closest to their deadline.</p>`, len(dueFiles))
+		}
 	}
 
 	html += `
@@ -943,7 +2092,7 @@ func (s *Server) handleUpdateTarget(w http.ResponseWriter, r *http.Request) {
 	enabled := r.FormValue("enabled") == "true"
 
 	// Validate type
-	if targetType != "local" && targetType != "nfs" && targetType != "smb" {
+	if !validTargetType(targetType) {
 		user := s.getCurrentUser(r)
 		data := map[string]interface{}{
 			"User":  user,
@@ -959,6 +2108,44 @@ func (s *Server) handleUpdateTarget(w http.ResponseWriter, r *http.Request) {
 	target.Path = path
 	target.Enabled = enabled
 
+	err = applyThrottleForm(r, target)
+	if err == nil {
+		err = applyWorkerForm(r, target)
+	}
+	if err == nil {
+		err = applyReadForm(r, target)
+	}
+	if err == nil {
+		err = applyVerificationForm(r, target)
+	}
+	if err == nil {
+		err = applyPathRulesForm(r, target)
+	}
+	if err == nil {
+		err = applyLinkForm(r, target)
+	}
+	if err == nil {
+		err = applyWalkForm(r, target)
+	}
+	if err == nil {
+		err = applyWatchForm(r, target)
+	}
+	if err == nil {
+		err = applySFTPForm(r, target)
+	}
+	if err == nil {
+		err = applyWebDAVForm(r, target)
+	}
+	if err != nil {
+		user := s.getCurrentUser(r)
+		data := map[string]interface{}{
+			"User":  user,
+			"Error": err.Error(),
+		}
+		s.renderSimpleTargetForm(w, data, target)
+		return
+	}
+
 	err = s.db.StorageTargets.Update(r.Context(), target)
 	if err != nil {
 		user := s.getCurrentUser(r)
@@ -969,6 +2156,7 @@ func (s *Server) handleUpdateTarget(w http.ResponseWriter, r *http.Request) {
 		s.renderSimpleTargetForm(w, data, target)
 		return
 	}
+	s.coordinator.RefreshWatchers(r.Context())
 
 	http.Redirect(w, r, fmt.Sprintf("/targets/%d", targetID), http.StatusSeeOther)
 }
@@ -993,6 +2181,7 @@ func (s *Server) handleDeleteTarget(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("Failed to delete target: %v", err), http.StatusInternalServerError)
 		return
 	}
+	s.coordinator.RefreshWatchers(r.Context())
 
 	http.Redirect(w, r, "/targets", http.StatusSeeOther)
 }
@@ -1020,6 +2209,55 @@ func (s *Server) handleTriggerScan(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
 
+func (s *Server) handleTriggerVerify(w http.ResponseWriter, r *http.Request) {
+	targetID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid target ID", http.StatusBadRequest)
+		return
+	}
+
+	// Verify target exists
+	_, err = s.db.StorageTargets.GetByID(r.Context(), targetID)
+	if err != nil {
+		http.Error(w, "Target not found", http.StatusNotFound)
+		return
+	}
+
+	budget, err := parseVerifyBudget(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Verification runs for its whole budget, so it must outlive this request
+	go func() {
+		s.coordinator.VerifyTarget(context.Background(), targetID, budget)
+	}()
+
+	http.Redirect(w, r, "/scans/running", http.StatusSeeOther)
+}
+
+// parseVerifyBudget parses the time and byte budget of a verify request
+func parseVerifyBudget(r *http.Request) (scanner.VerifyBudget, error) {
+	var budget scanner.VerifyBudget
+
+	if value := strings.TrimSpace(r.FormValue("verify_duration")); value != "" {
+		duration, err := time.ParseDuration(value)
+		if err != nil || duration < 0 {
+			return budget, fmt.Errorf("Invalid time budget: %s", value)
+		}
+		budget.Duration = duration
+	}
+
+	bytes, err := throttle.ParseRate(r.FormValue("verify_bytes"))
+	if err != nil {
+		return budget, fmt.Errorf("Invalid byte budget: %s", r.FormValue("verify_bytes"))
+	}
+	budget.Bytes = bytes
+
+	return budget, nil
+}
+
 func (s *Server) handleListScans(w http.ResponseWriter, r *http.Request) {
 	user := s.getCurrentUser(r)
 
@@ -1080,6 +2318,7 @@ func (s *Server) renderSimpleScansList(w http.ResponseWriter, data map[string]in
         .status-running { color: #28a745; font-weight: bold; }
         .status-completed { color: #6c757d; }
         .status-failed { color: #dc3545; font-weight: bold; }
+        .status-partial { color: #fd7e14; font-weight: bold; }
         .logout-form { display: inline; }
     </style>
 </head>
@@ -1090,7 +2329,9 @@ func (s *Server) renderSimpleScansList(w http.ResponseWriter, data map[string]in
             <a href="/">Dashboard</a>
             <a href="/targets">Storage Targets</a>
             <a href="/scans">Scans</a>
-            <a href="/files">Files</a>` + func() string {
+            <a href="/files">Files</a>
+            <a href="/duplicates">Duplicates</a>
+            <a href="/replicas">Replicas</a>` + func() string {
 		if user.IsAdmin {
 			return `<a href="/users">Users</a>`
 		}
@@ -1110,6 +2351,7 @@ func (s *Server) renderSimpleScansList(w http.ResponseWriter, data map[string]in
                 <tr>
                     <th>ID</th>
                     <th>Target</th>
+                    <th>Type</th>
                     <th>Status</th>
                     <th>Started</th>
                     <th>Completed</th>
@@ -1121,7 +2363,7 @@ func (s *Server) renderSimpleScansList(w http.ResponseWriter, data map[string]in
             <tbody>`
 
 	if len(scans) == 0 {
-		html += `<tr><td colspan="8">No scans found.</td></tr>`
+		html += `<tr><td colspan="9">No scans found.</td></tr>`
 	} else {
 		for _, scan := range scans {
 			targetName := targetMap[scan.StorageTargetID]
@@ -1139,6 +2381,7 @@ func (s *Server) renderSimpleScansList(w http.ResponseWriter, data map[string]in
                 <tr>
                     <td>%d</td>
                     <td>%s</td>
+                    <td>%s</td>
                     <td class="%s">%s</td>
                     <td>%s</td>
                     <td>%s</td>
@@ -1148,6 +2391,7 @@ func (s *Server) renderSimpleScansList(w http.ResponseWriter, data map[string]in
                 </tr>`,
 				scan.ID,
 				targetName,
+				scan.ScanType,
 				statusClass,
 				scan.Status,
 				scan.StartedAt.Format("2006-01-02 15:04"),
@@ -1171,6 +2415,9 @@ func (s *Server) renderSimpleScansList(w http.ResponseWriter, data map[string]in
 	w.Write([]byte(html))
 }
 
+// maxScanErrorsShown limits how many file errors the scan view lists
+const maxScanErrorsShown = 200
+
 func (s *Server) handleViewScan(w http.ResponseWriter, r *http.Request) {
 	scanID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
@@ -1203,12 +2450,27 @@ func (s *Server) handleViewScan(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Get per-file errors recorded during this scan
+	scanErrors, _ := s.db.ScanErrors.List(r.Context(), database.ScanErrorFilters{
+		ScanID: &scanID,
+		Limit:  maxScanErrorsShown,
+	})
+	if scanErrors == nil {
+		scanErrors = []*database.ScanError{}
+	}
+
+	// Verifying the time-stamp token is local, so it is checked on each view
+	ts, _, tsErr := timestamp.VerifyScan(r.Context(), s.db, scanID, s.config.TSARoots)
+
 	data := map[string]interface{}{
-		"User":         user,
-		"Scan":         scan,
-		"Target":       target,
-		"ChangeEvents": changeEvents,
-		"FilePathMap":  filePathMap,
+		"User":           user,
+		"Scan":           scan,
+		"Target":         target,
+		"ChangeEvents":   changeEvents,
+		"FilePathMap":    filePathMap,
+		"ScanErrors":     scanErrors,
+		"Timestamp":      ts,
+		"TimestampError": tsErr,
 	}
 
 	if s.templates != nil {
@@ -1229,6 +2491,9 @@ func (s *Server) renderSimpleScanView(w http.ResponseWriter, data map[string]int
 	target := data["Target"].(*database.StorageTarget)
 	changeEvents := data["ChangeEvents"].([]*database.ChangeEvent)
 	filePathMap := data["FilePathMap"].(map[int64]string)
+	scanErrors, _ := data["ScanErrors"].([]*database.ScanError)
+	ts, _ := data["Timestamp"].(*database.ScanTimestamp)
+	tsErr, _ := data["TimestampError"].(error)
 
 	targetName := "Unknown"
 	if target != nil {
@@ -1263,6 +2528,9 @@ func (s *Server) renderSimpleScanView(w http.ResponseWriter, data map[string]int
         .status-running { color: #28a745; font-weight: bold; }
         .status-completed { color: #6c757d; }
         .status-failed { color: #dc3545; font-weight: bold; }
+        .status-partial { color: #fd7e14; font-weight: bold; }
+        .verified { color: #28a745; font-weight: bold; }
+        .checksum { font-family: monospace; font-size: 0.9rem; }
         .stats { display: grid; grid-template-columns: repeat(4, 1fr); gap: 1rem; margin-bottom: 2rem; }
         .stat-card { background: #f8f9fa; padding: 1.5rem; border-radius: 8px; text-align: center; }
         .stat-value { font-size: 2rem; font-weight: bold; color: #007bff; }
@@ -1280,6 +2548,12 @@ func (s *Server) renderSimpleScanView(w http.ResponseWriter, data map[string]int
         .change-modified { color: #ffc107; }
         .change-deleted { color: #dc3545; }
         .change-verified { color: #17a2b8; }
+        .change-metadata_changed { color: #6f42c1; }
+        .change-member_added { color: #28a745; }
+        .change-member_modified { color: #ffc107; }
+        .change-member_deleted { color: #dc3545; }
+        .change-moved { color: #fd7e14; }
+        .change-excluded { color: #6c757d; }
         .logout-form { display: inline; }
     </style>
 </head>
@@ -1290,7 +2564,9 @@ func (s *Server) renderSimpleScanView(w http.ResponseWriter, data map[string]int
             <a href="/">Dashboard</a>
             <a href="/targets">Storage Targets</a>
             <a href="/scans">Scans</a>
-            <a href="/files">Files</a>` + func() string {
+            <a href="/files">Files</a>
+            <a href="/duplicates">Duplicates</a>
+            <a href="/replicas">Replicas</a>` + func() string {
 		if user.IsAdmin {
 			return `<a href="/users">Users</a>`
 		}
@@ -1308,7 +2584,17 @@ func (s *Server) renderSimpleScanView(w http.ResponseWriter, data map[string]int
 
         <div style="margin-bottom: 2rem;">
             <a href="/scans" class="btn btn-secondary">Back to Scans</a>
-            <a href="/targets/` + strconv.FormatInt(scan.StorageTargetID, 10) + `" class="btn btn-secondary">View Target</a>
+            <a href="/targets/` + strconv.FormatInt(scan.StorageTargetID, 10) + `" class="btn btn-secondary">View Target</a>` + func() string {
+		if scan.CompletedAt == nil || s.config.SigningKey == nil {
+			return ""
+		}
+		return `
+            <form method="POST" action="/targets/` + strconv.FormatInt(scan.StorageTargetID, 10) + `/attestations" style="display:inline;">
+                <input type="hidden" name="scope" value="scan">
+                <input type="hidden" name="scan" value="` + strconv.FormatInt(scan.ID, 10) + `">
+                <button type="submit" class="btn">Attest Checksums</button>
+            </form>`
+	}() + `
         </div>
 
         <div class="info-card">
@@ -1320,6 +2606,15 @@ func (s *Server) renderSimpleScanView(w http.ResponseWriter, data map[string]int
                 <div class="info-label">Status:</div>
                 <div class="info-value ` + statusClass + `">` + string(scan.Status) + `</div>
             </div>
+            <div class="info-row">
+                <div class="info-label">Type:</div>
+                <div class="info-value">` + string(scan.ScanType) + func() string {
+		if scan.BudgetExhausted {
+			return " (stopped at budget)"
+		}
+		return ""
+	}() + `</div>
+            </div>
             <div class="info-row">
                 <div class="info-label">Started:</div>
                 <div class="info-value">` + scan.StartedAt.Format("2006-01-02 15:04:05") + `</div>
@@ -1337,6 +2632,86 @@ func (s *Server) renderSimpleScanView(w http.ResponseWriter, data map[string]int
             </div>`
 	}
 
+	if scan.WorkerConcurrency != nil {
+		workers := strconv.Itoa(*scan.WorkerConcurrency)
+		if scan.WorkerConcurrencyAvg != nil && scan.WorkerConcurrencyPeak != nil {
+			workers = fmt.Sprintf("%d final, %.1f average, %d peak", *scan.WorkerConcurrency, *scan.WorkerConcurrencyAvg, *scan.WorkerConcurrencyPeak)
+		}
+		html += `
+            <div class="info-row">
+                <div class="info-label">Checksum Workers:</div>
+                <div class="info-value">` + workers + `</div>
+            </div>`
+	}
+
+	if scan.HashBytesPerSec != nil && *scan.HashBytesPerSec >= 1 {
+		html += `
+            <div class="info-row">
+                <div class="info-label">Hash Throughput:</div>
+                <div class="info-value">` + throttle.FormatRate(int64(*scan.HashBytesPerSec)) + ` (` + formatBytes(scan.BytesHashed) + ` hashed)</div>
+            </div>`
+	}
+
+	if scan.ScanType == database.ScanTypeVerification {
+		mismatchClass := ""
+		if scan.FilesMismatched > 0 {
+			mismatchClass = " status-failed"
+		}
+		html += `
+            <div class="info-row">
+                <div class="info-label">Checksum Mismatches:</div>
+                <div class="info-value` + mismatchClass + `">` + strconv.FormatInt(scan.FilesMismatched, 10) + `</div>
+            </div>`
+	}
+
+	if scan.DirsSkipped > 0 {
+		html += `
+            <div class="info-row">
+                <div class="info-label">Unchanged Directories:</div>
+                <div class="info-value">` + strconv.FormatInt(scan.DirsSkipped, 10) + ` directories not re-read</div>
+            </div>`
+	}
+
+	if scan.FilesMetadataChanged > 0 {
+		html += `
+            <div class="info-row">
+                <div class="info-label">Metadata Changed:</div>
+                <div class="info-value">` + strconv.FormatInt(scan.FilesMetadataChanged, 10) + ` files with changed permissions, owner or attributes</div>
+            </div>`
+	}
+
+	if scan.FilesMoved > 0 {
+		html += `
+            <div class="info-row">
+                <div class="info-label">Moved:</div>
+                <div class="info-value">` + strconv.FormatInt(scan.FilesMoved, 10) + ` files found at a new path, history kept</div>
+            </div>`
+	}
+
+	if scan.FilesSkipped > 0 {
+		html += `
+            <div class="info-row">
+                <div class="info-label">Skipped:</div>
+                <div class="info-value">` + strconv.FormatInt(scan.FilesSkipped, 10) + ` special files (sockets, FIFOs, devices)</div>
+            </div>`
+	}
+
+	if scan.ErrorsCount > 0 {
+		html += `
+            <div class="info-row">
+                <div class="info-label">Errors:</div>
+                <div class="info-value status-failed">` + strconv.Itoa(scan.ErrorsCount) + `</div>
+            </div>`
+	}
+
+	if ts != nil {
+		html += `
+            <div class="info-row">
+                <div class="info-label">Trusted Timestamp:</div>
+                <div class="info-value">` + scanTimestampSummary(ts, tsErr) + `</div>
+            </div>`
+	}
+
 	html += `
         </div>
 
@@ -1359,6 +2734,62 @@ func (s *Server) renderSimpleScanView(w http.ResponseWriter, data map[string]int
             </div>
         </div>
 
+`
+
+	if len(scanErrors) > 0 {
+		heading := "File Errors"
+		if scan.FilesSkipped > 0 {
+			heading = "File Errors and Skipped Files"
+		}
+		html += `
+        <h3>` + heading + `</h3>`
+		if total := int64(scan.ErrorsCount) + scan.FilesSkipped; total > int64(len(scanErrors)) {
+			html += fmt.Sprintf(`<p>Showing the first %d of %d entries.</p>`, len(scanErrors), total)
+		}
+		html += `
+        <table>
+            <thead>
+                <tr>
+                    <th>File Path</th>
+                    <th>Phase</th>
+                    <th>Class</th>
+                    <th>Attempts</th>
+                    <th>Retryable</th>
+                    <th>Message</th>
+                </tr>
+            </thead>
+            <tbody>`
+
+		for _, scanErr := range scanErrors {
+			retryable := "No"
+			if scanErr.Retryable {
+				retryable = "Yes"
+			}
+
+			html += fmt.Sprintf(`
+                <tr>
+                    <td>%s</td>
+                    <td>%s</td>
+                    <td>%s</td>
+                    <td>%d</td>
+                    <td>%s</td>
+                    <td>%s</td>
+                </tr>`,
+				template.HTMLEscapeString(scanErr.Path),
+				scanErr.Phase,
+				template.HTMLEscapeString(scanErr.ErrorClass),
+				scanErr.Attempts,
+				retryable,
+				template.HTMLEscapeString(scanErr.Message),
+			)
+		}
+
+		html += `
+            </tbody>
+        </table>`
+	}
+
+	html += `
         <h3>Change Events</h3>`
 
 	if len(changeEvents) == 0 {
@@ -1381,6 +2812,13 @@ func (s *Server) renderSimpleScanView(w http.ResponseWriter, data map[string]int
 				filePath = "Unknown"
 			}
 
+			if event.MemberPath != nil {
+				filePath += " → " + template.HTMLEscapeString(*event.MemberPath)
+			}
+			if event.OldPath != nil && event.NewPath != nil {
+				filePath = template.HTMLEscapeString(*event.OldPath) + " → " + template.HTMLEscapeString(*event.NewPath)
+			}
+
 			changeClass := "change-" + string(event.EventType)
 			html += fmt.Sprintf(`
                 <tr>
@@ -1475,7 +2913,9 @@ func (s *Server) renderSimpleRunningScans(w http.ResponseWriter, data map[string
             <a href="/">Dashboard</a>
             <a href="/targets">Storage Targets</a>
             <a href="/scans">Scans</a>
-            <a href="/files">Files</a>` + func() string {
+            <a href="/files">Files</a>
+            <a href="/duplicates">Duplicates</a>
+            <a href="/replicas">Replicas</a>` + func() string {
 		if user.IsAdmin {
 			return `<a href="/users">Users</a>`
 		}
@@ -1498,13 +2938,14 @@ func (s *Server) renderSimpleRunningScans(w http.ResponseWriter, data map[string
                     <th>Target</th>
                     <th>Started</th>
                     <th>Files Scanned</th>
+                    <th>Throttle</th>
                     <th>Actions</th>
                 </tr>
             </thead>
             <tbody>`
 
 	if len(runningScans) == 0 {
-		html += `<tr><td colspan="5">No scans currently running.</td></tr>`
+		html += `<tr><td colspan="6">No scans currently running.</td></tr>`
 	} else {
 		for _, scan := range runningScans {
 			targetName := targetMap[scan.TargetID]
@@ -1518,12 +2959,14 @@ func (s *Server) renderSimpleRunningScans(w http.ResponseWriter, data map[string
                     <td>%s</td>
                     <td>%s</td>
                     <td>%d</td>
+                    <td>%s</td>
                     <td><a href="/scans/%d" class="btn btn-sm">View</a></td>
                 </tr>`,
 				scan.ScanID,
 				targetName,
 				scan.StartedAt.Format("2006-01-02 15:04:05"),
 				scan.Progress.FilesScanned,
+				scan.Throttle.String(),
 				scan.ScanID,
 			)
 		}
@@ -1623,7 +3066,9 @@ func (s *Server) renderSimpleFilesBrowse(w http.ResponseWriter, data map[string]
             <a href="/">Dashboard</a>
             <a href="/targets">Storage Targets</a>
             <a href="/scans">Scans</a>
-            <a href="/files">Files</a>` + func() string {
+            <a href="/files">Files</a>
+            <a href="/duplicates">Duplicates</a>
+            <a href="/replicas">Replicas</a>` + func() string {
 		if user.IsAdmin {
 			return `<a href="/users">Users</a>`
 		}
@@ -1736,10 +3181,14 @@ func (s *Server) handleViewFile(w http.ResponseWriter, r *http.Request) {
 	// Get target
 	target, _ := s.db.StorageTargets.GetByID(r.Context(), file.StorageTargetID)
 
+	// Members recorded if the file is an archive
+	members, _ := s.db.ArchiveMembers.ListByFile(r.Context(), fileID)
+
 	data := map[string]interface{}{
-		"User":   user,
-		"File":   file,
-		"Target": target,
+		"User":    user,
+		"File":    file,
+		"Target":  target,
+		"Members": members,
 	}
 
 	if s.templates != nil {
@@ -1758,6 +3207,7 @@ func (s *Server) renderSimpleFileView(w http.ResponseWriter, data map[string]int
 	user := data["User"].(*database.User)
 	file := data["File"].(*database.File)
 	target := data["Target"].(*database.StorageTarget)
+	members, _ := data["Members"].([]*database.ArchiveMember)
 
 	targetName := "Unknown"
 	if target != nil {
@@ -1794,6 +3244,9 @@ func (s *Server) renderSimpleFileView(w http.ResponseWriter, data map[string]int
         .info-row { display: flex; margin-bottom: 0.75rem; }
         .info-label { font-weight: bold; width: 200px; }
         .info-value { flex: 1; font-family: monospace; word-break: break-all; }
+        table { width: 100%; border-collapse: collapse; background: white; margin-top: 1rem; }
+        th, td { padding: 0.75rem; text-align: left; border-bottom: 1px solid #dee2e6; }
+        th { background: #f8f9fa; font-weight: 600; }
         .btn { padding: 0.5rem 1rem; background: #007bff; color: white; border: none; border-radius: 4px; text-decoration: none; display: inline-block; }
         .btn:hover { background: #0056b3; }
         .btn-sm { padding: 0.25rem 0.5rem; font-size: 0.875rem; }
@@ -1809,7 +3262,9 @@ func (s *Server) renderSimpleFileView(w http.ResponseWriter, data map[string]int
             <a href="/">Dashboard</a>
             <a href="/targets">Storage Targets</a>
             <a href="/scans">Scans</a>
-            <a href="/files">Files</a>` + func() string {
+            <a href="/files">Files</a>
+            <a href="/duplicates">Duplicates</a>
+            <a href="/replicas">Replicas</a>` + func() string {
 		if user.IsAdmin {
 			return `<a href="/users">Users</a>`
 		}
@@ -1828,7 +3283,17 @@ func (s *Server) renderSimpleFileView(w http.ResponseWriter, data map[string]int
         <div style="margin-bottom: 2rem;">
             <a href="/files" class="btn btn-secondary">Back to Files</a>
             <a href="/files/` + strconv.FormatInt(file.ID, 10) + `/history" class="btn">View History</a>
-            <a href="/targets/` + strconv.FormatInt(file.StorageTargetID, 10) + `" class="btn btn-secondary">View Target</a>
+            <a href="/targets/` + strconv.FormatInt(file.StorageTargetID, 10) + `" class="btn btn-secondary">View Target</a>` + func() string {
+		if file.CurrentChecksum == nil || file.DeletedAt != nil || s.config.SigningKey == nil {
+			return ""
+		}
+		return `
+            <form method="POST" action="/targets/` + strconv.FormatInt(file.StorageTargetID, 10) + `/attestations" style="display:inline;">
+                <input type="hidden" name="scope" value="file">
+                <input type="hidden" name="path" value="` + template.HTMLEscapeString(file.Path) + `">
+                <button type="submit" class="btn">Attest Checksum</button>
+            </form>`
+	}() + `
         </div>
 
         <div class="info-card">
@@ -1851,12 +3316,55 @@ func (s *Server) renderSimpleFileView(w http.ResponseWriter, data map[string]int
             <div class="info-row">
                 <div class="info-label">Last Checksummed:</div>
                 <div class="info-value">` + lastChecksummed + `</div>
-            </div>
+            </div>` + func() string {
+		metadata := file.FileMetadata.String()
+		if metadata == "" {
+			return ""
+		}
+		return `
+            <div class="info-row">
+                <div class="info-label">Metadata:</div>
+                <div class="info-value">` + template.HTMLEscapeString(metadata) + `</div>
+            </div>`
+	}() + `
             <div class="info-row">
                 <div class="info-label">First Seen:</div>
                 <div class="info-value">` + file.CreatedAt.Format("2006-01-02 15:04:05") + `</div>
             </div>
-        </div>
+        </div>` + func() string {
+		if len(members) == 0 {
+			return ""
+		}
+		rows := ""
+		for _, member := range members {
+			memberChecksum := member.Checksum
+			if len(memberChecksum) > 16 {
+				memberChecksum = memberChecksum[:16] + "..."
+			}
+			rows += `
+                <tr>
+                    <td style="font-family: monospace;">` + template.HTMLEscapeString(member.Path) + `</td>
+                    <td>` + formatBytes(member.Size) + `</td>
+                    <td style="font-family: monospace; font-size: 0.85rem;">` + memberChecksum + `</td>
+                    <td>` + member.LastChecksummedAt.Format("2006-01-02 15:04:05") + `</td>
+                </tr>`
+		}
+		return `
+
+        <h3>Archive Members (` + strconv.Itoa(len(members)) + `)</h3>
+        <table>
+            <thead>
+                <tr>
+                    <th>Member</th>
+                    <th>Size</th>
+                    <th>Checksum (` + template.HTMLEscapeString(members[0].ChecksumType) + `)</th>
+                    <th>Last Checksummed</th>
+                </tr>
+            </thead>
+            <tbody>` + rows + `
+            </tbody>
+        </table>`
+	}() + `
     </div>
 </body>
 </html>`
@@ -1935,6 +3443,13 @@ func (s *Server) renderSimpleFileHistory(w http.ResponseWriter, data map[string]
         .change-modified { color: #ffc107; font-weight: bold; }
         .change-deleted { color: #dc3545; font-weight: bold; }
         .change-verified { color: #17a2b8; }
+        .change-metadata_changed { color: #6f42c1; }
+        .change-member_added { color: #28a745; }
+        .change-member_modified { color: #ffc107; }
+        .change-member_deleted { color: #dc3545; }
+        .change-moved { color: #fd7e14; font-weight: bold; }
+        .change-excluded { color: #6c757d; }
+        .member-path { display: block; font-family: monospace; font-size: 0.85rem; color: #495057; font-weight: normal; }
         .btn { padding: 0.5rem 1rem; background: #007bff; color: white; border: none; border-radius: 4px; text-decoration: none; display: inline-block; }
         .btn:hover { background: #0056b3; }
         .btn-sm { padding: 0.25rem 0.5rem; font-size: 0.875rem; }
@@ -1950,7 +3465,9 @@ func (s *Server) renderSimpleFileHistory(w http.ResponseWriter, data map[string]
             <a href="/">Dashboard</a>
             <a href="/targets">Storage Targets</a>
             <a href="/scans">Scans</a>
-            <a href="/files">Files</a>` + func() string {
+            <a href="/files">Files</a>
+            <a href="/duplicates">Duplicates</a>
+            <a href="/replicas">Replicas</a>` + func() string {
 		if user.IsAdmin {
 			return `<a href="/users">Users</a>`
 		}
@@ -2011,6 +3528,25 @@ func (s *Server) renderSimpleFileHistory(w http.ResponseWriter, data map[string]
 				newChecksum = *event.NewChecksum
 			}
 
+			// The content is unchanged, so show what did change
+			if event.EventType == database.ChangeEventMetadataChanged {
+				if event.OldMetadata != nil {
+					oldChecksum = template.HTMLEscapeString(*event.OldMetadata)
+				}
+				if event.NewMetadata != nil {
+					newChecksum = template.HTMLEscapeString(*event.NewMetadata)
+				}
+			}
+
+			// Member events name the member of the archive that changed
+			eventType := string(event.EventType)
+			if event.MemberPath != nil {
+				eventType += `<span class="member-path">` + template.HTMLEscapeString(*event.MemberPath) + `</span>`
+			}
+			if event.OldPath != nil && event.NewPath != nil {
+				eventType += `<span class="member-path">` + template.HTMLEscapeString(*event.OldPath) + ` → ` + template.HTMLEscapeString(*event.NewPath) + `</span>`
+			}
+
 			html += fmt.Sprintf(`
                 <tr>
                     <td class="%s">%s</td>
@@ -2020,7 +3556,7 @@ func (s *Server) renderSimpleFileHistory(w http.ResponseWriter, data map[string]
                     <td style="font-family: monospace; font-size: 0.85rem;">%s</td>
                 </tr>`,
 				changeClass,
-				event.EventType,
+				eventType,
 				event.DetectedAt.Format("2006-01-02 15:04:05"),
 				event.ScanID,
 				event.ScanID,
@@ -2116,6 +3652,8 @@ func (s *Server) renderSimpleUsersList(w http.ResponseWriter, data map[string]in
             <a href="/targets">Storage Targets</a>
             <a href="/scans">Scans</a>
             <a href="/files">Files</a>
+            <a href="/duplicates">Duplicates</a>
+            <a href="/replicas">Replicas</a>
             <a href="/users">Users</a>
             <span>|</span>
             <span>` + user.Username + `</span>
@@ -2263,6 +3801,8 @@ func (s *Server) renderSimpleUserForm(w http.ResponseWriter, data map[string]int
             <a href="/targets">Storage Targets</a>
             <a href="/scans">Scans</a>
             <a href="/files">Files</a>
+            <a href="/duplicates">Duplicates</a>
+            <a href="/replicas">Replicas</a>
             <a href="/users">Users</a>
             <span>|</span>
             <span>` + user.Username + `</span>
@@ -2444,6 +3984,8 @@ func (s *Server) renderSimpleUserView(w http.ResponseWriter, data map[string]int
             <a href="/targets">Storage Targets</a>
             <a href="/scans">Scans</a>
             <a href="/files">Files</a>
+            <a href="/duplicates">Duplicates</a>
+            <a href="/replicas">Replicas</a>
             <a href="/users">Users</a>
             <span>|</span>
             <span>` + user.Username + `</span>
@@ -0,0 +1,343 @@
+package server
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/jeffanddom/fixity/internal/attest"
+	"github.com/jeffanddom/fixity/internal/database"
+)
+
+// attestationsShown caps the attestations listed for a target
+const attestationsShown = 50
+
+// parseAttestationRequest parses what an attestation request covers
+func parseAttestationRequest(r *http.Request) (attest.Request, error) {
+	scope, err := attest.ParseScope(r.FormValue("scope"))
+	if err != nil {
+		return attest.Request{}, fmt.Errorf("Invalid scope: must be file, subtree or scan")
+	}
+
+	req := attest.Request{Scope: scope, Path: strings.TrimSpace(r.FormValue("path"))}
+	switch scope {
+	case attest.ScopeFile:
+		if req.Path == "" {
+			return req, fmt.Errorf("A file path is required")
+		}
+	case attest.ScopeScan:
+		req.ScanID, err = strconv.ParseInt(strings.TrimSpace(r.FormValue("scan")), 10, 64)
+		if err != nil {
+			return req, fmt.Errorf("Invalid scan ID")
+		}
+	}
+
+	return req, nil
+}
+
+func (s *Server) handleListAttestations(w http.ResponseWriter, r *http.Request) {
+	targetID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid target ID", http.StatusBadRequest)
+		return
+	}
+
+	user := s.getCurrentUser(r)
+	target, err := s.db.StorageTargets.GetByID(r.Context(), targetID)
+	if err != nil || target == nil {
+		http.Error(w, "Target not found", http.StatusNotFound)
+		return
+	}
+
+	attestations, err := s.db.Attestations.ListByTarget(r.Context(), targetID, attestationsShown)
+	if err != nil {
+		http.Error(w, "Failed to load attestations", http.StatusInternalServerError)
+		return
+	}
+
+	data := map[string]interface{}{
+		"User":         user,
+		"Target":       target,
+		"Attestations": attestations,
+		"CanIssue":     s.config.SigningKey != nil,
+	}
+
+	if s.templates != nil {
+		if err := s.templates.ExecuteTemplate(w, "target_attestations.html", data); err != nil {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		return
+	}
+
+	s.renderSimpleAttestations(w, data)
+}
+
+func (s *Server) handleCreateAttestation(w http.ResponseWriter, r *http.Request) {
+	targetID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid target ID", http.StatusBadRequest)
+		return
+	}
+
+	user := s.getCurrentUser(r)
+	target, err := s.db.StorageTargets.GetByID(r.Context(), targetID)
+	if err != nil || target == nil {
+		http.Error(w, "Target not found", http.StatusNotFound)
+		return
+	}
+
+	if s.config.SigningKey == nil {
+		http.Error(w, "Attestation signing is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	req, err := parseAttestationRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Requests name what they cover, so failing to build one is the
+	// caller's error: a missing file, an unhashed one or an unfinished scan
+	att, err := attest.Build(r.Context(), s.db, target, req)
+	if err != nil {
+		http.Error(w, "Cannot attest: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := attest.Issue(r.Context(), s.db, s.config.SigningKey, att, user.Username); err != nil {
+		http.Error(w, "Failed to issue attestation", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/targets/%d/attestations", targetID), http.StatusSeeOther)
+}
+
+// loadAttestation loads the attestation named in the URL, checking it
+// belongs to the target named there
+func (s *Server) loadAttestation(w http.ResponseWriter, r *http.Request) (*database.Attestation, *database.StorageTarget, bool) {
+	targetID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid target ID", http.StatusBadRequest)
+		return nil, nil, false
+	}
+	attestationID, err := strconv.ParseInt(chi.URLParam(r, "attestationID"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid attestation ID", http.StatusBadRequest)
+		return nil, nil, false
+	}
+
+	target, err := s.db.StorageTargets.GetByID(r.Context(), targetID)
+	if err != nil || target == nil {
+		http.Error(w, "Target not found", http.StatusNotFound)
+		return nil, nil, false
+	}
+
+	stored, err := s.db.Attestations.GetByID(r.Context(), attestationID)
+	if err != nil || stored.StorageTargetID != targetID {
+		http.Error(w, "Attestation not found", http.StatusNotFound)
+		return nil, nil, false
+	}
+
+	return stored, target, true
+}
+
+func (s *Server) handleDownloadAttestation(w http.ResponseWriter, r *http.Request) {
+	stored, target, ok := s.loadAttestation(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="`+attest.Filename(stored, target.Name)+`"`)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(stored.Content)
+}
+
+func (s *Server) handleDownloadAttestationSignature(w http.ResponseWriter, r *http.Request) {
+	stored, target, ok := s.loadAttestation(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="`+attest.Filename(stored, target.Name)+`.sig"`)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(stored.Signature))
+}
+
+// attestationSubject describes what a stored attestation covers
+func attestationSubject(stored *database.Attestation) string {
+	switch {
+	case stored.ScanID != nil:
+		return fmt.Sprintf(`Scan <a href="/scans/%d">#%d</a>`, *stored.ScanID, *stored.ScanID)
+	case stored.Path != nil && *stored.Path == "":
+		return "Whole target"
+	case stored.Scope == string(attest.ScopeFile):
+		return `File <span class="checksum">` + template.HTMLEscapeString(*stored.Path) + `</span>`
+	case stored.Path != nil:
+		return `Directory <span class="checksum">` + template.HTMLEscapeString(*stored.Path) + `</span>`
+	}
+	return template.HTMLEscapeString(stored.Scope)
+}
+
+func (s *Server) renderSimpleAttestations(w http.ResponseWriter, data map[string]interface{}) {
+	w.Header().Set("Content-Type", "text/html")
+	user := data["User"].(*database.User)
+	target := data["Target"].(*database.StorageTarget)
+	attestations := data["Attestations"].([]*database.Attestation)
+	canIssue := data["CanIssue"].(bool)
+
+	targetPath := "/targets/" + strconv.FormatInt(target.ID, 10)
+
+	html := `
+<!DOCTYPE html>
+<html>
+<head>
+    <title>Fixity - Attestations</title>
+    <style>
+        body { font-family: Arial, sans-serif; margin: 0; padding: 0; }
+        .header { background: #2c3e50; color: white; padding: 1rem 2rem; display: flex; justify-content: space-between; align-items: center; }
+        .nav { display: flex; gap: 1rem; }
+        .nav a { color: white; text-decoration: none; }
+        .nav a:hover { text-decoration: underline; }
+        .container { padding: 2rem; max-width: 1200px; margin: 0 auto; }
+        .info-card { background: #f8f9fa; padding: 1.5rem; border-radius: 8px; margin-bottom: 1rem; }
+        .form-row { display: flex; gap: 1rem; align-items: flex-end; flex-wrap: wrap; }
+        .form-group label { display: block; font-weight: bold; margin-bottom: 0.25rem; }
+        .form-group input, .form-group select { padding: 0.4rem; border: 1px solid #ced4da; border-radius: 4px; }
+        .btn { padding: 0.5rem 1rem; background: #007bff; color: white; border: none; border-radius: 4px; text-decoration: none; display: inline-block; cursor: pointer; }
+        .btn:hover { background: #0056b3; }
+        .btn-sm { padding: 0.25rem 0.5rem; font-size: 0.875rem; }
+        .btn-secondary { background: #6c757d; }
+        .btn-secondary:hover { background: #5a6268; }
+        table { width: 100%; border-collapse: collapse; background: white; margin-top: 1rem; }
+        th, td { padding: 0.75rem; text-align: left; border-bottom: 1px solid #dee2e6; }
+        th { background: #f8f9fa; font-weight: 600; }
+        tr:hover { background: #f8f9fa; }
+        .checksum { font-family: monospace; font-size: 0.9rem; }
+        .logout-form { display: inline; }
+        small { color: #6c757d; }
+    </style>
+</head>
+<body>
+    <div class="header">
+        <h1>Fixity</h1>
+        <div class="nav">
+            <a href="/">Dashboard</a>
+            <a href="/targets">Storage Targets</a>
+            <a href="/scans">Scans</a>
+            <a href="/files">Files</a>
+            <a href="/duplicates">Duplicates</a>
+            <a href="/replicas">Replicas</a>` + func() string {
+		if user.IsAdmin {
+			return `<a href="/users">Users</a>`
+		}
+		return ""
+	}() + `
+            <span>|</span>
+            <span>` + user.Username + `</span>
+            <form method="POST" action="/logout" class="logout-form">
+                <button type="submit" class="btn btn-sm">Logout</button>
+            </form>
+        </div>
+    </div>
+    <div class="container">
+        <h2>Attestations: ` + template.HTMLEscapeString(target.Name) + `</h2>
+
+        <a href="` + targetPath + `" class="btn btn-secondary">Back to Target</a>
+
+        <div class="info-card" style="margin-top: 1rem;">`
+
+	if canIssue {
+		html += `
+            <form method="POST" action="` + targetPath + `/attestations">
+                <div class="form-row">
+                    <div class="form-group">
+                        <label for="scope">Attest</label>
+                        <select id="scope" name="scope">
+                            <option value="file">File</option>
+                            <option value="subtree">Directory</option>
+                            <option value="scan">Scan</option>
+                        </select>
+                    </div>
+                    <div class="form-group">
+                        <label for="path">Path</label>
+                        <input type="text" id="path" name="path" placeholder="photos/2024" size="40">
+                    </div>
+                    <div class="form-group">
+                        <label for="scan">Scan ID</label>
+                        <input type="text" id="scan" name="scan" size="8">
+                    </div>
+                    <button type="submit" class="btn">Issue Attestation</button>
+                </div>
+                <small>An attestation is a signed statement of the digests the files had when Fixity hashed them. Leave the path empty to attest the whole target. Hand the attestation over with its signature; both can be checked with <code>fixity attest verify</code>.</small>
+            </form>`
+	} else {
+		html += `
+            <p>Attestation signing is not configured, so attestations cannot be issued.</p>`
+	}
+
+	html += `
+        </div>`
+
+	if len(attestations) == 0 {
+		html += `
+        <p>No attestations yet for this target.</p>`
+	} else {
+		html += `
+        <table>
+            <thead>
+                <tr>
+                    <th>Covers</th>
+                    <th>Files</th>
+                    <th>Size</th>
+                    <th>Issued</th>
+                    <th>By</th>
+                    <th>Key</th>
+                    <th>Actions</th>
+                </tr>
+            </thead>
+            <tbody>`
+
+		for _, stored := range attestations {
+			attestationPath := fmt.Sprintf("%s/attestations/%d", targetPath, stored.ID)
+			html += fmt.Sprintf(`
+                <tr>
+                    <td>%s</td>
+                    <td>%d</td>
+                    <td>%s</td>
+                    <td>%s</td>
+                    <td>%s</td>
+                    <td class="checksum">%s</td>
+                    <td>
+                        <a href="%s" class="btn btn-sm">Download</a>
+                        <a href="%s/signature" class="btn btn-sm btn-secondary">Signature</a>
+                    </td>
+                </tr>`,
+				attestationSubject(stored),
+				stored.FileCount,
+				formatBytes(stored.Size),
+				stored.IssuedAt.Format("2006-01-02 15:04"),
+				template.HTMLEscapeString(stored.IssuedBy),
+				template.HTMLEscapeString(stored.KeyID),
+				attestationPath,
+				attestationPath,
+			)
+		}
+
+		html += `
+            </tbody>
+        </table>`
+	}
+
+	html += `
+    </div>
+</body>
+</html>`
+
+	w.Write([]byte(html))
+}
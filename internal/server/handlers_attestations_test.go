@@ -0,0 +1,107 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/jeffanddom/fixity/internal/attest"
+	"github.com/jeffanddom/fixity/internal/signing"
+	"github.com/jeffanddom/fixity/tests/testutil"
+)
+
+func TestHandleAttestations(t *testing.T) {
+	server := setupTestServer(t)
+	ctx := context.Background()
+	target := testutil.MustCreateStorageTarget(t, server.db, "archive")
+	testutil.MustCreateFile(t, server.db, target.ID, "docs/contract.pdf")
+
+	user, token := createAuthenticatedUser(t, server)
+	defer server.db.Users.Delete(ctx, user.ID)
+
+	attestationsPath := fmt.Sprintf("/targets/%d/attestations", target.ID)
+	fileForm := url.Values{"scope": {"file"}, "path": {"docs/contract.pdf"}}
+
+	t.Run("refuses to issue without a signing key", func(t *testing.T) {
+		w, _ := makeAuthenticatedRequest(server, http.MethodPost, attestationsPath, token, fileForm)
+
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("expected status 503, got %d", w.Code)
+		}
+	})
+
+	key, err := signing.Generate()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	server.config.SigningKey = key
+
+	t.Run("rejects what cannot be attested", func(t *testing.T) {
+		for _, form := range []url.Values{
+			{"scope": {"target"}},
+			{"scope": {"file"}},
+			{"scope": {"file"}, "path": {"docs/missing.pdf"}},
+			{"scope": {"scan"}, "scan": {"abc"}},
+		} {
+			w, _ := makeAuthenticatedRequest(server, http.MethodPost, attestationsPath, token, form)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected status 400 for %v, got %d", form, w.Code)
+			}
+		}
+	})
+
+	t.Run("issues and lists an attestation", func(t *testing.T) {
+		w, _ := makeAuthenticatedRequest(server, http.MethodPost, attestationsPath, token, fileForm)
+		if w.Code != http.StatusSeeOther {
+			t.Fatalf("expected status 303, got %d", w.Code)
+		}
+
+		w, _ = makeAuthenticatedRequest(server, http.MethodGet, attestationsPath, token, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+		body := w.Body.String()
+		if !strings.Contains(body, "docs/contract.pdf") || !strings.Contains(body, key.ID()) {
+			t.Error("response should list the attestation with its path and key")
+		}
+	})
+
+	t.Run("downloads an attestation that verifies against its signature", func(t *testing.T) {
+		attestations, _ := server.db.Attestations.ListByTarget(ctx, target.ID, 10)
+		if len(attestations) != 1 {
+			t.Fatalf("expected 1 attestation, got %d", len(attestations))
+		}
+		attestationPath := fmt.Sprintf("%s/%d", attestationsPath, attestations[0].ID)
+
+		content, _ := makeAuthenticatedRequest(server, http.MethodGet, attestationPath, token, nil)
+		if content.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", content.Code)
+		}
+		signature, _ := makeAuthenticatedRequest(server, http.MethodGet, attestationPath+"/signature", token, nil)
+		if signature.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", signature.Code)
+		}
+
+		att, _, err := attest.Verify(ctx, server.db, content.Body.Bytes(), signature.Body.Bytes())
+		if err != nil {
+			t.Fatalf("expected the download to verify: %v", err)
+		}
+		if len(att.Files) != 1 || att.Files[0].Path != "docs/contract.pdf" || att.IssuedBy != user.Username {
+			t.Errorf("unexpected attestation: %+v", att)
+		}
+	})
+
+	t.Run("returns 404 for another target's attestation", func(t *testing.T) {
+		other := testutil.MustCreateStorageTarget(t, server.db, "other")
+		attestations, _ := server.db.Attestations.ListByTarget(ctx, target.ID, 10)
+		path := fmt.Sprintf("/targets/%d/attestations/%d", other.ID, attestations[0].ID)
+		w, _ := makeAuthenticatedRequest(server, http.MethodGet, path, token, nil)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", w.Code)
+		}
+	})
+}
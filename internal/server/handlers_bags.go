@@ -0,0 +1,230 @@
+package server
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/jeffanddom/fixity/internal/database"
+)
+
+// maxBagProblemsShown caps the problems listed for a bag validation
+const maxBagProblemsShown = 500
+
+func (s *Server) handleViewBagValidation(w http.ResponseWriter, r *http.Request) {
+	targetID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid target ID", http.StatusBadRequest)
+		return
+	}
+	validationID, err := strconv.ParseInt(chi.URLParam(r, "bagID"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid bag validation ID", http.StatusBadRequest)
+		return
+	}
+
+	user := s.getCurrentUser(r)
+	target, err := s.db.StorageTargets.GetByID(r.Context(), targetID)
+	if err != nil || target == nil {
+		http.Error(w, "Target not found", http.StatusNotFound)
+		return
+	}
+
+	validation, err := s.db.BagValidations.GetByID(r.Context(), validationID)
+	if err != nil || validation.StorageTargetID != targetID {
+		http.Error(w, "Bag validation not found", http.StatusNotFound)
+		return
+	}
+
+	problems, err := s.db.BagValidations.ListProblems(r.Context(), validationID, maxBagProblemsShown)
+	if err != nil {
+		http.Error(w, "Failed to load bag problems", http.StatusInternalServerError)
+		return
+	}
+
+	data := map[string]interface{}{
+		"User":       user,
+		"Target":     target,
+		"Validation": validation,
+		"Problems":   problems,
+	}
+
+	if s.templates != nil {
+		if err := s.templates.ExecuteTemplate(w, "bag_validation.html", data); err != nil {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		return
+	}
+
+	s.renderSimpleBagValidation(w, data)
+}
+
+func (s *Server) renderSimpleBagValidation(w http.ResponseWriter, data map[string]interface{}) {
+	w.Header().Set("Content-Type", "text/html")
+	user := data["User"].(*database.User)
+	target := data["Target"].(*database.StorageTarget)
+	validation := data["Validation"].(*database.BagValidation)
+	problems := data["Problems"].([]*database.BagProblem)
+
+	bagPath := validation.Path
+	if bagPath == "" {
+		bagPath = "/"
+	}
+
+	result, resultClass := "Valid", "status-valid"
+	if !validation.Valid {
+		result, resultClass = "Invalid", "status-invalid"
+	}
+
+	version := "Unknown"
+	if validation.BagItVersion != nil && *validation.BagItVersion != "" {
+		version = *validation.BagItVersion
+	}
+
+	html := `
+<!DOCTYPE html>
+<html>
+<head>
+    <title>Fixity - Bag Validation</title>
+    <style>
+        body { font-family: Arial, sans-serif; margin: 0; padding: 0; }
+        .header { background: #2c3e50; color: white; padding: 1rem 2rem; display: flex; justify-content: space-between; align-items: center; }
+        .nav { display: flex; gap: 1rem; }
+        .nav a { color: white; text-decoration: none; }
+        .nav a:hover { text-decoration: underline; }
+        .container { padding: 2rem; max-width: 1200px; margin: 0 auto; }
+        .info-card { background: #f8f9fa; padding: 1.5rem; border-radius: 8px; margin-bottom: 1rem; }
+        .info-row { display: flex; margin-bottom: 0.75rem; }
+        .info-label { font-weight: bold; width: 200px; }
+        .info-value { flex: 1; }
+        .btn { padding: 0.5rem 1rem; background: #007bff; color: white; border: none; border-radius: 4px; text-decoration: none; display: inline-block; cursor: pointer; }
+        .btn:hover { background: #0056b3; }
+        .btn-sm { padding: 0.25rem 0.5rem; font-size: 0.875rem; }
+        .btn-secondary { background: #6c757d; }
+        .btn-secondary:hover { background: #5a6268; }
+        .status-valid { color: #28a745; font-weight: bold; }
+        .status-invalid { color: #dc3545; font-weight: bold; }
+        table { width: 100%; border-collapse: collapse; background: white; margin-top: 1rem; }
+        th, td { padding: 0.75rem; text-align: left; border-bottom: 1px solid #dee2e6; }
+        th { background: #f8f9fa; font-weight: 600; }
+        tr:hover { background: #f8f9fa; }
+        .checksum, .file-path { font-family: monospace; font-size: 0.9rem; word-break: break-all; }
+        .logout-form { display: inline; }
+    </style>
+</head>
+<body>
+    <div class="header">
+        <h1>Fixity</h1>
+        <div class="nav">
+            <a href="/">Dashboard</a>
+            <a href="/targets">Storage Targets</a>
+            <a href="/scans">Scans</a>
+            <a href="/files">Files</a>
+            <a href="/duplicates">Duplicates</a>
+            <a href="/replicas">Replicas</a>` + func() string {
+		if user.IsAdmin {
+			return `<a href="/users">Users</a>`
+		}
+		return ""
+	}() + `
+            <span>|</span>
+            <span>` + user.Username + `</span>
+            <form method="POST" action="/logout" class="logout-form">
+                <button type="submit" class="btn btn-sm">Logout</button>
+            </form>
+        </div>
+    </div>
+    <div class="container">
+        <h2>Bag Validation</h2>
+
+        <div class="info-card">
+            <div class="info-row">
+                <div class="info-label">Target:</div>
+                <div class="info-value">` + template.HTMLEscapeString(target.Name) + `</div>
+            </div>
+            <div class="info-row">
+                <div class="info-label">Bag:</div>
+                <div class="info-value file-path">` + template.HTMLEscapeString(bagPath) + `</div>
+            </div>
+            <div class="info-row">
+                <div class="info-label">Result:</div>
+                <div class="info-value ` + resultClass + `">` + result + `</div>
+            </div>
+            <div class="info-row">
+                <div class="info-label">BagIt Version:</div>
+                <div class="info-value">` + template.HTMLEscapeString(version) + `</div>
+            </div>
+            <div class="info-row">
+                <div class="info-label">Payload Files:</div>
+                <div class="info-value">` + strconv.Itoa(validation.PayloadFiles) + `</div>
+            </div>
+            <div class="info-row">
+                <div class="info-label">Validated:</div>
+                <div class="info-value">` + validation.ValidatedAt.Format("2006-01-02 15:04:05") + `
+                    (<a href="/scans/` + strconv.FormatInt(validation.ScanID, 10) + `">scan #` + strconv.FormatInt(validation.ScanID, 10) + `</a>)</div>
+            </div>
+        </div>
+
+        <a href="/targets/` + strconv.FormatInt(target.ID, 10) + `" class="btn btn-secondary">Back to Target</a>`
+
+	if validation.ProblemCount == 0 {
+		html += `
+        <p>No problems found.</p>`
+	} else {
+		if validation.ProblemCount > len(problems) {
+			html += fmt.Sprintf(`
+        <p>Showing %d of %d problems.</p>`, len(problems), validation.ProblemCount)
+		}
+
+		html += `
+        <table>
+            <thead>
+                <tr>
+                    <th>Path</th>
+                    <th>Problem</th>
+                    <th>Details</th>
+                </tr>
+            </thead>
+            <tbody>`
+
+		for _, problem := range problems {
+			path := problem.Path
+			if path == "" {
+				path = "(bag)"
+			}
+
+			details := template.HTMLEscapeString(problem.Message)
+			if problem.Expected != nil && problem.Actual != nil {
+				details += fmt.Sprintf(`<br><span class="checksum">expected %s</span><br><span class="checksum">found %s</span>`,
+					template.HTMLEscapeString(*problem.Expected),
+					template.HTMLEscapeString(*problem.Actual))
+			}
+
+			html += fmt.Sprintf(`
+                <tr>
+                    <td class="file-path">%s</td>
+                    <td>%s</td>
+                    <td>%s</td>
+                </tr>`,
+				template.HTMLEscapeString(path),
+				template.HTMLEscapeString(problem.Kind),
+				details,
+			)
+		}
+
+		html += `
+            </tbody>
+        </table>`
+	}
+
+	html += `
+    </div>
+</body>
+</html>`
+
+	w.Write([]byte(html))
+}
@@ -0,0 +1,534 @@
+package server
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/jeffanddom/fixity/internal/database"
+	"github.com/jeffanddom/fixity/internal/throttle"
+)
+
+// duplicatesPageSize is the number of groups, or files within a group, shown
+// per page
+const duplicatesPageSize = 100
+
+// duplicateScope is the targets and minimum size a duplicates report is
+// limited to, carried from page to page in the query string
+type duplicateScope struct {
+	TargetIDs []int64
+	MinSize   int64
+}
+
+func parseDuplicateScope(r *http.Request) (duplicateScope, error) {
+	var scope duplicateScope
+	query := r.URL.Query()
+
+	for _, value := range query["target"] {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return scope, fmt.Errorf("Invalid target ID")
+		}
+		scope.TargetIDs = append(scope.TargetIDs, id)
+	}
+
+	minSize, err := throttle.ParseRate(query.Get("min_size"))
+	if err != nil {
+		return scope, fmt.Errorf("Invalid minimum size: %s", query.Get("min_size"))
+	}
+	scope.MinSize = minSize
+
+	return scope, nil
+}
+
+// values returns the scope as query parameters
+func (d duplicateScope) values() url.Values {
+	values := url.Values{}
+	for _, id := range d.TargetIDs {
+		values.Add("target", strconv.FormatInt(id, 10))
+	}
+	if d.MinSize > 0 {
+		values.Set("min_size", strconv.FormatInt(d.MinSize, 10))
+	}
+	return values
+}
+
+// contentValues adds a content key to query parameters under the given
+// prefix
+func contentValues(values url.Values, prefix string, key database.ContentKey) url.Values {
+	values.Set(prefix+"type", key.ChecksumType)
+	values.Set(prefix+"checksum", key.Checksum)
+	values.Set(prefix+"size", strconv.FormatInt(key.Size, 10))
+	return values
+}
+
+// parseContentKey reads a content key from query parameters under the given
+// prefix. ok is false if none was given.
+func parseContentKey(r *http.Request, prefix string) (key database.ContentKey, ok bool, err error) {
+	query := r.URL.Query()
+	key.ChecksumType = query.Get(prefix + "type")
+	key.Checksum = query.Get(prefix + "checksum")
+	size := query.Get(prefix + "size")
+	if key.ChecksumType == "" && key.Checksum == "" && size == "" {
+		return key, false, nil
+	}
+
+	key.Size, err = strconv.ParseInt(size, 10, 64)
+	if err != nil || key.Size < 0 || key.ChecksumType == "" || key.Checksum == "" {
+		return key, false, fmt.Errorf("Invalid content key")
+	}
+	return key, true, nil
+}
+
+func (s *Server) handleListDuplicates(w http.ResponseWriter, r *http.Request) {
+	user := s.getCurrentUser(r)
+
+	scope, err := parseDuplicateScope(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	after, paged, err := parseContentKey(r, "after_")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filters := database.DuplicateFilters{
+		TargetIDs: scope.TargetIDs,
+		MinSize:   scope.MinSize,
+		After:     after,
+		Limit:     duplicatesPageSize + 1,
+	}
+	groups, err := s.db.Files.ListDuplicateGroups(r.Context(), filters)
+	if err != nil {
+		http.Error(w, "Failed to list duplicates", http.StatusInternalServerError)
+		return
+	}
+
+	nextPage := ""
+	if len(groups) > duplicatesPageSize {
+		groups = groups[:duplicatesPageSize]
+		next := contentValues(scope.values(), "after_", groups[len(groups)-1].ContentKey)
+		nextPage = "/duplicates?" + next.Encode()
+	}
+
+	// The totals read every hashed file in scope, so only the first page
+	// computes them
+	var summary *database.DuplicateSummary
+	if !paged {
+		summary, err = s.db.Files.SummarizeDuplicates(r.Context(), filters)
+		if err != nil {
+			http.Error(w, "Failed to summarize duplicates", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	targets, _ := s.db.StorageTargets.ListAll(r.Context())
+
+	data := map[string]interface{}{
+		"User":     user,
+		"Groups":   groups,
+		"Summary":  summary,
+		"Targets":  targets,
+		"Scope":    scope,
+		"NextPage": nextPage,
+	}
+
+	if s.templates != nil {
+		if err := s.templates.ExecuteTemplate(w, "duplicates_list.html", data); err != nil {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		return
+	}
+
+	s.renderSimpleDuplicatesList(w, data)
+}
+
+func (s *Server) renderSimpleDuplicatesList(w http.ResponseWriter, data map[string]interface{}) {
+	w.Header().Set("Content-Type", "text/html")
+	user := data["User"].(*database.User)
+	groups := data["Groups"].([]*database.DuplicateGroup)
+	summary := data["Summary"].(*database.DuplicateSummary)
+	targets := data["Targets"].([]*database.StorageTarget)
+	scope := data["Scope"].(duplicateScope)
+	nextPage := data["NextPage"].(string)
+
+	selected := make(map[int64]bool, len(scope.TargetIDs))
+	for _, id := range scope.TargetIDs {
+		selected[id] = true
+	}
+
+	minSize := ""
+	if scope.MinSize > 0 {
+		minSize = strconv.FormatInt(scope.MinSize, 10)
+	}
+
+	html := `
+<!DOCTYPE html>
+<html>
+<head>
+    <title>Fixity - Duplicates</title>
+    <style>
+        body { font-family: Arial, sans-serif; margin: 0; padding: 0; }
+        .header { background: #2c3e50; color: white; padding: 1rem 2rem; display: flex; justify-content: space-between; align-items: center; }
+        .nav { display: flex; gap: 1rem; }
+        .nav a { color: white; text-decoration: none; }
+        .nav a:hover { text-decoration: underline; }
+        .container { padding: 2rem; max-width: 1200px; margin: 0 auto; }
+        .filter-bar { background: #f8f9fa; padding: 1rem; border-radius: 4px; margin-bottom: 1rem; }
+        .filter-bar label { margin-right: 1rem; }
+        .filter-bar input[type="text"] { padding: 0.5rem; border: 1px solid #dee2e6; border-radius: 4px; width: 8rem; }
+        .info-card { background: #f8f9fa; padding: 1.5rem; border-radius: 8px; margin-bottom: 1rem; }
+        .info-row { display: flex; margin-bottom: 0.75rem; }
+        .info-label { font-weight: bold; width: 200px; }
+        .info-value { flex: 1; }
+        .btn { padding: 0.5rem 1rem; background: #007bff; color: white; border: none; border-radius: 4px; text-decoration: none; display: inline-block; cursor: pointer; }
+        .btn:hover { background: #0056b3; }
+        .btn-sm { padding: 0.25rem 0.5rem; font-size: 0.875rem; }
+        table { width: 100%; border-collapse: collapse; background: white; margin-top: 1rem; }
+        th, td { padding: 0.75rem; text-align: left; border-bottom: 1px solid #dee2e6; }
+        th { background: #f8f9fa; font-weight: 600; }
+        tr:hover { background: #f8f9fa; }
+        .checksum { font-family: monospace; font-size: 0.9rem; }
+        .pagination { margin-top: 1rem; }
+        .logout-form { display: inline; }
+    </style>
+</head>
+<body>
+    <div class="header">
+        <h1>Fixity</h1>
+        <div class="nav">
+            <a href="/">Dashboard</a>
+            <a href="/targets">Storage Targets</a>
+            <a href="/scans">Scans</a>
+            <a href="/files">Files</a>
+            <a href="/duplicates">Duplicates</a>
+            <a href="/replicas">Replicas</a>` + func() string {
+		if user.IsAdmin {
+			return `<a href="/users">Users</a>`
+		}
+		return ""
+	}() + `
+            <span>|</span>
+            <span>` + user.Username + `</span>
+            <form method="POST" action="/logout" class="logout-form">
+                <button type="submit" class="btn btn-sm">Logout</button>
+            </form>
+        </div>
+    </div>
+    <div class="container">
+        <h2>Duplicate Content</h2>
+        <p>Active files sharing a checksum and size, grouped by content. Files not yet hashed are not included.</p>
+
+        <div class="filter-bar">
+            <form method="GET" action="/duplicates">
+                <strong>Targets:</strong>`
+
+	for _, target := range targets {
+		checked := ""
+		if selected[target.ID] {
+			checked = " checked"
+		}
+		html += fmt.Sprintf(`
+                <label><input type="checkbox" name="target" value="%d"%s> %s</label>`,
+			target.ID, checked, template.HTMLEscapeString(target.Name))
+	}
+
+	html += `
+                <label>Minimum size: <input type="text" name="min_size" value="` + minSize + `" placeholder="e.g. 1MB"></label>
+                <button type="submit" class="btn btn-sm">Filter</button>
+            </form>
+        </div>`
+
+	if summary != nil {
+		html += `
+        <div class="info-card">
+            <div class="info-row">
+                <div class="info-label">Duplicate Groups:</div>
+                <div class="info-value">` + strconv.FormatInt(summary.Groups, 10) + `</div>
+            </div>
+            <div class="info-row">
+                <div class="info-label">Files in Groups:</div>
+                <div class="info-value">` + strconv.FormatInt(summary.Copies, 10) + `</div>
+            </div>
+            <div class="info-row">
+                <div class="info-label">Wasted Space:</div>
+                <div class="info-value">` + formatBytes(summary.WastedBytes) + `</div>
+            </div>
+        </div>`
+	}
+
+	html += `
+        <table>
+            <thead>
+                <tr>
+                    <th>Checksum</th>
+                    <th>Size</th>
+                    <th>Copies</th>
+                    <th>Targets</th>
+                    <th>Wasted</th>
+                    <th>Actions</th>
+                </tr>
+            </thead>
+            <tbody>`
+
+	if len(groups) == 0 {
+		html += `<tr><td colspan="6">No duplicates found.</td></tr>`
+	} else {
+		for _, group := range groups {
+			checksum := group.Checksum
+			if len(checksum) > 16 {
+				checksum = checksum[:16] + "..."
+			}
+			link := "/duplicates/group?" + contentValues(scope.values(), "", group.ContentKey).Encode()
+
+			html += fmt.Sprintf(`
+                <tr>
+                    <td class="checksum">%s:%s</td>
+                    <td>%s</td>
+                    <td>%d</td>
+                    <td>%d</td>
+                    <td>%s</td>
+                    <td><a href="%s" class="btn btn-sm">View Files</a></td>
+                </tr>`,
+				template.HTMLEscapeString(group.ChecksumType),
+				template.HTMLEscapeString(checksum),
+				formatBytes(group.Size),
+				group.Copies,
+				group.Targets,
+				formatBytes(group.WastedBytes()),
+				template.HTMLEscapeString(link),
+			)
+		}
+	}
+
+	html += `
+            </tbody>
+        </table>`
+
+	if nextPage != "" {
+		html += `
+        <div class="pagination">
+            <a href="` + template.HTMLEscapeString(nextPage) + `" class="btn">Next Page</a>
+        </div>`
+	}
+
+	html += `
+    </div>
+</body>
+</html>`
+
+	w.Write([]byte(html))
+}
+
+func (s *Server) handleViewDuplicateGroup(w http.ResponseWriter, r *http.Request) {
+	user := s.getCurrentUser(r)
+
+	scope, err := parseDuplicateScope(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	key, ok, err := parseContentKey(r, "")
+	if err != nil || !ok {
+		http.Error(w, "Invalid content key", http.StatusBadRequest)
+		return
+	}
+
+	var afterID int64
+	if after := r.URL.Query().Get("after"); after != "" {
+		afterID, err = strconv.ParseInt(after, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid file ID", http.StatusBadRequest)
+			return
+		}
+	}
+
+	files, err := s.db.Files.ListByContent(r.Context(), key, scope.TargetIDs, afterID, duplicatesPageSize+1)
+	if err != nil {
+		http.Error(w, "Failed to list files", http.StatusInternalServerError)
+		return
+	}
+
+	nextPage := ""
+	if len(files) > duplicatesPageSize {
+		files = files[:duplicatesPageSize]
+		next := contentValues(scope.values(), "", key)
+		next.Set("after", strconv.FormatInt(files[len(files)-1].ID, 10))
+		nextPage = "/duplicates/group?" + next.Encode()
+	}
+
+	targets, _ := s.db.StorageTargets.ListAll(r.Context())
+	targetMap := make(map[int64]string)
+	for _, t := range targets {
+		targetMap[t.ID] = t.Name
+	}
+
+	data := map[string]interface{}{
+		"User":      user,
+		"Key":       key,
+		"Files":     files,
+		"TargetMap": targetMap,
+		"Scope":     scope,
+		"NextPage":  nextPage,
+	}
+
+	if s.templates != nil {
+		if err := s.templates.ExecuteTemplate(w, "duplicates_group.html", data); err != nil {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		return
+	}
+
+	s.renderSimpleDuplicateGroup(w, data)
+}
+
+func (s *Server) renderSimpleDuplicateGroup(w http.ResponseWriter, data map[string]interface{}) {
+	w.Header().Set("Content-Type", "text/html")
+	user := data["User"].(*database.User)
+	key := data["Key"].(database.ContentKey)
+	files := data["Files"].([]*database.File)
+	targetMap := data["TargetMap"].(map[int64]string)
+	scope := data["Scope"].(duplicateScope)
+	nextPage := data["NextPage"].(string)
+
+	back := "/duplicates"
+	if values := scope.values(); len(values) > 0 {
+		back += "?" + values.Encode()
+	}
+
+	html := `
+<!DOCTYPE html>
+<html>
+<head>
+    <title>Fixity - Duplicate Group</title>
+    <style>
+        body { font-family: Arial, sans-serif; margin: 0; padding: 0; }
+        .header { background: #2c3e50; color: white; padding: 1rem 2rem; display: flex; justify-content: space-between; align-items: center; }
+        .nav { display: flex; gap: 1rem; }
+        .nav a { color: white; text-decoration: none; }
+        .nav a:hover { text-decoration: underline; }
+        .container { padding: 2rem; max-width: 1200px; margin: 0 auto; }
+        .info-card { background: #f8f9fa; padding: 1.5rem; border-radius: 8px; margin-bottom: 1rem; }
+        .info-row { display: flex; margin-bottom: 0.75rem; }
+        .info-label { font-weight: bold; width: 200px; }
+        .info-value { flex: 1; }
+        .btn { padding: 0.5rem 1rem; background: #007bff; color: white; border: none; border-radius: 4px; text-decoration: none; display: inline-block; cursor: pointer; }
+        .btn:hover { background: #0056b3; }
+        .btn-sm { padding: 0.25rem 0.5rem; font-size: 0.875rem; }
+        .btn-secondary { background: #6c757d; }
+        .btn-secondary:hover { background: #5a6268; }
+        table { width: 100%; border-collapse: collapse; background: white; margin-top: 1rem; }
+        th, td { padding: 0.75rem; text-align: left; border-bottom: 1px solid #dee2e6; }
+        th { background: #f8f9fa; font-weight: 600; }
+        tr:hover { background: #f8f9fa; }
+        .checksum, .file-path { font-family: monospace; font-size: 0.9rem; }
+        .pagination { margin-top: 1rem; }
+        .logout-form { display: inline; }
+    </style>
+</head>
+<body>
+    <div class="header">
+        <h1>Fixity</h1>
+        <div class="nav">
+            <a href="/">Dashboard</a>
+            <a href="/targets">Storage Targets</a>
+            <a href="/scans">Scans</a>
+            <a href="/files">Files</a>
+            <a href="/duplicates">Duplicates</a>
+            <a href="/replicas">Replicas</a>` + func() string {
+		if user.IsAdmin {
+			return `<a href="/users">Users</a>`
+		}
+		return ""
+	}() + `
+            <span>|</span>
+            <span>` + user.Username + `</span>
+            <form method="POST" action="/logout" class="logout-form">
+                <button type="submit" class="btn btn-sm">Logout</button>
+            </form>
+        </div>
+    </div>
+    <div class="container">
+        <h2>Duplicate Group</h2>
+
+        <div class="info-card">
+            <div class="info-row">
+                <div class="info-label">Checksum:</div>
+                <div class="info-value checksum">` + template.HTMLEscapeString(key.ChecksumType+":"+key.Checksum) + `</div>
+            </div>
+            <div class="info-row">
+                <div class="info-label">Size:</div>
+                <div class="info-value">` + formatBytes(key.Size) + `</div>
+            </div>
+        </div>
+
+        <a href="` + template.HTMLEscapeString(back) + `" class="btn btn-secondary">Back to Duplicates</a>
+
+        <table>
+            <thead>
+                <tr>
+                    <th>Target</th>
+                    <th>Path</th>
+                    <th>Last Checksummed</th>
+                    <th>Actions</th>
+                </tr>
+            </thead>
+            <tbody>`
+
+	if len(files) == 0 {
+		html += `<tr><td colspan="4">No files found.</td></tr>`
+	} else {
+		for _, file := range files {
+			targetName := targetMap[file.StorageTargetID]
+			if targetName == "" {
+				targetName = "Unknown"
+			}
+
+			lastChecksummed := "Never"
+			if file.LastChecksummedAt != nil {
+				lastChecksummed = file.LastChecksummedAt.Format("2006-01-02 15:04")
+			}
+
+			html += fmt.Sprintf(`
+                <tr>
+                    <td>%s</td>
+                    <td class="file-path">%s</td>
+                    <td>%s</td>
+                    <td>
+                        <a href="/files/%d" class="btn btn-sm">View</a>
+                        <a href="/files/%d/history" class="btn btn-sm">History</a>
+                    </td>
+                </tr>`,
+				template.HTMLEscapeString(targetName),
+				template.HTMLEscapeString(file.Path),
+				lastChecksummed,
+				file.ID,
+				file.ID,
+			)
+		}
+	}
+
+	html += `
+            </tbody>
+        </table>`
+
+	if nextPage != "" {
+		html += `
+        <div class="pagination">
+            <a href="` + template.HTMLEscapeString(nextPage) + `" class="btn">Next Page</a>
+        </div>`
+	}
+
+	html += `
+    </div>
+</body>
+</html>`
+
+	w.Write([]byte(html))
+}
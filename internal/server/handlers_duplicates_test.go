@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jeffanddom/fixity/tests/testutil"
+)
+
+func TestHandleDuplicates(t *testing.T) {
+	server := setupTestServer(t)
+	ctx := context.Background()
+	primary := testutil.MustCreateStorageTarget(t, server.db, "primary")
+	backup := testutil.MustCreateStorageTarget(t, server.db, "backup")
+
+	// Files created by the helper share their checksum and size
+	testutil.MustCreateFile(t, server.db, primary.ID, "/photos/<a>.jpg")
+	testutil.MustCreateFile(t, server.db, backup.ID, "/photos/<a>.jpg")
+
+	user, token := createAuthenticatedUser(t, server)
+	defer server.db.Users.Delete(ctx, user.ID)
+
+	t.Run("lists groups with wasted space", func(t *testing.T) {
+		w, _ := makeAuthenticatedRequest(server, http.MethodGet, "/duplicates", token, nil)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+		body := w.Body.String()
+		if !strings.Contains(body, "md5:abc123") || !strings.Contains(body, "1.0 KB") {
+			t.Error("response should list the group and its wasted space")
+		}
+	})
+
+	t.Run("shows a group's files", func(t *testing.T) {
+		path := fmt.Sprintf("/duplicates/group?type=md5&checksum=abc123&size=1024&target=%d", backup.ID)
+		w, _ := makeAuthenticatedRequest(server, http.MethodGet, path, token, nil)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+		body := w.Body.String()
+		if strings.Count(body, "/photos/&lt;a&gt;.jpg") != 1 || !strings.Contains(body, "backup") {
+			t.Error("response should list only the backup copy")
+		}
+	})
+
+	t.Run("rejects an incomplete content key", func(t *testing.T) {
+		w, _ := makeAuthenticatedRequest(server, http.MethodGet, "/duplicates/group?type=md5", token, nil)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", w.Code)
+		}
+	})
+}
+
+func TestParseContentKey(t *testing.T) {
+	parse := func(query string) (bool, error) {
+		r := httptest.NewRequest(http.MethodGet, "/duplicates?"+query, nil)
+		_, ok, err := parseContentKey(r, "after_")
+		return ok, err
+	}
+
+	if ok, err := parse(""); ok || err != nil {
+		t.Errorf("expected no key and no error, got %v, %v", ok, err)
+	}
+	if ok, err := parse("after_type=md5&after_checksum=abc&after_size=0"); !ok || err != nil {
+		t.Errorf("expected a key, got %v, %v", ok, err)
+	}
+	if _, err := parse("after_type=md5&after_size=10"); err == nil {
+		t.Error("expected a key without a checksum to be rejected")
+	}
+	if _, err := parse("after_type=md5&after_checksum=abc&after_size=big"); err == nil {
+		t.Error("expected an invalid size to be rejected")
+	}
+}
@@ -0,0 +1,829 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/jeffanddom/fixity/internal/database"
+	"github.com/jeffanddom/fixity/internal/replica"
+)
+
+// maxDiscrepanciesShown caps the discrepancies listed on a replica group's
+// page; the export has all of them
+const maxDiscrepanciesShown = 500
+
+func (s *Server) handleListReplicaGroups(w http.ResponseWriter, r *http.Request) {
+	user := s.getCurrentUser(r)
+	groups, _ := s.db.ReplicaGroups.ListAll(r.Context())
+
+	groupTargets := make(map[int64][]*database.StorageTarget, len(groups))
+	latest := make(map[int64]*database.ReplicaComparison, len(groups))
+	for _, group := range groups {
+		groupTargets[group.ID], _ = s.db.ReplicaGroups.ListTargets(r.Context(), group.ID)
+		if comparisons, _ := s.db.ReplicaComparisons.ListByGroup(r.Context(), group.ID, 1); len(comparisons) > 0 {
+			latest[group.ID] = comparisons[0]
+		}
+	}
+
+	data := map[string]interface{}{
+		"User":    user,
+		"Groups":  groups,
+		"Targets": groupTargets,
+		"Latest":  latest,
+	}
+
+	if s.templates != nil {
+		if err := s.templates.ExecuteTemplate(w, "replicas_list.html", data); err != nil {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		return
+	}
+
+	s.renderSimpleReplicaGroupsList(w, data)
+}
+
+func (s *Server) renderSimpleReplicaGroupsList(w http.ResponseWriter, data map[string]interface{}) {
+	w.Header().Set("Content-Type", "text/html")
+	user := data["User"].(*database.User)
+	groups := data["Groups"].([]*database.ReplicaGroup)
+	groupTargets := data["Targets"].(map[int64][]*database.StorageTarget)
+	latest := data["Latest"].(map[int64]*database.ReplicaComparison)
+
+	html := `
+<!DOCTYPE html>
+<html>
+<head>
+    <title>Fixity - Replica Groups</title>
+    <style>
+        body { font-family: Arial, sans-serif; margin: 0; padding: 0; }
+        .header { background: #2c3e50; color: white; padding: 1rem 2rem; display: flex; justify-content: space-between; align-items: center; }
+        .nav { display: flex; gap: 1rem; }
+        .nav a { color: white; text-decoration: none; }
+        .nav a:hover { text-decoration: underline; }
+        .container { padding: 2rem; max-width: 1200px; margin: 0 auto; }
+        .btn { padding: 0.5rem 1rem; background: #007bff; color: white; border: none; border-radius: 4px; text-decoration: none; display: inline-block; cursor: pointer; }
+        .btn:hover { background: #0056b3; }
+        .btn-sm { padding: 0.25rem 0.5rem; font-size: 0.875rem; }
+        table { width: 100%; border-collapse: collapse; background: white; margin-top: 1rem; }
+        th, td { padding: 0.75rem; text-align: left; border-bottom: 1px solid #dee2e6; }
+        th { background: #f8f9fa; font-weight: 600; }
+        tr:hover { background: #f8f9fa; }
+        .status-completed { color: #28a745; font-weight: bold; }
+        .status-running { color: #007bff; font-weight: bold; }
+        .status-failed { color: #dc3545; font-weight: bold; }
+        .logout-form { display: inline; }
+    </style>
+</head>
+<body>
+    <div class="header">
+        <h1>Fixity</h1>
+        <div class="nav">
+            <a href="/">Dashboard</a>
+            <a href="/targets">Storage Targets</a>
+            <a href="/scans">Scans</a>
+            <a href="/files">Files</a>
+            <a href="/duplicates">Duplicates</a>
+            <a href="/replicas">Replicas</a>` + func() string {
+		if user.IsAdmin {
+			return `<a href="/users">Users</a>`
+		}
+		return ""
+	}() + `
+            <span>|</span>
+            <span>` + user.Username + `</span>
+            <form method="POST" action="/logout" class="logout-form">
+                <button type="submit" class="btn btn-sm">Logout</button>
+            </form>
+        </div>
+    </div>
+    <div class="container">
+        <h2>Replica Groups</h2>
+        <p>Storage targets that should hold identical content, compared by relative path and checksum.</p>
+        <a href="/replicas/new" class="btn">Add New Group</a>
+        <table>
+            <thead>
+                <tr>
+                    <th>Name</th>
+                    <th>Targets</th>
+                    <th>Last Comparison</th>
+                    <th>Actions</th>
+                </tr>
+            </thead>
+            <tbody>`
+
+	if len(groups) == 0 {
+		html += `<tr><td colspan="4">No replica groups configured.</td></tr>`
+	} else {
+		for _, group := range groups {
+			names := []string{}
+			for _, target := range groupTargets[group.ID] {
+				names = append(names, template.HTMLEscapeString(target.Name))
+			}
+
+			last := "Never"
+			if comparison := latest[group.ID]; comparison != nil {
+				last = fmt.Sprintf(`<span class="status-%s">%s</span> %s`,
+					comparison.Status, comparison.Status, comparison.StartedAt.Format("2006-01-02 15:04"))
+				if comparison.Status == database.ReplicaComparisonCompleted {
+					last += fmt.Sprintf(" (%d missing, %d divergent)", comparison.FilesMissing, comparison.FilesDivergent)
+				}
+			}
+
+			html += fmt.Sprintf(`
+                <tr>
+                    <td>%s</td>
+                    <td>%s</td>
+                    <td>%s</td>
+                    <td>
+                        <a href="/replicas/%d" class="btn btn-sm">View</a>
+                        <a href="/replicas/%d/edit" class="btn btn-sm">Edit</a>
+                        <form method="POST" action="/replicas/%d/compare" style="display:inline;">
+                            <button type="submit" class="btn btn-sm">Compare</button>
+                        </form>
+                    </td>
+                </tr>`,
+				template.HTMLEscapeString(group.Name),
+				strings.Join(names, ", "),
+				last,
+				group.ID,
+				group.ID,
+				group.ID,
+			)
+		}
+	}
+
+	html += `
+            </tbody>
+        </table>
+    </div>
+</body>
+</html>`
+
+	w.Write([]byte(html))
+}
+
+func (s *Server) handleNewReplicaGroupPage(w http.ResponseWriter, r *http.Request) {
+	data := map[string]interface{}{
+		"User":  s.getCurrentUser(r),
+		"Error": "",
+	}
+	s.renderSimpleReplicaGroupForm(w, r.Context(), data, nil, nil)
+}
+
+func (s *Server) handleEditReplicaGroupPage(w http.ResponseWriter, r *http.Request) {
+	groupID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid replica group ID", http.StatusBadRequest)
+		return
+	}
+
+	group, err := s.db.ReplicaGroups.GetByID(r.Context(), groupID)
+	if err != nil || group == nil {
+		http.Error(w, "Replica group not found", http.StatusNotFound)
+		return
+	}
+
+	targets, _ := s.db.ReplicaGroups.ListTargets(r.Context(), groupID)
+	selected := make([]int64, len(targets))
+	for i, target := range targets {
+		selected[i] = target.ID
+	}
+
+	data := map[string]interface{}{
+		"User":  s.getCurrentUser(r),
+		"Error": "",
+	}
+	s.renderSimpleReplicaGroupForm(w, r.Context(), data, group, selected)
+}
+
+func (s *Server) renderSimpleReplicaGroupForm(w http.ResponseWriter, ctx context.Context, data map[string]interface{}, group *database.ReplicaGroup, selected []int64) {
+	w.Header().Set("Content-Type", "text/html")
+	user := data["User"].(*database.User)
+	errorMsg := data["Error"].(string)
+	targets, _ := s.db.StorageTargets.ListAll(ctx)
+
+	title := "Add Replica Group"
+	action := "/replicas"
+	name := ""
+	description := ""
+	isEdit := group != nil && group.ID != 0
+	if isEdit {
+		title = "Edit Replica Group"
+		action = fmt.Sprintf("/replicas/%d", group.ID)
+	}
+	if group != nil {
+		name = template.HTMLEscapeString(group.Name)
+		if group.Description != nil {
+			description = template.HTMLEscapeString(*group.Description)
+		}
+	}
+
+	isSelected := make(map[int64]bool, len(selected))
+	for _, id := range selected {
+		isSelected[id] = true
+	}
+
+	html := `
+<!DOCTYPE html>
+<html>
+<head>
+    <title>Fixity - ` + title + `</title>
+    <style>
+        body { font-family: Arial, sans-serif; margin: 0; padding: 0; }
+        .header { background: #2c3e50; color: white; padding: 1rem 2rem; display: flex; justify-content: space-between; align-items: center; }
+        .nav { display: flex; gap: 1rem; }
+        .nav a { color: white; text-decoration: none; }
+        .nav a:hover { text-decoration: underline; }
+        .container { padding: 2rem; max-width: 600px; margin: 0 auto; }
+        .form-group { margin-bottom: 1.5rem; }
+        .form-group label { display: block; font-weight: bold; margin-bottom: 0.5rem; }
+        .form-group input[type="text"],
+        .form-group textarea { width: 100%; padding: 0.5rem; border: 1px solid #dee2e6; border-radius: 4px; }
+        .form-group .checkbox-label { font-weight: normal; }
+        .form-group input[type="checkbox"] { margin-right: 0.5rem; }
+        .form-group small { display: block; margin-top: 0.25rem; color: #6c757d; font-size: 0.875rem; }
+        .btn { padding: 0.5rem 1rem; background: #007bff; color: white; border: none; border-radius: 4px; cursor: pointer; text-decoration: none; display: inline-block; }
+        .btn:hover { background: #0056b3; }
+        .btn-secondary { background: #6c757d; margin-left: 0.5rem; }
+        .btn-secondary:hover { background: #5a6268; }
+        .error { color: #721c24; padding: 1rem; background: #f8d7da; margin-bottom: 1rem; border: 1px solid #f5c6cb; border-radius: 4px; }
+        .logout-form { display: inline; }
+        .btn-sm { padding: 0.25rem 0.5rem; font-size: 0.875rem; }
+    </style>
+</head>
+<body>
+    <div class="header">
+        <h1>Fixity</h1>
+        <div class="nav">
+            <a href="/">Dashboard</a>
+            <a href="/targets">Storage Targets</a>
+            <a href="/scans">Scans</a>
+            <a href="/files">Files</a>
+            <a href="/duplicates">Duplicates</a>
+            <a href="/replicas">Replicas</a>` + func() string {
+		if user.IsAdmin {
+			return `<a href="/users">Users</a>`
+		}
+		return ""
+	}() + `
+            <span>|</span>
+            <span>` + user.Username + `</span>
+            <form method="POST" action="/logout" class="logout-form">
+                <button type="submit" class="btn btn-sm">Logout</button>
+            </form>
+        </div>
+    </div>
+    <div class="container">
+        <h2>` + title + `</h2>`
+
+	if errorMsg != "" {
+		html += `<div class="error">` + template.HTMLEscapeString(errorMsg) + `</div>`
+	}
+
+	html += `
+        <form method="POST" action="` + action + `">`
+
+	if isEdit {
+		html += `<input type="hidden" name="_method" value="PUT">`
+	}
+
+	html += `
+            <div class="form-group">
+                <label for="name">Name</label>
+                <input type="text" id="name" name="name" value="` + name + `" required>
+            </div>
+            <div class="form-group">
+                <label for="description">Description</label>
+                <textarea id="description" name="description" rows="3">` + description + `</textarea>
+            </div>
+            <div class="form-group">
+                <label>Targets</label>`
+
+	if len(targets) == 0 {
+		html += `
+                <p>No storage targets configured.</p>`
+	}
+	for _, target := range targets {
+		checked := ""
+		if isSelected[target.ID] {
+			checked = " checked"
+		}
+		html += fmt.Sprintf(`
+                <label class="checkbox-label"><input type="checkbox" name="targets" value="%d"%s>%s (%s)</label>`,
+			target.ID, checked, template.HTMLEscapeString(target.Name), target.Type)
+	}
+
+	html += `
+                <small>Select at least two targets that should hold the same files at the same relative paths.</small>
+            </div>
+            <button type="submit" class="btn">Save</button>
+            <a href="/replicas" class="btn btn-secondary">Cancel</a>
+        </form>
+    </div>
+</body>
+</html>`
+
+	w.Write([]byte(html))
+}
+
+// parseReplicaGroupForm reads a replica group's name, description and targets
+// from a submitted form
+func parseReplicaGroupForm(r *http.Request, group *database.ReplicaGroup) ([]int64, error) {
+	group.Name = strings.TrimSpace(r.FormValue("name"))
+	group.Description = nil
+	if description := strings.TrimSpace(r.FormValue("description")); description != "" {
+		group.Description = &description
+	}
+
+	targetIDs := []int64{}
+	for _, value := range r.Form["targets"] {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid target: %s", value)
+		}
+		targetIDs = append(targetIDs, id)
+	}
+
+	if group.Name == "" {
+		return targetIDs, fmt.Errorf("Name is required")
+	}
+	if len(targetIDs) < 2 {
+		return targetIDs, fmt.Errorf("Select at least two storage targets")
+	}
+	return targetIDs, nil
+}
+
+func (s *Server) handleCreateReplicaGroup(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	group := &database.ReplicaGroup{}
+	targetIDs, err := parseReplicaGroupForm(r, group)
+	if err == nil {
+		err = s.db.ReplicaGroups.Create(r.Context(), group)
+		if err != nil {
+			err = fmt.Errorf("Failed to create replica group: %v", err)
+		}
+	}
+	if err == nil {
+		err = s.db.ReplicaGroups.SetTargets(r.Context(), group.ID, targetIDs)
+		if err != nil {
+			err = fmt.Errorf("Failed to set replica group targets: %v", err)
+		}
+	}
+	if err != nil {
+		data := map[string]interface{}{
+			"User":  s.getCurrentUser(r),
+			"Error": err.Error(),
+		}
+		s.renderSimpleReplicaGroupForm(w, r.Context(), data, group, targetIDs)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/replicas/%d", group.ID), http.StatusSeeOther)
+}
+
+func (s *Server) handleUpdateReplicaGroup(w http.ResponseWriter, r *http.Request) {
+	groupID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid replica group ID", http.StatusBadRequest)
+		return
+	}
+
+	group, err := s.db.ReplicaGroups.GetByID(r.Context(), groupID)
+	if err != nil || group == nil {
+		http.Error(w, "Replica group not found", http.StatusNotFound)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	// Check for method override (since browsers only support GET/POST)
+	if r.FormValue("_method") == "DELETE" {
+		s.handleDeleteReplicaGroup(w, r)
+		return
+	}
+
+	targetIDs, err := parseReplicaGroupForm(r, group)
+	if err == nil {
+		err = s.db.ReplicaGroups.Update(r.Context(), group)
+		if err != nil {
+			err = fmt.Errorf("Failed to update replica group: %v", err)
+		}
+	}
+	if err == nil {
+		err = s.db.ReplicaGroups.SetTargets(r.Context(), group.ID, targetIDs)
+		if err != nil {
+			err = fmt.Errorf("Failed to set replica group targets: %v", err)
+		}
+	}
+	if err != nil {
+		data := map[string]interface{}{
+			"User":  s.getCurrentUser(r),
+			"Error": err.Error(),
+		}
+		s.renderSimpleReplicaGroupForm(w, r.Context(), data, group, targetIDs)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/replicas/%d", groupID), http.StatusSeeOther)
+}
+
+func (s *Server) handleDeleteReplicaGroup(w http.ResponseWriter, r *http.Request) {
+	groupID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid replica group ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.db.ReplicaGroups.Delete(r.Context(), groupID); err != nil {
+		http.Error(w, "Replica group not found", http.StatusNotFound)
+		return
+	}
+
+	http.Redirect(w, r, "/replicas", http.StatusSeeOther)
+}
+
+func (s *Server) handleCompareReplicaGroup(w http.ResponseWriter, r *http.Request) {
+	groupID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid replica group ID", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := s.db.ReplicaGroups.GetByID(r.Context(), groupID); err != nil {
+		http.Error(w, "Replica group not found", http.StatusNotFound)
+		return
+	}
+
+	// Compare in the background; the request's context ends with the redirect
+	go func() {
+		s.coordinator.CompareReplicas(context.Background(), groupID)
+	}()
+
+	http.Redirect(w, r, fmt.Sprintf("/replicas/%d", groupID), http.StatusSeeOther)
+}
+
+func (s *Server) handleViewReplicaGroup(w http.ResponseWriter, r *http.Request) {
+	groupID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid replica group ID", http.StatusBadRequest)
+		return
+	}
+
+	user := s.getCurrentUser(r)
+	group, err := s.db.ReplicaGroups.GetByID(r.Context(), groupID)
+	if err != nil || group == nil {
+		http.Error(w, "Replica group not found", http.StatusNotFound)
+		return
+	}
+
+	targets, _ := s.db.ReplicaGroups.ListTargets(r.Context(), groupID)
+	comparisons, _ := s.db.ReplicaComparisons.ListByGroup(r.Context(), groupID, 10)
+
+	// Show the requested comparison, or the most recent one
+	var comparison *database.ReplicaComparison
+	if len(comparisons) > 0 {
+		comparison = comparisons[0]
+	}
+	if value := r.URL.Query().Get("comparison"); value != "" {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid comparison ID", http.StatusBadRequest)
+			return
+		}
+		comparison, err = s.db.ReplicaComparisons.GetByID(r.Context(), id)
+		if err != nil || comparison.GroupID != groupID {
+			http.Error(w, "Comparison not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	discrepancies := []*database.ReplicaDiscrepancy{}
+	if comparison != nil {
+		discrepancies, _ = s.db.ReplicaComparisons.ListDiscrepancies(r.Context(), comparison.ID, maxDiscrepanciesShown)
+	}
+
+	data := map[string]interface{}{
+		"User":          user,
+		"Group":         group,
+		"Targets":       targets,
+		"Comparisons":   comparisons,
+		"Comparison":    comparison,
+		"Discrepancies": discrepancies,
+	}
+
+	if s.templates != nil {
+		if err := s.templates.ExecuteTemplate(w, "replica_view.html", data); err != nil {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		return
+	}
+
+	s.renderSimpleReplicaGroup(w, data)
+}
+
+func (s *Server) renderSimpleReplicaGroup(w http.ResponseWriter, data map[string]interface{}) {
+	w.Header().Set("Content-Type", "text/html")
+	user := data["User"].(*database.User)
+	group := data["Group"].(*database.ReplicaGroup)
+	targets := data["Targets"].([]*database.StorageTarget)
+	comparisons := data["Comparisons"].([]*database.ReplicaComparison)
+	comparison := data["Comparison"].(*database.ReplicaComparison)
+	discrepancies := data["Discrepancies"].([]*database.ReplicaDiscrepancy)
+
+	groupID := strconv.FormatInt(group.ID, 10)
+	targetNames := make(map[int64]string, len(targets))
+	targetLinks := []string{}
+	for _, target := range targets {
+		targetNames[target.ID] = target.Name
+		targetLinks = append(targetLinks, fmt.Sprintf(`<a href="/targets/%d">%s</a>`, target.ID, template.HTMLEscapeString(target.Name)))
+	}
+
+	description := ""
+	if group.Description != nil {
+		description = template.HTMLEscapeString(*group.Description)
+	}
+
+	shortChecksum := func(sum *string) string {
+		if sum == nil {
+			return "N/A"
+		}
+		if len(*sum) > 16 {
+			return (*sum)[:16] + "..."
+		}
+		return *sum
+	}
+
+	html := `
+<!DOCTYPE html>
+<html>
+<head>
+    <title>Fixity - Replica Group</title>
+    <style>
+        body { font-family: Arial, sans-serif; margin: 0; padding: 0; }
+        .header { background: #2c3e50; color: white; padding: 1rem 2rem; display: flex; justify-content: space-between; align-items: center; }
+        .nav { display: flex; gap: 1rem; }
+        .nav a { color: white; text-decoration: none; }
+        .nav a:hover { text-decoration: underline; }
+        .container { padding: 2rem; max-width: 1200px; margin: 0 auto; }
+        .info-card { background: #f8f9fa; padding: 1.5rem; border-radius: 8px; margin-bottom: 2rem; }
+        .info-row { display: flex; margin-bottom: 0.75rem; }
+        .info-label { font-weight: bold; width: 200px; }
+        .info-value { flex: 1; }
+        .btn { padding: 0.5rem 1rem; background: #007bff; color: white; border: none; border-radius: 4px; text-decoration: none; display: inline-block; cursor: pointer; }
+        .btn:hover { background: #0056b3; }
+        .btn-sm { padding: 0.25rem 0.5rem; font-size: 0.875rem; }
+        .btn-secondary { background: #6c757d; }
+        .btn-secondary:hover { background: #5a6268; }
+        .btn-danger { background: #dc3545; }
+        .btn-danger:hover { background: #c82333; }
+        .status-completed { color: #28a745; font-weight: bold; }
+        .status-running { color: #007bff; font-weight: bold; }
+        .status-failed { color: #dc3545; font-weight: bold; }
+        .problem-missing { color: #fd7e14; font-weight: bold; }
+        .problem-divergent { color: #dc3545; font-weight: bold; }
+        table { width: 100%; border-collapse: collapse; background: white; margin-top: 1rem; }
+        th, td { padding: 0.75rem; text-align: left; border-bottom: 1px solid #dee2e6; }
+        th { background: #f8f9fa; font-weight: 600; }
+        tr:hover { background: #f8f9fa; }
+        .logout-form { display: inline; }
+        .actions { margin-bottom: 2rem; }
+    </style>
+</head>
+<body>
+    <div class="header">
+        <h1>Fixity</h1>
+        <div class="nav">
+            <a href="/">Dashboard</a>
+            <a href="/targets">Storage Targets</a>
+            <a href="/scans">Scans</a>
+            <a href="/files">Files</a>
+            <a href="/duplicates">Duplicates</a>
+            <a href="/replicas">Replicas</a>` + func() string {
+		if user.IsAdmin {
+			return `<a href="/users">Users</a>`
+		}
+		return ""
+	}() + `
+            <span>|</span>
+            <span>` + user.Username + `</span>
+            <form method="POST" action="/logout" class="logout-form">
+                <button type="submit" class="btn btn-sm">Logout</button>
+            </form>
+        </div>
+    </div>
+    <div class="container">
+        <h2>Replica Group: ` + template.HTMLEscapeString(group.Name) + `</h2>
+
+        <div class="actions">
+            <a href="/replicas/` + groupID + `/edit" class="btn">Edit</a>
+            <form method="POST" action="/replicas/` + groupID + `/compare" style="display:inline;">
+                <button type="submit" class="btn">Compare Now</button>
+            </form>
+            <a href="/replicas" class="btn btn-secondary">Back to List</a>
+            <form method="POST" action="/replicas/` + groupID + `" style="display:inline;">
+                <input type="hidden" name="_method" value="DELETE">
+                <button type="submit" class="btn btn-danger" onclick="return confirm('Are you sure you want to delete this replica group?')">Delete</button>
+            </form>
+        </div>
+
+        <div class="info-card">
+            <div class="info-row">
+                <div class="info-label">Name:</div>
+                <div class="info-value">` + template.HTMLEscapeString(group.Name) + `</div>
+            </div>`
+
+	if description != "" {
+		html += `
+            <div class="info-row">
+                <div class="info-label">Description:</div>
+                <div class="info-value">` + description + `</div>
+            </div>`
+	}
+
+	html += `
+            <div class="info-row">
+                <div class="info-label">Targets:</div>
+                <div class="info-value">` + strings.Join(targetLinks, ", ") + `</div>
+            </div>
+        </div>
+
+        <h3>Recent Comparisons</h3>`
+
+	if len(comparisons) == 0 {
+		html += `<p>This group has not been compared yet.</p>`
+	} else {
+		html += `
+        <table>
+            <thead>
+                <tr>
+                    <th>Started</th>
+                    <th>Status</th>
+                    <th>Paths</th>
+                    <th>Missing</th>
+                    <th>Divergent</th>
+                    <th>Actions</th>
+                </tr>
+            </thead>
+            <tbody>`
+
+		for _, c := range comparisons {
+			status := string(c.Status)
+			if c.ErrorMessage != nil {
+				status += ": " + template.HTMLEscapeString(*c.ErrorMessage)
+			}
+			html += fmt.Sprintf(`
+                <tr>
+                    <td>%s</td>
+                    <td class="status-%s">%s</td>
+                    <td>%d</td>
+                    <td>%d</td>
+                    <td>%d</td>
+                    <td>
+                        <a href="/replicas/%d?comparison=%d" class="btn btn-sm">View</a>
+                        <a href="/replicas/%d/comparisons/%d/export?format=csv" class="btn btn-sm">CSV</a>
+                        <a href="/replicas/%d/comparisons/%d/export?format=json" class="btn btn-sm">JSON</a>
+                    </td>
+                </tr>`,
+				c.StartedAt.Format("2006-01-02 15:04:05"),
+				c.Status,
+				status,
+				c.FilesCompared,
+				c.FilesMissing,
+				c.FilesDivergent,
+				group.ID, c.ID,
+				group.ID, c.ID,
+				group.ID, c.ID,
+			)
+		}
+
+		html += `
+            </tbody>
+        </table>`
+	}
+
+	if comparison != nil {
+		html += fmt.Sprintf(`
+        <h3>Discrepancies Found %s</h3>`, comparison.StartedAt.Format("2006-01-02 15:04:05"))
+
+		if len(discrepancies) == 0 {
+			html += `<p>All copies are present and agree.</p>`
+		} else {
+			html += `
+        <table>
+            <thead>
+                <tr>
+                    <th>Path</th>
+                    <th>Target</th>
+                    <th>Problem</th>
+                    <th>Checksum</th>
+                    <th>Likely Correct</th>
+                </tr>
+            </thead>
+            <tbody>`
+
+			for _, d := range discrepancies {
+				likely := shortChecksum(d.LikelyChecksum)
+				if d.LikelyChecksum == nil {
+					likely = "No majority"
+				}
+				html += fmt.Sprintf(`
+                <tr>
+                    <td>%s</td>
+                    <td>%s</td>
+                    <td class="problem-%s">%s</td>
+                    <td style="font-family: monospace; font-size: 0.85rem;">%s</td>
+                    <td style="font-family: monospace; font-size: 0.85rem;">%s</td>
+                </tr>`,
+					template.HTMLEscapeString(d.Path),
+					template.HTMLEscapeString(targetNames[d.StorageTargetID]),
+					d.Problem,
+					d.Problem,
+					shortChecksum(d.Checksum),
+					likely,
+				)
+			}
+
+			html += `
+            </tbody>
+        </table>`
+
+			if len(discrepancies) == maxDiscrepanciesShown {
+				html += fmt.Sprintf(`
+        <p>Showing the first %d discrepancies; export the comparison for all of them.</p>`, maxDiscrepanciesShown)
+			}
+		}
+	}
+
+	html += `
+    </div>
+</body>
+</html>`
+
+	w.Write([]byte(html))
+}
+
+func (s *Server) handleExportReplicaComparison(w http.ResponseWriter, r *http.Request) {
+	groupID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid replica group ID", http.StatusBadRequest)
+		return
+	}
+	comparisonID, err := strconv.ParseInt(chi.URLParam(r, "comparisonID"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid comparison ID", http.StatusBadRequest)
+		return
+	}
+
+	comparison, err := s.db.ReplicaComparisons.GetByID(r.Context(), comparisonID)
+	if err != nil || comparison.GroupID != groupID {
+		http.Error(w, "Comparison not found", http.StatusNotFound)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "json" {
+		http.Error(w, "Invalid format: must be csv or json", http.StatusBadRequest)
+		return
+	}
+
+	discrepancies, err := s.db.ReplicaComparisons.ListDiscrepancies(r.Context(), comparisonID, 0)
+	if err != nil {
+		http.Error(w, "Failed to load discrepancies", http.StatusInternalServerError)
+		return
+	}
+
+	// Name targets that have left the group since the comparison, too
+	targets, _ := s.db.StorageTargets.ListAll(r.Context())
+	targetNames := make(map[int64]string, len(targets))
+	for _, target := range targets {
+		targetNames[target.ID] = target.Name
+	}
+
+	filename := fmt.Sprintf("replica-comparison-%d.%s", comparisonID, format)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	if format == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		replica.WriteJSON(w, comparison, discrepancies, targetNames)
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv")
+	replica.WriteCSV(w, discrepancies, targetNames)
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/jeffanddom/fixity/internal/database"
+	"github.com/jeffanddom/fixity/tests/testutil"
+)
+
+func TestHandleCreateReplicaGroup(t *testing.T) {
+	server := setupTestServer(t)
+	primary := testutil.MustCreateStorageTarget(t, server.db, "primary")
+	dr := testutil.MustCreateStorageTarget(t, server.db, "dr")
+
+	t.Run("creates group with its targets", func(t *testing.T) {
+		user, token := createAuthenticatedUser(t, server)
+		defer server.db.Users.Delete(context.Background(), user.ID)
+
+		form := url.Values{
+			"name":        {"Collection"},
+			"description": {"Primary and DR copies"},
+			"targets":     {fmt.Sprint(primary.ID), fmt.Sprint(dr.ID)},
+		}
+		w, _ := makeAuthenticatedRequest(server, http.MethodPost, "/replicas", token, form)
+
+		if w.Code != http.StatusSeeOther {
+			t.Fatalf("expected status 303, got %d: %s", w.Code, w.Body.String())
+		}
+
+		groups, _ := server.db.ReplicaGroups.ListAll(context.Background())
+		if len(groups) != 1 || groups[0].Name != "Collection" {
+			t.Fatalf("expected the group to be created, got %v", groups)
+		}
+		targets, _ := server.db.ReplicaGroups.ListTargets(context.Background(), groups[0].ID)
+		if len(targets) != 2 {
+			t.Errorf("expected 2 targets, got %d", len(targets))
+		}
+	})
+
+	t.Run("rejects a single target", func(t *testing.T) {
+		user, token := createAuthenticatedUser(t, server)
+		defer server.db.Users.Delete(context.Background(), user.ID)
+
+		form := url.Values{"name": {"Lonely"}, "targets": {fmt.Sprint(primary.ID)}}
+		w, _ := makeAuthenticatedRequest(server, http.MethodPost, "/replicas", token, form)
+
+		if !strings.Contains(w.Body.String(), "Select at least two storage targets") {
+			t.Error("response should explain that two targets are needed")
+		}
+	})
+}
+
+func TestHandleViewReplicaGroup(t *testing.T) {
+	server := setupTestServer(t)
+	ctx := context.Background()
+	primary := testutil.MustCreateStorageTarget(t, server.db, "primary")
+	dr := testutil.MustCreateStorageTarget(t, server.db, "dr")
+
+	group := &database.ReplicaGroup{Name: "Collection"}
+	server.db.ReplicaGroups.Create(ctx, group)
+	server.db.ReplicaGroups.SetTargets(ctx, group.ID, []int64{primary.ID, dr.ID})
+
+	comparison := &database.ReplicaComparison{GroupID: group.ID, Status: database.ReplicaComparisonCompleted, FilesCompared: 1, FilesMissing: 1}
+	server.db.ReplicaComparisons.Create(ctx, comparison)
+	server.db.ReplicaComparisons.Update(ctx, comparison)
+	server.db.ReplicaComparisons.CreateDiscrepancies(ctx, []*database.ReplicaDiscrepancy{
+		{ComparisonID: comparison.ID, Path: "photos/<a>.jpg", StorageTargetID: dr.ID, Problem: database.ReplicaMissing},
+	})
+
+	user, token := createAuthenticatedUser(t, server)
+	defer server.db.Users.Delete(ctx, user.ID)
+
+	t.Run("shows the latest comparison's discrepancies", func(t *testing.T) {
+		w, _ := makeAuthenticatedRequest(server, http.MethodGet, fmt.Sprintf("/replicas/%d", group.ID), token, nil)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+		body := w.Body.String()
+		if !strings.Contains(body, "photos/&lt;a&gt;.jpg") || !strings.Contains(body, "problem-missing") {
+			t.Error("response should list the missing copy")
+		}
+	})
+
+	t.Run("exports discrepancies as CSV", func(t *testing.T) {
+		path := fmt.Sprintf("/replicas/%d/comparisons/%d/export?format=csv", group.ID, comparison.ID)
+		w, _ := makeAuthenticatedRequest(server, http.MethodGet, path, token, nil)
+
+		if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/csv" {
+			t.Fatalf("expected a CSV, got %d %s", w.Code, w.Header().Get("Content-Type"))
+		}
+		if !strings.Contains(w.Body.String(), "photos/<a>.jpg,"+fmt.Sprint(dr.ID)+",dr,missing") {
+			t.Errorf("unexpected export: %s", w.Body.String())
+		}
+	})
+
+	t.Run("refuses a comparison of another group", func(t *testing.T) {
+		path := fmt.Sprintf("/replicas/%d/comparisons/%d/export", group.ID+1, comparison.ID)
+		w, _ := makeAuthenticatedRequest(server, http.MethodGet, path, token, nil)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,345 @@
+package server
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/jeffanddom/fixity/internal/database"
+	"github.com/jeffanddom/fixity/internal/report"
+)
+
+// reportsShown caps the reports listed for a target
+const reportsShown = 50
+
+// parseReportPeriod parses the period of a report request: either the
+// previous whole day, week, month, quarter or year, or a custom range of
+// dates given inclusively
+func parseReportPeriod(r *http.Request, now time.Time) (time.Time, time.Time, error) {
+	period := r.FormValue("period")
+	if period != "" && period != "custom" {
+		start, end, err := report.PreviousPeriod(period, now)
+		if err != nil {
+			return start, end, fmt.Errorf("Invalid report period: %s", period)
+		}
+		return start, end, nil
+	}
+
+	start, err := report.ParseDate(strings.TrimSpace(r.FormValue("from")))
+	if err != nil {
+		return start, start, fmt.Errorf("Invalid start date: %s", r.FormValue("from"))
+	}
+	last, err := report.ParseDate(strings.TrimSpace(r.FormValue("to")))
+	if err != nil {
+		return start, start, fmt.Errorf("Invalid end date: %s", r.FormValue("to"))
+	}
+	end := last.AddDate(0, 0, 1)
+	if !end.After(start) {
+		return start, end, fmt.Errorf("Report period must end after it starts")
+	}
+
+	return start, end, nil
+}
+
+func (s *Server) handleListReports(w http.ResponseWriter, r *http.Request) {
+	targetID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid target ID", http.StatusBadRequest)
+		return
+	}
+
+	user := s.getCurrentUser(r)
+	target, err := s.db.StorageTargets.GetByID(r.Context(), targetID)
+	if err != nil || target == nil {
+		http.Error(w, "Target not found", http.StatusNotFound)
+		return
+	}
+
+	reports, err := s.db.AuditReports.ListByTarget(r.Context(), targetID, reportsShown)
+	if err != nil {
+		http.Error(w, "Failed to load reports", http.StatusInternalServerError)
+		return
+	}
+
+	data := map[string]interface{}{
+		"User":        user,
+		"Target":      target,
+		"Reports":     reports,
+		"CanGenerate": s.config.SigningKey != nil,
+	}
+
+	if s.templates != nil {
+		if err := s.templates.ExecuteTemplate(w, "target_reports.html", data); err != nil {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		return
+	}
+
+	s.renderSimpleReports(w, data)
+}
+
+func (s *Server) handleGenerateReport(w http.ResponseWriter, r *http.Request) {
+	targetID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid target ID", http.StatusBadRequest)
+		return
+	}
+
+	user := s.getCurrentUser(r)
+	target, err := s.db.StorageTargets.GetByID(r.Context(), targetID)
+	if err != nil || target == nil {
+		http.Error(w, "Target not found", http.StatusNotFound)
+		return
+	}
+
+	if s.config.SigningKey == nil {
+		http.Error(w, "Report signing is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	start, end, err := parseReportPeriod(r, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	format, err := report.ParseFormat(r.FormValue("format"))
+	if err != nil {
+		http.Error(w, "Invalid format: must be html, csv or json", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := report.Generate(r.Context(), s.db, s.config.SigningKey, target, start, end, format, user.Username); err != nil {
+		http.Error(w, "Failed to generate report", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/targets/%d/reports", targetID), http.StatusSeeOther)
+}
+
+// loadReport loads the report named in the URL, checking it belongs to the
+// target named there
+func (s *Server) loadReport(w http.ResponseWriter, r *http.Request) (*database.AuditReport, *database.StorageTarget, bool) {
+	targetID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid target ID", http.StatusBadRequest)
+		return nil, nil, false
+	}
+	reportID, err := strconv.ParseInt(chi.URLParam(r, "reportID"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid report ID", http.StatusBadRequest)
+		return nil, nil, false
+	}
+
+	target, err := s.db.StorageTargets.GetByID(r.Context(), targetID)
+	if err != nil || target == nil {
+		http.Error(w, "Target not found", http.StatusNotFound)
+		return nil, nil, false
+	}
+
+	stored, err := s.db.AuditReports.GetByID(r.Context(), reportID)
+	if err != nil || stored.StorageTargetID != targetID {
+		http.Error(w, "Report not found", http.StatusNotFound)
+		return nil, nil, false
+	}
+
+	return stored, target, true
+}
+
+func (s *Server) handleDownloadReport(w http.ResponseWriter, r *http.Request) {
+	stored, target, ok := s.loadReport(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="`+report.Filename(stored, target.Name)+`"`)
+	w.Header().Set("Content-Type", report.Format(stored.Format).ContentType())
+	w.Write(stored.Content)
+}
+
+func (s *Server) handleDownloadReportSignature(w http.ResponseWriter, r *http.Request) {
+	stored, target, ok := s.loadReport(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="`+report.Filename(stored, target.Name)+`.sig"`)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(stored.Signature))
+}
+
+func (s *Server) renderSimpleReports(w http.ResponseWriter, data map[string]interface{}) {
+	w.Header().Set("Content-Type", "text/html")
+	user := data["User"].(*database.User)
+	target := data["Target"].(*database.StorageTarget)
+	reports := data["Reports"].([]*database.AuditReport)
+	canGenerate := data["CanGenerate"].(bool)
+
+	targetPath := "/targets/" + strconv.FormatInt(target.ID, 10)
+
+	html := `
+<!DOCTYPE html>
+<html>
+<head>
+    <title>Fixity - Audit Reports</title>
+    <style>
+        body { font-family: Arial, sans-serif; margin: 0; padding: 0; }
+        .header { background: #2c3e50; color: white; padding: 1rem 2rem; display: flex; justify-content: space-between; align-items: center; }
+        .nav { display: flex; gap: 1rem; }
+        .nav a { color: white; text-decoration: none; }
+        .nav a:hover { text-decoration: underline; }
+        .container { padding: 2rem; max-width: 1200px; margin: 0 auto; }
+        .info-card { background: #f8f9fa; padding: 1.5rem; border-radius: 8px; margin-bottom: 1rem; }
+        .form-row { display: flex; gap: 1rem; align-items: flex-end; flex-wrap: wrap; }
+        .form-group label { display: block; font-weight: bold; margin-bottom: 0.25rem; }
+        .form-group input, .form-group select { padding: 0.4rem; border: 1px solid #ced4da; border-radius: 4px; }
+        .btn { padding: 0.5rem 1rem; background: #007bff; color: white; border: none; border-radius: 4px; text-decoration: none; display: inline-block; cursor: pointer; }
+        .btn:hover { background: #0056b3; }
+        .btn-sm { padding: 0.25rem 0.5rem; font-size: 0.875rem; }
+        .btn-secondary { background: #6c757d; }
+        .btn-secondary:hover { background: #5a6268; }
+        table { width: 100%; border-collapse: collapse; background: white; margin-top: 1rem; }
+        th, td { padding: 0.75rem; text-align: left; border-bottom: 1px solid #dee2e6; }
+        th { background: #f8f9fa; font-weight: 600; }
+        tr:hover { background: #f8f9fa; }
+        .checksum { font-family: monospace; font-size: 0.9rem; }
+        .logout-form { display: inline; }
+        small { color: #6c757d; }
+    </style>
+</head>
+<body>
+    <div class="header">
+        <h1>Fixity</h1>
+        <div class="nav">
+            <a href="/">Dashboard</a>
+            <a href="/targets">Storage Targets</a>
+            <a href="/scans">Scans</a>
+            <a href="/files">Files</a>
+            <a href="/duplicates">Duplicates</a>
+            <a href="/replicas">Replicas</a>` + func() string {
+		if user.IsAdmin {
+			return `<a href="/users">Users</a>`
+		}
+		return ""
+	}() + `
+            <span>|</span>
+            <span>` + user.Username + `</span>
+            <form method="POST" action="/logout" class="logout-form">
+                <button type="submit" class="btn btn-sm">Logout</button>
+            </form>
+        </div>
+    </div>
+    <div class="container">
+        <h2>Audit Reports: ` + template.HTMLEscapeString(target.Name) + `</h2>
+
+        <a href="` + targetPath + `" class="btn btn-secondary">Back to Target</a>
+
+        <div class="info-card" style="margin-top: 1rem;">`
+
+	if canGenerate {
+		html += `
+            <form method="POST" action="` + targetPath + `/reports">
+                <div class="form-row">
+                    <div class="form-group">
+                        <label for="period">Period</label>
+                        <select id="period" name="period">
+                            <option value="month">Previous month</option>
+                            <option value="week">Previous week</option>
+                            <option value="day">Previous day</option>
+                            <option value="quarter">Previous quarter</option>
+                            <option value="year">Previous year</option>
+                            <option value="custom">Dates below</option>
+                        </select>
+                    </div>
+                    <div class="form-group">
+                        <label for="from">From</label>
+                        <input type="date" id="from" name="from">
+                    </div>
+                    <div class="form-group">
+                        <label for="to">Through</label>
+                        <input type="date" id="to" name="to">
+                    </div>
+                    <div class="form-group">
+                        <label for="format">Format</label>
+                        <select id="format" name="format">
+                            <option value="html">HTML</option>
+                            <option value="csv">CSV</option>
+                            <option value="json">JSON</option>
+                        </select>
+                    </div>
+                    <button type="submit" class="btn">Generate Report</button>
+                </div>
+                <small>Periods are whole days in UTC. Each report is signed; download its signature with it and check both with <code>fixity report verify</code>.</small>
+            </form>`
+	} else {
+		html += `
+            <p>Report signing is not configured, so reports cannot be generated.</p>`
+	}
+
+	html += `
+        </div>`
+
+	if len(reports) == 0 {
+		html += `
+        <p>No reports yet for this target.</p>`
+	} else {
+		html += `
+        <table>
+            <thead>
+                <tr>
+                    <th>Period</th>
+                    <th>Format</th>
+                    <th>Size</th>
+                    <th>Generated</th>
+                    <th>By</th>
+                    <th>Key</th>
+                    <th>Actions</th>
+                </tr>
+            </thead>
+            <tbody>`
+
+		for _, stored := range reports {
+			reportPath := fmt.Sprintf("%s/reports/%d", targetPath, stored.ID)
+			html += fmt.Sprintf(`
+                <tr>
+                    <td>%s to %s</td>
+                    <td>%s</td>
+                    <td>%s</td>
+                    <td>%s</td>
+                    <td>%s</td>
+                    <td class="checksum">%s</td>
+                    <td>
+                        <a href="%s" class="btn btn-sm">Download</a>
+                        <a href="%s/signature" class="btn btn-sm btn-secondary">Signature</a>
+                    </td>
+                </tr>`,
+				stored.PeriodStart.UTC().Format("2006-01-02"),
+				stored.PeriodEnd.UTC().Add(-time.Nanosecond).Format("2006-01-02"),
+				strings.ToUpper(stored.Format),
+				formatBytes(stored.Size),
+				stored.GeneratedAt.Format("2006-01-02 15:04"),
+				template.HTMLEscapeString(stored.GeneratedBy),
+				template.HTMLEscapeString(stored.KeyID),
+				reportPath,
+				reportPath,
+			)
+		}
+
+		html += `
+            </tbody>
+        </table>`
+	}
+
+	html += `
+    </div>
+</body>
+</html>`
+
+	w.Write([]byte(html))
+}
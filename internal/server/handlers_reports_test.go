@@ -0,0 +1,99 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/jeffanddom/fixity/internal/report"
+	"github.com/jeffanddom/fixity/internal/signing"
+	"github.com/jeffanddom/fixity/tests/testutil"
+)
+
+func TestHandleReports(t *testing.T) {
+	server := setupTestServer(t)
+	ctx := context.Background()
+	target := testutil.MustCreateStorageTarget(t, server.db, "archive")
+	testutil.MustCreateFile(t, server.db, target.ID, "photo.jpg")
+
+	user, token := createAuthenticatedUser(t, server)
+	defer server.db.Users.Delete(ctx, user.ID)
+
+	reportsPath := fmt.Sprintf("/targets/%d/reports", target.ID)
+
+	t.Run("refuses to generate without a signing key", func(t *testing.T) {
+		form := url.Values{"period": {"month"}, "format": {"json"}}
+		w, _ := makeAuthenticatedRequest(server, http.MethodPost, reportsPath, token, form)
+
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("expected status 503, got %d", w.Code)
+		}
+	})
+
+	key, err := signing.Generate()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	server.config.SigningKey = key
+
+	t.Run("rejects an invalid period", func(t *testing.T) {
+		form := url.Values{"period": {"custom"}, "from": {"2024-05-01"}, "to": {"2024-04-01"}, "format": {"html"}}
+		w, _ := makeAuthenticatedRequest(server, http.MethodPost, reportsPath, token, form)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("generates and lists a report", func(t *testing.T) {
+		form := url.Values{"period": {"custom"}, "from": {"2024-04-01"}, "to": {"2024-04-30"}, "format": {"csv"}}
+		w, _ := makeAuthenticatedRequest(server, http.MethodPost, reportsPath, token, form)
+		if w.Code != http.StatusSeeOther {
+			t.Fatalf("expected status 303, got %d", w.Code)
+		}
+
+		w, _ = makeAuthenticatedRequest(server, http.MethodGet, reportsPath, token, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+		body := w.Body.String()
+		if !strings.Contains(body, "2024-04-01 to 2024-04-30") || !strings.Contains(body, key.ID()) {
+			t.Error("response should list the report with its period and key")
+		}
+	})
+
+	t.Run("downloads a report that verifies against its signature", func(t *testing.T) {
+		reports, _ := server.db.AuditReports.ListByTarget(ctx, target.ID, 10)
+		if len(reports) != 1 {
+			t.Fatalf("expected 1 report, got %d", len(reports))
+		}
+		reportPath := fmt.Sprintf("%s/%d", reportsPath, reports[0].ID)
+
+		content, _ := makeAuthenticatedRequest(server, http.MethodGet, reportPath, token, nil)
+		if content.Code != http.StatusOK || !strings.Contains(content.Header().Get("Content-Disposition"), "fixity-audit-archive-2024-04-01-2024-05-01.csv") {
+			t.Fatalf("unexpected download: %d %v", content.Code, content.Header())
+		}
+		signature, _ := makeAuthenticatedRequest(server, http.MethodGet, reportPath+"/signature", token, nil)
+		if signature.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", signature.Code)
+		}
+
+		if _, err := report.Verify(ctx, server.db, content.Body.Bytes(), signature.Body.Bytes()); err != nil {
+			t.Errorf("expected the download to verify: %v", err)
+		}
+	})
+
+	t.Run("returns 404 for another target's report", func(t *testing.T) {
+		other := testutil.MustCreateStorageTarget(t, server.db, "other")
+		reports, _ := server.db.AuditReports.ListByTarget(ctx, target.ID, 10)
+		path := fmt.Sprintf("/targets/%d/reports/%d", other.ID, reports[0].ID)
+		w, _ := makeAuthenticatedRequest(server, http.MethodGet, path, token, nil)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", w.Code)
+		}
+	})
+}
@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/jeffanddom/fixity/internal/database"
 )
@@ -305,6 +306,110 @@ func TestHandleCreateTarget(t *testing.T) {
 		}
 	})
 
+	t.Run("creates SFTP target", func(t *testing.T) {
+		user, token := createAuthenticatedUser(t, server)
+		defer server.db.Users.Delete(context.Background(), user.ID)
+
+		hostKey := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOMqqnkVzrm0SdG6UOoqKLsabgH5C9okWi0dh2l9GKJl"
+		form := url.Values{}
+		form.Add("name", "SFTP Target")
+		form.Add("type", "sftp")
+		form.Add("path", "/srv/archive")
+		form.Add("server", "fixity@archive.example.com:2222")
+		form.Add("credentials_ref", "env:ARCHIVE_PASSWORD")
+		form.Add("host_key", hostKey)
+
+		w, _ := makeAuthenticatedRequest(server, http.MethodPost, "/targets", token, form)
+		if w.Result().StatusCode != http.StatusSeeOther {
+			t.Fatalf("expected status 303, got %d. Response body:\n%s", w.Result().StatusCode, w.Body.String())
+		}
+
+		targets, _ := server.db.StorageTargets.ListAll(context.Background())
+		found := false
+		for _, target := range targets {
+			if target.Name == "SFTP Target" {
+				found = true
+				if target.Type != database.StorageTypeSFTP {
+					t.Errorf("expected type sftp, got %s", target.Type)
+				}
+				if target.Server == nil || *target.Server != "fixity@archive.example.com:2222" {
+					t.Errorf("expected the server address to be saved, got %v", target.Server)
+				}
+				if target.CredentialsRef == nil || *target.CredentialsRef != "env:ARCHIVE_PASSWORD" {
+					t.Errorf("expected the credentials reference to be saved, got %v", target.CredentialsRef)
+				}
+				if target.HostKey == nil || *target.HostKey != hostKey {
+					t.Errorf("expected the host key to be pinned, got %v", target.HostKey)
+				}
+				server.db.StorageTargets.Delete(context.Background(), target.ID)
+				break
+			}
+		}
+		if !found {
+			t.Error("target was not created in database")
+		}
+
+		form.Set("name", "SFTP Target Without Key")
+		form.Set("host_key", "")
+		w, _ = makeAuthenticatedRequest(server, http.MethodPost, "/targets", token, form)
+		if !strings.Contains(w.Body.String(), "Invalid host key") {
+			t.Error("response should require a pinned host key")
+		}
+
+		form.Set("name", "SFTP Target Skipping Directories")
+		form.Set("host_key", hostKey)
+		form.Set("skip_unchanged_dirs", "true")
+		w, _ = makeAuthenticatedRequest(server, http.MethodPost, "/targets", token, form)
+		if !strings.Contains(w.Body.String(), "Skipping unchanged directories is not supported") {
+			t.Error("response should reject unchanged directory skipping")
+		}
+	})
+
+	t.Run("creates WebDAV target", func(t *testing.T) {
+		user, token := createAuthenticatedUser(t, server)
+		defer server.db.Users.Delete(context.Background(), user.ID)
+
+		form := url.Values{}
+		form.Add("name", "WebDAV Target")
+		form.Add("type", "webdav")
+		form.Add("path", "/Photos")
+		form.Add("server", "https://cloud.example.com/remote.php/dav/files/alice")
+
+		w, _ := makeAuthenticatedRequest(server, http.MethodPost, "/targets", token, form)
+		if w.Result().StatusCode != http.StatusSeeOther {
+			t.Fatalf("expected status 303, got %d. Response body:\n%s", w.Result().StatusCode, w.Body.String())
+		}
+
+		targets, _ := server.db.StorageTargets.ListAll(context.Background())
+		found := false
+		for _, target := range targets {
+			if target.Name == "WebDAV Target" {
+				found = true
+				if target.Type != database.StorageTypeWebDAV {
+					t.Errorf("expected type webdav, got %s", target.Type)
+				}
+				if target.Server == nil || *target.Server != "https://cloud.example.com/remote.php/dav/files/alice" {
+					t.Errorf("expected the server URL to be saved, got %v", target.Server)
+				}
+				if target.CredentialsRef != nil {
+					t.Errorf("expected no credentials for a public share, got %v", *target.CredentialsRef)
+				}
+				server.db.StorageTargets.Delete(context.Background(), target.ID)
+				break
+			}
+		}
+		if !found {
+			t.Error("target was not created in database")
+		}
+
+		form.Set("name", "WebDAV Target Without URL")
+		form.Set("server", "cloud.example.com")
+		w, _ = makeAuthenticatedRequest(server, http.MethodPost, "/targets", token, form)
+		if !strings.Contains(w.Body.String(), "Invalid WebDAV server") {
+			t.Error("response should require an http(s) server URL")
+		}
+	})
+
 	t.Run("rejects invalid storage type", func(t *testing.T) {
 		user, token := createAuthenticatedUser(t, server)
 		defer server.db.Users.Delete(context.Background(), user.ID)
@@ -445,6 +550,82 @@ func TestHandleViewTarget(t *testing.T) {
 	})
 }
 
+func TestHandleTargetCoverage(t *testing.T) {
+	server := setupTestServer(t)
+
+	t.Run("displays coverage against the verification window", func(t *testing.T) {
+		window := 30
+		target := &database.StorageTarget{
+			Name:                   "Coverage Test Target",
+			Type:                   database.StorageTypeLocal,
+			Path:                   "/tmp/coveragetest",
+			Enabled:                true,
+			ParallelWorkers:        1,
+			RandomSamplePercent:    1.0,
+			ChecksumAlgorithm:      "md5",
+			CheckpointInterval:     1000,
+			BatchSize:              1000,
+			VerificationWindowDays: &window,
+		}
+		server.db.StorageTargets.Create(context.Background(), target)
+		defer server.db.StorageTargets.Delete(context.Background(), target.ID)
+
+		// One file verified long ago is overdue
+		old := time.Now().Add(-60 * 24 * time.Hour)
+		checksum, checksumType := "abc123", "md5"
+		file := &database.File{
+			StorageTargetID:   target.ID,
+			Path:              "/stale.txt",
+			Size:              100,
+			FirstSeen:         old,
+			LastSeen:          time.Now(),
+			CurrentChecksum:   &checksum,
+			ChecksumType:      &checksumType,
+			LastChecksummedAt: &old,
+		}
+		if err := server.db.Files.Create(context.Background(), file); err != nil {
+			t.Fatalf("failed to create file: %v", err)
+		}
+
+		user, token := createAuthenticatedUser(t, server)
+		defer server.db.Users.Delete(context.Background(), user.ID)
+
+		w, _ := makeAuthenticatedRequest(server, http.MethodGet, fmt.Sprintf("/targets/%d/coverage", target.ID), token, nil)
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("expected status 200, got %d", resp.StatusCode)
+		}
+
+		body := w.Body.String()
+		if !strings.Contains(body, "every 30 days") {
+			t.Error("response should describe the verification window")
+		}
+		if !strings.Contains(body, "/stale.txt") {
+			t.Error("response should list the overdue file")
+		}
+		if !strings.Contains(body, "Overdue by") {
+			t.Error("response should mark the file overdue")
+		}
+	})
+
+	t.Run("returns 404 for non-existent target", func(t *testing.T) {
+		user, token := createAuthenticatedUser(t, server)
+		defer server.db.Users.Delete(context.Background(), user.ID)
+
+		w, _ := makeAuthenticatedRequest(server, http.MethodGet, "/targets/999999/coverage", token, nil)
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", resp.StatusCode)
+		}
+	})
+}
+
 func TestHandleEditTargetPage(t *testing.T) {
 	server := setupTestServer(t)
 
@@ -644,6 +825,265 @@ func TestHandleUpdateTarget(t *testing.T) {
 		}
 	})
 
+	t.Run("updates path rules", func(t *testing.T) {
+		target := &database.StorageTarget{
+			Name:                "Path Rules Test",
+			Type:                database.StorageTypeLocal,
+			Path:                "/tmp/test",
+			Enabled:             true,
+			ParallelWorkers:     1,
+			RandomSamplePercent: 1.0,
+			ChecksumAlgorithm:   "md5",
+			CheckpointInterval:  1000,
+			BatchSize:           1000,
+		}
+		server.db.StorageTargets.Create(context.Background(), target)
+		defer server.db.StorageTargets.Delete(context.Background(), target.ID)
+
+		user, token := createAuthenticatedUser(t, server)
+		defer server.db.Users.Delete(context.Background(), user.ID)
+
+		form := url.Values{}
+		form.Add("name", "Path Rules Test")
+		form.Add("type", "local")
+		form.Add("path", "/tmp/test")
+		form.Add("exclude_patterns", ".snapshot/\r\n\r\n*.tmp\r\n")
+		form.Add("honor_ignore_files", "true")
+
+		w, _ := makeAuthenticatedRequest(server, http.MethodPut, fmt.Sprintf("/targets/%d", target.ID), token, form)
+		if w.Result().StatusCode != http.StatusSeeOther {
+			t.Fatalf("expected status 303, got %d", w.Result().StatusCode)
+		}
+
+		updated, _ := server.db.StorageTargets.GetByID(context.Background(), target.ID)
+		if len(updated.ExcludePatterns) != 2 || updated.ExcludePatterns[0] != ".snapshot/" || updated.ExcludePatterns[1] != "*.tmp" {
+			t.Errorf("unexpected exclude patterns: %v", updated.ExcludePatterns)
+		}
+		if len(updated.IncludePatterns) != 0 {
+			t.Errorf("expected no include patterns, got %v", updated.IncludePatterns)
+		}
+		if !updated.HonorIgnoreFiles {
+			t.Error("expected .fixityignore files to be honored")
+		}
+
+		form.Set("exclude_patterns", "re:(")
+		w, _ = makeAuthenticatedRequest(server, http.MethodPut, fmt.Sprintf("/targets/%d", target.ID), token, form)
+		if !strings.Contains(w.Body.String(), "Invalid path rules") {
+			t.Error("response should reject an invalid rule")
+		}
+	})
+
+	t.Run("updates walk concurrency", func(t *testing.T) {
+		target := &database.StorageTarget{
+			Name:                "Walk Concurrency Test",
+			Type:                database.StorageTypeLocal,
+			Path:                "/tmp/test",
+			Enabled:             true,
+			ParallelWorkers:     1,
+			RandomSamplePercent: 1.0,
+			ChecksumAlgorithm:   "md5",
+			CheckpointInterval:  1000,
+			BatchSize:           1000,
+		}
+		server.db.StorageTargets.Create(context.Background(), target)
+		defer server.db.StorageTargets.Delete(context.Background(), target.ID)
+
+		user, token := createAuthenticatedUser(t, server)
+		defer server.db.Users.Delete(context.Background(), user.ID)
+
+		form := url.Values{}
+		form.Add("name", "Walk Concurrency Test")
+		form.Add("type", "local")
+		form.Add("path", "/tmp/test")
+		form.Add("walk_concurrency", "16")
+
+		w, _ := makeAuthenticatedRequest(server, http.MethodPut, fmt.Sprintf("/targets/%d", target.ID), token, form)
+		if w.Result().StatusCode != http.StatusSeeOther {
+			t.Fatalf("expected status 303, got %d", w.Result().StatusCode)
+		}
+
+		updated, _ := server.db.StorageTargets.GetByID(context.Background(), target.ID)
+		if updated.WalkConcurrency == nil || *updated.WalkConcurrency != 16 {
+			t.Errorf("expected walk concurrency 16, got %v", updated.WalkConcurrency)
+		}
+
+		form.Set("walk_concurrency", "1000")
+		w, _ = makeAuthenticatedRequest(server, http.MethodPut, fmt.Sprintf("/targets/%d", target.ID), token, form)
+		if !strings.Contains(w.Body.String(), "Invalid directory listing concurrency") {
+			t.Error("response should reject an out of range concurrency")
+		}
+	})
+
+	t.Run("updates unchanged directory skipping", func(t *testing.T) {
+		target := &database.StorageTarget{
+			Name:                "Dir Skip Test",
+			Type:                database.StorageTypeNFS,
+			Path:                "/mnt/test",
+			Enabled:             true,
+			ParallelWorkers:     1,
+			RandomSamplePercent: 1.0,
+			ChecksumAlgorithm:   "md5",
+			CheckpointInterval:  1000,
+			BatchSize:           1000,
+		}
+		server.db.StorageTargets.Create(context.Background(), target)
+		defer server.db.StorageTargets.Delete(context.Background(), target.ID)
+
+		user, token := createAuthenticatedUser(t, server)
+		defer server.db.Users.Delete(context.Background(), user.ID)
+
+		form := url.Values{}
+		form.Add("name", "Dir Skip Test")
+		form.Add("type", "nfs")
+		form.Add("path", "/mnt/test")
+		form.Add("server", "nfs.example.com")
+		form.Add("share", "/exports/data")
+		form.Add("skip_unchanged_dirs", "true")
+		form.Add("full_walk_days", "30")
+
+		w, _ := makeAuthenticatedRequest(server, http.MethodPut, fmt.Sprintf("/targets/%d", target.ID), token, form)
+		if w.Result().StatusCode != http.StatusSeeOther {
+			t.Fatalf("expected status 303, got %d", w.Result().StatusCode)
+		}
+
+		updated, _ := server.db.StorageTargets.GetByID(context.Background(), target.ID)
+		if !updated.SkipUnchangedDirs {
+			t.Error("expected unchanged directories to be skipped")
+		}
+		if updated.FullWalkDays == nil || *updated.FullWalkDays != 30 {
+			t.Errorf("expected a full walk every 30 days, got %v", updated.FullWalkDays)
+		}
+
+		form.Set("full_walk_days", "0")
+		w, _ = makeAuthenticatedRequest(server, http.MethodPut, fmt.Sprintf("/targets/%d", target.ID), token, form)
+		if !strings.Contains(w.Body.String(), "Invalid full walk interval") {
+			t.Error("response should reject an out of range full walk interval")
+		}
+	})
+
+	t.Run("updates extended attribute capture", func(t *testing.T) {
+		target := &database.StorageTarget{
+			Name:                "Xattr Test",
+			Type:                database.StorageTypeLocal,
+			Path:                "/tmp/test",
+			Enabled:             true,
+			ParallelWorkers:     1,
+			RandomSamplePercent: 1.0,
+			ChecksumAlgorithm:   "md5",
+			CheckpointInterval:  1000,
+			BatchSize:           1000,
+		}
+		server.db.StorageTargets.Create(context.Background(), target)
+		defer server.db.StorageTargets.Delete(context.Background(), target.ID)
+
+		user, token := createAuthenticatedUser(t, server)
+		defer server.db.Users.Delete(context.Background(), user.ID)
+
+		form := url.Values{}
+		form.Add("name", "Xattr Test")
+		form.Add("type", "local")
+		form.Add("path", "/tmp/test")
+		form.Add("capture_xattrs", "true")
+
+		w, _ := makeAuthenticatedRequest(server, http.MethodPut, fmt.Sprintf("/targets/%d", target.ID), token, form)
+		if w.Result().StatusCode != http.StatusSeeOther {
+			t.Fatalf("expected status 303, got %d", w.Result().StatusCode)
+		}
+
+		updated, _ := server.db.StorageTargets.GetByID(context.Background(), target.ID)
+		if !updated.CaptureXattrs {
+			t.Error("expected extended attributes to be captured")
+		}
+
+		w, _ = makeAuthenticatedRequest(server, http.MethodGet, fmt.Sprintf("/targets/%d", target.ID), token, nil)
+		if !strings.Contains(w.Body.String(), "extended attributes and ACLs") {
+			t.Error("target view should show that extended attributes are captured")
+		}
+	})
+
+	t.Run("updates archive member hashing", func(t *testing.T) {
+		target := &database.StorageTarget{
+			Name:                "Archive Test",
+			Type:                database.StorageTypeLocal,
+			Path:                "/tmp/test",
+			Enabled:             true,
+			ParallelWorkers:     1,
+			RandomSamplePercent: 1.0,
+			ChecksumAlgorithm:   "md5",
+			CheckpointInterval:  1000,
+			BatchSize:           1000,
+		}
+		server.db.StorageTargets.Create(context.Background(), target)
+		defer server.db.StorageTargets.Delete(context.Background(), target.ID)
+
+		user, token := createAuthenticatedUser(t, server)
+		defer server.db.Users.Delete(context.Background(), user.ID)
+
+		form := url.Values{}
+		form.Add("name", "Archive Test")
+		form.Add("type", "local")
+		form.Add("path", "/tmp/test")
+		form.Add("scan_archives", "true")
+
+		w, _ := makeAuthenticatedRequest(server, http.MethodPut, fmt.Sprintf("/targets/%d", target.ID), token, form)
+		if w.Result().StatusCode != http.StatusSeeOther {
+			t.Fatalf("expected status 303, got %d", w.Result().StatusCode)
+		}
+
+		updated, _ := server.db.StorageTargets.GetByID(context.Background(), target.ID)
+		if !updated.ScanArchives {
+			t.Error("expected archive members to be hashed")
+		}
+
+		w, _ = makeAuthenticatedRequest(server, http.MethodGet, fmt.Sprintf("/targets/%d", target.ID), token, nil)
+		if !strings.Contains(w.Body.String(), "Hashed whole and by member") {
+			t.Error("target view should show that archive members are hashed")
+		}
+	})
+
+	t.Run("updates change watching", func(t *testing.T) {
+		target := &database.StorageTarget{
+			Name:                "Watch Test",
+			Type:                database.StorageTypeLocal,
+			Path:                "/tmp/test",
+			Enabled:             true,
+			ParallelWorkers:     1,
+			RandomSamplePercent: 1.0,
+			ChecksumAlgorithm:   "md5",
+			CheckpointInterval:  1000,
+			BatchSize:           1000,
+		}
+		server.db.StorageTargets.Create(context.Background(), target)
+		defer server.db.StorageTargets.Delete(context.Background(), target.ID)
+
+		user, token := createAuthenticatedUser(t, server)
+		defer server.db.Users.Delete(context.Background(), user.ID)
+
+		form := url.Values{}
+		form.Add("name", "Watch Test")
+		form.Add("type", "local")
+		form.Add("path", "/tmp/test")
+		form.Add("watch_changes", "true")
+
+		w, _ := makeAuthenticatedRequest(server, http.MethodPut, fmt.Sprintf("/targets/%d", target.ID), token, form)
+		if w.Result().StatusCode != http.StatusSeeOther {
+			t.Fatalf("expected status 303, got %d", w.Result().StatusCode)
+		}
+
+		updated, _ := server.db.StorageTargets.GetByID(context.Background(), target.ID)
+		if !updated.WatchChanges {
+			t.Error("expected change watching to be enabled")
+		}
+
+		form.Set("type", "nfs")
+		form.Set("server", "nfs.example.com")
+		form.Set("share", "/exports/data")
+		w, _ = makeAuthenticatedRequest(server, http.MethodPut, fmt.Sprintf("/targets/%d", target.ID), token, form)
+		if !strings.Contains(w.Body.String(), "only supported for local targets") {
+			t.Error("response should reject watching a network target")
+		}
+	})
+
 	t.Run("handles method override for delete", func(t *testing.T) {
 		target := &database.StorageTarget{
 			Name:                "Method Override Test",
@@ -810,3 +1250,73 @@ func TestHandleTriggerScan(t *testing.T) {
 		}
 	})
 }
+
+func TestHandleTriggerVerify(t *testing.T) {
+	server := setupTestServer(t)
+
+	target := &database.StorageTarget{
+		Name:                "Verify Target",
+		Type:                database.StorageTypeLocal,
+		Path:                t.TempDir(),
+		Enabled:             true,
+		ParallelWorkers:     1,
+		RandomSamplePercent: 1.0,
+		ChecksumAlgorithm:   "md5",
+		CheckpointInterval:  1000,
+		BatchSize:           1000,
+	}
+	server.db.StorageTargets.Create(context.Background(), target)
+	defer server.db.StorageTargets.Delete(context.Background(), target.ID)
+
+	t.Run("triggers verification with a budget", func(t *testing.T) {
+		user, token := createAuthenticatedUser(t, server)
+		defer server.db.Users.Delete(context.Background(), user.ID)
+
+		form := url.Values{
+			"verify_duration": {"4h"},
+			"verify_bytes":    {"500GB"},
+		}
+		w, _ := makeAuthenticatedRequest(server, http.MethodPost, fmt.Sprintf("/targets/%d/verify", target.ID), token, form)
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusSeeOther {
+			t.Errorf("expected status 303, got %d", resp.StatusCode)
+		}
+
+		location := resp.Header.Get("Location")
+		if location != "/scans/running" {
+			t.Errorf("expected redirect to /scans/running, got %s", location)
+		}
+	})
+
+	t.Run("rejects an invalid budget", func(t *testing.T) {
+		user, token := createAuthenticatedUser(t, server)
+		defer server.db.Users.Delete(context.Background(), user.ID)
+
+		form := url.Values{"verify_duration": {"four hours"}}
+		w, _ := makeAuthenticatedRequest(server, http.MethodPost, fmt.Sprintf("/targets/%d/verify", target.ID), token, form)
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("returns 404 for non-existent target", func(t *testing.T) {
+		user, token := createAuthenticatedUser(t, server)
+		defer server.db.Users.Delete(context.Background(), user.ID)
+
+		w, _ := makeAuthenticatedRequest(server, http.MethodPost, "/targets/999999/verify", token, nil)
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", resp.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/jeffanddom/fixity/internal/database"
+)
+
+func (s *Server) handleDownloadScanTimestamp(w http.ResponseWriter, r *http.Request) {
+	scanID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid scan ID", http.StatusBadRequest)
+		return
+	}
+
+	ts, err := s.db.ScanTimestamps.GetByScanID(r.Context(), scanID)
+	if err != nil || ts == nil || ts.Token == nil {
+		http.Error(w, "Timestamp not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="fixity-scan-%d.tst"`, scanID))
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(ts.Token)
+}
+
+// scanTimestampSummary describes a scan's time-stamp and whether its token
+// verified
+func scanTimestampSummary(ts *database.ScanTimestamp, verifyErr error) string {
+	root := `Merkle root <span class="checksum">` + ts.MerkleRoot + `</span> of ` +
+		strconv.FormatInt(ts.LeafCount, 10) + ` checksums`
+
+	if ts.Token == nil {
+		reason := ""
+		if ts.Error != nil {
+			reason = ": " + template.HTMLEscapeString(*ts.Error)
+		}
+		return root + `<br><span class="status-partial">Not yet time-stamped` + reason + `</span>`
+	}
+
+	html := root + `<br>Time-stamped ` + ts.GenTime.UTC().Format("2006-01-02 15:04:05 MST") +
+		` by ` + template.HTMLEscapeString(ts.TSAURL) + ` (serial ` + template.HTMLEscapeString(*ts.SerialNumber) + `) `
+	if verifyErr != nil {
+		html += `<span class="status-failed">✗ ` + template.HTMLEscapeString(verifyErr.Error()) + `</span>`
+	} else {
+		html += `<span class="verified">✓ verified</span>`
+	}
+	return html + ` <a href="/scans/` + strconv.FormatInt(ts.ScanID, 10) + `/timestamp" class="btn btn-sm btn-secondary">Download Token</a>`
+}
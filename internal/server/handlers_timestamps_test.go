@@ -0,0 +1,91 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeffanddom/fixity/internal/database"
+	"github.com/jeffanddom/fixity/internal/timestamp"
+	"github.com/jeffanddom/fixity/tests/testutil"
+)
+
+func TestHandleScanTimestamps(t *testing.T) {
+	server := setupTestServer(t)
+	ctx := context.Background()
+	target := testutil.MustCreateStorageTarget(t, server.db, "archive")
+	testutil.MustCreateFile(t, server.db, target.ID, "docs/contract.pdf")
+
+	user, token := createAuthenticatedUser(t, server)
+	defer server.db.Users.Delete(ctx, user.ID)
+
+	scan := testutil.MustCreateScan(t, server.db, target.ID)
+	completed := time.Now()
+	scan.Status, scan.CompletedAt = database.ScanStatusCompleted, &completed
+	if err := server.db.Scans.Update(ctx, scan); err != nil {
+		t.Fatalf("failed to complete scan: %v", err)
+	}
+	scanPath := fmt.Sprintf("/scans/%d", scan.ID)
+
+	t.Run("shows nothing for a scan without a timestamp", func(t *testing.T) {
+		w, _ := makeAuthenticatedRequest(server, http.MethodGet, scanPath, token, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+		if strings.Contains(w.Body.String(), "Trusted Timestamp") {
+			t.Error("expected no timestamp row")
+		}
+
+		w, _ = makeAuthenticatedRequest(server, http.MethodGet, scanPath+"/timestamp", token, nil)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", w.Code)
+		}
+	})
+
+	tsa := testutil.NewTSA(t)
+	ts, err := timestamp.StampScan(ctx, server.db, timestamp.NewClient(tsa.URL), scan)
+	if err != nil {
+		t.Fatalf("StampScan failed: %v", err)
+	}
+
+	t.Run("shows a verified timestamp", func(t *testing.T) {
+		server.config.TSARoots = tsa.Roots
+		w, _ := makeAuthenticatedRequest(server, http.MethodGet, scanPath, token, nil)
+		body := w.Body.String()
+		if !strings.Contains(body, "Trusted Timestamp") || !strings.Contains(body, ts.MerkleRoot) || !strings.Contains(body, "✓ verified") {
+			t.Error("expected the verified timestamp to be shown")
+		}
+	})
+
+	t.Run("shows a timestamp that fails to verify", func(t *testing.T) {
+		server.config.TSARoots = testutil.NewTSA(t).Roots
+		w, _ := makeAuthenticatedRequest(server, http.MethodGet, scanPath, token, nil)
+		if body := w.Body.String(); !strings.Contains(body, "not trusted") || strings.Contains(body, "✓ verified") {
+			t.Error("expected the verification failure to be shown")
+		}
+	})
+
+	t.Run("downloads the token", func(t *testing.T) {
+		w, _ := makeAuthenticatedRequest(server, http.MethodGet, scanPath+"/timestamp", token, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+		if !bytes.Equal(w.Body.Bytes(), ts.Token) {
+			t.Error("expected the stored token")
+		}
+		if cd := w.Header().Get("Content-Disposition"); !strings.Contains(cd, fmt.Sprintf("fixity-scan-%d.tst", scan.ID)) {
+			t.Errorf("unexpected Content-Disposition %q", cd)
+		}
+	})
+
+	t.Run("rejects an invalid scan ID", func(t *testing.T) {
+		w, _ := makeAuthenticatedRequest(server, http.MethodGet, "/scans/abc/timestamp", token, nil)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", w.Code)
+		}
+	})
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"crypto/x509"
 	"fmt"
 	"html/template"
 	"net/http"
@@ -13,6 +14,7 @@ import (
 	"github.com/jeffanddom/fixity/internal/auth"
 	"github.com/jeffanddom/fixity/internal/coordinator"
 	"github.com/jeffanddom/fixity/internal/database"
+	"github.com/jeffanddom/fixity/internal/signing"
 )
 
 // Server handles HTTP requests
@@ -31,6 +33,8 @@ type Config struct {
 	SessionCookieName string
 	TemplateDir     string
 	StaticDir       string
+	SigningKey      *signing.Key // Signs audit reports; without it none can be generated
+	TSARoots        *x509.CertPool // Trusted roots for scan time-stamps; the system's if nil
 }
 
 // New creates a new HTTP server
@@ -111,6 +115,17 @@ func (s *Server) setupRoutes() {
 			r.Put("/{id}", s.handleUpdateTarget)
 			r.Delete("/{id}", s.handleDeleteTarget)
 			r.Post("/{id}/scan", s.handleTriggerScan)
+			r.Post("/{id}/verify", s.handleTriggerVerify)
+			r.Get("/{id}/coverage", s.handleTargetCoverage)
+			r.Get("/{id}/bags/{bagID}", s.handleViewBagValidation)
+			r.Get("/{id}/reports", s.handleListReports)
+			r.Post("/{id}/reports", s.handleGenerateReport)
+			r.Get("/{id}/reports/{reportID}", s.handleDownloadReport)
+			r.Get("/{id}/reports/{reportID}/signature", s.handleDownloadReportSignature)
+			r.Get("/{id}/attestations", s.handleListAttestations)
+			r.Post("/{id}/attestations", s.handleCreateAttestation)
+			r.Get("/{id}/attestations/{attestationID}", s.handleDownloadAttestation)
+			r.Get("/{id}/attestations/{attestationID}/signature", s.handleDownloadAttestationSignature)
 		})
 
 		// Scans
@@ -118,6 +133,7 @@ func (s *Server) setupRoutes() {
 			r.Get("/", s.handleListScans)
 			r.Get("/{id}", s.handleViewScan)
 			r.Get("/running", s.handleRunningScans)
+			r.Get("/{id}/timestamp", s.handleDownloadScanTimestamp)
 		})
 
 		// Files
@@ -127,6 +143,26 @@ func (s *Server) setupRoutes() {
 			r.Get("/{id}/history", s.handleFileHistory)
 		})
 
+		// Duplicate content
+		r.Route("/duplicates", func(r chi.Router) {
+			r.Get("/", s.handleListDuplicates)
+			r.Get("/group", s.handleViewDuplicateGroup)
+		})
+
+		// Replica groups
+		r.Route("/replicas", func(r chi.Router) {
+			r.Get("/", s.handleListReplicaGroups)
+			r.Get("/new", s.handleNewReplicaGroupPage)
+			r.Post("/", s.handleCreateReplicaGroup)
+			r.Get("/{id}", s.handleViewReplicaGroup)
+			r.Get("/{id}/edit", s.handleEditReplicaGroupPage)
+			r.Post("/{id}", s.handleUpdateReplicaGroup)
+			r.Put("/{id}", s.handleUpdateReplicaGroup)
+			r.Delete("/{id}", s.handleDeleteReplicaGroup)
+			r.Post("/{id}/compare", s.handleCompareReplicaGroup)
+			r.Get("/{id}/comparisons/{comparisonID}/export", s.handleExportReplicaComparison)
+		})
+
 		// Admin routes
 		r.Group(func(r chi.Router) {
 			r.Use(s.requireAdmin)
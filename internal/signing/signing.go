@@ -0,0 +1,199 @@
+// Package signing manages Fixity's Ed25519 signing key and the detached
+// signatures made with it
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Algorithm names the signature scheme in detached signatures
+const Algorithm = "ed25519"
+
+// Key is an Ed25519 key Fixity signs with
+type Key struct {
+	id      string
+	private ed25519.PrivateKey
+}
+
+// Generate creates a new signing key
+func Generate() (*Key, error) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	return newKey(private), nil
+}
+
+func newKey(private ed25519.PrivateKey) *Key {
+	return &Key{id: KeyID(private.Public().(ed25519.PublicKey)), private: private}
+}
+
+// LoadOrCreate reads the PEM-encoded signing key at path, generating one and
+// writing it there, readable only by its owner, if the file does not exist
+func LoadOrCreate(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return create(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, fmt.Errorf("signing key %s is not a PEM private key", path)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key: %w", err)
+	}
+	private, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("signing key %s is not an Ed25519 key", path)
+	}
+
+	return newKey(private), nil
+}
+
+func create(path string) (*Key, error) {
+	key, err := Generate()
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key.private)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode signing key: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create signing key: %w", err)
+	}
+	defer f.Close()
+
+	if err := pem.Encode(f, &pem.Block{Type: "PRIVATE KEY", Bytes: der}); err != nil {
+		return nil, fmt.Errorf("failed to write signing key: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write signing key: %w", err)
+	}
+
+	return key, nil
+}
+
+// ID identifies the key by a digest of its public half
+func (k *Key) ID() string {
+	return k.id
+}
+
+// PublicKey returns the public half of the key
+func (k *Key) PublicKey() ed25519.PublicKey {
+	return k.private.Public().(ed25519.PublicKey)
+}
+
+// Sign makes a detached signature of data
+func (k *Key) Sign(data []byte) *Signature {
+	sum := sha256.Sum256(data)
+	return &Signature{
+		Algorithm: Algorithm,
+		KeyID:     k.id,
+		PublicKey: EncodePublicKey(k.PublicKey()),
+		SHA256:    hex.EncodeToString(sum[:]),
+		Value:     base64.StdEncoding.EncodeToString(ed25519.Sign(k.private, data)),
+		SignedAt:  time.Now().UTC(),
+	}
+}
+
+// KeyID returns the ID of a public key: the first 16 hex digits of its
+// SHA-256 digest
+func KeyID(public ed25519.PublicKey) string {
+	sum := sha256.Sum256(public)
+	return hex.EncodeToString(sum[:8])
+}
+
+// EncodePublicKey encodes a public key as base64
+func EncodePublicKey(public ed25519.PublicKey) string {
+	return base64.StdEncoding.EncodeToString(public)
+}
+
+// DecodePublicKey decodes a public key encoded by EncodePublicKey
+func DecodePublicKey(encoded string) (ed25519.PublicKey, error) {
+	public, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(public) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid Ed25519 public key")
+	}
+	return ed25519.PublicKey(public), nil
+}
+
+// Signature is a detached signature, carrying the public key that made it
+// and the digest of what was signed. The public key alone proves nothing;
+// check that KeyID names a key Fixity is known to have signed with.
+type Signature struct {
+	Algorithm string    `json:"algorithm"`
+	KeyID     string    `json:"key_id"`
+	PublicKey string    `json:"public_key"`
+	SHA256    string    `json:"sha256"`
+	Value     string    `json:"signature"`
+	SignedAt  time.Time `json:"signed_at"`
+}
+
+// ParseSignature reads a signature written by Marshal
+func ParseSignature(data []byte) (*Signature, error) {
+	var sig Signature
+	if err := json.Unmarshal(data, &sig); err != nil {
+		return nil, fmt.Errorf("failed to parse signature: %w", err)
+	}
+	return &sig, nil
+}
+
+// Marshal encodes the signature as indented JSON
+func (s *Signature) Marshal() ([]byte, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode signature: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// Verify checks that the signature was made over data by the public key it
+// carries
+func (s *Signature) Verify(data []byte) error {
+	if s.Algorithm != Algorithm {
+		return fmt.Errorf("unsupported signature algorithm: %s", s.Algorithm)
+	}
+
+	public, err := DecodePublicKey(s.PublicKey)
+	if err != nil {
+		return err
+	}
+	if KeyID(public) != s.KeyID {
+		return fmt.Errorf("public key does not match key ID %s", s.KeyID)
+	}
+
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != s.SHA256 {
+		return fmt.Errorf("content does not match the signed SHA-256 digest")
+	}
+
+	value, err := base64.StdEncoding.DecodeString(s.Value)
+	if err != nil {
+		return fmt.Errorf("invalid signature encoding: %w", err)
+	}
+	if !ed25519.Verify(public, data, value) {
+		return fmt.Errorf("signature does not match the content")
+	}
+
+	return nil
+}
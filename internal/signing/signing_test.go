@@ -0,0 +1,81 @@
+package signing
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadOrCreate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "signing.key")
+
+	created, err := LoadOrCreate(path)
+	if err != nil {
+		t.Fatalf("failed to create key: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("expected the key to be written: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected the key to be readable only by its owner, got %v", info.Mode().Perm())
+	}
+
+	loaded, err := LoadOrCreate(path)
+	if err != nil {
+		t.Fatalf("failed to load key: %v", err)
+	}
+	if loaded.ID() != created.ID() || len(loaded.ID()) != 16 {
+		t.Errorf("expected the same key to be loaded, got %s and %s", created.ID(), loaded.ID())
+	}
+}
+
+func TestLoadOrCreate_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "signing.key")
+	os.WriteFile(path, []byte("not a key"), 0600)
+
+	if _, err := LoadOrCreate(path); err == nil {
+		t.Error("expected an error for a file that is not a key")
+	}
+}
+
+func TestSignature(t *testing.T) {
+	key, err := Generate()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	data := []byte("report contents")
+	sig := key.Sign(data)
+
+	encoded, err := sig.Marshal()
+	if err != nil {
+		t.Fatalf("failed to encode signature: %v", err)
+	}
+	parsed, err := ParseSignature(encoded)
+	if err != nil {
+		t.Fatalf("failed to parse signature: %v", err)
+	}
+	if err := parsed.Verify(data); err != nil {
+		t.Errorf("expected the signature to verify: %v", err)
+	}
+
+	if err := parsed.Verify([]byte("report contents, edited")); err == nil {
+		t.Error("expected edited content to fail verification")
+	}
+
+	// A signature re-made by another key over the same digest must not pass
+	// under the original key's ID
+	other, _ := Generate()
+	forged := *parsed
+	forged.PublicKey = other.Sign(data).PublicKey
+	if err := forged.Verify(data); err == nil {
+		t.Error("expected a swapped public key to fail verification")
+	}
+
+	tampered := *parsed
+	tampered.Value = other.Sign(data).Value
+	if err := tampered.Verify(data); err == nil {
+		t.Error("expected another key's signature to fail verification")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 // BenchmarkBackend_Walk benchmarks directory traversal across all backends
@@ -233,6 +234,55 @@ func BenchmarkBackend_ConcurrentWalk(b *testing.B) {
 	}
 }
 
+// BenchmarkBackend_WalkConcurrency benchmarks walking a tree of directories
+// with simulated per-call metadata latency, as seen on network filesystems,
+// for different numbers of concurrent directory listings
+func BenchmarkBackend_WalkConcurrency(b *testing.B) {
+	const dirCount, filesPerDir = 50, 20
+
+	latencies := []time.Duration{0, time.Millisecond}
+	walkerCounts := []int{1, 4, 16}
+
+	testDir := setupBenchTree(b, dirCount, filesPerDir)
+	defer os.RemoveAll(testDir)
+
+	for _, latency := range latencies {
+		for _, walkers := range walkerCounts {
+			b.Run(fmt.Sprintf("latency_%s/%d_walkers", latency, walkers), func(b *testing.B) {
+				restore := simulateWalkLatency(latency)
+				defer restore()
+
+				backend, err := NewBackend(BackendConfig{
+					Type:            TypeLocal,
+					Path:            testDir,
+					WalkConcurrency: walkers,
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+				defer backend.Close()
+
+				ctx := context.Background()
+				b.ResetTimer()
+
+				for i := 0; i < b.N; i++ {
+					entries := 0
+					err := backend.Walk(ctx, func(path string, info *FileInfo) error {
+						entries++
+						return nil
+					})
+					if err != nil {
+						b.Fatal(err)
+					}
+					if entries != dirCount*(filesPerDir+1) {
+						b.Fatalf("expected %d entries, got %d", dirCount*(filesPerDir+1), entries)
+					}
+				}
+			})
+		}
+	}
+}
+
 // BenchmarkBackend_DeepNesting benchmarks deeply nested directory structures
 func BenchmarkBackend_DeepNesting(b *testing.B) {
 	depths := []int{10, 50, 100}
@@ -363,6 +413,49 @@ func setupBenchDirectory(b *testing.B, fileCount int) string {
 	return tempDir
 }
 
+func setupBenchTree(b *testing.B, dirCount, filesPerDir int) string {
+	b.Helper()
+
+	tempDir, err := os.MkdirTemp("", "fixity-bench-tree-*")
+	if err != nil {
+		b.Fatalf("failed to create temp dir: %v", err)
+	}
+
+	for d := 0; d < dirCount; d++ {
+		dir := filepath.Join(tempDir, fmt.Sprintf("dir%03d", d))
+		if err := os.Mkdir(dir, 0755); err != nil {
+			b.Fatalf("failed to create directory: %v", err)
+		}
+		for f := 0; f < filesPerDir; f++ {
+			filename := filepath.Join(dir, fmt.Sprintf("file%03d.txt", f))
+			if err := os.WriteFile(filename, []byte("Benchmark file\n"), 0644); err != nil {
+				b.Fatalf("failed to create file: %v", err)
+			}
+		}
+	}
+
+	return tempDir
+}
+
+// simulateWalkLatency delays every readdir and lstat made by Walk, returning
+// a function that restores the real calls
+func simulateWalkLatency(latency time.Duration) func() {
+	origReadDir, origLstat := readDir, lstat
+	if latency > 0 {
+		readDir = func(name string) ([]os.DirEntry, error) {
+			time.Sleep(latency)
+			return origReadDir(name)
+		}
+		lstat = func(name string) (os.FileInfo, error) {
+			time.Sleep(latency)
+			return origLstat(name)
+		}
+	}
+	return func() {
+		readDir, lstat = origReadDir, origLstat
+	}
+}
+
 func setupDeepDirectory(b *testing.B, depth int) string {
 	b.Helper()
 
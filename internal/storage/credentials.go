@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Credential references name where a backend's secret is kept, so secrets
+// themselves are never stored with the target:
+//
+//	env:NAME    the value of environment variable NAME
+//	file:PATH   the contents of the file at PATH
+const (
+	credsRefEnv  = "env:"
+	credsRefFile = "file:"
+)
+
+// ValidateCredentialsRef checks that ref is a supported credential reference
+func ValidateCredentialsRef(ref string) error {
+	switch {
+	case strings.HasPrefix(ref, credsRefEnv) && len(ref) > len(credsRefEnv):
+		return nil
+	case strings.HasPrefix(ref, credsRefFile) && len(ref) > len(credsRefFile):
+		return nil
+	default:
+		return fmt.Errorf("unsupported credentials reference %q (use env:NAME or file:PATH)", ref)
+	}
+}
+
+// ResolveCredentials reads the secret a credential reference points to
+func ResolveCredentials(ref string) ([]byte, error) {
+	if err := ValidateCredentialsRef(ref); err != nil {
+		return nil, err
+	}
+
+	if name, ok := strings.CutPrefix(ref, credsRefEnv); ok {
+		value, ok := os.LookupEnv(name)
+		if !ok || value == "" {
+			return nil, fmt.Errorf("credentials environment variable %s is not set", name)
+		}
+		return []byte(value), nil
+	}
+
+	path := strings.TrimPrefix(ref, credsRefFile)
+	secret, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials file: %w", err)
+	}
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("credentials file %s is empty", path)
+	}
+	return secret, nil
+}
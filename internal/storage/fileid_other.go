@@ -0,0 +1,15 @@
+//go:build !unix
+
+package storage
+
+import "os"
+
+// fileID is not available on this platform; hardlinks are not detected
+func fileID(info os.FileInfo) (dev, ino, nlink uint64) {
+	return 0, 0, 0
+}
+
+// fileOwner is not available on this platform; ownership is not recorded
+func fileOwner(info os.FileInfo) (uid, gid uint32, ok bool) {
+	return 0, 0, false
+}
@@ -0,0 +1,426 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// IgnoreFileName is the per-directory file listing paths to leave out of scans
+const IgnoreFileName = ".fixityignore"
+
+// regexPrefix marks a rule as a regular expression rather than a glob
+const regexPrefix = "re:"
+
+// PathRules selects which paths a scan sees. Rules are globs unless prefixed
+// with "re:", in which case they are regular expressions matched against the
+// whole relative path.
+//
+// Globs follow .gitignore conventions: a glob without a slash matches a file
+// or directory name at any depth, a glob containing a slash is matched against
+// the whole relative path (with ** matching any number of directories), and a
+// trailing slash matches directories only. Excluded directories are not
+// descended into. Include rules apply to files; when any are given, only files
+// matching one of them are kept.
+type PathRules struct {
+	Include     []string
+	Exclude     []string
+	IgnoreFiles bool // Honor .fixityignore files found during the walk
+}
+
+// Empty reports whether the rules leave every path in place
+func (r PathRules) Empty() bool {
+	return len(r.Include) == 0 && len(r.Exclude) == 0 && !r.IgnoreFiles
+}
+
+// Validate checks that every rule compiles
+func (r PathRules) Validate() error {
+	_, _, err := r.compile()
+	return err
+}
+
+func (r PathRules) compile() (include, exclude []*pathPattern, err error) {
+	for _, rule := range r.Include {
+		p, err := compilePattern(rule, "")
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid include rule %q: %w", rule, err)
+		}
+		if p != nil {
+			include = append(include, p)
+		}
+	}
+	for _, rule := range r.Exclude {
+		p, err := compilePattern(rule, "")
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid exclude rule %q: %w", rule, err)
+		}
+		if p != nil {
+			exclude = append(exclude, p)
+		}
+	}
+	return include, exclude, nil
+}
+
+// pathPattern is a single compiled rule. Patterns from a .fixityignore file
+// are relative to the directory holding it (base).
+type pathPattern struct {
+	re       *regexp.Regexp
+	glob     []string // Glob split into path segments
+	anchored bool     // Glob contains a slash and matches the whole path
+	dirOnly  bool
+	base     string
+}
+
+// compilePattern compiles a rule; blank rules yield nil
+func compilePattern(rule, base string) (*pathPattern, error) {
+	rule = strings.TrimSpace(rule)
+	if rule == "" {
+		return nil, nil
+	}
+
+	p := &pathPattern{base: base}
+
+	if strings.HasPrefix(rule, regexPrefix) {
+		re, err := regexp.Compile(strings.TrimPrefix(rule, regexPrefix))
+		if err != nil {
+			return nil, err
+		}
+		p.re = re
+		return p, nil
+	}
+
+	if strings.HasSuffix(rule, "/") {
+		p.dirOnly = true
+		rule = strings.TrimRight(rule, "/")
+	}
+	if strings.Contains(rule, "/") {
+		p.anchored = true
+		rule = strings.TrimPrefix(rule, "/")
+	}
+	if rule == "" {
+		return nil, errors.New("empty pattern")
+	}
+
+	p.glob = strings.Split(rule, "/")
+	for _, segment := range p.glob {
+		if _, err := path.Match(segment, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// match reports whether relPath (slash-separated, relative to the walk root)
+// matches the pattern
+func (p *pathPattern) match(relPath string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+
+	if p.base != "" {
+		if !strings.HasPrefix(relPath, p.base+"/") {
+			return false
+		}
+		relPath = strings.TrimPrefix(relPath, p.base+"/")
+	}
+
+	if p.re != nil {
+		return p.re.MatchString(relPath)
+	}
+
+	if !p.anchored {
+		ok, _ := path.Match(p.glob[0], path.Base(relPath))
+		return ok
+	}
+
+	return matchSegments(p.glob, strings.Split(relPath, "/"))
+}
+
+// matchSegments matches glob segments against path segments, with "**"
+// matching zero or more whole segments
+func matchSegments(glob, segments []string) bool {
+	for len(glob) > 0 {
+		if glob[0] == "**" {
+			for i := 0; i <= len(segments); i++ {
+				if matchSegments(glob[1:], segments[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segments) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(glob[0], segments[0]); !ok {
+			return false
+		}
+		glob, segments = glob[1:], segments[1:]
+	}
+	return len(segments) == 0
+}
+
+// PathExcluder is implemented by backends that leave some paths out of Walk,
+// so callers can tell an excluded path from one that has disappeared
+type PathExcluder interface {
+	Excluded(path string) bool
+}
+
+// FilteredBackend wraps a StorageBackend and applies PathRules to Walk
+type FilteredBackend struct {
+	StorageBackend
+	include     []*pathPattern
+	exclude     []*pathPattern
+	ignoreFiles bool
+
+	mu sync.Mutex
+	// Rules loaded from .fixityignore files, by directory ("" is the root)
+	ignoreRules map[string][]*pathPattern
+	// Directories whose .fixityignore file has been read
+	ignoreLoaded map[string]bool
+	// Directories pruned during the last walk
+	prunedDirs map[string]bool
+}
+
+// NewFilteredBackend wraps backend so that Walk honors rules. If the rules
+// are empty the backend is returned unchanged.
+func NewFilteredBackend(backend StorageBackend, rules PathRules) (StorageBackend, error) {
+	if rules.Empty() {
+		return backend, nil
+	}
+
+	include, exclude, err := rules.compile()
+	if err != nil {
+		return nil, err
+	}
+
+	return &FilteredBackend{
+		StorageBackend: backend,
+		include:        include,
+		exclude:        exclude,
+		ignoreFiles:    rules.IgnoreFiles,
+		ignoreRules:    make(map[string][]*pathPattern),
+		ignoreLoaded:   make(map[string]bool),
+		prunedDirs:     make(map[string]bool),
+	}, nil
+}
+
+// Walk traverses the wrapped backend, skipping excluded files and not
+// descending into excluded directories
+func (b *FilteredBackend) Walk(ctx context.Context, fn WalkFunc) error {
+	return b.walk(ctx, fn, func(fn WalkFunc) error {
+		return b.StorageBackend.Walk(ctx, fn)
+	})
+}
+
+// WalkSkipping walks like Walk, leaving out the files of directories
+// unchanged reports as unchanged
+func (b *FilteredBackend) WalkSkipping(ctx context.Context, unchanged UnchangedDirFunc, fn WalkFunc) error {
+	return b.walk(ctx, fn, func(fn WalkFunc) error {
+		return WalkSkipping(ctx, b.StorageBackend, unchanged, fn)
+	})
+}
+
+// CanSkipDirs reports whether the wrapped backend can skip unchanged directories
+func (b *FilteredBackend) CanSkipDirs() bool {
+	return CanSkipDirs(b.StorageBackend)
+}
+
+// walk applies the path rules to the entries walkInner reports
+func (b *FilteredBackend) walk(ctx context.Context, fn WalkFunc, walkInner func(WalkFunc) error) error {
+	b.mu.Lock()
+	b.ignoreRules = make(map[string][]*pathPattern)
+	b.ignoreLoaded = make(map[string]bool)
+	b.prunedDirs = make(map[string]bool)
+	b.mu.Unlock()
+
+	if b.ignoreFiles {
+		b.loadIgnoreFile(ctx, "")
+	}
+
+	return walkInner(func(relPath string, info *FileInfo) error {
+		if info.Err != nil {
+			// Unreadable entries are passed on unless a rule excludes them;
+			// their type is unknown, so include rules do not apply
+			if b.excludedBy(relPath, info.IsDir) {
+				return nil
+			}
+			return fn(relPath, info)
+		}
+
+		if b.excludedBy(relPath, info.IsDir) {
+			if info.IsDir {
+				b.mu.Lock()
+				b.prunedDirs[relPath] = true
+				b.mu.Unlock()
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if info.IsDir {
+			if b.ignoreFiles {
+				b.loadIgnoreFile(ctx, relPath)
+			}
+			return fn(relPath, info)
+		}
+
+		if !b.included(relPath) {
+			return nil
+		}
+
+		return fn(relPath, info)
+	})
+}
+
+// Lookup reports a single path the way Walk would, returning nil if the
+// path or a directory above it is excluded. .fixityignore files on the way
+// down are read if this backend has not seen them yet.
+func (b *FilteredBackend) Lookup(ctx context.Context, relPath string) (*FileInfo, error) {
+	segments := strings.Split(relPath, "/")
+	for i := 0; i < len(segments); i++ {
+		dir := strings.Join(segments[:i], "/")
+		if dir != "" && b.excludedBy(dir, true) {
+			return nil, nil
+		}
+		if b.ignoreFiles {
+			b.mu.Lock()
+			loaded := b.ignoreLoaded[dir]
+			b.mu.Unlock()
+			if !loaded {
+				b.loadIgnoreFile(ctx, dir)
+			}
+		}
+	}
+
+	info, err := Lookup(ctx, b.StorageBackend, relPath)
+	if err != nil || info == nil {
+		return info, err
+	}
+
+	if b.excludedBy(relPath, info.IsDir) || (!info.IsDir && !b.included(relPath)) {
+		return nil, nil
+	}
+	return info, nil
+}
+
+// Excluded reports whether the rules leave path out of Walk: the path is
+// excluded, lies under an excluded directory, or fails the include rules.
+// Decisions from .fixityignore files reflect the most recent walk.
+func (b *FilteredBackend) Excluded(relPath string) bool {
+	if !b.included(relPath) || b.excludedBy(relPath, false) {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for dir := path.Dir(relPath); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if b.prunedDirs[dir] {
+			return true
+		}
+		for _, p := range b.exclude {
+			if p.match(dir, true) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// included reports whether a file passes the include rules
+func (b *FilteredBackend) included(relPath string) bool {
+	if len(b.include) == 0 {
+		return true
+	}
+	for _, p := range b.include {
+		if p.match(relPath, false) {
+			return true
+		}
+	}
+	return false
+}
+
+// excludedBy reports whether an exclude rule or an applicable .fixityignore
+// rule matches the path itself
+func (b *FilteredBackend) excludedBy(relPath string, isDir bool) bool {
+	for _, p := range b.exclude {
+		if p.match(relPath, isDir) {
+			return true
+		}
+	}
+
+	if !b.ignoreFiles {
+		return false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for dir := path.Dir(relPath); ; dir = path.Dir(dir) {
+		if dir == "." {
+			dir = ""
+		}
+		for _, p := range b.ignoreRules[dir] {
+			if p.match(relPath, isDir) {
+				return true
+			}
+		}
+		if dir == "" {
+			return false
+		}
+	}
+}
+
+// loadIgnoreFile reads dir's .fixityignore, if any. A missing or unreadable
+// file is treated as empty; invalid lines are skipped. As in .gitignore,
+// lines starting with # are comments and \# matches a literal #.
+func (b *FilteredBackend) loadIgnoreFile(ctx context.Context, dir string) {
+	name := IgnoreFileName
+	if dir != "" {
+		name = dir + "/" + IgnoreFileName
+	}
+
+	b.mu.Lock()
+	b.ignoreLoaded[dir] = true
+	b.mu.Unlock()
+
+	rc, err := b.StorageBackend.Open(ctx, name)
+	if err != nil {
+		return
+	}
+	defer rc.Close()
+
+	var patterns []*pathPattern
+	scanner := bufio.NewScanner(rc)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, `\`)
+
+		p, err := compilePattern(line, dir)
+		if err != nil || p == nil {
+			continue
+		}
+		patterns = append(patterns, p)
+	}
+
+	if len(patterns) > 0 {
+		b.mu.Lock()
+		b.ignoreRules[dir] = patterns
+		b.mu.Unlock()
+	}
+}
+
+var (
+	_ PathExcluder = (*FilteredBackend)(nil)
+	_ PathLookup   = (*FilteredBackend)(nil)
+)
@@ -0,0 +1,223 @@
+package storage_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/jeffanddom/fixity/internal/storage"
+)
+
+func walkPaths(t *testing.T, backend storage.StorageBackend) []string {
+	t.Helper()
+
+	var paths []string
+	err := backend.Walk(context.Background(), func(path string, info *storage.FileInfo) error {
+		if !info.IsDir {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+
+	sort.Strings(paths)
+	return paths
+}
+
+func setupFilterTree(t *testing.T) string {
+	t.Helper()
+
+	tmpDir := t.TempDir()
+	for _, dir := range []string{".snapshot/hourly.0", "#recycle", "photos/2024", "docs"} {
+		if err := os.MkdirAll(filepath.Join(tmpDir, dir), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+	}
+
+	for _, file := range []string{
+		".snapshot/hourly.0/a.jpg",
+		"#recycle/old.txt",
+		"photos/2024/a.jpg",
+		"photos/2024/.DS_Store",
+		"photos/2024/Thumbs.db",
+		"docs/report.txt",
+		"docs/report.txt.tmp",
+		"readme.txt",
+	} {
+		writeFile(t, filepath.Join(tmpDir, file), "content")
+	}
+
+	return tmpDir
+}
+
+func TestFilteredBackend_Rules(t *testing.T) {
+	tmpDir := setupFilterTree(t)
+	local, _ := storage.NewLocalFSBackend(tmpDir)
+
+	tests := []struct {
+		name     string
+		rules    storage.PathRules
+		expected []string
+	}{
+		{
+			name:  "exclude directories and names at any depth",
+			rules: storage.PathRules{Exclude: []string{".snapshot/", "#recycle/", ".DS_Store", "Thumbs.db", "*.tmp"}},
+			expected: []string{
+				"docs/report.txt",
+				"photos/2024/a.jpg",
+				"readme.txt",
+			},
+		},
+		{
+			name:  "include limits files",
+			rules: storage.PathRules{Include: []string{"*.jpg"}, Exclude: []string{".snapshot/"}},
+			expected: []string{
+				"photos/2024/a.jpg",
+			},
+		},
+		{
+			name:  "anchored globs with double star",
+			rules: storage.PathRules{Include: []string{"photos/**/*.jpg", "docs/*"}, Exclude: []string{"*.tmp"}},
+			expected: []string{
+				"docs/report.txt",
+				"photos/2024/a.jpg",
+			},
+		},
+		{
+			name:  "regular expressions",
+			rules: storage.PathRules{Exclude: []string{`re:(^|/)(\.snapshot|#recycle)(/|$)`, `re:\.(tmp|db)$`}},
+			expected: []string{
+				"docs/report.txt",
+				"photos/2024/.DS_Store",
+				"photos/2024/a.jpg",
+				"readme.txt",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend, err := storage.NewFilteredBackend(local, tt.rules)
+			if err != nil {
+				t.Fatalf("failed to create filtered backend: %v", err)
+			}
+
+			got := walkPaths(t, backend)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFilteredBackend_IgnoreFiles(t *testing.T) {
+	tmpDir := setupFilterTree(t)
+	writeFile(t, filepath.Join(tmpDir, storage.IgnoreFileName), "# snapshots churn constantly\n.snapshot/\n\\#recycle/\n")
+	writeFile(t, filepath.Join(tmpDir, "photos", storage.IgnoreFileName), ".DS_Store\nThumbs.db\n")
+	writeFile(t, filepath.Join(tmpDir, "docs", storage.IgnoreFileName), "/report.txt.tmp\n")
+
+	local, _ := storage.NewLocalFSBackend(tmpDir)
+	backend, err := storage.NewFilteredBackend(local, storage.PathRules{IgnoreFiles: true})
+	if err != nil {
+		t.Fatalf("failed to create filtered backend: %v", err)
+	}
+
+	got := walkPaths(t, backend)
+	expected := []string{
+		storage.IgnoreFileName,
+		"docs/" + storage.IgnoreFileName,
+		"docs/report.txt",
+		"photos/" + storage.IgnoreFileName,
+		"photos/2024/a.jpg",
+		"readme.txt",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	excluder, ok := backend.(storage.PathExcluder)
+	if !ok {
+		t.Fatal("expected filtered backend to implement PathExcluder")
+	}
+
+	for _, path := range []string{".snapshot/hourly.0/a.jpg", "photos/2024/Thumbs.db", "docs/report.txt.tmp"} {
+		if !excluder.Excluded(path) {
+			t.Errorf("expected %s to be excluded", path)
+		}
+	}
+	if excluder.Excluded("docs/report.txt") {
+		t.Error("expected docs/report.txt not to be excluded")
+	}
+}
+
+func TestNewFilteredBackend(t *testing.T) {
+	local, _ := storage.NewLocalFSBackend(t.TempDir())
+
+	t.Run("returns backend unchanged without rules", func(t *testing.T) {
+		backend, err := storage.NewFilteredBackend(local, storage.PathRules{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if backend != storage.StorageBackend(local) {
+			t.Error("expected the original backend")
+		}
+	})
+
+	t.Run("rejects invalid rules", func(t *testing.T) {
+		for _, rule := range []string{"re:(", "[a-"} {
+			if _, err := storage.NewFilteredBackend(local, storage.PathRules{Exclude: []string{rule}}); err == nil {
+				t.Errorf("expected error for rule %q", rule)
+			}
+		}
+	})
+}
+
+// erroringBackend reports a fixed set of unreadable paths during Walk
+type erroringBackend struct {
+	storage.StorageBackend
+	paths []string
+}
+
+func (b *erroringBackend) Walk(ctx context.Context, fn storage.WalkFunc) error {
+	for _, path := range b.paths {
+		err := fn(path, &storage.FileInfo{
+			Path: path,
+			Err:  &storage.WalkError{Path: path, Op: "stat", Err: os.ErrPermission},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestFilteredBackend_WalkErrors(t *testing.T) {
+	local, _ := storage.NewLocalFSBackend(t.TempDir())
+	inner := &erroringBackend{StorageBackend: local, paths: []string{"photos/raw", "cache.tmp"}}
+
+	backend, err := storage.NewFilteredBackend(inner, storage.PathRules{
+		Include: []string{"*.jpg"},
+		Exclude: []string{"*.tmp"},
+	})
+	if err != nil {
+		t.Fatalf("failed to create filtered backend: %v", err)
+	}
+
+	var reported []string
+	backend.Walk(context.Background(), func(path string, info *storage.FileInfo) error {
+		if info.Err != nil {
+			reported = append(reported, path)
+		}
+		return nil
+	})
+
+	// Include rules cannot apply to an entry of unknown type, exclude rules still do
+	if !reflect.DeepEqual(reported, []string{"photos/raw"}) {
+		t.Errorf("expected only photos/raw to be reported, got %v", reported)
+	}
+}
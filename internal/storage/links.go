@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileType identifies what kind of filesystem entry a walked path is
+type FileType string
+
+const (
+	FileTypeRegular   FileType = "regular"
+	FileTypeDirectory FileType = "directory"
+	FileTypeSymlink   FileType = "symlink"
+	FileTypeSocket    FileType = "socket"
+	FileTypeFIFO      FileType = "fifo"
+	FileTypeDevice    FileType = "device"
+)
+
+// IsSpecial reports whether the entry has no content to hash (sockets, FIFOs
+// and devices)
+func (t FileType) IsSpecial() bool {
+	return t == FileTypeSocket || t == FileTypeFIFO || t == FileTypeDevice
+}
+
+// fileTypeOf maps an os.FileMode to a FileType
+func fileTypeOf(mode os.FileMode) FileType {
+	switch {
+	case mode.IsDir():
+		return FileTypeDirectory
+	case mode&os.ModeSymlink != 0:
+		return FileTypeSymlink
+	case mode&os.ModeSocket != 0:
+		return FileTypeSocket
+	case mode&os.ModeNamedPipe != 0:
+		return FileTypeFIFO
+	case mode&(os.ModeDevice|os.ModeCharDevice) != 0:
+		return FileTypeDevice
+	case mode.IsRegular():
+		return FileTypeRegular
+	default:
+		// Anything else (e.g. Solaris doors) has no content we can hash
+		return FileTypeDevice
+	}
+}
+
+// LinkPolicy controls how Walk reports symbolic links
+type LinkPolicy string
+
+const (
+	// LinkPolicyRecord reports symlinks as links, with their target path in
+	// FileInfo.LinkTarget; they are never followed
+	LinkPolicyRecord LinkPolicy = "record"
+	// LinkPolicyFollow reports symlinks as the file or directory they point
+	// to, as long as the target stays within the storage root. Links that
+	// dangle or point outside the root are reported as links.
+	LinkPolicyFollow LinkPolicy = "follow"
+	// LinkPolicySkip leaves symlinks out of the walk entirely
+	LinkPolicySkip LinkPolicy = "skip"
+)
+
+// ParseLinkPolicy parses a link policy name, treating "" as LinkPolicyFollow
+func ParseLinkPolicy(s string) (LinkPolicy, error) {
+	switch policy := LinkPolicy(s); policy {
+	case "":
+		return LinkPolicyFollow, nil
+	case LinkPolicyRecord, LinkPolicyFollow, LinkPolicySkip:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unsupported link policy: %s", s)
+	}
+}
+
+// linkPolicySetter is implemented by backends that walk a mounted filesystem
+type linkPolicySetter interface {
+	SetLinkPolicy(policy LinkPolicy)
+}
+
+// withinRoot reports whether absPath is rootPath or lies beneath it
+func withinRoot(rootPath, absPath string) bool {
+	return absPath == rootPath || strings.HasPrefix(absPath, rootPath+string(filepath.Separator))
+}
@@ -0,0 +1,167 @@
+//go:build unix
+
+package storage_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/jeffanddom/fixity/internal/storage"
+)
+
+// setupLinkTree creates:
+//
+//	/
+//	├── data/file.txt
+//	├── data/hardlink.txt   (hardlink to data/file.txt)
+//	├── file-link           -> data/file.txt
+//	├── dir-link            -> data
+//	├── loop                -> .
+//	├── outside-link        -> <outside the root>
+//	├── dangling            -> missing
+//	└── pipe                (FIFO)
+func setupLinkTree(t *testing.T) string {
+	t.Helper()
+
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	writeFile(t, outside, "secret")
+
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "data"), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	writeFile(t, filepath.Join(root, "data", "file.txt"), "content")
+
+	if err := os.Link(filepath.Join(root, "data", "file.txt"), filepath.Join(root, "data", "hardlink.txt")); err != nil {
+		t.Skipf("Cannot create hardlink: %v", err)
+	}
+
+	links := map[string]string{
+		"file-link":    "data/file.txt",
+		"dir-link":     "data",
+		"loop":         ".",
+		"outside-link": outside,
+		"dangling":     "missing",
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, name)); err != nil {
+			t.Skipf("Cannot create symlink (permissions?): %v", err)
+		}
+	}
+
+	if err := syscall.Mkfifo(filepath.Join(root, "pipe"), 0644); err != nil {
+		t.Skipf("Cannot create FIFO: %v", err)
+	}
+
+	return root
+}
+
+func walkInfos(t *testing.T, policy storage.LinkPolicy, root string) map[string]*storage.FileInfo {
+	t.Helper()
+
+	backend, err := storage.NewBackend(storage.BackendConfig{
+		Type:       storage.TypeLocal,
+		Path:       root,
+		LinkPolicy: policy,
+	})
+	if err != nil {
+		t.Fatalf("failed to create backend: %v", err)
+	}
+
+	infos := make(map[string]*storage.FileInfo)
+	err = backend.Walk(context.Background(), func(path string, info *storage.FileInfo) error {
+		infos[path] = info
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	return infos
+}
+
+func TestWalk_LinkPolicies(t *testing.T) {
+	root := setupLinkTree(t)
+
+	t.Run("record reports links with their targets", func(t *testing.T) {
+		infos := walkInfos(t, storage.LinkPolicyRecord, root)
+
+		for name, target := range map[string]string{"file-link": "data/file.txt", "dir-link": "data", "loop": "."} {
+			info, ok := infos[name]
+			if !ok {
+				t.Fatalf("expected %s to be reported", name)
+			}
+			if info.Type != storage.FileTypeSymlink || info.LinkTarget != target {
+				t.Errorf("expected %s to be a link to %s, got %s -> %q", name, target, info.Type, info.LinkTarget)
+			}
+		}
+		if _, ok := infos["dir-link/file.txt"]; ok {
+			t.Error("recorded directory links should not be descended into")
+		}
+	})
+
+	t.Run("follow reports targets within the root", func(t *testing.T) {
+		infos := walkInfos(t, storage.LinkPolicyFollow, root)
+
+		if info := infos["file-link"]; info == nil || info.Type != storage.FileTypeRegular || info.Size != int64(len("content")) {
+			t.Errorf("expected file-link to be reported as its target, got %+v", info)
+		}
+		if info := infos["dir-link/file.txt"]; info == nil || info.Type != storage.FileTypeRegular {
+			t.Errorf("expected the linked directory to be walked, got %+v", info)
+		}
+		if _, ok := infos["loop/data/file.txt"]; ok {
+			t.Error("a link back to an ancestor should not be walked")
+		}
+
+		// Links that leave the root or dangle are recorded rather than followed
+		for _, name := range []string{"outside-link", "dangling"} {
+			if info := infos[name]; info == nil || info.Type != storage.FileTypeSymlink {
+				t.Errorf("expected %s to be reported as a link, got %+v", name, info)
+			}
+		}
+	})
+
+	t.Run("skip leaves links out", func(t *testing.T) {
+		infos := walkInfos(t, storage.LinkPolicySkip, root)
+
+		for _, name := range []string{"file-link", "dir-link", "loop", "outside-link", "dangling"} {
+			if _, ok := infos[name]; ok {
+				t.Errorf("expected %s to be skipped", name)
+			}
+		}
+		if _, ok := infos["data/file.txt"]; !ok {
+			t.Error("expected regular files to be reported")
+		}
+	})
+}
+
+func TestWalk_FileIdentity(t *testing.T) {
+	root := setupLinkTree(t)
+	infos := walkInfos(t, storage.LinkPolicySkip, root)
+
+	file, link := infos["data/file.txt"], infos["data/hardlink.txt"]
+	if file == nil || link == nil {
+		t.Fatal("expected both hardlinked names to be reported")
+	}
+	if file.Inode == 0 || file.Device != link.Device || file.Inode != link.Inode {
+		t.Errorf("expected hardlinks to share device and inode, got %d:%d and %d:%d", file.Device, file.Inode, link.Device, link.Inode)
+	}
+	if file.Links != 2 {
+		t.Errorf("expected link count 2, got %d", file.Links)
+	}
+
+	if info := infos["pipe"]; info == nil || info.Type != storage.FileTypeFIFO || !info.Type.IsSpecial() {
+		t.Errorf("expected pipe to be reported as a FIFO, got %+v", info)
+	}
+}
+
+func TestParseLinkPolicy(t *testing.T) {
+	if policy, err := storage.ParseLinkPolicy(""); err != nil || policy != storage.LinkPolicyFollow {
+		t.Errorf("expected empty policy to default to follow, got %q (%v)", policy, err)
+	}
+	if _, err := storage.ParseLinkPolicy("sometimes"); err == nil {
+		t.Error("expected error for unknown policy")
+	}
+}
@@ -11,6 +11,10 @@ import (
 // LocalFSBackend implements StorageBackend for local filesystem
 type LocalFSBackend struct {
 	rootPath string
+	readOpts ReadOptions
+	links    LinkPolicy
+	walkers  int
+	xattrs   bool
 }
 
 // NewLocalFSBackend creates a new local filesystem backend
@@ -66,42 +70,13 @@ func (b *LocalFSBackend) Probe(ctx context.Context) error {
 
 // Walk traverses all files in the storage
 func (b *LocalFSBackend) Walk(ctx context.Context, fn WalkFunc) error {
-	return filepath.Walk(b.rootPath, func(path string, info os.FileInfo, err error) error {
-		// Check context cancellation
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
-
-		if err != nil {
-			// If we can't access this path, skip it and continue
-			return nil
-		}
-
-		// Convert to relative path from root
-		relPath, err := filepath.Rel(b.rootPath, path)
-		if err != nil {
-			return fmt.Errorf("failed to get relative path: %w", err)
-		}
-
-		// Skip the root directory itself
-		if relPath == "." {
-			return nil
-		}
-
-		// Convert to forward slashes for consistency
-		relPath = filepath.ToSlash(relPath)
-
-		fileInfo := &FileInfo{
-			Path:    relPath,
-			Size:    info.Size(),
-			ModTime: info.ModTime(),
-			IsDir:   info.IsDir(),
-		}
+	return walkTree(ctx, b.rootPath, b.links, b.walkers, b.xattrs, nil, fn)
+}
 
-		return fn(relPath, fileInfo)
-	})
+// WalkSkipping traverses all files in the storage, leaving out the files of
+// directories unchanged reports as unchanged
+func (b *LocalFSBackend) WalkSkipping(ctx context.Context, unchanged UnchangedDirFunc, fn WalkFunc) error {
+	return walkTree(ctx, b.rootPath, b.links, b.walkers, b.xattrs, unchanged, fn)
 }
 
 // Open opens a file for reading
@@ -124,7 +99,7 @@ func (b *LocalFSBackend) Open(ctx context.Context, path string) (io.ReadCloser,
 		absPath = evalPath
 	}
 
-	f, err := os.Open(absPath)
+	f, err := openForRead(absPath, b.readOpts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open file: %w", err)
 	}
@@ -132,6 +107,38 @@ func (b *LocalFSBackend) Open(ctx context.Context, path string) (io.ReadCloser,
 	return f, nil
 }
 
+// SetReadOptions configures how file contents are read by Open
+func (b *LocalFSBackend) SetReadOptions(opts ReadOptions) {
+	b.readOpts = opts
+}
+
+// SetLinkPolicy configures how Walk reports symlinks
+func (b *LocalFSBackend) SetLinkPolicy(policy LinkPolicy) {
+	b.links = policy
+}
+
+// SetWalkConcurrency sets how many directories Walk lists concurrently
+func (b *LocalFSBackend) SetWalkConcurrency(n int) {
+	b.walkers = n
+}
+
+// SetCaptureXattrs configures whether Walk reports a digest of each file's
+// extended attributes and ACLs
+func (b *LocalFSBackend) SetCaptureXattrs(capture bool) {
+	b.xattrs = capture
+}
+
+// Lookup reports a single path the way Walk would
+func (b *LocalFSBackend) Lookup(ctx context.Context, path string) (*FileInfo, error) {
+	return lookupPath(b.rootPath, b.links, b.xattrs, path)
+}
+
+// Watch queues changed paths under the root until ctx is done. It is
+// supported on Linux, using inotify.
+func (b *LocalFSBackend) Watch(ctx context.Context, queue *WatchQueue) error {
+	return watchTree(ctx, b.rootPath, queue)
+}
+
 // Stat returns file metadata
 func (b *LocalFSBackend) Stat(ctx context.Context, path string) (*FileInfo, error) {
 	// Convert from relative to absolute path
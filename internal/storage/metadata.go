@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"io/fs"
+	"sort"
+)
+
+// xattrSetter is implemented by backends that can read extended attributes
+type xattrSetter interface {
+	SetCaptureXattrs(capture bool)
+}
+
+// permissionBits converts a FileMode into POSIX permission bits
+func permissionBits(mode fs.FileMode) uint32 {
+	bits := uint32(mode.Perm())
+	if mode&fs.ModeSetuid != 0 {
+		bits |= 0o4000
+	}
+	if mode&fs.ModeSetgid != 0 {
+		bits |= 0o2000
+	}
+	if mode&fs.ModeSticky != 0 {
+		bits |= 0o1000
+	}
+	return bits
+}
+
+// digestXattrs returns a hex SHA-256 digest of a set of extended attributes,
+// independent of the order they were listed in. A file without any has the
+// digest of the empty set, so removing them all is still a change.
+func digestXattrs(attrs map[string][]byte) string {
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	var length [8]byte
+	for _, name := range names {
+		for _, field := range [][]byte{[]byte(name), attrs[name]} {
+			binary.BigEndian.PutUint64(length[:], uint64(len(field)))
+			h.Write(length[:])
+			h.Write(field)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
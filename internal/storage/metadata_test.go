@@ -0,0 +1,47 @@
+//go:build unix
+
+package storage_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jeffanddom/fixity/internal/storage"
+)
+
+func TestWalk_Metadata(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "file.txt")
+	writeFile(t, path, "content")
+	if err := os.Chmod(path, 0o640|os.ModeSetgid); err != nil {
+		t.Fatalf("failed to chmod: %v", err)
+	}
+
+	backend, _ := storage.NewLocalFSBackend(tmpDir)
+
+	var info *storage.FileInfo
+	err := backend.Walk(context.Background(), func(p string, fi *storage.FileInfo) error {
+		if p == "file.txt" {
+			info = fi
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if info == nil {
+		t.Fatal("expected file.txt to be walked")
+	}
+
+	if info.Mode != 0o2640 {
+		t.Errorf("expected mode 2640, got %o", info.Mode)
+	}
+	if !info.HasOwner || info.UID != uint32(os.Getuid()) || info.GID != uint32(os.Getgid()) {
+		t.Errorf("expected owner %d:%d, got %d:%d (known: %v)", os.Getuid(), os.Getgid(), info.UID, info.GID, info.HasOwner)
+	}
+	if info.XattrDigest != "" {
+		t.Error("expected no xattr digest unless capture is enabled")
+	}
+}
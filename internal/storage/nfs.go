@@ -14,6 +14,10 @@ type NFSBackend struct {
 	rootPath string
 	server   string
 	share    string
+	readOpts ReadOptions
+	links    LinkPolicy
+	walkers  int
+	xattrs   bool
 }
 
 // NewNFSBackend creates a new NFS backend
@@ -84,43 +88,13 @@ func (b *NFSBackend) Probe(ctx context.Context) error {
 
 // Walk traverses all files in the NFS storage
 func (b *NFSBackend) Walk(ctx context.Context, fn WalkFunc) error {
-	return filepath.Walk(b.rootPath, func(path string, info os.FileInfo, err error) error {
-		// Check context cancellation
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
-
-		if err != nil {
-			// If we can't access this path, skip it and continue
-			// This is especially important for NFS where network issues can occur
-			return nil
-		}
-
-		// Convert to relative path from root
-		relPath, err := filepath.Rel(b.rootPath, path)
-		if err != nil {
-			return fmt.Errorf("failed to get relative path: %w", err)
-		}
-
-		// Skip the root directory itself
-		if relPath == "." {
-			return nil
-		}
-
-		// Convert to forward slashes for consistency
-		relPath = filepath.ToSlash(relPath)
-
-		fileInfo := &FileInfo{
-			Path:    relPath,
-			Size:    info.Size(),
-			ModTime: info.ModTime(),
-			IsDir:   info.IsDir(),
-		}
+	return walkTree(ctx, b.rootPath, b.links, b.walkers, b.xattrs, nil, fn)
+}
 
-		return fn(relPath, fileInfo)
-	})
+// WalkSkipping traverses all files in the NFS storage, leaving out the
+// files of directories unchanged reports as unchanged
+func (b *NFSBackend) WalkSkipping(ctx context.Context, unchanged UnchangedDirFunc, fn WalkFunc) error {
+	return walkTree(ctx, b.rootPath, b.links, b.walkers, b.xattrs, unchanged, fn)
 }
 
 // Open opens a file for reading from NFS
@@ -143,7 +117,7 @@ func (b *NFSBackend) Open(ctx context.Context, path string) (io.ReadCloser, erro
 		absPath = evalPath
 	}
 
-	f, err := os.Open(absPath)
+	f, err := openForRead(absPath, b.readOpts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open file from NFS: %w", err)
 	}
@@ -151,6 +125,32 @@ func (b *NFSBackend) Open(ctx context.Context, path string) (io.ReadCloser, erro
 	return f, nil
 }
 
+// SetReadOptions configures how file contents are read by Open
+func (b *NFSBackend) SetReadOptions(opts ReadOptions) {
+	b.readOpts = opts
+}
+
+// SetLinkPolicy configures how Walk reports symlinks
+func (b *NFSBackend) SetLinkPolicy(policy LinkPolicy) {
+	b.links = policy
+}
+
+// SetWalkConcurrency sets how many directories Walk lists concurrently
+func (b *NFSBackend) SetWalkConcurrency(n int) {
+	b.walkers = n
+}
+
+// SetCaptureXattrs configures whether Walk reports a digest of each file's
+// extended attributes and ACLs
+func (b *NFSBackend) SetCaptureXattrs(capture bool) {
+	b.xattrs = capture
+}
+
+// Lookup reports a single path the way Walk would
+func (b *NFSBackend) Lookup(ctx context.Context, path string) (*FileInfo, error) {
+	return lookupPath(b.rootPath, b.links, b.xattrs, path)
+}
+
 // Stat returns file metadata from NFS
 func (b *NFSBackend) Stat(ctx context.Context, path string) (*FileInfo, error) {
 	// Convert from relative to absolute path
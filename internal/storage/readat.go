@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"io"
+	"os"
+)
+
+// RandomAccess is implemented by readers returned from Open that can also be
+// read at any offset, so formats that keep their index at the end, such as
+// ZIP, need not be copied first
+type RandomAccess interface {
+	// ReaderAt returns the file for reads at any offset and its size, or
+	// false if it can only be read in order
+	ReaderAt() (io.ReaderAt, int64, bool)
+}
+
+// ReaderAt returns random access to r, a reader returned from Open, and the
+// file's size, or false if r can only be read in order
+func ReaderAt(r io.Reader) (io.ReaderAt, int64, bool) {
+	switch f := r.(type) {
+	case RandomAccess:
+		return f.ReaderAt()
+	case *os.File:
+		info, err := f.Stat()
+		if err != nil {
+			return nil, 0, false
+		}
+		return f, info.Size(), true
+	}
+	return nil, 0, false
+}
@@ -0,0 +1,68 @@
+package storage_test
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jeffanddom/fixity/internal/storage"
+	"github.com/jeffanddom/fixity/internal/throttle"
+	"github.com/jeffanddom/fixity/tests/testutil"
+)
+
+func TestReaderAt(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFile(t, filepath.Join(tmpDir, "data.bin"), "0123456789")
+
+	local, _ := storage.NewLocalFSBackend(tmpDir)
+	chunked, _ := storage.NewLocalFSBackend(tmpDir)
+	chunked.SetReadOptions(storage.ReadOptions{CacheMode: storage.CacheModeDrop, ReadSize: 4})
+
+	server := testutil.NewSFTPServer(t, "hunter2", nil)
+	sftp, err := storage.NewSFTPBackend("fixity@"+server.Addr, tmpDir, []byte("hunter2"), server.Pin())
+	if err != nil {
+		t.Fatalf("failed to create backend: %v", err)
+	}
+	defer sftp.Close()
+
+	limiter := throttle.NewLimiter(throttle.Schedule{Default: throttle.Limits{IOPS: 1000}})
+	tests := []struct {
+		name    string
+		backend storage.StorageBackend
+	}{
+		{"local", local},
+		{"chunked", chunked},
+		{"sftp", sftp},
+		{"throttled", storage.NewThrottledBackend(chunked, limiter)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc, err := tt.backend.Open(context.Background(), "data.bin")
+			if err != nil {
+				t.Fatalf("failed to open: %v", err)
+			}
+			defer rc.Close()
+
+			readerAt, size, ok := storage.ReaderAt(rc)
+			if !ok || size != 10 {
+				t.Fatalf("expected random access to 10 bytes, got %v and %d", ok, size)
+			}
+			buf := make([]byte, 3)
+			if _, err := readerAt.ReadAt(buf, 6); err != nil || string(buf) != "678" {
+				t.Errorf("ReadAt(6) = %q, %v", buf, err)
+			}
+
+			// Reading at an offset leaves the reader at the start
+			all, err := io.ReadAll(rc)
+			if err != nil || string(all) != "0123456789" {
+				t.Errorf("expected the whole file after ReadAt, got %q, %v", all, err)
+			}
+		})
+	}
+
+	if _, _, ok := storage.ReaderAt(io.NopCloser(strings.NewReader("data"))); ok {
+		t.Error("expected a plain reader to have no random access")
+	}
+}
@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// CacheMode controls how file reads interact with the OS page cache
+type CacheMode string
+
+const (
+	// CacheModeNormal reads through the page cache as usual
+	CacheModeNormal CacheMode = "normal"
+	// CacheModeDrop reads through the page cache but advises the kernel to
+	// drop each chunk once hashed (posix_fadvise DONTNEED)
+	CacheModeDrop CacheMode = "drop"
+	// CacheModeDirect bypasses the page cache with O_DIRECT and aligned
+	// buffers, falling back to CacheModeDrop where O_DIRECT is unsupported
+	CacheModeDirect CacheMode = "direct"
+)
+
+// DefaultReadSize is the read size used when ReadOptions.ReadSize is unset (1MB)
+const DefaultReadSize = 1024 * 1024
+
+// directIOAlignment is the buffer, offset and length alignment used for O_DIRECT
+const directIOAlignment = 4096
+
+// ReadOptions controls how file contents are read for hashing
+type ReadOptions struct {
+	CacheMode CacheMode
+	ReadSize  int // Bytes per read; 0 uses DefaultReadSize
+}
+
+// Validate checks that the read options are supported
+func (o ReadOptions) Validate() error {
+	switch o.CacheMode {
+	case "", CacheModeNormal, CacheModeDrop, CacheModeDirect:
+	default:
+		return fmt.Errorf("unsupported cache mode: %s", o.CacheMode)
+	}
+	if o.ReadSize < 0 {
+		return fmt.Errorf("read size must not be negative: %d", o.ReadSize)
+	}
+	return nil
+}
+
+// readSize returns the effective read size, rounded up to the O_DIRECT
+// alignment when direct I/O is requested
+func (o ReadOptions) readSize() int {
+	size := o.ReadSize
+	if size <= 0 {
+		size = DefaultReadSize
+	}
+	if o.CacheMode == CacheModeDirect && size%directIOAlignment != 0 {
+		size += directIOAlignment - size%directIOAlignment
+	}
+	return size
+}
+
+// ParseCacheMode parses a cache mode name, treating "" as CacheModeNormal
+func ParseCacheMode(s string) (CacheMode, error) {
+	mode := CacheMode(s)
+	if mode == "" {
+		mode = CacheModeNormal
+	}
+	if err := (ReadOptions{CacheMode: mode}).Validate(); err != nil {
+		return "", err
+	}
+	return mode, nil
+}
+
+// openForRead opens absPath honoring the read options. With default options
+// the plain *os.File is returned so behavior matches os.Open.
+func openForRead(absPath string, opts ReadOptions) (io.ReadCloser, error) {
+	if (opts.CacheMode == "" || opts.CacheMode == CacheModeNormal) && opts.ReadSize <= 0 {
+		return os.Open(absPath)
+	}
+
+	f, err := openChunked(absPath, opts)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// chunkedFile reads a file in fixed-size chunks into a reusable buffer,
+// applying platform-specific cache hints
+type chunkedFile struct {
+	f      *os.File
+	mode   CacheMode
+	buf    []byte // Read buffer, aligned for O_DIRECT when needed
+	data   []byte // Unconsumed portion of buf
+	offset int64  // File offset of the next chunk
+	eof    bool
+	err    error
+}
+
+// Read implements io.Reader, refilling the chunk buffer as needed
+func (c *chunkedFile) Read(p []byte) (int, error) {
+	if len(c.data) == 0 {
+		if c.eof {
+			return 0, io.EOF
+		}
+		if c.err != nil {
+			return 0, c.err
+		}
+		c.fill()
+		if len(c.data) == 0 {
+			if c.err != nil {
+				return 0, c.err
+			}
+			return 0, io.EOF
+		}
+	}
+
+	n := copy(p, c.data)
+	c.data = c.data[n:]
+	return n, nil
+}
+
+// fill reads the next chunk into the buffer
+func (c *chunkedFile) fill() {
+	total := 0
+	for total < len(c.buf) {
+		n, err := c.f.Read(c.buf[total:])
+		total += n
+
+		if err == io.EOF {
+			c.eof = true
+			break
+		}
+		if err != nil {
+			c.err = err
+			break
+		}
+
+		// With O_DIRECT a short, unaligned read only happens at end of file,
+		// and reading again from an unaligned offset would fail
+		if c.mode == CacheModeDirect && n%directIOAlignment != 0 {
+			c.eof = true
+			break
+		}
+	}
+
+	if total > 0 {
+		c.data = c.buf[:total]
+		c.afterRead(c.offset, int64(total))
+		c.offset += int64(total)
+	}
+}
+
+// ReaderAt returns the file for reads at any offset, bypassing the chunk
+// buffer. O_DIRECT files are read in order only, since their reads must be
+// aligned.
+func (c *chunkedFile) ReaderAt() (io.ReaderAt, int64, bool) {
+	if c.mode == CacheModeDirect {
+		return nil, 0, false
+	}
+	info, err := c.f.Stat()
+	if err != nil {
+		return nil, 0, false
+	}
+	return c.f, info.Size(), true
+}
+
+// Close closes the file, dropping any remaining cached pages first
+func (c *chunkedFile) Close() error {
+	c.beforeClose()
+	return c.f.Close()
+}
@@ -0,0 +1,76 @@
+//go:build linux
+
+package storage
+
+import (
+	"errors"
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// openChunked opens a file for chunked reads, using O_DIRECT and
+// posix_fadvise according to the cache mode
+func openChunked(absPath string, opts ReadOptions) (*chunkedFile, error) {
+	mode := opts.CacheMode
+	if mode == "" {
+		mode = CacheModeNormal
+	}
+
+	var f *os.File
+	var err error
+	if mode == CacheModeDirect {
+		f, err = os.OpenFile(absPath, os.O_RDONLY|unix.O_DIRECT, 0)
+		if errors.Is(err, unix.EINVAL) {
+			// Filesystem doesn't support O_DIRECT (e.g. tmpfs); drop pages instead
+			mode = CacheModeDrop
+			f, err = os.Open(absPath)
+		}
+	} else {
+		f, err = os.Open(absPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if mode != CacheModeNormal {
+		// Hint sequential access so readahead stays effective
+		_ = unix.Fadvise(int(f.Fd()), 0, 0, unix.FADV_SEQUENTIAL)
+	}
+
+	opts.CacheMode = mode
+	return &chunkedFile{
+		f:    f,
+		mode: mode,
+		buf:  alignedBuffer(opts.readSize(), mode == CacheModeDirect),
+	}, nil
+}
+
+// alignedBuffer allocates a buffer of size bytes, aligned for O_DIRECT if requested
+func alignedBuffer(size int, aligned bool) []byte {
+	if !aligned {
+		return make([]byte, size)
+	}
+
+	raw := make([]byte, size+directIOAlignment)
+	shift := 0
+	if rem := int(uintptr(unsafe.Pointer(&raw[0])) & (directIOAlignment - 1)); rem != 0 {
+		shift = directIOAlignment - rem
+	}
+	return raw[shift : shift+size]
+}
+
+// afterRead drops a just-read chunk from the page cache in drop mode
+func (c *chunkedFile) afterRead(offset, length int64) {
+	if c.mode == CacheModeDrop {
+		_ = unix.Fadvise(int(c.f.Fd()), offset, length, unix.FADV_DONTNEED)
+	}
+}
+
+// beforeClose drops any pages of the file still cached (e.g. readahead)
+func (c *chunkedFile) beforeClose() {
+	if c.mode != CacheModeNormal {
+		_ = unix.Fadvise(int(c.f.Fd()), 0, 0, unix.FADV_DONTNEED)
+	}
+}
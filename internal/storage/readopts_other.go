@@ -0,0 +1,25 @@
+//go:build !linux
+
+package storage
+
+import "os"
+
+// openChunked opens a file for chunked reads. Cache hints are Linux-only;
+// elsewhere only the read size is honored.
+func openChunked(absPath string, opts ReadOptions) (*chunkedFile, error) {
+	f, err := os.Open(absPath)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.CacheMode = CacheModeNormal
+	return &chunkedFile{
+		f:    f,
+		mode: CacheModeNormal,
+		buf:  make([]byte, opts.readSize()),
+	}, nil
+}
+
+func (c *chunkedFile) afterRead(offset, length int64) {}
+
+func (c *chunkedFile) beforeClose() {}
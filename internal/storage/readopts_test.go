@@ -0,0 +1,82 @@
+package storage_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jeffanddom/fixity/internal/storage"
+)
+
+func TestLocalFSBackend_ReadOptions(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Sizes around the 4KB O_DIRECT alignment and a multi-chunk file
+	sizes := []int{0, 1, 4095, 4096, 10000, 3*1024*1024 + 17}
+	for _, size := range sizes {
+		data := make([]byte, size)
+		for i := range data {
+			data[i] = byte(i % 251)
+		}
+		writeFile(t, filepath.Join(tmpDir, fmt.Sprintf("file-%d.bin", size)), string(data))
+	}
+
+	modes := []storage.CacheMode{storage.CacheModeNormal, storage.CacheModeDrop, storage.CacheModeDirect}
+	readSizes := []int{0, 1000, 64 * 1024}
+
+	for _, mode := range modes {
+		for _, readSize := range readSizes {
+			t.Run(fmt.Sprintf("%s/readsize-%d", mode, readSize), func(t *testing.T) {
+				backend, _ := storage.NewLocalFSBackend(tmpDir)
+				backend.SetReadOptions(storage.ReadOptions{CacheMode: mode, ReadSize: readSize})
+
+				for _, size := range sizes {
+					name := fmt.Sprintf("file-%d.bin", size)
+					expected, _ := os.ReadFile(filepath.Join(tmpDir, name))
+
+					rc, err := backend.Open(context.Background(), name)
+					if err != nil {
+						t.Fatalf("failed to open %s: %v", name, err)
+					}
+
+					got, err := io.ReadAll(rc)
+					rc.Close()
+					if err != nil {
+						t.Fatalf("failed to read %s: %v", name, err)
+					}
+
+					if !bytes.Equal(got, expected) {
+						t.Errorf("%s: read %d bytes, content mismatch (expected %d bytes)", name, len(got), len(expected))
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestReadOptions_Validate(t *testing.T) {
+	if err := (storage.ReadOptions{CacheMode: "bogus"}).Validate(); err == nil {
+		t.Error("expected error for unknown cache mode")
+	}
+
+	if err := (storage.ReadOptions{ReadSize: -1}).Validate(); err == nil {
+		t.Error("expected error for negative read size")
+	}
+
+	if _, err := storage.NewBackend(storage.BackendConfig{
+		Type:        storage.TypeLocal,
+		Path:        t.TempDir(),
+		ReadOptions: storage.ReadOptions{CacheMode: "bogus"},
+	}); err == nil {
+		t.Error("expected NewBackend to reject invalid read options")
+	}
+
+	mode, err := storage.ParseCacheMode("")
+	if err != nil || mode != storage.CacheModeNormal {
+		t.Errorf("expected empty cache mode to parse as normal, got %q (%v)", mode, err)
+	}
+}
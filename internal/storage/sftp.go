@@ -0,0 +1,624 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// sftpDefaultPort is used when the server address has no port
+	sftpDefaultPort = "22"
+
+	// sftpMaxConns caps the SSH connections a backend keeps open. Open calls
+	// beyond it wait for a connection to be released.
+	sftpMaxConns = 8
+
+	// sftpDialTimeout bounds connecting and the SSH handshake
+	sftpDialTimeout = 30 * time.Second
+
+	// sftpMaxLinkHops bounds how many symlinks are followed to resolve a path
+	sftpMaxLinkHops = 40
+)
+
+// SFTPBackend implements StorageBackend for directories served over SFTP.
+// Each Open holds a pooled SSH connection until the file is closed, so
+// parallel hashing workers read over separate connections.
+type SFTPBackend struct {
+	addr     string // host:port
+	user     string
+	rootPath string // Remote directory, as configured
+	config   *ssh.ClientConfig
+	links    LinkPolicy
+
+	slots  chan struct{} // One per open connection, in use or idle
+	mu     sync.Mutex
+	idle   []*sftpConn
+	closed bool
+}
+
+// sftpConn is one pooled SSH connection with its SFTP session
+type sftpConn struct {
+	ssh    *ssh.Client
+	client *sftp.Client
+	root   string      // rootPath with symlinks resolved by the server
+	broken atomic.Bool // The SSH connection has gone away
+}
+
+// NewSFTPBackend creates a new SFTP backend. server is user@host[:port],
+// secret is a private key (PEM) or a password, and hostKey pins the
+// server's key as an authorized_keys line or a SHA256 fingerprint. No
+// connection is made until the backend is used.
+func NewSFTPBackend(server, rootPath string, secret []byte, hostKey string) (*SFTPBackend, error) {
+	user, addr, err := parseSFTPServer(server)
+	if err != nil {
+		return nil, err
+	}
+	if rootPath == "" {
+		return nil, fmt.Errorf("remote path is required")
+	}
+
+	auth, err := sftpAuth(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	hostKeyCallback, err := pinnedHostKey(hostKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SFTPBackend{
+		addr:     addr,
+		user:     user,
+		rootPath: path.Clean(rootPath),
+		config: &ssh.ClientConfig{
+			User:            user,
+			Auth:            auth,
+			HostKeyCallback: hostKeyCallback,
+			Timeout:         sftpDialTimeout,
+		},
+		slots: make(chan struct{}, sftpMaxConns),
+	}, nil
+}
+
+// parseSFTPServer splits user@host[:port] into the user and a dialable address
+func parseSFTPServer(server string) (user, addr string, err error) {
+	user, hostPort, ok := strings.Cut(server, "@")
+	if !ok || user == "" || hostPort == "" {
+		return "", "", fmt.Errorf("SFTP server must be user@host[:port]: %s", server)
+	}
+
+	host, port, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		// No port given
+		host, port = strings.Trim(hostPort, "[]"), sftpDefaultPort
+	}
+	if host == "" {
+		return "", "", fmt.Errorf("SFTP server must be user@host[:port]: %s", server)
+	}
+
+	return user, net.JoinHostPort(host, port), nil
+}
+
+// ValidateSFTPServer checks that server is user@host[:port]
+func ValidateSFTPServer(server string) error {
+	_, _, err := parseSFTPServer(server)
+	return err
+}
+
+// sftpAuth authenticates with secret as a private key if it parses as one,
+// and as a password otherwise
+func sftpAuth(secret []byte) ([]ssh.AuthMethod, error) {
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("SFTP credentials are required")
+	}
+
+	signer, err := ssh.ParsePrivateKey(secret)
+	if err == nil {
+		return []ssh.AuthMethod{ssh.PublicKeys(signer)}, nil
+	}
+	var passphraseErr *ssh.PassphraseMissingError
+	if errors.As(err, &passphraseErr) {
+		return nil, fmt.Errorf("SFTP private key is encrypted; passphrase-protected keys are not supported")
+	}
+
+	password := strings.TrimRight(string(secret), "\r\n")
+	return []ssh.AuthMethod{
+		ssh.Password(password),
+		// Servers that only offer keyboard-interactive ask for the password
+		// that way
+		ssh.KeyboardInteractive(func(name, instruction string, questions []string, echos []bool) ([]string, error) {
+			answers := make([]string, len(questions))
+			for i := range answers {
+				answers[i] = password
+			}
+			return answers, nil
+		}),
+	}, nil
+}
+
+// ValidateHostKey checks that hostKey is an authorized_keys line or a SHA256
+// fingerprint
+func ValidateHostKey(hostKey string) error {
+	_, err := pinnedHostKey(hostKey)
+	return err
+}
+
+// pinnedHostKey accepts only the server key given as an authorized_keys line
+// ("ssh-ed25519 AAAA...") or a fingerprint ("SHA256:...")
+func pinnedHostKey(hostKey string) (ssh.HostKeyCallback, error) {
+	hostKey = strings.TrimSpace(hostKey)
+	if hostKey == "" {
+		return nil, fmt.Errorf("SFTP host key is required")
+	}
+
+	if strings.HasPrefix(hostKey, "SHA256:") {
+		return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			if fingerprint := ssh.FingerprintSHA256(key); fingerprint != hostKey {
+				return fmt.Errorf("host key mismatch for %s: server offered %s", hostname, fingerprint)
+			}
+			return nil
+		}, nil
+	}
+
+	pinned, _, _, _, err := ssh.ParseAuthorizedKey([]byte(hostKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SFTP host key (use an authorized_keys line or SHA256 fingerprint): %w", err)
+	}
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		if !bytes.Equal(key.Marshal(), pinned.Marshal()) {
+			return fmt.Errorf("host key mismatch for %s: server offered %s", hostname, ssh.FingerprintSHA256(key))
+		}
+		return nil
+	}, nil
+}
+
+// acquire takes an idle connection from the pool, or dials a new one if
+// fewer than sftpMaxConns are open
+func (b *SFTPBackend) acquire(ctx context.Context) (*sftpConn, error) {
+	select {
+	case b.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		<-b.slots
+		return nil, fmt.Errorf("SFTP backend is closed")
+	}
+	for len(b.idle) > 0 {
+		conn := b.idle[len(b.idle)-1]
+		b.idle = b.idle[:len(b.idle)-1]
+		if !conn.broken.Load() {
+			b.mu.Unlock()
+			return conn, nil
+		}
+		conn.close()
+	}
+	b.mu.Unlock()
+
+	conn, err := b.dial(ctx)
+	if err != nil {
+		<-b.slots
+		return nil, err
+	}
+	return conn, nil
+}
+
+// release returns a connection to the pool, closing it if it has failed or
+// the backend is closed
+func (b *SFTPBackend) release(conn *sftpConn) {
+	b.mu.Lock()
+	if b.closed || conn.broken.Load() {
+		conn.close()
+	} else {
+		b.idle = append(b.idle, conn)
+	}
+	b.mu.Unlock()
+	<-b.slots
+}
+
+// dial opens an SSH connection and SFTP session, resolving the root path
+func (b *SFTPBackend) dial(ctx context.Context) (*sftpConn, error) {
+	dialer := net.Dialer{Timeout: sftpDialTimeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", b.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to SFTP server %s: %w", b.addr, err)
+	}
+
+	// The handshake ignores ctx, so bound it by deadline instead
+	deadline := time.Now().Add(sftpDialTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	netConn.SetDeadline(deadline)
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(netConn, b.addr, b.config)
+	if err != nil {
+		netConn.Close()
+		return nil, fmt.Errorf("SSH handshake with %s failed: %w", b.addr, err)
+	}
+	netConn.SetDeadline(time.Time{})
+	sshClient := ssh.NewClient(sshConn, chans, reqs)
+
+	client, err := sftp.NewClient(sshClient)
+	if err != nil {
+		sshClient.Close()
+		return nil, fmt.Errorf("failed to start SFTP session on %s: %w", b.addr, err)
+	}
+
+	conn := &sftpConn{ssh: sshClient, client: client}
+	go func() {
+		sshClient.Wait()
+		conn.broken.Store(true)
+	}()
+
+	conn.root, err = client.RealPath(b.rootPath)
+	if err != nil {
+		conn.close()
+		return nil, fmt.Errorf("failed to resolve remote path %s: %w", b.rootPath, err)
+	}
+
+	return conn, nil
+}
+
+func (c *sftpConn) close() {
+	c.client.Close()
+	c.ssh.Close()
+}
+
+// remotePath converts a slash-separated relative path to a remote path,
+// rejecting paths that leave the root
+func (c *sftpConn) remotePath(relPath string) (string, error) {
+	remote := path.Join(c.root, relPath)
+	if !withinRemoteRoot(c.root, remote) {
+		return "", fmt.Errorf("path traversal attempt detected: %s", relPath)
+	}
+	return remote, nil
+}
+
+// resolveLink follows the symlink at remote until it reaches an entry that is
+// not a link. Servers differ in whether realpath resolves links, so each
+// link is read in turn.
+func (c *sftpConn) resolveLink(remote string) (string, os.FileInfo, error) {
+	for i := 0; i < sftpMaxLinkHops; i++ {
+		target, err := c.client.ReadLink(remote)
+		if err != nil {
+			return "", nil, err
+		}
+		if !path.IsAbs(target) {
+			target = path.Join(path.Dir(remote), target)
+		}
+		remote = path.Clean(target)
+
+		info, err := c.client.Lstat(remote)
+		if err != nil {
+			return "", nil, err
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			return remote, info, nil
+		}
+	}
+	return "", nil, fmt.Errorf("too many levels of symbolic links: %s", remote)
+}
+
+// withinRemoteRoot reports whether remote is root or lies beneath it
+func withinRemoteRoot(root, remote string) bool {
+	return root == "/" || remote == root || strings.HasPrefix(remote, root+"/")
+}
+
+// Probe checks that the server is reachable and the remote path is a
+// readable directory
+func (b *SFTPBackend) Probe(ctx context.Context) error {
+	conn, err := b.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer b.release(conn)
+
+	info, err := conn.client.Stat(conn.root)
+	if err != nil {
+		return fmt.Errorf("failed to access SFTP path %s on %s: %w", b.rootPath, b.addr, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("SFTP path is not a directory: %s", b.rootPath)
+	}
+
+	if _, err := conn.client.ReadDir(conn.root); err != nil {
+		return fmt.Errorf("failed to read SFTP path %s: %w", b.rootPath, err)
+	}
+
+	return nil
+}
+
+// Walk traverses all files under the remote path over one connection. It
+// reports entries in lexical order with the same link policy and error
+// reporting as local walks.
+func (b *SFTPBackend) Walk(ctx context.Context, fn WalkFunc) error {
+	conn, err := b.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer b.release(conn)
+
+	w := &sftpWalker{
+		conn:   conn,
+		links:  b.links,
+		fn:     fn,
+		active: map[string]bool{conn.root: true},
+	}
+	if w.links == "" {
+		w.links = LinkPolicyFollow
+	}
+	return w.walkDir(ctx, conn.root, "")
+}
+
+// sftpWalker walks a remote tree for SFTPBackend.Walk
+type sftpWalker struct {
+	conn   *sftpConn
+	links  LinkPolicy
+	fn     WalkFunc
+	active map[string]bool // Resolved directories being walked, to break link cycles
+}
+
+// walkDir visits the entries of the remote directory dir, whose path
+// relative to the root is relDir ("" for the root itself)
+func (w *sftpWalker) walkDir(ctx context.Context, dir, relDir string) error {
+	entries, err := w.conn.client.ReadDir(dir)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if relDir == "" {
+			return fmt.Errorf("failed to read storage root: %w", err)
+		}
+		return w.report(relDir, &FileInfo{
+			Path:  relDir,
+			IsDir: true,
+			Type:  FileTypeDirectory,
+			Err:   &WalkError{Path: relDir, Op: "readdir", Err: err},
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	for _, entry := range entries {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		relPath := entry.Name()
+		if relDir != "" {
+			relPath = relDir + "/" + entry.Name()
+		}
+
+		err := w.visit(ctx, path.Join(dir, entry.Name()), relPath, entry)
+		if err == filepath.SkipDir {
+			// Returned for a file: skip the rest of this directory
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// visit reports one entry and descends into it if it is a directory
+func (w *sftpWalker) visit(ctx context.Context, remote, relPath string, info os.FileInfo) error {
+	fileInfo, resolved := w.describe(remote, relPath, info)
+	if fileInfo == nil {
+		return nil
+	}
+	if !fileInfo.IsDir || fileInfo.Type == FileTypeSymlink {
+		return w.fn(relPath, fileInfo)
+	}
+
+	if err := w.fn(relPath, fileInfo); err != nil {
+		if err == filepath.SkipDir {
+			return nil
+		}
+		return err
+	}
+
+	dir := remote
+	if resolved != "" {
+		dir = resolved
+	}
+	if w.active[dir] {
+		return nil
+	}
+	w.active[dir] = true
+	defer delete(w.active, dir)
+
+	return w.walkDir(ctx, dir, relPath)
+}
+
+// describe converts a remote lstat result into the FileInfo Walk reports,
+// applying the link policy. It returns nil if the entry is left out, and
+// the resolved path of a followed link.
+func (w *sftpWalker) describe(remote, relPath string, info os.FileInfo) (*FileInfo, string) {
+	fileInfo := newSFTPFileInfo(relPath, info)
+	if fileInfo.Type != FileTypeSymlink {
+		return fileInfo, ""
+	}
+
+	switch w.links {
+	case LinkPolicySkip:
+		return nil, ""
+
+	case LinkPolicyFollow:
+		resolved, target, err := w.conn.resolveLink(remote)
+		if err == nil && withinRemoteRoot(w.conn.root, resolved) {
+			return newSFTPFileInfo(relPath, target), resolved
+		}
+	}
+
+	// Record the link itself
+	fileInfo.LinkTarget, _ = w.conn.client.ReadLink(remote)
+	return fileInfo, ""
+}
+
+// report passes an unreadable entry to fn; SkipDir is meaningless for it
+func (w *sftpWalker) report(relPath string, info *FileInfo) error {
+	if err := w.fn(relPath, info); err != nil && err != filepath.SkipDir {
+		return err
+	}
+	return nil
+}
+
+// newSFTPFileInfo converts a remote os.FileInfo into a FileInfo for relPath.
+// SFTP does not expose device and inode numbers, so hardlinks are not
+// detected.
+func newSFTPFileInfo(relPath string, info os.FileInfo) *FileInfo {
+	fileInfo := &FileInfo{
+		Path:    relPath,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		IsDir:   info.IsDir(),
+		Type:    fileTypeOf(info.Mode()),
+		Mode:    permissionBits(info.Mode()),
+		HasMode: true,
+	}
+	if stat, ok := info.Sys().(*sftp.FileStat); ok {
+		fileInfo.UID, fileInfo.GID, fileInfo.HasOwner = stat.UID, stat.GID, true
+	}
+	return fileInfo
+}
+
+// Open opens a remote file for reading, holding a pooled connection until
+// the file is closed
+func (b *SFTPBackend) Open(ctx context.Context, relPath string) (io.ReadCloser, error) {
+	conn, err := b.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	remote, err := conn.remotePath(relPath)
+	if err != nil {
+		b.release(conn)
+		return nil, err
+	}
+
+	// Resolve symlinks and verify they stay within boundaries
+	if info, err := conn.client.Lstat(remote); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		resolved, _, err := conn.resolveLink(remote)
+		if err == nil && !withinRemoteRoot(conn.root, resolved) {
+			b.release(conn)
+			return nil, fmt.Errorf("symlink points outside storage boundary: %s", relPath)
+		}
+		if err == nil {
+			remote = resolved
+		}
+	}
+
+	f, err := conn.client.Open(remote)
+	if err != nil {
+		b.release(conn)
+		return nil, fmt.Errorf("failed to open file over SFTP: %w", err)
+	}
+
+	return &sftpFile{File: f, backend: b, conn: conn}, nil
+}
+
+// sftpFile returns its connection to the pool when closed
+type sftpFile struct {
+	*sftp.File
+	backend *SFTPBackend
+	conn    *sftpConn
+	once    sync.Once
+}
+
+func (f *sftpFile) Close() error {
+	err := f.File.Close()
+	f.once.Do(func() { f.backend.release(f.conn) })
+	return err
+}
+
+// ReaderAt returns the remote file for reads at any offset
+func (f *sftpFile) ReaderAt() (io.ReaderAt, int64, bool) {
+	info, err := f.File.Stat()
+	if err != nil {
+		return nil, 0, false
+	}
+	return f.File, info.Size(), true
+}
+
+// SetLinkPolicy configures how Walk reports symlinks
+func (b *SFTPBackend) SetLinkPolicy(policy LinkPolicy) {
+	b.links = policy
+}
+
+// Stat returns remote file metadata
+func (b *SFTPBackend) Stat(ctx context.Context, relPath string) (*FileInfo, error) {
+	conn, err := b.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer b.release(conn)
+
+	remote, err := conn.remotePath(relPath)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := conn.client.Stat(remote)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file over SFTP: %w", err)
+	}
+
+	return &FileInfo{
+		Path:    relPath,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		IsDir:   info.IsDir(),
+	}, nil
+}
+
+// Close closes idle connections; connections still in use are closed as
+// they are released
+func (b *SFTPBackend) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for _, conn := range b.idle {
+		conn.close()
+	}
+	b.idle = nil
+	return nil
+}
+
+// Server returns the SFTP server address as host:port
+func (b *SFTPBackend) Server() string {
+	return b.addr
+}
+
+// User returns the user the backend logs in as
+func (b *SFTPBackend) User() string {
+	return b.user
+}
+
+// RootPath returns the remote path as configured
+func (b *SFTPBackend) RootPath() string {
+	return b.rootPath
+}
@@ -0,0 +1,218 @@
+package storage
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jeffanddom/fixity/tests/testutil"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestSFTPBackend_WalkAndOpen(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	os.WriteFile(filepath.Join(root, "a.txt"), []byte("alpha"), 0644)
+	os.Mkdir(filepath.Join(root, "sub"), 0755)
+	os.WriteFile(filepath.Join(root, "sub", "b.txt"), []byte("bravo!"), 0640)
+	os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0644)
+	os.Symlink("a.txt", filepath.Join(root, "link.txt"))
+	os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(root, "escape.txt"))
+
+	server := testutil.NewSFTPServer(t, "hunter2", nil)
+	backend, err := NewSFTPBackend("fixity@"+server.Addr, root, []byte("hunter2\n"), server.Pin())
+	if err != nil {
+		t.Fatalf("failed to create backend: %v", err)
+	}
+	defer backend.Close()
+
+	ctx := context.Background()
+	if err := backend.Probe(ctx); err != nil {
+		t.Fatalf("probe failed: %v", err)
+	}
+
+	seen := map[string]*FileInfo{}
+	var order []string
+	err = backend.Walk(ctx, func(path string, info *FileInfo) error {
+		if info.Err != nil {
+			t.Errorf("unexpected walk error at %s: %v", path, info.Err)
+		}
+		seen[path] = info
+		order = append(order, path)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+
+	want := []string{"a.txt", "escape.txt", "link.txt", "sub", "sub/b.txt"}
+	if strings.Join(order, ",") != strings.Join(want, ",") {
+		t.Errorf("expected walk order %v, got %v", want, order)
+	}
+	if info := seen["sub/b.txt"]; info == nil || info.Size != 6 || info.Mode != 0640 || !info.HasOwner {
+		t.Errorf("unexpected info for sub/b.txt: %+v", info)
+	}
+	if info := seen["link.txt"]; info == nil || info.Type != FileTypeRegular || info.Size != 5 {
+		t.Errorf("expected link.txt to be followed to a.txt, got %+v", info)
+	}
+	if info := seen["escape.txt"]; info == nil || info.Type != FileTypeSymlink || info.LinkTarget == "" {
+		t.Errorf("expected escape.txt to be recorded as a link, got %+v", info)
+	}
+
+	rc, err := backend.Open(ctx, "sub/b.txt")
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil || string(data) != "bravo!" {
+		t.Errorf("expected to read bravo!, got %q (%v)", data, err)
+	}
+
+	if _, err := backend.Open(ctx, "escape.txt"); err == nil || !strings.Contains(err.Error(), "outside storage boundary") {
+		t.Errorf("expected a link leaving the root to be refused, got %v", err)
+	}
+	if _, err := backend.Open(ctx, "../outside"); err == nil {
+		t.Error("expected a path leaving the root to be refused")
+	}
+}
+
+func TestSFTPBackend_KeyAuth(t *testing.T) {
+	clientPub, clientPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate client key: %v", err)
+	}
+	sshPub, err := ssh.NewPublicKey(clientPub)
+	if err != nil {
+		t.Fatalf("failed to convert client key: %v", err)
+	}
+	block, err := ssh.MarshalPrivateKey(clientPriv, "")
+	if err != nil {
+		t.Fatalf("failed to marshal client key: %v", err)
+	}
+
+	server := testutil.NewSFTPServer(t, "", sshPub)
+	backend, err := NewSFTPBackend("fixity@"+server.Addr, t.TempDir(), pem.EncodeToMemory(block), ssh.FingerprintSHA256(server.HostKey))
+	if err != nil {
+		t.Fatalf("failed to create backend: %v", err)
+	}
+	defer backend.Close()
+
+	if err := backend.Probe(context.Background()); err != nil {
+		t.Fatalf("probe with key auth and a pinned fingerprint failed: %v", err)
+	}
+}
+
+func TestSFTPBackend_HostKeyMismatch(t *testing.T) {
+	server := testutil.NewSFTPServer(t, "hunter2", nil)
+	other := testutil.NewSFTPServer(t, "hunter2", nil)
+
+	backend, err := NewSFTPBackend("fixity@"+server.Addr, t.TempDir(), []byte("hunter2"), other.Pin())
+	if err != nil {
+		t.Fatalf("failed to create backend: %v", err)
+	}
+	defer backend.Close()
+
+	err = backend.Probe(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "host key mismatch") {
+		t.Errorf("expected a host key mismatch, got %v", err)
+	}
+	if server.Accepted() != 0 {
+		t.Error("expected no session to be established with an unpinned key")
+	}
+}
+
+func TestSFTPBackend_ConnectionPool(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"1", "2", "3"} {
+		os.WriteFile(filepath.Join(root, name), []byte(name), 0644)
+	}
+
+	server := testutil.NewSFTPServer(t, "hunter2", nil)
+	backend, err := NewSFTPBackend("fixity@"+server.Addr, root, []byte("hunter2"), server.Pin())
+	if err != nil {
+		t.Fatalf("failed to create backend: %v", err)
+	}
+	defer backend.Close()
+
+	ctx := context.Background()
+	openAll := func() {
+		var files []io.ReadCloser
+		for _, name := range []string{"1", "2", "3"} {
+			rc, err := backend.Open(ctx, name)
+			if err != nil {
+				t.Fatalf("open %s failed: %v", name, err)
+			}
+			files = append(files, rc)
+		}
+		for _, rc := range files {
+			rc.Close()
+		}
+	}
+
+	openAll()
+	if got := server.Accepted(); got != 3 {
+		t.Errorf("expected files open at once to use 3 connections, got %d", got)
+	}
+
+	openAll()
+	if got := server.Accepted(); got != 3 {
+		t.Errorf("expected released connections to be reused, got %d connections", got)
+	}
+}
+
+func TestNewSFTPBackend_Validation(t *testing.T) {
+	server := testutil.NewSFTPServer(t, "hunter2", nil)
+
+	tests := []struct {
+		name    string
+		server  string
+		hostKey string
+		want    string
+	}{
+		{"missing user", server.Addr, server.Pin(), "user@host"},
+		{"missing host key", "fixity@" + server.Addr, "", "host key is required"},
+		{"invalid host key", "fixity@" + server.Addr, "not-a-key", "invalid SFTP host key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewSFTPBackend(tt.server, "/data", []byte("hunter2"), tt.hostKey)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	if _, addr, _ := parseSFTPServer("fixity@archive.example.com"); addr != "archive.example.com:22" {
+		t.Errorf("expected the default port, got %s", addr)
+	}
+}
+
+func TestResolveCredentials(t *testing.T) {
+	t.Setenv("FIXITY_TEST_SECRET", "from-env")
+	secret, err := ResolveCredentials("env:FIXITY_TEST_SECRET")
+	if err != nil || string(secret) != "from-env" {
+		t.Errorf("expected the environment secret, got %q (%v)", secret, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "secret")
+	os.WriteFile(path, []byte("from-file"), 0600)
+	secret, err = ResolveCredentials("file:" + path)
+	if err != nil || string(secret) != "from-file" {
+		t.Errorf("expected the file secret, got %q (%v)", secret, err)
+	}
+
+	if _, err := ResolveCredentials("env:FIXITY_TEST_UNSET"); err == nil {
+		t.Error("expected an unset variable to fail")
+	}
+	if _, err := ResolveCredentials("hunter2"); err == nil {
+		t.Error("expected a bare secret to be rejected")
+	}
+}
@@ -12,7 +12,10 @@ type StorageBackend interface {
 	// Probe checks if storage is accessible
 	Probe(ctx context.Context) error
 
-	// Walk traverses all files in the storage
+	// Walk traverses all files in the storage. Entries that cannot be read
+	// are reported with FileInfo.Err set rather than skipped; a directory
+	// that cannot be listed is reported a second time with Err set, and its
+	// contents are unknown.
 	Walk(ctx context.Context, fn WalkFunc) error
 
 	// Open opens a file for reading
@@ -34,28 +37,111 @@ type FileInfo struct {
 	Size    int64
 	ModTime time.Time
 	IsDir   bool
+
+	Type       FileType // Kind of entry; empty is treated as a regular file
+	LinkTarget string   // Target path of a symlink reported as a link
+	Device     uint64   // Device and inode identify hardlinked names (0 if unknown)
+	Inode      uint64
+	Links      uint64 // Number of hardlinks to the file
+
+	Mode        uint32 // POSIX permission bits, including setuid, setgid and sticky, if HasMode
+	HasMode     bool
+	UID         uint32 // Owner and group, if HasOwner
+	GID         uint32
+	HasOwner    bool
+	XattrDigest string // Digest of extended attributes and ACLs; empty if not captured
+
+	ServerChecksum string // Checksum the server keeps for the file, as "algorithm:hex"; empty if none
+
+	Entries   int  // Number of entries in a directory; set by WalkSkipping
+	Unchanged bool // Directory whose files WalkSkipping left out
+
+	Err error // Set when the entry could not be read; see StorageBackend.Walk
+}
+
+// WalkError describes a path that could not be read during Walk
+type WalkError struct {
+	Path string // Relative path of the unreadable entry
+	Op   string // "stat" or "readdir"
+	Err  error
+}
+
+func (e *WalkError) Error() string {
+	return fmt.Sprintf("%s %s: %v", e.Op, e.Path, e.Err)
+}
+
+func (e *WalkError) Unwrap() error {
+	return e.Err
 }
 
 // StorageType represents the type of storage backend
 type StorageType string
 
 const (
-	TypeLocal StorageType = "local"
-	TypeNFS   StorageType = "nfs"
-	TypeSMB   StorageType = "smb"
+	TypeLocal  StorageType = "local"
+	TypeNFS    StorageType = "nfs"
+	TypeSMB    StorageType = "smb"
+	TypeSFTP   StorageType = "sftp"
+	TypeWebDAV StorageType = "webdav"
 )
 
 // BackendConfig contains configuration for creating a storage backend
 type BackendConfig struct {
 	Type      StorageType
 	Path      string  // Mount path or local directory path
-	Server    *string // NFS/SMB server address
+	Server    *string // NFS/SMB server address, SFTP user@host[:port] or WebDAV URL
 	Share     *string // NFS export path or SMB share name
-	CredsRef  *string // Optional credentials reference (for future use)
+	CredsRef  *string // Credentials reference (env:NAME or file:PATH); SFTP key or password, WebDAV user:password
+	HostKey   string  // Pinned SFTP host key: authorized_keys line or SHA256 fingerprint
+
+	ReadOptions ReadOptions // How file contents are read for hashing
+	LinkPolicy  LinkPolicy  // How symlinks are reported by Walk
+
+	WalkConcurrency int  // Directories listed concurrently by Walk; 0 uses DefaultWalkConcurrency
+	CaptureXattrs   bool // Report a digest of each file's extended attributes and ACLs
+}
+
+// readOptionsSetter is implemented by backends that read from a mounted filesystem
+type readOptionsSetter interface {
+	SetReadOptions(opts ReadOptions)
 }
 
 // NewBackend creates a new storage backend based on the provided configuration
 func NewBackend(cfg BackendConfig) (StorageBackend, error) {
+	if err := cfg.ReadOptions.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid read options: %w", err)
+	}
+	linkPolicy, err := ParseLinkPolicy(string(cfg.LinkPolicy))
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateWalkConcurrency(cfg.WalkConcurrency); err != nil {
+		return nil, err
+	}
+
+	backend, err := newBackend(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if setter, ok := backend.(readOptionsSetter); ok {
+		setter.SetReadOptions(cfg.ReadOptions)
+	}
+	if setter, ok := backend.(linkPolicySetter); ok {
+		setter.SetLinkPolicy(linkPolicy)
+	}
+	if setter, ok := backend.(walkConcurrencySetter); ok {
+		setter.SetWalkConcurrency(cfg.WalkConcurrency)
+	}
+	if setter, ok := backend.(xattrSetter); ok {
+		setter.SetCaptureXattrs(cfg.CaptureXattrs)
+	}
+
+	return backend, nil
+}
+
+// newBackend constructs the backend for cfg.Type
+func newBackend(cfg BackendConfig) (StorageBackend, error) {
 	switch cfg.Type {
 	case TypeLocal:
 		return NewLocalFSBackend(cfg.Path)
@@ -78,6 +164,32 @@ func NewBackend(cfg BackendConfig) (StorageBackend, error) {
 		}
 		return NewSMBBackend(*cfg.Server, *cfg.Share, cfg.Path)
 
+	case TypeSFTP:
+		if cfg.Server == nil || *cfg.Server == "" {
+			return nil, fmt.Errorf("SFTP backend requires server address")
+		}
+		if cfg.CredsRef == nil || *cfg.CredsRef == "" {
+			return nil, fmt.Errorf("SFTP backend requires a credentials reference")
+		}
+		secret, err := ResolveCredentials(*cfg.CredsRef)
+		if err != nil {
+			return nil, err
+		}
+		return NewSFTPBackend(*cfg.Server, cfg.Path, secret, cfg.HostKey)
+
+	case TypeWebDAV:
+		if cfg.Server == nil || *cfg.Server == "" {
+			return nil, fmt.Errorf("WebDAV backend requires server URL")
+		}
+		var secret []byte
+		if cfg.CredsRef != nil && *cfg.CredsRef != "" {
+			var err error
+			if secret, err = ResolveCredentials(*cfg.CredsRef); err != nil {
+				return nil, err
+			}
+		}
+		return NewWebDAVBackend(*cfg.Server, cfg.Path, secret)
+
 	default:
 		return nil, fmt.Errorf("unsupported storage type: %s", cfg.Type)
 	}
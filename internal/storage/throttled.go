@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"context"
+	"io"
+
+	"github.com/jeffanddom/fixity/internal/throttle"
+)
+
+// ThrottledBackend wraps a StorageBackend and paces file reads through
+// one or more limiters (e.g. a global limiter plus a per-target one).
+//
+// Only StorageBackend and the optional PathLookup and DirSkipper interfaces
+// are forwarded to the wrapped backend; ChangeWatcher and the rest are
+// hidden, so wrap a backend after configuring it. Readers returned by Open
+// wrap the backend's own; use ReaderAt for throttled random access to them.
+type ThrottledBackend struct {
+	StorageBackend
+	limiters []*throttle.Limiter
+}
+
+// NewThrottledBackend wraps backend so that reads from Open honor the given limiters.
+// Nil limiters are ignored; if none remain the backend is returned unchanged.
+func NewThrottledBackend(backend StorageBackend, limiters ...*throttle.Limiter) StorageBackend {
+	active := make([]*throttle.Limiter, 0, len(limiters))
+	for _, l := range limiters {
+		if l != nil {
+			active = append(active, l)
+		}
+	}
+
+	if len(active) == 0 {
+		return backend
+	}
+
+	return &ThrottledBackend{
+		StorageBackend: backend,
+		limiters:       active,
+	}
+}
+
+// Open opens a file for reading, counting the open as one I/O operation
+// and throttling subsequent reads
+func (b *ThrottledBackend) Open(ctx context.Context, path string) (io.ReadCloser, error) {
+	for _, l := range b.limiters {
+		if err := l.WaitOp(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	rc, err := b.StorageBackend.Open(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &throttledFile{Reader: throttle.NewReader(ctx, rc, b.limiters...), ctx: ctx, rc: rc, limiters: b.limiters}, nil
+}
+
+// throttledFile is a throttled reader that keeps the wrapped file's random
+// access, throttling reads at an offset the same way
+type throttledFile struct {
+	*throttle.Reader
+	ctx      context.Context
+	rc       io.ReadCloser
+	limiters []*throttle.Limiter
+}
+
+// ReaderAt returns throttled random access to the wrapped file, if it has any
+func (f *throttledFile) ReaderAt() (io.ReaderAt, int64, bool) {
+	readerAt, size, ok := ReaderAt(f.rc)
+	if !ok {
+		return nil, 0, false
+	}
+	return &throttledReaderAt{ctx: f.ctx, r: readerAt, limiters: f.limiters}, size, true
+}
+
+// throttledReaderAt paces reads at an offset like throttle.Reader paces reads
+type throttledReaderAt struct {
+	ctx      context.Context
+	r        io.ReaderAt
+	limiters []*throttle.Limiter
+}
+
+// ReadAt implements io.ReaderAt, counting each call as one I/O operation
+func (r *throttledReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	for _, l := range r.limiters {
+		if err := l.WaitOp(r.ctx); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.r.ReadAt(p, off)
+
+	for _, l := range r.limiters {
+		if werr := l.WaitBytes(r.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+
+	return n, err
+}
+
+// Lookup reports a single path of the wrapped backend; metadata is not throttled
+func (b *ThrottledBackend) Lookup(ctx context.Context, path string) (*FileInfo, error) {
+	return Lookup(ctx, b.StorageBackend, path)
+}
+
+// WalkSkipping walks the wrapped backend, leaving out the files of unchanged
+// directories; metadata is not throttled
+func (b *ThrottledBackend) WalkSkipping(ctx context.Context, unchanged UnchangedDirFunc, fn WalkFunc) error {
+	return WalkSkipping(ctx, b.StorageBackend, unchanged, fn)
+}
+
+// CanSkipDirs reports whether the wrapped backend can skip unchanged directories
+func (b *ThrottledBackend) CanSkipDirs() bool {
+	return CanSkipDirs(b.StorageBackend)
+}
+
+// Limits returns the combined limits currently in effect
+func (b *ThrottledBackend) Limits() throttle.Limits {
+	var limits throttle.Limits
+	for _, l := range b.limiters {
+		limits = throttle.Combine(limits, l.Current())
+	}
+	return limits
+}
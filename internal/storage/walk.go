@@ -0,0 +1,492 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// DefaultWalkConcurrency is the number of directories listed at once when a
+// backend has no walk concurrency configured; 1 walks sequentially
+const DefaultWalkConcurrency = 1
+
+// MaxWalkConcurrency caps concurrent directory listings per walk
+const MaxWalkConcurrency = 64
+
+// walkLookahead is how many upcoming subdirectories of each directory being
+// walked are listed ahead of time, per concurrent listing. It bounds the
+// listings held in memory to roughly depth × lookahead.
+const walkLookahead = 4
+
+// ValidateWalkConcurrency checks that n is a supported walk concurrency; 0
+// selects DefaultWalkConcurrency
+func ValidateWalkConcurrency(n int) error {
+	if n < 0 || n > MaxWalkConcurrency {
+		return fmt.Errorf("walk concurrency must be between 1 and %d", MaxWalkConcurrency)
+	}
+	return nil
+}
+
+// ErrDirSkipUnsupported is returned by WalkSkipping for backends that cannot
+// skip unchanged directories
+var ErrDirSkipUnsupported = errors.New("skipping unchanged directories is not supported")
+
+// UnchangedDirFunc reports whether a directory is known not to have changed
+// since it was last walked, given its FileInfo with Entries filled in. It may
+// be called from several goroutines at once, and for directories the walk
+// later skips.
+type UnchangedDirFunc func(relPath string, info *FileInfo) bool
+
+// DirSkipper is implemented by backends whose walk can leave out the files of
+// unchanged directories
+type DirSkipper interface {
+	// WalkSkipping walks like Walk, but lists each directory before reporting
+	// it and sets FileInfo.Entries. Directories unchanged reports true for
+	// are reported with FileInfo.Unchanged set, and only their subdirectories
+	// and symlinks are visited; the files directly within them are neither
+	// read nor reported.
+	WalkSkipping(ctx context.Context, unchanged UnchangedDirFunc, fn WalkFunc) error
+}
+
+// dirSkipChecker is implemented by wrappers that define WalkSkipping but can
+// only skip directories when the backend they wrap can
+type dirSkipChecker interface {
+	CanSkipDirs() bool
+}
+
+// CanSkipDirs reports whether WalkSkipping is supported by backend
+func CanSkipDirs(backend StorageBackend) bool {
+	if checker, ok := backend.(dirSkipChecker); ok {
+		return checker.CanSkipDirs()
+	}
+	_, ok := backend.(DirSkipper)
+	return ok
+}
+
+// WalkSkipping walks backend, leaving out the files of unchanged directories,
+// or returns ErrDirSkipUnsupported
+func WalkSkipping(ctx context.Context, backend StorageBackend, unchanged UnchangedDirFunc, fn WalkFunc) error {
+	skipper, ok := backend.(DirSkipper)
+	if !ok {
+		return ErrDirSkipUnsupported
+	}
+	return skipper.WalkSkipping(ctx, unchanged, fn)
+}
+
+// Filesystem calls made while walking; replaced in benchmarks to simulate
+// network latency
+var (
+	readDir = os.ReadDir
+	lstat   = os.Lstat
+)
+
+// walkConcurrencySetter is implemented by backends that walk a mounted filesystem
+type walkConcurrencySetter interface {
+	SetWalkConcurrency(n int)
+}
+
+// treeWalker walks a mounted filesystem for the local, NFS and SMB backends.
+// It visits entries in lexical order like filepath.Walk, reporting each
+// directory before its contents, and applies a LinkPolicy to symlinks.
+//
+// With more than one walker, directories are listed (readdir plus an lstat
+// per entry) ahead of the traversal by a bounded pool of goroutines, hiding
+// metadata latency on network filesystems. Entries are still reported one at
+// a time in the same order as a sequential walk, so callers can checkpoint
+// on the stream.
+//
+// With an UnchangedDirFunc, each directory is listed before it is reported
+// so its entry count can be filled in, and directories reported unchanged
+// have only their subdirectories and symlinks examined.
+type treeWalker struct {
+	rootPath  string
+	links     LinkPolicy
+	xattrs    bool // Fill in FileInfo.XattrDigest for files
+	fn        WalkFunc
+	unchanged UnchangedDirFunc
+
+	// Limits concurrent directory listings; nil walks sequentially
+	sem       chan struct{}
+	lookahead int
+
+	// Resolved directories currently being walked, so following a symlink
+	// back to an ancestor does not loop forever
+	active map[string]bool
+}
+
+// dirListing is the contents of one directory, read either inline or by a
+// background goroutine
+type dirListing struct {
+	absPath string
+	relPath string
+	info    *FileInfo // The directory as reported by Walk; nil for the root
+	once    sync.Once
+	done    chan struct{}
+	entries []dirEntry
+	count   int  // Entries in the directory, including any not examined
+	skipped bool // Files were left out because the directory is unchanged
+	err     error
+}
+
+// dirEntry is one listed entry with the result of its lstat
+type dirEntry struct {
+	name    string
+	info    os.FileInfo
+	err     error
+	listing *dirListing // Set for subdirectories
+}
+
+// walkTree walks rootPath, calling fn with slash-separated paths relative to
+// the root. Entries that cannot be read are reported with FileInfo.Err set;
+// failing to list the root itself fails the walk. Up to walkers directories
+// are listed concurrently. If unchanged is non-nil, the files of directories
+// it reports as unchanged are left out.
+func walkTree(ctx context.Context, rootPath string, links LinkPolicy, walkers int, xattrs bool, unchanged UnchangedDirFunc, fn WalkFunc) error {
+	if links == "" {
+		links = LinkPolicyFollow
+	}
+	if walkers <= 0 {
+		walkers = DefaultWalkConcurrency
+	}
+
+	// Listings still in flight when the walk ends are abandoned
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	w := &treeWalker{
+		rootPath:  rootPath,
+		links:     links,
+		xattrs:    xattrs,
+		fn:        fn,
+		unchanged: unchanged,
+		active:    map[string]bool{rootPath: true},
+	}
+	if walkers > 1 {
+		w.sem = make(chan struct{}, walkers)
+		w.lookahead = walkers * walkLookahead
+	}
+
+	err := w.walkDir(ctx, newDirListing(rootPath, "", nil), "")
+	if err == filepath.SkipDir {
+		return nil
+	}
+	return err
+}
+
+func newDirListing(absPath, relPath string, info *FileInfo) *dirListing {
+	return &dirListing{absPath: absPath, relPath: relPath, info: info, done: make(chan struct{})}
+}
+
+// start begins reading l if it has not been started yet. Sequential walkers
+// read it inline.
+func (w *treeWalker) start(ctx context.Context, l *dirListing) {
+	l.once.Do(func() {
+		if w.sem == nil {
+			w.read(ctx, l)
+			close(l.done)
+			return
+		}
+
+		go func() {
+			defer close(l.done)
+
+			select {
+			case w.sem <- struct{}{}:
+			case <-ctx.Done():
+				l.err = ctx.Err()
+				return
+			}
+			defer func() { <-w.sem }()
+
+			w.read(ctx, l)
+		}()
+	})
+}
+
+// wait starts l if needed and blocks until it has been read
+func (w *treeWalker) wait(ctx context.Context, l *dirListing) error {
+	w.start(ctx, l)
+
+	select {
+	case <-l.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// read lists the directory and lstats each entry. Only subdirectories and
+// symlinks are lstatted in a directory the walk's UnchangedDirFunc reports
+// as unchanged.
+func (w *treeWalker) read(ctx context.Context, l *dirListing) {
+	entries, err := readDir(l.absPath)
+	if err != nil {
+		l.err = err
+		return
+	}
+
+	l.count = len(entries)
+	if w.unchanged != nil && l.info != nil {
+		info := *l.info
+		info.Entries = l.count
+		l.skipped = w.unchanged(l.relPath, &info)
+	}
+
+	l.entries = make([]dirEntry, 0, len(entries))
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			l.err = ctx.Err()
+			return
+		}
+		if l.skipped && entry.Type()&(fs.ModeDir|fs.ModeSymlink) == 0 {
+			continue
+		}
+
+		absPath := filepath.Join(l.absPath, entry.Name())
+		info, err := lstat(absPath)
+
+		e := dirEntry{name: entry.Name(), info: info, err: err}
+		if err == nil && info.IsDir() {
+			relPath := entry.Name()
+			if l.relPath != "" {
+				relPath = l.relPath + "/" + entry.Name()
+			}
+			e.listing = newDirListing(absPath, relPath, newFileInfo(relPath, info))
+		}
+		l.entries = append(l.entries, e)
+	}
+}
+
+// walkDir visits the entries of the directory listed by l, whose path
+// relative to the root is relDir ("" for the root itself)
+func (w *treeWalker) walkDir(ctx context.Context, l *dirListing, relDir string) error {
+	if err := w.wait(ctx, l); err != nil {
+		return err
+	}
+
+	if l.err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if relDir == "" {
+			return fmt.Errorf("failed to read storage root: %w", l.err)
+		}
+		// Report the directory again so callers know its contents are
+		// unknown, then carry on with the rest of the tree
+		return w.report(relDir, &FileInfo{
+			Path:  relDir,
+			IsDir: true,
+			Type:  FileTypeDirectory,
+			Err:   &WalkError{Path: relDir, Op: "readdir", Err: l.err},
+		})
+	}
+
+	// Subdirectories are listed ahead of the traversal, keeping at most
+	// lookahead of them beyond the one being walked in flight
+	var subdirs []*dirListing
+	if w.sem != nil {
+		for _, entry := range l.entries {
+			if entry.listing != nil {
+				subdirs = append(subdirs, entry.listing)
+			}
+		}
+	}
+	seen, started := 0, 0
+
+	for _, entry := range l.entries {
+		// Check context cancellation
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		if entry.listing != nil {
+			seen++
+		}
+		for ; started < len(subdirs) && started < seen+w.lookahead; started++ {
+			w.start(ctx, subdirs[started])
+		}
+
+		relPath := entry.name
+		if relDir != "" {
+			relPath = relDir + "/" + entry.name
+		}
+
+		err := w.visit(ctx, filepath.Join(l.absPath, entry.name), relPath, entry)
+		if err == filepath.SkipDir {
+			// Returned for a file: skip the rest of this directory
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// visit reports one entry and descends into it if it is a directory
+func (w *treeWalker) visit(ctx context.Context, absPath, relPath string, entry dirEntry) error {
+	if entry.err != nil {
+		return w.report(relPath, &FileInfo{
+			Path: relPath,
+			Err:  &WalkError{Path: relPath, Op: "stat", Err: entry.err},
+		})
+	}
+
+	fileInfo, resolved := w.describe(absPath, relPath, entry.info)
+	if fileInfo == nil {
+		return nil
+	}
+	if fileInfo.Type == FileTypeSymlink {
+		// Recorded links are never descended into
+		return w.fn(relPath, fileInfo)
+	}
+
+	listing := entry.listing
+	if resolved != "" && fileInfo.IsDir {
+		listing = newDirListing(resolved, relPath, fileInfo)
+	}
+
+	if !fileInfo.IsDir {
+		return w.fn(relPath, fileInfo)
+	}
+
+	// The entry count is only known once the directory has been listed
+	if w.unchanged != nil {
+		if err := w.wait(ctx, listing); err != nil {
+			return err
+		}
+		if listing.err == nil {
+			fileInfo.Entries = listing.count
+			fileInfo.Unchanged = listing.skipped
+		}
+	}
+
+	if err := w.fn(relPath, fileInfo); err != nil {
+		if err == filepath.SkipDir {
+			return nil
+		}
+		return err
+	}
+
+	// Paths are built from the resolved root and resolved link targets, so
+	// the listing path is already free of symlinks
+	dirPath := listing.absPath
+	if w.active[dirPath] {
+		return nil
+	}
+	w.active[dirPath] = true
+	defer delete(w.active, dirPath)
+
+	return w.walkDir(ctx, listing, relPath)
+}
+
+// describe converts an lstat result into the FileInfo Walk reports, applying
+// the link policy. It returns nil if the entry is left out, and the resolved
+// path of a followed link.
+func (w *treeWalker) describe(absPath, relPath string, info os.FileInfo) (*FileInfo, string) {
+	fileInfo := newFileInfo(relPath, info)
+	if fileInfo.Type != FileTypeSymlink {
+		w.captureXattrs(fileInfo, absPath)
+		return fileInfo, ""
+	}
+
+	switch w.links {
+	case LinkPolicySkip:
+		return nil, ""
+
+	case LinkPolicyFollow:
+		if resolved, target, ok := w.resolve(absPath); ok {
+			fileInfo = newFileInfo(relPath, target)
+			w.captureXattrs(fileInfo, resolved)
+			return fileInfo, resolved
+		}
+	}
+
+	// Record the link itself
+	fileInfo.LinkTarget, _ = os.Readlink(absPath)
+	w.captureXattrs(fileInfo, absPath)
+	return fileInfo, ""
+}
+
+// captureXattrs fills in the extended attribute digest of a file, if the
+// walk captures them; directories are not recorded, so they are left alone
+func (w *treeWalker) captureXattrs(fileInfo *FileInfo, absPath string) {
+	if w.xattrs && !fileInfo.IsDir {
+		fileInfo.XattrDigest = xattrDigest(absPath)
+	}
+}
+
+// lookupPath reports the single path relPath the way walkTree would. It
+// returns nil if the link policy leaves the path out, and an error wrapping
+// fs.ErrNotExist if it no longer exists.
+func lookupPath(rootPath string, links LinkPolicy, xattrs bool, relPath string) (*FileInfo, error) {
+	if links == "" {
+		links = LinkPolicyFollow
+	}
+	w := &treeWalker{rootPath: rootPath, links: links, xattrs: xattrs}
+
+	absPath := filepath.Join(rootPath, filepath.FromSlash(relPath))
+	if !withinRoot(rootPath, absPath) {
+		return nil, fmt.Errorf("path traversal attempt detected: %s", relPath)
+	}
+
+	info, err := lstat(absPath)
+	if err != nil {
+		return nil, &WalkError{Path: relPath, Op: "stat", Err: err}
+	}
+
+	fileInfo, _ := w.describe(absPath, relPath, info)
+	return fileInfo, nil
+}
+
+// report passes an unreadable entry to fn; SkipDir is meaningless for it
+func (w *treeWalker) report(relPath string, info *FileInfo) error {
+	if err := w.fn(relPath, info); err != nil && err != filepath.SkipDir {
+		return err
+	}
+	return nil
+}
+
+// resolve follows a symlink, returning its resolved path and target info if
+// the target exists within the storage root
+func (w *treeWalker) resolve(absPath string) (string, os.FileInfo, bool) {
+	resolved, err := filepath.EvalSymlinks(absPath)
+	if err != nil || !withinRoot(w.rootPath, resolved) {
+		return "", nil, false
+	}
+
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return "", nil, false
+	}
+
+	return resolved, info, true
+}
+
+// newFileInfo converts an os.FileInfo into a FileInfo for relPath
+func newFileInfo(relPath string, info os.FileInfo) *FileInfo {
+	dev, ino, nlink := fileID(info)
+	uid, gid, hasOwner := fileOwner(info)
+	return &FileInfo{
+		Path:     relPath,
+		Size:     info.Size(),
+		ModTime:  info.ModTime(),
+		IsDir:    info.IsDir(),
+		Type:     fileTypeOf(info.Mode()),
+		Device:   dev,
+		Inode:    ino,
+		Links:    nlink,
+		Mode:     permissionBits(info.Mode()),
+		HasMode:  true,
+		UID:      uid,
+		GID:      gid,
+		HasOwner: hasOwner,
+	}
+}
@@ -0,0 +1,305 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jeffanddom/fixity/internal/storage"
+	"github.com/jeffanddom/fixity/internal/throttle"
+	"github.com/jeffanddom/fixity/tests/testutil"
+)
+
+func TestWalk_UnreadableDirectory(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("permission checks do not apply to root")
+	}
+
+	tmpDir := setupTestDir(t)
+	locked := filepath.Join(tmpDir, "subdir", "nested")
+	if err := os.Chmod(locked, 0); err != nil {
+		t.Fatalf("failed to chmod: %v", err)
+	}
+	defer os.Chmod(locked, 0755)
+
+	backend, _ := storage.NewLocalFSBackend(tmpDir)
+
+	var walkErrs []*storage.WalkError
+	var files []string
+	err := backend.Walk(context.Background(), func(path string, info *storage.FileInfo) error {
+		if info.Err != nil {
+			var walkErr *storage.WalkError
+			if !errors.As(info.Err, &walkErr) {
+				t.Fatalf("expected a WalkError, got %T", info.Err)
+			}
+			walkErrs = append(walkErrs, walkErr)
+			return nil
+		}
+		if !info.IsDir {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk should continue past unreadable directories: %v", err)
+	}
+
+	if len(walkErrs) != 1 {
+		t.Fatalf("expected 1 walk error, got %d", len(walkErrs))
+	}
+	if walkErrs[0].Path != "subdir/nested" || walkErrs[0].Op != "readdir" {
+		t.Errorf("unexpected walk error: %v", walkErrs[0])
+	}
+	if !errors.Is(walkErrs[0], fs.ErrPermission) {
+		t.Errorf("expected a permission error, got %v", walkErrs[0].Err)
+	}
+	if len(files) != 3 {
+		t.Errorf("expected the 3 readable files, got %v", files)
+	}
+}
+
+func TestWalk_UnreadableRoot(t *testing.T) {
+	backend, _ := storage.NewLocalFSBackend(filepath.Join(t.TempDir(), "missing"))
+
+	err := backend.Walk(context.Background(), func(path string, info *storage.FileInfo) error {
+		return nil
+	})
+	if err == nil {
+		t.Error("expected an error when the root cannot be read")
+	}
+}
+
+// setupWideTree creates dirs directories of files files each, with a nested
+// directory in every other one
+func setupWideTree(t *testing.T, dirs, files int) string {
+	t.Helper()
+
+	root := t.TempDir()
+	for d := 0; d < dirs; d++ {
+		dir := filepath.Join(root, fmt.Sprintf("dir%02d", d))
+		if d%2 == 0 {
+			dir = filepath.Join(dir, "nested")
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		for f := 0; f < files; f++ {
+			writeFile(t, filepath.Join(dir, fmt.Sprintf("file%02d.txt", f)), "content")
+		}
+	}
+	return root
+}
+
+func walkOrder(t *testing.T, root string, walkers int, fn func(path string, info *storage.FileInfo) error) []string {
+	t.Helper()
+
+	backend, err := storage.NewBackend(storage.BackendConfig{
+		Type:            storage.TypeLocal,
+		Path:            root,
+		WalkConcurrency: walkers,
+	})
+	if err != nil {
+		t.Fatalf("failed to create backend: %v", err)
+	}
+
+	var paths []string
+	err = backend.Walk(context.Background(), func(path string, info *storage.FileInfo) error {
+		paths = append(paths, path)
+		if fn != nil {
+			return fn(path, info)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	return paths
+}
+
+func TestWalk_ConcurrentOrder(t *testing.T) {
+	root := setupWideTree(t, 20, 5)
+	sequential := walkOrder(t, root, 1, nil)
+
+	if !sort.StringsAreSorted(sequential) {
+		t.Errorf("expected a sorted walk, got %v", sequential)
+	}
+
+	for _, walkers := range []int{2, 8, storage.MaxWalkConcurrency} {
+		t.Run(fmt.Sprintf("%d walkers", walkers), func(t *testing.T) {
+			got := walkOrder(t, root, walkers, nil)
+			if !reflect.DeepEqual(got, sequential) {
+				t.Errorf("expected the sequential order %v, got %v", sequential, got)
+			}
+		})
+	}
+}
+
+func TestWalk_ConcurrentSkipDir(t *testing.T) {
+	root := setupWideTree(t, 10, 3)
+
+	got := walkOrder(t, root, 8, func(path string, info *storage.FileInfo) error {
+		if info.IsDir && strings.HasSuffix(path, "nested") {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+
+	for _, path := range got {
+		if strings.Contains(path, "nested/") {
+			t.Errorf("expected skipped directories not to be walked, got %s", path)
+		}
+	}
+	if len(got) != 10+5+5*3 {
+		t.Errorf("expected 30 entries, got %d: %v", len(got), got)
+	}
+}
+
+func TestWalk_ConcurrentStop(t *testing.T) {
+	root := setupWideTree(t, 20, 5)
+	stop := errors.New("stop")
+
+	backend, _ := storage.NewBackend(storage.BackendConfig{
+		Type:            storage.TypeLocal,
+		Path:            root,
+		WalkConcurrency: 8,
+	})
+
+	visited := 0
+	err := backend.Walk(context.Background(), func(path string, info *storage.FileInfo) error {
+		visited++
+		if visited == 10 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) {
+		t.Errorf("expected the callback error, got %v", err)
+	}
+	if visited != 10 {
+		t.Errorf("expected the walk to stop after 10 entries, got %d", visited)
+	}
+}
+
+func TestWalk_SkipUnchanged(t *testing.T) {
+	root := setupWideTree(t, 4, 3)
+
+	for _, walkers := range []int{1, 8} {
+		t.Run(fmt.Sprintf("%d walkers", walkers), func(t *testing.T) {
+			backend, _ := storage.NewBackend(storage.BackendConfig{
+				Type:            storage.TypeLocal,
+				Path:            root,
+				WalkConcurrency: walkers,
+			})
+
+			var mu sync.Mutex
+			counts := make(map[string]int)
+			unchanged := func(path string, info *storage.FileInfo) bool {
+				mu.Lock()
+				defer mu.Unlock()
+				counts[path] = info.Entries
+				return path == "dir00" || path == "dir01"
+			}
+
+			var files []string
+			dirs := make(map[string]*storage.FileInfo)
+			err := storage.WalkSkipping(context.Background(), backend, unchanged, func(path string, info *storage.FileInfo) error {
+				if info.IsDir {
+					dirs[path] = info
+				} else {
+					files = append(files, path)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("walk failed: %v", err)
+			}
+
+			if counts["dir00"] != 1 || counts["dir01"] != 3 {
+				t.Errorf("expected entry counts of 1 and 3, got %v", counts)
+			}
+			if !dirs["dir01"].Unchanged || dirs["dir01"].Entries != 3 {
+				t.Errorf("expected dir01 to be reported unchanged with 3 entries, got %+v", dirs["dir01"])
+			}
+			if dirs["dir03"].Unchanged || dirs["dir03"].Entries != 3 {
+				t.Errorf("expected dir03 to be reported changed with 3 entries, got %+v", dirs["dir03"])
+			}
+
+			// Files of unchanged directories are left out, but their
+			// subdirectories are still walked
+			for _, path := range files {
+				if strings.HasPrefix(path, "dir01/") {
+					t.Errorf("expected files of an unchanged directory to be left out, got %s", path)
+				}
+			}
+			if _, ok := dirs["dir00/nested"]; !ok {
+				t.Error("expected subdirectories of an unchanged directory to be walked")
+			}
+			if len(files) != 3*3 {
+				t.Errorf("expected 9 files, got %d: %v", len(files), files)
+			}
+		})
+	}
+}
+
+func TestValidateWalkConcurrency(t *testing.T) {
+	for _, n := range []int{0, 1, storage.MaxWalkConcurrency} {
+		if err := storage.ValidateWalkConcurrency(n); err != nil {
+			t.Errorf("expected %d to be valid: %v", n, err)
+		}
+	}
+	for _, n := range []int{-1, storage.MaxWalkConcurrency + 1} {
+		if err := storage.ValidateWalkConcurrency(n); err == nil {
+			t.Errorf("expected %d to be rejected", n)
+		}
+	}
+}
+
+func TestCanSkipDirs(t *testing.T) {
+	local, err := storage.NewLocalFSBackend(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create backend: %v", err)
+	}
+	server := testutil.NewSFTPServer(t, "hunter2", nil)
+	sftp, err := storage.NewSFTPBackend("fixity@"+server.Addr, "/srv", []byte("hunter2"), server.Pin())
+	if err != nil {
+		t.Fatalf("failed to create backend: %v", err)
+	}
+	defer sftp.Close()
+
+	// Wrappers define WalkSkipping but can only skip what they wrap can
+	wrap := func(backend storage.StorageBackend) storage.StorageBackend {
+		limiter := throttle.NewLimiter(throttle.Schedule{Default: throttle.Limits{IOPS: 1000}})
+		backend = storage.NewThrottledBackend(backend, limiter)
+		backend, err := storage.NewFilteredBackend(backend, storage.PathRules{Exclude: []string{"*.tmp"}})
+		if err != nil {
+			t.Fatalf("failed to create filtered backend: %v", err)
+		}
+		return backend
+	}
+
+	tests := []struct {
+		name    string
+		backend storage.StorageBackend
+		want    bool
+	}{
+		{"local", local, true},
+		{"wrapped local", wrap(local), true},
+		{"sftp", sftp, false},
+		{"wrapped sftp", wrap(sftp), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := storage.CanSkipDirs(tt.backend); got != tt.want {
+				t.Errorf("CanSkipDirs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// ErrWatchUnsupported is returned when a backend or platform cannot watch
+// for changes
+var ErrWatchUnsupported = errors.New("watching for changes is not supported")
+
+// ErrLookupUnsupported is returned by Lookup for backends that cannot report
+// a single path the way Walk would
+var ErrLookupUnsupported = errors.New("path lookup is not supported")
+
+// DefaultWatchQueueSize is the number of distinct pending paths a WatchQueue
+// holds before it overflows
+const DefaultWatchQueueSize = 100000
+
+// ChangeWatcher is implemented by backends that can report changed paths as
+// they happen
+type ChangeWatcher interface {
+	// Watch adds the slash-separated relative paths of entries that may have
+	// changed to queue until ctx is done. A removed or renamed directory is
+	// queued as the directory itself; a created or moved-in directory is
+	// queued as the files within it.
+	Watch(ctx context.Context, queue *WatchQueue) error
+}
+
+// PathLookup is implemented by backends that can report a single path the
+// way Walk would, so changed paths can be scanned without a full walk
+type PathLookup interface {
+	// Lookup returns the path's FileInfo, or nil if Walk would leave it out.
+	// A path that no longer exists yields an error wrapping fs.ErrNotExist.
+	Lookup(ctx context.Context, path string) (*FileInfo, error)
+}
+
+// Lookup reports a single path of backend the way Walk would, or returns
+// ErrLookupUnsupported
+func Lookup(ctx context.Context, backend StorageBackend, path string) (*FileInfo, error) {
+	lookup, ok := backend.(PathLookup)
+	if !ok {
+		return nil, ErrLookupUnsupported
+	}
+	return lookup.Lookup(ctx, path)
+}
+
+// WatchQueue collects candidate changed paths between incremental scans.
+// Paths are deduplicated; once more than its size are pending, or the
+// watcher loses events, the queue overflows and only a full scan can tell
+// what changed.
+type WatchQueue struct {
+	mu       sync.Mutex
+	size     int
+	paths    map[string]struct{}
+	overflow bool
+	ready    chan struct{}
+}
+
+// NewWatchQueue creates a queue holding up to size pending paths; size <= 0
+// uses DefaultWatchQueueSize
+func NewWatchQueue(size int) *WatchQueue {
+	if size <= 0 {
+		size = DefaultWatchQueueSize
+	}
+	return &WatchQueue{
+		size:  size,
+		paths: make(map[string]struct{}),
+		ready: make(chan struct{}, 1),
+	}
+}
+
+// Add queues a path that may have changed
+func (q *WatchQueue) Add(path string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.overflow {
+		return
+	}
+	if _, ok := q.paths[path]; !ok && len(q.paths) >= q.size {
+		q.setOverflow()
+		return
+	}
+	q.paths[path] = struct{}{}
+	q.signal()
+}
+
+// Overflow marks the queue as having lost changes
+func (q *WatchQueue) Overflow() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.setOverflow()
+}
+
+func (q *WatchQueue) setOverflow() {
+	q.overflow = true
+	q.paths = make(map[string]struct{})
+	q.signal()
+}
+
+// signal wakes a consumer waiting on Ready; callers hold mu
+func (q *WatchQueue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// Ready is signalled when paths are added or the queue overflows
+func (q *WatchQueue) Ready() <-chan struct{} {
+	return q.ready
+}
+
+// Len returns the number of pending paths
+func (q *WatchQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.paths)
+}
+
+// Drain empties the queue, returning the pending paths in sorted order and
+// whether it overflowed since the last drain
+func (q *WatchQueue) Drain() (paths []string, overflowed bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	paths = make([]string, 0, len(q.paths))
+	for path := range q.paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	overflowed = q.overflow
+	q.paths = make(map[string]struct{})
+	q.overflow = false
+
+	return paths, overflowed
+}
@@ -0,0 +1,197 @@
+//go:build linux
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// watchMask selects the inotify events that can change what a scan sees
+const watchMask = unix.IN_CREATE | unix.IN_MODIFY | unix.IN_CLOSE_WRITE | unix.IN_ATTRIB |
+	unix.IN_DELETE | unix.IN_MOVED_FROM | unix.IN_MOVED_TO |
+	unix.IN_DELETE_SELF | unix.IN_MOVE_SELF |
+	unix.IN_ONLYDIR | unix.IN_DONT_FOLLOW
+
+// inotifyWatcher keeps an inotify watch on every directory of a tree
+type inotifyWatcher struct {
+	rootPath string
+	fd       int
+	queue    *WatchQueue
+	dirs     map[int]string // Watch descriptor -> relative directory ("" is the root)
+	wds      map[string]int // Relative directory -> watch descriptor
+}
+
+// watchTree watches every directory under rootPath with inotify, queueing
+// changed paths until ctx is done. Symlinked directories are not followed.
+func watchTree(ctx context.Context, rootPath string, queue *WatchQueue) error {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return fmt.Errorf("failed to initialize inotify: %w", err)
+	}
+
+	// A non-blocking descriptor is served by the runtime poller, so closing
+	// the file unblocks a pending read
+	file := os.NewFile(uintptr(fd), "inotify")
+	defer file.Close()
+	stop := context.AfterFunc(ctx, func() { file.Close() })
+	defer stop()
+
+	w := &inotifyWatcher{
+		rootPath: rootPath,
+		fd:       fd,
+		queue:    queue,
+		dirs:     make(map[int]string),
+		wds:      make(map[string]int),
+	}
+	if err := w.addTree("", false); err != nil {
+		return err
+	}
+
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := file.Read(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to read inotify events: %w", err)
+		}
+		if err := w.handle(buf[:n]); err != nil {
+			return err
+		}
+	}
+}
+
+// handle processes a buffer of inotify events
+func (w *inotifyWatcher) handle(buf []byte) error {
+	for offset := 0; offset+unix.SizeofInotifyEvent <= len(buf); {
+		event := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+		nameStart := offset + unix.SizeofInotifyEvent
+		offset = nameStart + int(event.Len)
+		if offset > len(buf) {
+			break
+		}
+		name := strings.TrimRight(string(buf[nameStart:offset]), "\x00")
+
+		if event.Mask&unix.IN_Q_OVERFLOW != 0 {
+			w.queue.Overflow()
+			continue
+		}
+
+		dir, ok := w.dirs[int(event.Wd)]
+		if !ok {
+			continue
+		}
+
+		switch {
+		case event.Mask&unix.IN_IGNORED != 0:
+			// The kernel dropped the watch (directory removed or unmounted)
+			delete(w.dirs, int(event.Wd))
+			if w.wds[dir] == int(event.Wd) {
+				delete(w.wds, dir)
+			}
+			continue
+
+		case event.Mask&(unix.IN_DELETE_SELF|unix.IN_MOVE_SELF) != 0:
+			if dir == "" {
+				return errors.New("storage root was removed or moved")
+			}
+			// Reported to the parent directory as well
+			continue
+		}
+
+		relPath := name
+		if dir != "" {
+			relPath = dir + "/" + name
+		}
+
+		if event.Mask&unix.IN_ISDIR == 0 {
+			w.queue.Add(relPath)
+			continue
+		}
+
+		switch {
+		case event.Mask&(unix.IN_CREATE|unix.IN_MOVED_TO) != 0:
+			if err := w.addTree(relPath, true); err != nil {
+				return err
+			}
+		case event.Mask&(unix.IN_DELETE|unix.IN_MOVED_FROM) != 0:
+			w.queue.Add(relPath)
+			w.removeTree(relPath)
+		}
+	}
+
+	return nil
+}
+
+// addTree watches relDir and every directory beneath it. New directories
+// have their files queued, since they may have been filled before the
+// watch was in place. Directories that vanish or cannot be read are left
+// to the next full scan; running out of watches fails.
+func (w *inotifyWatcher) addTree(relDir string, queueFiles bool) error {
+	absDir := filepath.Join(w.rootPath, filepath.FromSlash(relDir))
+
+	return filepath.WalkDir(absDir, func(absPath string, d fs.DirEntry, err error) error {
+		rel, relErr := filepath.Rel(w.rootPath, absPath)
+		if relErr != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == "." {
+			rel = ""
+		}
+
+		if err != nil {
+			if rel == "" && d == nil {
+				return fmt.Errorf("failed to watch storage root: %w", err)
+			}
+			// A directory that could not be listed keeps its own watch
+			return nil
+		}
+
+		if !d.IsDir() {
+			if queueFiles {
+				w.queue.Add(rel)
+			}
+			return nil
+		}
+
+		// Watch before the directory is listed so nothing created in
+		// between is missed
+		wd, err := unix.InotifyAddWatch(w.fd, absPath, watchMask)
+		if err != nil {
+			if errors.Is(err, unix.ENOSPC) {
+				return fmt.Errorf("inotify watch limit reached (raise fs.inotify.max_user_watches): %w", err)
+			}
+			if rel == "" {
+				return fmt.Errorf("failed to watch storage root: %w", err)
+			}
+			return filepath.SkipDir
+		}
+		w.dirs[wd] = rel
+		w.wds[rel] = wd
+
+		return nil
+	})
+}
+
+// removeTree drops the watches on relDir and everything beneath it, which
+// have moved out of the tree or been removed
+func (w *inotifyWatcher) removeTree(relDir string) {
+	for dir, wd := range w.wds {
+		if dir == relDir || strings.HasPrefix(dir, relDir+"/") {
+			unix.InotifyRmWatch(w.fd, uint32(wd))
+			delete(w.wds, dir)
+			delete(w.dirs, wd)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+//go:build linux
+
+package storage_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jeffanddom/fixity/internal/storage"
+)
+
+// waitForPaths drains queue until it has seen every expected path or the
+// timeout passes, returning everything drained
+func waitForPaths(t *testing.T, queue *storage.WatchQueue, expected ...string) map[string]bool {
+	t.Helper()
+
+	seen := make(map[string]bool)
+	deadline := time.After(5 * time.Second)
+	for {
+		missing := false
+		for _, path := range expected {
+			if !seen[path] {
+				missing = true
+			}
+		}
+		if !missing {
+			return seen
+		}
+
+		select {
+		case <-queue.Ready():
+			paths, _ := queue.Drain()
+			for _, path := range paths {
+				seen[path] = true
+			}
+		case <-deadline:
+			t.Fatalf("timed out waiting for %v, saw %v", expected, seen)
+		}
+	}
+}
+
+func TestLocalFSBackend_Watch(t *testing.T) {
+	tmpDir := setupTestDir(t)
+	backend, _ := storage.NewLocalFSBackend(tmpDir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	queue := storage.NewWatchQueue(0)
+	done := make(chan error, 1)
+	go func() {
+		done <- backend.Watch(ctx, queue)
+	}()
+
+	// Give the watcher time to place its watches
+	time.Sleep(100 * time.Millisecond)
+
+	t.Run("reports modified and created files", func(t *testing.T) {
+		writeFile(t, filepath.Join(tmpDir, "file1.txt"), "changed")
+		writeFile(t, filepath.Join(tmpDir, "subdir", "nested", "new.txt"), "new")
+
+		waitForPaths(t, queue, "file1.txt", "subdir/nested/new.txt")
+	})
+
+	t.Run("reports files in new directories", func(t *testing.T) {
+		staging := filepath.Join(t.TempDir(), "incoming")
+		os.MkdirAll(filepath.Join(staging, "deep"), 0755)
+		writeFile(t, filepath.Join(staging, "deep", "a.txt"), "a")
+		if err := os.Rename(staging, filepath.Join(tmpDir, "incoming")); err != nil {
+			t.Skipf("cannot move directories across filesystems: %v", err)
+		}
+
+		waitForPaths(t, queue, "incoming/deep/a.txt")
+
+		// The moved-in directory is watched from now on
+		writeFile(t, filepath.Join(tmpDir, "incoming", "deep", "b.txt"), "b")
+		waitForPaths(t, queue, "incoming/deep/b.txt")
+	})
+
+	t.Run("reports removed directories as a whole", func(t *testing.T) {
+		if err := os.RemoveAll(filepath.Join(tmpDir, "subdir")); err != nil {
+			t.Fatalf("failed to remove directory: %v", err)
+		}
+
+		waitForPaths(t, queue, "subdir")
+	})
+
+	t.Run("reports renamed directories", func(t *testing.T) {
+		os.Mkdir(filepath.Join(tmpDir, "before"), 0755)
+		writeFile(t, filepath.Join(tmpDir, "before", "c.txt"), "c")
+		waitForPaths(t, queue, "before/c.txt")
+
+		if err := os.Rename(filepath.Join(tmpDir, "before"), filepath.Join(tmpDir, "after")); err != nil {
+			t.Fatalf("failed to rename directory: %v", err)
+		}
+		waitForPaths(t, queue, "before", "after/c.txt")
+	})
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expected Watch to stop cleanly, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Watch did not stop after cancellation")
+	}
+}
+
+func TestLocalFSBackend_WatchMissingRoot(t *testing.T) {
+	backend, _ := storage.NewLocalFSBackend(filepath.Join(t.TempDir(), "missing"))
+
+	err := backend.Watch(context.Background(), storage.NewWatchQueue(0))
+	if err == nil {
+		t.Error("expected an error when the root cannot be watched")
+	}
+
+	if _, ok := storage.StorageBackend(backend).(storage.ChangeWatcher); !ok {
+		t.Error("expected LocalFSBackend to implement ChangeWatcher")
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package storage
+
+import "context"
+
+// watchTree is only implemented with inotify on Linux
+func watchTree(ctx context.Context, rootPath string, queue *WatchQueue) error {
+	return ErrWatchUnsupported
+}
@@ -0,0 +1,109 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jeffanddom/fixity/internal/storage"
+)
+
+func TestWatchQueue(t *testing.T) {
+	t.Run("deduplicates and drains in order", func(t *testing.T) {
+		queue := storage.NewWatchQueue(10)
+		for _, path := range []string{"b.txt", "a.txt", "b.txt"} {
+			queue.Add(path)
+		}
+
+		select {
+		case <-queue.Ready():
+		default:
+			t.Error("expected the queue to signal readiness")
+		}
+
+		paths, overflowed := queue.Drain()
+		if !reflect.DeepEqual(paths, []string{"a.txt", "b.txt"}) || overflowed {
+			t.Errorf("unexpected drain: %v (overflowed %v)", paths, overflowed)
+		}
+		if queue.Len() != 0 {
+			t.Errorf("expected an empty queue after draining, got %d", queue.Len())
+		}
+	})
+
+	t.Run("overflows past its size", func(t *testing.T) {
+		queue := storage.NewWatchQueue(2)
+		for _, path := range []string{"a", "b", "c", "d"} {
+			queue.Add(path)
+		}
+
+		paths, overflowed := queue.Drain()
+		if !overflowed || len(paths) != 0 {
+			t.Errorf("expected an overflow with no paths, got %v (overflowed %v)", paths, overflowed)
+		}
+
+		// Draining resets the overflow
+		queue.Add("e")
+		if paths, overflowed := queue.Drain(); overflowed || !reflect.DeepEqual(paths, []string{"e"}) {
+			t.Errorf("expected a fresh queue after draining, got %v (overflowed %v)", paths, overflowed)
+		}
+	})
+}
+
+func TestLookup(t *testing.T) {
+	tmpDir := setupFilterTree(t)
+	writeFile(t, filepath.Join(tmpDir, "photos", storage.IgnoreFileName), "Thumbs.db\n")
+
+	local, _ := storage.NewLocalFSBackend(tmpDir)
+	ctx := context.Background()
+
+	t.Run("reports files and directories", func(t *testing.T) {
+		info, err := storage.Lookup(ctx, local, "docs/report.txt")
+		if err != nil || info == nil {
+			t.Fatalf("expected file info, got %v (%v)", info, err)
+		}
+		if info.Type != storage.FileTypeRegular || info.Size != int64(len("content")) {
+			t.Errorf("unexpected file info: %+v", info)
+		}
+
+		info, err = storage.Lookup(ctx, local, "photos")
+		if err != nil || info == nil || !info.IsDir {
+			t.Errorf("expected a directory, got %+v (%v)", info, err)
+		}
+	})
+
+	t.Run("reports missing paths", func(t *testing.T) {
+		_, err := storage.Lookup(ctx, local, "docs/missing.txt")
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("expected a not-exist error, got %v", err)
+		}
+	})
+
+	t.Run("applies path rules", func(t *testing.T) {
+		backend, err := storage.NewFilteredBackend(local, storage.PathRules{
+			Exclude:     []string{".snapshot/"},
+			IgnoreFiles: true,
+		})
+		if err != nil {
+			t.Fatalf("failed to create filtered backend: %v", err)
+		}
+
+		for _, path := range []string{".snapshot/hourly.0/a.jpg", "photos/2024/Thumbs.db"} {
+			if info, err := storage.Lookup(ctx, backend, path); err != nil || info != nil {
+				t.Errorf("expected %s to be left out, got %+v (%v)", path, info, err)
+			}
+		}
+		if info, err := storage.Lookup(ctx, backend, "photos/2024/a.jpg"); err != nil || info == nil {
+			t.Errorf("expected photos/2024/a.jpg to be reported, got %v", err)
+		}
+	})
+
+	t.Run("unsupported backends", func(t *testing.T) {
+		inner := &erroringBackend{StorageBackend: local}
+		if _, err := storage.Lookup(ctx, inner, "readme.txt"); !errors.Is(err, storage.ErrLookupUnsupported) {
+			t.Errorf("expected ErrLookupUnsupported, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,392 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// webdavMaxConns caps the HTTP connections kept open to the server, so
+// parallel Open calls reuse connections instead of dialing for every file
+const webdavMaxConns = 8
+
+// webdavPropfind asks for the properties Walk reports, including the
+// checksums Nextcloud and ownCloud servers keep for each file
+const webdavPropfind = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:" xmlns:oc="http://owncloud.org/ns">
+  <d:prop>
+    <d:resourcetype/>
+    <d:getcontentlength/>
+    <d:getlastmodified/>
+    <oc:checksums/>
+  </d:prop>
+</d:propfind>`
+
+// webdavChecksumPreference orders the oc:checksums algorithms by preference
+var webdavChecksumPreference = []string{"sha256", "sha1", "md5", "adler32"}
+
+// WebDAVBackend implements StorageBackend for a directory served over WebDAV.
+// Walk lists each collection with a depth-1 PROPFIND and Open downloads the
+// file with GET.
+type WebDAVBackend struct {
+	baseURL  *url.URL // Server URL of the WebDAV root, e.g. https://host/remote.php/dav/files/alice
+	rootPath string   // Directory under baseURL that is scanned
+	user     string
+	password string
+	client   *http.Client
+}
+
+// NewWebDAVBackend creates a new WebDAV backend. server is the URL of the
+// WebDAV root and rootPath the directory beneath it to scan. secret, if not
+// empty, is "user:password" for basic authentication.
+func NewWebDAVBackend(server, rootPath string, secret []byte) (*WebDAVBackend, error) {
+	baseURL, err := url.Parse(server)
+	if err != nil || (baseURL.Scheme != "http" && baseURL.Scheme != "https") || baseURL.Host == "" {
+		return nil, fmt.Errorf("WebDAV server must be an http(s) URL: %s", server)
+	}
+
+	b := &WebDAVBackend{
+		baseURL:  baseURL,
+		rootPath: strings.Trim(path.Clean("/"+rootPath), "/"),
+		client: &http.Client{
+			Transport: &http.Transport{
+				Proxy:               http.ProxyFromEnvironment,
+				MaxIdleConnsPerHost: webdavMaxConns,
+				MaxConnsPerHost:     webdavMaxConns,
+			},
+		},
+	}
+
+	if len(secret) > 0 {
+		user, password, ok := strings.Cut(strings.TrimRight(string(secret), "\r\n"), ":")
+		if !ok || user == "" {
+			return nil, fmt.Errorf("WebDAV credentials must be user:password")
+		}
+		b.user, b.password = user, password
+	}
+
+	return b, nil
+}
+
+// ValidateWebDAVServer checks that server is an http(s) URL
+func ValidateWebDAVServer(server string) error {
+	_, err := NewWebDAVBackend(server, "", nil)
+	return err
+}
+
+// url returns the URL of relPath under the root; collections get a trailing
+// slash, which some servers require
+func (b *WebDAVBackend) url(relPath string, collection bool) (string, error) {
+	remote := path.Join(b.rootPath, relPath)
+	if remote == ".." || strings.HasPrefix(remote, "../") || !withinRemoteRoot("/"+b.rootPath, "/"+remote) {
+		return "", fmt.Errorf("path traversal attempt detected: %s", relPath)
+	}
+
+	u := b.baseURL.JoinPath(remote)
+	if collection && !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+		u.RawPath = ""
+	}
+	return u.String(), nil
+}
+
+// do sends a request with the backend's credentials
+func (b *WebDAVBackend) do(ctx context.Context, method, target string, header http.Header, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if b.user != "" {
+		req.SetBasicAuth(b.user, b.password)
+	}
+	return b.client.Do(req)
+}
+
+// webdavStatusError describes an unexpected HTTP status; 404 and 410 wrap
+// fs.ErrNotExist and 401 and 403 wrap fs.ErrPermission
+type webdavStatusError struct {
+	Method string
+	Status string
+	Code   int
+}
+
+func (e *webdavStatusError) Error() string {
+	return fmt.Sprintf("WebDAV %s returned %s", e.Method, e.Status)
+}
+
+func (e *webdavStatusError) Unwrap() error {
+	switch e.Code {
+	case http.StatusNotFound, http.StatusGone:
+		return fs.ErrNotExist
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fs.ErrPermission
+	}
+	return nil
+}
+
+// webdavMultistatus is the body of a PROPFIND response
+type webdavMultistatus struct {
+	Responses []struct {
+		Href     string `xml:"DAV: href"`
+		Propstat []struct {
+			Status string `xml:"DAV: status"`
+			Prop   struct {
+				ResourceType struct {
+					Collection *struct{} `xml:"DAV: collection"`
+				} `xml:"DAV: resourcetype"`
+				ContentLength string   `xml:"DAV: getcontentlength"`
+				LastModified  string   `xml:"DAV: getlastmodified"`
+				Checksums     []string `xml:"http://owncloud.org/ns checksums>checksum"`
+			} `xml:"DAV: prop"`
+		} `xml:"DAV: propstat"`
+	} `xml:"DAV: response"`
+}
+
+// webdavEntry is one resource reported by PROPFIND
+type webdavEntry struct {
+	name string // Last path segment; "" for the resource that was asked about
+	info *FileInfo
+}
+
+// propfind lists relPath with the given depth ("0" or "1"). The entry for
+// relPath itself has an empty name.
+func (b *WebDAVBackend) propfind(ctx context.Context, relPath, depth string, collection bool) ([]webdavEntry, error) {
+	target, err := b.url(relPath, collection)
+	if err != nil {
+		return nil, err
+	}
+
+	header := http.Header{}
+	header.Set("Depth", depth)
+	header.Set("Content-Type", "application/xml; charset=utf-8")
+	resp, err := b.do(ctx, "PROPFIND", target, header, []byte(webdavPropfind))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, &webdavStatusError{Method: "PROPFIND", Status: resp.Status, Code: resp.StatusCode}
+	}
+
+	var ms webdavMultistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return nil, fmt.Errorf("failed to parse PROPFIND response: %w", err)
+	}
+
+	requested, _ := url.Parse(target)
+	self := strings.TrimSuffix(requested.Path, "/")
+
+	entries := make([]webdavEntry, 0, len(ms.Responses))
+	for _, r := range ms.Responses {
+		href, err := url.Parse(r.Href)
+		if err != nil {
+			continue
+		}
+		hrefPath := strings.TrimSuffix(href.Path, "/")
+
+		var name string
+		switch {
+		case hrefPath == self:
+		case path.Dir(hrefPath) == self:
+			name = path.Base(hrefPath)
+		default:
+			// Not a member of the collection
+			continue
+		}
+
+		entryPath := relPath
+		if name != "" {
+			entryPath = path.Join(relPath, name)
+		}
+		info := &FileInfo{Path: entryPath, Type: FileTypeRegular}
+		for _, ps := range r.Propstat {
+			if !strings.Contains(ps.Status, " 200 ") {
+				continue
+			}
+			if ps.Prop.ResourceType.Collection != nil {
+				info.IsDir = true
+				info.Type = FileTypeDirectory
+			}
+			if ps.Prop.ContentLength != "" {
+				info.Size, _ = strconv.ParseInt(ps.Prop.ContentLength, 10, 64)
+			}
+			if ps.Prop.LastModified != "" {
+				info.ModTime, _ = http.ParseTime(ps.Prop.LastModified)
+			}
+			if len(ps.Prop.Checksums) > 0 {
+				info.ServerChecksum = preferredChecksum(strings.Join(ps.Prop.Checksums, " "))
+			}
+		}
+		entries = append(entries, webdavEntry{name: name, info: info})
+	}
+
+	return entries, nil
+}
+
+// preferredChecksum picks the strongest checksum from an oc:checksums value
+// such as "SHA1:ab12 MD5:cd34 ADLER32:ef56", as "sha1:ab12"
+func preferredChecksum(value string) string {
+	found := make(map[string]string)
+	for _, field := range strings.Fields(value) {
+		algorithm, sum, ok := strings.Cut(field, ":")
+		if ok && sum != "" {
+			found[strings.ToLower(algorithm)] = strings.ToLower(sum)
+		}
+	}
+	for _, algorithm := range webdavChecksumPreference {
+		if sum, ok := found[algorithm]; ok {
+			return algorithm + ":" + sum
+		}
+	}
+	return ""
+}
+
+// Probe checks that the root is a collection the server lets us list
+func (b *WebDAVBackend) Probe(ctx context.Context) error {
+	entries, err := b.propfind(ctx, "", "0", true)
+	if err != nil {
+		return fmt.Errorf("failed to access WebDAV path %s on %s: %w", b.rootPath, b.baseURL.Host, err)
+	}
+	for _, entry := range entries {
+		if entry.name == "" && !entry.info.IsDir {
+			return fmt.Errorf("WebDAV path is not a collection: %s", b.rootPath)
+		}
+	}
+	return nil
+}
+
+// Walk traverses all files under the root, listing one collection at a time
+// in lexical order. Collections that cannot be listed are reported with Err
+// set, as for mounted filesystems; WebDAV has no symlinks or special files.
+func (b *WebDAVBackend) Walk(ctx context.Context, fn WalkFunc) error {
+	return b.walkDir(ctx, "", fn)
+}
+
+// walkDir visits the members of the collection relDir ("" for the root)
+func (b *WebDAVBackend) walkDir(ctx context.Context, relDir string, fn WalkFunc) error {
+	entries, err := b.propfind(ctx, relDir, "1", true)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if relDir == "" {
+			return fmt.Errorf("failed to read storage root: %w", err)
+		}
+		err := fn(relDir, &FileInfo{
+			Path:  relDir,
+			IsDir: true,
+			Type:  FileTypeDirectory,
+			Err:   &WalkError{Path: relDir, Op: "readdir", Err: err},
+		})
+		if err != nil && err != filepath.SkipDir {
+			return err
+		}
+		return nil
+	}
+
+	members := entries[:0]
+	for _, entry := range entries {
+		if entry.name != "" {
+			members = append(members, entry)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].name < members[j].name })
+
+	for _, entry := range members {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		relPath := entry.info.Path
+		err := fn(relPath, entry.info)
+		if err == filepath.SkipDir {
+			if entry.info.IsDir {
+				continue
+			}
+			// Returned for a file: skip the rest of this collection
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if entry.info.IsDir {
+			if err := b.walkDir(ctx, relPath, fn); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Open downloads a file with GET
+func (b *WebDAVBackend) Open(ctx context.Context, relPath string) (io.ReadCloser, error) {
+	target, err := b.url(relPath, false)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := b.do(ctx, http.MethodGet, target, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file over WebDAV: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to open file over WebDAV: %w",
+			&webdavStatusError{Method: "GET", Status: resp.Status, Code: resp.StatusCode})
+	}
+
+	return resp.Body, nil
+}
+
+// Stat returns file metadata with a depth-0 PROPFIND
+func (b *WebDAVBackend) Stat(ctx context.Context, relPath string) (*FileInfo, error) {
+	entries, err := b.propfind(ctx, relPath, "0", false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file over WebDAV: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.name == "" {
+			entry.info.Path = relPath
+			return entry.info, nil
+		}
+	}
+	return nil, fmt.Errorf("failed to stat file over WebDAV: %s missing from response", relPath)
+}
+
+// Close releases idle connections
+func (b *WebDAVBackend) Close() error {
+	b.client.CloseIdleConnections()
+	return nil
+}
+
+// Server returns the URL of the WebDAV root
+func (b *WebDAVBackend) Server() string {
+	return b.baseURL.String()
+}
+
+// RootPath returns the scanned directory under the WebDAV root
+func (b *WebDAVBackend) RootPath() string {
+	return b.rootPath
+}
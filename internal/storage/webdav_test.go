@@ -0,0 +1,238 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// startWebDAVServer serves root under /dav/ with enough of WebDAV for the
+// backend: PROPFIND at depth 0 and 1, reporting SHA1 checksums the way
+// ownCloud does, and GET. If user is set, basic authentication is required.
+func startWebDAVServer(t *testing.T, root, user, password string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user != "" {
+			u, p, ok := r.BasicAuth()
+			if !ok || u != user || p != password {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		}
+
+		rel, ok := strings.CutPrefix(r.URL.Path, "/dav/")
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		local := filepath.Join(root, filepath.FromSlash(path.Clean("/"+rel)))
+		info, err := os.Stat(local)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			if info.IsDir() {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			http.ServeFile(w, r, local)
+		case "PROPFIND":
+			var body strings.Builder
+			body.WriteString(`<?xml version="1.0"?><d:multistatus xmlns:d="DAV:" xmlns:oc="http://owncloud.org/ns">`)
+			writeWebDAVResponse(&body, r.URL.Path, local, info)
+			if info.IsDir() && r.Header.Get("Depth") == "1" {
+				entries, _ := os.ReadDir(local)
+				for _, entry := range entries {
+					child, err := entry.Info()
+					if err != nil {
+						continue
+					}
+					href := strings.TrimSuffix(r.URL.Path, "/") + "/" + entry.Name()
+					writeWebDAVResponse(&body, href, filepath.Join(local, entry.Name()), child)
+				}
+			}
+			body.WriteString(`</d:multistatus>`)
+			w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+			w.WriteHeader(http.StatusMultiStatus)
+			io.WriteString(w, body.String())
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func writeWebDAVResponse(body *strings.Builder, href, local string, info os.FileInfo) {
+	fmt.Fprintf(body, `<d:response><d:href>%s</d:href><d:propstat><d:prop>`, href)
+	if info.IsDir() {
+		body.WriteString(`<d:resourcetype><d:collection/></d:resourcetype>`)
+	} else {
+		data, _ := os.ReadFile(local)
+		sum := sha1.Sum(data)
+		fmt.Fprintf(body, `<d:resourcetype/><d:getcontentlength>%d</d:getcontentlength>`, info.Size())
+		fmt.Fprintf(body, `<oc:checksums><oc:checksum>SHA1:%s MD5:00</oc:checksum></oc:checksums>`, hex.EncodeToString(sum[:]))
+	}
+	fmt.Fprintf(body, `<d:getlastmodified>%s</d:getlastmodified>`, info.ModTime().UTC().Format(http.TimeFormat))
+	body.WriteString(`</d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>`)
+}
+
+func TestWebDAVBackend_WalkAndOpen(t *testing.T) {
+	root := t.TempDir()
+	os.Mkdir(filepath.Join(root, "photos"), 0755)
+	os.WriteFile(filepath.Join(root, "photos", "b.txt"), []byte("bravo!"), 0644)
+	os.WriteFile(filepath.Join(root, "photos", "a b.txt"), []byte("alpha"), 0644)
+	os.Mkdir(filepath.Join(root, "photos", "sub"), 0755)
+	os.WriteFile(filepath.Join(root, "photos", "sub", "c.txt"), []byte("charlie"), 0644)
+	os.WriteFile(filepath.Join(root, "outside.txt"), []byte("secret"), 0644)
+
+	server := startWebDAVServer(t, root, "alice", "hunter2")
+	backend, err := NewWebDAVBackend(server.URL+"/dav", "/photos", []byte("alice:hunter2\n"))
+	if err != nil {
+		t.Fatalf("failed to create backend: %v", err)
+	}
+	defer backend.Close()
+
+	ctx := context.Background()
+	if err := backend.Probe(ctx); err != nil {
+		t.Fatalf("probe failed: %v", err)
+	}
+
+	seen := map[string]*FileInfo{}
+	var order []string
+	err = backend.Walk(ctx, func(path string, info *FileInfo) error {
+		if info.Err != nil {
+			t.Errorf("unexpected walk error at %s: %v", path, info.Err)
+		}
+		seen[path] = info
+		order = append(order, path)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+
+	want := []string{"a b.txt", "b.txt", "sub", "sub/c.txt"}
+	if strings.Join(order, ",") != strings.Join(want, ",") {
+		t.Errorf("expected walk order %v, got %v", want, order)
+	}
+	if info := seen["sub"]; info == nil || !info.IsDir || info.Type != FileTypeDirectory {
+		t.Errorf("expected sub to be a directory, got %+v", info)
+	}
+
+	sum := sha1.Sum([]byte("bravo!"))
+	info := seen["b.txt"]
+	if info == nil || info.Size != 6 || info.ModTime.IsZero() || info.HasMode {
+		t.Errorf("unexpected info for b.txt: %+v", info)
+	} else if info.ServerChecksum != "sha1:"+hex.EncodeToString(sum[:]) {
+		t.Errorf("expected the SHA1 server checksum to be preferred, got %q", info.ServerChecksum)
+	}
+
+	rc, err := backend.Open(ctx, "sub/c.txt")
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil || string(data) != "charlie" {
+		t.Errorf("expected to read charlie, got %q (%v)", data, err)
+	}
+
+	stat, err := backend.Stat(ctx, "a b.txt")
+	if err != nil || stat.Size != 5 || stat.Path != "a b.txt" {
+		t.Errorf("unexpected stat for a b.txt: %+v (%v)", stat, err)
+	}
+
+	if _, err := backend.Open(ctx, "missing.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected a missing file to be fs.ErrNotExist, got %v", err)
+	}
+	if _, err := backend.Open(ctx, "../outside.txt"); err == nil || !strings.Contains(err.Error(), "path traversal") {
+		t.Errorf("expected a path leaving the root to be refused, got %v", err)
+	}
+}
+
+func TestWebDAVBackend_Auth(t *testing.T) {
+	root := t.TempDir()
+	server := startWebDAVServer(t, root, "alice", "hunter2")
+
+	backend, err := NewWebDAVBackend(server.URL+"/dav", "", []byte("alice:wrong"))
+	if err != nil {
+		t.Fatalf("failed to create backend: %v", err)
+	}
+	defer backend.Close()
+
+	if err := backend.Probe(context.Background()); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("expected rejected credentials to be fs.ErrPermission, got %v", err)
+	}
+
+	anonymous, err := NewWebDAVBackend(server.URL+"/dav", "", nil)
+	if err != nil {
+		t.Fatalf("failed to create backend: %v", err)
+	}
+	defer anonymous.Close()
+
+	if err := anonymous.Probe(context.Background()); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("expected missing credentials to be fs.ErrPermission, got %v", err)
+	}
+}
+
+func TestNewWebDAVBackend_Validation(t *testing.T) {
+	tests := []struct {
+		name   string
+		server string
+		secret string
+		want   string
+	}{
+		{"not a URL", "cloud.example.com", "", "http(s) URL"},
+		{"unsupported scheme", "ftp://cloud.example.com/dav", "", "http(s) URL"},
+		{"malformed credentials", "https://cloud.example.com/dav", "hunter2", "user:password"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewWebDAVBackend(tt.server, "/", []byte(tt.secret))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	if err := ValidateWebDAVServer("https://cloud.example.com/remote.php/dav/files/alice"); err != nil {
+		t.Errorf("expected a Nextcloud URL to be accepted, got %v", err)
+	}
+}
+
+func TestPreferredChecksum(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"SHA1:AB12 MD5:cd34 ADLER32:ef56", "sha1:ab12"},
+		{"MD5:cd34 SHA256:0f0f", "sha256:0f0f"},
+		{"ADLER32:ef56", "adler32:ef56"},
+		{"CRC32:1234", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := preferredChecksum(tt.value); got != tt.want {
+			t.Errorf("preferredChecksum(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+//go:build linux
+
+package storage
+
+import (
+	"bytes"
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// xattrDigest returns a digest of the extended attributes of path, without
+// following a final symlink. POSIX ACLs are stored as system.posix_acl_*
+// attributes, so they are covered too. It returns "" if the attributes
+// cannot be read, for example on filesystems without xattr support.
+func xattrDigest(path string) string {
+	names, err := listXattrs(path)
+	if err != nil {
+		return ""
+	}
+
+	attrs := make(map[string][]byte, len(names))
+	for _, name := range names {
+		value, err := getXattr(path, name)
+		if errors.Is(err, unix.ENODATA) {
+			// Removed since it was listed
+			continue
+		}
+		if err != nil {
+			return ""
+		}
+		attrs[name] = value
+	}
+
+	return digestXattrs(attrs)
+}
+
+// listXattrs returns the names of the extended attributes of path
+func listXattrs(path string) ([]string, error) {
+	for {
+		size, err := unix.Llistxattr(path, nil)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return nil, nil
+		}
+
+		buf := make([]byte, size)
+		n, err := unix.Llistxattr(path, buf)
+		if errors.Is(err, unix.ERANGE) {
+			// Grew since it was sized
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var names []string
+		for _, name := range bytes.Split(buf[:n], []byte{0}) {
+			if len(name) > 0 {
+				names = append(names, string(name))
+			}
+		}
+		return names, nil
+	}
+}
+
+// getXattr returns the value of one extended attribute of path
+func getXattr(path, name string) ([]byte, error) {
+	for {
+		size, err := unix.Lgetxattr(path, name, nil)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return []byte{}, nil
+		}
+
+		buf := make([]byte, size)
+		n, err := unix.Lgetxattr(path, name, buf)
+		if errors.Is(err, unix.ERANGE) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+}
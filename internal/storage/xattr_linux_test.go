@@ -0,0 +1,62 @@
+//go:build linux
+
+package storage_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/jeffanddom/fixity/internal/storage"
+)
+
+func TestLocalFSBackend_XattrDigest(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "file.txt")
+	writeFile(t, path, "content")
+
+	backend, err := storage.NewBackend(storage.BackendConfig{
+		Type:          storage.TypeLocal,
+		Path:          tmpDir,
+		CaptureXattrs: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to create backend: %v", err)
+	}
+
+	lookup := func() string {
+		t.Helper()
+		info, err := storage.Lookup(context.Background(), backend, "file.txt")
+		if err != nil {
+			t.Fatalf("lookup failed: %v", err)
+		}
+		return info.XattrDigest
+	}
+
+	if err := unix.Setxattr(path, "user.fixity.test", []byte("one"), 0); err != nil {
+		t.Skipf("filesystem does not support user xattrs: %v", err)
+	}
+	first := lookup()
+	if first == "" {
+		t.Fatal("expected an xattr digest")
+	}
+	if again := lookup(); again != first {
+		t.Errorf("expected a stable digest, got %s then %s", first, again)
+	}
+
+	if err := unix.Setxattr(path, "user.fixity.test", []byte("two"), 0); err != nil {
+		t.Fatalf("failed to set xattr: %v", err)
+	}
+	if changed := lookup(); changed == first {
+		t.Error("expected the digest to change with an xattr value")
+	}
+
+	if err := unix.Removexattr(path, "user.fixity.test"); err != nil {
+		t.Fatalf("failed to remove xattr: %v", err)
+	}
+	if removed := lookup(); removed == "" || removed == first {
+		t.Errorf("expected a distinct digest once every xattr is removed, got %q", removed)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package storage
+
+// xattrDigest is not implemented on this platform; extended attributes are
+// not recorded
+func xattrDigest(path string) string {
+	return ""
+}
@@ -0,0 +1,412 @@
+package throttle
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limits describes a throughput ceiling. Zero values mean unlimited.
+type Limits struct {
+	BytesPerSec int64
+	IOPS        int
+}
+
+// Unlimited reports whether neither bandwidth nor IOPS is capped
+func (l Limits) Unlimited() bool {
+	return l.BytesPerSec <= 0 && l.IOPS <= 0
+}
+
+// String returns a human-readable description of the limits
+func (l Limits) String() string {
+	if l.Unlimited() {
+		return "unlimited"
+	}
+
+	parts := []string{}
+	if l.BytesPerSec > 0 {
+		parts = append(parts, FormatRate(l.BytesPerSec))
+	}
+	if l.IOPS > 0 {
+		parts = append(parts, fmt.Sprintf("%d IOPS", l.IOPS))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Combine returns the stricter of two limits for each dimension
+func Combine(a, b Limits) Limits {
+	return Limits{
+		BytesPerSec: minPositive(a.BytesPerSec, b.BytesPerSec),
+		IOPS:        int(minPositive(int64(a.IOPS), int64(b.IOPS))),
+	}
+}
+
+func minPositive(a, b int64) int64 {
+	if a <= 0 {
+		return b
+	}
+	if b <= 0 || a < b {
+		return a
+	}
+	return b
+}
+
+// Window overrides the default limits during a time-of-day range.
+// Start and End are offsets from local midnight; a window whose End is
+// before its Start wraps past midnight (e.g. 18:00-08:00).
+type Window struct {
+	Start  time.Duration
+	End    time.Duration
+	Limits Limits
+}
+
+// contains reports whether the time-of-day offset falls inside the window
+func (w Window) contains(offset time.Duration) bool {
+	if w.Start == w.End {
+		return true
+	}
+	if w.Start < w.End {
+		return offset >= w.Start && offset < w.End
+	}
+	return offset >= w.Start || offset < w.End
+}
+
+// Schedule holds default limits plus optional time-of-day windows
+type Schedule struct {
+	Default Limits
+	Windows []Window
+}
+
+// At returns the limits in effect at the given time.
+// The first matching window wins; otherwise Default applies.
+func (s Schedule) At(t time.Time) Limits {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	offset := t.Sub(midnight)
+
+	for _, w := range s.Windows {
+		if w.contains(offset) {
+			return w.Limits
+		}
+	}
+	return s.Default
+}
+
+// Unlimited reports whether the schedule never restricts throughput
+func (s Schedule) Unlimited() bool {
+	if !s.Default.Unlimited() {
+		return false
+	}
+	for _, w := range s.Windows {
+		if !w.Limits.Unlimited() {
+			return false
+		}
+	}
+	return true
+}
+
+// Limiter is a token-bucket limiter for bytes and I/O operations
+// whose rates follow a Schedule. A nil *Limiter never blocks.
+type Limiter struct {
+	mu       sync.Mutex
+	schedule Schedule
+	bytes    bucket
+	ops      bucket
+	now      func() time.Time
+}
+
+// NewLimiter creates a limiter following the given schedule
+func NewLimiter(schedule Schedule) *Limiter {
+	return &Limiter{
+		schedule: schedule,
+		now:      time.Now,
+	}
+}
+
+// Current returns the limits in effect right now
+func (l *Limiter) Current() Limits {
+	if l == nil {
+		return Limits{}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.schedule.At(l.now())
+}
+
+// Schedule returns the schedule this limiter follows
+func (l *Limiter) Schedule() Schedule {
+	if l == nil {
+		return Schedule{}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.schedule
+}
+
+// WaitBytes blocks until n bytes may be transferred
+func (l *Limiter) WaitBytes(ctx context.Context, n int) error {
+	return l.wait(ctx, n, true)
+}
+
+// WaitOp blocks until one more I/O operation may be issued
+func (l *Limiter) WaitOp(ctx context.Context) error {
+	return l.wait(ctx, 1, false)
+}
+
+// wait reserves n tokens from the bytes or ops bucket and sleeps off any deficit
+func (l *Limiter) wait(ctx context.Context, n int, bytes bool) error {
+	if l == nil || n <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := l.now()
+	limits := l.schedule.At(now)
+	var delay time.Duration
+	if bytes {
+		delay = l.bytes.reserve(now, float64(limits.BytesPerSec), float64(n))
+	} else {
+		delay = l.ops.reserve(now, float64(limits.IOPS), float64(n))
+	}
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// bucket is a single token bucket with a one-second burst
+type bucket struct {
+	tokens float64
+	rate   float64
+	last   time.Time
+}
+
+// reserve takes n tokens and returns how long the caller must wait to
+// stay within rate. Tokens may go negative so large requests are paid
+// for by later callers instead of being rejected.
+func (b *bucket) reserve(now time.Time, rate, n float64) time.Duration {
+	if rate <= 0 {
+		b.rate = 0
+		b.tokens = 0
+		b.last = now
+		return 0
+	}
+
+	if b.rate <= 0 || b.last.IsZero() {
+		// Newly limited: start with a full burst
+		b.tokens = rate
+	} else {
+		b.tokens += now.Sub(b.last).Seconds() * rate
+		if b.tokens > rate {
+			b.tokens = rate
+		}
+	}
+	b.rate = rate
+	b.last = now
+
+	b.tokens -= n
+	if b.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-b.tokens / rate * float64(time.Second))
+}
+
+// Reader wraps a ReadCloser and paces reads through one or more limiters
+type Reader struct {
+	ctx      context.Context
+	rc       io.ReadCloser
+	limiters []*Limiter
+}
+
+// NewReader returns a ReadCloser whose reads are throttled by the given limiters.
+// Nil limiters are ignored.
+func NewReader(ctx context.Context, rc io.ReadCloser, limiters ...*Limiter) *Reader {
+	active := make([]*Limiter, 0, len(limiters))
+	for _, l := range limiters {
+		if l != nil {
+			active = append(active, l)
+		}
+	}
+
+	return &Reader{
+		ctx:      ctx,
+		rc:       rc,
+		limiters: active,
+	}
+}
+
+// Read implements io.Reader, counting each call as one I/O operation
+func (r *Reader) Read(p []byte) (int, error) {
+	for _, l := range r.limiters {
+		if err := l.WaitOp(r.ctx); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.rc.Read(p)
+
+	for _, l := range r.limiters {
+		if werr := l.WaitBytes(r.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+
+	return n, err
+}
+
+// Close closes the underlying reader
+func (r *Reader) Close() error {
+	return r.rc.Close()
+}
+
+// ParseRate parses a bandwidth such as "50MB/s", "512KiB" or "1048576".
+// Units are binary (1KB = 1024 bytes). "unlimited", "none" and "0" yield 0.
+func ParseRate(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	lower := strings.ToLower(s)
+	if lower == "" || lower == "unlimited" || lower == "none" {
+		return 0, nil
+	}
+
+	lower = strings.TrimSuffix(lower, "/s")
+
+	multipliers := []struct {
+		suffix string
+		mult   int64
+	}{
+		{"tib", 1 << 40}, {"gib", 1 << 30}, {"mib", 1 << 20}, {"kib", 1 << 10},
+		{"tb", 1 << 40}, {"gb", 1 << 30}, {"mb", 1 << 20}, {"kb", 1 << 10},
+		{"t", 1 << 40}, {"g", 1 << 30}, {"m", 1 << 20}, {"k", 1 << 10},
+		{"b", 1},
+	}
+
+	mult := int64(1)
+	for _, m := range multipliers {
+		if strings.HasSuffix(lower, m.suffix) {
+			lower = strings.TrimSuffix(lower, m.suffix)
+			mult = m.mult
+			break
+		}
+	}
+
+	value, err := strconv.ParseFloat(strings.TrimSpace(lower), 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid rate: %q", s)
+	}
+
+	return int64(value * float64(mult)), nil
+}
+
+// FormatRate formats a byte rate for display
+func FormatRate(bytesPerSec int64) string {
+	if bytesPerSec <= 0 {
+		return "unlimited"
+	}
+
+	const unit = 1024
+	if bytesPerSec < unit {
+		return fmt.Sprintf("%d B/s", bytesPerSec)
+	}
+	div, exp := int64(unit), 0
+	for n := bytesPerSec / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB/s", float64(bytesPerSec)/float64(div), "KMGTPE"[exp])
+}
+
+// ParseLimits parses a comma-separated limit list such as "50MB/s,200iops".
+// "unlimited" yields zero limits.
+func ParseLimits(s string) (Limits, error) {
+	var limits Limits
+
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		lower := strings.ToLower(part)
+		if strings.HasSuffix(lower, "iops") {
+			n, err := strconv.Atoi(strings.TrimSpace(strings.TrimSuffix(lower, "iops")))
+			if err != nil || n < 0 {
+				return Limits{}, fmt.Errorf("invalid IOPS limit: %q", part)
+			}
+			limits.IOPS = n
+			continue
+		}
+
+		rate, err := ParseRate(part)
+		if err != nil {
+			return Limits{}, err
+		}
+		limits.BytesPerSec = rate
+	}
+
+	return limits, nil
+}
+
+// ParseWindows parses a schedule specification of semicolon-separated
+// windows, e.g. "08:00-18:00=50MB/s,200iops; 18:00-08:00=unlimited".
+func ParseWindows(spec string) ([]Window, error) {
+	var windows []Window
+
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		rangePart, limitPart, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid schedule window %q: expected HH:MM-HH:MM=limits", entry)
+		}
+
+		startStr, endStr, ok := strings.Cut(strings.TrimSpace(rangePart), "-")
+		if !ok {
+			return nil, fmt.Errorf("invalid schedule window %q: expected HH:MM-HH:MM", entry)
+		}
+
+		start, err := parseTimeOfDay(startStr)
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseTimeOfDay(endStr)
+		if err != nil {
+			return nil, err
+		}
+
+		limits, err := ParseLimits(limitPart)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule window %q: %w", entry, err)
+		}
+
+		windows = append(windows, Window{Start: start, End: end, Limits: limits})
+	}
+
+	return windows, nil
+}
+
+// parseTimeOfDay parses "HH:MM" into an offset from midnight
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q: expected HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
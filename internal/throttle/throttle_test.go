@@ -0,0 +1,269 @@
+package throttle
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"", 0},
+		{"unlimited", 0},
+		{"0", 0},
+		{"1024", 1024},
+		{"512B", 512},
+		{"1KB", 1024},
+		{"50MB/s", 50 * 1024 * 1024},
+		{"50mb/s", 50 * 1024 * 1024},
+		{"1.5GiB", 1536 * 1024 * 1024},
+		{"2T", 2 << 40},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseRate(tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("ParseRate(%q) = %d, want %d", tt.input, got, tt.expected)
+			}
+		})
+	}
+
+	t.Run("rejects invalid input", func(t *testing.T) {
+		for _, input := range []string{"fast", "-5MB", "MB"} {
+			if _, err := ParseRate(input); err == nil {
+				t.Errorf("expected error for %q", input)
+			}
+		}
+	})
+}
+
+func TestFormatRate(t *testing.T) {
+	tests := []struct {
+		rate     int64
+		expected string
+	}{
+		{0, "unlimited"},
+		{512, "512 B/s"},
+		{1024, "1.0 KB/s"},
+		{50 * 1024 * 1024, "50.0 MB/s"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatRate(tt.rate); got != tt.expected {
+			t.Errorf("FormatRate(%d) = %s, want %s", tt.rate, got, tt.expected)
+		}
+	}
+}
+
+func TestParseWindows(t *testing.T) {
+	t.Run("parses multiple windows", func(t *testing.T) {
+		windows, err := ParseWindows("08:00-18:00=50MB/s,200iops; 18:00-08:00=unlimited")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(windows) != 2 {
+			t.Fatalf("expected 2 windows, got %d", len(windows))
+		}
+
+		if windows[0].Start != 8*time.Hour || windows[0].End != 18*time.Hour {
+			t.Errorf("unexpected first window range: %v-%v", windows[0].Start, windows[0].End)
+		}
+		if windows[0].Limits.BytesPerSec != 50*1024*1024 {
+			t.Errorf("expected 50MB/s, got %d", windows[0].Limits.BytesPerSec)
+		}
+		if windows[0].Limits.IOPS != 200 {
+			t.Errorf("expected 200 IOPS, got %d", windows[0].Limits.IOPS)
+		}
+		if !windows[1].Limits.Unlimited() {
+			t.Errorf("expected second window to be unlimited, got %s", windows[1].Limits)
+		}
+	})
+
+	t.Run("empty spec yields no windows", func(t *testing.T) {
+		windows, err := ParseWindows("  ")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(windows) != 0 {
+			t.Errorf("expected no windows, got %d", len(windows))
+		}
+	})
+
+	t.Run("rejects malformed windows", func(t *testing.T) {
+		for _, spec := range []string{"08:00=50MB", "8am-6pm=50MB", "08:00-18:00", "08:00-18:00=fast"} {
+			if _, err := ParseWindows(spec); err == nil {
+				t.Errorf("expected error for %q", spec)
+			}
+		}
+	})
+}
+
+func TestSchedule_At(t *testing.T) {
+	day := Limits{BytesPerSec: 50 * 1024 * 1024}
+	night := Limits{}
+	schedule := Schedule{
+		Default: Limits{BytesPerSec: 10 * 1024 * 1024},
+		Windows: []Window{
+			{Start: 8 * time.Hour, End: 18 * time.Hour, Limits: day},
+			{Start: 22 * time.Hour, End: 6 * time.Hour, Limits: night},
+		},
+	}
+
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 1, 15, hour, minute, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		name     string
+		t        time.Time
+		expected Limits
+	}{
+		{"inside day window", at(12, 0), day},
+		{"window start is inclusive", at(8, 0), day},
+		{"window end is exclusive", at(18, 0), schedule.Default},
+		{"between windows uses default", at(20, 30), schedule.Default},
+		{"wrapping window before midnight", at(23, 0), night},
+		{"wrapping window after midnight", at(3, 0), night},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := schedule.At(tt.t); got != tt.expected {
+				t.Errorf("At(%s) = %s, want %s", tt.t.Format("15:04"), got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCombine(t *testing.T) {
+	a := Limits{BytesPerSec: 100, IOPS: 0}
+	b := Limits{BytesPerSec: 50, IOPS: 10}
+
+	got := Combine(a, b)
+	if got.BytesPerSec != 50 || got.IOPS != 10 {
+		t.Errorf("Combine = %+v, want {50 10}", got)
+	}
+
+	if !Combine(Limits{}, Limits{}).Unlimited() {
+		t.Error("combining unlimited limits should be unlimited")
+	}
+}
+
+func TestBucket_Reserve(t *testing.T) {
+	start := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	t.Run("allows an initial burst of one second", func(t *testing.T) {
+		var b bucket
+		if d := b.reserve(start, 1000, 1000); d != 0 {
+			t.Errorf("expected no delay for burst, got %v", d)
+		}
+	})
+
+	t.Run("delays once the burst is spent", func(t *testing.T) {
+		var b bucket
+		b.reserve(start, 1000, 1000)
+		d := b.reserve(start, 1000, 500)
+		if d != 500*time.Millisecond {
+			t.Errorf("expected 500ms delay, got %v", d)
+		}
+	})
+
+	t.Run("refills over time", func(t *testing.T) {
+		var b bucket
+		b.reserve(start, 1000, 1000)
+		if d := b.reserve(start.Add(time.Second), 1000, 1000); d != 0 {
+			t.Errorf("expected no delay after refill, got %v", d)
+		}
+	})
+
+	t.Run("zero rate never delays", func(t *testing.T) {
+		var b bucket
+		for i := 0; i < 10; i++ {
+			if d := b.reserve(start, 0, 1<<30); d != 0 {
+				t.Fatalf("expected no delay when unlimited, got %v", d)
+			}
+		}
+	})
+}
+
+func TestLimiter(t *testing.T) {
+	t.Run("nil limiter never blocks", func(t *testing.T) {
+		var l *Limiter
+		if err := l.WaitBytes(context.Background(), 1<<30); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if !l.Current().Unlimited() {
+			t.Error("nil limiter should report unlimited")
+		}
+	})
+
+	t.Run("follows schedule windows", func(t *testing.T) {
+		clock := time.Date(2024, 1, 15, 12, 0, 0, 0, time.Local)
+		l := NewLimiter(Schedule{
+			Windows: []Window{{Start: 8 * time.Hour, End: 18 * time.Hour, Limits: Limits{IOPS: 5}}},
+		})
+		l.now = func() time.Time { return clock }
+
+		if got := l.Current().IOPS; got != 5 {
+			t.Errorf("expected 5 IOPS during the day, got %d", got)
+		}
+
+		clock = time.Date(2024, 1, 15, 20, 0, 0, 0, time.Local)
+		if !l.Current().Unlimited() {
+			t.Errorf("expected unlimited outside window, got %s", l.Current())
+		}
+	})
+
+	t.Run("returns context error while waiting", func(t *testing.T) {
+		l := NewLimiter(Schedule{Default: Limits{BytesPerSec: 1}})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if err := l.WaitBytes(ctx, 1000); err == nil {
+			t.Error("expected context error")
+		}
+	})
+}
+
+func TestReader(t *testing.T) {
+	t.Run("passes data through unchanged", func(t *testing.T) {
+		data := bytes.Repeat([]byte("x"), 4096)
+		l := NewLimiter(Schedule{Default: Limits{BytesPerSec: 1 << 20}})
+		r := NewReader(context.Background(), io.NopCloser(bytes.NewReader(data)), l, nil)
+
+		got, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Error("throttled reader altered data")
+		}
+	})
+
+	t.Run("paces reads to the configured rate", func(t *testing.T) {
+		// 1KB/s with a 1KB burst: reading 1.5KB should take about half a second
+		data := bytes.Repeat([]byte("x"), 1536)
+		l := NewLimiter(Schedule{Default: Limits{BytesPerSec: 1024}})
+		r := NewReader(context.Background(), io.NopCloser(bytes.NewReader(data)), l)
+
+		start := time.Now()
+		if _, err := io.ReadAll(r); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		elapsed := time.Since(start)
+
+		if elapsed < 400*time.Millisecond {
+			t.Errorf("expected read to be throttled, took %v", elapsed)
+		}
+	})
+}
@@ -0,0 +1,125 @@
+// Package timestamp obtains and checks RFC 3161 trusted time-stamps of scan
+// results. When a scan completes, a Merkle tree hash (RFC 6962) of every
+// file checksum the target then has is sent to a Time Stamping Authority,
+// whose signed token proves the checksums existed no later than the time
+// it gives, independently of Fixity's own records and keys.
+package timestamp
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// maxResponseSize bounds a TSA's response; tokens are a few kilobytes
+const maxResponseSize = 1 << 20
+
+// Client requests time-stamps from a TSA over HTTP (RFC 3161 section 3.4)
+type Client struct {
+	URL        string
+	HTTPClient *http.Client
+}
+
+// NewClient creates a client for the TSA at url
+func NewClient(url string) *Client {
+	return &Client{
+		URL:        url,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Request has the TSA time-stamp a SHA-256 digest, returning its token. The
+// token is checked to be for digest and the request, but not that the TSA is
+// trusted; use Token.Verify for that.
+func (c *Client) Request(ctx context.Context, digest []byte) (*Token, error) {
+	body, nonce, err := newRequest(digest)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create TSA request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/timestamp-query")
+	req.Header.Set("Accept", "application/timestamp-reply")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach TSA: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("TSA returned HTTP %d", resp.StatusCode)
+	}
+	reply, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TSA response: %w", err)
+	}
+
+	der, err := parseResponse(reply)
+	if err != nil {
+		return nil, err
+	}
+	token, err := Parse(der)
+	if err != nil {
+		return nil, err
+	}
+	if token.Nonce == nil || token.Nonce.Cmp(nonce) != 0 {
+		return nil, fmt.Errorf("TSA response does not answer the request: nonce mismatch")
+	}
+	if !bytes.Equal(token.HashedMessage, digest) {
+		return nil, fmt.Errorf("TSA time-stamped a different digest")
+	}
+	cert, err := token.Signer()
+	if err != nil {
+		return nil, err
+	}
+	if err := token.checkSignature(cert); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// LoadRoots reads PEM certificates to trust as TSA roots. An empty path
+// returns nil, meaning the system's trusted roots.
+func LoadRoots(path string) (*x509.CertPool, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TSA certificates: %w", err)
+	}
+
+	roots := x509.NewCertPool()
+	found := false
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TSA certificate in %s: %w", path, err)
+		}
+		roots.AddCert(cert)
+		found = true
+	}
+	if !found {
+		return nil, fmt.Errorf("no certificates in %s", path)
+	}
+	return roots, nil
+}
@@ -0,0 +1,142 @@
+package timestamp_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeffanddom/fixity/internal/timestamp"
+	"github.com/jeffanddom/fixity/tests/testutil"
+)
+
+func TestRequest(t *testing.T) {
+	ctx := context.Background()
+	digest := sha256.Sum256([]byte("merkle root"))
+
+	t.Run("issues a token that verifies", func(t *testing.T) {
+		tsa := testutil.NewTSA(t)
+		token, err := timestamp.NewClient(tsa.URL).Request(ctx, digest[:])
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if time.Since(token.GenTime) > time.Minute || token.SerialNumber.Int64() != 1 {
+			t.Errorf("unexpected token: time %v, serial %v", token.GenTime, token.SerialNumber)
+		}
+		if err := token.Verify(digest[:], tsa.Roots); err != nil {
+			t.Fatalf("expected the token to verify: %v", err)
+		}
+
+		// The stored encoding verifies on its own
+		parsed, err := timestamp.Parse(token.Raw)
+		if err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		if err := parsed.Verify(digest[:], tsa.Roots); err != nil {
+			t.Fatalf("expected the parsed token to verify: %v", err)
+		}
+	})
+
+	t.Run("rejects a token for another digest", func(t *testing.T) {
+		tsa := testutil.NewTSA(t)
+		token, err := timestamp.NewClient(tsa.URL).Request(ctx, digest[:])
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		other := sha256.Sum256([]byte("other root"))
+		if err := token.Verify(other[:], tsa.Roots); err == nil {
+			t.Error("expected a token for another digest to fail")
+		}
+	})
+
+	t.Run("rejects an untrusted TSA", func(t *testing.T) {
+		tsa := testutil.NewTSA(t)
+		token, err := timestamp.NewClient(tsa.URL).Request(ctx, digest[:])
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if err := token.Verify(digest[:], testutil.NewTSA(t).Roots); err == nil || !strings.Contains(err.Error(), "not trusted") {
+			t.Errorf("expected an untrusted TSA to fail, got %v", err)
+		}
+	})
+
+	t.Run("rejects a tampered token", func(t *testing.T) {
+		tsa := testutil.NewTSA(t)
+		token, err := timestamp.NewClient(tsa.URL).Request(ctx, digest[:])
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+
+		// The serial number is the only INTEGER 1 in the TSTInfo
+		tampered := append([]byte{}, token.Raw...)
+		i := strings.Index(string(tampered), "\x02\x01\x01\x18")
+		if i < 0 {
+			t.Fatal("serial number not found in token")
+		}
+		tampered[i+2] = 2
+		parsed, err := timestamp.Parse(tampered)
+		if err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		if err := parsed.Verify(digest[:], tsa.Roots); err == nil {
+			t.Error("expected a tampered token to fail")
+		}
+	})
+
+	t.Run("reports a refusal", func(t *testing.T) {
+		tsa := testutil.NewTSA(t)
+		tsa.Reject(2)
+		_, err := timestamp.NewClient(tsa.URL).Request(ctx, digest[:])
+		if err == nil || !strings.Contains(err.Error(), "request refused") {
+			t.Errorf("expected the refusal to be reported, got %v", err)
+		}
+	})
+
+	t.Run("rejects an answer to another request", func(t *testing.T) {
+		tsa := testutil.NewTSA(t)
+		tsa.AnswerWrongNonce()
+		if _, err := timestamp.NewClient(tsa.URL).Request(ctx, digest[:]); err == nil || !strings.Contains(err.Error(), "nonce") {
+			t.Errorf("expected a nonce mismatch, got %v", err)
+		}
+	})
+
+	t.Run("reports an unreachable TSA", func(t *testing.T) {
+		tsa := testutil.NewTSA(t)
+		url := tsa.URL
+		tsa.Close()
+		if _, err := timestamp.NewClient(url).Request(ctx, digest[:]); err == nil {
+			t.Error("expected an unreachable TSA to fail")
+		}
+	})
+}
+
+// testdata/openssl.tst was issued by "openssl ts -reply" for the SHA-256 of
+// "hello", signed with an RSA key certified by testdata/openssl-ca.pem
+func TestVerifyOpenSSLToken(t *testing.T) {
+	der, err := os.ReadFile("testdata/openssl.tst")
+	if err != nil {
+		t.Fatalf("failed to read token: %v", err)
+	}
+	roots, err := timestamp.LoadRoots("testdata/openssl-ca.pem")
+	if err != nil {
+		t.Fatalf("LoadRoots failed: %v", err)
+	}
+
+	token, err := timestamp.Parse(der)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if token.GenTime.Nanosecond() == 0 {
+		t.Errorf("expected fractional seconds in %v", token.GenTime)
+	}
+
+	digest := sha256.Sum256([]byte("hello"))
+	if err := token.Verify(digest[:], roots); err != nil {
+		t.Fatalf("expected the token to verify: %v", err)
+	}
+	if err := token.Verify(digest[:], testutil.NewTSA(t).Roots); err == nil {
+		t.Error("expected the token to fail against other roots")
+	}
+}
@@ -0,0 +1,93 @@
+package timestamp
+
+import (
+	"crypto/sha256"
+	"hash"
+)
+
+// Tree computes a Merkle tree hash as defined by RFC 6962 section 2.1,
+// using SHA-256, over leaves added in order. Leaves are hashed as they are
+// added and only one subtree hash per level is kept, so any number of leaves
+// can be added in constant memory.
+type Tree struct {
+	h      hash.Hash
+	levels [][]byte // levels[i] is the hash of a full subtree of 2^i leaves, if one is pending
+	count  int64
+}
+
+// NewTree creates an empty tree
+func NewTree() *Tree {
+	return &Tree{h: sha256.New()}
+}
+
+// Add appends a leaf
+func (t *Tree) Add(data []byte) {
+	node := t.leafHash(data)
+	for i := 0; ; i++ {
+		if i == len(t.levels) {
+			t.levels = append(t.levels, node)
+			break
+		}
+		if t.levels[i] == nil {
+			t.levels[i] = node
+			break
+		}
+		node = t.nodeHash(t.levels[i], node)
+		t.levels[i] = nil
+	}
+	t.count++
+}
+
+// Count returns the number of leaves added
+func (t *Tree) Count() int64 {
+	return t.count
+}
+
+// Root returns the tree hash of the leaves added so far. The root of an
+// empty tree is the SHA-256 of no data.
+func (t *Tree) Root() []byte {
+	var root []byte
+	for _, node := range t.levels {
+		switch {
+		case node == nil:
+		case root == nil:
+			root = node
+		default:
+			// Pending subtrees are combined smallest first, each on the
+			// right of the next larger one
+			root = t.nodeHash(node, root)
+		}
+	}
+	if root == nil {
+		sum := sha256.Sum256(nil)
+		return sum[:]
+	}
+	return root
+}
+
+func (t *Tree) leafHash(data []byte) []byte {
+	t.h.Reset()
+	t.h.Write([]byte{0x00})
+	t.h.Write(data)
+	return t.h.Sum(nil)
+}
+
+func (t *Tree) nodeHash(left, right []byte) []byte {
+	t.h.Reset()
+	t.h.Write([]byte{0x01})
+	t.h.Write(left)
+	t.h.Write(right)
+	return t.h.Sum(nil)
+}
+
+// Leaf encodes a file's checksum as the leaf data of a scan's tree: its path,
+// a NUL byte, then the algorithm and digest separated by a colon
+func Leaf(path, algorithm, digest string) []byte {
+	leaf := make([]byte, 0, len(path)+len(algorithm)+len(digest)+2)
+	leaf = append(leaf, path...)
+	leaf = append(leaf, 0)
+	leaf = append(leaf, algorithm...)
+	leaf = append(leaf, ':')
+	leaf = append(leaf, digest...)
+	return leaf
+}
@@ -0,0 +1,80 @@
+package timestamp
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+)
+
+// referenceRoot is the recursive definition of RFC 6962 section 2.1
+func referenceRoot(leaves [][]byte) []byte {
+	switch len(leaves) {
+	case 0:
+		sum := sha256.Sum256(nil)
+		return sum[:]
+	case 1:
+		sum := sha256.Sum256(append([]byte{0x00}, leaves[0]...))
+		return sum[:]
+	}
+	k := 1
+	for k*2 < len(leaves) {
+		k *= 2
+	}
+	node := append([]byte{0x01}, referenceRoot(leaves[:k])...)
+	node = append(node, referenceRoot(leaves[k:])...)
+	sum := sha256.Sum256(node)
+	return sum[:]
+}
+
+func TestTreeRoot(t *testing.T) {
+	var leaves [][]byte
+	for n := 0; n <= 70; n++ {
+		tree := NewTree()
+		for _, leaf := range leaves {
+			tree.Add(leaf)
+		}
+		if tree.Count() != int64(n) {
+			t.Fatalf("expected %d leaves, got %d", n, tree.Count())
+		}
+		if got, want := tree.Root(), referenceRoot(leaves); !bytes.Equal(got, want) {
+			t.Fatalf("%d leaves: got root %x, want %x", n, got, want)
+		}
+		leaves = append(leaves, []byte(fmt.Sprintf("leaf %d", n)))
+	}
+}
+
+func TestTreeRootKnownValues(t *testing.T) {
+	// The empty tree and the tree of one empty leaf, from RFC 6962
+	tree := NewTree()
+	if got := hex.EncodeToString(tree.Root()); got != "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" {
+		t.Errorf("unexpected empty root %s", got)
+	}
+	tree.Add(nil)
+	if got := hex.EncodeToString(tree.Root()); got != "6e340b9cffb37a989ca544e6bb780a2c78901d3fb33738768511a30617afa01d" {
+		t.Errorf("unexpected root of one empty leaf %s", got)
+	}
+}
+
+func TestTreeRootIsRepeatable(t *testing.T) {
+	tree := NewTree()
+	tree.Add([]byte("a"))
+	tree.Add([]byte("b"))
+	tree.Add([]byte("c"))
+	first := tree.Root()
+	if !bytes.Equal(first, tree.Root()) {
+		t.Fatal("Root changed the tree")
+	}
+	tree.Add([]byte("d"))
+	if bytes.Equal(first, tree.Root()) {
+		t.Fatal("adding a leaf did not change the root")
+	}
+}
+
+func TestLeaf(t *testing.T) {
+	got := Leaf("docs/a.txt", "sha256", "abc")
+	if want := []byte("docs/a.txt\x00sha256:abc"); !bytes.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,428 @@
+package timestamp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+var (
+	oidSignedData    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidTSTInfo       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+	oidContentType   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+
+	oidSHA256 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA384 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSHA512 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+
+	oidRSA             = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidSHA256WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+	oidSHA384WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 12}
+	oidSHA512WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 13}
+	oidECDSA           = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidECDSAWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	oidECDSAWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 3}
+	oidECDSAWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 4}
+	oidEd25519         = asn1.ObjectIdentifier{1, 3, 101, 112}
+)
+
+// ASN.1 structures of RFC 3161 and the parts of CMS (RFC 5652) a time-stamp
+// token uses
+
+type messageImprint struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	HashedMessage []byte
+}
+
+type timeStampReq struct {
+	Version        int
+	MessageImprint messageImprint
+	ReqPolicy      asn1.ObjectIdentifier `asn1:"optional"`
+	Nonce          *big.Int              `asn1:"optional"`
+	CertReq        bool                  `asn1:"optional"`
+}
+
+type pkiStatusInfo struct {
+	Status       int
+	StatusString asn1.RawValue  `asn1:"optional"`
+	FailInfo     asn1.BitString `asn1:"optional"`
+}
+
+type timeStampResp struct {
+	Status         pkiStatusInfo
+	TimeStampToken asn1.RawValue `asn1:"optional"`
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"tag:0"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo encapsulatedContentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type encapsulatedContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     []byte `asn1:"explicit,tag:0"`
+}
+
+type signerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values []asn1.RawValue `asn1:"set"`
+}
+
+type tstInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint messageImprint
+	SerialNumber   *big.Int
+	GenTime        asn1.RawValue // GeneralizedTime, which may have fractional seconds
+	Accuracy       accuracy      `asn1:"optional"`
+	Ordering       bool          `asn1:"optional"`
+	Nonce          *big.Int      `asn1:"optional"`
+	TSA            asn1.RawValue `asn1:"optional,tag:0"`
+	Extensions     asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type accuracy struct {
+	Seconds int `asn1:"optional"`
+	Millis  int `asn1:"optional,tag:0"`
+	Micros  int `asn1:"optional,tag:1"`
+}
+
+// Token is a parsed RFC 3161 time-stamp token: a TSA's signed statement that
+// it saw a digest at a time
+type Token struct {
+	Raw           []byte      // DER encoding of the token
+	HashAlgorithm crypto.Hash // Algorithm of HashedMessage
+	HashedMessage []byte      // The digest the TSA time-stamped
+	GenTime       time.Time   // When the TSA time-stamped it
+	SerialNumber  *big.Int    // Unique among the TSA's tokens
+	Policy        asn1.ObjectIdentifier
+	Nonce         *big.Int // Echoed from the request, if it had one
+
+	Certificates []*x509.Certificate // Included by the TSA; Signer is among them
+
+	signedData signedData
+	signer     signerInfo
+	eContent   []byte
+}
+
+// newRequest encodes a time-stamp request for a SHA-256 digest, asking for
+// the TSA's certificate to be included in the token
+func newRequest(digest []byte) ([]byte, *big.Int, error) {
+	if len(digest) != crypto.SHA256.Size() {
+		return nil, nil, fmt.Errorf("digest must be SHA-256, got %d bytes", len(digest))
+	}
+	nonce, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	req, err := asn1.Marshal(timeStampReq{
+		Version: 1,
+		MessageImprint: messageImprint{
+			HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue},
+			HashedMessage: digest,
+		},
+		Nonce:   nonce,
+		CertReq: true,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode time-stamp request: %w", err)
+	}
+	return req, nonce, nil
+}
+
+// parseResponse extracts the token from a TSA's response, or returns why the
+// TSA refused to issue one
+func parseResponse(der []byte) ([]byte, error) {
+	var resp timeStampResp
+	rest, err := asn1.Unmarshal(der, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time-stamp response: %w", err)
+	}
+	if len(rest) > 0 {
+		return nil, errors.New("invalid time-stamp response: trailing data")
+	}
+
+	// granted (0) and grantedWithMods (1) carry a token
+	if resp.Status.Status > 1 {
+		msg := fmt.Sprintf("TSA rejected the request (status %d", resp.Status.Status)
+		var text []string
+		if len(resp.Status.StatusString.FullBytes) > 0 {
+			if _, err := asn1.Unmarshal(resp.Status.StatusString.FullBytes, &text); err == nil && len(text) > 0 {
+				msg += ": " + strings.Join(text, "; ")
+			}
+		}
+		return nil, errors.New(msg + ")")
+	}
+	if len(resp.TimeStampToken.FullBytes) == 0 {
+		return nil, errors.New("time-stamp response has no token")
+	}
+	return resp.TimeStampToken.FullBytes, nil
+}
+
+// Parse decodes a DER time-stamp token. It does not check the signature; use
+// Verify for that.
+func Parse(der []byte) (*Token, error) {
+	var ci contentInfo
+	rest, err := asn1.Unmarshal(der, &ci)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time-stamp token: %w", err)
+	}
+	if len(rest) > 0 {
+		return nil, errors.New("invalid time-stamp token: trailing data")
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return nil, fmt.Errorf("invalid time-stamp token: content type %v is not signed data", ci.ContentType)
+	}
+
+	token := &Token{Raw: der}
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &token.signedData); err != nil {
+		return nil, fmt.Errorf("invalid time-stamp token: %w", err)
+	}
+	sd := &token.signedData
+	if !sd.EncapContentInfo.EContentType.Equal(oidTSTInfo) {
+		return nil, fmt.Errorf("invalid time-stamp token: content type %v is not TSTInfo", sd.EncapContentInfo.EContentType)
+	}
+	if len(sd.SignerInfos) != 1 {
+		return nil, fmt.Errorf("invalid time-stamp token: %d signers, want 1", len(sd.SignerInfos))
+	}
+	token.signer = sd.SignerInfos[0]
+	token.eContent = sd.EncapContentInfo.EContent
+
+	if len(sd.Certificates.Bytes) > 0 {
+		token.Certificates, err = x509.ParseCertificates(sd.Certificates.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time-stamp token certificates: %w", err)
+		}
+	}
+
+	var info tstInfo
+	if rest, err := asn1.Unmarshal(token.eContent, &info); err != nil || len(rest) > 0 {
+		return nil, fmt.Errorf("invalid time-stamp token: malformed TSTInfo")
+	}
+	if info.Version != 1 {
+		return nil, fmt.Errorf("invalid time-stamp token: TSTInfo version %d", info.Version)
+	}
+	token.HashAlgorithm, err = hashOf(info.MessageImprint.HashAlgorithm.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if info.GenTime.Class != asn1.ClassUniversal || info.GenTime.Tag != asn1.TagGeneralizedTime {
+		return nil, errors.New("invalid time-stamp token: genTime is not a GeneralizedTime")
+	}
+	// time.Parse accepts fractional seconds the layout does not mention
+	token.GenTime, err = time.Parse("20060102150405Z0700", string(info.GenTime.Bytes))
+	if err != nil {
+		return nil, fmt.Errorf("invalid time-stamp token genTime: %w", err)
+	}
+	token.GenTime = token.GenTime.UTC()
+	token.HashedMessage = info.MessageImprint.HashedMessage
+	token.SerialNumber = info.SerialNumber
+	token.Policy = info.Policy
+	token.Nonce = info.Nonce
+
+	return token, nil
+}
+
+// Signer returns the certificate of the TSA key that signed the token, if
+// the token includes it
+func (t *Token) Signer() (*x509.Certificate, error) {
+	sid := t.signer.SID
+	for _, cert := range t.Certificates {
+		switch {
+		case sid.Class == asn1.ClassUniversal && sid.Tag == asn1.TagSequence:
+			var ias issuerAndSerialNumber
+			if _, err := asn1.Unmarshal(sid.FullBytes, &ias); err != nil {
+				return nil, fmt.Errorf("invalid time-stamp token signer: %w", err)
+			}
+			if bytes.Equal(cert.RawIssuer, ias.Issuer.FullBytes) && cert.SerialNumber.Cmp(ias.SerialNumber) == 0 {
+				return cert, nil
+			}
+		case sid.Class == asn1.ClassContextSpecific && sid.Tag == 0:
+			if len(cert.SubjectKeyId) > 0 && bytes.Equal(cert.SubjectKeyId, sid.Bytes) {
+				return cert, nil
+			}
+		}
+	}
+	return nil, errors.New("time-stamp token does not include the TSA's certificate")
+}
+
+// Verify checks that the token time-stamps digest, that the TSA's signature
+// over it is valid, and that the TSA's certificate is for time-stamping and
+// chains to roots as of the time-stamp. With nil roots the system's trusted
+// roots are used.
+func (t *Token) Verify(digest []byte, roots *x509.CertPool) error {
+	if t.HashAlgorithm != crypto.SHA256 || !bytes.Equal(t.HashedMessage, digest) {
+		return errors.New("time-stamp token is for a different digest")
+	}
+
+	cert, err := t.Signer()
+	if err != nil {
+		return err
+	}
+	if err := t.checkSignature(cert); err != nil {
+		return err
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, c := range t.Certificates {
+		if c != cert {
+			intermediates.AddCert(c)
+		}
+	}
+	_, err = cert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   t.GenTime,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	})
+	if err != nil {
+		return fmt.Errorf("TSA certificate is not trusted: %w", err)
+	}
+	return nil
+}
+
+// checkSignature checks the signer's signature over the signed attributes,
+// and that they bind the signature to the token's TSTInfo
+func (t *Token) checkSignature(cert *x509.Certificate) error {
+	si := t.signer
+	if len(si.SignedAttrs.FullBytes) == 0 {
+		return errors.New("time-stamp token has no signed attributes")
+	}
+
+	// The signature is over the attributes' DER encoding as a SET OF, not
+	// with the implicit [0] tag they are stored with
+	signed := append([]byte{}, si.SignedAttrs.FullBytes...)
+	signed[0] = 0x31
+
+	var attrs []attribute
+	if _, err := asn1.UnmarshalWithParams(signed, &attrs, "set"); err != nil {
+		return fmt.Errorf("invalid time-stamp token signed attributes: %w", err)
+	}
+
+	digestHash, err := hashOf(si.DigestAlgorithm.Algorithm)
+	if err != nil {
+		return err
+	}
+	h := digestHash.New()
+	h.Write(t.eContent)
+	contentDigest := h.Sum(nil)
+
+	var sawContentType, sawDigest bool
+	for _, attr := range attrs {
+		if len(attr.Values) != 1 {
+			continue
+		}
+		switch {
+		case attr.Type.Equal(oidContentType):
+			var contentType asn1.ObjectIdentifier
+			if _, err := asn1.Unmarshal(attr.Values[0].FullBytes, &contentType); err != nil || !contentType.Equal(oidTSTInfo) {
+				return errors.New("time-stamp token signed content type is not TSTInfo")
+			}
+			sawContentType = true
+		case attr.Type.Equal(oidMessageDigest):
+			var messageDigest []byte
+			if _, err := asn1.Unmarshal(attr.Values[0].FullBytes, &messageDigest); err != nil || !bytes.Equal(messageDigest, contentDigest) {
+				return errors.New("time-stamp token signature is not over its TSTInfo")
+			}
+			sawDigest = true
+		}
+	}
+	if !sawContentType || !sawDigest {
+		return errors.New("time-stamp token is missing required signed attributes")
+	}
+
+	algorithm, err := signatureAlgorithm(si.SignatureAlgorithm.Algorithm, digestHash)
+	if err != nil {
+		return err
+	}
+	if err := cert.CheckSignature(algorithm, signed, si.Signature); err != nil {
+		return fmt.Errorf("invalid time-stamp token signature: %w", err)
+	}
+	return nil
+}
+
+func hashOf(oid asn1.ObjectIdentifier) (crypto.Hash, error) {
+	switch {
+	case oid.Equal(oidSHA256):
+		return crypto.SHA256, nil
+	case oid.Equal(oidSHA384):
+		return crypto.SHA384, nil
+	case oid.Equal(oidSHA512):
+		return crypto.SHA512, nil
+	}
+	return 0, fmt.Errorf("unsupported time-stamp hash algorithm %v", oid)
+}
+
+// signatureAlgorithm maps a CMS signature algorithm, which may name only the
+// key type, and the signer's digest algorithm to an x509 one
+func signatureAlgorithm(oid asn1.ObjectIdentifier, digest crypto.Hash) (x509.SignatureAlgorithm, error) {
+	switch {
+	case oid.Equal(oidRSA):
+		switch digest {
+		case crypto.SHA256:
+			return x509.SHA256WithRSA, nil
+		case crypto.SHA384:
+			return x509.SHA384WithRSA, nil
+		case crypto.SHA512:
+			return x509.SHA512WithRSA, nil
+		}
+	case oid.Equal(oidECDSA):
+		switch digest {
+		case crypto.SHA256:
+			return x509.ECDSAWithSHA256, nil
+		case crypto.SHA384:
+			return x509.ECDSAWithSHA384, nil
+		case crypto.SHA512:
+			return x509.ECDSAWithSHA512, nil
+		}
+	case oid.Equal(oidSHA256WithRSA):
+		return x509.SHA256WithRSA, nil
+	case oid.Equal(oidSHA384WithRSA):
+		return x509.SHA384WithRSA, nil
+	case oid.Equal(oidSHA512WithRSA):
+		return x509.SHA512WithRSA, nil
+	case oid.Equal(oidECDSAWithSHA256):
+		return x509.ECDSAWithSHA256, nil
+	case oid.Equal(oidECDSAWithSHA384):
+		return x509.ECDSAWithSHA384, nil
+	case oid.Equal(oidECDSAWithSHA512):
+		return x509.ECDSAWithSHA512, nil
+	case oid.Equal(oidEd25519):
+		return x509.PureEd25519, nil
+	}
+	return 0, fmt.Errorf("unsupported time-stamp signature algorithm %v", oid)
+}
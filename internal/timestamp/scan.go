@@ -0,0 +1,112 @@
+package timestamp
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/jeffanddom/fixity/internal/database"
+)
+
+// MerkleRoot computes the tree hash over a target's recorded checksums: one
+// leaf (see Leaf) per present, hashed file, in byte order of path
+func MerkleRoot(ctx context.Context, db *database.Database, targetID int64) ([]byte, int64, error) {
+	tree := NewTree()
+	err := db.Files.ForEachChecksum(ctx, targetID, func(path, algorithm, digest string) {
+		tree.Add(Leaf(path, algorithm, digest))
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return tree.Root(), tree.Count(), nil
+}
+
+// StampScan computes the Merkle root of a target's checksums at the end of
+// a completed scan and has the TSA time-stamp it. It must run before the
+// target is scanned again. The root is stored even if the TSA fails, with
+// the error, so it can be time-stamped later by Stamp.
+func StampScan(ctx context.Context, db *database.Database, client *Client, scan *database.Scan) (*database.ScanTimestamp, error) {
+	if scan.Status != database.ScanStatusCompleted {
+		return nil, fmt.Errorf("scan %d has not completed", scan.ID)
+	}
+
+	root, count, err := MerkleRoot(ctx, db, scan.StorageTargetID)
+	if err != nil {
+		return nil, err
+	}
+
+	ts := &database.ScanTimestamp{
+		ScanID:     scan.ID,
+		MerkleRoot: hex.EncodeToString(root),
+		LeafCount:  count,
+	}
+	return ts, Stamp(ctx, db, client, ts)
+}
+
+// Stamp has the TSA time-stamp a stored Merkle root, such as one the TSA
+// could not be reached for when its scan completed, and stores the token
+func Stamp(ctx context.Context, db *database.Database, client *Client, ts *database.ScanTimestamp) error {
+	root, err := hex.DecodeString(ts.MerkleRoot)
+	if err != nil {
+		return fmt.Errorf("invalid Merkle root: %w", err)
+	}
+
+	ts.TSAURL = client.URL
+	token, requestErr := client.Request(ctx, root)
+	if requestErr != nil {
+		msg := requestErr.Error()
+		ts.Token, ts.GenTime, ts.SerialNumber, ts.Error = nil, nil, nil, &msg
+	} else {
+		serial := token.SerialNumber.String()
+		ts.Token, ts.GenTime, ts.SerialNumber, ts.Error = token.Raw, &token.GenTime, &serial, nil
+	}
+
+	if err := db.ScanTimestamps.Save(ctx, ts); err != nil {
+		return err
+	}
+	if requestErr != nil {
+		return requestErr
+	}
+	return nil
+}
+
+// VerifyScan checks a scan's stored time-stamp token: that it is for the
+// stored Merkle root, matches the stored time and serial number, and is
+// signed by a TSA that chains to roots (the system's if nil)
+func VerifyScan(ctx context.Context, db *database.Database, scanID int64, roots *x509.CertPool) (*database.ScanTimestamp, *Token, error) {
+	ts, err := db.ScanTimestamps.GetByScanID(ctx, scanID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if ts == nil {
+		return nil, nil, fmt.Errorf("scan %d was not time-stamped", scanID)
+	}
+	if ts.Token == nil {
+		reason := "no token"
+		if ts.Error != nil {
+			reason = *ts.Error
+		}
+		return ts, nil, fmt.Errorf("scan %d has not been time-stamped yet: %s", scanID, reason)
+	}
+
+	root, err := hex.DecodeString(ts.MerkleRoot)
+	if err != nil {
+		return ts, nil, fmt.Errorf("invalid Merkle root: %w", err)
+	}
+	token, err := Parse(ts.Token)
+	if err != nil {
+		return ts, nil, err
+	}
+	if err := token.Verify(root, roots); err != nil {
+		return ts, token, err
+	}
+	// The database keeps times to the microsecond
+	if ts.GenTime == nil || ts.GenTime.Sub(token.GenTime).Abs() >= time.Microsecond ||
+		ts.SerialNumber == nil || *ts.SerialNumber != token.SerialNumber.String() {
+		return ts, token, fmt.Errorf("stored time or serial number differs from the token")
+	}
+
+	return ts, token, nil
+}
@@ -0,0 +1,144 @@
+package timestamp_test
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/jeffanddom/fixity/internal/database"
+	"github.com/jeffanddom/fixity/internal/timestamp"
+	"github.com/jeffanddom/fixity/tests/testutil"
+)
+
+func TestStampAndVerifyScan(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupDB(t, db)
+
+	ctx := context.Background()
+	target := testutil.MustCreateStorageTarget(t, db, "archive")
+	testutil.MustCreateFile(t, db, target.ID, "b.txt")
+	testutil.MustCreateFile(t, db, target.ID, "B.txt")
+	testutil.MustCreateFile(t, db, target.ID, "a/c.txt")
+	unhashed := testutil.MustCreateFile(t, db, target.ID, "unhashed.txt")
+	unhashed.CurrentChecksum, unhashed.ChecksumType, unhashed.LastChecksummedAt = nil, nil, nil
+	if err := db.Files.Update(ctx, unhashed); err != nil {
+		t.Fatalf("failed to update file: %v", err)
+	}
+
+	completeScan := func(t *testing.T) *database.Scan {
+		t.Helper()
+		scan := testutil.MustCreateScan(t, db, target.ID)
+		completed := time.Now()
+		scan.Status, scan.CompletedAt = database.ScanStatusCompleted, &completed
+		if err := db.Scans.Update(ctx, scan); err != nil {
+			t.Fatalf("failed to complete scan: %v", err)
+		}
+		return scan
+	}
+
+	t.Run("computes the root over hashed files in byte order", func(t *testing.T) {
+		tree := timestamp.NewTree()
+		for _, path := range []string{"B.txt", "a/c.txt", "b.txt"} {
+			tree.Add(timestamp.Leaf(path, "md5", "abc123"))
+		}
+
+		root, count, err := timestamp.MerkleRoot(ctx, db, target.ID)
+		if err != nil {
+			t.Fatalf("MerkleRoot failed: %v", err)
+		}
+		if count != 3 || hex.EncodeToString(root) != hex.EncodeToString(tree.Root()) {
+			t.Errorf("got root %x of %d leaves, want %x of 3", root, count, tree.Root())
+		}
+	})
+
+	t.Run("time-stamps a completed scan", func(t *testing.T) {
+		tsa := testutil.NewTSA(t)
+		scan := completeScan(t)
+
+		ts, err := timestamp.StampScan(ctx, db, timestamp.NewClient(tsa.URL), scan)
+		if err != nil {
+			t.Fatalf("StampScan failed: %v", err)
+		}
+		if ts.Token == nil || ts.GenTime == nil || ts.LeafCount != 3 || ts.TSAURL != tsa.URL || ts.Error != nil {
+			t.Errorf("unexpected timestamp: %+v", ts)
+		}
+
+		stored, token, err := timestamp.VerifyScan(ctx, db, scan.ID, tsa.Roots)
+		if err != nil {
+			t.Fatalf("expected the timestamp to verify: %v", err)
+		}
+		if stored.MerkleRoot != ts.MerkleRoot || token.SerialNumber.String() != *ts.SerialNumber {
+			t.Errorf("unexpected verified timestamp: %+v", stored)
+		}
+
+		if _, _, err := timestamp.VerifyScan(ctx, db, scan.ID, testutil.NewTSA(t).Roots); err == nil {
+			t.Error("expected verification against other roots to fail")
+		}
+	})
+
+	t.Run("detects an altered root", func(t *testing.T) {
+		tsa := testutil.NewTSA(t)
+		scan := completeScan(t)
+		ts, err := timestamp.StampScan(ctx, db, timestamp.NewClient(tsa.URL), scan)
+		if err != nil {
+			t.Fatalf("StampScan failed: %v", err)
+		}
+
+		ts.MerkleRoot = "00" + ts.MerkleRoot[2:]
+		if _, err := db.DB().ExecContext(ctx, `UPDATE scan_timestamps SET merkle_root = $1 WHERE scan_id = $2`, ts.MerkleRoot, scan.ID); err != nil {
+			t.Fatalf("failed to alter root: %v", err)
+		}
+		if _, _, err := timestamp.VerifyScan(ctx, db, scan.ID, tsa.Roots); err == nil {
+			t.Error("expected an altered root to fail")
+		}
+	})
+
+	t.Run("keeps the root when the TSA fails and retries it", func(t *testing.T) {
+		tsa := testutil.NewTSA(t)
+		tsa.Reject(2)
+		scan := completeScan(t)
+		client := timestamp.NewClient(tsa.URL)
+
+		ts, err := timestamp.StampScan(ctx, db, client, scan)
+		if err == nil {
+			t.Fatal("expected a refused request to fail")
+		}
+		if _, _, err := timestamp.VerifyScan(ctx, db, scan.ID, tsa.Roots); err == nil {
+			t.Error("expected a scan without a token not to verify")
+		}
+
+		pending, err := db.ScanTimestamps.ListPending(ctx, 10)
+		if err != nil {
+			t.Fatalf("ListPending failed: %v", err)
+		}
+		if len(pending) != 1 || pending[0].ScanID != scan.ID || pending[0].MerkleRoot != ts.MerkleRoot || pending[0].Error == nil {
+			t.Fatalf("unexpected pending timestamps: %+v", pending)
+		}
+
+		// Files changed since the scan do not change what is retried
+		testutil.MustCreateFile(t, db, target.ID, "later.txt")
+		tsa.Reject(0)
+		if err := timestamp.Stamp(ctx, db, client, pending[0]); err != nil {
+			t.Fatalf("Stamp failed: %v", err)
+		}
+		stored, _, err := timestamp.VerifyScan(ctx, db, scan.ID, tsa.Roots)
+		if err != nil {
+			t.Fatalf("expected the retried timestamp to verify: %v", err)
+		}
+		if stored.MerkleRoot != ts.MerkleRoot || stored.LeafCount != 3 || stored.Error != nil {
+			t.Errorf("unexpected retried timestamp: %+v", stored)
+		}
+	})
+
+	t.Run("rejects a scan that has not completed", func(t *testing.T) {
+		scan := testutil.MustCreateScan(t, db, target.ID)
+		if _, err := timestamp.StampScan(ctx, db, timestamp.NewClient(testutil.NewTSA(t).URL), scan); err == nil {
+			t.Error("expected a running scan to be rejected")
+		}
+		if _, _, err := timestamp.VerifyScan(ctx, db, scan.ID, nil); err == nil {
+			t.Error("expected a scan without a timestamp not to verify")
+		}
+	})
+}
@@ -0,0 +1,5 @@
+-- Per-target throughput throttling
+ALTER TABLE storage_targets
+    ADD COLUMN throttle_bytes_per_sec  BIGINT CHECK (throttle_bytes_per_sec IS NULL OR throttle_bytes_per_sec > 0),
+    ADD COLUMN throttle_iops           INT CHECK (throttle_iops IS NULL OR throttle_iops > 0),
+    ADD COLUMN throttle_schedule       TEXT;
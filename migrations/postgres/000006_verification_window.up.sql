@@ -0,0 +1,6 @@
+-- Verification policy: every file must be re-verified within this many days
+ALTER TABLE storage_targets
+    ADD COLUMN verification_window_days INT CHECK (verification_window_days IS NULL OR verification_window_days > 0);
+
+-- Supports finding the files closest to their verification deadline
+CREATE INDEX idx_files_verification_due ON files(storage_target_id, last_checksummed_at NULLS FIRST) WHERE deleted_at IS NULL;
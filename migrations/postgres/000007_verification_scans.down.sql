@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_scans_type;
+
+ALTER TABLE scans
+    DROP COLUMN IF EXISTS budget_exhausted,
+    DROP COLUMN IF EXISTS files_mismatched,
+    DROP COLUMN IF EXISTS scan_type;
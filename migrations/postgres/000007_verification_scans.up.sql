@@ -0,0 +1,7 @@
+-- Verification-only scans re-hash known files without walking the tree
+ALTER TABLE scans
+    ADD COLUMN scan_type        TEXT NOT NULL DEFAULT 'discovery' CHECK (scan_type IN ('discovery', 'verification')),
+    ADD COLUMN files_mismatched INT NOT NULL DEFAULT 0 CHECK (files_mismatched >= 0),
+    ADD COLUMN budget_exhausted BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX idx_scans_type ON scans(scan_type);
@@ -0,0 +1,5 @@
+-- Per-target include/exclude path rules and .fixityignore support
+ALTER TABLE storage_targets
+    ADD COLUMN include_patterns   TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN exclude_patterns   TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN honor_ignore_files BOOLEAN NOT NULL DEFAULT TRUE;
@@ -0,0 +1,3 @@
+-- Directories listed concurrently when walking a target; NULL walks sequentially
+ALTER TABLE storage_targets
+    ADD COLUMN walk_concurrency INT CHECK (walk_concurrency BETWEEN 1 AND 64);
@@ -0,0 +1,7 @@
+-- Local targets can watch for filesystem events and scan changed paths as they happen
+ALTER TABLE storage_targets
+    ADD COLUMN watch_changes BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE scans
+    DROP CONSTRAINT scans_scan_type_check,
+    ADD CONSTRAINT scans_scan_type_check CHECK (scan_type IN ('discovery', 'verification', 'incremental'));
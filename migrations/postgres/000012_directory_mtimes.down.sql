@@ -0,0 +1,8 @@
+ALTER TABLE scans
+    DROP COLUMN IF EXISTS dirs_skipped;
+
+ALTER TABLE storage_targets
+    DROP COLUMN IF EXISTS full_walk_days,
+    DROP COLUMN IF EXISTS skip_unchanged_dirs;
+
+DROP TABLE IF EXISTS directories;
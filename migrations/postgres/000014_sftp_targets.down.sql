@@ -0,0 +1,6 @@
+DELETE FROM storage_targets WHERE type = 'sftp';
+
+ALTER TABLE storage_targets
+    DROP COLUMN IF EXISTS host_key,
+    DROP CONSTRAINT storage_targets_type_check,
+    ADD CONSTRAINT storage_targets_type_check CHECK (type IN ('local', 'nfs', 'smb'));
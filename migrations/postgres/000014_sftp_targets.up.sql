@@ -0,0 +1,6 @@
+-- Targets can be served over SFTP; the server's key is pinned per target and
+-- credentials_ref names where the key or password is kept
+ALTER TABLE storage_targets
+    DROP CONSTRAINT storage_targets_type_check,
+    ADD CONSTRAINT storage_targets_type_check CHECK (type IN ('local', 'nfs', 'smb', 'sftp')),
+    ADD COLUMN host_key TEXT;
@@ -0,0 +1,8 @@
+ALTER TABLE files
+    DROP COLUMN IF EXISTS server_checksum;
+
+DELETE FROM storage_targets WHERE type = 'webdav';
+
+ALTER TABLE storage_targets
+    DROP CONSTRAINT storage_targets_type_check,
+    ADD CONSTRAINT storage_targets_type_check CHECK (type IN ('local', 'nfs', 'smb', 'sftp'));
@@ -0,0 +1,9 @@
+-- Targets can be served over WebDAV
+ALTER TABLE storage_targets
+    DROP CONSTRAINT storage_targets_type_check,
+    ADD CONSTRAINT storage_targets_type_check CHECK (type IN ('local', 'nfs', 'smb', 'sftp', 'webdav'));
+
+-- Checksum the server keeps for the file (e.g. Nextcloud's oc:checksums), as
+-- "algorithm:hex"; a cheap way to notice content changes between hashes
+ALTER TABLE files
+    ADD COLUMN server_checksum TEXT;
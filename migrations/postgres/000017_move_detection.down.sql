@@ -0,0 +1,10 @@
+ALTER TABLE scans
+    DROP COLUMN IF EXISTS files_moved;
+
+DELETE FROM change_events WHERE event_type = 'moved';
+
+ALTER TABLE change_events
+    DROP COLUMN IF EXISTS new_path,
+    DROP COLUMN IF EXISTS old_path,
+    DROP CONSTRAINT change_events_event_type_check,
+    ADD CONSTRAINT change_events_event_type_check CHECK (event_type IN ('added', 'deleted', 'modified', 'verified', 'metadata_changed', 'member_added', 'member_deleted', 'member_modified'));
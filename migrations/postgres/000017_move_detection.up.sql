@@ -0,0 +1,10 @@
+-- Files whose content reappears at another path are recorded as moved,
+-- keeping their history, instead of deleted and added
+ALTER TABLE change_events
+    DROP CONSTRAINT change_events_event_type_check,
+    ADD CONSTRAINT change_events_event_type_check CHECK (event_type IN ('added', 'deleted', 'modified', 'verified', 'metadata_changed', 'member_added', 'member_deleted', 'member_modified', 'moved')),
+    ADD COLUMN old_path TEXT,
+    ADD COLUMN new_path TEXT;
+
+ALTER TABLE scans
+    ADD COLUMN files_moved INT NOT NULL DEFAULT 0 CHECK (files_moved >= 0);
@@ -0,0 +1,45 @@
+-- Replica groups link storage targets that should hold identical content
+CREATE TABLE replica_groups (
+    id                  BIGSERIAL PRIMARY KEY,
+    name                TEXT NOT NULL UNIQUE,
+    description         TEXT,
+    created_at          TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE replica_group_targets (
+    group_id            BIGINT NOT NULL REFERENCES replica_groups(id) ON DELETE CASCADE,
+    storage_target_id   BIGINT NOT NULL REFERENCES storage_targets(id) ON DELETE CASCADE,
+    PRIMARY KEY (group_id, storage_target_id)
+);
+
+CREATE INDEX idx_replica_group_targets_target ON replica_group_targets(storage_target_id);
+
+-- Each comparison joins the group's files by relative path and checksum
+CREATE TABLE replica_comparisons (
+    id                  BIGSERIAL PRIMARY KEY,
+    group_id            BIGINT NOT NULL REFERENCES replica_groups(id) ON DELETE CASCADE,
+    status              TEXT NOT NULL CHECK (status IN ('running', 'completed', 'failed')),
+    started_at          TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    completed_at        TIMESTAMP WITH TIME ZONE,
+    files_compared      BIGINT NOT NULL DEFAULT 0,
+    files_missing       BIGINT NOT NULL DEFAULT 0,
+    files_divergent     BIGINT NOT NULL DEFAULT 0,
+    error_message       TEXT
+);
+
+CREATE INDEX idx_replica_comparisons_group ON replica_comparisons(group_id, started_at DESC);
+
+-- One row per copy that is missing or disagrees with the other copies
+CREATE TABLE replica_discrepancies (
+    id                  BIGSERIAL PRIMARY KEY,
+    comparison_id       BIGINT NOT NULL REFERENCES replica_comparisons(id) ON DELETE CASCADE,
+    path                TEXT NOT NULL,
+    storage_target_id   BIGINT NOT NULL REFERENCES storage_targets(id) ON DELETE CASCADE,
+    problem             TEXT NOT NULL CHECK (problem IN ('missing', 'divergent')),
+    checksum            TEXT,
+    checksum_type       TEXT,
+    likely_checksum     TEXT
+);
+
+CREATE INDEX idx_replica_discrepancies_comparison ON replica_discrepancies(comparison_id, path);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS bag_problems;
+DROP TABLE IF EXISTS bag_validations;
+
+ALTER TABLE storage_targets
+    DROP COLUMN IF EXISTS validate_bags;
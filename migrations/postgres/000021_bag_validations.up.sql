@@ -0,0 +1,33 @@
+-- Validating every BagIt bag reads its manifests, and payload hashed with
+-- algorithms the target does not use, so targets opt in
+ALTER TABLE storage_targets
+    ADD COLUMN validate_bags BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Each scan that validates bags records one result per bag found
+CREATE TABLE bag_validations (
+    id                  BIGSERIAL PRIMARY KEY,
+    scan_id             BIGINT NOT NULL REFERENCES scans(id) ON DELETE CASCADE,
+    storage_target_id   BIGINT NOT NULL REFERENCES storage_targets(id) ON DELETE CASCADE,
+    path                TEXT NOT NULL,
+    valid               BOOLEAN NOT NULL,
+    bagit_version       TEXT,
+    payload_files       INT NOT NULL DEFAULT 0 CHECK (payload_files >= 0),
+    problem_count       INT NOT NULL DEFAULT 0 CHECK (problem_count >= 0),
+    validated_at        TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_bag_validations_target_path ON bag_validations(storage_target_id, path, validated_at DESC);
+CREATE INDEX idx_bag_validations_scan ON bag_validations(scan_id);
+
+CREATE TABLE bag_problems (
+    id                  BIGSERIAL PRIMARY KEY,
+    validation_id       BIGINT NOT NULL REFERENCES bag_validations(id) ON DELETE CASCADE,
+    path                TEXT NOT NULL,
+    kind                TEXT NOT NULL CHECK (kind IN ('missing', 'extra', 'mismatch', 'invalid')),
+    algorithm           TEXT,
+    expected            TEXT,
+    actual              TEXT,
+    message             TEXT NOT NULL
+);
+
+CREATE INDEX idx_bag_problems_validation ON bag_problems(validation_id);
@@ -0,0 +1,25 @@
+-- Public halves of the keys Fixity has signed with, so signatures made with
+-- a key since replaced can still be checked
+CREATE TABLE signing_keys (
+    key_id              TEXT PRIMARY KEY,
+    public_key          TEXT NOT NULL,
+    created_at          TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Signed fixity audit reports, kept as generated so the signature stays valid
+CREATE TABLE audit_reports (
+    id                  BIGSERIAL PRIMARY KEY,
+    storage_target_id   BIGINT NOT NULL REFERENCES storage_targets(id) ON DELETE CASCADE,
+    period_start        TIMESTAMP WITH TIME ZONE NOT NULL,
+    period_end          TIMESTAMP WITH TIME ZONE NOT NULL,
+    format              TEXT NOT NULL CHECK (format IN ('html', 'csv', 'json')),
+    content             BYTEA NOT NULL,
+    sha256              TEXT NOT NULL,
+    signature           TEXT NOT NULL,
+    key_id              TEXT NOT NULL REFERENCES signing_keys(key_id),
+    generated_by        TEXT NOT NULL,
+    generated_at        TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK (period_end > period_start)
+);
+
+CREATE INDEX idx_audit_reports_target ON audit_reports(storage_target_id, generated_at DESC);
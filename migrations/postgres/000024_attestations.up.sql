@@ -0,0 +1,18 @@
+-- Signed statements that files had given digests, issued for third parties
+-- and kept as issued so the signature stays valid
+CREATE TABLE attestations (
+    id                  BIGSERIAL PRIMARY KEY,
+    storage_target_id   BIGINT NOT NULL REFERENCES storage_targets(id) ON DELETE CASCADE,
+    scope               TEXT NOT NULL CHECK (scope IN ('file', 'subtree', 'scan')),
+    path                TEXT,
+    scan_id             BIGINT REFERENCES scans(id) ON DELETE SET NULL,
+    file_count          BIGINT NOT NULL CHECK (file_count >= 0),
+    content             BYTEA NOT NULL,
+    sha256              TEXT NOT NULL,
+    signature           TEXT NOT NULL,
+    key_id              TEXT NOT NULL REFERENCES signing_keys(key_id),
+    issued_by           TEXT NOT NULL,
+    issued_at           TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_attestations_target ON attestations(storage_target_id, issued_at DESC);
@@ -0,0 +1,18 @@
+-- RFC 3161 time-stamps of the Merkle root of a target's checksums at the end
+-- of each completed scan. The root is kept even if the TSA could not be
+-- reached, so it can be time-stamped later.
+CREATE TABLE scan_timestamps (
+    scan_id             BIGINT PRIMARY KEY REFERENCES scans(id) ON DELETE CASCADE,
+    merkle_root         TEXT NOT NULL,
+    leaf_count          BIGINT NOT NULL CHECK (leaf_count >= 0),
+    tsa_url             TEXT NOT NULL,
+    token               BYTEA,
+    gen_time            TIMESTAMP WITH TIME ZONE,
+    serial_number       TEXT,
+    error               TEXT,
+    created_at          TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK ((token IS NULL) = (gen_time IS NULL) AND (token IS NULL) = (serial_number IS NULL))
+);
+
+CREATE INDEX idx_scan_timestamps_pending ON scan_timestamps(scan_id) WHERE token IS NULL;
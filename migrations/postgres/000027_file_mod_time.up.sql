@@ -0,0 +1,5 @@
+-- The modification time reported for each file when it was last seen, which
+-- scans compare against to find modified files; NULL until the next scan
+-- records it
+ALTER TABLE files
+    ADD COLUMN mod_time TIMESTAMP WITH TIME ZONE;
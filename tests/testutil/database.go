@@ -69,10 +69,26 @@ func CleanupDB(t *testing.T, db *database.Database) {
 	tables := []string{
 		"webhook_deliveries",
 		"webhooks",
+		"replica_discrepancies",
+		"replica_comparisons",
+		"replica_group_targets",
+		"replica_groups",
+		"scan_timestamps",
+		"attestations",
+		"audit_checkpoints",
+		"event_chain_heads",
+		"audit_reports",
+		"signing_keys",
+		"bag_problems",
+		"bag_validations",
 		"change_events",
+		"archive_members",
+		"scan_errors",
 		"scan_checkpoints",
 		"scans",
 		"files",
+		"expected_checksums",
+		"directories",
 		"storage_targets",
 		"sessions",
 		"users",
@@ -0,0 +1,130 @@
+package testutil
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// SFTPServer is an in-process SSH server offering the sftp subsystem over
+// the local filesystem
+type SFTPServer struct {
+	Addr    string
+	HostKey ssh.PublicKey
+
+	accepted atomic.Int32 // SSH connections accepted
+	open     atomic.Int32 // SSH connections not yet closed by the client
+}
+
+// NewSFTPServer serves SFTP on a loopback port, closed when the test ends.
+// It accepts password or, if clientKey is set, that public key.
+func NewSFTPServer(t *testing.T, password string, clientKey ssh.PublicKey) *SFTPServer {
+	t.Helper()
+
+	_, hostPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate host key: %v", err)
+	}
+	hostSigner, err := ssh.NewSignerFromKey(hostPriv)
+	if err != nil {
+		t.Fatalf("failed to create host signer: %v", err)
+	}
+
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if password != "" && string(pass) == password {
+				return nil, nil
+			}
+			return nil, errors.New("wrong password")
+		},
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if clientKey != nil && string(key.Marshal()) == string(clientKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, errors.New("unknown key")
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	server := &SFTPServer{Addr: listener.Addr().String(), HostKey: hostSigner.PublicKey()}
+	go func() {
+		for {
+			netConn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(netConn, config)
+		}
+	}()
+
+	return server
+}
+
+func (s *SFTPServer) serve(netConn net.Conn, config *ssh.ServerConfig) {
+	defer netConn.Close()
+
+	conn, chans, reqs, err := ssh.NewServerConn(netConn, config)
+	if err != nil {
+		return
+	}
+	s.accepted.Add(1)
+	s.open.Add(1)
+	go ssh.DiscardRequests(reqs)
+	go func() {
+		conn.Wait()
+		s.open.Add(-1)
+	}()
+
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "session only")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			for req := range requests {
+				ok := req.Type == "subsystem" && len(req.Payload) > 4 && string(req.Payload[4:]) == "sftp"
+				req.Reply(ok, nil)
+				if !ok {
+					continue
+				}
+				server, err := sftp.NewServer(channel)
+				if err == nil {
+					server.Serve()
+				}
+				channel.Close()
+			}
+		}()
+	}
+}
+
+// Pin returns the server's host key as an authorized_keys line
+func (s *SFTPServer) Pin() string {
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(s.HostKey)))
+}
+
+// Accepted returns the number of SSH connections the server has accepted
+func (s *SFTPServer) Accepted() int {
+	return int(s.accepted.Load())
+}
+
+// Open returns the number of SSH connections clients have not yet closed
+func (s *SFTPServer) Open() int {
+	return int(s.open.Load())
+}
@@ -0,0 +1,289 @@
+package testutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+var (
+	oidSignedData      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidTSTInfo         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+	oidContentType     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidMessageDigest   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidSHA256          = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidECDSAWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	oidTestPolicy      = asn1.ObjectIdentifier{1, 2, 3, 4, 1}
+	oidSigningCertV2   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 47}
+	oidExtKeyUsage     = asn1.ObjectIdentifier{2, 5, 29, 37}
+	oidTimeStamping    = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 8}
+
+	timeStampingUsage, _ = asn1.Marshal([]asn1.ObjectIdentifier{oidTimeStamping})
+)
+
+// TSA is a local RFC 3161 Time Stamping Authority for tests. It signs
+// tokens with an ECDSA key whose certificate chains to Roots.
+type TSA struct {
+	*httptest.Server
+	Roots *x509.CertPool // Trusts the TSA's certificate
+
+	mu       sync.Mutex
+	status   int  // PKIStatus to answer with; 0 grants
+	badNonce bool // Answer with a nonce other than the request's
+	serial   int64
+	key      *ecdsa.PrivateKey
+	cert     *x509.Certificate
+}
+
+// NewTSA starts a local TSA, closed when the test ends
+func NewTSA(t *testing.T) *TSA {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate CA key: %v", err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test TSA Root"},
+		NotBefore:             time.Now().Add(-Hour),
+		NotAfter:              time.Now().Add(24 * Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("failed to create CA certificate: %v", err)
+	}
+	ca, _ := x509.ParseCertificate(caDER)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate TSA key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "Test TSA"},
+		NotBefore:    time.Now().Add(-Hour),
+		NotAfter:     time.Now().Add(24 * Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		// RFC 3161 requires the time-stamping key usage to be critical
+		ExtraExtensions: []pkix.Extension{{Id: oidExtKeyUsage, Critical: true, Value: timeStampingUsage}},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("failed to create TSA certificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(certDER)
+
+	tsa := &TSA{Roots: x509.NewCertPool(), key: key, cert: cert}
+	tsa.Roots.AddCert(ca)
+	tsa.Server = httptest.NewServer(http.HandlerFunc(tsa.handle))
+	t.Cleanup(tsa.Close)
+	return tsa
+}
+
+// Reject makes the TSA refuse requests with a PKIStatus, such as 2
+// (rejection); 0 grants them again
+func (tsa *TSA) Reject(status int) {
+	tsa.mu.Lock()
+	defer tsa.mu.Unlock()
+	tsa.status = status
+}
+
+// AnswerWrongNonce makes the TSA answer with a nonce other than the request's
+func (tsa *TSA) AnswerWrongNonce() {
+	tsa.mu.Lock()
+	defer tsa.mu.Unlock()
+	tsa.badNonce = true
+}
+
+type tsaImprint struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	HashedMessage []byte
+}
+
+type tsaRequest struct {
+	Version        int
+	MessageImprint tsaImprint
+	ReqPolicy      asn1.ObjectIdentifier `asn1:"optional"`
+	Nonce          *big.Int              `asn1:"optional"`
+	CertReq        bool                  `asn1:"optional"`
+}
+
+type tsaStatus struct {
+	Status       int
+	StatusString []string `asn1:"optional"`
+}
+
+type tsaResponse struct {
+	Status tsaStatus
+	Token  asn1.RawValue `asn1:"optional"`
+}
+
+type tsaTSTInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint tsaImprint
+	SerialNumber   *big.Int
+	GenTime        time.Time `asn1:"generalized"`
+	Nonce          *big.Int  `asn1:"optional"`
+}
+
+type tsaAttribute struct {
+	Type   asn1.ObjectIdentifier
+	Values []asn1.RawValue `asn1:"set"`
+}
+
+type tsaCertID struct {
+	CertHash []byte
+}
+
+type tsaSigningCertificate struct {
+	Certs []tsaCertID
+}
+
+type tsaIssuerAndSerial struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type tsaSignerInfo struct {
+	Version            int
+	SID                tsaIssuerAndSerial
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+}
+
+type tsaEncapContent struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     asn1.RawValue
+}
+
+type tsaSignedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo tsaEncapContent
+	Certificates     asn1.RawValue
+	SignerInfos      []tsaSignerInfo `asn1:"set"`
+}
+
+type tsaContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue
+}
+
+// explicit wraps DER in a context-specific [0] constructed tag
+func explicit(der []byte) asn1.RawValue {
+	return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: der}
+}
+
+func (tsa *TSA) handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/timestamp-query" {
+		http.Error(w, "expected a time-stamp query", http.StatusBadRequest)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	var req tsaRequest
+	if _, err := asn1.Unmarshal(body, &req); err != nil {
+		http.Error(w, "malformed time-stamp query", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := tsa.respond(&req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/timestamp-reply")
+	w.Write(resp)
+}
+
+func (tsa *TSA) respond(req *tsaRequest) ([]byte, error) {
+	tsa.mu.Lock()
+	defer tsa.mu.Unlock()
+
+	if tsa.status != 0 {
+		return asn1.Marshal(tsaResponse{Status: tsaStatus{Status: tsa.status, StatusString: []string{"request refused"}}})
+	}
+
+	tsa.serial++
+	nonce := req.Nonce
+	if tsa.badNonce && nonce != nil {
+		nonce = new(big.Int).Add(nonce, big.NewInt(1))
+	}
+	info, err := asn1.Marshal(tsaTSTInfo{
+		Version:        1,
+		Policy:         oidTestPolicy,
+		MessageImprint: req.MessageImprint,
+		SerialNumber:   big.NewInt(tsa.serial),
+		GenTime:        time.Now().UTC().Truncate(time.Second),
+		Nonce:          nonce,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	contentType, _ := asn1.Marshal(oidTSTInfo)
+	infoDigest := sha256.Sum256(info)
+	messageDigest, _ := asn1.Marshal(infoDigest[:])
+	// SigningCertificateV2 (RFC 5816) names the TSA's certificate by its
+	// SHA-256, as real TSAs do
+	certHash := sha256.Sum256(tsa.cert.Raw)
+	signingCert, _ := asn1.Marshal(tsaSigningCertificate{Certs: []tsaCertID{{CertHash: certHash[:]}}})
+	signedAttrs, err := asn1.MarshalWithParams([]tsaAttribute{
+		{Type: oidContentType, Values: []asn1.RawValue{{FullBytes: contentType}}},
+		{Type: oidMessageDigest, Values: []asn1.RawValue{{FullBytes: messageDigest}}},
+		{Type: oidSigningCertV2, Values: []asn1.RawValue{{FullBytes: signingCert}}},
+	}, "set")
+	if err != nil {
+		return nil, err
+	}
+	attrsDigest := sha256.Sum256(signedAttrs)
+	signature, err := ecdsa.SignASN1(rand.Reader, tsa.key, attrsDigest[:])
+	if err != nil {
+		return nil, err
+	}
+
+	// Signed attributes are signed as a SET OF but stored as [0] IMPLICIT
+	storedAttrs := append([]byte{0xa0}, signedAttrs[1:]...)
+	infoOctets, _ := asn1.Marshal(info)
+	sha256Algorithm := pkix.AlgorithmIdentifier{Algorithm: oidSHA256}
+	signed, err := asn1.Marshal(tsaSignedData{
+		Version:          3,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{sha256Algorithm},
+		EncapContentInfo: tsaEncapContent{EContentType: oidTSTInfo, EContent: explicit(infoOctets)},
+		Certificates:     explicit(tsa.cert.Raw),
+		SignerInfos: []tsaSignerInfo{{
+			Version:            1,
+			SID:                tsaIssuerAndSerial{Issuer: asn1.RawValue{FullBytes: tsa.cert.RawIssuer}, SerialNumber: tsa.cert.SerialNumber},
+			DigestAlgorithm:    sha256Algorithm,
+			SignedAttrs:        asn1.RawValue{FullBytes: storedAttrs},
+			SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidECDSAWithSHA256},
+			Signature:          signature,
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := asn1.Marshal(tsaContentInfo{ContentType: oidSignedData, Content: explicit(signed)})
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(tsaResponse{Status: tsaStatus{Status: 0}, Token: asn1.RawValue{FullBytes: token}})
+}